@@ -47,12 +47,18 @@ const (
 	AllIntentsRemovedAnnotation                          = "intents.otterize.com/all-intents-removed"
 	OtterizeCreatedForServiceAnnotation                  = "intents.otterize.com/created-for-service"
 	OtterizeCreatedForIngressAnnotation                  = "intents.otterize.com/created-for-ingress"
+	OtterizeCreatedForGatewayRouteAnnotation             = "intents.otterize.com/created-for-gateway-route"
+	OtterizeManualOverrideAnnotation                     = "intents.otterize.com/manual-override"
 	OtterizeNetworkPolicyNameTemplate                    = "access-to-%s-from-%s"
 	OtterizeServiceNetworkPolicyNameTemplate             = "svc-access-to-%s-from-%s"
 	OtterizeNetworkPolicy                                = "intents.otterize.com/network-policy"
 	OtterizeSvcNetworkPolicy                             = "intents.otterize.com/svc-network-policy"
 	OtterizeNetworkPolicyServiceDefaultDeny              = "intents.otterize.com/network-policy-service-default-deny"
 	OtterizeNetworkPolicyExternalTraffic                 = "intents.otterize.com/network-policy-external-traffic"
+	OtterizeNamespaceDefaultDenyAnnotation               = "intents.otterize.com/default-deny"
+	OtterizeNetworkPolicyNamespaceDefaultDeny            = "intents.otterize.com/network-policy-namespace-default-deny"
+	OtterizeNamespaceDefaultDenyNetworkPolicyName        = "otterize-namespace-default-deny"
+	OtterizeAlwaysAllowedRulesAnnotation                 = "intents.otterize.com/always-allowed-rules"
 	ClientIntentsFinalizerName                           = "intents.otterize.com/client-intents-finalizer"
 	ProtectedServicesFinalizerName                       = "intents.otterize.com/protected-services-finalizer"
 	OtterizeIstioClientAnnotationKey                     = "intents.otterize.com/istio-client"
@@ -60,6 +66,16 @@ const (
 	OtterizeSharedServiceAccountAnnotation               = "intents.otterize.com/shared-service-account"
 	OtterizeMissingSidecarAnnotation                     = "intents.otterize.com/service-missing-sidecar"
 	OtterizeServersWithoutSidecarAnnotation              = "intents.otterize.com/servers-without-sidecar"
+	OtterizeClientsWithoutSidecarAnnotation              = "intents.otterize.com/clients-without-sidecar"
+	OtterizeAmbientServersAnnotation                     = "intents.otterize.com/ambient-servers"
+	OtterizeIstioPeerAuthenticationNameTemplate          = "strict-mtls-for-%s"
+	OtterizeIstioPeerAuthenticationServerLabelKey        = "intents.otterize.com/peer-authentication-server"
+	OtterizeLinkerdClientLabelKey                        = "intents.otterize.com/linkerd-client"
+	OtterizeLinkerdServerLabelKey                        = "intents.otterize.com/linkerd-server"
+	OtterizeLinkerdClientServiceAccountAnnotation        = "intents.otterize.com/linkerd-client-service-account"
+	OtterizeLinkerdMissingProxyAnnotation                = "intents.otterize.com/linkerd-service-missing-proxy"
+	OtterizeLinkerdServersWithoutProxyAnnotation         = "intents.otterize.com/linkerd-servers-without-proxy"
+	OtterizeKafkaServerLabelKey                          = "intents.otterize.com/kafka-server"
 	OtterizeTargetServerIndexField                       = "spec.service.calls.server"
 	OtterizeKafkaServerConfigServiceNameField            = "spec.service.name"
 	OtterizeProtectedServiceNameIndexField               = "spec.name"
@@ -67,6 +83,8 @@ const (
 	EndpointsPodNamesIndexField                          = "endpointsPodNames"
 	IngressServiceNamesIndexField                        = "ingressServiceNames"
 	NetworkPoliciesByIngressNameIndexField               = "networkPoliciesByIngressName"
+	GatewayRouteServiceNamesIndexField                   = "gatewayRouteServiceNames"
+	NetworkPoliciesByGatewayRouteIndexField              = "networkPoliciesByGatewayRoute"
 	MaxOtterizeNameLength                                = 20
 	MaxNamespaceLength                                   = 20
 	OtterizeSvcEgressNetworkPolicyNameTemplate           = "svc-egress-to-%s-from-%s"
@@ -77,16 +95,25 @@ const (
 	OtterizeEgressNetworkPolicyNameTemplate              = "egress-to-%s-from-%s"
 	OtterizeEgressNetworkPolicy                          = "intents.otterize.com/egress-network-policy"
 	OtterizeEgressNetworkPolicyTarget                    = "intents.otterize.com/egress-network-policy-target"
+	OtterizeKubernetesRBACNameTemplate                   = "otterize-intents-%s"
+	OtterizeKubernetesRBACClientLabelKey                 = "intents.otterize.com/kubernetes-rbac-client"
+	OtterizeKubernetesAPIEgressNetworkPolicyNameTemplate = "egress-to-kubernetes-api-from-%s"
+	OtterizeKubernetesAPIEgressNetworkPolicy             = "intents.otterize.com/kubernetes-api-egress-network-policy"
+	OtterizeIstioSidecarNameTemplate                     = "egress-from-%s"
+	OtterizeIstioServiceEntryNameTemplate                = "internet-%s-from-%s"
+	OtterizeIstioEgressClientLabelKey                    = "intents.otterize.com/istio-egress-client"
 )
 
-// +kubebuilder:validation:Enum=http;kafka;database;aws
+// +kubebuilder:validation:Enum=http;kafka;database;aws;kubernetes;internet
 type IntentType string
 
 const (
-	IntentTypeHTTP     IntentType = "http"
-	IntentTypeKafka    IntentType = "kafka"
-	IntentTypeDatabase IntentType = "database"
-	IntentTypeAWS      IntentType = "aws"
+	IntentTypeHTTP       IntentType = "http"
+	IntentTypeKafka      IntentType = "kafka"
+	IntentTypeDatabase   IntentType = "database"
+	IntentTypeAWS        IntentType = "aws"
+	IntentTypeKubernetes IntentType = "kubernetes"
+	IntentTypeInternet   IntentType = "internet"
 )
 
 // +kubebuilder:validation:Enum=all;consume;produce;create;alter;delete;describe;ClusterAction;DescribeConfigs;AlterConfigs;IdempotentWrite
@@ -135,12 +162,29 @@ const (
 type IntentsSpec struct {
 	Service Service  `json:"service" yaml:"service"`
 	Calls   []Intent `json:"calls" yaml:"calls"`
+
+	//+optional
+	RemoteClients []RemoteClient `json:"remoteClients,omitempty" yaml:"remoteClients,omitempty"`
 }
 
 type Service struct {
 	Name string `json:"name" yaml:"name"`
 }
 
+// RemoteClient is an instance of the client running in another cluster or trust domain, such as in a federated mesh.
+// Istio policies created for the intents also accept its identity.
+type RemoteClient struct {
+	TrustDomain string `json:"trustDomain" yaml:"trustDomain"`
+
+	// Namespace defaults to the namespace of the ClientIntents.
+	//+optional
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+
+	// ServiceAccountName defaults to the service account of the client's pods in this cluster.
+	//+optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty" yaml:"serviceAccountName,omitempty"`
+}
+
 type Intent struct {
 	Name string `json:"name" yaml:"name"`
 
@@ -150,6 +194,19 @@ type Intent struct {
 	//+optional
 	Topics []KafkaTopic `json:"kafkaTopics,omitempty" yaml:"kafkaTopics,omitempty"`
 
+	//+optional
+	ConsumerGroups []KafkaConsumerGroup `json:"consumerGroups,omitempty" yaml:"consumerGroups,omitempty"`
+
+	//+optional
+	TransactionalIDs []KafkaTransactionalID `json:"transactionalIds,omitempty" yaml:"transactionalIds,omitempty"`
+
+	// ClusterOperations are operations on the Kafka cluster itself, such as those performed by admin tools.
+	//+optional
+	ClusterOperations []KafkaOperation `json:"clusterOperations,omitempty" yaml:"clusterOperations,omitempty"`
+
+	//+optional
+	Quotas *KafkaQuotas `json:"quotas,omitempty" yaml:"quotas,omitempty"`
+
 	//+optional
 	HTTPResources []HTTPResource `json:"HTTPResources,omitempty" yaml:"HTTPResources,omitempty"`
 
@@ -158,6 +215,35 @@ type Intent struct {
 
 	//+optional
 	AWSActions []string `json:"awsActions,omitempty" yaml:"awsActions,omitempty"`
+
+	//+optional
+	KubernetesResources []KubernetesResource `json:"kubernetesResources,omitempty" yaml:"kubernetesResources,omitempty"`
+
+	//+optional
+	Internet *Internet `json:"internet,omitempty" yaml:"internet,omitempty"`
+}
+
+// Internet describes access to hosts outside the cluster. The intent's name only identifies the target, and is not
+// resolved as a server.
+type Internet struct {
+	Domains []string `json:"domains" yaml:"domains"`
+	// Ports defaults to 443.
+	//+optional
+	Ports []int32 `json:"ports,omitempty" yaml:"ports,omitempty"`
+}
+
+// KubernetesResource describes access to the Kubernetes API, in the terms of an RBAC policy rule. The resources are in
+// the namespace of the intent's target, e.g. "kubernetes.monitoring" for the monitoring namespace, or in the client's
+// namespace when no namespace is given.
+type KubernetesResource struct {
+	APIGroups []string `json:"apiGroups" yaml:"apiGroups"`
+	Resources []string `json:"resources" yaml:"resources"`
+	Verbs     []string `json:"verbs" yaml:"verbs"`
+	//+optional
+	ResourceNames []string `json:"resourceNames,omitempty" yaml:"resourceNames,omitempty"`
+	// ClusterWide grants access in all namespaces, which is required for cluster-scoped resources such as nodes.
+	//+optional
+	ClusterWide bool `json:"clusterWide,omitempty" yaml:"clusterWide,omitempty"`
 }
 
 type DatabaseResource struct {
@@ -175,10 +261,52 @@ type KafkaTopic struct {
 	Operations []KafkaOperation `json:"operations" yaml:"operations"`
 }
 
+// KafkaConsumerGroup grants the client the read and describe access to consumer groups that consuming with them
+// requires, in addition to its access to topics.
+type KafkaConsumerGroup struct {
+	Name string `json:"name" yaml:"name"`
+	// Pattern of the name, literal by default or prefix.
+	//+optional
+	Pattern ResourcePatternType `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+}
+
+// KafkaTransactionalID grants the client access to transactional IDs, which exactly-once producers require produce
+// and describe access to.
+type KafkaTransactionalID struct {
+	Name string `json:"name" yaml:"name"`
+	// Pattern of the name, literal by default or prefix.
+	//+optional
+	Pattern    ResourcePatternType `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	Operations []KafkaOperation    `json:"operations" yaml:"operations"`
+}
+
+// KafkaQuotas limits the byte rates of the client on each broker of a Kafka server. Quotas apply to the client's
+// principal, which must be a user.
+type KafkaQuotas struct {
+	//+optional
+	//+kubebuilder:validation:Minimum=1
+	ProducerByteRate int64 `json:"producerByteRate,omitempty" yaml:"producerByteRate,omitempty"`
+	//+optional
+	//+kubebuilder:validation:Minimum=1
+	ConsumerByteRate int64 `json:"consumerByteRate,omitempty" yaml:"consumerByteRate,omitempty"`
+}
+
+// KafkaServerQuotas are the quotas applied to the client on a Kafka server.
+type KafkaServerQuotas struct {
+	// Server is the Kafka server, as <name>.<namespace>.
+	Server      string `json:"server" yaml:"server"`
+	KafkaQuotas `json:",inline" yaml:",inline"`
+}
+
 // IntentsStatus defines the observed state of ClientIntents
 type IntentsStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// DatabaseServers are the database servers the client's database intents were applied on, as <name>.<namespace>.
+	// The client's intents are removed from them once it no longer calls them.
+	//+optional
+	DatabaseServers []string `json:"databaseServers,omitempty" yaml:"databaseServers,omitempty"`
+	// KafkaQuotas are the quotas applied to the client, as reported by the Kafka servers.
+	//+optional
+	KafkaQuotas []KafkaServerQuotas `json:"kafkaQuotas,omitempty" yaml:"kafkaQuotas,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -225,7 +353,7 @@ func (in *ClientIntents) GetIntentsLabelMapping(requestNamespace string) map[str
 	otterizeAccessLabels := make(map[string]string)
 
 	for _, intent := range in.GetCallsList() {
-		if intent.Type == IntentTypeAWS || intent.Type == IntentTypeDatabase {
+		if intent.Type == IntentTypeAWS || intent.Type == IntentTypeDatabase || intent.Type == IntentTypeKubernetes || intent.Type == IntentTypeInternet {
 			continue
 		}
 		ns := intent.GetTargetServerNamespace(requestNamespace)
@@ -312,11 +440,21 @@ func (in *Intent) typeAsGQLType() graphqlclient.IntentType {
 }
 
 func (in *ClientIntents) GetServersWithoutSidecar() (sets.Set[string], error) {
+	return in.getServersSetAnnotation(OtterizeServersWithoutSidecarAnnotation)
+}
+
+// GetServersWithoutLinkerdProxy returns the servers the Linkerd policy manager found to have no Linkerd proxy, which are
+// tracked separately from the Istio sidecar annotations since both meshes may be enforced.
+func (in *ClientIntents) GetServersWithoutLinkerdProxy() (sets.Set[string], error) {
+	return in.getServersSetAnnotation(OtterizeLinkerdServersWithoutProxyAnnotation)
+}
+
+func (in *ClientIntents) getServersSetAnnotation(annotation string) (sets.Set[string], error) {
 	if in.Annotations == nil {
 		return sets.New[string](), nil
 	}
 
-	servers, ok := in.Annotations[OtterizeServersWithoutSidecarAnnotation]
+	servers, ok := in.Annotations[annotation]
 	if !ok {
 		return sets.New[string](), nil
 	}
@@ -339,10 +477,53 @@ func (in *ClientIntents) IsServerMissingSidecar(intent Intent) (bool, error) {
 	return serversSet.Has(serverIdentity), nil
 }
 
+// AmbientServer is a called server enrolled in Istio ambient mode, where it has no sidecar. L7 policies for it are
+// enforced by its waypoint, and can only target the services using the waypoint.
+type AmbientServer struct {
+	Waypoint string   `json:"waypoint,omitempty"`
+	Services []string `json:"services,omitempty"`
+}
+
+// GetAmbientServers returns the called servers enrolled in Istio ambient mode, by their formatted identity.
+func (in *ClientIntents) GetAmbientServers() (map[string]AmbientServer, error) {
+	servers, ok := in.Annotations[OtterizeAmbientServersAnnotation]
+	if !ok {
+		return make(map[string]AmbientServer), nil
+	}
+
+	ambientServers := make(map[string]AmbientServer)
+	err := json.Unmarshal([]byte(servers), &ambientServers)
+	if err != nil {
+		return nil, err
+	}
+
+	return ambientServers, nil
+}
+
+// IsServerMissingWaypoint returns whether the intent is an HTTP intent for a server in Istio ambient mode that has no
+// waypoint, so its HTTP rules cannot be enforced.
+func (in *ClientIntents) IsServerMissingWaypoint(intent Intent) (bool, error) {
+	if intent.Type != IntentTypeHTTP {
+		return false, nil
+	}
+
+	ambientServers, err := in.GetAmbientServers()
+	if err != nil {
+		return false, err
+	}
+	serverIdentity := GetFormattedOtterizeIdentity(intent.GetTargetServerName(), intent.GetTargetServerNamespace(in.Namespace))
+	ambientServer, ok := ambientServers[serverIdentity]
+	return ok && ambientServer.Waypoint == "", nil
+}
+
 func (in *ClientIntentsList) FormatAsOtterizeIntents() ([]*graphqlclient.IntentInput, error) {
 	otterizeIntents := make([]*graphqlclient.IntentInput, 0)
 	for _, clientIntents := range in.Items {
 		for _, intent := range clientIntents.GetCallsList() {
+			if intent.Type == IntentTypeKubernetes || intent.Type == IntentTypeInternet {
+				// Kubernetes API and internet intents are not reported to Otterize Cloud.
+				continue
+			}
 			input := intent.ConvertToCloudFormat(clientIntents.Namespace, clientIntents.GetServiceName())
 			statusInput, err := clientIntentsStatusToCloudFormat(clientIntents, intent)
 			if err != nil {
@@ -394,7 +575,13 @@ func clientIntentsStatusToCloudFormat(clientIntents ClientIntents, intent Intent
 	if err != nil {
 		return nil, err
 	}
-	status.IstioStatus.IsServerMissingSidecar = lo.ToPtr(isServerMissingSidecar)
+	isServerMissingWaypoint, err := clientIntents.IsServerMissingWaypoint(intent)
+	if err != nil {
+		return nil, err
+	}
+	// The status has no field for policies that are not enforced for other reasons, and a server missing its waypoint
+	// is reported the same as one missing its sidecar.
+	status.IstioStatus.IsServerMissingSidecar = lo.ToPtr(isServerMissingSidecar || isServerMissingWaypoint)
 	return &status, nil
 }
 
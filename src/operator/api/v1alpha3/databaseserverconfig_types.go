@@ -0,0 +1,77 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	DatabaseCredentialsUsernameKey = "username"
+	DatabaseCredentialsPasswordKey = "password"
+)
+
+// DatabaseServerConfigSpec defines the desired state of DatabaseServerConfig
+type DatabaseServerConfigSpec struct {
+	// Address of the PostgreSQL server, of the form host:port.
+	// +kubebuilder:validation:Required
+	Address string `json:"address" yaml:"address"`
+	// Database whose tables are granted to clients by database intents.
+	// +kubebuilder:validation:Required
+	Database string `json:"database" yaml:"database"`
+	// Name of a Secret in the DatabaseServerConfig's namespace holding the username and password of an admin user, which
+	// can create roles and grant privileges on the database's tables.
+	// +kubebuilder:validation:Required
+	CredentialsSecretName string `json:"credentialsSecretName" yaml:"credentialsSecretName"`
+	// SSL mode of the connection to the server. Defaults to prefer.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=disable;allow;prefer;require;verify-ca;verify-full
+	SSLMode string `json:"sslMode,omitempty" yaml:"sslMode,omitempty"`
+}
+
+// DatabaseServerConfigStatus defines the observed state of DatabaseServerConfig
+type DatabaseServerConfigStatus struct {
+	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+
+// DatabaseServerConfig is the Schema for the databaseserverconfig API. Database intents whose name is that of a
+// DatabaseServerConfig are applied on its server, as a role per client granted the intents' table operations.
+type DatabaseServerConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DatabaseServerConfigSpec   `json:"spec,omitempty"`
+	Status DatabaseServerConfigStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// DatabaseServerConfigList contains a list of DatabaseServerConfig
+type DatabaseServerConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DatabaseServerConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DatabaseServerConfig{}, &DatabaseServerConfigList{})
+}
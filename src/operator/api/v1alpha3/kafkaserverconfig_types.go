@@ -46,6 +46,97 @@ type TopicConfig struct {
 	IntentsRequired        bool                `json:"intentsRequired" yaml:"intentsRequired"`
 }
 
+// +kubebuilder:validation:Enum=PLAIN;SCRAM-SHA-256;SCRAM-SHA-512;OAUTHBEARER
+type SASLMechanism string
+
+const (
+	SASLMechanismPlain       SASLMechanism = "PLAIN"
+	SASLMechanismSCRAMSHA256 SASLMechanism = "SCRAM-SHA-256"
+	SASLMechanismSCRAMSHA512 SASLMechanism = "SCRAM-SHA-512"
+	SASLMechanismOAuthBearer SASLMechanism = "OAUTHBEARER"
+)
+
+const (
+	KafkaCredentialsUsernameKey     = "username"
+	KafkaCredentialsPasswordKey     = "password"
+	KafkaCredentialsClientIDKey     = "clientID"
+	KafkaCredentialsClientSecretKey = "clientSecret"
+	KafkaCredentialsTokenURLKey     = "tokenURL"
+	KafkaCredentialsScopesKey       = "scopes"
+	// KafkaCredentialsSecretLabelKey must be set to "true" on secrets referenced as Kafka credentials.
+	KafkaCredentialsSecretLabelKey = "intents.otterize.com/kafka-credentials"
+)
+
+// KafkaAuth configures SASL authentication of the operator to the Kafka server, instead of mTLS.
+type KafkaAuth struct {
+	// +kubebuilder:validation:Required
+	SASLMechanism SASLMechanism `json:"saslMechanism" yaml:"saslMechanism"`
+	// Name of a Secret in the KafkaServerConfig's namespace holding the operator's credentials: username and password
+	// for PLAIN and SCRAM, or clientID, clientSecret, tokenURL and optionally space separated scopes for OAUTHBEARER.
+	// The Secret must be labelled intents.otterize.com/kafka-credentials=true.
+	// +kubebuilder:validation:Required
+	CredentialsSecretName string `json:"credentialsSecretName" yaml:"credentialsSecretName"`
+	// Connect without TLS. Otherwise, the KafkaServerConfig's TLS configuration is used if set, or the system's root CAs.
+	// Not allowed with PLAIN, which would send the password in the clear.
+	// +kubebuilder:validation:Optional
+	Plaintext bool `json:"plaintext,omitempty" yaml:"plaintext,omitempty"`
+}
+
+// StrimziConfig configures the operator to manage ACLs through the KafkaUser resources of a Strimzi-managed Kafka
+// cluster, instead of through the Kafka admin API which the Strimzi User Operator would overwrite.
+type StrimziConfig struct {
+	// Name of the Strimzi Kafka cluster, set as the strimzi.io/cluster label of KafkaUsers.
+	// +kubebuilder:validation:Required
+	ClusterName string `json:"clusterName" yaml:"clusterName"`
+	// Namespace watched by the Strimzi User Operator. Defaults to the KafkaServerConfig's namespace.
+	// +kubebuilder:validation:Optional
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+}
+
+// KafkaManagedTopic is a topic the operator creates on the Kafka server.
+type KafkaManagedTopic struct {
+	// +kubebuilder:validation:Required
+	Name string `json:"name" yaml:"name"`
+	// Partitions are added to existing topics with fewer partitions, but never removed.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	Partitions int32 `json:"partitions" yaml:"partitions"`
+	// Only applies when the topic is created.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	ReplicationFactor int16 `json:"replicationFactor" yaml:"replicationFactor"`
+	// Topic config overrides, such as retention.ms.
+	// +kubebuilder:validation:Optional
+	Config map[string]string `json:"config,omitempty" yaml:"config,omitempty"`
+}
+
+// KafkaTopicManagement configures the operator to manage topics on the Kafka server, and to report topics referenced by
+// intents that don't exist on it.
+type KafkaTopicManagement struct {
+	// Topics to create, or update, on the server.
+	// +kubebuilder:validation:Optional
+	Topics []KafkaManagedTopic `json:"topics,omitempty" yaml:"topics,omitempty"`
+	// Delete topics created by the operator once they are removed from topics. Topics still referenced by intents, or
+	// not created by the operator, are never deleted.
+	// +kubebuilder:validation:Optional
+	DeleteRemovedTopics bool `json:"deleteRemovedTopics,omitempty" yaml:"deleteRemovedTopics,omitempty"`
+}
+
+// KafkaListenerPorts are the ports of the Kafka server's listeners, so that network policies only allow access to them,
+// rather than to all of the server's ports, such as those of JMX or inter-broker listeners.
+type KafkaListenerPorts struct {
+	// Ports of the listeners clients connect to, allowed by network policies generated for kafka intents.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	Client []int32 `json:"client" yaml:"client"`
+	// Port of the listener the operator connects to, allowed by network policies generated for the intents the operator
+	// creates to this server. Defaults to the port of addr.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Admin int32 `json:"admin,omitempty" yaml:"admin,omitempty"`
+}
+
 // KafkaServerConfigSpec defines the desired state of KafkaServerConfig
 type KafkaServerConfigSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
@@ -57,14 +148,98 @@ type KafkaServerConfigSpec struct {
 	NoAutoCreateIntentsForOperator bool   `json:"noAutoCreateIntentsForOperator,omitempty" yaml:"noAutoCreateIntentsForOperator,omitempty"`
 	Addr                           string `json:"addr,omitempty" yaml:"addr,omitempty"`
 	// +kubebuilder:validation:Optional
-	TLS    TLSSource     `json:"tls,omitempty" yaml:"tls,omitempty"`
-	Topics []TopicConfig `json:"topics,omitempty" yaml:"topics,omitempty"`
+	TLS TLSSource `json:"tls,omitempty" yaml:"tls,omitempty"`
+	// +kubebuilder:validation:Optional
+	Auth *KafkaAuth `json:"auth,omitempty" yaml:"auth,omitempty"`
+	// Template of the principals of clients in ACLs, of the form <PrincipalType>:<name>. $ServiceName, $Namespace,
+	// $ServiceAccount and $Cluster are replaced by the client's name, namespace, service account and the operator's
+	// cluster name. Defaults to the subject of the operator's certificate with the client's name and namespace as CN,
+	// or to User:$ServiceName.$Namespace with SASL auth.
+	// +kubebuilder:validation:Optional
+	PrincipalTemplate string `json:"principalTemplate,omitempty" yaml:"principalTemplate,omitempty"`
+	// Cluster operations that intents to this server may grant clients, such as IdempotentWrite. Intents declaring
+	// other cluster operations are not applied.
+	// +kubebuilder:validation:Optional
+	AllowedClusterOperations []KafkaOperation `json:"allowedClusterOperations,omitempty" yaml:"allowedClusterOperations,omitempty"`
+	// Allow intents to this server to grant clients access to transactional IDs. Otherwise, intents declaring
+	// transactional IDs are not applied.
+	// +kubebuilder:validation:Optional
+	AllowTransactionalIDs bool `json:"allowTransactionalIds,omitempty" yaml:"allowTransactionalIds,omitempty"`
+	// Only allow clients to read and describe the consumer groups declared in their intents, by removing the ACLs that
+	// allow all users to use all consumer groups. Otherwise, those ACLs are created. Enable once intents to this server
+	// declare the consumer groups their clients use.
+	// +kubebuilder:validation:Optional
+	RestrictConsumerGroups bool `json:"restrictConsumerGroups,omitempty" yaml:"restrictConsumerGroups,omitempty"`
+	// Manage ACLs and quotas through the KafkaUser resources of a Strimzi cluster, rather than connecting to Kafka.
+	// Principals must be of the form User:<name> or User:CN=<name>, where <name> is the KafkaUser's name.
+	// +kubebuilder:validation:Optional
+	Strimzi *StrimziConfig `json:"strimzi,omitempty" yaml:"strimzi,omitempty"`
+	// Manage topics on the Kafka server. Not supported with Strimzi, whose topics are managed with KafkaTopic resources.
+	// +kubebuilder:validation:Optional
+	TopicManagement *KafkaTopicManagement `json:"topicManagement,omitempty" yaml:"topicManagement,omitempty"`
+	// Listener ports of the server. When not set, network policies generated for kafka intents allow all of its ports.
+	// +kubebuilder:validation:Optional
+	ListenerPorts *KafkaListenerPorts `json:"listenerPorts,omitempty" yaml:"listenerPorts,omitempty"`
+	Topics        []TopicConfig       `json:"topics,omitempty" yaml:"topics,omitempty"`
+}
+
+// KafkaACL is an ACL on a Kafka server.
+type KafkaACL struct {
+	Principal    string `json:"principal" yaml:"principal"`
+	ResourceType string `json:"resourceType" yaml:"resourceType"`
+	ResourceName string `json:"resourceName" yaml:"resourceName"`
+	PatternType  string `json:"patternType" yaml:"patternType"`
+	Operation    string `json:"operation" yaml:"operation"`
+	Permission   string `json:"permission" yaml:"permission"`
+	Host         string `json:"host" yaml:"host"`
+}
+
+// KafkaACLDrift lists the differences between the ACLs on a Kafka server and those expected from the intents to it and
+// its topic configuration.
+type KafkaACLDrift struct {
+	// ACLs that are expected but not found on the server.
+	Missing []KafkaACL `json:"missing,omitempty" yaml:"missing,omitempty"`
+	// ACLs of principals managed by the operator that are found on the server but not expected.
+	Unexpected []KafkaACL `json:"unexpected,omitempty" yaml:"unexpected,omitempty"`
+}
+
+// MaxKafkaACLDriftStatusACLs is the number of missing and unexpected ACLs each listed in KafkaServerConfig status.
+const MaxKafkaACLDriftStatusACLs = 20
+
+// KafkaACLDriftStatus summarizes the ACL drift found on a Kafka server.
+type KafkaACLDriftStatus struct {
+	MissingCount    int `json:"missingCount" yaml:"missingCount"`
+	UnexpectedCount int `json:"unexpectedCount" yaml:"unexpectedCount"`
+	// Up to MaxKafkaACLDriftStatusACLs of the missing and unexpected ACLs each.
+	KafkaACLDrift `json:",inline" yaml:",inline"`
+}
+
+// KafkaTopicsStatus reports the topics on a Kafka server with topic management.
+type KafkaTopicsStatus struct {
+	// Topics created by the operator that still exist on the server.
+	Created []string `json:"created,omitempty" yaml:"created,omitempty"`
+	// Topics referenced by intents to the server that don't exist on it.
+	Missing []string `json:"missing,omitempty" yaml:"missing,omitempty"`
 }
 
 // KafkaServerConfigStatus defines the observed state of KafkaServerConfig
 type KafkaServerConfigStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+
+	// ACL drift found by the last periodic resync in report-only mode. Unset if no drift was found.
+	// +optional
+	ACLDrift *KafkaACLDriftStatus `json:"aclDrift,omitempty" yaml:"aclDrift,omitempty"`
+	// Clients the last periodic resync skipped, as no pods were found for resolving their service account.
+	// +optional
+	ACLResyncSkippedClients []string `json:"aclResyncSkippedClients,omitempty" yaml:"aclResyncSkippedClients,omitempty"`
+	// Topics created by the operator and topics missing on the server. Unset without topic management.
+	// +optional
+	Topics *KafkaTopicsStatus `json:"topics,omitempty" yaml:"topics,omitempty"`
+	// Principals the operator created ACLs for. Garbage collection only deletes ACLs of these principals, once no
+	// intents to the server use them.
+	// +optional
+	ManagedPrincipals []string `json:"managedPrincipals,omitempty" yaml:"managedPrincipals,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -1,7 +1,11 @@
 package v1alpha3
 
 import (
+	"fmt"
+	"golang.org/x/exp/slices"
 	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"strings"
 )
 
@@ -40,6 +44,44 @@ func HasOtterizeServerLabel(pod *v1.Pod, labelValue string) bool {
 	return exists && value == labelValue
 }
 
+// IsManuallyOverridden checks if an Otterize-generated policy was marked as deliberately modified by a user, in which
+// case the operator should not revert changes made to it.
+func IsManuallyOverridden(obj metav1.Object) bool {
+	return obj.GetAnnotations()[OtterizeManualOverrideAnnotation] == "true"
+}
+
+// GetNamespaceDefaultDenyPolicyTypes returns the traffic directions a namespace's default-deny annotation asks to
+// block. The annotation holds a comma separated list of "ingress" and "egress"; "true" is shorthand for "ingress".
+// Returns nil if the namespace is not annotated.
+func GetNamespaceDefaultDenyPolicyTypes(namespace metav1.Object) ([]networkingv1.PolicyType, error) {
+	value, ok := namespace.GetAnnotations()[OtterizeNamespaceDefaultDenyAnnotation]
+	if !ok || value == "" || value == "false" {
+		return nil, nil
+	}
+
+	if value == "true" {
+		return []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}, nil
+	}
+
+	var policyTypes []networkingv1.PolicyType
+	for _, direction := range strings.Split(value, ",") {
+		var policyType networkingv1.PolicyType
+		switch strings.ToLower(strings.TrimSpace(direction)) {
+		case "ingress":
+			policyType = networkingv1.PolicyTypeIngress
+		case "egress":
+			policyType = networkingv1.PolicyTypeEgress
+		default:
+			return nil, fmt.Errorf("invalid value %q for annotation %s, expected a comma separated list of ingress and egress", value, OtterizeNamespaceDefaultDenyAnnotation)
+		}
+		if !slices.Contains(policyTypes, policyType) {
+			policyTypes = append(policyTypes, policyType)
+		}
+	}
+
+	return policyTypes, nil
+}
+
 func cleanupOtterizeLabelsAndAnnotations(pod *v1.Pod) *v1.Pod {
 	for k := range pod.Labels {
 		if isOtterizeAccessLabel(k) {
@@ -25,6 +25,26 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AmbientServer) DeepCopyInto(out *AmbientServer) {
+	*out = *in
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AmbientServer.
+func (in *AmbientServer) DeepCopy() *AmbientServer {
+	if in == nil {
+		return nil
+	}
+	out := new(AmbientServer)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClientIntents) DeepCopyInto(out *ClientIntents) {
 	*out = *in
@@ -38,7 +58,7 @@ func (in *ClientIntents) DeepCopyInto(out *ClientIntents) {
 	if in.Status != nil {
 		in, out := &in.Status, &out.Status
 		*out = new(IntentsStatus)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 }
 
@@ -112,6 +132,95 @@ func (in *DatabaseResource) DeepCopy() *DatabaseResource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseServerConfig) DeepCopyInto(out *DatabaseServerConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseServerConfig.
+func (in *DatabaseServerConfig) DeepCopy() *DatabaseServerConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseServerConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DatabaseServerConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseServerConfigList) DeepCopyInto(out *DatabaseServerConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DatabaseServerConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseServerConfigList.
+func (in *DatabaseServerConfigList) DeepCopy() *DatabaseServerConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseServerConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DatabaseServerConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseServerConfigSpec) DeepCopyInto(out *DatabaseServerConfigSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseServerConfigSpec.
+func (in *DatabaseServerConfigSpec) DeepCopy() *DatabaseServerConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseServerConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseServerConfigStatus) DeepCopyInto(out *DatabaseServerConfigStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseServerConfigStatus.
+func (in *DatabaseServerConfigStatus) DeepCopy() *DatabaseServerConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseServerConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HTTPResource) DeepCopyInto(out *HTTPResource) {
 	*out = *in
@@ -142,6 +251,28 @@ func (in *Intent) DeepCopyInto(out *Intent) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ConsumerGroups != nil {
+		in, out := &in.ConsumerGroups, &out.ConsumerGroups
+		*out = make([]KafkaConsumerGroup, len(*in))
+		copy(*out, *in)
+	}
+	if in.TransactionalIDs != nil {
+		in, out := &in.TransactionalIDs, &out.TransactionalIDs
+		*out = make([]KafkaTransactionalID, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ClusterOperations != nil {
+		in, out := &in.ClusterOperations, &out.ClusterOperations
+		*out = make([]KafkaOperation, len(*in))
+		copy(*out, *in)
+	}
+	if in.Quotas != nil {
+		in, out := &in.Quotas, &out.Quotas
+		*out = new(KafkaQuotas)
+		**out = **in
+	}
 	if in.HTTPResources != nil {
 		in, out := &in.HTTPResources, &out.HTTPResources
 		*out = make([]HTTPResource, len(*in))
@@ -156,6 +287,23 @@ func (in *Intent) DeepCopyInto(out *Intent) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.AWSActions != nil {
+		in, out := &in.AWSActions, &out.AWSActions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.KubernetesResources != nil {
+		in, out := &in.KubernetesResources, &out.KubernetesResources
+		*out = make([]KubernetesResource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Internet != nil {
+		in, out := &in.Internet, &out.Internet
+		*out = new(Internet)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Intent.
@@ -179,6 +327,11 @@ func (in *IntentsSpec) DeepCopyInto(out *IntentsSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.RemoteClients != nil {
+		in, out := &in.RemoteClients, &out.RemoteClients
+		*out = make([]RemoteClient, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IntentsSpec.
@@ -194,6 +347,16 @@ func (in *IntentsSpec) DeepCopy() *IntentsSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IntentsStatus) DeepCopyInto(out *IntentsStatus) {
 	*out = *in
+	if in.DatabaseServers != nil {
+		in, out := &in.DatabaseServers, &out.DatabaseServers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.KafkaQuotas != nil {
+		in, out := &in.KafkaQuotas, &out.KafkaQuotas
+		*out = make([]KafkaServerQuotas, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IntentsStatus.
@@ -206,13 +369,181 @@ func (in *IntentsStatus) DeepCopy() *IntentsStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Internet) DeepCopyInto(out *Internet) {
+	*out = *in
+	if in.Domains != nil {
+		in, out := &in.Domains, &out.Domains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Internet.
+func (in *Internet) DeepCopy() *Internet {
+	if in == nil {
+		return nil
+	}
+	out := new(Internet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaACL) DeepCopyInto(out *KafkaACL) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaACL.
+func (in *KafkaACL) DeepCopy() *KafkaACL {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaACL)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaACLDrift) DeepCopyInto(out *KafkaACLDrift) {
+	*out = *in
+	if in.Missing != nil {
+		in, out := &in.Missing, &out.Missing
+		*out = make([]KafkaACL, len(*in))
+		copy(*out, *in)
+	}
+	if in.Unexpected != nil {
+		in, out := &in.Unexpected, &out.Unexpected
+		*out = make([]KafkaACL, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaACLDrift.
+func (in *KafkaACLDrift) DeepCopy() *KafkaACLDrift {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaACLDrift)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaACLDriftStatus) DeepCopyInto(out *KafkaACLDriftStatus) {
+	*out = *in
+	in.KafkaACLDrift.DeepCopyInto(&out.KafkaACLDrift)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaACLDriftStatus.
+func (in *KafkaACLDriftStatus) DeepCopy() *KafkaACLDriftStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaACLDriftStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaAuth) DeepCopyInto(out *KafkaAuth) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaAuth.
+func (in *KafkaAuth) DeepCopy() *KafkaAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaConsumerGroup) DeepCopyInto(out *KafkaConsumerGroup) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaConsumerGroup.
+func (in *KafkaConsumerGroup) DeepCopy() *KafkaConsumerGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaConsumerGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaListenerPorts) DeepCopyInto(out *KafkaListenerPorts) {
+	*out = *in
+	if in.Client != nil {
+		in, out := &in.Client, &out.Client
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaListenerPorts.
+func (in *KafkaListenerPorts) DeepCopy() *KafkaListenerPorts {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaListenerPorts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaManagedTopic) DeepCopyInto(out *KafkaManagedTopic) {
+	*out = *in
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaManagedTopic.
+func (in *KafkaManagedTopic) DeepCopy() *KafkaManagedTopic {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaManagedTopic)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaQuotas) DeepCopyInto(out *KafkaQuotas) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaQuotas.
+func (in *KafkaQuotas) DeepCopy() *KafkaQuotas {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaQuotas)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KafkaServerConfig) DeepCopyInto(out *KafkaServerConfig) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaServerConfig.
@@ -270,6 +601,31 @@ func (in *KafkaServerConfigSpec) DeepCopyInto(out *KafkaServerConfigSpec) {
 	*out = *in
 	out.Service = in.Service
 	out.TLS = in.TLS
+	if in.Auth != nil {
+		in, out := &in.Auth, &out.Auth
+		*out = new(KafkaAuth)
+		**out = **in
+	}
+	if in.AllowedClusterOperations != nil {
+		in, out := &in.AllowedClusterOperations, &out.AllowedClusterOperations
+		*out = make([]KafkaOperation, len(*in))
+		copy(*out, *in)
+	}
+	if in.Strimzi != nil {
+		in, out := &in.Strimzi, &out.Strimzi
+		*out = new(StrimziConfig)
+		**out = **in
+	}
+	if in.TopicManagement != nil {
+		in, out := &in.TopicManagement, &out.TopicManagement
+		*out = new(KafkaTopicManagement)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ListenerPorts != nil {
+		in, out := &in.ListenerPorts, &out.ListenerPorts
+		*out = new(KafkaListenerPorts)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Topics != nil {
 		in, out := &in.Topics, &out.Topics
 		*out = make([]TopicConfig, len(*in))
@@ -290,6 +646,26 @@ func (in *KafkaServerConfigSpec) DeepCopy() *KafkaServerConfigSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KafkaServerConfigStatus) DeepCopyInto(out *KafkaServerConfigStatus) {
 	*out = *in
+	if in.ACLDrift != nil {
+		in, out := &in.ACLDrift, &out.ACLDrift
+		*out = new(KafkaACLDriftStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ACLResyncSkippedClients != nil {
+		in, out := &in.ACLResyncSkippedClients, &out.ACLResyncSkippedClients
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Topics != nil {
+		in, out := &in.Topics, &out.Topics
+		*out = new(KafkaTopicsStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ManagedPrincipals != nil {
+		in, out := &in.ManagedPrincipals, &out.ManagedPrincipals
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaServerConfigStatus.
@@ -302,6 +678,22 @@ func (in *KafkaServerConfigStatus) DeepCopy() *KafkaServerConfigStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaServerQuotas) DeepCopyInto(out *KafkaServerQuotas) {
+	*out = *in
+	out.KafkaQuotas = in.KafkaQuotas
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaServerQuotas.
+func (in *KafkaServerQuotas) DeepCopy() *KafkaServerQuotas {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaServerQuotas)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KafkaTopic) DeepCopyInto(out *KafkaTopic) {
 	*out = *in
@@ -322,6 +714,108 @@ func (in *KafkaTopic) DeepCopy() *KafkaTopic {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaTopicManagement) DeepCopyInto(out *KafkaTopicManagement) {
+	*out = *in
+	if in.Topics != nil {
+		in, out := &in.Topics, &out.Topics
+		*out = make([]KafkaManagedTopic, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaTopicManagement.
+func (in *KafkaTopicManagement) DeepCopy() *KafkaTopicManagement {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaTopicManagement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaTopicsStatus) DeepCopyInto(out *KafkaTopicsStatus) {
+	*out = *in
+	if in.Created != nil {
+		in, out := &in.Created, &out.Created
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Missing != nil {
+		in, out := &in.Missing, &out.Missing
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaTopicsStatus.
+func (in *KafkaTopicsStatus) DeepCopy() *KafkaTopicsStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaTopicsStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaTransactionalID) DeepCopyInto(out *KafkaTransactionalID) {
+	*out = *in
+	if in.Operations != nil {
+		in, out := &in.Operations, &out.Operations
+		*out = make([]KafkaOperation, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaTransactionalID.
+func (in *KafkaTransactionalID) DeepCopy() *KafkaTransactionalID {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaTransactionalID)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubernetesResource) DeepCopyInto(out *KubernetesResource) {
+	*out = *in
+	if in.APIGroups != nil {
+		in, out := &in.APIGroups, &out.APIGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Verbs != nil {
+		in, out := &in.Verbs, &out.Verbs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ResourceNames != nil {
+		in, out := &in.ResourceNames, &out.ResourceNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubernetesResource.
+func (in *KubernetesResource) DeepCopy() *KubernetesResource {
+	if in == nil {
+		return nil
+	}
+	out := new(KubernetesResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProtectedService) DeepCopyInto(out *ProtectedService) {
 	*out = *in
@@ -411,6 +905,21 @@ func (in *ProtectedServiceStatus) DeepCopy() *ProtectedServiceStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemoteClient) DeepCopyInto(out *RemoteClient) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemoteClient.
+func (in *RemoteClient) DeepCopy() *RemoteClient {
+	if in == nil {
+		return nil
+	}
+	out := new(RemoteClient)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Service) DeepCopyInto(out *Service) {
 	*out = *in
@@ -426,6 +935,21 @@ func (in *Service) DeepCopy() *Service {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StrimziConfig) DeepCopyInto(out *StrimziConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StrimziConfig.
+func (in *StrimziConfig) DeepCopy() *StrimziConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(StrimziConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TLSSource) DeepCopyInto(out *TLSSource) {
 	*out = *in
@@ -0,0 +1,258 @@
+package always_allowed
+
+import (
+	"context"
+	"fmt"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"net"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"strconv"
+	"strings"
+)
+
+// Config describes traffic that protected servers always accept, regardless of intents - typically monitoring agents
+// scraping metrics and kubelet health checks on hostNetwork setups.
+type Config struct {
+	// PodSelector and NamespaceSelector identify the pods that are always allowed, e.g. the Prometheus server.
+	PodSelector       *metav1.LabelSelector
+	NamespaceSelector *metav1.LabelSelector
+	// CIDRs are always allowed, e.g. the node CIDRs kubelet probes originate from when pods run with hostNetwork.
+	CIDRs []string
+	// Ports restricts the always allowed traffic to these ports, given as numbers or container port names.
+	Ports []intstr.IntOrString
+	// PortAnnotations are annotations of the server's pods that hold additional ports, e.g. prometheus.io/port.
+	PortAnnotations []string
+}
+
+// ParseConfig parses comma separated values. Label selectors are in the format accepted by kubectl
+// (e.g. "app=prometheus"); empty strings leave the corresponding field unset.
+func ParseConfig(podSelector string, namespaceSelector string, cidrs string, ports string, portAnnotations string) (Config, error) {
+	config := Config{}
+	if podSelector != "" {
+		selector, err := metav1.ParseToLabelSelector(podSelector)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid always allowed pod selector %q: %w", podSelector, err)
+		}
+		config.PodSelector = normalizeLabelSelector(selector)
+	}
+
+	if namespaceSelector != "" {
+		selector, err := metav1.ParseToLabelSelector(namespaceSelector)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid always allowed namespace selector %q: %w", namespaceSelector, err)
+		}
+		config.NamespaceSelector = normalizeLabelSelector(selector)
+	}
+
+	for _, cidr := range splitList(cidrs) {
+		_, _, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid always allowed CIDR %q: %w", cidr, err)
+		}
+		config.CIDRs = append(config.CIDRs, cidr)
+	}
+
+	for _, port := range splitList(ports) {
+		config.Ports = append(config.Ports, intstr.Parse(port))
+	}
+
+	config.PortAnnotations = splitList(portAnnotations)
+
+	if !config.hasPeers() && len(config.Ports) == 0 && len(config.PortAnnotations) != 0 {
+		return Config{}, fmt.Errorf("always allowed port annotations require a pod selector, namespace selector or CIDRs to allow traffic from")
+	}
+
+	return config, nil
+}
+
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// normalizeLabelSelector drops empty fields, as the API server does, so that generated policies compare equal to
+// the ones read back from the cluster.
+func normalizeLabelSelector(selector *metav1.LabelSelector) *metav1.LabelSelector {
+	if len(selector.MatchLabels) == 0 {
+		selector.MatchLabels = nil
+	}
+	if len(selector.MatchExpressions) == 0 {
+		selector.MatchExpressions = nil
+	}
+	return selector
+}
+
+func (c Config) hasPeers() bool {
+	return c.PodSelector != nil || c.NamespaceSelector != nil || len(c.CIDRs) != 0
+}
+
+// IsSet returns whether any always allowed traffic is configured.
+func (c Config) IsSet() bool {
+	return c.hasPeers() || len(c.Ports) != 0
+}
+
+func (c Config) peers() []v1.NetworkPolicyPeer {
+	peers := make([]v1.NetworkPolicyPeer, 0)
+	if c.PodSelector != nil || c.NamespaceSelector != nil {
+		peer := v1.NetworkPolicyPeer{
+			PodSelector:       c.PodSelector,
+			NamespaceSelector: c.NamespaceSelector,
+		}
+		// A peer with only a pod selector matches pods in the policy's namespace, but monitoring agents usually run
+		// in a namespace of their own.
+		if peer.NamespaceSelector == nil {
+			peer.NamespaceSelector = &metav1.LabelSelector{}
+		}
+		peers = append(peers, peer)
+	}
+
+	for _, cidr := range c.CIDRs {
+		peers = append(peers, v1.NetworkPolicyPeer{IPBlock: &v1.IPBlock{CIDR: cidr}})
+	}
+
+	return peers
+}
+
+// IngressRules returns the rules to append to a policy selecting protected pods. annotatedPorts are the ports read
+// from the pods' annotations, see ResolveAnnotatedPorts. Without any ports, the configured peers may access all ports;
+// without peers, the configured ports are open to all sources.
+func (c Config) IngressRules(annotatedPorts []intstr.IntOrString) []v1.NetworkPolicyIngressRule {
+	if !c.IsSet() {
+		return nil
+	}
+
+	// Ports are only taken from annotations, and none of the pods has them - allowing all ports would be too broad.
+	if len(c.Ports) == 0 && len(c.PortAnnotations) != 0 && len(annotatedPorts) == 0 {
+		return nil
+	}
+
+	rule := v1.NetworkPolicyIngressRule{}
+	if c.hasPeers() {
+		rule.From = c.peers()
+	}
+
+	allPorts := append(slices.Clone(c.Ports), annotatedPorts...)
+	allPorts = lo.UniqBy(allPorts, func(port intstr.IntOrString) string { return port.String() })
+	for i := range allPorts {
+		rule.Ports = append(rule.Ports, v1.NetworkPolicyPort{
+			Protocol: lo.ToPtr(corev1.ProtocolTCP),
+			Port:     &allPorts[i],
+		})
+	}
+
+	return []v1.NetworkPolicyIngressRule{rule}
+}
+
+// ResolveAnnotatedPorts reads the ports held in the configured annotations of the pods matching selector.
+// Annotations that are not valid port numbers are ignored.
+func (c Config) ResolveAnnotatedPorts(ctx context.Context, k8sClient client.Client, namespace string, selector metav1.LabelSelector) ([]intstr.IntOrString, error) {
+	if len(c.PortAnnotations) == 0 {
+		return nil, nil
+	}
+
+	labelSelector, err := metav1.LabelSelectorAsSelector(&selector)
+	if err != nil {
+		return nil, err
+	}
+
+	var pods corev1.PodList
+	err = k8sClient.List(ctx, &pods, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: labelSelector})
+	if err != nil {
+		return nil, err
+	}
+
+	portNumbers := make([]int, 0)
+	for _, pod := range pods.Items {
+		for _, annotation := range c.PortAnnotations {
+			value, ok := pod.Annotations[annotation]
+			if !ok {
+				continue
+			}
+			port, err := strconv.Atoi(value)
+			if err != nil || port <= 0 || port > 65535 {
+				logrus.Warningf("Ignoring invalid port %q in annotation %s of pod %s/%s", value, annotation, pod.Namespace, pod.Name)
+				continue
+			}
+			portNumbers = append(portNumbers, port)
+		}
+	}
+
+	portNumbers = lo.Uniq(portNumbers)
+	slices.Sort(portNumbers)
+	return lo.Map(portNumbers, func(port int, _ int) intstr.IntOrString { return intstr.FromInt(port) }), nil
+}
+
+// AddToPolicy appends the always allowed rules to a generated policy, resolving annotated ports from the pods the
+// policy selects. The rules are marked using the intents.otterize.com/always-allowed-rules annotation, which holds
+// the number of trailing rules that were added.
+func (c Config) AddToPolicy(ctx context.Context, k8sClient client.Client, policy *v1.NetworkPolicy) error {
+	if !c.IsSet() {
+		return nil
+	}
+
+	annotatedPorts, err := c.ResolveAnnotatedPorts(ctx, k8sClient, policy.Namespace, policy.Spec.PodSelector)
+	if err != nil {
+		return fmt.Errorf("failed resolving always allowed ports: %w", err)
+	}
+
+	rules := c.IngressRules(annotatedPorts)
+	if len(rules) == 0 {
+		return nil
+	}
+
+	policy.Spec.Ingress = append(policy.Spec.Ingress, rules...)
+	if policy.Annotations == nil {
+		policy.Annotations = make(map[string]string)
+	}
+	policy.Annotations[otterizev1alpha3.OtterizeAlwaysAllowedRulesAnnotation] = strconv.Itoa(len(rules))
+	return nil
+}
+
+// PortAnnotationsChangedPredicate passes pod events that may change the ports read from the configured port
+// annotations, so that policies holding always allowed rules can be rebuilt. Pods are only watched for this when
+// port annotations are configured, see HasPortAnnotations.
+func (c Config) PortAnnotationsChangedPredicate() predicate.Predicate {
+	hasPortAnnotation := func(obj client.Object) bool {
+		return lo.ContainsBy(c.PortAnnotations, func(annotation string) bool {
+			_, ok := obj.GetAnnotations()[annotation]
+			return ok
+		})
+	}
+
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return hasPortAnnotation(e.Object)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return lo.ContainsBy(c.PortAnnotations, func(annotation string) bool {
+				return e.ObjectOld.GetAnnotations()[annotation] != e.ObjectNew.GetAnnotations()[annotation]
+			})
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return hasPortAnnotation(e.Object)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+// HasPortAnnotations returns whether ports are read from pod annotations.
+func (c Config) HasPortAnnotations() bool {
+	return c.IsSet() && len(c.PortAnnotations) != 0
+}
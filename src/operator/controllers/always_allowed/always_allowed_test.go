@@ -0,0 +1,104 @@
+package always_allowed
+
+import (
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/suite"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"testing"
+)
+
+type AlwaysAllowedTestSuite struct {
+	suite.Suite
+}
+
+func (s *AlwaysAllowedTestSuite) TestParseConfig() {
+	config, err := ParseConfig("app=prometheus", "", "10.0.0.0/16, 10.1.0.0/16", "9090,metrics", "prometheus.io/port")
+	s.Require().NoError(err)
+	s.Require().Equal(&metav1.LabelSelector{MatchLabels: map[string]string{"app": "prometheus"}}, config.PodSelector)
+	s.Require().Nil(config.NamespaceSelector)
+	s.Require().Equal([]string{"10.0.0.0/16", "10.1.0.0/16"}, config.CIDRs)
+	s.Require().Equal([]intstr.IntOrString{intstr.FromInt(9090), intstr.FromString("metrics")}, config.Ports)
+	s.Require().Equal([]string{"prometheus.io/port"}, config.PortAnnotations)
+}
+
+func (s *AlwaysAllowedTestSuite) TestParseConfigEmpty() {
+	config, err := ParseConfig("", "", "", "", "")
+	s.Require().NoError(err)
+	s.Require().False(config.IsSet())
+	s.Require().Nil(config.IngressRules(nil))
+}
+
+func (s *AlwaysAllowedTestSuite) TestParseConfigInvalidCIDR() {
+	_, err := ParseConfig("", "", "10.0.0.0/33", "", "")
+	s.Require().Error(err)
+}
+
+func (s *AlwaysAllowedTestSuite) TestParseConfigPortAnnotationsWithoutPeers() {
+	_, err := ParseConfig("", "", "", "", "prometheus.io/port")
+	s.Require().Error(err)
+}
+
+func (s *AlwaysAllowedTestSuite) TestIngressRulesPodSelectorMatchesAllNamespaces() {
+	config, err := ParseConfig("app=prometheus", "", "", "", "prometheus.io/port")
+	s.Require().NoError(err)
+
+	port := intstr.FromInt(8080)
+	expectedRules := []v1.NetworkPolicyIngressRule{
+		{
+			From: []v1.NetworkPolicyPeer{
+				{
+					PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "prometheus"}},
+					NamespaceSelector: &metav1.LabelSelector{},
+				},
+			},
+			Ports: []v1.NetworkPolicyPort{{Protocol: lo.ToPtr(corev1.ProtocolTCP), Port: &port}},
+		},
+	}
+	s.Require().Equal(expectedRules, config.IngressRules([]intstr.IntOrString{intstr.FromInt(8080)}))
+}
+
+func (s *AlwaysAllowedTestSuite) TestIngressRulesNoAnnotatedPorts() {
+	config, err := ParseConfig("", "kubernetes.io/metadata.name=monitoring", "", "", "prometheus.io/port")
+	s.Require().NoError(err)
+
+	// Allowing all ports just because the pods lack the annotation would be broader than configured.
+	s.Require().Nil(config.IngressRules(nil))
+}
+
+func (s *AlwaysAllowedTestSuite) TestIngressRulesDeduplicatesPorts() {
+	config, err := ParseConfig("", "", "10.0.0.0/16", "9090", "prometheus.io/port")
+	s.Require().NoError(err)
+
+	rules := config.IngressRules([]intstr.IntOrString{intstr.FromInt(9090), intstr.FromInt(8080)})
+	s.Require().Len(rules, 1)
+	s.Require().Equal([]v1.NetworkPolicyPeer{{IPBlock: &v1.IPBlock{CIDR: "10.0.0.0/16"}}}, rules[0].From)
+	s.Require().Len(rules[0].Ports, 2)
+	s.Require().Equal(9090, rules[0].Ports[0].Port.IntValue())
+	s.Require().Equal(8080, rules[0].Ports[1].Port.IntValue())
+}
+
+func (s *AlwaysAllowedTestSuite) TestPortAnnotationsChangedPredicate() {
+	config, err := ParseConfig("app=prometheus", "", "", "", "prometheus.io/port")
+	s.Require().NoError(err)
+	s.Require().True(config.HasPortAnnotations())
+	annotatedPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"prometheus.io/port": "9090"}}}
+	reannotatedPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"prometheus.io/port": "9091"}}}
+	relabeledPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"prometheus.io/port": "9090"}, Labels: map[string]string{"app": "server"}}}
+	otherPod := &corev1.Pod{}
+
+	predicate := config.PortAnnotationsChangedPredicate()
+	s.Require().True(predicate.Create(event.CreateEvent{Object: annotatedPod}))
+	s.Require().False(predicate.Create(event.CreateEvent{Object: otherPod}))
+	s.Require().True(predicate.Update(event.UpdateEvent{ObjectOld: annotatedPod, ObjectNew: reannotatedPod}))
+	s.Require().True(predicate.Update(event.UpdateEvent{ObjectOld: otherPod, ObjectNew: annotatedPod}))
+	s.Require().False(predicate.Update(event.UpdateEvent{ObjectOld: annotatedPod, ObjectNew: relabeledPod}))
+	s.Require().True(predicate.Delete(event.DeleteEvent{Object: annotatedPod}))
+}
+
+func TestAlwaysAllowedTestSuite(t *testing.T) {
+	suite.Run(t, new(AlwaysAllowedTestSuite))
+}
@@ -0,0 +1,222 @@
+package external_traffic
+
+import (
+	"context"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/shared/injectablerecorder"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	"strings"
+)
+
+//+kubebuilder:rbac:groups="gateway.networking.k8s.io",resources=httproutes;grpcroutes;tlsroutes,verbs=get;list;watch
+//+kubebuilder:rbac:groups="gateway.networking.k8s.io",resources=referencegrants,verbs=get;list;watch
+//+kubebuilder:rbac:groups="networking.k8s.io",resources=networkpolicies,verbs=get;update;patch;list;watch;delete;create
+
+// GatewayRouteReconciler is the Gateway API counterpart of the IngressReconciler. There is one instance per route
+// kind (HTTPRoute, GRPCRoute, TLSRoute) - routes are handled as unstructured objects, so that clusters without the
+// Gateway API CRDs (or with only some of them) are supported. Since the manager's client does not cache unstructured
+// objects, routes and ReferenceGrants are read using routeReader (the manager's cache).
+type GatewayRouteReconciler struct {
+	client.Client
+	routeReader        client.Reader
+	extNetpolHandler   *NetworkPolicyHandler
+	routeKind          schema.GroupVersionKind
+	referenceGrantKind *schema.GroupVersionKind
+	injectablerecorder.InjectableRecorder
+}
+
+func NewGatewayRouteReconciler(
+	client client.Client,
+	routeReader client.Reader,
+	extNetpolHandler *NetworkPolicyHandler,
+	routeKind schema.GroupVersionKind,
+	referenceGrantKind *schema.GroupVersionKind,
+) *GatewayRouteReconciler {
+	return &GatewayRouteReconciler{
+		Client:             client,
+		routeReader:        routeReader,
+		extNetpolHandler:   extNetpolHandler,
+		routeKind:          routeKind,
+		referenceGrantKind: referenceGrantKind,
+	}
+}
+
+func (r *GatewayRouteReconciler) newRoute() *unstructured.Unstructured {
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(r.routeKind)
+	return route
+}
+
+func (r *GatewayRouteReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	recorder := mgr.GetEventRecorderFor("intents-operator")
+	r.InjectRecorder(recorder)
+
+	builder := ctrl.NewControllerManagedBy(mgr).
+		Named(strings.ToLower(r.routeKind.Kind)).
+		For(r.newRoute()).
+		WithOptions(controller.Options{RecoverPanic: lo.ToPtr(true)})
+
+	if r.referenceGrantKind != nil {
+		referenceGrant := &unstructured.Unstructured{}
+		referenceGrant.SetGroupVersionKind(*r.referenceGrantKind)
+		builder = builder.Watches(&source.Kind{Type: referenceGrant}, handler.EnqueueRequestsFromMapFunc(r.mapReferenceGrantToRoutes))
+	}
+
+	return builder.Complete(r)
+}
+
+// mapReferenceGrantToRoutes enqueues the routes a ReferenceGrant applies to, so that external traffic policies are
+// created or removed for services in the ReferenceGrant's namespace once it is created, updated or deleted.
+func (r *GatewayRouteReconciler) mapReferenceGrantToRoutes(obj client.Object) []reconcile.Request {
+	grant := obj.(*unstructured.Unstructured)
+	namespaces, err := referenceGrantNamespacesForRouteKind(grant, r.routeKind.Kind)
+	if err != nil {
+		logrus.WithError(err).Warningf("failed parsing %s %s/%s", ReferenceGrantKind, grant.GetNamespace(), grant.GetName())
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0)
+	for namespace := range namespaces {
+		routeList := &unstructured.UnstructuredList{}
+		routeList.SetGroupVersionKind(r.routeKind.GroupVersion().WithKind(r.routeKind.Kind + "List"))
+		err := r.routeReader.List(context.Background(), routeList, client.InNamespace(namespace))
+		if err != nil {
+			logrus.WithError(err).Errorf("Failed to list %s in namespace %s", r.routeKind.Kind, namespace)
+			continue
+		}
+
+		for _, route := range routeList.Items {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: route.GetName(), Namespace: route.GetNamespace()},
+			})
+		}
+	}
+
+	return requests
+}
+
+func (r *GatewayRouteReconciler) InjectRecorder(recorder record.EventRecorder) {
+	r.Recorder = recorder
+	r.extNetpolHandler.InjectRecorder(recorder)
+}
+
+// Reconcile handles route creation, update and delete. Just like the IngressReconciler, it resolves which services
+// the route refers to (as backendRefs), as well as services that had external traffic policies created for this route,
+// and asks the NetworkPolicyHandler to handle each of them. This way, policies are created for newly referenced
+// services and removed once a service is no longer referenced by any route, ingress or load balancer.
+func (r *GatewayRouteReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	routeID := formatGatewayRouteID(r.routeKind.Kind, req.Namespace, req.Name)
+	services, err := r.getServicesReferencedByNetworkPoliciesCreatedForRoute(ctx, routeID)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	route := r.newRoute()
+	err = r.routeReader.Get(ctx, req.NamespacedName, route)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+
+	if err == nil {
+		routeServices, err := serviceNamesFromGatewayRoute(route)
+		if err != nil {
+			logrus.WithError(err).Warningf("failed parsing backendRefs of %s %s", r.routeKind.Kind, req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		services = services.Union(routeServices)
+	}
+
+	for service := range services {
+		err := r.extNetpolHandler.HandleEndpointsByName(ctx, service.Name, service.Namespace)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *GatewayRouteReconciler) getServicesReferencedByNetworkPoliciesCreatedForRoute(ctx context.Context, routeID string) (sets.Set[types.NamespacedName], error) {
+	services := sets.Set[types.NamespacedName]{}
+
+	netpolList := &v1.NetworkPolicyList{}
+	err := r.List(ctx, netpolList, &client.MatchingFields{otterizev1alpha3.NetworkPoliciesByGatewayRouteIndexField: routeID})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, netpol := range netpolList.Items {
+		serviceName, ok := netpol.Annotations[otterizev1alpha3.OtterizeCreatedForServiceAnnotation]
+		if !ok {
+			continue
+		}
+		services.Insert(types.NamespacedName{Name: serviceName, Namespace: netpol.Namespace})
+	}
+
+	return services, nil
+}
+
+// InitGatewayRouteReferencedServicesIndex indexes routes of this reconciler's kind by the services they reference,
+// in the form "namespace/name".
+func (r *GatewayRouteReconciler) InitGatewayRouteReferencedServicesIndex(mgr ctrl.Manager) error {
+	err := mgr.GetCache().IndexField(
+		context.Background(),
+		r.newRoute(),
+		otterizev1alpha3.GatewayRouteServiceNamesIndexField,
+		func(object client.Object) []string {
+			route := object.(*unstructured.Unstructured)
+			services, err := serviceNamesFromGatewayRoute(route)
+			if err != nil {
+				logrus.WithError(err).Warningf("failed parsing backendRefs of %s %s/%s", route.GetKind(), route.GetNamespace(), route.GetName())
+				return nil
+			}
+
+			return lo.Map(services.UnsortedList(), func(service types.NamespacedName, _ int) string {
+				return formatGatewayRouteServiceIndexValue(service)
+			})
+		})
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// InitNetworkPoliciesByGatewayRouteIndex indexes external traffic network policies by the routes they were created for.
+// The index is shared by all route kinds, so it should only be initialized once.
+func InitNetworkPoliciesByGatewayRouteIndex(mgr ctrl.Manager) error {
+	err := mgr.GetCache().IndexField(
+		context.Background(),
+		&v1.NetworkPolicy{},
+		otterizev1alpha3.NetworkPoliciesByGatewayRouteIndexField,
+		func(object client.Object) []string {
+			netpol := object.(*v1.NetworkPolicy)
+			value, ok := netpol.Annotations[otterizev1alpha3.OtterizeCreatedForGatewayRouteAnnotation]
+			if !ok {
+				return nil
+			}
+
+			return strings.Split(value, ",")
+		})
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
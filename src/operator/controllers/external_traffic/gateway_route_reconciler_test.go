@@ -0,0 +1,174 @@
+package external_traffic
+
+import (
+	"context"
+	mocks "github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/mocks"
+	"github.com/otterize/intents-operator/src/shared/testbase"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"testing"
+)
+
+const (
+	testRouteNamespace   = "routes-ns"
+	testRouteName        = "my-route"
+	testServiceNamespace = "services-ns"
+	testServiceName      = "my-svc"
+	testPodName          = "my-pod"
+)
+
+var (
+	testHTTPRouteKind      = schema.GroupVersionKind{Group: GatewayAPIGroup, Version: "v1", Kind: HTTPRouteKind}
+	testReferenceGrantKind = schema.GroupVersionKind{Group: GatewayAPIGroup, Version: "v1beta1", Kind: ReferenceGrantKind}
+)
+
+type GatewayRouteReconcilerTestSuite struct {
+	testbase.MocksSuiteBase
+	routeReader *mocks.MockClient
+	handler     *NetworkPolicyHandler
+	reconciler  *GatewayRouteReconciler
+}
+
+func (s *GatewayRouteReconcilerTestSuite) SetupTest() {
+	s.MocksSuiteBase.SetupTest()
+	s.routeReader = mocks.NewMockClient(s.Controller)
+	s.handler = NewNetworkPolicyHandler(s.Client, &runtime.Scheme{}, true, false)
+	s.handler.SetGatewayRouteKinds(s.routeReader, []schema.GroupVersionKind{testHTTPRouteKind}, &testReferenceGrantKind)
+	s.reconciler = NewGatewayRouteReconciler(s.Client, s.routeReader, s.handler, testHTTPRouteKind, &testReferenceGrantKind)
+	s.reconciler.InjectRecorder(s.Recorder)
+}
+
+func newReferenceGrant(fromKind string, fromNamespace string, toName string) *unstructured.Unstructured {
+	to := map[string]any{"group": "", "kind": serviceKind}
+	if toName != "" {
+		to["name"] = toName
+	}
+
+	grant := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{
+			"from": []any{map[string]any{"group": GatewayAPIGroup, "kind": fromKind, "namespace": fromNamespace}},
+			"to":   []any{to},
+		},
+	}}
+	grant.SetGroupVersionKind(testReferenceGrantKind)
+	grant.SetNamespace(testServiceNamespace)
+	grant.SetName("allow-routes")
+	return grant
+}
+
+// expectReconcileCrossNamespaceRoute sets up the calls made when reconciling a route in testRouteNamespace that
+// refers to testServiceName in testServiceNamespace, up to the point where the ReferenceGrants are checked.
+func (s *GatewayRouteReconcilerTestSuite) expectReconcileCrossNamespaceRoute(referenceGrants ...unstructured.Unstructured) {
+	route := newHTTPRoute(testRouteNamespace, testRouteName, map[string]any{"name": testServiceName, "namespace": testServiceNamespace})
+
+	s.Client.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&v1.NetworkPolicyList{}), gomock.Any()).Return(nil)
+	s.routeReader.EXPECT().Get(gomock.Any(), types.NamespacedName{Name: testRouteName, Namespace: testRouteNamespace}, gomock.AssignableToTypeOf(&unstructured.Unstructured{})).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, obj *unstructured.Unstructured, opts ...client.GetOption) error {
+			route.DeepCopyInto(obj)
+			return nil
+		})
+
+	serviceName := types.NamespacedName{Name: testServiceName, Namespace: testServiceNamespace}
+	s.Client.EXPECT().Get(gomock.Any(), serviceName, gomock.AssignableToTypeOf(&corev1.Endpoints{})).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, endpoints *corev1.Endpoints, opts ...client.GetOption) error {
+			endpoints.ObjectMeta = metav1.ObjectMeta{Name: testServiceName, Namespace: testServiceNamespace}
+			endpoints.Subsets = []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{
+				{IP: "10.0.0.1", TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: testPodName, Namespace: testServiceNamespace}},
+			}}}
+			return nil
+		})
+	s.Client.EXPECT().Get(gomock.Any(), serviceName, gomock.AssignableToTypeOf(&corev1.Service{})).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, svc *corev1.Service, opts ...client.GetOption) error {
+			svc.ObjectMeta = metav1.ObjectMeta{Name: testServiceName, Namespace: testServiceNamespace}
+			svc.Spec.Type = corev1.ServiceTypeClusterIP
+			return nil
+		})
+	s.Client.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&v1.IngressList{}), gomock.Any()).Return(nil)
+	s.routeReader.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&unstructured.UnstructuredList{}), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, list *unstructured.UnstructuredList, opts ...client.ListOption) error {
+			s.Require().Equal(HTTPRouteKind+"List", list.GetKind())
+			list.Items = []unstructured.Unstructured{*route}
+			return nil
+		})
+	s.routeReader.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&unstructured.UnstructuredList{}), client.InNamespace(testServiceNamespace)).DoAndReturn(
+		func(ctx context.Context, list *unstructured.UnstructuredList, opts ...client.ListOption) error {
+			s.Require().Equal(ReferenceGrantKind+"List", list.GetKind())
+			list.Items = referenceGrants
+			return nil
+		})
+}
+
+func (s *GatewayRouteReconcilerTestSuite) expectExternalTrafficPolicyNotFound() {
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Name: s.handler.formatPolicyName(testServiceName), Namespace: testServiceNamespace}, gomock.AssignableToTypeOf(&v1.NetworkPolicy{})).
+		Return(k8serrors.NewNotFound(v1.Resource("networkpolicy"), s.handler.formatPolicyName(testServiceName)))
+}
+
+func (s *GatewayRouteReconcilerTestSuite) reconcile() {
+	res, err := s.reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: testRouteName, Namespace: testRouteNamespace}})
+	s.Require().NoError(err)
+	s.Require().Equal(ctrl.Result{}, res)
+}
+
+func (s *GatewayRouteReconcilerTestSuite) TestCrossNamespaceBackendWithoutReferenceGrantIgnored() {
+	s.expectReconcileCrossNamespaceRoute()
+	// The service is not considered exposed by the route, so its pods are not looked up and any policy is removed
+	s.expectExternalTrafficPolicyNotFound()
+
+	s.reconcile()
+}
+
+func (s *GatewayRouteReconcilerTestSuite) TestCrossNamespaceBackendWithReferenceGrantForOtherKindIgnored() {
+	s.expectReconcileCrossNamespaceRoute(*newReferenceGrant(GRPCRouteKind, testRouteNamespace, ""))
+	s.expectExternalTrafficPolicyNotFound()
+
+	s.reconcile()
+}
+
+func (s *GatewayRouteReconcilerTestSuite) TestCrossNamespaceBackendWithReferenceGrantForOtherServiceIgnored() {
+	s.expectReconcileCrossNamespaceRoute(*newReferenceGrant(HTTPRouteKind, testRouteNamespace, "other-svc"))
+	s.expectExternalTrafficPolicyNotFound()
+
+	s.reconcile()
+}
+
+func (s *GatewayRouteReconcilerTestSuite) TestCrossNamespaceBackendWithReferenceGrantHandled() {
+	s.expectReconcileCrossNamespaceRoute(*newReferenceGrant(HTTPRouteKind, testRouteNamespace, testServiceName))
+	// The service is exposed by the route, so the pods backing it are checked for Otterize network policies
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Name: testPodName, Namespace: testServiceNamespace}, gomock.AssignableToTypeOf(&corev1.Pod{})).
+		Return(k8serrors.NewNotFound(corev1.Resource("pod"), testPodName))
+	s.expectExternalTrafficPolicyNotFound()
+
+	s.reconcile()
+}
+
+func (s *GatewayRouteReconcilerTestSuite) TestMapReferenceGrantToRoutes() {
+	route := newHTTPRoute(testRouteNamespace, testRouteName, map[string]any{"name": testServiceName, "namespace": testServiceNamespace})
+	s.routeReader.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&unstructured.UnstructuredList{}), client.InNamespace(testRouteNamespace)).DoAndReturn(
+		func(ctx context.Context, list *unstructured.UnstructuredList, opts ...client.ListOption) error {
+			list.Items = []unstructured.Unstructured{*route}
+			return nil
+		})
+
+	requests := s.reconciler.mapReferenceGrantToRoutes(newReferenceGrant(HTTPRouteKind, testRouteNamespace, ""))
+	s.Require().Equal([]reconcile.Request{{NamespacedName: types.NamespacedName{Name: testRouteName, Namespace: testRouteNamespace}}}, requests)
+}
+
+func (s *GatewayRouteReconcilerTestSuite) TestMapReferenceGrantForOtherKindToNoRoutes() {
+	requests := s.reconciler.mapReferenceGrantToRoutes(newReferenceGrant(TLSRouteKind, testRouteNamespace, ""))
+	s.Require().Empty(requests)
+}
+
+func TestGatewayRouteReconcilerTestSuite(t *testing.T) {
+	suite.Run(t, new(GatewayRouteReconcilerTestSuite))
+}
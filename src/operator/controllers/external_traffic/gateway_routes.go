@@ -0,0 +1,198 @@
+package external_traffic
+
+import (
+	"fmt"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"strings"
+)
+
+const (
+	GatewayAPIGroup    = "gateway.networking.k8s.io"
+	HTTPRouteKind      = "HTTPRoute"
+	GRPCRouteKind      = "GRPCRoute"
+	TLSRouteKind       = "TLSRoute"
+	ReferenceGrantKind = "ReferenceGrant"
+	serviceKind        = "Service"
+	gatewayRouteIDSep  = "/"
+)
+
+var supportedGatewayRouteKinds = []string{HTTPRouteKind, GRPCRouteKind, TLSRouteKind}
+
+// gatewayRouteSpec is the subset of the HTTPRoute, GRPCRoute and TLSRoute specs needed to determine which
+// services a route sends traffic to. All three kinds share the same rules[].backendRefs[] structure.
+type gatewayRouteSpec struct {
+	Rules []gatewayRouteRule `json:"rules,omitempty"`
+}
+
+type gatewayRouteRule struct {
+	BackendRefs []gatewayBackendRef `json:"backendRefs,omitempty"`
+}
+
+type gatewayBackendRef struct {
+	Group     *string `json:"group,omitempty"`
+	Kind      *string `json:"kind,omitempty"`
+	Name      string  `json:"name"`
+	Namespace *string `json:"namespace,omitempty"`
+}
+
+// referenceGrantSpec is the subset of the ReferenceGrant spec needed to determine whether routes in other namespaces
+// may send traffic to services in the ReferenceGrant's namespace.
+type referenceGrantSpec struct {
+	From []referenceGrantFrom `json:"from,omitempty"`
+	To   []referenceGrantTo   `json:"to,omitempty"`
+}
+
+type referenceGrantFrom struct {
+	Group     string `json:"group"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+}
+
+type referenceGrantTo struct {
+	Group string  `json:"group"`
+	Kind  string  `json:"kind"`
+	Name  *string `json:"name,omitempty"`
+}
+
+// DetectGatewayRouteKinds returns the GroupVersionKinds of the Gateway API route kinds served by the cluster, using
+// the preferred version of each. Route kinds whose CRDs are not installed are skipped.
+func DetectGatewayRouteKinds(mapper meta.RESTMapper) ([]schema.GroupVersionKind, error) {
+	kinds := make([]schema.GroupVersionKind, 0)
+	for _, kind := range supportedGatewayRouteKinds {
+		mapping, err := mapper.RESTMapping(schema.GroupKind{Group: GatewayAPIGroup, Kind: kind})
+		if meta.IsNoMatchError(err) {
+			logrus.Debugf("Gateway API %s CRD not found, skipping", kind)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		kinds = append(kinds, mapping.GroupVersionKind)
+	}
+
+	return kinds, nil
+}
+
+// DetectReferenceGrantKind returns the GroupVersionKind of the ReferenceGrant served by the cluster, or nil if the
+// ReferenceGrant CRD is not installed - in which case routes may not refer to services in other namespaces.
+func DetectReferenceGrantKind(mapper meta.RESTMapper) (*schema.GroupVersionKind, error) {
+	mapping, err := mapper.RESTMapping(schema.GroupKind{Group: GatewayAPIGroup, Kind: ReferenceGrantKind})
+	if meta.IsNoMatchError(err) {
+		logrus.Debugf("Gateway API %s CRD not found, skipping", ReferenceGrantKind)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &mapping.GroupVersionKind, nil
+}
+
+// serviceNamesFromGatewayRoute returns the services a route references as backends. Backends that are not
+// Kubernetes services are ignored, and backends without a namespace default to the route's namespace.
+// Backends in other namespaces are returned regardless of ReferenceGrants, which are checked by the
+// NetworkPolicyHandler before allowing external traffic to them.
+func serviceNamesFromGatewayRoute(route *unstructured.Unstructured) (sets.Set[types.NamespacedName], error) {
+	serviceNames := sets.Set[types.NamespacedName]{}
+	rawSpec, ok := route.Object["spec"].(map[string]any)
+	if !ok {
+		return serviceNames, nil
+	}
+
+	spec := gatewayRouteSpec{}
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(rawSpec, &spec)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range spec.Rules {
+		for _, backendRef := range rule.BackendRefs {
+			if backendRef.Group != nil && *backendRef.Group != "" {
+				continue
+			}
+			if backendRef.Kind != nil && *backendRef.Kind != serviceKind {
+				continue
+			}
+
+			namespace := route.GetNamespace()
+			if backendRef.Namespace != nil && *backendRef.Namespace != "" {
+				namespace = *backendRef.Namespace
+			}
+			serviceNames.Insert(types.NamespacedName{Name: backendRef.Name, Namespace: namespace})
+		}
+	}
+
+	return serviceNames, nil
+}
+
+// formatGatewayRouteID identifies a route across kinds and namespaces, since routes may reference services in
+// other namespaces (using a ReferenceGrant) and route names are only unique per kind.
+func formatGatewayRouteID(kind string, namespace string, name string) string {
+	return strings.Join([]string{kind, namespace, name}, gatewayRouteIDSep)
+}
+
+func formatGatewayRouteServiceIndexValue(serviceName types.NamespacedName) string {
+	return fmt.Sprintf("%s%s%s", serviceName.Namespace, gatewayRouteIDSep, serviceName.Name)
+}
+
+func parseReferenceGrantSpec(grant *unstructured.Unstructured) (referenceGrantSpec, error) {
+	spec := referenceGrantSpec{}
+	rawSpec, ok := grant.Object["spec"].(map[string]any)
+	if !ok {
+		return spec, nil
+	}
+
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(rawSpec, &spec)
+	if err != nil {
+		return referenceGrantSpec{}, err
+	}
+
+	return spec, nil
+}
+
+// referenceGrantNamespacesForRouteKind returns the namespaces from which a ReferenceGrant allows routes of the given
+// kind to refer to services in the ReferenceGrant's namespace.
+func referenceGrantNamespacesForRouteKind(grant *unstructured.Unstructured, routeKind string) (sets.Set[string], error) {
+	spec, err := parseReferenceGrantSpec(grant)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := sets.Set[string]{}
+	if !lo.ContainsBy(spec.To, func(to referenceGrantTo) bool { return to.Group == "" && to.Kind == serviceKind }) {
+		return namespaces, nil
+	}
+
+	for _, from := range spec.From {
+		if from.Group == GatewayAPIGroup && from.Kind == routeKind {
+			namespaces.Insert(from.Namespace)
+		}
+	}
+
+	return namespaces, nil
+}
+
+// referenceGrantPermitsRoute returns whether a ReferenceGrant allows a route of the given kind and namespace to refer
+// to a service in the ReferenceGrant's namespace.
+func referenceGrantPermitsRoute(grant *unstructured.Unstructured, routeKind string, routeNamespace string, serviceName string) (bool, error) {
+	spec, err := parseReferenceGrantSpec(grant)
+	if err != nil {
+		return false, err
+	}
+
+	fromRoute := lo.ContainsBy(spec.From, func(from referenceGrantFrom) bool {
+		return from.Group == GatewayAPIGroup && from.Kind == routeKind && from.Namespace == routeNamespace
+	})
+	toService := lo.ContainsBy(spec.To, func(to referenceGrantTo) bool {
+		return to.Group == "" && to.Kind == serviceKind && (to.Name == nil || *to.Name == "" || *to.Name == serviceName)
+	})
+
+	return fromRoute && toService, nil
+}
@@ -0,0 +1,70 @@
+package external_traffic
+
+import (
+	"github.com/stretchr/testify/suite"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"testing"
+)
+
+type GatewayRoutesTestSuite struct {
+	suite.Suite
+}
+
+func newHTTPRoute(namespace string, name string, backendRefs ...map[string]any) *unstructured.Unstructured {
+	refs := make([]any, 0)
+	for _, ref := range backendRefs {
+		refs = append(refs, ref)
+	}
+
+	route := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{
+			"parentRefs": []any{map[string]any{"name": "gateway"}},
+			"rules": []any{
+				map[string]any{"backendRefs": refs},
+			},
+		},
+	}}
+	route.SetAPIVersion(GatewayAPIGroup + "/v1")
+	route.SetKind(HTTPRouteKind)
+	route.SetNamespace(namespace)
+	route.SetName(name)
+	return route
+}
+
+func (s *GatewayRoutesTestSuite) TestServiceNamesFromGatewayRoute() {
+	route := newHTTPRoute("routes-ns",
+		"my-route",
+		map[string]any{"name": "same-ns-svc", "port": int64(80)},
+		map[string]any{"name": "other-ns-svc", "namespace": "other-ns", "port": int64(8080)},
+		map[string]any{"name": "explicit-kind-svc", "group": "", "kind": "Service"},
+		map[string]any{"name": "not-a-svc", "group": "example.com", "kind": "Bucket"},
+	)
+
+	services, err := serviceNamesFromGatewayRoute(route)
+	s.Require().NoError(err)
+	s.Require().Equal(sets.New(
+		types.NamespacedName{Name: "same-ns-svc", Namespace: "routes-ns"},
+		types.NamespacedName{Name: "other-ns-svc", Namespace: "other-ns"},
+		types.NamespacedName{Name: "explicit-kind-svc", Namespace: "routes-ns"},
+	), services)
+}
+
+func (s *GatewayRoutesTestSuite) TestServiceNamesFromGatewayRoute_NoSpec() {
+	route := &unstructured.Unstructured{Object: map[string]any{}}
+	route.SetNamespace("routes-ns")
+
+	services, err := serviceNamesFromGatewayRoute(route)
+	s.Require().NoError(err)
+	s.Require().Empty(services)
+}
+
+func (s *GatewayRoutesTestSuite) TestFormatGatewayRouteID() {
+	s.Require().Equal("GRPCRoute/routes-ns/my-route", formatGatewayRouteID(GRPCRouteKind, "routes-ns", "my-route"))
+	s.Require().Equal("other-ns/my-svc", formatGatewayRouteServiceIndexValue(types.NamespacedName{Name: "my-svc", Namespace: "other-ns"}))
+}
+
+func TestGatewayRoutesTestSuite(t *testing.T) {
+	suite.Run(t, new(GatewayRoutesTestSuite))
+}
@@ -0,0 +1,69 @@
+package external_traffic
+
+import (
+	"fmt"
+	v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IngressControllerConfig identifies the pods of the ingress controller (or Gateway API implementation) that forward
+// external traffic to services referenced by Ingresses and routes. When set, external traffic policies created for
+// those services only allow traffic from the ingress controller, rather than from anywhere.
+type IngressControllerConfig struct {
+	PodSelector       *metav1.LabelSelector
+	NamespaceSelector *metav1.LabelSelector
+}
+
+// ParseIngressControllerConfig parses label selectors in the format accepted by kubectl (e.g. "app=ingress-nginx").
+// Empty strings leave the corresponding selector unset.
+func ParseIngressControllerConfig(podSelector string, namespaceSelector string) (IngressControllerConfig, error) {
+	config := IngressControllerConfig{}
+	if podSelector != "" {
+		selector, err := metav1.ParseToLabelSelector(podSelector)
+		if err != nil {
+			return IngressControllerConfig{}, fmt.Errorf("invalid ingress controller pod selector %q: %w", podSelector, err)
+		}
+		config.PodSelector = normalizeLabelSelector(selector)
+	}
+
+	if namespaceSelector != "" {
+		selector, err := metav1.ParseToLabelSelector(namespaceSelector)
+		if err != nil {
+			return IngressControllerConfig{}, fmt.Errorf("invalid ingress controller namespace selector %q: %w", namespaceSelector, err)
+		}
+		config.NamespaceSelector = normalizeLabelSelector(selector)
+	}
+
+	return config, nil
+}
+
+// normalizeLabelSelector drops empty fields, as the API server does, so that generated policies compare equal to
+// the ones read back from the cluster.
+func normalizeLabelSelector(selector *metav1.LabelSelector) *metav1.LabelSelector {
+	if len(selector.MatchLabels) == 0 {
+		selector.MatchLabels = nil
+	}
+	if len(selector.MatchExpressions) == 0 {
+		selector.MatchExpressions = nil
+	}
+	return selector
+}
+
+func (c IngressControllerConfig) IsSet() bool {
+	return c.PodSelector != nil || c.NamespaceSelector != nil
+}
+
+func (c IngressControllerConfig) asNetworkPolicyPeer() v1.NetworkPolicyPeer {
+	peer := v1.NetworkPolicyPeer{
+		PodSelector:       c.PodSelector,
+		NamespaceSelector: c.NamespaceSelector,
+	}
+
+	// A peer with only a pod selector matches pods in the policy's namespace, but the ingress controller usually
+	// runs in a namespace of its own.
+	if peer.NamespaceSelector == nil {
+		peer.NamespaceSelector = &metav1.LabelSelector{}
+	}
+
+	return peer
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/otterize/intents-operator/src/operator/api/v1alpha2"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
 	"github.com/otterize/intents-operator/src/shared/injectablerecorder"
 	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
@@ -14,6 +15,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"reflect"
@@ -40,16 +42,79 @@ type NetworkPolicyHandler struct {
 	injectablerecorder.InjectableRecorder
 	enabled                                bool
 	createEvenIfNoPreexistingNetworkPolicy bool
+	gatewayRouteReader                     client.Reader
+	gatewayRouteKinds                      []schema.GroupVersionKind
+	referenceGrantKind                     *schema.GroupVersionKind
+	ingressControllerConfig                IngressControllerConfig
+}
+
+// serviceReferrers holds the Ingresses and Gateway API routes that refer to a service, and as a result
+// allow traffic from outside the cluster to reach it.
+type serviceReferrers struct {
+	ingressList   *v1.IngressList
+	gatewayRoutes []unstructured.Unstructured
+}
+
+func (s *serviceReferrers) isEmpty() bool {
+	return len(s.ingressList.Items) == 0 && len(s.gatewayRoutes) == 0
 }
 
 func NewNetworkPolicyHandler(client client.Client, scheme *runtime.Scheme, enabled bool, createEvenIfNoPreexistingNetworkPolicy bool) *NetworkPolicyHandler {
 	return &NetworkPolicyHandler{client: client, scheme: scheme, enabled: enabled, createEvenIfNoPreexistingNetworkPolicy: createEvenIfNoPreexistingNetworkPolicy}
 }
 
+// SetGatewayRouteKinds sets the Gateway API route kinds that should be considered when looking for routes that refer
+// to a service, and the ReferenceGrant kind (nil if not installed) that allows routes to refer to services in other
+// namespaces. Should be called with the kinds returned by DetectGatewayRouteKinds and DetectReferenceGrantKind, before
+// the manager is started. Routes and ReferenceGrants are unstructured, which the manager's client does not cache, so
+// they are read using the given reader (the manager's cache).
+func (r *NetworkPolicyHandler) SetGatewayRouteKinds(reader client.Reader, kinds []schema.GroupVersionKind, referenceGrantKind *schema.GroupVersionKind) {
+	r.gatewayRouteReader = reader
+	r.gatewayRouteKinds = kinds
+	r.referenceGrantKind = referenceGrantKind
+}
+
+// SetIngressControllerConfig limits external traffic to services that are only exposed through Ingresses or routes
+// to the configured ingress controller pods.
+func (r *NetworkPolicyHandler) SetIngressControllerConfig(config IngressControllerConfig) {
+	r.ingressControllerConfig = config
+}
+
+// externalTrafficPeers returns the peers that should be allowed to reach the service. A nil result means traffic is
+// allowed from anywhere. Services exposed directly (LoadBalancer or NodePort) accept traffic from anywhere, unless
+// loadBalancerSourceRanges are set and externalTrafficPolicy is Local. With externalTrafficPolicy: Cluster the source IP
+// is replaced with a node IP before traffic reaches the pods, so source ranges can't be enforced by network policies.
+// Services exposed only through Ingresses or routes accept traffic from the ingress controller, if configured.
+func (r *NetworkPolicyHandler) externalTrafficPeers(svc *corev1.Service, referrers *serviceReferrers) []v1.NetworkPolicyPeer {
+	peers := make([]v1.NetworkPolicyPeer, 0)
+	if svc.Spec.Type == corev1.ServiceTypeNodePort {
+		return nil
+	}
+
+	if svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		if len(svc.Spec.LoadBalancerSourceRanges) == 0 || svc.Spec.ExternalTrafficPolicy != corev1.ServiceExternalTrafficPolicyTypeLocal {
+			return nil
+		}
+		for _, sourceRange := range svc.Spec.LoadBalancerSourceRanges {
+			peers = append(peers, v1.NetworkPolicyPeer{IPBlock: &v1.IPBlock{CIDR: sourceRange}})
+		}
+	}
+
+	if !referrers.isEmpty() {
+		if !r.ingressControllerConfig.IsSet() {
+			return nil
+		}
+		peers = append(peers, r.ingressControllerConfig.asNetworkPolicyPeer())
+	}
+
+	return peers
+}
+
 func (r *NetworkPolicyHandler) createOrUpdateNetworkPolicy(
-	ctx context.Context, endpoints *corev1.Endpoints, owner *corev1.Service, otterizeServiceName string, selector metav1.LabelSelector, ingressList *v1.IngressList, successMsg string) error {
+	ctx context.Context, endpoints *corev1.Endpoints, owner *corev1.Service, otterizeServiceName string, selector metav1.LabelSelector, referrers *serviceReferrers, successMsg string) error {
 	policyName := r.formatPolicyName(endpoints.Name)
-	newPolicy := buildNetworkPolicyObjectForEndpoints(endpoints, otterizeServiceName, selector, ingressList, policyName)
+	peers := r.externalTrafficPeers(owner, referrers)
+	newPolicy := buildNetworkPolicyObjectForEndpoints(endpoints, otterizeServiceName, selector, referrers, peers, policyName)
 	err := controllerutil.SetOwnerReference(owner, newPolicy, r.scheme)
 	if err != nil {
 		return err
@@ -106,19 +171,25 @@ func (r *NetworkPolicyHandler) arePoliciesEqual(existingPolicy *v1.NetworkPolicy
 }
 
 func buildNetworkPolicyObjectForEndpoints(
-	endpoints *corev1.Endpoints, otterizeServiceName string, selector metav1.LabelSelector, ingressList *v1.IngressList, policyName string) *v1.NetworkPolicy {
+	endpoints *corev1.Endpoints, otterizeServiceName string, selector metav1.LabelSelector, referrers *serviceReferrers, peers []v1.NetworkPolicyPeer, policyName string) *v1.NetworkPolicy {
 	serviceSpecCopy := endpoints.Subsets
 
 	annotations := map[string]string{
 		v1alpha2.OtterizeCreatedForServiceAnnotation: endpoints.GetName(),
 	}
 
-	if len(ingressList.Items) != 0 {
-		annotations[v1alpha2.OtterizeCreatedForIngressAnnotation] = strings.Join(lo.Map(ingressList.Items, func(ingress v1.Ingress, _ int) string {
+	if len(referrers.ingressList.Items) != 0 {
+		annotations[v1alpha2.OtterizeCreatedForIngressAnnotation] = strings.Join(lo.Map(referrers.ingressList.Items, func(ingress v1.Ingress, _ int) string {
 			return ingress.Name
 		}), ",")
 	}
 
+	if len(referrers.gatewayRoutes) != 0 {
+		annotations[otterizev1alpha3.OtterizeCreatedForGatewayRouteAnnotation] = strings.Join(lo.Map(referrers.gatewayRoutes, func(route unstructured.Unstructured, _ int) string {
+			return formatGatewayRouteID(route.GetKind(), route.GetNamespace(), route.GetName())
+		}), ",")
+	}
+
 	netpol := &v1.NetworkPolicy{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      policyName,
@@ -132,7 +203,9 @@ func buildNetworkPolicyObjectForEndpoints(
 			PolicyTypes: []v1.PolicyType{v1.PolicyTypeIngress},
 			PodSelector: selector,
 			Ingress: []v1.NetworkPolicyIngressRule{
-				{},
+				{
+					From: peers,
+				},
 			},
 		},
 	}
@@ -283,14 +356,14 @@ func (r *NetworkPolicyHandler) handlePod(ctx context.Context, pod *corev1.Pod) e
 // HandleEndpoints
 // Every HandleX function goes through this function, and it handles this cases:
 // (1) Endpoints reconciler watch endpoints and call HandleEndpoints, which means it gets updates when Services are updated, or the pods backing them are updated.
-// (2) It receives handle requests from the IngressReconciler, when Ingresses are created, updated or deleted.
+// (2) It receives handle requests from the IngressReconciler and GatewayRouteReconciler, when Ingresses or routes are created, updated or deleted.
 // (3) It receives handle requests from the Intents NetworkPolicyReconciler, when Network Policies that apply intents
 //
 //	are created, updated or deleted. This means that if you create, update or delete intents, the corresponding
 //	external traffic policy will be created (if there were no other intents affecting the service before then) or
 //	deleted (if no intents network policies refer to the pods backing the service any longer).
 //
-//	 When HandleEndpoints is called, and the Service is of type LoadBalancer, NodePort, or is referenced by an Ingress,
+//	 When HandleEndpoints is called, and the Service is of type LoadBalancer, NodePort, or is referenced by an Ingress or a route,
 //		   it checks if the backing pods are affected by Otterize Intents Network Policies.
 //		   If so, and the reconciler is enabled, it will create network policies to allow external traffic to those pods.
 //		   If the Endpoints (= Services) update port, it will update the port specified in the corresponding network policy.
@@ -313,16 +386,23 @@ func (r *NetworkPolicyHandler) HandleEndpoints(ctx context.Context, endpoints *c
 	if err != nil {
 		return err
 	}
-	// If it's not a load balancer or a node port service, and the service is not referenced by any Ingress,
+
+	gatewayRoutes, err := r.getGatewayRoutesReferringToService(ctx, svc)
+	if err != nil {
+		return err
+	}
+
+	referrers := &serviceReferrers{ingressList: ingressList, gatewayRoutes: gatewayRoutes}
+	// If it's not a load balancer or a node port service, and the service is not referenced by any Ingress or route,
 	// then there's nothing we need to do.
-	if svc.Spec.Type != corev1.ServiceTypeLoadBalancer && svc.Spec.Type != corev1.ServiceTypeNodePort && len(ingressList.Items) == 0 {
+	if svc.Spec.Type != corev1.ServiceTypeLoadBalancer && svc.Spec.Type != corev1.ServiceTypeNodePort && referrers.isEmpty() {
 		return r.handlePolicyDelete(ctx, r.formatPolicyName(svc.Name), svc.Namespace)
 	}
 
-	return r.handleEndpointsWithIngressList(ctx, endpoints, ingressList)
+	return r.handleEndpointsWithReferrers(ctx, endpoints, referrers)
 }
 
-func (r *NetworkPolicyHandler) handleEndpointsWithIngressList(ctx context.Context, endpoints *corev1.Endpoints, ingressList *v1.IngressList) error {
+func (r *NetworkPolicyHandler) handleEndpointsWithReferrers(ctx context.Context, endpoints *corev1.Endpoints, referrers *serviceReferrers) error {
 
 	addresses := r.getAddressesFromEndpoints(endpoints)
 	foundOtterizeNetpolsAffectingPods := false
@@ -370,7 +450,7 @@ func (r *NetworkPolicyHandler) handleEndpointsWithIngressList(ctx context.Contex
 
 		if len(netpolList.Items) == 0 && len(svcNetpolList.Items) == 0 {
 			if r.createEvenIfNoPreexistingNetworkPolicy {
-				err := r.handleNetpolsForOtterizeServiceWithoutIntents(ctx, endpoints, serverLabel, ingressList)
+				err := r.handleNetpolsForOtterizeServiceWithoutIntents(ctx, endpoints, serverLabel, referrers)
 				if err != nil {
 					return err
 				}
@@ -383,7 +463,7 @@ func (r *NetworkPolicyHandler) handleEndpointsWithIngressList(ctx context.Contex
 		netpolSlice = append(netpolSlice, svcNetpolList.Items...)
 
 		foundOtterizeNetpolsAffectingPods = true
-		err = r.handleNetpolsForOtterizeService(ctx, endpoints, serverLabel, ingressList, netpolSlice)
+		err = r.handleNetpolsForOtterizeService(ctx, endpoints, serverLabel, referrers, netpolSlice)
 		if err != nil {
 			return err
 		}
@@ -440,6 +520,80 @@ func (r *NetworkPolicyHandler) getIngressRefersToService(ctx context.Context, sv
 
 }
 
+func (r *NetworkPolicyHandler) getGatewayRoutesReferringToService(ctx context.Context, svc *corev1.Service) ([]unstructured.Unstructured, error) {
+	routes := make([]unstructured.Unstructured, 0)
+	var referenceGrants []unstructured.Unstructured
+	for _, routeKind := range r.gatewayRouteKinds {
+		routeList := &unstructured.UnstructuredList{}
+		routeList.SetGroupVersionKind(routeKind.GroupVersion().WithKind(routeKind.Kind + "List"))
+		// Routes may refer to services in other namespaces, so the index holds namespaced service names
+		// and the lookup is not limited to the service's namespace.
+		err := r.gatewayRouteReader.List(
+			ctx, routeList,
+			&client.MatchingFields{otterizev1alpha3.GatewayRouteServiceNamesIndexField: formatGatewayRouteServiceIndexValue(
+				types.NamespacedName{Name: svc.Name, Namespace: svc.Namespace})})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, route := range routeList.Items {
+			// Make sure the kind is set even if the list returned items without it, as it is used to identify the route
+			route.SetGroupVersionKind(routeKind)
+			if route.GetNamespace() != svc.Namespace {
+				// Routes may only send traffic to services in other namespaces if a ReferenceGrant in the service's
+				// namespace allows it - otherwise, anyone who can create a route could open a service to external traffic.
+				if referenceGrants == nil {
+					referenceGrants, err = r.getReferenceGrants(ctx, svc.Namespace)
+					if err != nil {
+						return nil, err
+					}
+				}
+				permitted, err := referenceGrantsPermitRoute(referenceGrants, routeKind.Kind, route.GetNamespace(), svc.Name)
+				if err != nil {
+					return nil, err
+				}
+				if !permitted {
+					logrus.Debugf("%s %s/%s refers to service %s/%s, but no ReferenceGrant allows it, ignoring",
+						routeKind.Kind, route.GetNamespace(), route.GetName(), svc.Namespace, svc.Name)
+					continue
+				}
+			}
+			routes = append(routes, route)
+		}
+	}
+
+	return routes, nil
+}
+
+func (r *NetworkPolicyHandler) getReferenceGrants(ctx context.Context, namespace string) ([]unstructured.Unstructured, error) {
+	if r.referenceGrantKind == nil {
+		return make([]unstructured.Unstructured, 0), nil
+	}
+
+	grantList := &unstructured.UnstructuredList{}
+	grantList.SetGroupVersionKind(r.referenceGrantKind.GroupVersion().WithKind(r.referenceGrantKind.Kind + "List"))
+	err := r.gatewayRouteReader.List(ctx, grantList, client.InNamespace(namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	return grantList.Items, nil
+}
+
+func referenceGrantsPermitRoute(grants []unstructured.Unstructured, routeKind string, routeNamespace string, serviceName string) (bool, error) {
+	for _, grant := range grants {
+		permitted, err := referenceGrantPermitsRoute(&grant, routeKind, routeNamespace, serviceName)
+		if err != nil {
+			return false, err
+		}
+		if permitted {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
 func (r *NetworkPolicyHandler) handlePolicyDelete(ctx context.Context, policyName string, policyNamespace string) error {
 	policy := &v1.NetworkPolicy{}
 	err := r.client.Get(ctx, types.NamespacedName{Name: policyName, Namespace: policyNamespace}, policy)
@@ -478,7 +632,7 @@ func (r *NetworkPolicyHandler) handlePolicyDelete(ctx context.Context, policyNam
 	return nil
 }
 
-func (r *NetworkPolicyHandler) handleNetpolsForOtterizeService(ctx context.Context, endpoints *corev1.Endpoints, otterizeServiceName string, ingressList *v1.IngressList, netpolList []v1.NetworkPolicy) error {
+func (r *NetworkPolicyHandler) handleNetpolsForOtterizeService(ctx context.Context, endpoints *corev1.Endpoints, otterizeServiceName string, referrers *serviceReferrers, netpolList []v1.NetworkPolicy) error {
 	svc := &corev1.Service{}
 	err := r.client.Get(ctx, types.NamespacedName{Name: endpoints.Name, Namespace: endpoints.Namespace}, svc)
 	if err != nil {
@@ -497,7 +651,7 @@ func (r *NetworkPolicyHandler) handleNetpolsForOtterizeService(ctx context.Conte
 
 	for _, netpol := range netpolList {
 		successMsg := fmt.Sprintf(successMsgNetpolCreate, endpoints.GetName(), netpol.GetName())
-		err = r.createOrUpdateNetworkPolicy(ctx, endpoints, svc, otterizeServiceName, netpol.Spec.PodSelector, ingressList, successMsg)
+		err = r.createOrUpdateNetworkPolicy(ctx, endpoints, svc, otterizeServiceName, netpol.Spec.PodSelector, referrers, successMsg)
 
 		if err != nil {
 			return err
@@ -506,7 +660,7 @@ func (r *NetworkPolicyHandler) handleNetpolsForOtterizeService(ctx context.Conte
 	return nil
 }
 
-func (r *NetworkPolicyHandler) handleNetpolsForOtterizeServiceWithoutIntents(ctx context.Context, endpoints *corev1.Endpoints, otterizeServiceName string, ingressList *v1.IngressList) error {
+func (r *NetworkPolicyHandler) handleNetpolsForOtterizeServiceWithoutIntents(ctx context.Context, endpoints *corev1.Endpoints, otterizeServiceName string, referrers *serviceReferrers) error {
 	svc := &corev1.Service{}
 	err := r.client.Get(ctx, types.NamespacedName{Name: endpoints.Name, Namespace: endpoints.Namespace}, svc)
 	if err != nil {
@@ -523,7 +677,7 @@ func (r *NetworkPolicyHandler) handleNetpolsForOtterizeServiceWithoutIntents(ctx
 		return nil
 	}
 
-	err = r.createOrUpdateNetworkPolicy(ctx, endpoints, svc, otterizeServiceName, metav1.LabelSelector{MatchLabels: svc.Spec.Selector}, ingressList, fmt.Sprintf("created external traffic network policy for service '%s'", endpoints.GetName()))
+	err = r.createOrUpdateNetworkPolicy(ctx, endpoints, svc, otterizeServiceName, metav1.LabelSelector{MatchLabels: svc.Spec.Selector}, referrers, fmt.Sprintf("created external traffic network policy for service '%s'", endpoints.GetName()))
 	if err != nil {
 		return err
 	}
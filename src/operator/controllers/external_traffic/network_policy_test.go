@@ -6,6 +6,7 @@ import (
 	"github.com/otterize/intents-operator/src/shared/testbase"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -167,6 +168,82 @@ func (s *NetworkPolicyHandlerTestSuite) TestNetworkPolicyHandler_HandleBeforeAcc
 	s.Require().NoError(err)
 }
 
+func (s *NetworkPolicyHandlerTestSuite) TestExternalTrafficPeers_LoadBalancerWithoutSourceRanges_AllowsAnywhere() {
+	svc := &corev1.Service{Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer}}
+	referrers := &serviceReferrers{ingressList: &v1.IngressList{}}
+
+	s.Require().Nil(s.handler.externalTrafficPeers(svc, referrers))
+}
+
+func (s *NetworkPolicyHandlerTestSuite) TestExternalTrafficPeers_LoadBalancerWithSourceRanges_IPBlocks() {
+	svc := &corev1.Service{Spec: corev1.ServiceSpec{
+		Type:                     corev1.ServiceTypeLoadBalancer,
+		LoadBalancerSourceRanges: []string{"10.0.0.0/8", "192.168.1.0/24"},
+		ExternalTrafficPolicy:    corev1.ServiceExternalTrafficPolicyTypeLocal,
+	}}
+	referrers := &serviceReferrers{ingressList: &v1.IngressList{}}
+
+	s.Require().Equal([]v1.NetworkPolicyPeer{
+		{IPBlock: &v1.IPBlock{CIDR: "10.0.0.0/8"}},
+		{IPBlock: &v1.IPBlock{CIDR: "192.168.1.0/24"}},
+	}, s.handler.externalTrafficPeers(svc, referrers))
+}
+
+func (s *NetworkPolicyHandlerTestSuite) TestExternalTrafficPeers_LoadBalancerWithSourceRangesAndClusterTrafficPolicy_AllowsAnywhere() {
+	svc := &corev1.Service{Spec: corev1.ServiceSpec{
+		Type:                     corev1.ServiceTypeLoadBalancer,
+		LoadBalancerSourceRanges: []string{"10.0.0.0/8"},
+		ExternalTrafficPolicy:    corev1.ServiceExternalTrafficPolicyTypeCluster,
+	}}
+	referrers := &serviceReferrers{ingressList: &v1.IngressList{}}
+
+	s.Require().Nil(s.handler.externalTrafficPeers(svc, referrers))
+}
+
+func (s *NetworkPolicyHandlerTestSuite) TestExternalTrafficPeers_IngressWithoutControllerConfig_AllowsAnywhere() {
+	svc := &corev1.Service{Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP}}
+	referrers := &serviceReferrers{ingressList: &v1.IngressList{Items: []v1.Ingress{{}}}}
+
+	s.Require().Nil(s.handler.externalTrafficPeers(svc, referrers))
+}
+
+func (s *NetworkPolicyHandlerTestSuite) TestExternalTrafficPeers_IngressWithControllerConfig_OnlyController() {
+	config, err := ParseIngressControllerConfig("app.kubernetes.io/name=ingress-nginx", "")
+	s.Require().NoError(err)
+	s.handler.SetIngressControllerConfig(config)
+	svc := &corev1.Service{Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP}}
+	referrers := &serviceReferrers{ingressList: &v1.IngressList{Items: []v1.Ingress{{}}}}
+
+	s.Require().Equal([]v1.NetworkPolicyPeer{
+		{
+			PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app.kubernetes.io/name": "ingress-nginx"}},
+			NamespaceSelector: &metav1.LabelSelector{},
+		},
+	}, s.handler.externalTrafficPeers(svc, referrers))
+}
+
+func (s *NetworkPolicyHandlerTestSuite) TestExternalTrafficPeers_LoadBalancerWithSourceRangesAndIngress_BothAllowed() {
+	config, err := ParseIngressControllerConfig("", "kubernetes.io/metadata.name=ingress-nginx")
+	s.Require().NoError(err)
+	s.handler.SetIngressControllerConfig(config)
+	svc := &corev1.Service{Spec: corev1.ServiceSpec{
+		Type:                     corev1.ServiceTypeLoadBalancer,
+		LoadBalancerSourceRanges: []string{"10.0.0.0/8"},
+		ExternalTrafficPolicy:    corev1.ServiceExternalTrafficPolicyTypeLocal,
+	}}
+	referrers := &serviceReferrers{ingressList: &v1.IngressList{Items: []v1.Ingress{{}}}}
+
+	s.Require().Equal([]v1.NetworkPolicyPeer{
+		{IPBlock: &v1.IPBlock{CIDR: "10.0.0.0/8"}},
+		{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": "ingress-nginx"}}},
+	}, s.handler.externalTrafficPeers(svc, referrers))
+}
+
+func (s *NetworkPolicyHandlerTestSuite) TestParseIngressControllerConfig_InvalidSelector() {
+	_, err := ParseIngressControllerConfig("app in (", "")
+	s.Require().Error(err)
+}
+
 func TestNetworkPolicyHandlerTestSuite(t *testing.T) {
 	suite.Run(t, new(NetworkPolicyHandlerTestSuite))
 }
@@ -0,0 +1,636 @@
+package garbage_collection
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/istiopolicy"
+	"github.com/otterize/intents-operator/src/operator/controllers/kafkaacls"
+	"github.com/otterize/intents-operator/src/operator/controllers/linkerdpolicy"
+	"github.com/otterize/intents-operator/src/shared/awsagent"
+	"github.com/otterize/intents-operator/src/shared/serviceidresolver"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	networkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	"istio.io/client-go/pkg/apis/security/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	ArtifactTypeIstioPolicy             = "istio-authorization-policy"
+	ArtifactTypeKafkaACL                = "kafka-acl"
+	ArtifactTypeAWSPolicy               = "aws-iam-policy"
+	ArtifactTypeLinkerdPolicy           = "linkerd-policy"
+	ArtifactTypeIstioPeerAuthentication = "istio-peer-authentication"
+	ArtifactTypeIstioEgress             = "istio-egress"
+	ArtifactTypeKubernetesRBAC          = "kubernetes-rbac"
+	ArtifactTypeNamespaceDefaultDeny    = "namespace-default-deny-network-policy"
+)
+
+//+kubebuilder:rbac:groups="security.istio.io",resources=authorizationpolicies,verbs=get;list;watch;delete
+
+type istioPolicyCollector struct {
+	client client.Client
+}
+
+// NewIstioPolicyCollector collects authorization policies whose client no longer has intents to call the policy's server,
+// and consolidated policies none of whose clients still have intents to call the server.
+func NewIstioPolicyCollector(c client.Client) Collector {
+	return &istioPolicyCollector{client: c}
+}
+
+func (c *istioPolicyCollector) ArtifactType() string {
+	return ArtifactTypeIstioPolicy
+}
+
+func clientServerKey(formattedClient string, formattedServer string) string {
+	return fmt.Sprintf("%s|%s", formattedClient, formattedServer)
+}
+
+func (c *istioPolicyCollector) CollectOrphans(ctx context.Context, dryRun bool) (int, error) {
+	var intentsList otterizev1alpha3.ClientIntentsList
+	err := c.client.List(ctx, &intentsList)
+	if err != nil {
+		return 0, err
+	}
+
+	validPolicies := sets.New[string]()
+	for _, clientIntents := range intentsList.Items {
+		formattedClient := otterizev1alpha3.GetFormattedOtterizeIdentity(clientIntents.GetServiceName(), clientIntents.Namespace)
+		for _, intent := range clientIntents.GetCallsList() {
+			formattedServer := otterizev1alpha3.GetFormattedOtterizeIdentity(intent.GetTargetServerName(), intent.GetTargetServerNamespace(clientIntents.Namespace))
+			validPolicies.Insert(clientServerKey(formattedClient, formattedServer))
+		}
+	}
+
+	var policies v1beta1.AuthorizationPolicyList
+	err = c.client.List(ctx, &policies, client.HasLabels{otterizev1alpha3.OtterizeIstioClientAnnotationKey})
+	if err != nil {
+		return 0, err
+	}
+
+	var consolidatedPolicies v1beta1.AuthorizationPolicyList
+	err = c.client.List(ctx, &consolidatedPolicies, client.MatchingLabels{istiopolicy.OtterizeIstioConsolidatedPolicyLabelKey: "true"})
+	if err != nil {
+		return 0, err
+	}
+
+	orphanCount := 0
+	for _, policy := range append(policies.Items, consolidatedPolicies.Items...) {
+		if c.isPolicyInUse(policy, validPolicies) {
+			continue
+		}
+
+		orphanCount++
+		if dryRun {
+			logrus.Infof("Dry run, not removing orphaned Istio policy %s/%s", policy.Namespace, policy.Name)
+			continue
+		}
+
+		logrus.Infof("Removing orphaned Istio policy %s/%s", policy.Namespace, policy.Name)
+		err = c.client.Delete(ctx, policy)
+		if client.IgnoreNotFound(err) != nil {
+			return orphanCount, err
+		}
+	}
+
+	return orphanCount, nil
+}
+
+// isPolicyInUse returns whether the policy's client still has intents to call its server. Consolidated policies are in
+// use while any of their clients does; rules of clients that stopped calling the server are removed by reconciles.
+func (c *istioPolicyCollector) isPolicyInUse(policy *v1beta1.AuthorizationPolicy, validPolicies sets.Set[string]) bool {
+	formattedServer := policy.Labels[otterizev1alpha3.OtterizeServerLabelKey]
+	if policy.Labels[istiopolicy.OtterizeIstioConsolidatedPolicyLabelKey] != "true" {
+		return validPolicies.Has(clientServerKey(policy.Labels[otterizev1alpha3.OtterizeIstioClientAnnotationKey], formattedServer))
+	}
+
+	var clients []string
+	err := json.Unmarshal([]byte(policy.Annotations[istiopolicy.OtterizeIstioPolicyClientsAnnotation]), &clients)
+	if err != nil {
+		// The policy's clients are unknown, so leave it for reconciles to rebuild.
+		logrus.WithError(err).Warningf("Could not parse the clients of Istio policy %s/%s", policy.Namespace, policy.Name)
+		return true
+	}
+
+	return lo.ContainsBy(clients, func(formattedClient string) bool {
+		return validPolicies.Has(clientServerKey(formattedClient, formattedServer))
+	})
+}
+
+type kafkaACLCollector struct {
+	client          client.Client
+	serversStore    kafkaacls.ServersStore
+	serviceResolver serviceidresolver.ServiceResolver
+}
+
+// NewKafkaACLCollector collects ACLs on each configured Kafka server whose principal no longer has intents to call it.
+func NewKafkaACLCollector(c client.Client, serversStore kafkaacls.ServersStore) Collector {
+	return &kafkaACLCollector{client: c, serversStore: serversStore, serviceResolver: serviceidresolver.NewResolver(c)}
+}
+
+func (c *kafkaACLCollector) ArtifactType() string {
+	return ArtifactTypeKafkaACL
+}
+
+func (c *kafkaACLCollector) CollectOrphans(ctx context.Context, dryRun bool) (int, error) {
+	var intentsList otterizev1alpha3.ClientIntentsList
+	err := c.client.List(ctx, &intentsList)
+	if err != nil {
+		return 0, err
+	}
+
+	activeClientsByServer := make(map[types.NamespacedName][]types.NamespacedName)
+	intentsByClient := make(map[types.NamespacedName]otterizev1alpha3.ClientIntents)
+	for _, clientIntents := range intentsList.Items {
+		clientName := types.NamespacedName{Name: clientIntents.GetServiceName(), Namespace: clientIntents.Namespace}
+		intentsByClient[clientName] = clientIntents
+		for _, intent := range clientIntents.GetCallsList() {
+			if intent.Type != otterizev1alpha3.IntentTypeKafka {
+				continue
+			}
+			serverName := types.NamespacedName{Name: intent.GetTargetServerName(), Namespace: intent.GetTargetServerNamespace(clientIntents.Namespace)}
+			activeClientsByServer[serverName] = append(activeClientsByServer[serverName], clientName)
+		}
+	}
+
+	orphanCount := 0
+	err = c.serversStore.MapErr(func(serverName types.NamespacedName, config *otterizev1alpha3.KafkaServerConfig, _ otterizev1alpha3.TLSSource) error {
+		activeClients := lo.Uniq(activeClientsByServer[serverName])
+		clientServiceAccounts := make(map[types.NamespacedName]string)
+		if kafkaacls.PrincipalTemplateUsesServiceAccount(config.Spec.PrincipalTemplate) {
+			for _, activeClient := range activeClients {
+				pod, err := c.serviceResolver.ResolveClientIntentToPod(ctx, intentsByClient[activeClient])
+				if errors.Is(err, serviceidresolver.ErrPodNotFound) {
+					// Without the client's principal, its ACLs cannot be told apart from orphaned ones.
+					logrus.Infof("Skipping Kafka server %s, no pods found for resolving the service account of client %s", serverName, activeClient)
+					return nil
+				} else if err != nil {
+					return err
+				}
+				clientServiceAccounts[activeClient] = pod.Spec.ServiceAccountName
+			}
+		}
+
+		kafkaIntentsAdmin, err := c.serversStore.Get(serverName.Name, serverName.Namespace)
+		if err != nil {
+			return fmt.Errorf("failed to connect to Kafka server %s: %w", serverName, err)
+		}
+		defer kafkaIntentsAdmin.Close()
+
+		// The store's copy of the KafkaServerConfig is not updated when its status changes.
+		configName := types.NamespacedName{Name: config.Name, Namespace: config.Namespace}
+		currentConfig := &otterizev1alpha3.KafkaServerConfig{}
+		if err := c.client.Get(ctx, configName, currentConfig); err != nil {
+			return fmt.Errorf("failed getting KafkaServerConfig %s: %w", configName, err)
+		}
+
+		serverOrphanCount, err := kafkaIntentsAdmin.RemoveOrphanedClientACLs(currentConfig.Status.ManagedPrincipals, activeClients, clientServiceAccounts, dryRun)
+		orphanCount += serverOrphanCount
+		if err != nil {
+			return fmt.Errorf("failed removing orphaned ACLs from Kafka server %s: %w", serverName, err)
+		}
+		if dryRun {
+			return nil
+		}
+
+		activePrincipals := sets.New(lo.Map(activeClients, func(activeClient types.NamespacedName, _ int) string {
+			return kafkaIntentsAdmin.FormatClientPrincipal(activeClient.Name, activeClient.Namespace, clientServiceAccounts[activeClient])
+		})...)
+		return kafkaacls.PruneManagedPrincipals(ctx, c.client, configName, activePrincipals)
+	})
+
+	return orphanCount, err
+}
+
+type awsPolicyAgent interface {
+	ListOtterizePolicies(ctx context.Context) ([]awsagent.PolicyReference, error)
+	DeleteRolePolicy(ctx context.Context, namespace string, intentsName string) error
+}
+
+type awsPolicyCollector struct {
+	client            client.Client
+	agent             awsPolicyAgent
+	watchedNamespaces sets.Set[string]
+}
+
+// NewAWSPolicyCollector collects IAM policies of this cluster created for ClientIntents that no longer exist. When
+// watchedNamespaces is set, policies of other namespaces are left alone, since their intents are not visible to the
+// operator.
+func NewAWSPolicyCollector(c client.Client, agent awsPolicyAgent, watchedNamespaces []string) Collector {
+	return &awsPolicyCollector{
+		client:            c,
+		agent:             agent,
+		watchedNamespaces: sets.New(watchedNamespaces...),
+	}
+}
+
+func (c *awsPolicyCollector) ArtifactType() string {
+	return ArtifactTypeAWSPolicy
+}
+
+func (c *awsPolicyCollector) CollectOrphans(ctx context.Context, dryRun bool) (int, error) {
+	policies, err := c.agent.ListOtterizePolicies(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	orphanCount := 0
+	for _, policy := range policies {
+		if c.watchedNamespaces.Len() != 0 && !c.watchedNamespaces.Has(policy.Namespace) {
+			continue
+		}
+
+		err = c.client.Get(ctx, types.NamespacedName{Namespace: policy.Namespace, Name: policy.Name}, &otterizev1alpha3.ClientIntents{})
+		if err == nil {
+			continue
+		}
+		if !k8serrors.IsNotFound(err) {
+			return orphanCount, err
+		}
+
+		orphanCount++
+		if dryRun {
+			logrus.Infof("Dry run, not removing orphaned AWS policy for ClientIntents %s/%s", policy.Namespace, policy.Name)
+			continue
+		}
+
+		logrus.Infof("Removing orphaned AWS policy for ClientIntents %s/%s", policy.Namespace, policy.Name)
+		err = c.agent.DeleteRolePolicy(ctx, policy.Namespace, policy.Name)
+		if err != nil {
+			return orphanCount, err
+		}
+	}
+
+	return orphanCount, nil
+}
+
+// removeOrphan deletes an orphaned artifact, or only logs it when dryRun is set.
+func removeOrphan(ctx context.Context, c client.Client, obj client.Object, description string, dryRun bool) error {
+	if dryRun {
+		logrus.Infof("Dry run, not removing orphaned %s %s", description, client.ObjectKeyFromObject(obj))
+		return nil
+	}
+
+	logrus.Infof("Removing orphaned %s %s", description, client.ObjectKeyFromObject(obj))
+	return client.IgnoreNotFound(c.Delete(ctx, obj))
+}
+
+// listFormattedClients returns the formatted identities of the clients whose intents include an intent matching filter.
+func listFormattedClients(ctx context.Context, c client.Client, filter func(intent otterizev1alpha3.Intent) bool) (sets.Set[string], error) {
+	var intentsList otterizev1alpha3.ClientIntentsList
+	err := c.List(ctx, &intentsList)
+	if err != nil {
+		return nil, err
+	}
+
+	formattedClients := sets.New[string]()
+	for _, clientIntents := range intentsList.Items {
+		if lo.ContainsBy(clientIntents.GetCallsList(), filter) {
+			formattedClients.Insert(otterizev1alpha3.GetFormattedOtterizeIdentity(clientIntents.GetServiceName(), clientIntents.Namespace))
+		}
+	}
+
+	return formattedClients, nil
+}
+
+// removeClientOrphans removes the resources whose clientLabelKey label is not one of activeClients.
+func removeClientOrphans(
+	ctx context.Context,
+	c client.Client,
+	resources []client.Object,
+	description string,
+	clientLabelKey string,
+	activeClients sets.Set[string],
+	dryRun bool,
+) (int, error) {
+	orphanCount := 0
+	for _, resource := range resources {
+		if activeClients.Has(resource.GetLabels()[clientLabelKey]) {
+			continue
+		}
+
+		orphanCount++
+		err := removeOrphan(ctx, c, resource, description, dryRun)
+		if err != nil {
+			return orphanCount, err
+		}
+	}
+
+	return orphanCount, nil
+}
+
+type linkerdPolicyCollector struct {
+	client client.Client
+	kinds  linkerdpolicy.PolicyKinds
+}
+
+// NewLinkerdPolicyCollector collects the Linkerd resources of clients that no longer have intents to call their server,
+// and the Servers and default routes of servers none of whose clients still have authorization policies.
+func NewLinkerdPolicyCollector(c client.Client, kinds linkerdpolicy.PolicyKinds) Collector {
+	return &linkerdPolicyCollector{client: c, kinds: kinds}
+}
+
+func (c *linkerdPolicyCollector) ArtifactType() string {
+	return ArtifactTypeLinkerdPolicy
+}
+
+func (c *linkerdPolicyCollector) list(ctx context.Context, kind schema.GroupVersionKind, opts ...client.ListOption) ([]*unstructured.Unstructured, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(kind.GroupVersion().WithKind(kind.Kind + "List"))
+	err := c.client.List(ctx, list, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Map(list.Items, func(item unstructured.Unstructured, _ int) *unstructured.Unstructured {
+		resource := item.DeepCopy()
+		resource.SetGroupVersionKind(kind)
+		return resource
+	}), nil
+}
+
+func (c *linkerdPolicyCollector) CollectOrphans(ctx context.Context, dryRun bool) (int, error) {
+	var intentsList otterizev1alpha3.ClientIntentsList
+	err := c.client.List(ctx, &intentsList)
+	if err != nil {
+		return 0, err
+	}
+
+	validPolicies := sets.New[string]()
+	for _, clientIntents := range intentsList.Items {
+		formattedClient := otterizev1alpha3.GetFormattedOtterizeIdentity(clientIntents.GetServiceName(), clientIntents.Namespace)
+		for _, intent := range clientIntents.GetCallsList() {
+			if intent.Type != "" && intent.Type != otterizev1alpha3.IntentTypeHTTP {
+				continue
+			}
+			formattedServer := otterizev1alpha3.GetFormattedOtterizeIdentity(intent.GetTargetServerName(), intent.GetTargetServerNamespace(clientIntents.Namespace))
+			validPolicies.Insert(clientServerKey(formattedClient, formattedServer))
+		}
+	}
+
+	orphanCount := 0
+	serversInUse := sets.New[types.NamespacedName]()
+	for _, kind := range []schema.GroupVersionKind{c.kinds.AuthorizationPolicy, c.kinds.HTTPRoute, c.kinds.MeshTLSAuthentication} {
+		resources, err := c.list(ctx, kind, client.HasLabels{otterizev1alpha3.OtterizeLinkerdClientLabelKey})
+		if err != nil {
+			return orphanCount, err
+		}
+
+		for _, resource := range resources {
+			formattedServer := resource.GetLabels()[otterizev1alpha3.OtterizeServerLabelKey]
+			if validPolicies.Has(clientServerKey(resource.GetLabels()[otterizev1alpha3.OtterizeLinkerdClientLabelKey], formattedServer)) {
+				if kind == c.kinds.AuthorizationPolicy {
+					serversInUse.Insert(types.NamespacedName{Namespace: resource.GetNamespace(), Name: formattedServer})
+				}
+				continue
+			}
+
+			orphanCount++
+			err = removeOrphan(ctx, c.client, resource, "Linkerd "+kind.Kind, dryRun)
+			if err != nil {
+				return orphanCount, err
+			}
+		}
+	}
+
+	// Servers deny traffic no authorization policy allows, so they are only kept while a client is allowed access.
+	for _, kind := range []schema.GroupVersionKind{c.kinds.Server, c.kinds.HTTPRoute} {
+		resources, err := c.list(ctx, kind, client.HasLabels{otterizev1alpha3.OtterizeLinkerdServerLabelKey})
+		if err != nil {
+			return orphanCount, err
+		}
+
+		for _, resource := range resources {
+			if serversInUse.Has(types.NamespacedName{Namespace: resource.GetNamespace(), Name: resource.GetLabels()[otterizev1alpha3.OtterizeLinkerdServerLabelKey]}) {
+				continue
+			}
+
+			orphanCount++
+			err = removeOrphan(ctx, c.client, resource, "Linkerd "+kind.Kind, dryRun)
+			if err != nil {
+				return orphanCount, err
+			}
+		}
+	}
+
+	return orphanCount, nil
+}
+
+type peerAuthenticationCollector struct {
+	client client.Client
+}
+
+// NewPeerAuthenticationCollector collects the peer authentications of servers that are no longer protected services.
+func NewPeerAuthenticationCollector(c client.Client) Collector {
+	return &peerAuthenticationCollector{client: c}
+}
+
+func (c *peerAuthenticationCollector) ArtifactType() string {
+	return ArtifactTypeIstioPeerAuthentication
+}
+
+func (c *peerAuthenticationCollector) CollectOrphans(ctx context.Context, dryRun bool) (int, error) {
+	var protectedServices otterizev1alpha3.ProtectedServiceList
+	err := c.client.List(ctx, &protectedServices)
+	if err != nil {
+		return 0, err
+	}
+
+	protectedServers := sets.New[types.NamespacedName]()
+	for _, protectedService := range protectedServices.Items {
+		if protectedService.DeletionTimestamp != nil {
+			continue
+		}
+		formattedServer := otterizev1alpha3.GetFormattedOtterizeIdentity(protectedService.Spec.Name, protectedService.Namespace)
+		protectedServers.Insert(types.NamespacedName{Namespace: protectedService.Namespace, Name: formattedServer})
+	}
+
+	var peerAuthentications v1beta1.PeerAuthenticationList
+	err = c.client.List(ctx, &peerAuthentications, client.HasLabels{otterizev1alpha3.OtterizeIstioPeerAuthenticationServerLabelKey})
+	if err != nil {
+		return 0, err
+	}
+
+	orphanCount := 0
+	for _, peerAuthentication := range peerAuthentications.Items {
+		formattedServer := peerAuthentication.Labels[otterizev1alpha3.OtterizeIstioPeerAuthenticationServerLabelKey]
+		if protectedServers.Has(types.NamespacedName{Namespace: peerAuthentication.Namespace, Name: formattedServer}) {
+			continue
+		}
+
+		orphanCount++
+		err = removeOrphan(ctx, c.client, peerAuthentication, "Istio peer authentication", dryRun)
+		if err != nil {
+			return orphanCount, err
+		}
+	}
+
+	return orphanCount, nil
+}
+
+type istioEgressCollector struct {
+	client client.Client
+}
+
+// NewIstioEgressCollector collects the Sidecars and ServiceEntries of clients that no longer have intents.
+func NewIstioEgressCollector(c client.Client) Collector {
+	return &istioEgressCollector{client: c}
+}
+
+func (c *istioEgressCollector) ArtifactType() string {
+	return ArtifactTypeIstioEgress
+}
+
+func (c *istioEgressCollector) CollectOrphans(ctx context.Context, dryRun bool) (int, error) {
+	// Every client's egress is restricted to the targets of its intents, whatever their type.
+	activeClients, err := listFormattedClients(ctx, c.client, func(_ otterizev1alpha3.Intent) bool { return true })
+	if err != nil {
+		return 0, err
+	}
+
+	clientLabel := client.HasLabels{otterizev1alpha3.OtterizeIstioEgressClientLabelKey}
+	var sidecars networkingv1beta1.SidecarList
+	err = c.client.List(ctx, &sidecars, clientLabel)
+	if err != nil {
+		return 0, err
+	}
+	var serviceEntries networkingv1beta1.ServiceEntryList
+	err = c.client.List(ctx, &serviceEntries, clientLabel)
+	if err != nil {
+		return 0, err
+	}
+
+	orphanCount, err := removeClientOrphans(ctx, c.client, lo.Map(sidecars.Items, func(sidecar *networkingv1beta1.Sidecar, _ int) client.Object { return sidecar }),
+		"Istio Sidecar", otterizev1alpha3.OtterizeIstioEgressClientLabelKey, activeClients, dryRun)
+	if err != nil {
+		return orphanCount, err
+	}
+
+	serviceEntryOrphanCount, err := removeClientOrphans(ctx, c.client, lo.Map(serviceEntries.Items, func(serviceEntry *networkingv1beta1.ServiceEntry, _ int) client.Object { return serviceEntry }),
+		"Istio ServiceEntry", otterizev1alpha3.OtterizeIstioEgressClientLabelKey, activeClients, dryRun)
+	return orphanCount + serviceEntryOrphanCount, err
+}
+
+type kubernetesRBACCollector struct {
+	client client.Client
+}
+
+// NewKubernetesRBACCollector collects the Roles, ClusterRoles and their bindings of clients that no longer have
+// kubernetes intents.
+func NewKubernetesRBACCollector(c client.Client) Collector {
+	return &kubernetesRBACCollector{client: c}
+}
+
+func (c *kubernetesRBACCollector) ArtifactType() string {
+	return ArtifactTypeKubernetesRBAC
+}
+
+func (c *kubernetesRBACCollector) CollectOrphans(ctx context.Context, dryRun bool) (int, error) {
+	activeClients, err := listFormattedClients(ctx, c.client, func(intent otterizev1alpha3.Intent) bool {
+		return intent.Type == otterizev1alpha3.IntentTypeKubernetes
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	clientLabel := client.HasLabels{otterizev1alpha3.OtterizeKubernetesRBACClientLabelKey}
+	var roleBindings rbacv1.RoleBindingList
+	if err := c.client.List(ctx, &roleBindings, clientLabel); err != nil {
+		return 0, err
+	}
+	var roles rbacv1.RoleList
+	if err := c.client.List(ctx, &roles, clientLabel); err != nil {
+		return 0, err
+	}
+	var clusterRoleBindings rbacv1.ClusterRoleBindingList
+	if err := c.client.List(ctx, &clusterRoleBindings, clientLabel); err != nil {
+		return 0, err
+	}
+	var clusterRoles rbacv1.ClusterRoleList
+	if err := c.client.List(ctx, &clusterRoles, clientLabel); err != nil {
+		return 0, err
+	}
+
+	// Bindings are removed before the roles they refer to.
+	resourcesByKind := []struct {
+		kind      string
+		resources []client.Object
+	}{
+		{kind: "RoleBinding", resources: lo.Map(roleBindings.Items, func(_ rbacv1.RoleBinding, i int) client.Object { return &roleBindings.Items[i] })},
+		{kind: "Role", resources: lo.Map(roles.Items, func(_ rbacv1.Role, i int) client.Object { return &roles.Items[i] })},
+		{kind: "ClusterRoleBinding", resources: lo.Map(clusterRoleBindings.Items, func(_ rbacv1.ClusterRoleBinding, i int) client.Object { return &clusterRoleBindings.Items[i] })},
+		{kind: "ClusterRole", resources: lo.Map(clusterRoles.Items, func(_ rbacv1.ClusterRole, i int) client.Object { return &clusterRoles.Items[i] })},
+	}
+
+	orphanCount := 0
+	for _, kindResources := range resourcesByKind {
+		kindOrphanCount, err := removeClientOrphans(ctx, c.client, kindResources.resources, kindResources.kind, otterizev1alpha3.OtterizeKubernetesRBACClientLabelKey, activeClients, dryRun)
+		orphanCount += kindOrphanCount
+		if err != nil {
+			return orphanCount, err
+		}
+	}
+
+	return orphanCount, nil
+}
+
+type namespaceDefaultDenyCollector struct {
+	client client.Client
+}
+
+// NewNamespaceDefaultDenyCollector collects namespace default deny network policies of namespaces that are no longer
+// annotated with intents.otterize.com/default-deny. Policies of namespaces with an invalid annotation are kept, just
+// like the NamespaceDefaultDenyReconciler keeps them.
+func NewNamespaceDefaultDenyCollector(c client.Client) Collector {
+	return &namespaceDefaultDenyCollector{client: c}
+}
+
+func (c *namespaceDefaultDenyCollector) ArtifactType() string {
+	return ArtifactTypeNamespaceDefaultDeny
+}
+
+func (c *namespaceDefaultDenyCollector) CollectOrphans(ctx context.Context, dryRun bool) (int, error) {
+	var policies networkingv1.NetworkPolicyList
+	err := c.client.List(ctx, &policies, client.MatchingLabels{otterizev1alpha3.OtterizeNetworkPolicyNamespaceDefaultDeny: "true"})
+	if err != nil {
+		return 0, err
+	}
+
+	orphanCount := 0
+	for i := range policies.Items {
+		policy := &policies.Items[i]
+		namespace := &corev1.Namespace{}
+		err = c.client.Get(ctx, types.NamespacedName{Name: policy.Namespace}, namespace)
+		if k8serrors.IsNotFound(err) {
+			// Network policies are deleted along with their namespace.
+			continue
+		}
+		if err != nil {
+			return orphanCount, err
+		}
+
+		policyTypes, err := otterizev1alpha3.GetNamespaceDefaultDenyPolicyTypes(namespace)
+		if err != nil || len(policyTypes) != 0 {
+			continue
+		}
+
+		orphanCount++
+		err = removeOrphan(ctx, c.client, policy, "namespace default deny network policy", dryRun)
+		if err != nil {
+			return orphanCount, err
+		}
+	}
+
+	return orphanCount, nil
+}
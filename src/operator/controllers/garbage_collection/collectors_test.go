@@ -0,0 +1,263 @@
+package garbage_collection
+
+import (
+	"context"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/istiopolicy"
+	"github.com/otterize/intents-operator/src/operator/controllers/linkerdpolicy"
+	"github.com/otterize/intents-operator/src/shared/testbase"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	networkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	"istio.io/client-go/pkg/apis/security/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"testing"
+)
+
+type IstioPolicyCollectorTestSuite struct {
+	testbase.MocksSuiteBase
+}
+
+func consolidatedPolicy(name string, formattedServer string, clientsAnnotation string) *v1beta1.AuthorizationPolicy {
+	return &v1beta1.AuthorizationPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "test-namespace",
+			Labels:      map[string]string{otterizev1alpha3.OtterizeServerLabelKey: formattedServer, istiopolicy.OtterizeIstioConsolidatedPolicyLabelKey: "true"},
+			Annotations: map[string]string{istiopolicy.OtterizeIstioPolicyClientsAnnotation: clientsAnnotation},
+		},
+	}
+}
+
+func (s *IstioPolicyCollectorTestSuite) TestConsolidatedPolicyCollectedWhenNoClientCallsServer() {
+	formattedClient := otterizev1alpha3.GetFormattedOtterizeIdentity("client", "test-namespace")
+	formattedServer := otterizev1alpha3.GetFormattedOtterizeIdentity("server", "test-namespace")
+	formattedOtherServer := otterizev1alpha3.GetFormattedOtterizeIdentity("other-server", "test-namespace")
+	clientsAnnotation := `["` + formattedClient + `"]`
+	inUse := consolidatedPolicy("authorization-policy-to-server", formattedServer, clientsAnnotation)
+	orphaned := consolidatedPolicy("authorization-policy-to-other-server", formattedOtherServer, clientsAnnotation)
+	unparsable := consolidatedPolicy("authorization-policy-to-unknown", formattedOtherServer, "")
+
+	s.Client.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&otterizev1alpha3.ClientIntentsList{})).DoAndReturn(
+		func(_ context.Context, list *otterizev1alpha3.ClientIntentsList, _ ...client.ListOption) error {
+			list.Items = []otterizev1alpha3.ClientIntents{{
+				ObjectMeta: metav1.ObjectMeta{Name: "client-intents", Namespace: "test-namespace"},
+				Spec: &otterizev1alpha3.IntentsSpec{
+					Service: otterizev1alpha3.Service{Name: "client"},
+					Calls:   []otterizev1alpha3.Intent{{Name: "server"}},
+				},
+			}}
+			return nil
+		})
+	s.Client.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&v1beta1.AuthorizationPolicyList{}), client.HasLabels{otterizev1alpha3.OtterizeIstioClientAnnotationKey}).Return(nil)
+	s.Client.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&v1beta1.AuthorizationPolicyList{}), client.MatchingLabels{istiopolicy.OtterizeIstioConsolidatedPolicyLabelKey: "true"}).DoAndReturn(
+		func(_ context.Context, list *v1beta1.AuthorizationPolicyList, _ ...client.ListOption) error {
+			list.Items = []*v1beta1.AuthorizationPolicy{inUse, orphaned, unparsable}
+			return nil
+		})
+	s.Client.EXPECT().Delete(gomock.Any(), orphaned).Return(nil)
+
+	orphanCount, err := NewIstioPolicyCollector(s.Client).CollectOrphans(context.Background(), false)
+	s.Require().NoError(err)
+	s.Require().Equal(1, orphanCount)
+}
+
+func TestIstioPolicyCollectorTestSuite(t *testing.T) {
+	suite.Run(t, new(IstioPolicyCollectorTestSuite))
+}
+
+type ResourceCollectorsTestSuite struct {
+	testbase.MocksSuiteBase
+	formattedClient      string
+	formattedOtherClient string
+	formattedServer      string
+}
+
+func (s *ResourceCollectorsTestSuite) SetupTest() {
+	s.MocksSuiteBase.SetupTest()
+	s.formattedClient = otterizev1alpha3.GetFormattedOtterizeIdentity("client", "test-namespace")
+	s.formattedOtherClient = otterizev1alpha3.GetFormattedOtterizeIdentity("other-client", "test-namespace")
+	s.formattedServer = otterizev1alpha3.GetFormattedOtterizeIdentity("server", "test-namespace")
+}
+
+func (s *ResourceCollectorsTestSuite) expectListIntents(intents ...otterizev1alpha3.Intent) {
+	s.Client.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&otterizev1alpha3.ClientIntentsList{})).DoAndReturn(
+		func(_ context.Context, list *otterizev1alpha3.ClientIntentsList, _ ...client.ListOption) error {
+			list.Items = []otterizev1alpha3.ClientIntents{{
+				ObjectMeta: metav1.ObjectMeta{Name: "client-intents", Namespace: "test-namespace"},
+				Spec: &otterizev1alpha3.IntentsSpec{
+					Service: otterizev1alpha3.Service{Name: "client"},
+					Calls:   intents,
+				},
+			}}
+			return nil
+		})
+}
+
+func (s *ResourceCollectorsTestSuite) TestLinkerdPolicyCollector() {
+	kinds := linkerdpolicy.PolicyKinds{
+		Server:                schema.GroupVersionKind{Group: linkerdpolicy.LinkerdPolicyAPIGroup, Version: "v1beta1", Kind: linkerdpolicy.ServerKind},
+		HTTPRoute:             schema.GroupVersionKind{Group: linkerdpolicy.LinkerdPolicyAPIGroup, Version: "v1beta3", Kind: linkerdpolicy.HTTPRouteKind},
+		AuthorizationPolicy:   schema.GroupVersionKind{Group: linkerdpolicy.LinkerdPolicyAPIGroup, Version: "v1alpha1", Kind: linkerdpolicy.AuthorizationPolicyKind},
+		MeshTLSAuthentication: schema.GroupVersionKind{Group: linkerdpolicy.LinkerdPolicyAPIGroup, Version: "v1alpha1", Kind: linkerdpolicy.MeshTLSAuthenticationKind},
+	}
+	formattedOtherServer := otterizev1alpha3.GetFormattedOtterizeIdentity("other-server", "test-namespace")
+	newResource := func(kind schema.GroupVersionKind, name string, labels map[string]string) unstructured.Unstructured {
+		resource := unstructured.Unstructured{}
+		resource.SetGroupVersionKind(kind)
+		resource.SetNamespace("test-namespace")
+		resource.SetName(name)
+		resource.SetLabels(labels)
+		return resource
+	}
+	inUsePolicy := newResource(kinds.AuthorizationPolicy, "in-use", map[string]string{
+		otterizev1alpha3.OtterizeLinkerdClientLabelKey: s.formattedClient,
+		otterizev1alpha3.OtterizeServerLabelKey:        s.formattedServer,
+	})
+	orphanedPolicy := newResource(kinds.AuthorizationPolicy, "orphaned", map[string]string{
+		otterizev1alpha3.OtterizeLinkerdClientLabelKey: s.formattedClient,
+		otterizev1alpha3.OtterizeServerLabelKey:        formattedOtherServer,
+	})
+	inUseServer := newResource(kinds.Server, "in-use", map[string]string{otterizev1alpha3.OtterizeLinkerdServerLabelKey: s.formattedServer})
+	orphanedServer := newResource(kinds.Server, "orphaned", map[string]string{otterizev1alpha3.OtterizeLinkerdServerLabelKey: formattedOtherServer})
+	resourcesByListKind := map[string][]unstructured.Unstructured{
+		kinds.AuthorizationPolicy.Kind + "List/" + otterizev1alpha3.OtterizeLinkerdClientLabelKey: {inUsePolicy, orphanedPolicy},
+		kinds.Server.Kind + "List/" + otterizev1alpha3.OtterizeLinkerdServerLabelKey:              {inUseServer, orphanedServer},
+	}
+
+	s.expectListIntents(otterizev1alpha3.Intent{Name: "server"})
+	s.Client.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&unstructured.UnstructuredList{}), gomock.Any()).DoAndReturn(
+		func(_ context.Context, list *unstructured.UnstructuredList, opts ...client.ListOption) error {
+			s.Require().Len(opts, 1)
+			list.Items = resourcesByListKind[list.GetKind()+"/"+opts[0].(client.HasLabels)[0]]
+			return nil
+		}).Times(5)
+	s.Client.EXPECT().Delete(gomock.Any(), &orphanedPolicy).Return(nil)
+	s.Client.EXPECT().Delete(gomock.Any(), &orphanedServer).Return(nil)
+
+	orphanCount, err := NewLinkerdPolicyCollector(s.Client, kinds).CollectOrphans(context.Background(), false)
+	s.Require().NoError(err)
+	s.Require().Equal(2, orphanCount)
+}
+
+func (s *ResourceCollectorsTestSuite) TestPeerAuthenticationCollector() {
+	inUse := &v1beta1.PeerAuthentication{ObjectMeta: metav1.ObjectMeta{Name: "in-use", Namespace: "test-namespace",
+		Labels: map[string]string{otterizev1alpha3.OtterizeIstioPeerAuthenticationServerLabelKey: s.formattedServer}}}
+	orphaned := &v1beta1.PeerAuthentication{ObjectMeta: metav1.ObjectMeta{Name: "orphaned", Namespace: "test-namespace",
+		Labels: map[string]string{otterizev1alpha3.OtterizeIstioPeerAuthenticationServerLabelKey: otterizev1alpha3.GetFormattedOtterizeIdentity("other-server", "test-namespace")}}}
+
+	s.Client.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&otterizev1alpha3.ProtectedServiceList{})).DoAndReturn(
+		func(_ context.Context, list *otterizev1alpha3.ProtectedServiceList, _ ...client.ListOption) error {
+			list.Items = []otterizev1alpha3.ProtectedService{{
+				ObjectMeta: metav1.ObjectMeta{Name: "protect-server", Namespace: "test-namespace"},
+				Spec:       otterizev1alpha3.ProtectedServiceSpec{Name: "server"},
+			}}
+			return nil
+		})
+	s.Client.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&v1beta1.PeerAuthenticationList{}), client.HasLabels{otterizev1alpha3.OtterizeIstioPeerAuthenticationServerLabelKey}).DoAndReturn(
+		func(_ context.Context, list *v1beta1.PeerAuthenticationList, _ ...client.ListOption) error {
+			list.Items = []*v1beta1.PeerAuthentication{inUse, orphaned}
+			return nil
+		})
+	s.Client.EXPECT().Delete(gomock.Any(), orphaned).Return(nil)
+
+	orphanCount, err := NewPeerAuthenticationCollector(s.Client).CollectOrphans(context.Background(), false)
+	s.Require().NoError(err)
+	s.Require().Equal(1, orphanCount)
+}
+
+func (s *ResourceCollectorsTestSuite) TestIstioEgressCollectorDryRun() {
+	clientLabel := client.HasLabels{otterizev1alpha3.OtterizeIstioEgressClientLabelKey}
+	s.expectListIntents(otterizev1alpha3.Intent{Name: "server"})
+	s.Client.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&networkingv1beta1.SidecarList{}), clientLabel).DoAndReturn(
+		func(_ context.Context, list *networkingv1beta1.SidecarList, _ ...client.ListOption) error {
+			list.Items = []*networkingv1beta1.Sidecar{
+				{ObjectMeta: metav1.ObjectMeta{Name: "client", Namespace: "test-namespace", Labels: map[string]string{otterizev1alpha3.OtterizeIstioEgressClientLabelKey: s.formattedClient}}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "other-client", Namespace: "test-namespace", Labels: map[string]string{otterizev1alpha3.OtterizeIstioEgressClientLabelKey: s.formattedOtherClient}}},
+			}
+			return nil
+		})
+	s.Client.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&networkingv1beta1.ServiceEntryList{}), clientLabel).DoAndReturn(
+		func(_ context.Context, list *networkingv1beta1.ServiceEntryList, _ ...client.ListOption) error {
+			list.Items = []*networkingv1beta1.ServiceEntry{
+				{ObjectMeta: metav1.ObjectMeta{Name: "other-client-internet", Namespace: "test-namespace", Labels: map[string]string{otterizev1alpha3.OtterizeIstioEgressClientLabelKey: s.formattedOtherClient}}},
+			}
+			return nil
+		})
+
+	// Dry run, so nothing is deleted
+	orphanCount, err := NewIstioEgressCollector(s.Client).CollectOrphans(context.Background(), true)
+	s.Require().NoError(err)
+	s.Require().Equal(2, orphanCount)
+}
+
+func (s *ResourceCollectorsTestSuite) TestKubernetesRBACCollector() {
+	clientLabel := client.HasLabels{otterizev1alpha3.OtterizeKubernetesRBACClientLabelKey}
+	inUseRoleBinding := rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "in-use", Namespace: "test-namespace",
+		Labels: map[string]string{otterizev1alpha3.OtterizeKubernetesRBACClientLabelKey: s.formattedClient}}}
+	orphanedClusterRole := rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "orphaned",
+		Labels: map[string]string{otterizev1alpha3.OtterizeKubernetesRBACClientLabelKey: s.formattedOtherClient}}}
+
+	s.expectListIntents(otterizev1alpha3.Intent{Name: "kubernetes", Type: otterizev1alpha3.IntentTypeKubernetes})
+	s.Client.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&rbacv1.RoleBindingList{}), clientLabel).DoAndReturn(
+		func(_ context.Context, list *rbacv1.RoleBindingList, _ ...client.ListOption) error {
+			list.Items = []rbacv1.RoleBinding{inUseRoleBinding}
+			return nil
+		})
+	s.Client.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&rbacv1.RoleList{}), clientLabel).Return(nil)
+	s.Client.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&rbacv1.ClusterRoleBindingList{}), clientLabel).Return(nil)
+	s.Client.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&rbacv1.ClusterRoleList{}), clientLabel).DoAndReturn(
+		func(_ context.Context, list *rbacv1.ClusterRoleList, _ ...client.ListOption) error {
+			list.Items = []rbacv1.ClusterRole{orphanedClusterRole}
+			return nil
+		})
+	s.Client.EXPECT().Delete(gomock.Any(), &orphanedClusterRole).Return(nil)
+
+	orphanCount, err := NewKubernetesRBACCollector(s.Client).CollectOrphans(context.Background(), false)
+	s.Require().NoError(err)
+	s.Require().Equal(1, orphanCount)
+}
+
+func (s *ResourceCollectorsTestSuite) TestNamespaceDefaultDenyCollector() {
+	newPolicy := func(namespace string) networkingv1.NetworkPolicy {
+		return networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{
+			Name:      otterizev1alpha3.OtterizeNamespaceDefaultDenyNetworkPolicyName,
+			Namespace: namespace,
+			Labels:    map[string]string{otterizev1alpha3.OtterizeNetworkPolicyNamespaceDefaultDeny: "true"},
+		}}
+	}
+	annotations := map[string]map[string]string{
+		"annotated":   {otterizev1alpha3.OtterizeNamespaceDefaultDenyAnnotation: "true"},
+		"invalid":     {otterizev1alpha3.OtterizeNamespaceDefaultDenyAnnotation: "ingres"},
+		"unannotated": nil,
+	}
+	orphaned := newPolicy("unannotated")
+
+	s.Client.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&networkingv1.NetworkPolicyList{}), client.MatchingLabels{otterizev1alpha3.OtterizeNetworkPolicyNamespaceDefaultDeny: "true"}).DoAndReturn(
+		func(_ context.Context, list *networkingv1.NetworkPolicyList, _ ...client.ListOption) error {
+			list.Items = []networkingv1.NetworkPolicy{newPolicy("annotated"), newPolicy("invalid"), orphaned}
+			return nil
+		})
+	s.Client.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf(&corev1.Namespace{})).DoAndReturn(
+		func(_ context.Context, key types.NamespacedName, namespace *corev1.Namespace, _ ...client.GetOption) error {
+			namespace.Name = key.Name
+			namespace.Annotations = annotations[key.Name]
+			return nil
+		}).Times(3)
+	s.Client.EXPECT().Delete(gomock.Any(), &orphaned).Return(nil)
+
+	orphanCount, err := NewNamespaceDefaultDenyCollector(s.Client).CollectOrphans(context.Background(), false)
+	s.Require().NoError(err)
+	s.Require().Equal(1, orphanCount)
+}
+
+func TestResourceCollectorsTestSuite(t *testing.T) {
+	suite.Run(t, new(ResourceCollectorsTestSuite))
+}
@@ -0,0 +1,139 @@
+package garbage_collection
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"time"
+)
+
+const artifactTypeLabel = "artifact_type"
+
+var (
+	orphanedArtifacts = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "intents_operator_gc_orphaned_artifacts",
+		Help: "Number of orphaned artifacts found by the last garbage collection run",
+	}, []string{artifactTypeLabel})
+	deletedArtifacts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "intents_operator_gc_deleted_artifacts_total",
+		Help: "Number of orphaned artifacts deleted by garbage collection",
+	}, []string{artifactTypeLabel})
+	collectionErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "intents_operator_gc_errors_total",
+		Help: "Number of garbage collection runs that failed for an artifact type",
+	}, []string{artifactTypeLabel})
+)
+
+func init() {
+	metrics.Registry.MustRegister(orphanedArtifacts, deletedArtifacts, collectionErrors)
+}
+
+// Collector finds artifacts of a single type whose owning intents no longer exist.
+type Collector interface {
+	ArtifactType() string
+	// CollectOrphans deletes orphaned artifacts, or only logs them when dryRun is set, and returns how many were found.
+	CollectOrphans(ctx context.Context, dryRun bool) (int, error)
+}
+
+type collectorFunc struct {
+	artifactType string
+	collect      func(ctx context.Context, dryRun bool) (int, error)
+}
+
+func (c *collectorFunc) ArtifactType() string {
+	return c.artifactType
+}
+
+func (c *collectorFunc) CollectOrphans(ctx context.Context, dryRun bool) (int, error) {
+	return c.collect(ctx, dryRun)
+}
+
+// NewCollector wraps an existing orphan removal function, such as a reconciler's RemoveOrphanNetworkPolicies.
+func NewCollector(artifactType string, collect func(ctx context.Context, dryRun bool) (int, error)) Collector {
+	return &collectorFunc{artifactType: artifactType, collect: collect}
+}
+
+// GarbageCollector periodically sweeps the cluster, and the external systems the operator configures, for artifacts
+// left behind by intents that were deleted while the operator was not running, or that reconciles failed to clean up.
+type GarbageCollector struct {
+	interval   time.Duration
+	dryRun     bool
+	collectors []registeredCollector
+}
+
+type registeredCollector struct {
+	Collector
+	dryRun bool
+}
+
+func NewGarbageCollector(interval time.Duration, dryRun bool) *GarbageCollector {
+	return &GarbageCollector{
+		interval: interval,
+		dryRun:   dryRun,
+	}
+}
+
+func (g *GarbageCollector) AddCollector(collector Collector) {
+	g.collectors = append(g.collectors, registeredCollector{Collector: collector, dryRun: g.dryRun})
+}
+
+// AddDryRunCollector adds a collector whose orphans are only logged and counted, even if the garbage collector is not
+// in dry run mode.
+func (g *GarbageCollector) AddDryRunCollector(collector Collector) {
+	g.collectors = append(g.collectors, registeredCollector{Collector: collector, dryRun: true})
+}
+
+// Start implements manager.Runnable. The first collection runs after one interval, once the initial reconciles have
+// had a chance to clean up after themselves.
+func (g *GarbageCollector) Start(ctx context.Context) error {
+	logrus.Infof("Starting garbage collection every %s, dry run: %t", g.interval, g.dryRun)
+	ticker := time.NewTicker(g.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			g.Collect(ctx)
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, so that only one operator replica deletes artifacts.
+func (g *GarbageCollector) NeedLeaderElection() bool {
+	return true
+}
+
+// Collect runs all collectors once. A failing collector does not prevent the others from running.
+func (g *GarbageCollector) Collect(ctx context.Context) {
+	orphansByType := make(map[string]int)
+	failedTypes := make(map[string]bool)
+	for _, collector := range g.collectors {
+		artifactType := collector.ArtifactType()
+		orphanCount, err := collector.CollectOrphans(ctx, collector.dryRun)
+		if err != nil {
+			logrus.WithError(err).Errorf("Garbage collection failed for %s", artifactType)
+			collectionErrors.WithLabelValues(artifactType).Inc()
+			failedTypes[artifactType] = true
+			continue
+		}
+
+		orphansByType[artifactType] += orphanCount
+		if !collector.dryRun {
+			deletedArtifacts.WithLabelValues(artifactType).Add(float64(orphanCount))
+		}
+	}
+
+	for artifactType, orphanCount := range orphansByType {
+		// A partial count would under-report, so keep the previous value for types that failed.
+		if failedTypes[artifactType] {
+			continue
+		}
+		orphanedArtifacts.WithLabelValues(artifactType).Set(float64(orphanCount))
+		if orphanCount != 0 {
+			logrus.Infof("Garbage collection found %d orphaned %s artifacts", orphanCount, artifactType)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package garbage_collection
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+	"testing"
+	"time"
+)
+
+type fakeCollector struct {
+	artifactType string
+	orphanCount  int
+	err          error
+	dryRunCalls  []bool
+}
+
+func (c *fakeCollector) ArtifactType() string {
+	return c.artifactType
+}
+
+func (c *fakeCollector) CollectOrphans(_ context.Context, dryRun bool) (int, error) {
+	c.dryRunCalls = append(c.dryRunCalls, dryRun)
+	return c.orphanCount, c.err
+}
+
+type GarbageCollectorTestSuite struct {
+	suite.Suite
+}
+
+func (s *GarbageCollectorTestSuite) SetupTest() {
+	orphanedArtifacts.Reset()
+	deletedArtifacts.Reset()
+	collectionErrors.Reset()
+}
+
+func (s *GarbageCollectorTestSuite) TestCollectSumsOrphansPerArtifactType() {
+	svcCollector := &fakeCollector{artifactType: "svc-egress-network-policy", orphanCount: 2}
+	svcWatcherCollector := &fakeCollector{artifactType: "svc-egress-network-policy", orphanCount: 1}
+	gc := NewGarbageCollector(time.Minute, false)
+	gc.AddCollector(svcCollector)
+	gc.AddCollector(svcWatcherCollector)
+
+	gc.Collect(context.Background())
+
+	s.Require().Equal([]bool{false}, svcCollector.dryRunCalls)
+	s.Require().Equal(float64(3), testutil.ToFloat64(orphanedArtifacts.WithLabelValues("svc-egress-network-policy")))
+	s.Require().Equal(float64(3), testutil.ToFloat64(deletedArtifacts.WithLabelValues("svc-egress-network-policy")))
+}
+
+func (s *GarbageCollectorTestSuite) TestDryRunDoesNotCountDeletions() {
+	collector := &fakeCollector{artifactType: ArtifactTypeIstioPolicy, orphanCount: 4}
+	gc := NewGarbageCollector(time.Minute, true)
+	gc.AddCollector(collector)
+
+	gc.Collect(context.Background())
+
+	s.Require().Equal([]bool{true}, collector.dryRunCalls)
+	s.Require().Equal(float64(4), testutil.ToFloat64(orphanedArtifacts.WithLabelValues(ArtifactTypeIstioPolicy)))
+	s.Require().Equal(0, testutil.CollectAndCount(deletedArtifacts))
+}
+
+func (s *GarbageCollectorTestSuite) TestFailingCollectorDoesNotStopOthers() {
+	failing := &fakeCollector{artifactType: ArtifactTypeKafkaACL, err: errors.New("broker unavailable")}
+	working := &fakeCollector{artifactType: ArtifactTypeAWSPolicy, orphanCount: 1}
+	gc := NewGarbageCollector(time.Minute, false)
+	gc.AddCollector(failing)
+	gc.AddCollector(working)
+
+	gc.Collect(context.Background())
+
+	s.Require().Len(working.dryRunCalls, 1)
+	s.Require().Equal(float64(1), testutil.ToFloat64(collectionErrors.WithLabelValues(ArtifactTypeKafkaACL)))
+	s.Require().Equal(float64(1), testutil.ToFloat64(orphanedArtifacts.WithLabelValues(ArtifactTypeAWSPolicy)))
+}
+
+func (s *GarbageCollectorTestSuite) TestDryRunCollectorDoesNotDelete() {
+	dryRunCollector := &fakeCollector{artifactType: ArtifactTypeAWSPolicy, orphanCount: 2}
+	collector := &fakeCollector{artifactType: ArtifactTypeIstioPolicy, orphanCount: 1}
+	gc := NewGarbageCollector(time.Minute, false)
+	gc.AddDryRunCollector(dryRunCollector)
+	gc.AddCollector(collector)
+
+	gc.Collect(context.Background())
+
+	s.Require().Equal([]bool{true}, dryRunCollector.dryRunCalls)
+	s.Require().Equal([]bool{false}, collector.dryRunCalls)
+	s.Require().Equal(float64(2), testutil.ToFloat64(orphanedArtifacts.WithLabelValues(ArtifactTypeAWSPolicy)))
+	s.Require().Equal(1, testutil.CollectAndCount(deletedArtifacts))
+}
+
+func TestGarbageCollectorTestSuite(t *testing.T) {
+	suite.Run(t, new(GarbageCollectorTestSuite))
+}
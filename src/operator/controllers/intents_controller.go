@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/always_allowed"
 	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers"
 	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/egress_network_policy"
 	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/exp"
@@ -27,7 +28,10 @@ import (
 	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/port_egress_network_policy"
 	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/port_network_policy"
 	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/protected_services"
+	"github.com/otterize/intents-operator/src/operator/controllers/istiopolicy"
 	"github.com/otterize/intents-operator/src/operator/controllers/kafkaacls"
+	"github.com/otterize/intents-operator/src/operator/controllers/policy_drift"
+	"github.com/otterize/intents-operator/src/operator/controllers/postgres"
 	"github.com/otterize/intents-operator/src/shared/initonce"
 	"github.com/otterize/intents-operator/src/shared/operator_cloud_client"
 	"github.com/otterize/intents-operator/src/shared/reconcilergroup"
@@ -36,12 +40,17 @@ import (
 	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"reflect"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
@@ -61,9 +70,13 @@ type EnforcementConfig struct {
 	EnableNetworkPolicy                  bool
 	EnableKafkaACL                       bool
 	EnableIstioPolicy                    bool
+	EnableLinkerdPolicy                  bool
 	EnableDatabaseReconciler             bool
 	EnableEgressNetworkPolicyReconcilers bool
 	EnableAWSPolicy                      bool
+	EnableKubernetesRBAC                 bool
+	EnableIstioEgressPolicy              bool
+	IstioConsolidatedPolicies            bool
 }
 
 // IntentsReconciler reconciles a Intents object
@@ -72,18 +85,26 @@ type IntentsReconciler struct {
 	client                  client.Client
 	initOnce                initonce.InitOnce
 	networkPolicyReconciler *ingress_network_policy.NetworkPolicyReconciler
+	// watchDatabaseServerConfigs is set when database intents are applied on DatabaseServerConfigs' servers, whose
+	// CRD is otherwise not required to be installed.
+	watchDatabaseServerConfigs bool
+	alwaysAllowed              always_allowed.Config
+	policyDriftHandlers        []*policy_drift.PolicyDriftHandler
 }
 
 func NewIntentsReconciler(
 	client client.Client,
 	scheme *runtime.Scheme,
 	kafkaServerStore kafkaacls.ServersStore,
+	kafkaIntentsAdminFactory kafkaacls.IntentsAdminFactoryFunction,
+	postgresAdminFactory postgres.AdminFactoryFunction,
 	networkPolicyReconciler *ingress_network_policy.NetworkPolicyReconciler,
 	portNetpolReconciler *port_network_policy.PortNetworkPolicyReconciler,
 	egressNetpolReconciler *egress_network_policy.EgressNetworkPolicyReconciler,
 	portEgressNetpolReconciler *port_egress_network_policy.PortEgressNetworkPolicyReconciler,
 	restrictToNamespaces []string,
 	enforcementConfig EnforcementConfig,
+	istioTrustDomain istiopolicy.TrustDomain,
 	otterizeClient operator_cloud_client.CloudClient,
 	operatorPodName string,
 	operatorPodNamespace string,
@@ -94,8 +115,8 @@ func NewIntentsReconciler(
 	reconcilers := []reconcilergroup.ReconcilerWithEvents{
 		intents_reconcilers.NewCRDValidatorReconciler(client, scheme),
 		intents_reconcilers.NewPodLabelReconciler(client, scheme),
-		intents_reconcilers.NewKafkaACLReconciler(client, scheme, kafkaServerStore, enforcementConfig.EnableKafkaACL, kafkaacls.NewKafkaIntentsAdmin, enforcementConfig.EnforcementDefaultState, operatorPodName, operatorPodNamespace, serviceIdResolver),
-		intents_reconcilers.NewIstioPolicyReconciler(client, scheme, restrictToNamespaces, enforcementConfig.EnableIstioPolicy, enforcementConfig.EnforcementDefaultState),
+		intents_reconcilers.NewKafkaACLReconciler(client, scheme, kafkaServerStore, enforcementConfig.EnableKafkaACL, kafkaIntentsAdminFactory, enforcementConfig.EnforcementDefaultState, operatorPodName, operatorPodNamespace, serviceIdResolver),
+		intents_reconcilers.NewIstioPolicyReconciler(client, scheme, restrictToNamespaces, enforcementConfig.EnableIstioPolicy, enforcementConfig.EnforcementDefaultState, istioTrustDomain, enforcementConfig.IstioConsolidatedPolicies),
 		networkPolicyReconciler,
 	}
 	reconcilers = append(reconcilers, additionalReconcilers...)
@@ -128,8 +149,16 @@ func NewIntentsReconciler(
 	}
 
 	if enforcementConfig.EnableDatabaseReconciler {
-		databaseReconciler := exp.NewDatabaseReconciler(client, scheme, otterizeClient)
-		intentsReconciler.group.AddToGroup(databaseReconciler)
+		postgresReconciler := intents_reconcilers.NewPostgresReconciler(client, scheme, postgresAdminFactory)
+		intentsReconciler.group.AddToGroup(postgresReconciler)
+		intentsReconciler.watchDatabaseServerConfigs = true
+
+		// The experimental DatabaseReconciler applies database intents through Otterize Cloud, so it requires a cloud
+		// client. Without one, the PostgresReconciler alone applies them.
+		if otterizeClient != nil {
+			databaseReconciler := exp.NewDatabaseReconciler(client, scheme, otterizeClient)
+			intentsReconciler.group.AddToGroup(databaseReconciler)
+		}
 	}
 
 	if enforcementConfig.EnableEgressNetworkPolicyReconcilers {
@@ -165,18 +194,40 @@ func (r *IntentsReconciler) intentsReconcilerInit(ctx context.Context) error {
 	return r.networkPolicyReconciler.CleanAllNamespaces(ctx)
 }
 
+// AddPolicyDriftHandler makes the controller watch the policies handled by driftHandler, so that changes made to
+// them outside the operator are reverted by reconciling the ClientIntents that own them. It must be called before
+// SetupWithManager.
+func (r *IntentsReconciler) AddPolicyDriftHandler(driftHandler *policy_drift.PolicyDriftHandler) {
+	r.policyDriftHandlers = append(r.policyDriftHandlers, driftHandler)
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *IntentsReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	err := ctrl.NewControllerManagedBy(mgr).
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
 		For(&otterizev1alpha3.ClientIntents{}).
 		WithOptions(controller.Options{RecoverPanic: lo.ToPtr(true)}).
 		Watches(&source.Kind{Type: &otterizev1alpha3.ProtectedService{}}, handler.EnqueueRequestsFromMapFunc(r.mapProtectedServiceToClientIntents)).
-		Complete(r)
+		Watches(&source.Kind{Type: &corev1.Namespace{}}, handler.EnqueueRequestsFromMapFunc(r.mapNamespaceToClientIntents), builder.WithPredicates(namespaceDefaultDenyChangedPredicate())).
+		Watches(&source.Kind{Type: &otterizev1alpha3.KafkaServerConfig{}}, handler.EnqueueRequestsFromMapFunc(r.mapKafkaServerConfigToClientIntents), builder.WithPredicates(kafkaListenerPortsChangedPredicate()))
+	if r.alwaysAllowed.HasPortAnnotations() {
+		controllerBuilder = controllerBuilder.Watches(&source.Kind{Type: &corev1.Pod{}}, handler.EnqueueRequestsFromMapFunc(r.mapPodToClientIntents), builder.WithPredicates(r.alwaysAllowed.PortAnnotationsChangedPredicate()))
+	}
+	if r.watchDatabaseServerConfigs {
+		controllerBuilder = controllerBuilder.Watches(&source.Kind{Type: &otterizev1alpha3.DatabaseServerConfig{}}, handler.EnqueueRequestsFromMapFunc(r.mapDatabaseServerConfigToClientIntents), builder.WithPredicates(predicate.GenerationChangedPredicate{}))
+	}
+	for _, driftHandler := range r.policyDriftHandlers {
+		controllerBuilder = controllerBuilder.Watches(&source.Kind{Type: driftHandler.Object()}, driftHandler)
+	}
+	err := controllerBuilder.Complete(r)
 	if err != nil {
 		return err
 	}
 
-	r.group.InjectRecorder(mgr.GetEventRecorderFor("intents-operator"))
+	recorder := mgr.GetEventRecorderFor("intents-operator")
+	r.group.InjectRecorder(recorder)
+	for _, driftHandler := range r.policyDriftHandlers {
+		driftHandler.InjectRecorder(recorder)
+	}
 
 	return nil
 }
@@ -189,6 +240,138 @@ func (r *IntentsReconciler) mapProtectedServiceToClientIntents(obj client.Object
 	return r.mapIntentsToRequests(intentsToReconcile)
 }
 
+// namespaceDefaultDenyChangedPredicate passes namespace updates that change the default deny annotation, which changes
+// whether the namespace's servers are protected.
+func namespaceDefaultDenyChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return e.ObjectOld.GetAnnotations()[otterizev1alpha3.OtterizeNamespaceDefaultDenyAnnotation] != e.ObjectNew.GetAnnotations()[otterizev1alpha3.OtterizeNamespaceDefaultDenyAnnotation]
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+func (r *IntentsReconciler) mapNamespaceToClientIntents(obj client.Object) []reconcile.Request {
+	namespace := obj.GetName()
+	logrus.Infof("Enqueueing client intents for servers in namespace %s due to default deny change", namespace)
+
+	var intentsList otterizev1alpha3.ClientIntentsList
+	err := r.client.List(context.Background(), &intentsList)
+	if err != nil {
+		logrus.Errorf("Failed to list client intents for namespace %s: %v", namespace, err)
+		return nil
+	}
+
+	intentsToReconcile := lo.Filter(intentsList.Items, func(clientIntents otterizev1alpha3.ClientIntents, _ int) bool {
+		return lo.SomeBy(clientIntents.GetCallsList(), func(intent otterizev1alpha3.Intent) bool {
+			return intent.GetTargetServerNamespace(clientIntents.Namespace) == namespace
+		})
+	})
+	return r.mapIntentsToRequests(intentsToReconcile)
+}
+
+// kafkaListenerPortsChangedPredicate passes KafkaServerConfig changes that change the ports network policies for kafka
+// intents to the server allow.
+func kafkaListenerPortsChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldConfig, newConfig := e.ObjectOld.(*otterizev1alpha3.KafkaServerConfig), e.ObjectNew.(*otterizev1alpha3.KafkaServerConfig)
+			return oldConfig.Spec.Addr != newConfig.Spec.Addr || !reflect.DeepEqual(oldConfig.Spec.ListenerPorts, newConfig.Spec.ListenerPorts)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+func (r *IntentsReconciler) mapKafkaServerConfigToClientIntents(obj client.Object) []reconcile.Request {
+	kafkaServerConfig := obj.(*otterizev1alpha3.KafkaServerConfig)
+	fullServerName := fmt.Sprintf("%s.%s", kafkaServerConfig.Spec.Service.Name, kafkaServerConfig.Namespace)
+	logrus.Infof("Enqueueing client intents for Kafka server %s due to listener ports change", fullServerName)
+
+	var intentsToServer otterizev1alpha3.ClientIntentsList
+	err := r.client.List(context.Background(),
+		&intentsToServer,
+		&client.MatchingFields{otterizev1alpha3.OtterizeTargetServerIndexField: fullServerName},
+	)
+	if err != nil {
+		logrus.Errorf("Failed to list client intents for Kafka server %s: %v", fullServerName, err)
+		return nil
+	}
+
+	return r.mapIntentsToRequests(intentsToServer.Items)
+}
+
+// SetAlwaysAllowedConfig makes intents to servers be reconciled when the always allowed port annotations of the
+// servers' pods change, so that their network policies allow the annotated ports.
+func (r *IntentsReconciler) SetAlwaysAllowedConfig(config always_allowed.Config) {
+	r.alwaysAllowed = config
+}
+
+// mapPodToClientIntents maps pods whose always allowed port annotations changed to the intents calling them, directly
+// or through the Kubernetes services selecting them.
+func (r *IntentsReconciler) mapPodToClientIntents(obj client.Object) []reconcile.Request {
+	pod := obj.(*corev1.Pod)
+	indexValues := make([]string, 0)
+	if formattedServer, ok := pod.Labels[otterizev1alpha3.OtterizeServerLabelKey]; ok {
+		indexValues = append(indexValues, formattedServer)
+	}
+
+	var services corev1.ServiceList
+	err := r.client.List(context.Background(), &services, client.InNamespace(pod.Namespace))
+	if err != nil {
+		logrus.WithError(err).Errorf("Failed to list services in namespace %s", pod.Namespace)
+		return nil
+	}
+	for _, service := range services.Items {
+		if len(service.Spec.Selector) != 0 && labels.SelectorFromSet(service.Spec.Selector).Matches(labels.Set(pod.Labels)) {
+			indexValues = append(indexValues, "svc:"+otterizev1alpha3.GetFormattedOtterizeIdentity(service.Name, service.Namespace))
+		}
+	}
+
+	intentsToReconcile := make([]otterizev1alpha3.ClientIntents, 0)
+	for _, indexValue := range indexValues {
+		var intentsToServer otterizev1alpha3.ClientIntentsList
+		err := r.client.List(context.Background(),
+			&intentsToServer,
+			&client.MatchingFields{otterizev1alpha3.OtterizeFormattedTargetServerIndexField: indexValue},
+		)
+		if err != nil {
+			logrus.WithError(err).Errorf("Failed to list client intents for server %s", indexValue)
+			continue
+		}
+		intentsToReconcile = append(intentsToReconcile, intentsToServer.Items...)
+	}
+
+	return lo.Uniq(r.mapIntentsToRequests(intentsToReconcile))
+}
+
+func (r *IntentsReconciler) mapDatabaseServerConfigToClientIntents(obj client.Object) []reconcile.Request {
+	databaseServerConfig := obj.(*otterizev1alpha3.DatabaseServerConfig)
+	fullServerName := fmt.Sprintf("%s.%s", databaseServerConfig.Name, databaseServerConfig.Namespace)
+	logrus.Infof("Enqueueing client intents for database server %s", fullServerName)
+
+	var intentsToServer otterizev1alpha3.ClientIntentsList
+	err := r.client.List(context.Background(),
+		&intentsToServer,
+		&client.MatchingFields{otterizev1alpha3.OtterizeTargetServerIndexField: fullServerName},
+	)
+	if err != nil {
+		logrus.Errorf("Failed to list client intents for database server %s: %v", fullServerName, err)
+		return nil
+	}
+
+	return r.mapIntentsToRequests(intentsToServer.Items)
+}
+
 func (r *IntentsReconciler) mapIntentsToRequests(intentsToReconcile []otterizev1alpha3.ClientIntents) []reconcile.Request {
 	requests := make([]reconcile.Request, 0)
 	for _, clientIntents := range intentsToReconcile {
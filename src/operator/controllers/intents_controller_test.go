@@ -4,9 +4,11 @@ import (
 	"context"
 	otterizev1alpha2 "github.com/otterize/intents-operator/src/operator/api/v1alpha2"
 	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/istiopolicy"
 	"github.com/otterize/intents-operator/src/shared/testbase"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -32,7 +34,10 @@ func (s *IntentsControllerTestSuite) SetupTest() {
 		nil,
 		nil,
 		nil,
+		nil,
+		nil,
 		EnforcementConfig{},
+		istiopolicy.TrustDomain{},
 		nil,
 		"",
 		"",
@@ -119,6 +124,46 @@ func (s *IntentsControllerTestSuite) TestMappingProtectedServicesToIntent() {
 	s.Require().Equal(expected, res)
 }
 
+func (s *IntentsControllerTestSuite) TestMappingPodToIntentsCallingItAndItsServices() {
+	formattedServer := otterizev1alpha3.GetFormattedOtterizeIdentity("checkoutservice", "test-namespace")
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "checkoutservice-abc",
+			Namespace: "test-namespace",
+			Labels:    map[string]string{otterizev1alpha3.OtterizeServerLabelKey: formattedServer, "app": "checkout"},
+		},
+	}
+
+	s.Client.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&corev1.ServiceList{}), client.InNamespace("test-namespace")).DoAndReturn(
+		func(ctx context.Context, list *corev1.ServiceList, opts ...client.ListOption) error {
+			list.Items = []corev1.Service{
+				{ObjectMeta: metav1.ObjectMeta{Name: "checkout", Namespace: "test-namespace"}, Spec: corev1.ServiceSpec{Selector: map[string]string{"app": "checkout"}}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "test-namespace"}, Spec: corev1.ServiceSpec{Selector: map[string]string{"app": "other"}}},
+			}
+			return nil
+		})
+	s.Client.EXPECT().List(gomock.Any(), gomock.Any(), &client.MatchingFields{otterizev1alpha3.OtterizeFormattedTargetServerIndexField: formattedServer}).DoAndReturn(
+		func(ctx context.Context, list *otterizev1alpha3.ClientIntentsList, opts ...client.ListOption) error {
+			list.Items = []otterizev1alpha3.ClientIntents{{ObjectMeta: metav1.ObjectMeta{Name: "client-intents", Namespace: "test-namespace"}}}
+			return nil
+		})
+	serviceIndexValue := "svc:" + otterizev1alpha3.GetFormattedOtterizeIdentity("checkout", "test-namespace")
+	s.Client.EXPECT().List(gomock.Any(), gomock.Any(), &client.MatchingFields{otterizev1alpha3.OtterizeFormattedTargetServerIndexField: serviceIndexValue}).DoAndReturn(
+		func(ctx context.Context, list *otterizev1alpha3.ClientIntentsList, opts ...client.ListOption) error {
+			list.Items = []otterizev1alpha3.ClientIntents{
+				{ObjectMeta: metav1.ObjectMeta{Name: "client-intents", Namespace: "test-namespace"}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "svc-client-intents", Namespace: "test-namespace"}},
+			}
+			return nil
+		})
+
+	expected := []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: "test-namespace", Name: "client-intents"}},
+		{NamespacedName: types.NamespacedName{Namespace: "test-namespace", Name: "svc-client-intents"}},
+	}
+	s.Require().Equal(expected, s.intentsReconciler.mapPodToClientIntents(pod))
+}
+
 func (s *IntentsControllerTestSuite) TestMappingProtectedServicesToIntentNoIntents() {
 	protectedService := otterizev1alpha3.ProtectedService{
 		ObjectMeta: metav1.ObjectMeta{
@@ -2,22 +2,34 @@ package consts
 
 // Consts have to go here to prevent import cycle between istiopolicy and intents_reconcilers.
 const (
-	ReasonEnforcementDefaultOff                = "EnforcementGloballyDisabled"
-	ReasonNetworkPolicyCreationDisabled        = "NetworkPolicyCreationDisabled"
-	ReasonGettingNetworkPolicyFailed           = "GettingNetworkPolicyFailed"
-	ReasonRemovingNetworkPolicyFailed          = "RemovingNetworkPolicyFailed"
-	ReasonNamespaceNotAllowed                  = "NamespaceNotAllowed"
-	ReasonCreatingNetworkPoliciesFailed        = "CreatingNetworkPoliciesFailed"
-	ReasonCreatedNetworkPolicies               = "CreatedNetworkPolicies"
-	ReasonIstioPolicyCreationDisabled          = "IstioPolicyCreationDisabled"
-	ReasonRemovingIstioPolicyFailed            = "RemovingIstioPolicyFailed"
-	ReasonPodsNotFound                         = "PodsNotFound"
-	ReasonAWSIntentsFoundButNoServiceAccount   = "ReasonAWSIntentsFoundButNoServiceAccount"
-	ReasonKubernetesServiceNotFound            = "KubernetesServiceNotFound"
-	ReasonPortRestrictionUnsupportedForStrings = "TypeStringPortNotSupported"
-	ReasonEgressNetworkPolicyCreationDisabled  = "EgressNetworkPolicyCreationDisabled"
-	ReasonGettingEgressNetworkPolicyFailed     = "GettingEgressNetworkPolicyFailed"
-	ReasonRemovingEgressNetworkPolicyFailed    = "RemovingEgressNetworkPolicyFailed"
-	ReasonCreatingEgressNetworkPoliciesFailed  = "CreatingEgressNetworkPoliciesFailed"
-	ReasonCreatedEgressNetworkPolicies         = "CreatedEgressNetworkPolicies"
+	ReasonEnforcementDefaultOff                 = "EnforcementGloballyDisabled"
+	ReasonNetworkPolicyCreationDisabled         = "NetworkPolicyCreationDisabled"
+	ReasonGettingNetworkPolicyFailed            = "GettingNetworkPolicyFailed"
+	ReasonRemovingNetworkPolicyFailed           = "RemovingNetworkPolicyFailed"
+	ReasonNamespaceNotAllowed                   = "NamespaceNotAllowed"
+	ReasonCreatingNetworkPoliciesFailed         = "CreatingNetworkPoliciesFailed"
+	ReasonCreatedNetworkPolicies                = "CreatedNetworkPolicies"
+	ReasonIstioPolicyCreationDisabled           = "IstioPolicyCreationDisabled"
+	ReasonRemovingIstioPolicyFailed             = "RemovingIstioPolicyFailed"
+	ReasonLinkerdPolicyCreationDisabled         = "LinkerdPolicyCreationDisabled"
+	ReasonRemovingLinkerdPolicyFailed           = "RemovingLinkerdPolicyFailed"
+	ReasonPodsNotFound                          = "PodsNotFound"
+	ReasonAWSIntentsFoundButNoServiceAccount    = "ReasonAWSIntentsFoundButNoServiceAccount"
+	ReasonKubernetesServiceNotFound             = "KubernetesServiceNotFound"
+	ReasonPortRestrictionUnsupportedForStrings  = "TypeStringPortNotSupported"
+	ReasonEgressNetworkPolicyCreationDisabled   = "EgressNetworkPolicyCreationDisabled"
+	ReasonGettingEgressNetworkPolicyFailed      = "GettingEgressNetworkPolicyFailed"
+	ReasonRemovingEgressNetworkPolicyFailed     = "RemovingEgressNetworkPolicyFailed"
+	ReasonCreatingEgressNetworkPoliciesFailed   = "CreatingEgressNetworkPoliciesFailed"
+	ReasonCreatedEgressNetworkPolicies          = "CreatedEgressNetworkPolicies"
+	ReasonNetworkPolicyDriftReverted            = "NetworkPolicyDriftReverted"
+	ReasonIstioPolicyDriftReverted              = "IstioPolicyDriftReverted"
+	ReasonKubernetesRBACUpdated                 = "KubernetesRBACUpdated"
+	ReasonUpdatingKubernetesRBACFailed          = "UpdatingKubernetesRBACFailed"
+	ReasonRemovingKubernetesRBACFailed          = "RemovingKubernetesRBACFailed"
+	ReasonKubernetesRBACRequiresWebhook         = "KubernetesRBACRequiresWebhook"
+	ReasonClusterWideKubernetesAccessNotAllowed = "ClusterWideKubernetesAccessNotAllowed"
+	ReasonCreatedIstioEgress                    = "CreatedIstioEgress"
+	ReasonCreatingIstioEgressFailed             = "CreatingIstioEgressFailed"
+	ReasonRemovingIstioEgressFailed             = "RemovingIstioEgressFailed"
 )
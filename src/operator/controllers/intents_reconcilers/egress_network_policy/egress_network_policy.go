@@ -5,6 +5,7 @@ import (
 	"fmt"
 	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
 	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/consts"
+	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/kafka_listener_ports"
 	"github.com/otterize/intents-operator/src/shared/injectablerecorder"
 	"github.com/otterize/intents-operator/src/shared/telemetries/telemetriesgql"
 	"github.com/otterize/intents-operator/src/shared/telemetries/telemetrysender"
@@ -28,6 +29,7 @@ type EgressNetworkPolicyReconciler struct {
 	RestrictToNamespaces        []string
 	enableNetworkPolicyCreation bool
 	enforcementDefaultState     bool
+	operatorPodNamespace        string
 	injectablerecorder.InjectableRecorder
 }
 
@@ -36,13 +38,15 @@ func NewEgressNetworkPolicyReconciler(
 	s *runtime.Scheme,
 	restrictToNamespaces []string,
 	enableNetworkPolicyCreation bool,
-	enforcementDefaultState bool) *EgressNetworkPolicyReconciler {
+	enforcementDefaultState bool,
+	operatorPodNamespace string) *EgressNetworkPolicyReconciler {
 	return &EgressNetworkPolicyReconciler{
 		Client:                      c,
 		Scheme:                      s,
 		RestrictToNamespaces:        restrictToNamespaces,
 		enableNetworkPolicyCreation: enableNetworkPolicyCreation,
 		enforcementDefaultState:     enforcementDefaultState,
+		operatorPodNamespace:        operatorPodNamespace,
 	}
 }
 
@@ -100,7 +104,7 @@ func (r *EgressNetworkPolicyReconciler) Reconcile(ctx context.Context, req ctrl.
 		}
 	}
 
-	err = r.removeOrphanNetworkPolicies(ctx)
+	_, err = r.RemoveOrphanNetworkPolicies(ctx, false)
 	if err != nil {
 		r.RecordWarningEventf(intents, consts.ReasonRemovingEgressNetworkPolicyFailed, "failed to remove network policies: %s", err.Error())
 		return ctrl.Result{}, err
@@ -130,8 +134,13 @@ func (r *EgressNetworkPolicyReconciler) handleNetworkPolicyCreation(
 
 	policyName := fmt.Sprintf(otterizev1alpha3.OtterizeEgressNetworkPolicyNameTemplate, intent.GetServerFullyQualifiedName(intentsObj.Namespace), intentsObj.GetServiceName())
 	existingPolicy := &v1.NetworkPolicy{}
-	newPolicy := r.buildNetworkPolicyObjectForIntents(intentsObj, intent, policyName)
-	err := r.Get(ctx, types.NamespacedName{
+	// The policy is shared by all the client's intents to the server, so its ports are those of all of them.
+	ports, err := kafka_listener_ports.GetClientPorts(ctx, r.Client, intentsObj, intent, r.operatorPodNamespace)
+	if err != nil {
+		return false, err
+	}
+	newPolicy := r.buildNetworkPolicyObjectForIntents(intentsObj, intent, policyName, ports)
+	err = r.Get(ctx, types.NamespacedName{
 		Name:      policyName,
 		Namespace: intentsObjNamespace},
 		existingPolicy)
@@ -148,6 +157,11 @@ func (r *EgressNetworkPolicyReconciler) handleNetworkPolicyCreation(
 }
 
 func (r *EgressNetworkPolicyReconciler) UpdateExistingPolicy(ctx context.Context, existingPolicy *v1.NetworkPolicy, newPolicy *v1.NetworkPolicy, intent otterizev1alpha3.Intent, intentsObjNamespace string) error {
+	if otterizev1alpha3.IsManuallyOverridden(existingPolicy) {
+		logrus.Debugf("Network policy %s/%s is manually overridden, skipping update", existingPolicy.Namespace, existingPolicy.Name)
+		return nil
+	}
+
 	if !reflect.DeepEqual(existingPolicy.Spec, newPolicy.Spec) {
 		policyCopy := existingPolicy.DeepCopy()
 		policyCopy.Labels = newPolicy.Labels
@@ -198,18 +212,21 @@ func (r *EgressNetworkPolicyReconciler) handleIntentRemoval(
 	return r.deleteNetworkPolicy(ctx, intent, intentsObj)
 }
 
-func (r *EgressNetworkPolicyReconciler) removeOrphanNetworkPolicies(ctx context.Context) error {
+// RemoveOrphanNetworkPolicies removes network policies whose ClientIntents no longer exist, and returns how many were
+// found. When dryRun is set, orphaned policies are only logged.
+func (r *EgressNetworkPolicyReconciler) RemoveOrphanNetworkPolicies(ctx context.Context, dryRun bool) (int, error) {
 	logrus.Info("Searching for orphaned network policies")
+	orphanCount := 0
 	networkPolicyList := &v1.NetworkPolicyList{}
 	selector, err := matchAccessNetworkPolicy()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	err = r.List(ctx, networkPolicyList, &client.ListOptions{LabelSelector: selector})
 	if err != nil {
 		logrus.Infof("Error listing network policies: %s", err.Error())
-		return err
+		return 0, err
 	}
 
 	logrus.Infof("Selector: %s found %d network policies", selector.String(), len(networkPolicyList.Items))
@@ -225,19 +242,24 @@ func (r *EgressNetworkPolicyReconciler) removeOrphanNetworkPolicies(ctx context.
 			&client.ListOptions{Namespace: clientNamespace},
 		)
 		if err != nil {
-			return err
+			return 0, err
 		}
 
 		if len(intentsList.Items) == 0 {
+			orphanCount++
+			if dryRun {
+				logrus.Infof("Dry run, not removing orphaned network policy: %s server %s ns %s", networkPolicy.Name, formattedServerName, networkPolicy.Namespace)
+				continue
+			}
 			logrus.Infof("Removing orphaned network policy: %s server %s ns %s", networkPolicy.Name, formattedServerName, networkPolicy.Namespace)
 			err = r.removeNetworkPolicy(ctx, networkPolicy)
 			if err != nil {
-				return err
+				return 0, err
 			}
 		}
 	}
 
-	return nil
+	return orphanCount, nil
 }
 
 func (r *EgressNetworkPolicyReconciler) removeNetworkPolicy(ctx context.Context, networkPolicy v1.NetworkPolicy) error {
@@ -272,9 +294,10 @@ func (r *EgressNetworkPolicyReconciler) deleteNetworkPolicy(
 	return r.removeNetworkPolicy(ctx, *policy)
 }
 
-// buildNetworkPolicyObjectForIntents builds the network policy that represents the intent from the parameter
+// buildNetworkPolicyObjectForIntents builds the network policy that represents the intent from the parameter, allowing
+// only the given ports, or all ports if there are none.
 func (r *EgressNetworkPolicyReconciler) buildNetworkPolicyObjectForIntents(
-	intentsObj *otterizev1alpha3.ClientIntents, intent otterizev1alpha3.Intent, policyName string) *v1.NetworkPolicy {
+	intentsObj *otterizev1alpha3.ClientIntents, intent otterizev1alpha3.Intent, policyName string, ports []v1.NetworkPolicyPort) *v1.NetworkPolicy {
 	// The intent's target server made of name + namespace + hash
 	formattedClient := otterizev1alpha3.GetFormattedOtterizeIdentity(intentsObj.GetServiceName(), intentsObj.Namespace)
 	formattedTargetServer := otterizev1alpha3.GetFormattedOtterizeIdentity(intent.GetTargetServerName(), intent.GetTargetServerNamespace(intentsObj.Namespace))
@@ -307,6 +330,7 @@ func (r *EgressNetworkPolicyReconciler) buildNetworkPolicyObjectForIntents(
 							},
 						},
 					},
+					Ports: ports,
 				},
 			},
 		},
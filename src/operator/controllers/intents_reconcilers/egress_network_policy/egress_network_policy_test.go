@@ -8,15 +8,18 @@ import (
 	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/consts"
 	mocks "github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/mocks"
 	"github.com/otterize/intents-operator/src/shared/testbase"
+	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/networking/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"testing"
@@ -55,6 +58,7 @@ func (s *EgressNetworkPolicyReconcilerTestSuite) SetupTest() {
 		restrictToNamespaces,
 		true,
 		true,
+		testClientNamespace,
 	)
 
 	s.Reconciler.Recorder = s.Recorder
@@ -464,6 +468,165 @@ func (s *EgressNetworkPolicyReconcilerTestSuite) testCreateNetworkPolicy(
 	s.Empty(res)
 }
 
+func (s *EgressNetworkPolicyReconcilerTestSuite) TestCreateNetworkPolicyForOperatorKafkaIntents() {
+	// The operator's intents are limited to the admin listener
+	s.testCreateNetworkPolicyForKafkaIntentsNamedAsOperatorIntents(9094)
+}
+
+func (s *EgressNetworkPolicyReconcilerTestSuite) TestCreateNetworkPolicyForKafkaIntentsNamedAsOperatorIntentsInOtherNamespace() {
+	// Intents outside the operator's namespace are not the operator's, even if named like them
+	s.Reconciler.operatorPodNamespace = "intents-operator-namespace"
+	s.testCreateNetworkPolicyForKafkaIntentsNamedAsOperatorIntents(9092)
+}
+
+func (s *EgressNetworkPolicyReconcilerTestSuite) testCreateNetworkPolicyForKafkaIntentsNamedAsOperatorIntents(expectedPort int32) {
+	clientIntentsName := "operator-to-kafkaserverconfig-kafka-server-config-namespace-other-namespace"
+	policyName := "egress-to-test-server.other-namespace-from-test-client"
+	formattedTargetServer := "test-server-other-namespace-f6a461"
+	kafkaServerConfig := otterizev1alpha3.KafkaServerConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka-server-config", Namespace: "other-namespace"},
+		Spec: otterizev1alpha3.KafkaServerConfigSpec{
+			Service:       otterizev1alpha3.Service{Name: "test-server"},
+			Addr:          "test-server.other-namespace:9092",
+			ListenerPorts: &otterizev1alpha3.KafkaListenerPorts{Client: []int32{9092}, Admin: 9094},
+		},
+	}
+
+	clientIntents := otterizev1alpha3.ClientIntents{
+		ObjectMeta: metav1.ObjectMeta{Name: clientIntentsName, Namespace: testClientNamespace},
+		Spec: &otterizev1alpha3.IntentsSpec{
+			Service: otterizev1alpha3.Service{Name: "test-client"},
+			Calls: []otterizev1alpha3.Intent{
+				{
+					Name:   "test-server.other-namespace",
+					Type:   otterizev1alpha3.IntentTypeKafka,
+					Topics: []otterizev1alpha3.KafkaTopic{{Name: "*", Operations: []otterizev1alpha3.KafkaOperation{otterizev1alpha3.KafkaOperationDescribe}}},
+				},
+			},
+		},
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: testClientNamespace, Name: clientIntentsName}}
+	s.Client.EXPECT().Get(gomock.Any(), req.NamespacedName, gomock.Eq(&otterizev1alpha3.ClientIntents{})).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, intents *otterizev1alpha3.ClientIntents, options ...client.ListOption) error {
+			clientIntents.DeepCopyInto(intents)
+			return nil
+		})
+	s.expectListKafkaServerConfigs(kafkaServerConfig)
+	s.expectListClientIntentsToServer("test-server.other-namespace", clientIntents)
+
+	networkPolicyNamespacedName := types.NamespacedName{Namespace: testClientNamespace, Name: policyName}
+	s.Client.EXPECT().Get(gomock.Any(), networkPolicyNamespacedName, gomock.Eq(&v1.NetworkPolicy{})).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, networkPolicy *v1.NetworkPolicy, options ...client.ListOption) error {
+			return apierrors.NewNotFound(v1.Resource("networkpolicy"), name.Name)
+		})
+
+	newPolicy := networkPolicyTemplate(
+		policyName,
+		"other-namespace",
+		"test-client-test-client-namespac-edb3a2",
+		formattedTargetServer,
+		testClientNamespace,
+	)
+	newPolicy.Spec.Egress[0].Ports = []v1.NetworkPolicyPort{
+		{Protocol: lo.ToPtr(corev1.ProtocolTCP), Port: &intstr.IntOrString{IntVal: expectedPort}},
+	}
+	s.Client.EXPECT().Create(gomock.Any(), gomock.Eq(newPolicy)).Return(nil)
+	s.ignoreRemoveOrphan()
+
+	res, err := s.Reconciler.Reconcile(context.Background(), req)
+	s.NoError(err)
+	s.Empty(res)
+	s.ExpectEvent(consts.ReasonCreatedEgressNetworkPolicies)
+}
+
+func (s *EgressNetworkPolicyReconcilerTestSuite) TestCreateNetworkPolicyForKafkaAndHTTPIntentsToSameServer() {
+	clientIntentsName := "client-intents"
+	policyName := "egress-to-test-server.other-namespace-from-test-client"
+	formattedTargetServer := "test-server-other-namespace-f6a461"
+	kafkaServerConfig := otterizev1alpha3.KafkaServerConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka-server-config", Namespace: "other-namespace"},
+		Spec: otterizev1alpha3.KafkaServerConfigSpec{
+			Service:       otterizev1alpha3.Service{Name: "test-server"},
+			Addr:          "test-server.other-namespace:9092",
+			ListenerPorts: &otterizev1alpha3.KafkaListenerPorts{Client: []int32{9092}},
+		},
+	}
+	clientIntents := otterizev1alpha3.ClientIntents{
+		ObjectMeta: metav1.ObjectMeta{Name: clientIntentsName, Namespace: testClientNamespace},
+		Spec: &otterizev1alpha3.IntentsSpec{
+			Service: otterizev1alpha3.Service{Name: "test-client"},
+			Calls: []otterizev1alpha3.Intent{
+				{
+					Name:   "test-server.other-namespace",
+					Type:   otterizev1alpha3.IntentTypeKafka,
+					Topics: []otterizev1alpha3.KafkaTopic{{Name: "*", Operations: []otterizev1alpha3.KafkaOperation{otterizev1alpha3.KafkaOperationDescribe}}},
+				},
+				{
+					Name: "test-server.other-namespace",
+					Type: otterizev1alpha3.IntentTypeHTTP,
+				},
+			},
+		},
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: testClientNamespace, Name: clientIntentsName}}
+	s.Client.EXPECT().Get(gomock.Any(), req.NamespacedName, gomock.Eq(&otterizev1alpha3.ClientIntents{})).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, intents *otterizev1alpha3.ClientIntents, options ...client.ListOption) error {
+			clientIntents.DeepCopyInto(intents)
+			return nil
+		})
+	s.expectListKafkaServerConfigs(kafkaServerConfig)
+	s.expectListClientIntentsToServer("test-server.other-namespace", clientIntents)
+
+	// Both intents share the policy, which allows all ports as the HTTP intent is not limited to the Kafka listeners
+	newPolicy := networkPolicyTemplate(
+		policyName,
+		"other-namespace",
+		"test-client-test-client-namespac-edb3a2",
+		formattedTargetServer,
+		testClientNamespace,
+	)
+	networkPolicyNamespacedName := types.NamespacedName{Namespace: testClientNamespace, Name: policyName}
+	s.Client.EXPECT().Get(gomock.Any(), networkPolicyNamespacedName, gomock.Eq(&v1.NetworkPolicy{})).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, networkPolicy *v1.NetworkPolicy, options ...client.ListOption) error {
+			return apierrors.NewNotFound(v1.Resource("networkpolicy"), name.Name)
+		})
+	s.Client.EXPECT().Create(gomock.Any(), gomock.Eq(newPolicy)).Return(nil)
+	s.Client.EXPECT().Get(gomock.Any(), networkPolicyNamespacedName, gomock.Eq(&v1.NetworkPolicy{})).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, networkPolicy *v1.NetworkPolicy, options ...client.ListOption) error {
+			newPolicy.DeepCopyInto(networkPolicy)
+			return nil
+		})
+	s.ignoreRemoveOrphan()
+
+	res, err := s.Reconciler.Reconcile(context.Background(), req)
+	s.NoError(err)
+	s.Empty(res)
+	s.ExpectEvent(consts.ReasonCreatedEgressNetworkPolicies)
+}
+
+func (s *EgressNetworkPolicyReconcilerTestSuite) expectListKafkaServerConfigs(kafkaServerConfigs ...otterizev1alpha3.KafkaServerConfig) {
+	s.Client.EXPECT().List(gomock.Any(), gomock.Eq(&otterizev1alpha3.KafkaServerConfigList{}), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, list *otterizev1alpha3.KafkaServerConfigList, opts ...client.ListOption) error {
+			list.Items = append(list.Items, kafkaServerConfigs...)
+			return nil
+		})
+}
+
+func (s *EgressNetworkPolicyReconcilerTestSuite) expectListClientIntentsToServer(serverName string, clientIntents ...otterizev1alpha3.ClientIntents) {
+	s.Client.EXPECT().List(
+		gomock.Any(),
+		gomock.Eq(&otterizev1alpha3.ClientIntentsList{}),
+		&client.MatchingFields{otterizev1alpha3.OtterizeTargetServerIndexField: serverName},
+		&client.ListOptions{Namespace: testClientNamespace},
+	).DoAndReturn(
+		func(ctx context.Context, list *otterizev1alpha3.ClientIntentsList, opts ...client.ListOption) error {
+			list.Items = append(list.Items, clientIntents...)
+			return nil
+		})
+}
+
 func (s *EgressNetworkPolicyReconcilerTestSuite) ignoreRemoveOrphan() {
 	selector, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
 		{
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/always_allowed"
 	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/consts"
+	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/kafka_listener_ports"
 	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/protected_services"
 	"github.com/otterize/intents-operator/src/shared/injectablerecorder"
 	"github.com/otterize/intents-operator/src/shared/telemetries/telemetriesgql"
@@ -37,6 +39,8 @@ type NetworkPolicyReconciler struct {
 	enableNetworkPolicyCreation                   bool
 	enforcementDefaultState                       bool
 	externalNetworkPoliciesCreatedEvenIfNoIntents bool
+	operatorPodNamespace                          string
+	alwaysAllowed                                 always_allowed.Config
 	injectablerecorder.InjectableRecorder
 }
 
@@ -47,7 +51,8 @@ func NewNetworkPolicyReconciler(
 	restrictToNamespaces []string,
 	enableNetworkPolicyCreation bool,
 	enforcementDefaultState bool,
-	externalNetworkPoliciesCreatedEvenIfNoIntents bool) *NetworkPolicyReconciler {
+	externalNetworkPoliciesCreatedEvenIfNoIntents bool,
+	operatorPodNamespace string) *NetworkPolicyReconciler {
 	return &NetworkPolicyReconciler{
 		Client:                      c,
 		Scheme:                      s,
@@ -56,9 +61,16 @@ func NewNetworkPolicyReconciler(
 		enableNetworkPolicyCreation: enableNetworkPolicyCreation,
 		enforcementDefaultState:     enforcementDefaultState,
 		externalNetworkPoliciesCreatedEvenIfNoIntents: externalNetworkPoliciesCreatedEvenIfNoIntents,
+		operatorPodNamespace:                          operatorPodNamespace,
 	}
 }
 
+// SetAlwaysAllowedConfig sets traffic that is allowed into protected servers regardless of intents, such as
+// monitoring. It is added to each generated policy.
+func (r *NetworkPolicyReconciler) SetAlwaysAllowedConfig(config always_allowed.Config) {
+	r.alwaysAllowed = config
+}
+
 func (r *NetworkPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	intents := &otterizev1alpha3.ClientIntents{}
 	err := r.Get(ctx, req.NamespacedName, intents)
@@ -114,7 +126,7 @@ func (r *NetworkPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		}
 	}
 
-	err = r.removeOrphanNetworkPolicies(ctx)
+	_, err = r.RemoveOrphanNetworkPolicies(ctx, false)
 	if err != nil {
 		r.RecordWarningEventf(intents, consts.ReasonRemovingNetworkPolicyFailed, "failed to remove network policies: %s", err.Error())
 		return ctrl.Result{}, err
@@ -151,7 +163,16 @@ func (r *NetworkPolicyReconciler) handleNetworkPolicyCreation(
 
 	policyName := fmt.Sprintf(otterizev1alpha3.OtterizeNetworkPolicyNameTemplate, intent.GetTargetServerName(), intentsObjNamespace)
 	existingPolicy := &v1.NetworkPolicy{}
-	newPolicy := r.buildNetworkPolicyObjectForIntent(intent, policyName, intentsObjNamespace)
+	ports, err := kafka_listener_ports.GetNamespacePorts(ctx, r.Client, intentsObj, intent, r.operatorPodNamespace)
+	if err != nil {
+		return false, err
+	}
+	newPolicy := r.buildNetworkPolicyObjectForIntent(intent, policyName, intentsObjNamespace, ports)
+	err = r.alwaysAllowed.AddToPolicy(ctx, r.Client, newPolicy)
+	if err != nil {
+		return false, err
+	}
+
 	err = r.Get(ctx, types.NamespacedName{
 		Name:      policyName,
 		Namespace: intent.GetTargetServerNamespace(intentsObjNamespace)},
@@ -169,6 +190,11 @@ func (r *NetworkPolicyReconciler) handleNetworkPolicyCreation(
 }
 
 func (r *NetworkPolicyReconciler) UpdateExistingPolicy(ctx context.Context, existingPolicy *v1.NetworkPolicy, newPolicy *v1.NetworkPolicy, intent otterizev1alpha3.Intent, intentsObjNamespace string) error {
+	if otterizev1alpha3.IsManuallyOverridden(existingPolicy) {
+		logrus.Debugf("Network policy %s/%s is manually overridden, skipping update", existingPolicy.Namespace, existingPolicy.Name)
+		return nil
+	}
+
 	if !reflect.DeepEqual(existingPolicy.Spec, newPolicy.Spec) {
 		policyCopy := existingPolicy.DeepCopy()
 		policyCopy.Labels = newPolicy.Labels
@@ -260,18 +286,21 @@ func (r *NetworkPolicyReconciler) handleIntentRemoval(
 	return nil
 }
 
-func (r *NetworkPolicyReconciler) removeOrphanNetworkPolicies(ctx context.Context) error {
+// RemoveOrphanNetworkPolicies removes network policies whose ClientIntents no longer exist, and returns how many were
+// found. When dryRun is set, orphaned policies are only logged.
+func (r *NetworkPolicyReconciler) RemoveOrphanNetworkPolicies(ctx context.Context, dryRun bool) (int, error) {
 	logrus.Info("Searching for orphaned network policies")
+	orphanCount := 0
 	networkPolicyList := &v1.NetworkPolicyList{}
 	selector, err := matchAccessNetworkPolicy()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	err = r.List(ctx, networkPolicyList, &client.ListOptions{LabelSelector: selector})
 	if err != nil {
 		logrus.Infof("Error listing network policies: %s", err.Error())
-		return err
+		return 0, err
 	}
 
 	logrus.Infof("Selector: %s found %d network policies", selector.String(), len(networkPolicyList.Items))
@@ -287,19 +316,24 @@ func (r *NetworkPolicyReconciler) removeOrphanNetworkPolicies(ctx context.Contex
 			&client.ListOptions{Namespace: clientNamespace},
 		)
 		if err != nil {
-			return err
+			return 0, err
 		}
 
 		if len(intentsList.Items) == 0 {
+			orphanCount++
+			if dryRun {
+				logrus.Infof("Dry run, not removing orphaned network policy: %s server %s ns %s", networkPolicy.Name, serverName, networkPolicy.Namespace)
+				continue
+			}
 			logrus.Infof("Removing orphaned network policy: %s server %s ns %s", networkPolicy.Name, serverName, networkPolicy.Namespace)
 			err = r.removeNetworkPolicy(ctx, networkPolicy)
 			if err != nil {
-				return err
+				return 0, err
 			}
 		}
 	}
 
-	return nil
+	return orphanCount, nil
 }
 
 func (r *NetworkPolicyReconciler) removeNetworkPolicy(ctx context.Context, networkPolicy v1.NetworkPolicy) error {
@@ -368,6 +402,15 @@ func (r *NetworkPolicyReconciler) CleanPoliciesFromUnprotectedServices(ctx conte
 		return nil
 	}
 
+	// All servers in a namespace with default deny are protected, whether or not they have a ProtectedService.
+	namespaceProtected, err := protected_services.IsNamespaceDefaultDenyEnabled(ctx, r.Client, namespace)
+	if err != nil {
+		return err
+	}
+	if namespaceProtected {
+		return nil
+	}
+
 	var protectedServicesResources otterizev1alpha3.ProtectedServiceList
 	err = r.List(ctx, &protectedServicesResources, &client.ListOptions{Namespace: namespace})
 	if err != nil {
@@ -414,9 +457,10 @@ func (r *NetworkPolicyReconciler) CleanAllNamespaces(ctx context.Context) error
 	return nil
 }
 
-// buildNetworkPolicyObjectForIntent builds the network policy that represents the intent from the parameter
+// buildNetworkPolicyObjectForIntent builds the network policy that represents the intent from the parameter, allowing
+// only the given ports, or all ports if there are none.
 func (r *NetworkPolicyReconciler) buildNetworkPolicyObjectForIntent(
-	intent otterizev1alpha3.Intent, policyName, intentsObjNamespace string) *v1.NetworkPolicy {
+	intent otterizev1alpha3.Intent, policyName, intentsObjNamespace string, ports []v1.NetworkPolicyPort) *v1.NetworkPolicy {
 	targetNamespace := intent.GetTargetServerNamespace(intentsObjNamespace)
 	// The intent's target server made of name + namespace + hash
 	formattedTargetServer := otterizev1alpha3.GetFormattedOtterizeIdentity(intent.GetTargetServerName(), targetNamespace)
@@ -449,6 +493,7 @@ func (r *NetworkPolicyReconciler) buildNetworkPolicyObjectForIntent(
 							},
 						},
 					},
+					Ports: ports,
 				},
 			},
 		},
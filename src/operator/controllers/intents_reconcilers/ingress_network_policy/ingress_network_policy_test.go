@@ -8,16 +8,19 @@ import (
 	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/consts"
 	mocks "github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/mocks"
 	"github.com/otterize/intents-operator/src/shared/testbase"
+	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/networking/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"testing"
@@ -57,6 +60,7 @@ func (s *NetworkPolicyReconcilerTestSuite) SetupTest() {
 		true,
 		true,
 		false,
+		testNamespace,
 	)
 
 	s.Reconciler.Recorder = s.Recorder
@@ -552,6 +556,87 @@ func (s *NetworkPolicyReconcilerTestSuite) testCreateNetworkPolicy(
 	s.Empty(res)
 }
 
+func (s *NetworkPolicyReconcilerTestSuite) TestCreateNetworkPolicyForKafkaIntentWithListenerPorts() {
+	clientIntentsName := "client-intents"
+	policyName := "access-to-test-server-from-test-namespace"
+	formattedTargetServer := "test-server-test-namespace-8ddecb"
+	kafkaServerConfig := otterizev1alpha3.KafkaServerConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "kafka-server-config", Namespace: testNamespace},
+		Spec: otterizev1alpha3.KafkaServerConfigSpec{
+			Service:       otterizev1alpha3.Service{Name: "test-server"},
+			Addr:          "test-server.test-namespace:9093",
+			ListenerPorts: &otterizev1alpha3.KafkaListenerPorts{Client: []int32{9092}},
+		},
+	}
+	kafkaIntent := otterizev1alpha3.Intent{
+		Name:   "test-server",
+		Type:   otterizev1alpha3.IntentTypeKafka,
+		Topics: []otterizev1alpha3.KafkaTopic{{Name: "orders", Operations: []otterizev1alpha3.KafkaOperation{otterizev1alpha3.KafkaOperationConsume}}},
+	}
+	clientIntents := otterizev1alpha3.ClientIntents{
+		ObjectMeta: metav1.ObjectMeta{Name: clientIntentsName, Namespace: testNamespace},
+		Spec: &otterizev1alpha3.IntentsSpec{
+			Service: otterizev1alpha3.Service{Name: "test-client"},
+			Calls:   []otterizev1alpha3.Intent{kafkaIntent},
+		},
+	}
+	// The operator is deployed in the client's namespace, so its intents to the server share the policy
+	operatorIntents := otterizev1alpha3.ClientIntents{
+		ObjectMeta: metav1.ObjectMeta{Name: "operator-to-kafkaserverconfig-kafka-server-config-namespace-test-namespace", Namespace: testNamespace},
+		Spec: &otterizev1alpha3.IntentsSpec{
+			Service: otterizev1alpha3.Service{Name: "intents-operator"},
+			Calls:   []otterizev1alpha3.Intent{kafkaIntent},
+		},
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: clientIntentsName}}
+	s.Client.EXPECT().Get(gomock.Any(), req.NamespacedName, gomock.Eq(&otterizev1alpha3.ClientIntents{})).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, intents *otterizev1alpha3.ClientIntents, options ...client.ListOption) error {
+			clientIntents.DeepCopyInto(intents)
+			return nil
+		})
+	s.Client.EXPECT().List(gomock.Any(), gomock.Eq(&otterizev1alpha3.KafkaServerConfigList{}), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, list *otterizev1alpha3.KafkaServerConfigList, opts ...client.ListOption) error {
+			list.Items = append(list.Items, kafkaServerConfig)
+			return nil
+		})
+	s.Client.EXPECT().List(
+		gomock.Any(),
+		gomock.Eq(&otterizev1alpha3.ClientIntentsList{}),
+		&client.MatchingFields{otterizev1alpha3.OtterizeTargetServerIndexField: "test-server.test-namespace"},
+		&client.ListOptions{Namespace: testNamespace},
+	).DoAndReturn(
+		func(ctx context.Context, list *otterizev1alpha3.ClientIntentsList, opts ...client.ListOption) error {
+			list.Items = append(list.Items, clientIntents, operatorIntents)
+			return nil
+		})
+
+	networkPolicyNamespacedName := types.NamespacedName{Namespace: testNamespace, Name: policyName}
+	s.Client.EXPECT().Get(gomock.Any(), networkPolicyNamespacedName, gomock.Eq(&v1.NetworkPolicy{})).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, networkPolicy *v1.NetworkPolicy, options ...client.ListOption) error {
+			return apierrors.NewNotFound(v1.Resource("networkpolicy"), name.Name)
+		})
+
+	// Only the client listener, and the admin listener of the operator's address, are allowed
+	newPolicy := networkPolicyTemplate(policyName, testNamespace, formattedTargetServer, testNamespace)
+	newPolicy.Spec.Ingress[0].Ports = []v1.NetworkPolicyPort{
+		{Protocol: lo.ToPtr(corev1.ProtocolTCP), Port: &intstr.IntOrString{IntVal: 9092}},
+		{Protocol: lo.ToPtr(corev1.ProtocolTCP), Port: &intstr.IntOrString{IntVal: 9093}},
+	}
+	s.Client.EXPECT().Create(gomock.Any(), gomock.Eq(newPolicy)).Return(nil)
+
+	selector := labels.SelectorFromSet(labels.Set(map[string]string{
+		otterizev1alpha3.OtterizeServerLabelKey: formattedTargetServer,
+	}))
+	s.externalNetpolHandler.EXPECT().HandlePodsByLabelSelector(gomock.Any(), testNamespace, selector)
+	s.ignoreRemoveOrphan()
+
+	res, err := s.Reconciler.Reconcile(context.Background(), req)
+	s.NoError(err)
+	s.Empty(res)
+	s.ExpectEvent(consts.ReasonCreatedNetworkPolicies)
+}
+
 func (s *NetworkPolicyReconcilerTestSuite) ignoreRemoveOrphan() {
 	selector, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
 		{
@@ -725,6 +810,7 @@ func (s *NetworkPolicyReconcilerTestSuite) testServerNotProtected(clientIntentsN
 		func(ctx context.Context, list *otterizev1alpha3.ProtectedServiceList, opts ...client.ListOption) error {
 			return nil
 		})
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Name: serverNamespace}, gomock.AssignableToTypeOf(&corev1.Namespace{})).Return(nil)
 
 	s.ignoreRemoveOrphan()
 
@@ -790,6 +876,7 @@ func (s *NetworkPolicyReconcilerTestSuite) testEnforcementDisabled() {
 		func(ctx context.Context, list *otterizev1alpha3.ProtectedServiceList, opts ...client.ListOption) error {
 			return nil
 		})
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Name: serverNamespace}, gomock.AssignableToTypeOf(&corev1.Namespace{})).AnyTimes().Return(nil)
 
 	res, err := s.Reconciler.Reconcile(context.Background(), req)
 	s.NoError(err)
@@ -1002,6 +1089,7 @@ func (s *NetworkPolicyReconcilerTestSuite) TestAllServerAreProtected() {
 		},
 	}
 
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Name: testNamespace}, gomock.AssignableToTypeOf(&corev1.Namespace{})).Return(nil)
 	s.Client.EXPECT().List(gomock.Any(), gomock.Eq(&otterizev1alpha3.ProtectedServiceList{}), &client.ListOptions{Namespace: testNamespace}).DoAndReturn(
 		func(ctx context.Context, list *otterizev1alpha3.ProtectedServiceList, opts ...client.ListOption) error {
 			protectedServicesResources.DeepCopyInto(list)
@@ -1128,6 +1216,7 @@ func (s *NetworkPolicyReconcilerTestSuite) TestUnprotectedServerWithAccessPolicy
 		},
 	}
 
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Name: testNamespace}, gomock.AssignableToTypeOf(&corev1.Namespace{})).Return(nil)
 	s.Client.EXPECT().List(gomock.Any(), gomock.Eq(&otterizev1alpha3.ProtectedServiceList{}), &client.ListOptions{Namespace: testNamespace}).DoAndReturn(
 		func(ctx context.Context, list *otterizev1alpha3.ProtectedServiceList, opts ...client.ListOption) error {
 			protectedServicesResources.DeepCopyInto(list)
@@ -1268,6 +1357,7 @@ func (s *NetworkPolicyReconcilerTestSuite) TestProtectedServiceInDeletionWithAcc
 		},
 	}
 
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Name: testNamespace}, gomock.AssignableToTypeOf(&corev1.Namespace{})).Return(nil)
 	s.Client.EXPECT().List(gomock.Any(), gomock.Eq(&otterizev1alpha3.ProtectedServiceList{}), &client.ListOptions{Namespace: testNamespace}).DoAndReturn(
 		func(ctx context.Context, list *otterizev1alpha3.ProtectedServiceList, opts ...client.ListOption) error {
 			protectedServicesResources.DeepCopyInto(list)
@@ -1369,6 +1459,7 @@ func (s *NetworkPolicyReconcilerTestSuite) TestServerWithoutPolicyNothingShouldH
 		},
 	}
 
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Name: testNamespace}, gomock.AssignableToTypeOf(&corev1.Namespace{})).Return(nil)
 	s.Client.EXPECT().List(gomock.Any(), gomock.Eq(&otterizev1alpha3.ProtectedServiceList{}), &client.ListOptions{Namespace: testNamespace}).DoAndReturn(
 		func(ctx context.Context, list *otterizev1alpha3.ProtectedServiceList, opts ...client.ListOption) error {
 			protectedServicesResources.DeepCopyInto(list)
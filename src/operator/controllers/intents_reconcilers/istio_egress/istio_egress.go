@@ -0,0 +1,397 @@
+package istio_egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/consts"
+	"github.com/otterize/intents-operator/src/operator/controllers/istiopolicy"
+	"github.com/otterize/intents-operator/src/shared/injectablerecorder"
+	"github.com/otterize/intents-operator/src/shared/serviceidresolver"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+	"google.golang.org/protobuf/proto"
+	v1beta1networking "istio.io/api/networking/v1beta1"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+const (
+	clusterDomain                       = "cluster.local"
+	kubernetesAPIServiceName            = "kubernetes"
+	kubernetesAPIServiceNamespace       = "default"
+	defaultInternetPort           int32 = 443
+)
+
+//+kubebuilder:rbac:groups="networking.istio.io",resources=sidecars;serviceentries,verbs=get;list;watch;create;update;patch;delete
+
+// IstioEgressReconciler restricts the egress of clients in the Istio mesh to the targets of their intents. Each client
+// gets a Sidecar resource whose egress hosts are the services it calls, with the REGISTRY_ONLY outbound traffic policy,
+// and the domains of its internet intents are added to the mesh registry by ServiceEntry resources exported to the
+// client's namespace only. This also limits the Envoy configuration pushed to the client's sidecar to these hosts.
+// Istio applies a single Sidecar resource to each workload, so clients must not also be selected by Sidecar resources
+// created by users. Targets of other intent types, such as AWS, are blocked unless declared with internet intents.
+type IstioEgressReconciler struct {
+	client.Client
+	Scheme                  *runtime.Scheme
+	RestrictToNamespaces    []string
+	enforcementDefaultState bool
+	istioNamespace          string
+	serviceIdResolver       serviceidresolver.ServiceResolver
+	injectablerecorder.InjectableRecorder
+}
+
+// NewIstioEgressReconciler creates an IstioEgressReconciler. Egress to the Istio control plane namespace is always
+// allowed.
+func NewIstioEgressReconciler(
+	c client.Client,
+	s *runtime.Scheme,
+	restrictToNamespaces []string,
+	serviceIdResolver serviceidresolver.ServiceResolver,
+	enforcementDefaultState bool,
+	istioNamespace string,
+) *IstioEgressReconciler {
+	return &IstioEgressReconciler{
+		Client:                  c,
+		Scheme:                  s,
+		RestrictToNamespaces:    restrictToNamespaces,
+		enforcementDefaultState: enforcementDefaultState,
+		istioNamespace:          istioNamespace,
+		serviceIdResolver:       serviceIdResolver,
+	}
+}
+
+func (r *IstioEgressReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	intents := &otterizev1alpha3.ClientIntents{}
+	err := r.Get(ctx, req.NamespacedName, intents)
+	if k8serrors.IsNotFound(err) {
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if intents.Spec == nil {
+		return ctrl.Result{}, nil
+	}
+
+	formattedClient := otterizev1alpha3.GetFormattedOtterizeIdentity(intents.GetServiceName(), intents.Namespace)
+	if !intents.DeletionTimestamp.IsZero() {
+		return r.removeAll(ctx, intents, formattedClient)
+	}
+
+	if !r.enforcementDefaultState {
+		logrus.Infof("Enforcement is disabled globally, skipping Istio egress restriction for service %s in namespace %s", intents.GetServiceName(), intents.Namespace)
+		r.RecordNormalEvent(intents, consts.ReasonEnforcementDefaultOff, "Enforcement is disabled globally, Istio egress restriction skipped")
+		return r.removeAll(ctx, intents, formattedClient)
+	}
+
+	if len(r.RestrictToNamespaces) != 0 && !lo.Contains(r.RestrictToNamespaces, intents.Namespace) {
+		// Namespace is not in list of namespaces we're allowed to act in, so drop it.
+		r.RecordWarningEventf(intents, consts.ReasonNamespaceNotAllowed, "ClientIntents are in namespace %s but namespace is not allowed by configuration", intents.Namespace)
+		return ctrl.Result{}, nil
+	}
+
+	logrus.Infof("Reconciling Istio egress for service %s in namespace %s", intents.GetServiceName(), intents.Namespace)
+
+	// The Sidecar selects the client's pods by label, so it is created even if the client has no pods yet, and applies to
+	// them once they start. It has no effect on pods without sidecars.
+	pod, err := r.serviceIdResolver.ResolveClientIntentToPod(ctx, *intents)
+	if err != nil && !errors.Is(err, serviceidresolver.ErrPodNotFound) {
+		return ctrl.Result{}, err
+	}
+
+	if err == nil && !istiopolicy.IsPodPartOfIstioMesh(pod) {
+		// Sidecar resources only apply to workloads with sidecars.
+		logrus.Infof("Pod %s/%s does not have a sidecar, skipping Istio egress restriction", pod.Namespace, pod.Name)
+		return r.removeAll(ctx, intents, formattedClient)
+	}
+
+	err = r.applyEgress(ctx, intents, formattedClient)
+	if err != nil {
+		if k8serrors.IsConflict(err) {
+			return ctrl.Result{Requeue: true}, nil
+		}
+		r.RecordWarningEventf(intents, consts.ReasonCreatingIstioEgressFailed, "could not restrict Istio egress: %s", err.Error())
+		return ctrl.Result{}, err
+	}
+
+	r.RecordNormalEventf(intents, consts.ReasonCreatedIstioEgress, "Istio egress reconcile complete, reconciled %d targets", len(intents.GetCallsList()))
+	return ctrl.Result{}, nil
+}
+
+func (r *IstioEgressReconciler) applyEgress(ctx context.Context, intents *otterizev1alpha3.ClientIntents, formattedClient string) error {
+	hosts, err := r.buildEgressHosts(ctx, intents)
+	if err != nil {
+		return err
+	}
+
+	serviceEntries := buildServiceEntries(intents, formattedClient)
+	for _, serviceEntry := range serviceEntries {
+		existingServiceEntry := &v1beta1.ServiceEntry{}
+		err = r.createOrPatch(ctx, serviceEntry, existingServiceEntry,
+			func() bool {
+				return proto.Equal(&existingServiceEntry.Spec, &serviceEntry.Spec) && reflect.DeepEqual(existingServiceEntry.Labels, serviceEntry.Labels)
+			},
+			func() {
+				existingServiceEntry.Labels = serviceEntry.Labels
+				existingServiceEntry.Spec.Hosts = serviceEntry.Spec.Hosts
+				existingServiceEntry.Spec.Ports = serviceEntry.Spec.Ports
+				existingServiceEntry.Spec.Location = serviceEntry.Spec.Location
+				existingServiceEntry.Spec.Resolution = serviceEntry.Spec.Resolution
+				existingServiceEntry.Spec.ExportTo = serviceEntry.Spec.ExportTo
+			})
+		if err != nil {
+			return err
+		}
+
+		for _, domain := range serviceEntry.Spec.Hosts {
+			hosts = append(hosts, fmt.Sprintf("./%s", domain))
+		}
+	}
+
+	err = r.removeServiceEntries(ctx, formattedClient, lo.Map(serviceEntries, func(serviceEntry *v1beta1.ServiceEntry, _ int) string {
+		return serviceEntry.Name
+	}))
+	if err != nil {
+		return err
+	}
+
+	hosts = lo.Uniq(hosts)
+	slices.Sort(hosts)
+	sidecar := buildSidecar(intents, formattedClient, hosts)
+	existingSidecar := &v1beta1.Sidecar{}
+	return r.createOrPatch(ctx, sidecar, existingSidecar,
+		func() bool {
+			return otterizev1alpha3.IsManuallyOverridden(existingSidecar) ||
+				proto.Equal(&existingSidecar.Spec, &sidecar.Spec) && reflect.DeepEqual(existingSidecar.Labels, sidecar.Labels)
+		},
+		func() {
+			existingSidecar.Labels = sidecar.Labels
+			existingSidecar.Spec.WorkloadSelector = sidecar.Spec.WorkloadSelector
+			existingSidecar.Spec.Egress = sidecar.Spec.Egress
+			existingSidecar.Spec.OutboundTrafficPolicy = sidecar.Spec.OutboundTrafficPolicy
+		})
+}
+
+// buildEgressHosts returns the Sidecar egress hosts of the services the client calls, in the namespace/dnsName format.
+func (r *IstioEgressReconciler) buildEgressHosts(ctx context.Context, intents *otterizev1alpha3.ClientIntents) ([]string, error) {
+	hosts := []string{fmt.Sprintf("%s/*", r.istioNamespace)}
+	for _, intent := range intents.GetCallsList() {
+		switch intent.Type {
+		case "", otterizev1alpha3.IntentTypeHTTP, otterizev1alpha3.IntentTypeKafka:
+			serverHosts, err := r.buildServerHosts(ctx, intents, intent)
+			if err != nil {
+				return nil, err
+			}
+			hosts = append(hosts, serverHosts...)
+		case otterizev1alpha3.IntentTypeKubernetes:
+			hosts = append(hosts, serviceHost(kubernetesAPIServiceName, kubernetesAPIServiceNamespace))
+		}
+	}
+
+	return hosts, nil
+}
+
+// buildServerHosts returns the hosts of the Kubernetes services of the intent's server. Servers without pods, or with
+// pods that are not targeted by any service, are assumed to have a service named after them.
+func (r *IstioEgressReconciler) buildServerHosts(ctx context.Context, intents *otterizev1alpha3.ClientIntents, intent otterizev1alpha3.Intent) ([]string, error) {
+	serverName := intent.GetTargetServerName()
+	serverNamespace := intent.GetTargetServerNamespace(intents.Namespace)
+	if intent.IsTargetServerKubernetesService() {
+		return []string{serviceHost(serverName, serverNamespace)}, nil
+	}
+
+	pod, err := r.serviceIdResolver.ResolveIntentServerToPod(ctx, intent, serverNamespace)
+	if errors.Is(err, serviceidresolver.ErrPodNotFound) {
+		return []string{serviceHost(serverName, serverNamespace)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	services, err := r.serviceIdResolver.GetKubernetesServicesTargetingPod(ctx, &pod)
+	if err != nil {
+		return nil, err
+	}
+	if len(services) == 0 {
+		return []string{serviceHost(serverName, serverNamespace)}, nil
+	}
+
+	hosts := make([]string, 0, len(services))
+	for _, service := range services {
+		hosts = append(hosts, serviceHost(service.Name, service.Namespace))
+	}
+	return hosts, nil
+}
+
+func serviceHost(name string, namespace string) string {
+	return fmt.Sprintf("%s/%s.%s.svc.%s", namespace, name, namespace, clusterDomain)
+}
+
+func buildSidecar(intents *otterizev1alpha3.ClientIntents, formattedClient string, hosts []string) *v1beta1.Sidecar {
+	return &v1beta1.Sidecar{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf(otterizev1alpha3.OtterizeIstioSidecarNameTemplate, intents.GetServiceName()),
+			Namespace: intents.Namespace,
+			Labels: map[string]string{
+				otterizev1alpha3.OtterizeIstioEgressClientLabelKey: formattedClient,
+			},
+		},
+		Spec: v1beta1networking.Sidecar{
+			WorkloadSelector: &v1beta1networking.WorkloadSelector{
+				Labels: map[string]string{
+					otterizev1alpha3.OtterizeClientLabelKey: formattedClient,
+				},
+			},
+			Egress: []*v1beta1networking.IstioEgressListener{
+				{Hosts: hosts},
+			},
+			OutboundTrafficPolicy: &v1beta1networking.OutboundTrafficPolicy{
+				Mode: v1beta1networking.OutboundTrafficPolicy_REGISTRY_ONLY,
+			},
+		},
+	}
+}
+
+// buildServiceEntries returns a ServiceEntry for each internet intent of the client.
+func buildServiceEntries(intents *otterizev1alpha3.ClientIntents, formattedClient string) []*v1beta1.ServiceEntry {
+	serviceEntries := make([]*v1beta1.ServiceEntry, 0)
+	for _, intent := range intents.GetFilteredCallsList(otterizev1alpha3.IntentTypeInternet) {
+		if intent.Internet == nil || len(intent.Internet.Domains) == 0 {
+			continue
+		}
+
+		ports := intent.Internet.Ports
+		if len(ports) == 0 {
+			ports = []int32{defaultInternetPort}
+		}
+
+		// Wildcard hosts cannot be resolved, so traffic to them is passed through to its original destination.
+		resolution := v1beta1networking.ServiceEntry_DNS
+		if lo.SomeBy(intent.Internet.Domains, func(domain string) bool { return strings.HasPrefix(domain, "*") }) {
+			resolution = v1beta1networking.ServiceEntry_NONE
+		}
+
+		serviceEntries = append(serviceEntries, &v1beta1.ServiceEntry{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf(otterizev1alpha3.OtterizeIstioServiceEntryNameTemplate, intent.Name, intents.GetServiceName()),
+				Namespace: intents.Namespace,
+				Labels: map[string]string{
+					otterizev1alpha3.OtterizeIstioEgressClientLabelKey: formattedClient,
+				},
+			},
+			Spec: v1beta1networking.ServiceEntry{
+				Hosts:      intent.Internet.Domains,
+				Ports:      lo.Map(ports, func(port int32, _ int) *v1beta1networking.ServicePort { return toServicePort(port) }),
+				Location:   v1beta1networking.ServiceEntry_MESH_EXTERNAL,
+				Resolution: resolution,
+				ExportTo:   []string{"."},
+			},
+		})
+	}
+
+	return serviceEntries
+}
+
+// toServicePort infers the protocol of well known ports, so that TLS and HTTP traffic is routed by host. Traffic to
+// other ports is routed by port only.
+func toServicePort(port int32) *v1beta1networking.ServicePort {
+	protocol := "TCP"
+	switch port {
+	case 443:
+		protocol = "TLS"
+	case 80:
+		protocol = "HTTP"
+	}
+
+	return &v1beta1networking.ServicePort{
+		Number:   uint32(port),
+		Protocol: protocol,
+		Name:     fmt.Sprintf("%s-%d", strings.ToLower(protocol), port),
+	}
+}
+
+// createOrPatch creates desired, or patches the existing object of the same name using update when isUpToDate reports
+// that it differs. existing is filled in by the lookup before isUpToDate is called.
+func (r *IstioEgressReconciler) createOrPatch(ctx context.Context, desired client.Object, existing client.Object, isUpToDate func() bool, update func()) error {
+	err := r.Get(ctx, client.ObjectKeyFromObject(desired), existing)
+	if k8serrors.IsNotFound(err) {
+		logrus.Infof("Creating %T %s", desired, client.ObjectKeyFromObject(desired))
+		return r.Create(ctx, desired)
+	}
+	if err != nil {
+		return err
+	}
+
+	if isUpToDate() {
+		return nil
+	}
+
+	original := existing.DeepCopyObject().(client.Object)
+	update()
+	logrus.Infof("Updating %T %s", desired, client.ObjectKeyFromObject(desired))
+	return r.Patch(ctx, existing, client.MergeFrom(original))
+}
+
+// removeServiceEntries deletes the client's ServiceEntries, except for those named in keep.
+func (r *IstioEgressReconciler) removeServiceEntries(ctx context.Context, formattedClient string, keep []string) error {
+	var serviceEntries v1beta1.ServiceEntryList
+	err := r.List(ctx, &serviceEntries, client.MatchingLabels{otterizev1alpha3.OtterizeIstioEgressClientLabelKey: formattedClient})
+	if err != nil {
+		return err
+	}
+
+	for _, serviceEntry := range serviceEntries.Items {
+		if lo.Contains(keep, serviceEntry.Name) {
+			continue
+		}
+		logrus.Infof("Removing ServiceEntry %s/%s", serviceEntry.Namespace, serviceEntry.Name)
+		err = r.Delete(ctx, serviceEntry)
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *IstioEgressReconciler) removeAll(ctx context.Context, intents *otterizev1alpha3.ClientIntents, formattedClient string) (ctrl.Result, error) {
+	err := r.removeEgress(ctx, intents, formattedClient)
+	if err != nil {
+		if k8serrors.IsConflict(err) {
+			return ctrl.Result{Requeue: true}, nil
+		}
+		r.RecordWarningEventf(intents, consts.ReasonRemovingIstioEgressFailed, "could not remove Istio egress restriction: %s", err.Error())
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *IstioEgressReconciler) removeEgress(ctx context.Context, intents *otterizev1alpha3.ClientIntents, formattedClient string) error {
+	sidecar := &v1beta1.Sidecar{}
+	key := types.NamespacedName{Name: fmt.Sprintf(otterizev1alpha3.OtterizeIstioSidecarNameTemplate, intents.GetServiceName()), Namespace: intents.Namespace}
+	err := r.Get(ctx, key, sidecar)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		logrus.Infof("Removing Sidecar %s", key)
+		err = r.Delete(ctx, sidecar)
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return r.removeServiceEntries(ctx, formattedClient, nil)
+}
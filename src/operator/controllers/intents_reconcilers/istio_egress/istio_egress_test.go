@@ -0,0 +1,237 @@
+package istio_egress
+
+import (
+	"context"
+	"fmt"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/consts"
+	"github.com/otterize/intents-operator/src/operator/controllers/istiopolicy"
+	"github.com/otterize/intents-operator/src/shared/serviceidresolver"
+	serviceidresolvermocks "github.com/otterize/intents-operator/src/shared/serviceidresolver/mocks"
+	"github.com/otterize/intents-operator/src/shared/testbase"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/protobuf/proto"
+	v1beta1networking "istio.io/api/networking/v1beta1"
+	"istio.io/client-go/pkg/apis/networking/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"testing"
+	"time"
+)
+
+const (
+	testNamespace  = "test-namespace"
+	testClientName = "test-client"
+)
+
+type IstioEgressReconcilerTestSuite struct {
+	testbase.MocksSuiteBase
+	reconciler      *IstioEgressReconciler
+	serviceResolver *serviceidresolvermocks.MockServiceResolver
+	formattedClient string
+	sidecarKey      types.NamespacedName
+}
+
+func (s *IstioEgressReconcilerTestSuite) SetupTest() {
+	s.MocksSuiteBase.SetupTest()
+	s.serviceResolver = serviceidresolvermocks.NewMockServiceResolver(s.Controller)
+	s.reconciler = NewIstioEgressReconciler(s.Client, nil, nil, s.serviceResolver, true, "istio-system")
+	s.reconciler.Recorder = s.Recorder
+	s.formattedClient = otterizev1alpha3.GetFormattedOtterizeIdentity(testClientName, testNamespace)
+	s.sidecarKey = types.NamespacedName{Namespace: testNamespace, Name: fmt.Sprintf(otterizev1alpha3.OtterizeIstioSidecarNameTemplate, testClientName)}
+}
+
+func (s *IstioEgressReconcilerTestSuite) buildIntents(intents ...otterizev1alpha3.Intent) *otterizev1alpha3.ClientIntents {
+	return &otterizev1alpha3.ClientIntents{
+		ObjectMeta: metav1.ObjectMeta{Name: "client-intents", Namespace: testNamespace},
+		Spec: &otterizev1alpha3.IntentsSpec{
+			Service: otterizev1alpha3.Service{Name: testClientName},
+			Calls:   intents,
+		},
+	}
+}
+
+func (s *IstioEgressReconcilerTestSuite) expectGetIntents(intents *otterizev1alpha3.ClientIntents) {
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Namespace: testNamespace, Name: intents.Name}, gomock.AssignableToTypeOf(&otterizev1alpha3.ClientIntents{})).DoAndReturn(
+		func(_ context.Context, _ types.NamespacedName, obj *otterizev1alpha3.ClientIntents, _ ...client.GetOption) error {
+			intents.DeepCopyInto(obj)
+			return nil
+		})
+}
+
+func (s *IstioEgressReconcilerTestSuite) expectResolveClient(intents *otterizev1alpha3.ClientIntents, containers ...string) {
+	pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-client-pod", Namespace: testNamespace}}
+	for _, container := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: container})
+	}
+	s.serviceResolver.EXPECT().ResolveClientIntentToPod(gomock.Any(), gomock.Eq(*intents)).Return(pod, nil)
+}
+
+func (s *IstioEgressReconcilerTestSuite) expectNotFound(key types.NamespacedName, obj client.Object) {
+	s.Client.EXPECT().Get(gomock.Any(), key, gomock.AssignableToTypeOf(obj)).Return(k8serrors.NewNotFound(schema.GroupResource{}, key.Name))
+}
+
+func (s *IstioEgressReconcilerTestSuite) expectListServiceEntries(serviceEntries ...*v1beta1.ServiceEntry) {
+	s.Client.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&v1beta1.ServiceEntryList{}), client.MatchingLabels{otterizev1alpha3.OtterizeIstioEgressClientLabelKey: s.formattedClient}).DoAndReturn(
+		func(_ context.Context, list *v1beta1.ServiceEntryList, _ ...client.ListOption) error {
+			list.Items = serviceEntries
+			return nil
+		})
+}
+
+func (s *IstioEgressReconcilerTestSuite) reconcile(intents *otterizev1alpha3.ClientIntents) {
+	res, err := s.reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: intents.Name}})
+	s.Require().NoError(err)
+	s.Require().True(res.IsZero())
+}
+
+func (s *IstioEgressReconcilerTestSuite) TestSidecarCreatedForIntents() {
+	intents := s.buildIntents(
+		otterizev1alpha3.Intent{Name: "server", Type: otterizev1alpha3.IntentTypeHTTP},
+		otterizev1alpha3.Intent{Name: "svc:other-server.other-namespace"},
+		otterizev1alpha3.Intent{Name: "missing-server.other-namespace"},
+		otterizev1alpha3.Intent{Name: "stripe", Type: otterizev1alpha3.IntentTypeInternet, Internet: &otterizev1alpha3.Internet{Domains: []string{"api.stripe.com"}}},
+	)
+	s.expectGetIntents(intents)
+	s.expectResolveClient(intents, "app", istiopolicy.IstioProxyContainerName)
+
+	serverPod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "server-pod", Namespace: testNamespace}}
+	s.serviceResolver.EXPECT().ResolveIntentServerToPod(gomock.Any(), intents.Spec.Calls[0], testNamespace).Return(serverPod, nil)
+	s.serviceResolver.EXPECT().GetKubernetesServicesTargetingPod(gomock.Any(), &serverPod).Return([]corev1.Service{
+		{ObjectMeta: metav1.ObjectMeta{Name: "server-svc", Namespace: testNamespace}},
+	}, nil)
+	s.serviceResolver.EXPECT().ResolveIntentServerToPod(gomock.Any(), intents.Spec.Calls[2], "other-namespace").Return(corev1.Pod{}, serviceidresolver.ErrPodNotFound)
+
+	serviceEntryName := fmt.Sprintf(otterizev1alpha3.OtterizeIstioServiceEntryNameTemplate, "stripe", testClientName)
+	s.expectNotFound(types.NamespacedName{Namespace: testNamespace, Name: serviceEntryName}, &v1beta1.ServiceEntry{})
+	s.Client.EXPECT().Create(gomock.Any(), gomock.AssignableToTypeOf(&v1beta1.ServiceEntry{})).DoAndReturn(
+		func(_ context.Context, serviceEntry *v1beta1.ServiceEntry, _ ...client.CreateOption) error {
+			s.Require().Equal(serviceEntryName, serviceEntry.Name)
+			s.Require().True(proto.Equal(&v1beta1networking.ServiceEntry{
+				Hosts:      []string{"api.stripe.com"},
+				Ports:      []*v1beta1networking.ServicePort{{Number: 443, Protocol: "TLS", Name: "tls-443"}},
+				Location:   v1beta1networking.ServiceEntry_MESH_EXTERNAL,
+				Resolution: v1beta1networking.ServiceEntry_DNS,
+				ExportTo:   []string{"."},
+			}, &serviceEntry.Spec))
+			return nil
+		})
+	s.expectListServiceEntries()
+
+	s.expectNotFound(s.sidecarKey, &v1beta1.Sidecar{})
+	s.Client.EXPECT().Create(gomock.Any(), gomock.AssignableToTypeOf(&v1beta1.Sidecar{})).DoAndReturn(
+		func(_ context.Context, sidecar *v1beta1.Sidecar, _ ...client.CreateOption) error {
+			s.Require().Equal(map[string]string{otterizev1alpha3.OtterizeClientLabelKey: s.formattedClient}, sidecar.Spec.WorkloadSelector.Labels)
+			s.Require().Equal(v1beta1networking.OutboundTrafficPolicy_REGISTRY_ONLY, sidecar.Spec.OutboundTrafficPolicy.Mode)
+			s.Require().Len(sidecar.Spec.Egress, 1)
+			s.Require().Equal([]string{
+				"./api.stripe.com",
+				"istio-system/*",
+				"other-namespace/missing-server.other-namespace.svc.cluster.local",
+				"other-namespace/other-server.other-namespace.svc.cluster.local",
+				"test-namespace/server-svc.test-namespace.svc.cluster.local",
+			}, sidecar.Spec.Egress[0].Hosts)
+			return nil
+		})
+
+	s.reconcile(intents)
+	s.ExpectEvent(consts.ReasonCreatedIstioEgress)
+}
+
+func (s *IstioEgressReconcilerTestSuite) TestOutdatedSidecarPatchedAndServiceEntriesRemoved() {
+	intents := s.buildIntents(otterizev1alpha3.Intent{Name: "kubernetes", Type: otterizev1alpha3.IntentTypeKubernetes})
+	s.expectGetIntents(intents)
+	s.expectResolveClient(intents, istiopolicy.IstioProxyContainerName)
+
+	staleServiceEntry := &v1beta1.ServiceEntry{ObjectMeta: metav1.ObjectMeta{Name: "stale", Namespace: testNamespace}}
+	s.expectListServiceEntries(staleServiceEntry)
+	s.Client.EXPECT().Delete(gomock.Any(), staleServiceEntry).Return(nil)
+
+	existingSidecar := buildSidecar(intents, s.formattedClient, []string{"istio-system/*", "./api.stripe.com"})
+	s.Client.EXPECT().Get(gomock.Any(), s.sidecarKey, gomock.AssignableToTypeOf(&v1beta1.Sidecar{})).DoAndReturn(
+		func(_ context.Context, _ types.NamespacedName, sidecar *v1beta1.Sidecar, _ ...client.GetOption) error {
+			existingSidecar.DeepCopyInto(sidecar)
+			return nil
+		})
+	s.Client.EXPECT().Patch(gomock.Any(), gomock.AssignableToTypeOf(&v1beta1.Sidecar{}), gomock.Any()).DoAndReturn(
+		func(_ context.Context, sidecar *v1beta1.Sidecar, _ client.Patch, _ ...client.PatchOption) error {
+			s.Require().Equal([]string{"default/kubernetes.default.svc.cluster.local", "istio-system/*"}, sidecar.Spec.Egress[0].Hosts)
+			return nil
+		})
+
+	s.reconcile(intents)
+	s.ExpectEvent(consts.ReasonCreatedIstioEgress)
+}
+
+func (s *IstioEgressReconcilerTestSuite) TestClientWithoutSidecarRemovesEgress() {
+	intents := s.buildIntents(otterizev1alpha3.Intent{Name: "server"})
+	s.expectGetIntents(intents)
+	s.expectResolveClient(intents, "app")
+
+	s.expectNotFound(s.sidecarKey, &v1beta1.Sidecar{})
+	s.expectListServiceEntries()
+
+	s.reconcile(intents)
+	s.ExpectNoEvent()
+}
+
+func (s *IstioEgressReconcilerTestSuite) TestSidecarCreatedForClientWithoutPods() {
+	intents := s.buildIntents(otterizev1alpha3.Intent{Name: "kubernetes", Type: otterizev1alpha3.IntentTypeKubernetes})
+	s.expectGetIntents(intents)
+	s.serviceResolver.EXPECT().ResolveClientIntentToPod(gomock.Any(), gomock.Any()).Return(corev1.Pod{}, serviceidresolver.ErrPodNotFound)
+
+	s.expectListServiceEntries()
+	s.expectNotFound(s.sidecarKey, &v1beta1.Sidecar{})
+	s.Client.EXPECT().Create(gomock.Any(), gomock.AssignableToTypeOf(&v1beta1.Sidecar{})).DoAndReturn(
+		func(_ context.Context, sidecar *v1beta1.Sidecar, _ ...client.CreateOption) error {
+			s.Require().Equal(map[string]string{otterizev1alpha3.OtterizeClientLabelKey: s.formattedClient}, sidecar.Spec.WorkloadSelector.Labels)
+			return nil
+		})
+
+	s.reconcile(intents)
+	s.ExpectEvent(consts.ReasonCreatedIstioEgress)
+}
+
+func (s *IstioEgressReconcilerTestSuite) TestDeletedIntentsRemoveEgress() {
+	intents := s.buildIntents(otterizev1alpha3.Intent{Name: "server"})
+	intents.DeletionTimestamp = lo.ToPtr(metav1.NewTime(time.Now()))
+	s.expectGetIntents(intents)
+
+	sidecar := buildSidecar(intents, s.formattedClient, nil)
+	s.Client.EXPECT().Get(gomock.Any(), s.sidecarKey, gomock.AssignableToTypeOf(&v1beta1.Sidecar{})).DoAndReturn(
+		func(_ context.Context, _ types.NamespacedName, obj *v1beta1.Sidecar, _ ...client.GetOption) error {
+			sidecar.DeepCopyInto(obj)
+			return nil
+		})
+	s.Client.EXPECT().Delete(gomock.Any(), gomock.AssignableToTypeOf(&v1beta1.Sidecar{})).Return(nil)
+	serviceEntry := &v1beta1.ServiceEntry{ObjectMeta: metav1.ObjectMeta{Name: "service-entry", Namespace: testNamespace}}
+	s.expectListServiceEntries(serviceEntry)
+	s.Client.EXPECT().Delete(gomock.Any(), serviceEntry).Return(nil)
+
+	s.reconcile(intents)
+}
+
+func (s *IstioEgressReconcilerTestSuite) TestBuildServiceEntriesForWildcardDomains() {
+	intents := s.buildIntents(otterizev1alpha3.Intent{
+		Name:     "google",
+		Type:     otterizev1alpha3.IntentTypeInternet,
+		Internet: &otterizev1alpha3.Internet{Domains: []string{"*.googleapis.com"}, Ports: []int32{80, 5432}},
+	})
+
+	serviceEntries := buildServiceEntries(intents, s.formattedClient)
+	s.Require().Len(serviceEntries, 1)
+	s.Require().Equal(v1beta1networking.ServiceEntry_NONE, serviceEntries[0].Spec.Resolution)
+	s.Require().True(proto.Equal(&v1beta1networking.ServicePort{Number: 80, Protocol: "HTTP", Name: "http-80"}, serviceEntries[0].Spec.Ports[0]))
+	s.Require().True(proto.Equal(&v1beta1networking.ServicePort{Number: 5432, Protocol: "TCP", Name: "tcp-5432"}, serviceEntries[0].Spec.Ports[1]))
+}
+
+func TestIstioEgressReconcilerTestSuite(t *testing.T) {
+	suite.Run(t, new(IstioEgressReconcilerTestSuite))
+}
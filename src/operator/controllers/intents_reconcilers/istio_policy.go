@@ -8,7 +8,9 @@ import (
 	istiopolicy "github.com/otterize/intents-operator/src/operator/controllers/istiopolicy"
 	"github.com/otterize/intents-operator/src/shared/injectablerecorder"
 	"github.com/otterize/intents-operator/src/shared/serviceidresolver"
+	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -31,7 +33,9 @@ func NewIstioPolicyReconciler(
 	s *runtime.Scheme,
 	restrictToNamespaces []string,
 	enableIstioPolicyCreation bool,
-	enforcementDefaultState bool) *IstioPolicyReconciler {
+	enforcementDefaultState bool,
+	trustDomain istiopolicy.TrustDomain,
+	consolidatePolicies bool) *IstioPolicyReconciler {
 	reconciler := &IstioPolicyReconciler{
 		Client:                    c,
 		Scheme:                    s,
@@ -42,7 +46,7 @@ func NewIstioPolicyReconciler(
 	}
 
 	reconciler.policyManager = istiopolicy.NewPolicyManager(c, &reconciler.InjectableRecorder, restrictToNamespaces,
-		reconciler.enforcementDefaultState, reconciler.enableIstioPolicyCreation)
+		reconciler.enforcementDefaultState, reconciler.enableIstioPolicyCreation, trustDomain, consolidatePolicies)
 
 	return reconciler
 }
@@ -76,9 +80,6 @@ func (r *IstioPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 	if !intents.DeletionTimestamp.IsZero() {
 		err := r.policyManager.DeleteAll(ctx, intents)
-		if err != nil {
-			return ctrl.Result{}, err
-		}
 		if err != nil {
 			if k8serrors.IsConflict(err) {
 				return ctrl.Result{Requeue: true}, nil
@@ -92,6 +93,11 @@ func (r *IstioPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	pod, err := r.serviceIdResolver.ResolveClientIntentToPod(ctx, *intents)
 	if err != nil {
 		if errors.Is(err, serviceidresolver.ErrPodNotFound) {
+			if isRemoteOnlyClient(intents) {
+				logrus.Infof("Service %s in namespace %s has no pods in this cluster, creating Istio policies for its remote clients",
+					intents.Spec.Service.Name, intents.Namespace)
+				return r.createPolicies(ctx, intents, "")
+			}
 			r.RecordWarningEventf(
 				intents,
 				consts.ReasonPodsNotFound,
@@ -105,7 +111,11 @@ func (r *IstioPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	}
 
 	clientServiceAccountName := pod.Spec.ServiceAccountName
-	missingSideCar := !istiopolicy.IsPodPartOfIstioMesh(pod)
+	clientEnrollment, err := istiopolicy.ResolveMeshEnrollment(ctx, r.Client, r.serviceIdResolver, pod)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	missingSideCar := !clientEnrollment.IsEnrolled()
 
 	err = r.policyManager.UpdateIntentsStatus(ctx, intents, clientServiceAccountName, missingSideCar)
 	if err != nil {
@@ -123,7 +133,11 @@ func (r *IstioPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, nil
 	}
 
-	err = r.policyManager.Create(ctx, intents, clientServiceAccountName)
+	return r.createPolicies(ctx, intents, clientServiceAccountName)
+}
+
+func (r *IstioPolicyReconciler) createPolicies(ctx context.Context, intents *otterizev1alpha3.ClientIntents, clientServiceAccountName string) (ctrl.Result, error) {
+	err := r.policyManager.Create(ctx, intents, clientServiceAccountName)
 	if err != nil {
 		if k8serrors.IsConflict(err) {
 			return ctrl.Result{Requeue: true}, nil
@@ -134,10 +148,33 @@ func (r *IstioPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	return ctrl.Result{}, nil
 }
 
+// updateServerAmbientStatus records the ambient status of servers that are, or were, in ambient mode. Intents calling
+// servers with sidecars only are left as is.
+func updateServerAmbientStatus(ctx context.Context, policyManager istiopolicy.PolicyManager, intents *otterizev1alpha3.ClientIntents, formattedServer string, enrollment istiopolicy.MeshEnrollment) error {
+	ambientServers, err := intents.GetAmbientServers()
+	if err != nil {
+		return err
+	}
+
+	if _, wasAmbient := ambientServers[formattedServer]; !wasAmbient && !enrollment.Ambient {
+		return nil
+	}
+
+	return policyManager.UpdateServerAmbientStatus(ctx, intents, formattedServer, enrollment.AmbientServer())
+}
+
+// isRemoteOnlyClient returns whether every remote client of the intents names its service account, so policies can be
+// created for it while the client has no pods in this cluster.
+func isRemoteOnlyClient(intents *otterizev1alpha3.ClientIntents) bool {
+	return len(intents.Spec.RemoteClients) != 0 && lo.EveryBy(intents.Spec.RemoteClients, func(remoteClient otterizev1alpha3.RemoteClient) bool {
+		return remoteClient.ServiceAccountName != ""
+	})
+}
+
 func (r *IstioPolicyReconciler) updateServerSidecarStatus(ctx context.Context, intents *otterizev1alpha3.ClientIntents) error {
 	for _, intent := range intents.Spec.Calls {
 		serverNamespace := intent.GetTargetServerNamespace(intents.Namespace)
-		pod, err := r.serviceIdResolver.ResolveIntentServerToPod(ctx, intent, serverNamespace)
+		pod, err := r.resolveServerPod(ctx, intent, serverNamespace)
 		if err != nil {
 			if errors.Is(err, serviceidresolver.ErrPodNotFound) {
 				continue
@@ -145,9 +182,18 @@ func (r *IstioPolicyReconciler) updateServerSidecarStatus(ctx context.Context, i
 			return err
 		}
 
-		missingSideCar := !istiopolicy.IsPodPartOfIstioMesh(pod)
+		enrollment, err := istiopolicy.ResolveMeshEnrollment(ctx, r.Client, r.serviceIdResolver, pod)
+		if err != nil {
+			return err
+		}
+
 		formattedTargetServer := otterizev1alpha3.GetFormattedOtterizeIdentity(intent.GetTargetServerName(), serverNamespace)
-		err = r.policyManager.UpdateServerSidecar(ctx, intents, formattedTargetServer, missingSideCar)
+		err = r.policyManager.UpdateServerSidecar(ctx, intents, formattedTargetServer, !enrollment.IsEnrolled())
+		if err != nil {
+			return err
+		}
+
+		err = updateServerAmbientStatus(ctx, r.policyManager, intents, formattedTargetServer, enrollment)
 		if err != nil {
 			return err
 		}
@@ -155,3 +201,12 @@ func (r *IstioPolicyReconciler) updateServerSidecarStatus(ctx context.Context, i
 
 	return nil
 }
+
+// resolveServerPod returns a pod of the intent's server. Kubernetes service targets are resolved through the service's
+// selector, as their pods are not labelled with the service's name.
+func (r *IstioPolicyReconciler) resolveServerPod(ctx context.Context, intent otterizev1alpha3.Intent, serverNamespace string) (corev1.Pod, error) {
+	if intent.IsTargetServerKubernetesService() {
+		return istiopolicy.ResolveKubernetesServiceToPod(ctx, r.Client, intent.GetTargetServerName(), serverNamespace)
+	}
+	return r.serviceIdResolver.ResolveIntentServerToPod(ctx, intent, serverNamespace)
+}
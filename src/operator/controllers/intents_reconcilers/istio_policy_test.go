@@ -5,6 +5,7 @@ import (
 	"fmt"
 	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
 	mocks "github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/mocks"
+	"github.com/otterize/intents-operator/src/operator/controllers/istiopolicy"
 	"github.com/otterize/intents-operator/src/shared/testbase"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/mock/gomock"
@@ -42,6 +43,8 @@ func (s *IstioPolicyReconcilerTestSuite) SetupTest() {
 		restrictToNamespaces,
 		true,
 		true,
+		istiopolicy.TrustDomain{},
+		false,
 	)
 
 	s.Reconciler.Recorder = s.Recorder
@@ -298,6 +301,59 @@ func (s *IstioPolicyReconcilerTestSuite) TestIstioPolicyDeleted() {
 	s.Empty(res)
 }
 
+func (s *IstioPolicyReconcilerTestSuite) TestCreatePolicyAmbientMode() {
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: "client-intents"}}
+	intents := otterizev1alpha3.ClientIntents{
+		ObjectMeta: metav1.ObjectMeta{Name: "client-intents", Namespace: testNamespace},
+		Spec: &otterizev1alpha3.IntentsSpec{
+			Service: otterizev1alpha3.Service{Name: "test-client"},
+			Calls:   []otterizev1alpha3.Intent{{Name: "test-server", Type: otterizev1alpha3.IntentTypeHTTP}},
+		},
+	}
+	ambientNamespace := v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   testNamespace,
+			Labels: map[string]string{istiopolicy.IstioDataplaneModeLabel: istiopolicy.IstioDataplaneModeAmbient, istiopolicy.IstioUseWaypointLabel: "waypoint"},
+		},
+	}
+	clientPod := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-client-fdae32", Namespace: testNamespace},
+		Spec:       v1.PodSpec{ServiceAccountName: "test-client-sa", Containers: []v1.Container{{Name: "client"}}},
+	}
+	serverPod := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-server-2b5e0d", Namespace: testNamespace},
+		Spec:       v1.PodSpec{ServiceAccountName: "test-server-sa", Containers: []v1.Container{{Name: "server"}}},
+	}
+
+	s.expectValidatingIstioIsInstalled()
+	s.Client.EXPECT().Get(gomock.Any(), req.NamespacedName, gomock.Eq(&otterizev1alpha3.ClientIntents{})).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, obj *otterizev1alpha3.ClientIntents, options ...client.GetOption) error {
+			intents.DeepCopyInto(obj)
+			return nil
+		})
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Name: testNamespace}, gomock.AssignableToTypeOf(&v1.Namespace{})).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, namespace *v1.Namespace, options ...client.GetOption) error {
+			ambientNamespace.DeepCopyInto(namespace)
+			return nil
+		}).Times(2)
+	s.serviceResolver.EXPECT().ResolveClientIntentToPod(gomock.Any(), gomock.Eq(intents)).Return(clientPod, nil)
+	s.serviceResolver.EXPECT().GetKubernetesServicesTargetingPod(gomock.Any(), gomock.Eq(&clientPod)).Return(nil, nil)
+	s.policyAdmin.EXPECT().UpdateIntentsStatus(gomock.Any(), gomock.Eq(&intents), "test-client-sa", false).Return(nil)
+	s.serviceResolver.EXPECT().ResolveIntentServerToPod(gomock.Any(), gomock.Eq(intents.Spec.Calls[0]), testNamespace).Return(serverPod, nil)
+	s.serviceResolver.EXPECT().GetKubernetesServicesTargetingPod(gomock.Any(), gomock.Eq(&serverPod)).Return([]v1.Service{
+		{ObjectMeta: metav1.ObjectMeta{Name: "test-server", Namespace: testNamespace}},
+	}, nil)
+	formattedServer := otterizev1alpha3.GetFormattedOtterizeIdentity("test-server", testNamespace)
+	s.policyAdmin.EXPECT().UpdateServerSidecar(gomock.Any(), gomock.Eq(&intents), formattedServer, false).Return(nil)
+	s.policyAdmin.EXPECT().UpdateServerAmbientStatus(gomock.Any(), gomock.Eq(&intents), formattedServer,
+		&otterizev1alpha3.AmbientServer{Waypoint: "waypoint", Services: []string{"test-server"}}).Return(nil)
+	s.policyAdmin.EXPECT().Create(gomock.Any(), gomock.Eq(&intents), "test-client-sa").Return(nil)
+
+	res, err := s.Reconciler.Reconcile(context.Background(), req)
+	s.NoError(err)
+	s.Empty(res)
+}
+
 func TestIstioPolicyReconcilerTestSuite(t *testing.T) {
 	suite.Run(t, new(IstioPolicyReconcilerTestSuite))
 }
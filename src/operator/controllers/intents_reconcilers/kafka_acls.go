@@ -2,6 +2,7 @@ package intents_reconcilers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
 	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/consts"
@@ -9,10 +10,13 @@ import (
 	"github.com/otterize/intents-operator/src/operator/controllers/kafkaacls"
 	"github.com/otterize/intents-operator/src/shared/injectablerecorder"
 	"github.com/otterize/intents-operator/src/shared/serviceidresolver"
+	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"reflect"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -83,8 +87,32 @@ func getIntentsByServer(defaultNamespace string, intents []otterizev1alpha3.Inte
 	return intentsByServer
 }
 
+// hasAppliedQuotas returns whether quotas were applied to the client on the server, according to the status of its
+// intents.
+func hasAppliedQuotas(intents *otterizev1alpha3.ClientIntents, serverName types.NamespacedName) bool {
+	return lo.ContainsBy(lo.FromPtr(intents.Status).KafkaQuotas, func(quotas otterizev1alpha3.KafkaServerQuotas) bool {
+		return quotas.Server == formatQuotasServer(serverName)
+	})
+}
+
+func formatQuotasServer(serverName types.NamespacedName) string {
+	return fmt.Sprintf("%s.%s", serverName.Name, serverName.Namespace)
+}
+
+// getQuotas returns the quotas of the first intent that declares them.
+func getQuotas(intents []otterizev1alpha3.Intent) *otterizev1alpha3.KafkaQuotas {
+	intent, found := lo.Find(intents, func(intent otterizev1alpha3.Intent) bool {
+		return intent.Quotas != nil
+	})
+	if !found {
+		return nil
+	}
+	return intent.Quotas
+}
+
 func (r *KafkaACLReconciler) applyACLs(ctx context.Context, intents *otterizev1alpha3.ClientIntents) (serverCount int, err error) {
 	intentsByServer := getIntentsByServer(intents.Namespace, intents.Spec.Calls)
+	appliedQuotas := make([]otterizev1alpha3.KafkaServerQuotas, 0)
 
 	if err := r.KafkaServersStore.MapErr(func(serverName types.NamespacedName, config *otterizev1alpha3.KafkaServerConfig, tls otterizev1alpha3.TLSSource) error {
 		intentsForServer := intentsByServer[serverName]
@@ -98,6 +126,13 @@ func (r *KafkaACLReconciler) applyACLs(ctx context.Context, intents *otterizev1a
 			r.RecordNormalEventf(intents, consts.ReasonEnforcementDefaultOff, "Enforcement is disabled globally and called service '%s' is not explicitly protected using a ProtectedService resource, Kafka ACL creation skipped", serverName.Name)
 			// Intentionally no return - KafkaIntentsAdminImpl skips the creation, but still needs to do deletion.
 		}
+		clientServiceAccount, err := r.resolveClientServiceAccount(ctx, intents, config)
+		if err != nil {
+			err = fmt.Errorf("failed resolving service account of client for principal template of Kafka server %s: %w", serverName, err)
+			r.RecordWarningEventf(intents, ReasonCouldNotApplyIntentsOnKafkaServer, "Kafka ACL reconcile failed: %s", err.Error())
+			return err
+		}
+
 		kafkaIntentsAdmin, err := r.getNewKafkaIntentsAdmin(*config, tls, r.enableKafkaACLCreation, shouldCreatePolicy)
 		if err != nil {
 			err = fmt.Errorf("failed to connect to Kafka server %s: %w", serverName, err)
@@ -105,15 +140,41 @@ func (r *KafkaACLReconciler) applyACLs(ctx context.Context, intents *otterizev1a
 			return err
 		}
 		defer kafkaIntentsAdmin.Close()
-		if err := kafkaIntentsAdmin.ApplyClientIntents(intents.Spec.Service.Name, intents.Namespace, intentsForServer); err != nil {
+		if err := kafkaIntentsAdmin.ApplyClientIntents(intents.Spec.Service.Name, intents.Namespace, clientServiceAccount, intentsForServer); err != nil {
 			r.RecordWarningEventf(intents, ReasonCouldNotApplyIntentsOnKafkaServer, "Kafka ACL reconcile failed: %s", err.Error())
 			return fmt.Errorf("failed applying intents on kafka server %s: %w", serverName, err)
 		}
+		if r.enableKafkaACLCreation && shouldCreatePolicy && len(intentsForServer) != 0 {
+			principal := kafkaIntentsAdmin.FormatClientPrincipal(intents.Spec.Service.Name, intents.Namespace, clientServiceAccount)
+			if err := kafkaacls.RecordManagedPrincipal(ctx, r.client, types.NamespacedName{Name: config.Name, Namespace: config.Namespace}, principal); err != nil {
+				return err
+			}
+		}
+
+		declaredQuotas := getQuotas(intentsForServer)
+		if declaredQuotas == nil && !hasAppliedQuotas(intents, serverName) {
+			return nil
+		}
+		quotas, err := kafkaIntentsAdmin.ApplyClientQuotas(intents.Spec.Service.Name, intents.Namespace, clientServiceAccount, declaredQuotas)
+		if err != nil {
+			r.RecordWarningEventf(intents, ReasonCouldNotApplyIntentsOnKafkaServer, "Kafka quotas reconcile failed: %s", err.Error())
+			return fmt.Errorf("failed applying quotas on kafka server %s: %w", serverName, err)
+		}
+		if !lo.IsEmpty(quotas) {
+			appliedQuotas = append(appliedQuotas, otterizev1alpha3.KafkaServerQuotas{
+				Server:      formatQuotasServer(serverName),
+				KafkaQuotas: quotas,
+			})
+		}
 		return nil
 	}); err != nil {
 		return 0, err
 	}
 
+	if err := r.updateQuotasStatus(ctx, intents, appliedQuotas); err != nil {
+		return 0, err
+	}
+
 	if !r.enableKafkaACLCreation {
 		r.RecordNormalEvent(intents, ReasonKafkaACLCreationDisabled, "Kafka ACL creation is disabled, creation skipped")
 	}
@@ -128,6 +189,26 @@ func (r *KafkaACLReconciler) applyACLs(ctx context.Context, intents *otterizev1a
 	return len(intentsByServer), nil
 }
 
+func (r *KafkaACLReconciler) updateQuotasStatus(ctx context.Context, intents *otterizev1alpha3.ClientIntents, appliedQuotas []otterizev1alpha3.KafkaServerQuotas) error {
+	slices.SortFunc(appliedQuotas, func(a, b otterizev1alpha3.KafkaServerQuotas) bool {
+		return a.Server < b.Server
+	})
+	currentQuotas := lo.FromPtr(intents.Status).KafkaQuotas
+	if (len(currentQuotas) == 0 && len(appliedQuotas) == 0) || reflect.DeepEqual(currentQuotas, appliedQuotas) {
+		return nil
+	}
+
+	intentsCopy := intents.DeepCopy()
+	if intentsCopy.Status == nil {
+		intentsCopy.Status = &otterizev1alpha3.IntentsStatus{}
+	}
+	intentsCopy.Status.KafkaQuotas = appliedQuotas
+	if err := r.client.Status().Patch(ctx, intentsCopy, client.MergeFrom(intents)); err != nil {
+		return fmt.Errorf("failed updating Kafka quotas status: %w", err)
+	}
+	return nil
+}
+
 func (r *KafkaACLReconciler) RemoveACLs(ctx context.Context, intents *otterizev1alpha3.ClientIntents) error {
 	return r.KafkaServersStore.MapErr(func(serverName types.NamespacedName, config *otterizev1alpha3.KafkaServerConfig, tls otterizev1alpha3.TLSSource) error {
 		shouldCreatePolicy, err := protected_services.IsServerEnforcementEnabledDueToProtectionOrDefaultState(ctx, r.client, serverName.Name, serverName.Namespace, r.enforcementDefaultState)
@@ -135,6 +216,15 @@ func (r *KafkaACLReconciler) RemoveACLs(ctx context.Context, intents *otterizev1
 			return err
 		}
 
+		clientServiceAccount, err := r.resolveClientServiceAccount(ctx, intents, config)
+		if errors.Is(err, serviceidresolver.ErrPodNotFound) {
+			// The client's principal cannot be formatted, its ACLs are removed by garbage collection instead.
+			logrus.WithField("server", serverName).Warning("Could not remove Kafka ACLs - no pods found for resolving the client's service account")
+			return nil
+		} else if err != nil {
+			return err
+		}
+
 		// We just pass shouldCreatePolicy to the KafkaIntentsAdmin - it determines whether to create or delete.
 		kafkaIntentsAdmin, err := r.getNewKafkaIntentsAdmin(*config, tls, r.enableKafkaACLCreation, shouldCreatePolicy)
 		if err != nil {
@@ -142,13 +232,32 @@ func (r *KafkaACLReconciler) RemoveACLs(ctx context.Context, intents *otterizev1
 		}
 		defer kafkaIntentsAdmin.Close()
 
-		if err := kafkaIntentsAdmin.RemoveClientIntents(intents.Spec.Service.Name, intents.Namespace); err != nil {
+		if hasAppliedQuotas(intents, serverName) {
+			if _, err := kafkaIntentsAdmin.ApplyClientQuotas(intents.Spec.Service.Name, intents.Namespace, clientServiceAccount, nil); err != nil {
+				return fmt.Errorf("failed removing quotas from kafka server %s: %w", serverName, err)
+			}
+		}
+		if err := kafkaIntentsAdmin.RemoveClientIntents(intents.Spec.Service.Name, intents.Namespace, clientServiceAccount); err != nil {
 			return fmt.Errorf("failed removing intents from kafka server %s: %w", serverName, err)
 		}
 		return nil
 	})
 }
 
+// resolveClientServiceAccount returns the service account of the client's pods, if the Kafka server's principal template
+// uses it.
+func (r *KafkaACLReconciler) resolveClientServiceAccount(ctx context.Context, intents *otterizev1alpha3.ClientIntents, config *otterizev1alpha3.KafkaServerConfig) (string, error) {
+	if !kafkaacls.PrincipalTemplateUsesServiceAccount(config.Spec.PrincipalTemplate) {
+		return "", nil
+	}
+
+	pod, err := r.serviceResolver.ResolveClientIntentToPod(ctx, *intents)
+	if err != nil {
+		return "", err
+	}
+	return pod.Spec.ServiceAccountName, nil
+}
+
 func (r *KafkaACLReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	intents := &otterizev1alpha3.ClientIntents{}
 	logger := logrus.WithField("namespacedName", req.String())
@@ -18,6 +18,7 @@ import (
 	"go.uber.org/mock/gomock"
 	istiosecurityscheme "istio.io/client-go/pkg/apis/security/v1beta1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/kubernetes"
@@ -69,8 +70,9 @@ func (s *KafkaACLReconcilerTestSuite) SetupSuite() {
 	utilruntime.Must(otterizev1alpha3.AddToScheme(s.TestEnv.Scheme))
 }
 
-func (s *KafkaACLReconcilerTestSuite) setupServerStore(serviceName string) *kafkaacls.ServersStoreImpl {
+func (s *KafkaACLReconcilerTestSuite) newServerConfig(serviceName string) *otterizev1alpha3.KafkaServerConfig {
 	serverConfig := &otterizev1alpha3.KafkaServerConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: serviceName, Namespace: s.TestNamespace},
 		Spec: otterizev1alpha3.KafkaServerConfigSpec{
 			Service: otterizev1alpha3.Service{
 				Name: serviceName,
@@ -85,9 +87,22 @@ func (s *KafkaACLReconcilerTestSuite) setupServerStore(serviceName string) *kafk
 		},
 	}
 
-	serverConfig.SetNamespace(s.TestNamespace)
+	return serverConfig
+}
+
+// addServerConfig creates the KafkaServerConfig, which the reconciler records managed principals in.
+func (s *KafkaACLReconcilerTestSuite) addServerConfig(serviceName string) {
+	serverConfig := s.newServerConfig(serviceName)
+	s.Require().NoError(s.Mgr.GetClient().Create(context.Background(), serverConfig))
+	s.WaitUntilCondition(func(assert *assert.Assertions) {
+		assert.NoError(s.Mgr.GetClient().Get(context.Background(), types.NamespacedName{Name: serviceName, Namespace: s.TestNamespace}, &otterizev1alpha3.KafkaServerConfig{}))
+	})
+}
+
+func (s *KafkaACLReconcilerTestSuite) setupServerStore(serviceName string) *kafkaacls.ServersStoreImpl {
+	serverConfig := s.newServerConfig(serviceName)
 	emptyTls := otterizev1alpha3.TLSSource{}
-	kafkaServersStore := kafkaacls.NewServersStore(emptyTls, true, kafkaacls.NewKafkaIntentsAdmin, true)
+	kafkaServersStore := kafkaacls.NewServersStore(emptyTls, true, kafkaacls.NewKafkaIntentsAdmin, true, nil)
 	kafkaServersStore.Add(serverConfig)
 	return kafkaServersStore
 }
@@ -103,6 +118,7 @@ func (s *KafkaACLReconcilerTestSuite) BeforeTest(_, testName string) {
 	s.mockKafkaAdmin = kafkaaclsmocks.NewMockClusterAdmin(controller)
 	s.mockServiceResolver = intentsreconcilersmocks.NewMockServiceResolver(controller)
 
+	s.addServerConfig(kafkaServiceName)
 	s.initKafkaIntentsAdmin(true, true)
 }
 
@@ -0,0 +1,129 @@
+package kafka_listener_ports
+
+import (
+	"context"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/kafka_server_config_reconcilers"
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"net"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strconv"
+)
+
+// GetClientPorts returns the ports egress network policies for the client of intentsObj allow on the intent's target
+// server, which are those of all the client's intents to the server, or nil if they allow all of its ports. Kafka
+// intents to servers whose KafkaServerConfig declares listener ports are limited to the client listener ports, or to the
+// admin listener port for the intents the operator creates to the server in operatorNamespace.
+func GetClientPorts(ctx context.Context, kube client.Client, intentsObj *otterizev1alpha3.ClientIntents, intent otterizev1alpha3.Intent, operatorNamespace string) ([]v1.NetworkPolicyPort, error) {
+	return getServerPorts(ctx, kube, intentsObj, intent, operatorNamespace, func(clientIntents otterizev1alpha3.ClientIntents) bool {
+		return clientIntents.GetServiceName() == intentsObj.GetServiceName()
+	})
+}
+
+// GetNamespacePorts returns the ports network policies allowing access from the namespace of intentsObj to the intent's
+// target server allow, which are those of all intents to the server in the namespace, or nil if they allow all of its
+// ports.
+func GetNamespacePorts(ctx context.Context, kube client.Client, intentsObj *otterizev1alpha3.ClientIntents, intent otterizev1alpha3.Intent, operatorNamespace string) ([]v1.NetworkPolicyPort, error) {
+	return getServerPorts(ctx, kube, intentsObj, intent, operatorNamespace, func(otterizev1alpha3.ClientIntents) bool {
+		return true
+	})
+}
+
+// getServerPorts returns the ports of the intents to the intent's target server, of the ClientIntents in the namespace
+// of intentsObj that match shouldInclude.
+func getServerPorts(
+	ctx context.Context,
+	kube client.Client,
+	intentsObj *otterizev1alpha3.ClientIntents,
+	intent otterizev1alpha3.Intent,
+	operatorNamespace string,
+	shouldInclude func(otterizev1alpha3.ClientIntents) bool,
+) ([]v1.NetworkPolicyPort, error) {
+	if intent.Type != otterizev1alpha3.IntentTypeKafka {
+		return nil, nil
+	}
+
+	namespace := intentsObj.Namespace
+	kafkaServerConfig, ok, err := getKafkaServerConfig(ctx, kube, intent.GetTargetServerName(), intent.GetTargetServerNamespace(namespace))
+	if err != nil || !ok || kafkaServerConfig.Spec.ListenerPorts == nil {
+		return nil, err
+	}
+
+	var intentsList otterizev1alpha3.ClientIntentsList
+	err = kube.List(
+		ctx, &intentsList,
+		&client.MatchingFields{otterizev1alpha3.OtterizeTargetServerIndexField: intent.GetServerFullyQualifiedName(namespace)},
+		&client.ListOptions{Namespace: namespace})
+	if err != nil {
+		return nil, err
+	}
+
+	ports := listenerPorts(kafkaServerConfig, intentsObj, operatorNamespace)
+	for _, clientIntents := range intentsList.Items {
+		if !clientIntents.DeletionTimestamp.IsZero() || !shouldInclude(clientIntents) {
+			continue
+		}
+		for _, call := range clientIntents.GetCallsList() {
+			if call.GetServerFullyQualifiedName(namespace) != intent.GetServerFullyQualifiedName(namespace) {
+				continue
+			}
+			if call.Type != otterizev1alpha3.IntentTypeKafka {
+				// Other intents to the server are not limited to its Kafka listeners.
+				return nil, nil
+			}
+			ports = ports.Union(listenerPorts(kafkaServerConfig, &clientIntents, operatorNamespace))
+		}
+	}
+	return toNetworkPolicyPorts(ports), nil
+}
+
+func getKafkaServerConfig(ctx context.Context, kube client.Client, serverName string, serverNamespace string) (otterizev1alpha3.KafkaServerConfig, bool, error) {
+	var kafkaServerConfigs otterizev1alpha3.KafkaServerConfigList
+	err := kube.List(ctx, &kafkaServerConfigs,
+		client.MatchingFields{otterizev1alpha3.OtterizeKafkaServerConfigServiceNameField: serverName},
+		client.InNamespace(serverNamespace))
+	if err != nil {
+		return otterizev1alpha3.KafkaServerConfig{}, false, err
+	}
+
+	kafkaServerConfig, ok := lo.Find(kafkaServerConfigs.Items, func(config otterizev1alpha3.KafkaServerConfig) bool {
+		return config.DeletionTimestamp.IsZero()
+	})
+	return kafkaServerConfig, ok, nil
+}
+
+// listenerPorts returns the listener ports the ClientIntents use on the server, which is the admin listener port for the
+// intents the operator creates to it in operatorNamespace.
+func listenerPorts(kafkaServerConfig otterizev1alpha3.KafkaServerConfig, clientIntents *otterizev1alpha3.ClientIntents, operatorNamespace string) sets.Set[int32] {
+	isOperatorIntents := clientIntents.Name == kafka_server_config_reconcilers.FormatIntentsName(&kafkaServerConfig) && clientIntents.Namespace == operatorNamespace
+	if !isOperatorIntents {
+		return sets.New(kafkaServerConfig.Spec.ListenerPorts.Client...)
+	}
+
+	if kafkaServerConfig.Spec.ListenerPorts.Admin != 0 {
+		return sets.New(kafkaServerConfig.Spec.ListenerPorts.Admin)
+	}
+
+	_, port, err := net.SplitHostPort(kafkaServerConfig.Spec.Addr)
+	if err != nil {
+		return sets.New(kafkaServerConfig.Spec.ListenerPorts.Client...)
+	}
+	addrPort, err := strconv.ParseInt(port, 10, 32)
+	if err != nil {
+		return sets.New(kafkaServerConfig.Spec.ListenerPorts.Client...)
+	}
+	return sets.New(int32(addrPort))
+}
+
+func toNetworkPolicyPorts(ports sets.Set[int32]) []v1.NetworkPolicyPort {
+	return lo.Map(sets.List(ports), func(port int32, _ int) v1.NetworkPolicyPort {
+		return v1.NetworkPolicyPort{
+			Protocol: lo.ToPtr(corev1.ProtocolTCP),
+			Port:     &intstr.IntOrString{IntVal: port},
+		}
+	})
+}
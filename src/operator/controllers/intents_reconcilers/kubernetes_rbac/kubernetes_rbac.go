@@ -0,0 +1,453 @@
+package kubernetes_rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/consts"
+	"github.com/otterize/intents-operator/src/shared/injectablerecorder"
+	"github.com/otterize/intents-operator/src/shared/serviceidresolver"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"net"
+	"reflect"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	kubernetesAPIServiceName      = "kubernetes"
+	kubernetesAPIServiceNamespace = "default"
+)
+
+// The operator can only grant permissions it holds itself; the intents webhook checks that authors hold them as well.
+//+kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=roles;rolebindings;clusterroles;clusterrolebindings,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=endpoints,verbs=get;list;watch
+
+// KubernetesRBACReconciler grants clients the Kubernetes API access declared by their kubernetes intents. It manages a
+// Role and RoleBinding for the client's service account in every namespace the intents refer to, and a ClusterRole and
+// ClusterRoleBinding for cluster-wide access. All of them are labeled with the client, as RoleBindings in other
+// namespaces cannot be owned by the ClientIntents.
+type KubernetesRBACReconciler struct {
+	client.Client
+	Scheme                      *runtime.Scheme
+	RestrictToNamespaces        []string
+	createAPIServerEgressPolicy bool
+	webhookServerEnabled        bool
+	serviceIdResolver           serviceidresolver.ServiceResolver
+	injectablerecorder.InjectableRecorder
+}
+
+// NewKubernetesRBACReconciler creates a KubernetesRBACReconciler. When createAPIServerEgressPolicy is set, clients with
+// kubernetes intents also get an egress network policy allowing traffic to the API server endpoints.
+// webhookServerEnabled should be set only when the intents webhook runs, as it is what prevents intents authors from
+// granting access they do not hold themselves - without it, no access is granted.
+func NewKubernetesRBACReconciler(
+	c client.Client,
+	s *runtime.Scheme,
+	restrictToNamespaces []string,
+	serviceIdResolver serviceidresolver.ServiceResolver,
+	createAPIServerEgressPolicy bool,
+	webhookServerEnabled bool,
+) *KubernetesRBACReconciler {
+	return &KubernetesRBACReconciler{
+		Client:                      c,
+		Scheme:                      s,
+		RestrictToNamespaces:        restrictToNamespaces,
+		createAPIServerEgressPolicy: createAPIServerEgressPolicy,
+		webhookServerEnabled:        webhookServerEnabled,
+		serviceIdResolver:           serviceIdResolver,
+	}
+}
+
+func (r *KubernetesRBACReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	intents := &otterizev1alpha3.ClientIntents{}
+	err := r.Get(ctx, req.NamespacedName, intents)
+	if k8serrors.IsNotFound(err) {
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if intents.Spec == nil {
+		return ctrl.Result{}, nil
+	}
+
+	formattedClient := otterizev1alpha3.GetFormattedOtterizeIdentity(intents.GetServiceName(), intents.Namespace)
+	kubernetesIntents := intents.GetFilteredCallsList(otterizev1alpha3.IntentTypeKubernetes)
+	if !intents.DeletionTimestamp.IsZero() || len(kubernetesIntents) == 0 {
+		err := r.removeAll(ctx, formattedClient, intents.Namespace, intents.GetServiceName())
+		if err != nil {
+			if k8serrors.IsConflict(err) {
+				return ctrl.Result{Requeue: true}, nil
+			}
+			r.RecordWarningEventf(intents, consts.ReasonRemovingKubernetesRBACFailed, "could not remove Kubernetes RBAC: %s", err.Error())
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if !r.webhookServerEnabled {
+		r.RecordWarningEvent(intents, consts.ReasonKubernetesRBACRequiresWebhook, "Kubernetes intents are not applied since the webhook server is disabled, and it is required to check that intents authors hold the access they grant")
+		err := r.removeAll(ctx, formattedClient, intents.Namespace, intents.GetServiceName())
+		if err != nil {
+			if k8serrors.IsConflict(err) {
+				return ctrl.Result{Requeue: true}, nil
+			}
+			r.RecordWarningEventf(intents, consts.ReasonRemovingKubernetesRBACFailed, "could not remove Kubernetes RBAC: %s", err.Error())
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	logrus.Infof("Reconciling Kubernetes RBAC for service %s in namespace %s", intents.GetServiceName(), intents.Namespace)
+
+	pod, err := r.serviceIdResolver.ResolveClientIntentToPod(ctx, *intents)
+	if err != nil {
+		if errors.Is(err, serviceidresolver.ErrPodNotFound) {
+			r.RecordWarningEventf(
+				intents,
+				consts.ReasonPodsNotFound,
+				"Could not find non-terminating pods for service %s in namespace %s. Intents could not be reconciled now, but will be reconciled if pods appear later.",
+				intents.Spec.Service.Name,
+				intents.Namespace)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	err = r.applyRBAC(ctx, intents, kubernetesIntents, formattedClient, pod.Spec.ServiceAccountName)
+	if err != nil {
+		if k8serrors.IsConflict(err) {
+			return ctrl.Result{Requeue: true}, nil
+		}
+		r.RecordWarningEventf(intents, consts.ReasonUpdatingKubernetesRBACFailed, "could not update Kubernetes RBAC: %s", err.Error())
+		return ctrl.Result{}, err
+	}
+
+	if r.createAPIServerEgressPolicy {
+		err = r.applyAPIServerEgressPolicy(ctx, intents, formattedClient)
+	} else {
+		err = r.removeAPIServerEgressPolicy(ctx, intents.Namespace, intents.GetServiceName())
+	}
+	if err != nil {
+		r.RecordWarningEventf(intents, consts.ReasonUpdatingKubernetesRBACFailed, "could not update Kubernetes API egress network policy: %s", err.Error())
+		return ctrl.Result{}, err
+	}
+
+	r.RecordNormalEventf(intents, consts.ReasonKubernetesRBACUpdated, "Kubernetes RBAC reconcile complete, granted access for service account %s", pod.Spec.ServiceAccountName)
+	return ctrl.Result{}, nil
+}
+
+func toPolicyRule(resource otterizev1alpha3.KubernetesResource) rbacv1.PolicyRule {
+	return rbacv1.PolicyRule{
+		APIGroups:     resource.APIGroups,
+		Resources:     resource.Resources,
+		Verbs:         resource.Verbs,
+		ResourceNames: resource.ResourceNames,
+	}
+}
+
+// buildRules groups the rules of the kubernetes intents by the namespace they apply to. Cluster-wide rules are
+// returned separately.
+func (r *KubernetesRBACReconciler) buildRules(intents *otterizev1alpha3.ClientIntents, kubernetesIntents []otterizev1alpha3.Intent) (map[string][]rbacv1.PolicyRule, []rbacv1.PolicyRule) {
+	rulesByNamespace := make(map[string][]rbacv1.PolicyRule)
+	clusterRules := make([]rbacv1.PolicyRule, 0)
+	for _, intent := range kubernetesIntents {
+		namespace := intent.GetTargetServerNamespace(intents.Namespace)
+		for _, resource := range intent.KubernetesResources {
+			if resource.ClusterWide {
+				if len(r.RestrictToNamespaces) != 0 {
+					// The operator is limited to some namespaces, so it may not grant access to all of them.
+					r.RecordWarningEvent(intents, consts.ReasonClusterWideKubernetesAccessNotAllowed, "Kubernetes intents request cluster-wide access but the operator is restricted to specific namespaces")
+					continue
+				}
+				clusterRules = append(clusterRules, toPolicyRule(resource))
+				continue
+			}
+			if len(r.RestrictToNamespaces) != 0 && !lo.Contains(r.RestrictToNamespaces, namespace) {
+				// Namespace is not in list of namespaces we're allowed to act in, so drop it.
+				r.RecordWarningEventf(intents, consts.ReasonNamespaceNotAllowed, "Kubernetes intents refer to namespace %s but namespace is not allowed by configuration", namespace)
+				continue
+			}
+			rulesByNamespace[namespace] = append(rulesByNamespace[namespace], toPolicyRule(resource))
+		}
+	}
+
+	return rulesByNamespace, clusterRules
+}
+
+func (r *KubernetesRBACReconciler) applyRBAC(
+	ctx context.Context,
+	intents *otterizev1alpha3.ClientIntents,
+	kubernetesIntents []otterizev1alpha3.Intent,
+	formattedClient string,
+	serviceAccountName string,
+) error {
+	name := fmt.Sprintf(otterizev1alpha3.OtterizeKubernetesRBACNameTemplate, formattedClient)
+	labels := map[string]string{otterizev1alpha3.OtterizeKubernetesRBACClientLabelKey: formattedClient}
+	subjects := []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: serviceAccountName, Namespace: intents.Namespace}}
+
+	rulesByNamespace, clusterRules := r.buildRules(intents, kubernetesIntents)
+	namespaces := maps.Keys(rulesByNamespace)
+	slices.Sort(namespaces)
+	for _, namespace := range namespaces {
+		role := &rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+			Rules:      rulesByNamespace[namespace],
+		}
+		existingRole := &rbacv1.Role{}
+		err := r.createOrPatch(ctx, role, existingRole,
+			func() bool {
+				return reflect.DeepEqual(existingRole.Rules, role.Rules) && reflect.DeepEqual(existingRole.Labels, role.Labels)
+			},
+			func() {
+				existingRole.Rules = role.Rules
+				existingRole.Labels = role.Labels
+			})
+		if err != nil {
+			return err
+		}
+
+		roleBinding := &rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+			Subjects:   subjects,
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: name},
+		}
+		existingRoleBinding := &rbacv1.RoleBinding{}
+		err = r.createOrPatch(ctx, roleBinding, existingRoleBinding,
+			func() bool {
+				return reflect.DeepEqual(existingRoleBinding.Subjects, roleBinding.Subjects) && reflect.DeepEqual(existingRoleBinding.Labels, roleBinding.Labels)
+			},
+			func() {
+				existingRoleBinding.Subjects = roleBinding.Subjects
+				existingRoleBinding.Labels = roleBinding.Labels
+			})
+		if err != nil {
+			return err
+		}
+	}
+
+	err := r.removeNamespacedRBAC(ctx, formattedClient, namespaces)
+	if err != nil {
+		return err
+	}
+
+	if len(clusterRules) == 0 {
+		return r.removeClusterRBAC(ctx, name)
+	}
+
+	clusterRole := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Rules:      clusterRules,
+	}
+	existingClusterRole := &rbacv1.ClusterRole{}
+	err = r.createOrPatch(ctx, clusterRole, existingClusterRole,
+		func() bool {
+			return reflect.DeepEqual(existingClusterRole.Rules, clusterRole.Rules) && reflect.DeepEqual(existingClusterRole.Labels, clusterRole.Labels)
+		},
+		func() {
+			existingClusterRole.Rules = clusterRole.Rules
+			existingClusterRole.Labels = clusterRole.Labels
+		})
+	if err != nil {
+		return err
+	}
+
+	clusterRoleBinding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Subjects:   subjects,
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: name},
+	}
+	existingClusterRoleBinding := &rbacv1.ClusterRoleBinding{}
+	return r.createOrPatch(ctx, clusterRoleBinding, existingClusterRoleBinding,
+		func() bool {
+			return reflect.DeepEqual(existingClusterRoleBinding.Subjects, clusterRoleBinding.Subjects) && reflect.DeepEqual(existingClusterRoleBinding.Labels, clusterRoleBinding.Labels)
+		},
+		func() {
+			existingClusterRoleBinding.Subjects = clusterRoleBinding.Subjects
+			existingClusterRoleBinding.Labels = clusterRoleBinding.Labels
+		})
+}
+
+// createOrPatch creates desired, or patches the existing object of the same name using update when isUpToDate reports
+// that it differs. existing is filled in by the lookup before isUpToDate is called.
+func (r *KubernetesRBACReconciler) createOrPatch(ctx context.Context, desired client.Object, existing client.Object, isUpToDate func() bool, update func()) error {
+	err := r.Get(ctx, client.ObjectKeyFromObject(desired), existing)
+	if k8serrors.IsNotFound(err) {
+		logrus.Infof("Creating %T %s", desired, client.ObjectKeyFromObject(desired))
+		return r.Create(ctx, desired)
+	}
+	if err != nil {
+		return err
+	}
+
+	if isUpToDate() {
+		return nil
+	}
+
+	original := existing.DeepCopyObject().(client.Object)
+	update()
+	logrus.Infof("Updating %T %s", desired, client.ObjectKeyFromObject(desired))
+	return r.Patch(ctx, existing, client.MergeFrom(original))
+}
+
+// removeNamespacedRBAC deletes the client's Roles and RoleBindings outside of keepNamespaces.
+func (r *KubernetesRBACReconciler) removeNamespacedRBAC(ctx context.Context, formattedClient string, keepNamespaces []string) error {
+	clientLabel := client.MatchingLabels{otterizev1alpha3.OtterizeKubernetesRBACClientLabelKey: formattedClient}
+
+	var roleBindings rbacv1.RoleBindingList
+	err := r.List(ctx, &roleBindings, clientLabel)
+	if err != nil {
+		return err
+	}
+	for i := range roleBindings.Items {
+		if lo.Contains(keepNamespaces, roleBindings.Items[i].Namespace) {
+			continue
+		}
+		logrus.Infof("Removing RoleBinding %s/%s", roleBindings.Items[i].Namespace, roleBindings.Items[i].Name)
+		err = r.Delete(ctx, &roleBindings.Items[i])
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	var roles rbacv1.RoleList
+	err = r.List(ctx, &roles, clientLabel)
+	if err != nil {
+		return err
+	}
+	for i := range roles.Items {
+		if lo.Contains(keepNamespaces, roles.Items[i].Namespace) {
+			continue
+		}
+		logrus.Infof("Removing Role %s/%s", roles.Items[i].Namespace, roles.Items[i].Name)
+		err = r.Delete(ctx, &roles.Items[i])
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *KubernetesRBACReconciler) removeClusterRBAC(ctx context.Context, name string) error {
+	err := r.deleteIfExists(ctx, types.NamespacedName{Name: name}, &rbacv1.ClusterRoleBinding{})
+	if err != nil {
+		return err
+	}
+
+	return r.deleteIfExists(ctx, types.NamespacedName{Name: name}, &rbacv1.ClusterRole{})
+}
+
+// deleteIfExists looks the object up before deleting it, so that clients without kubernetes intents are served from
+// the cache instead of issuing a delete on every reconcile.
+func (r *KubernetesRBACReconciler) deleteIfExists(ctx context.Context, key types.NamespacedName, obj client.Object) error {
+	err := r.Get(ctx, key, obj)
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Removing %T %s", obj, key)
+	return client.IgnoreNotFound(r.Delete(ctx, obj))
+}
+
+func (r *KubernetesRBACReconciler) removeAll(ctx context.Context, formattedClient string, namespace string, serviceName string) error {
+	err := r.removeNamespacedRBAC(ctx, formattedClient, nil)
+	if err != nil {
+		return err
+	}
+
+	err = r.removeClusterRBAC(ctx, fmt.Sprintf(otterizev1alpha3.OtterizeKubernetesRBACNameTemplate, formattedClient))
+	if err != nil {
+		return err
+	}
+
+	return r.removeAPIServerEgressPolicy(ctx, namespace, serviceName)
+}
+
+// buildAPIServerEgressRule allows traffic to the addresses backing the kubernetes service in the default namespace.
+// Network policies are evaluated after the service's cluster IP is translated, so the endpoints are used instead.
+func buildAPIServerEgressRule(endpoints *corev1.Endpoints) v1.NetworkPolicyEgressRule {
+	rule := v1.NetworkPolicyEgressRule{}
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			prefixLength := 32
+			if ip := net.ParseIP(address.IP); ip != nil && ip.To4() == nil {
+				prefixLength = 128
+			}
+			rule.To = append(rule.To, v1.NetworkPolicyPeer{IPBlock: &v1.IPBlock{CIDR: fmt.Sprintf("%s/%d", address.IP, prefixLength)}})
+		}
+		for _, port := range subset.Ports {
+			rule.Ports = append(rule.Ports, v1.NetworkPolicyPort{
+				Protocol: lo.ToPtr(port.Protocol),
+				Port:     lo.ToPtr(intstr.FromInt(int(port.Port))),
+			})
+		}
+	}
+
+	rule.To = lo.UniqBy(rule.To, func(peer v1.NetworkPolicyPeer) string { return peer.IPBlock.CIDR })
+	rule.Ports = lo.UniqBy(rule.Ports, func(port v1.NetworkPolicyPort) string {
+		return fmt.Sprintf("%s/%s", *port.Protocol, port.Port.String())
+	})
+	return rule
+}
+
+func (r *KubernetesRBACReconciler) applyAPIServerEgressPolicy(ctx context.Context, intents *otterizev1alpha3.ClientIntents, formattedClient string) error {
+	endpoints := &corev1.Endpoints{}
+	err := r.Get(ctx, types.NamespacedName{Name: kubernetesAPIServiceName, Namespace: kubernetesAPIServiceNamespace}, endpoints)
+	if err != nil {
+		return err
+	}
+
+	policy := &v1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf(otterizev1alpha3.OtterizeKubernetesAPIEgressNetworkPolicyNameTemplate, intents.GetServiceName()),
+			Namespace: intents.Namespace,
+			Labels: map[string]string{
+				otterizev1alpha3.OtterizeKubernetesAPIEgressNetworkPolicy: formattedClient,
+			},
+		},
+		Spec: v1.NetworkPolicySpec{
+			PolicyTypes: []v1.PolicyType{v1.PolicyTypeEgress},
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					otterizev1alpha3.OtterizeClientLabelKey: formattedClient,
+				},
+			},
+			Egress: []v1.NetworkPolicyEgressRule{buildAPIServerEgressRule(endpoints)},
+		},
+	}
+
+	existingPolicy := &v1.NetworkPolicy{}
+	return r.createOrPatch(ctx, policy, existingPolicy,
+		func() bool {
+			return otterizev1alpha3.IsManuallyOverridden(existingPolicy) || reflect.DeepEqual(existingPolicy.Spec, policy.Spec)
+		},
+		func() {
+			existingPolicy.Labels = policy.Labels
+			existingPolicy.Spec = policy.Spec
+		})
+}
+
+func (r *KubernetesRBACReconciler) removeAPIServerEgressPolicy(ctx context.Context, namespace string, serviceName string) error {
+	return r.deleteIfExists(ctx, types.NamespacedName{
+		Name:      fmt.Sprintf(otterizev1alpha3.OtterizeKubernetesAPIEgressNetworkPolicyNameTemplate, serviceName),
+		Namespace: namespace,
+	}, &v1.NetworkPolicy{})
+}
@@ -0,0 +1,274 @@
+package kubernetes_rbac
+
+import (
+	"context"
+	"fmt"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/consts"
+	serviceidresolvermocks "github.com/otterize/intents-operator/src/shared/serviceidresolver/mocks"
+	"github.com/otterize/intents-operator/src/shared/testbase"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"testing"
+	"time"
+)
+
+const (
+	testNamespace      = "test-namespace"
+	testClientName     = "test-client"
+	testServiceAccount = "test-client-sa"
+)
+
+type KubernetesRBACReconcilerTestSuite struct {
+	testbase.MocksSuiteBase
+	reconciler      *KubernetesRBACReconciler
+	serviceResolver *serviceidresolvermocks.MockServiceResolver
+	formattedClient string
+	rbacName        string
+}
+
+func (s *KubernetesRBACReconcilerTestSuite) SetupTest() {
+	s.MocksSuiteBase.SetupTest()
+	s.serviceResolver = serviceidresolvermocks.NewMockServiceResolver(s.Controller)
+	s.reconciler = NewKubernetesRBACReconciler(s.Client, nil, nil, s.serviceResolver, false, true)
+	s.reconciler.Recorder = s.Recorder
+	s.formattedClient = otterizev1alpha3.GetFormattedOtterizeIdentity(testClientName, testNamespace)
+	s.rbacName = fmt.Sprintf(otterizev1alpha3.OtterizeKubernetesRBACNameTemplate, s.formattedClient)
+}
+
+func (s *KubernetesRBACReconcilerTestSuite) expectGetIntents(intents *otterizev1alpha3.ClientIntents) {
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Namespace: testNamespace, Name: intents.Name}, gomock.AssignableToTypeOf(&otterizev1alpha3.ClientIntents{})).DoAndReturn(
+		func(_ context.Context, _ types.NamespacedName, obj *otterizev1alpha3.ClientIntents, _ ...client.GetOption) error {
+			intents.DeepCopyInto(obj)
+			return nil
+		})
+}
+
+func (s *KubernetesRBACReconcilerTestSuite) expectNotFound(key types.NamespacedName, obj client.Object) {
+	s.Client.EXPECT().Get(gomock.Any(), key, gomock.AssignableToTypeOf(obj)).Return(k8serrors.NewNotFound(schema.GroupResource{}, key.Name))
+}
+
+func (s *KubernetesRBACReconcilerTestSuite) expectList(list client.ObjectList, items ...client.Object) {
+	s.Client.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(list), client.MatchingLabels{otterizev1alpha3.OtterizeKubernetesRBACClientLabelKey: s.formattedClient}).DoAndReturn(
+		func(_ context.Context, list client.ObjectList, _ ...client.ListOption) error {
+			switch typedList := list.(type) {
+			case *rbacv1.RoleList:
+				for _, item := range items {
+					typedList.Items = append(typedList.Items, *item.(*rbacv1.Role))
+				}
+			case *rbacv1.RoleBindingList:
+				for _, item := range items {
+					typedList.Items = append(typedList.Items, *item.(*rbacv1.RoleBinding))
+				}
+			}
+			return nil
+		})
+}
+
+func (s *KubernetesRBACReconcilerTestSuite) buildIntents(intents ...otterizev1alpha3.Intent) *otterizev1alpha3.ClientIntents {
+	return &otterizev1alpha3.ClientIntents{
+		ObjectMeta: metav1.ObjectMeta{Name: "client-intents", Namespace: testNamespace},
+		Spec: &otterizev1alpha3.IntentsSpec{
+			Service: otterizev1alpha3.Service{Name: testClientName},
+			Calls:   intents,
+		},
+	}
+}
+
+func (s *KubernetesRBACReconcilerTestSuite) expectResolveServiceAccount(intents *otterizev1alpha3.ClientIntents) {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-client-pod", Namespace: testNamespace},
+		Spec:       corev1.PodSpec{ServiceAccountName: testServiceAccount},
+	}
+	s.serviceResolver.EXPECT().ResolveClientIntentToPod(gomock.Any(), gomock.Eq(*intents)).Return(pod, nil)
+}
+
+func (s *KubernetesRBACReconcilerTestSuite) reconcile(intents *otterizev1alpha3.ClientIntents) {
+	res, err := s.reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: intents.Name}})
+	s.Require().NoError(err)
+	s.Require().True(res.IsZero())
+}
+
+func (s *KubernetesRBACReconcilerTestSuite) TestRoleAndBindingCreatedInTargetNamespace() {
+	podReader := otterizev1alpha3.KubernetesResource{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list"}}
+	intents := s.buildIntents(otterizev1alpha3.Intent{
+		Name:                "kubernetes.monitoring",
+		Type:                otterizev1alpha3.IntentTypeKubernetes,
+		KubernetesResources: []otterizev1alpha3.KubernetesResource{podReader},
+	})
+	s.expectGetIntents(intents)
+	s.expectResolveServiceAccount(intents)
+
+	labels := map[string]string{otterizev1alpha3.OtterizeKubernetesRBACClientLabelKey: s.formattedClient}
+	expectedRole := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{Name: s.rbacName, Namespace: "monitoring", Labels: labels},
+		Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list"}}},
+	}
+	s.expectNotFound(types.NamespacedName{Namespace: "monitoring", Name: s.rbacName}, &rbacv1.Role{})
+	s.Client.EXPECT().Create(gomock.Any(), gomock.Eq(expectedRole)).Return(nil)
+
+	expectedRoleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: s.rbacName, Namespace: "monitoring", Labels: labels},
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: testServiceAccount, Namespace: testNamespace}},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: s.rbacName},
+	}
+	s.expectNotFound(types.NamespacedName{Namespace: "monitoring", Name: s.rbacName}, &rbacv1.RoleBinding{})
+	s.Client.EXPECT().Create(gomock.Any(), gomock.Eq(expectedRoleBinding)).Return(nil)
+
+	s.expectList(&rbacv1.RoleBindingList{}, expectedRoleBinding)
+	s.expectList(&rbacv1.RoleList{}, expectedRole)
+	s.expectNotFound(types.NamespacedName{Name: s.rbacName}, &rbacv1.ClusterRoleBinding{})
+	s.expectNotFound(types.NamespacedName{Name: s.rbacName}, &rbacv1.ClusterRole{})
+	s.expectNotFound(types.NamespacedName{Namespace: testNamespace, Name: fmt.Sprintf(otterizev1alpha3.OtterizeKubernetesAPIEgressNetworkPolicyNameTemplate, testClientName)}, &v1.NetworkPolicy{})
+
+	s.reconcile(intents)
+	s.ExpectEvent(consts.ReasonKubernetesRBACUpdated)
+}
+
+func (s *KubernetesRBACReconcilerTestSuite) TestClusterWideAccessUpdatesExistingClusterRole() {
+	nodeReader := otterizev1alpha3.KubernetesResource{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"get"}, ClusterWide: true}
+	intents := s.buildIntents(otterizev1alpha3.Intent{
+		Name:                "kubernetes",
+		Type:                otterizev1alpha3.IntentTypeKubernetes,
+		KubernetesResources: []otterizev1alpha3.KubernetesResource{nodeReader},
+	})
+	s.expectGetIntents(intents)
+	s.expectResolveServiceAccount(intents)
+
+	// The client used to have namespaced access, which is removed.
+	staleRole := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: s.rbacName, Namespace: testNamespace}}
+	staleRoleBinding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: s.rbacName, Namespace: testNamespace}}
+	s.expectList(&rbacv1.RoleBindingList{}, staleRoleBinding)
+	s.Client.EXPECT().Delete(gomock.Any(), gomock.Eq(staleRoleBinding)).Return(nil)
+	s.expectList(&rbacv1.RoleList{}, staleRole)
+	s.Client.EXPECT().Delete(gomock.Any(), gomock.Eq(staleRole)).Return(nil)
+
+	existingClusterRole := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: s.rbacName, Labels: map[string]string{otterizev1alpha3.OtterizeKubernetesRBACClientLabelKey: s.formattedClient}},
+		Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"get", "list"}}},
+	}
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Name: s.rbacName}, gomock.AssignableToTypeOf(&rbacv1.ClusterRole{})).DoAndReturn(
+		func(_ context.Context, _ types.NamespacedName, obj *rbacv1.ClusterRole, _ ...client.GetOption) error {
+			existingClusterRole.DeepCopyInto(obj)
+			return nil
+		})
+	s.Client.EXPECT().Patch(gomock.Any(), gomock.AssignableToTypeOf(&rbacv1.ClusterRole{}), gomock.Any()).DoAndReturn(
+		func(_ context.Context, clusterRole *rbacv1.ClusterRole, _ client.Patch, _ ...client.PatchOption) error {
+			s.Require().Equal([]rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"get"}}}, clusterRole.Rules)
+			return nil
+		})
+
+	s.expectNotFound(types.NamespacedName{Name: s.rbacName}, &rbacv1.ClusterRoleBinding{})
+	s.Client.EXPECT().Create(gomock.Any(), gomock.AssignableToTypeOf(&rbacv1.ClusterRoleBinding{})).DoAndReturn(
+		func(_ context.Context, binding *rbacv1.ClusterRoleBinding, _ ...client.CreateOption) error {
+			s.Require().Equal(rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: s.rbacName}, binding.RoleRef)
+			return nil
+		})
+	s.expectNotFound(types.NamespacedName{Namespace: testNamespace, Name: fmt.Sprintf(otterizev1alpha3.OtterizeKubernetesAPIEgressNetworkPolicyNameTemplate, testClientName)}, &v1.NetworkPolicy{})
+
+	s.reconcile(intents)
+	s.ExpectEvent(consts.ReasonKubernetesRBACUpdated)
+}
+
+func (s *KubernetesRBACReconcilerTestSuite) TestDeletedIntentsRemoveRBAC() {
+	intents := s.buildIntents(otterizev1alpha3.Intent{
+		Name:                "kubernetes",
+		Type:                otterizev1alpha3.IntentTypeKubernetes,
+		KubernetesResources: []otterizev1alpha3.KubernetesResource{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}},
+	})
+	intents.DeletionTimestamp = lo.ToPtr(metav1.NewTime(time.Now()))
+	s.expectGetIntents(intents)
+
+	role := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: s.rbacName, Namespace: testNamespace}}
+	roleBinding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: s.rbacName, Namespace: testNamespace}}
+	s.expectList(&rbacv1.RoleBindingList{}, roleBinding)
+	s.Client.EXPECT().Delete(gomock.Any(), gomock.Eq(roleBinding)).Return(nil)
+	s.expectList(&rbacv1.RoleList{}, role)
+	s.Client.EXPECT().Delete(gomock.Any(), gomock.Eq(role)).Return(nil)
+	s.expectNotFound(types.NamespacedName{Name: s.rbacName}, &rbacv1.ClusterRoleBinding{})
+	s.expectNotFound(types.NamespacedName{Name: s.rbacName}, &rbacv1.ClusterRole{})
+	s.expectNotFound(types.NamespacedName{Namespace: testNamespace, Name: fmt.Sprintf(otterizev1alpha3.OtterizeKubernetesAPIEgressNetworkPolicyNameTemplate, testClientName)}, &v1.NetworkPolicy{})
+
+	s.reconcile(intents)
+}
+
+func (s *KubernetesRBACReconcilerTestSuite) TestClusterWideAccessNotGrantedWhenRestrictedToNamespaces() {
+	s.reconciler.RestrictToNamespaces = []string{testNamespace}
+	nodeReader := otterizev1alpha3.KubernetesResource{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"get"}, ClusterWide: true}
+	intents := s.buildIntents(otterizev1alpha3.Intent{
+		Name:                "kubernetes",
+		Type:                otterizev1alpha3.IntentTypeKubernetes,
+		KubernetesResources: []otterizev1alpha3.KubernetesResource{nodeReader},
+	})
+	s.expectGetIntents(intents)
+	s.expectResolveServiceAccount(intents)
+
+	s.expectList(&rbacv1.RoleBindingList{})
+	s.expectList(&rbacv1.RoleList{})
+	s.expectNotFound(types.NamespacedName{Name: s.rbacName}, &rbacv1.ClusterRoleBinding{})
+	s.expectNotFound(types.NamespacedName{Name: s.rbacName}, &rbacv1.ClusterRole{})
+	s.expectNotFound(types.NamespacedName{Namespace: testNamespace, Name: fmt.Sprintf(otterizev1alpha3.OtterizeKubernetesAPIEgressNetworkPolicyNameTemplate, testClientName)}, &v1.NetworkPolicy{})
+
+	s.reconcile(intents)
+	s.ExpectEvent(consts.ReasonClusterWideKubernetesAccessNotAllowed)
+	s.ExpectEvent(consts.ReasonKubernetesRBACUpdated)
+}
+
+func (s *KubernetesRBACReconcilerTestSuite) TestWebhookServerDisabledRemovesRBAC() {
+	s.reconciler.webhookServerEnabled = false
+	intents := s.buildIntents(otterizev1alpha3.Intent{
+		Name:                "kubernetes",
+		Type:                otterizev1alpha3.IntentTypeKubernetes,
+		KubernetesResources: []otterizev1alpha3.KubernetesResource{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}},
+	})
+	s.expectGetIntents(intents)
+
+	role := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: s.rbacName, Namespace: testNamespace}}
+	roleBinding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: s.rbacName, Namespace: testNamespace}}
+	s.expectList(&rbacv1.RoleBindingList{}, roleBinding)
+	s.Client.EXPECT().Delete(gomock.Any(), gomock.Eq(roleBinding)).Return(nil)
+	s.expectList(&rbacv1.RoleList{}, role)
+	s.Client.EXPECT().Delete(gomock.Any(), gomock.Eq(role)).Return(nil)
+	s.expectNotFound(types.NamespacedName{Name: s.rbacName}, &rbacv1.ClusterRoleBinding{})
+	s.expectNotFound(types.NamespacedName{Name: s.rbacName}, &rbacv1.ClusterRole{})
+	s.expectNotFound(types.NamespacedName{Namespace: testNamespace, Name: fmt.Sprintf(otterizev1alpha3.OtterizeKubernetesAPIEgressNetworkPolicyNameTemplate, testClientName)}, &v1.NetworkPolicy{})
+
+	s.reconcile(intents)
+	s.ExpectEvent(consts.ReasonKubernetesRBACRequiresWebhook)
+}
+
+func (s *KubernetesRBACReconcilerTestSuite) TestBuildAPIServerEgressRule() {
+	endpoints := &corev1.Endpoints{
+		Subsets: []corev1.EndpointSubset{
+			{
+				Addresses: []corev1.EndpointAddress{{IP: "172.18.0.2"}, {IP: "fd00::2"}},
+				Ports:     []corev1.EndpointPort{{Name: "https", Port: 6443, Protocol: corev1.ProtocolTCP}},
+			},
+		},
+	}
+
+	port := intstr.FromInt(6443)
+	expectedRule := v1.NetworkPolicyEgressRule{
+		To: []v1.NetworkPolicyPeer{
+			{IPBlock: &v1.IPBlock{CIDR: "172.18.0.2/32"}},
+			{IPBlock: &v1.IPBlock{CIDR: "fd00::2/128"}},
+		},
+		Ports: []v1.NetworkPolicyPort{{Protocol: lo.ToPtr(corev1.ProtocolTCP), Port: &port}},
+	}
+	s.Require().Equal(expectedRule, buildAPIServerEgressRule(endpoints))
+}
+
+func TestKubernetesRBACReconcilerTestSuite(t *testing.T) {
+	suite.Run(t, new(KubernetesRBACReconcilerTestSuite))
+}
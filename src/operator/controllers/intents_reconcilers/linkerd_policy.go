@@ -0,0 +1,147 @@
+package intents_reconcilers
+
+import (
+	"context"
+	"errors"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/consts"
+	"github.com/otterize/intents-operator/src/operator/controllers/linkerdpolicy"
+	"github.com/otterize/intents-operator/src/shared/injectablerecorder"
+	"github.com/otterize/intents-operator/src/shared/serviceidresolver"
+	"github.com/sirupsen/logrus"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// LinkerdPolicyReconciler is the Linkerd counterpart of the IstioPolicyReconciler. It is only added when the Linkerd
+// policy CRDs are installed.
+type LinkerdPolicyReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	injectablerecorder.InjectableRecorder
+	serviceIdResolver serviceidresolver.ServiceResolver
+	policyManager     linkerdpolicy.PolicyManager
+}
+
+func NewLinkerdPolicyReconciler(
+	c client.Client,
+	s *runtime.Scheme,
+	kinds linkerdpolicy.PolicyKinds,
+	restrictToNamespaces []string,
+	enableLinkerdPolicyCreation bool,
+	enforcementDefaultState bool) *LinkerdPolicyReconciler {
+	reconciler := &LinkerdPolicyReconciler{
+		Client:            c,
+		Scheme:            s,
+		serviceIdResolver: serviceidresolver.NewResolver(c),
+	}
+
+	reconciler.policyManager = linkerdpolicy.NewPolicyManager(c, &reconciler.InjectableRecorder, kinds, restrictToNamespaces,
+		enforcementDefaultState, enableLinkerdPolicyCreation)
+
+	return reconciler
+}
+
+// PolicyManager is shared with the pod watcher, so that policies are also updated when pods of clients and servers
+// are started.
+func (r *LinkerdPolicyReconciler) PolicyManager() linkerdpolicy.PolicyManager {
+	return r.policyManager
+}
+
+func (r *LinkerdPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	intents := &otterizev1alpha3.ClientIntents{}
+	err := r.Get(ctx, req.NamespacedName, intents)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if intents.Spec == nil {
+		return ctrl.Result{}, nil
+	}
+
+	logrus.Infof("Reconciling Linkerd policies for service %s in namespace %s",
+		intents.Spec.Service.Name, req.Namespace)
+
+	if !intents.DeletionTimestamp.IsZero() {
+		err := r.policyManager.DeleteAll(ctx, intents)
+		if err != nil {
+			if k8serrors.IsConflict(err) {
+				return ctrl.Result{Requeue: true}, nil
+			}
+			r.RecordWarningEventf(intents, consts.ReasonRemovingLinkerdPolicyFailed, "Could not remove Linkerd policies: %s", err.Error())
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	pod, err := r.serviceIdResolver.ResolveClientIntentToPod(ctx, *intents)
+	if err != nil {
+		if errors.Is(err, serviceidresolver.ErrPodNotFound) {
+			r.RecordWarningEventf(
+				intents,
+				consts.ReasonPodsNotFound,
+				"Could not find non-terminating pods for service %s in namespace %s. Intents could not be reconciled now, but will be reconciled if pods appear later.",
+				intents.Spec.Service.Name,
+				intents.Namespace)
+			return ctrl.Result{}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	clientServiceAccountName := pod.Spec.ServiceAccountName
+	missingSideCar := !linkerdpolicy.IsPodPartOfLinkerdMesh(pod)
+
+	err = r.policyManager.UpdateIntentsStatus(ctx, intents, clientServiceAccountName, missingSideCar)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	err = r.updateServerSidecarStatus(ctx, intents)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if missingSideCar {
+		r.RecordWarningEvent(intents, linkerdpolicy.ReasonMissingSidecar, "Client pod missing Linkerd proxy, will not create policies")
+		logrus.Infof("Pod %s/%s does not have a Linkerd proxy, skipping Linkerd policy creation", pod.Namespace, pod.Name)
+		return ctrl.Result{}, nil
+	}
+
+	err = r.policyManager.Create(ctx, intents, clientServiceAccountName)
+	if err != nil {
+		if k8serrors.IsConflict(err) {
+			return ctrl.Result{Requeue: true}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *LinkerdPolicyReconciler) updateServerSidecarStatus(ctx context.Context, intents *otterizev1alpha3.ClientIntents) error {
+	for _, intent := range intents.Spec.Calls {
+		serverNamespace := intent.GetTargetServerNamespace(intents.Namespace)
+		pod, err := r.serviceIdResolver.ResolveIntentServerToPod(ctx, intent, serverNamespace)
+		if err != nil {
+			if errors.Is(err, serviceidresolver.ErrPodNotFound) {
+				continue
+			}
+			return err
+		}
+
+		missingSideCar := !linkerdpolicy.IsPodPartOfLinkerdMesh(pod)
+		formattedTargetServer := otterizev1alpha3.GetFormattedOtterizeIdentity(intent.GetTargetServerName(), serverNamespace)
+		err = r.policyManager.UpdateServerSidecar(ctx, intents, formattedTargetServer, missingSideCar)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -77,6 +77,20 @@ func (mr *MockAdminMockRecorder) UpdateIntentsStatus(ctx, clientIntents, clientS
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateIntentsStatus", reflect.TypeOf((*MockAdmin)(nil).UpdateIntentsStatus), ctx, clientIntents, clientServiceAccount, missingSideCar)
 }
 
+// UpdateServerAmbientStatus mocks base method.
+func (m *MockAdmin) UpdateServerAmbientStatus(ctx context.Context, clientIntents *v1alpha3.ClientIntents, serverName string, ambientServer *v1alpha3.AmbientServer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateServerAmbientStatus", ctx, clientIntents, serverName, ambientServer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateServerAmbientStatus indicates an expected call of UpdateServerAmbientStatus.
+func (mr *MockAdminMockRecorder) UpdateServerAmbientStatus(ctx, clientIntents, serverName, ambientServer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateServerAmbientStatus", reflect.TypeOf((*MockAdmin)(nil).UpdateServerAmbientStatus), ctx, clientIntents, serverName, ambientServer)
+}
+
 // UpdateServerSidecar mocks base method.
 func (m *MockAdmin) UpdateServerSidecar(ctx context.Context, clientIntents *v1alpha3.ClientIntents, serverName string, missingSideCar bool) error {
 	m.ctrl.T.Helper()
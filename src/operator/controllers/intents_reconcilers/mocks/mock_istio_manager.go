@@ -77,6 +77,20 @@ func (mr *MockPolicyManagerMockRecorder) UpdateIntentsStatus(ctx, clientIntents,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateIntentsStatus", reflect.TypeOf((*MockPolicyManager)(nil).UpdateIntentsStatus), ctx, clientIntents, clientServiceAccount, missingSideCar)
 }
 
+// UpdateServerAmbientStatus mocks base method.
+func (m *MockPolicyManager) UpdateServerAmbientStatus(ctx context.Context, clientIntents *v1alpha3.ClientIntents, serverName string, ambientServer *v1alpha3.AmbientServer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateServerAmbientStatus", ctx, clientIntents, serverName, ambientServer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateServerAmbientStatus indicates an expected call of UpdateServerAmbientStatus.
+func (mr *MockPolicyManagerMockRecorder) UpdateServerAmbientStatus(ctx, clientIntents, serverName, ambientServer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateServerAmbientStatus", reflect.TypeOf((*MockPolicyManager)(nil).UpdateServerAmbientStatus), ctx, clientIntents, serverName, ambientServer)
+}
+
 // UpdateServerSidecar mocks base method.
 func (m *MockPolicyManager) UpdateServerSidecar(ctx context.Context, clientIntents *v1alpha3.ClientIntents, serverName string, missingSideCar bool) error {
 	m.ctrl.T.Helper()
@@ -104,7 +104,7 @@ func (r *PortEgressNetworkPolicyReconciler) Reconcile(ctx context.Context, req c
 		}
 	}
 
-	err = r.removeOrphanNetworkPolicies(ctx)
+	_, err = r.RemoveOrphanNetworkPolicies(ctx, false)
 	if err != nil {
 		r.RecordWarningEventf(intents, consts.ReasonRemovingEgressNetworkPolicyFailed, "failed to remove network policies: %s", err.Error())
 		return ctrl.Result{}, err
@@ -163,6 +163,11 @@ func (r *PortEgressNetworkPolicyReconciler) handleNetworkPolicyCreation(
 }
 
 func (r *PortEgressNetworkPolicyReconciler) UpdateExistingPolicy(ctx context.Context, existingPolicy *v1.NetworkPolicy, newPolicy *v1.NetworkPolicy, intent otterizev1alpha3.Intent, intentsObjNamespace string) error {
+	if otterizev1alpha3.IsManuallyOverridden(existingPolicy) {
+		logrus.Debugf("Network policy %s/%s is manually overridden, skipping update", existingPolicy.Namespace, existingPolicy.Name)
+		return nil
+	}
+
 	if !reflect.DeepEqual(existingPolicy.Spec, newPolicy.Spec) {
 		policyCopy := existingPolicy.DeepCopy()
 		policyCopy.Labels = newPolicy.Labels
@@ -213,18 +218,21 @@ func (r *PortEgressNetworkPolicyReconciler) handleIntentRemoval(
 	return r.deleteNetworkPolicy(ctx, intent, intentsObj)
 }
 
-func (r *PortEgressNetworkPolicyReconciler) removeOrphanNetworkPolicies(ctx context.Context) error {
+// RemoveOrphanNetworkPolicies removes network policies whose ClientIntents no longer exist, and returns how many were
+// found. When dryRun is set, orphaned policies are only logged.
+func (r *PortEgressNetworkPolicyReconciler) RemoveOrphanNetworkPolicies(ctx context.Context, dryRun bool) (int, error) {
 	logrus.Info("Searching for orphaned network policies")
+	orphanCount := 0
 	networkPolicyList := &v1.NetworkPolicyList{}
 	selector, err := matchAccessNetworkPolicy()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	err = r.List(ctx, networkPolicyList, &client.ListOptions{LabelSelector: selector})
 	if err != nil {
 		logrus.Infof("Error listing network policies: %s", err.Error())
-		return err
+		return 0, err
 	}
 
 	logrus.Infof("Selector: %s found %d network policies", selector.String(), len(networkPolicyList.Items))
@@ -240,19 +248,24 @@ func (r *PortEgressNetworkPolicyReconciler) removeOrphanNetworkPolicies(ctx cont
 			&client.ListOptions{Namespace: clientNamespace},
 		)
 		if err != nil {
-			return err
+			return 0, err
 		}
 
 		if len(intentsList.Items) == 0 {
+			orphanCount++
+			if dryRun {
+				logrus.Infof("Dry run, not removing orphaned network policy: %s server %s ns %s", networkPolicy.Name, serverName, networkPolicy.Namespace)
+				continue
+			}
 			logrus.Infof("Removing orphaned network policy: %s server %s ns %s", networkPolicy.Name, serverName, networkPolicy.Namespace)
 			err = r.removeNetworkPolicy(ctx, networkPolicy)
 			if err != nil {
-				return err
+				return 0, err
 			}
 		}
 	}
 
-	return nil
+	return orphanCount, nil
 }
 
 func (r *PortEgressNetworkPolicyReconciler) removeNetworkPolicy(ctx context.Context, networkPolicy v1.NetworkPolicy) error {
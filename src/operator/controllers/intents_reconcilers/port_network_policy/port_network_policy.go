@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/always_allowed"
 	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/consts"
 	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/protected_services"
 	"github.com/otterize/intents-operator/src/shared/injectablerecorder"
@@ -39,6 +40,7 @@ type PortNetworkPolicyReconciler struct {
 	RestrictToNamespaces        []string
 	enableNetworkPolicyCreation bool
 	enforcementDefaultState     bool
+	alwaysAllowed               always_allowed.Config
 	injectablerecorder.InjectableRecorder
 }
 
@@ -60,6 +62,12 @@ func NewPortNetworkPolicyReconciler(
 	}
 }
 
+// SetAlwaysAllowedConfig sets traffic that is allowed into protected servers regardless of intents, such as
+// monitoring. It is added to each generated policy.
+func (r *PortNetworkPolicyReconciler) SetAlwaysAllowedConfig(config always_allowed.Config) {
+	r.alwaysAllowed = config
+}
+
 func (r *PortNetworkPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	intents := &otterizev1alpha3.ClientIntents{}
 	err := r.Get(ctx, req.NamespacedName, intents)
@@ -115,7 +123,7 @@ func (r *PortNetworkPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		}
 	}
 
-	err = r.removeOrphanNetworkPolicies(ctx)
+	_, err = r.RemoveOrphanNetworkPolicies(ctx, false)
 	if err != nil {
 		r.RecordWarningEventf(intents, consts.ReasonRemovingNetworkPolicyFailed, "failed to remove network policies: %s", err.Error())
 		return ctrl.Result{}, err
@@ -161,6 +169,10 @@ func (r *PortNetworkPolicyReconciler) handleNetworkPolicyCreation(
 	if err != nil {
 		return false, err
 	}
+	err = r.alwaysAllowed.AddToPolicy(ctx, r.Client, newPolicy)
+	if err != nil {
+		return false, err
+	}
 
 	err = r.Get(ctx, types.NamespacedName{
 		Name:      policyName,
@@ -179,6 +191,11 @@ func (r *PortNetworkPolicyReconciler) handleNetworkPolicyCreation(
 }
 
 func (r *PortNetworkPolicyReconciler) UpdateExistingPolicy(ctx context.Context, existingPolicy *v1.NetworkPolicy, newPolicy *v1.NetworkPolicy, intent otterizev1alpha3.Intent, intentsObjNamespace string) error {
+	if otterizev1alpha3.IsManuallyOverridden(existingPolicy) {
+		logrus.Debugf("Network policy %s/%s is manually overridden, skipping update", existingPolicy.Namespace, existingPolicy.Name)
+		return nil
+	}
+
 	if !reflect.DeepEqual(existingPolicy.Spec, newPolicy.Spec) {
 		policyCopy := existingPolicy.DeepCopy()
 		policyCopy.Labels = newPolicy.Labels
@@ -264,18 +281,21 @@ func (r *PortNetworkPolicyReconciler) handleIntentRemoval(
 	return nil
 }
 
-func (r *PortNetworkPolicyReconciler) removeOrphanNetworkPolicies(ctx context.Context) error {
+// RemoveOrphanNetworkPolicies removes network policies whose ClientIntents no longer exist, and returns how many were
+// found. When dryRun is set, orphaned policies are only logged.
+func (r *PortNetworkPolicyReconciler) RemoveOrphanNetworkPolicies(ctx context.Context, dryRun bool) (int, error) {
 	logrus.Info("Searching for orphaned network policies")
+	orphanCount := 0
 	networkPolicyList := &v1.NetworkPolicyList{}
 	selector, err := matchAccessNetworkPolicy()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	err = r.List(ctx, networkPolicyList, &client.ListOptions{LabelSelector: selector})
 	if err != nil {
 		logrus.Infof("Error listing network policies: %s", err.Error())
-		return err
+		return 0, err
 	}
 
 	logrus.Infof("Selector: %s found %d network policies", selector.String(), len(networkPolicyList.Items))
@@ -292,20 +312,24 @@ func (r *PortNetworkPolicyReconciler) removeOrphanNetworkPolicies(ctx context.Co
 			&client.ListOptions{Namespace: clientNamespace},
 		)
 		if err != nil {
-			return err
+			return 0, err
 		}
 
 		if len(intentsList.Items) == 0 {
-			// Check
+			orphanCount++
+			if dryRun {
+				logrus.Infof("Dry run, not removing orphaned network policy: %s server %s ns %s", networkPolicy.Name, serverName, networkPolicy.Namespace)
+				continue
+			}
 			logrus.Infof("Removing orphaned network policy: %s server %s ns %s", networkPolicy.Name, serverName, networkPolicy.Namespace)
 			err = r.removeNetworkPolicy(ctx, networkPolicy)
 			if err != nil {
-				return err
+				return 0, err
 			}
 		}
 	}
 
-	return nil
+	return orphanCount, nil
 }
 
 func (r *PortNetworkPolicyReconciler) removeNetworkPolicy(ctx context.Context, networkPolicy v1.NetworkPolicy) error {
@@ -365,6 +389,15 @@ func (r *PortNetworkPolicyReconciler) CleanPoliciesFromUnprotectedServices(ctx c
 		return nil
 	}
 
+	// All servers in a namespace with default deny are protected, whether or not they have a ProtectedService.
+	namespaceProtected, err := protected_services.IsNamespaceDefaultDenyEnabled(ctx, r.Client, namespace)
+	if err != nil {
+		return err
+	}
+	if namespaceProtected {
+		return nil
+	}
+
 	var protectedServicesResources otterizev1alpha3.ProtectedServiceList
 	err = r.List(ctx, &protectedServicesResources, &client.ListOptions{Namespace: namespace})
 	if err != nil {
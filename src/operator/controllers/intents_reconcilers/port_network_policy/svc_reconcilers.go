@@ -6,6 +6,7 @@ import (
 	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
 	"github.com/otterize/intents-operator/src/shared/injectablerecorder"
 	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/networking/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
@@ -69,7 +70,7 @@ func (r *ServiceWatcher) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 		}
 	}
 
-	err = r.removeOrphanEgressServiceNetpols(ctx)
+	_, err = r.RemoveOrphanEgressServiceNetpols(ctx, false)
 	if err != nil {
 		return ctrl.Result{}, nil
 	}
@@ -87,34 +88,35 @@ func matchEgressAccessNetworkPolicy() (labels.Selector, error) {
 	}})
 }
 
-// removeOrphanEgressServiceNetpols removes orphaned egress service network policies. Normally, for service network policies,
+// RemoveOrphanEgressServiceNetpols removes orphaned egress service network policies. Normally, for service network policies,
 // the network policy has an owner reference to the service, which takes care of the deletion.
 // But for egress policies, the network policy is in the client's namespace, which is potentially in a different namespace
 // than the service, and cross-namespace owner references are not possible.
 // To resolve this, we must either use a finalizer on the service (which would be very harmful if for any reason we fail to remove it),
 // or, this implementation, which lists all service egress network policies, and for each of them (O(n)) checks if the service
-// exists (O(1)). If not, it deletes the netpol.
-func (r *ServiceWatcher) removeOrphanEgressServiceNetpols(ctx context.Context) error {
+// exists (O(1)). If not, it deletes the netpol. Returns how many orphans were found; when dryRun is set, they are only logged.
+func (r *ServiceWatcher) RemoveOrphanEgressServiceNetpols(ctx context.Context, dryRun bool) (int, error) {
 	networkPolicyList := &v1.NetworkPolicyList{}
 	selector, err := matchEgressAccessNetworkPolicy()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	err = r.List(ctx, networkPolicyList, &client.ListOptions{LabelSelector: selector})
 	if err != nil {
-		return err
+		return 0, err
 	}
 
+	orphanCount := 0
 	for _, netpol := range networkPolicyList.Items {
 		svcName, ok := netpol.Annotations[otterizev1alpha3.OtterizeSvcEgressNetworkPolicyTargetService]
 		if !ok {
-			return err
+			continue
 		}
 
 		svcNamespace, ok := netpol.Annotations[otterizev1alpha3.OtterizeSvcEgressNetworkPolicyTargetServiceNamespace]
 		if !ok {
-			return err
+			continue
 		}
 
 		netpolSvc := &corev1.Service{}
@@ -123,21 +125,27 @@ func (r *ServiceWatcher) removeOrphanEgressServiceNetpols(ctx context.Context) e
 			Name:      svcName,
 		}, netpolSvc)
 		if k8serrors.IsNotFound(err) || netpolSvc.DeletionTimestamp != nil {
+			orphanCount++
+			if dryRun {
+				logrus.Infof("Dry run, not removing orphaned egress network policy %s/%s for service %s/%s", netpol.Namespace, netpol.Name, svcNamespace, svcName)
+				continue
+			}
 			err := r.Delete(ctx, &netpol)
 			if k8serrors.IsNotFound(err) {
 				continue
 			}
 			if err != nil {
-				return err
+				return 0, err
 			}
+			continue
 		}
 
 		if err != nil {
-			return err
+			return 0, err
 		}
 
 	}
-	return nil
+	return orphanCount, nil
 }
 
 func (r *ServiceWatcher) SetupWithManager(mgr manager.Manager) error {
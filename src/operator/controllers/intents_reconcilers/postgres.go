@@ -0,0 +1,223 @@
+package intents_reconcilers
+
+import (
+	"context"
+	"fmt"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/postgres"
+	"github.com/otterize/intents-operator/src/shared/injectablerecorder"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"reflect"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+const (
+	ReasonCouldNotConnectToDatabaseServer    = "CouldNotConnectToDatabaseServer"
+	ReasonApplyingDatabaseIntentsFailed      = "ApplyingDatabaseIntentsFailed"
+	ReasonRemovingDatabaseIntentsFailed      = "RemovingDatabaseIntentsFailed"
+	ReasonAppliedDatabaseIntents             = "AppliedDatabaseIntents"
+	ReasonDatabaseServerNotConfigured        = "DatabaseServerNotConfigured"
+	ReasonDatabaseIntentsTablesNotFound      = "DatabaseIntentsTablesNotFound"
+	ReasonGettingDatabaseServerConfigsFailed = "GettingDatabaseServerConfigsFailed"
+)
+
+//+kubebuilder:rbac:groups=k8s.otterize.com,resources=databaseserverconfigs,verbs=get;list;watch
+
+// PostgresReconciler applies database intents on the PostgreSQL servers of DatabaseServerConfigs, without Otterize
+// Cloud. Each client has a role on each server, granted the operations of its intents to that server and revoked all
+// privileges once its intents to the server are removed.
+type PostgresReconciler struct {
+	client              client.Client
+	scheme              *runtime.Scheme
+	getNewPostgresAdmin postgres.AdminFactoryFunction
+	injectablerecorder.InjectableRecorder
+}
+
+func NewPostgresReconciler(
+	client client.Client,
+	scheme *runtime.Scheme,
+	factoryFunc postgres.AdminFactoryFunction,
+) *PostgresReconciler {
+	return &PostgresReconciler{
+		client:              client,
+		scheme:              scheme,
+		getNewPostgresAdmin: factoryFunc,
+	}
+}
+
+func getDatabaseIntentsByServer(defaultNamespace string, intents []otterizev1alpha3.Intent) map[types.NamespacedName][]otterizev1alpha3.Intent {
+	intentsByServer := map[types.NamespacedName][]otterizev1alpha3.Intent{}
+	for _, intent := range intents {
+		if intent.Type != otterizev1alpha3.IntentTypeDatabase {
+			continue
+		}
+
+		serverName := types.NamespacedName{
+			Name:      intent.GetTargetServerName(),
+			Namespace: intent.GetTargetServerNamespace(defaultNamespace),
+		}
+
+		intentsByServer[serverName] = append(intentsByServer[serverName], intent)
+	}
+
+	return intentsByServer
+}
+
+func (r *PostgresReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	intents := &otterizev1alpha3.ClientIntents{}
+	logger := logrus.WithField("namespacedName", req.String())
+	err := r.client.Get(ctx, req.NamespacedName, intents)
+	if err != nil && k8serrors.IsNotFound(err) {
+		logger.Info("No intents found")
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if intents.Spec == nil {
+		logger.Info("No specs found")
+		return ctrl.Result{}, nil
+	}
+
+	// Only servers the intents call, and servers intents were applied on before and are removed from, are touched.
+	intentsByServer := getDatabaseIntentsByServer(intents.Namespace, intents.GetCallsList())
+	appliedServers := sets.New(lo.FromPtr(intents.Status).DatabaseServers...)
+	servers := sets.KeySet(intentsByServer)
+	for server := range appliedServers {
+		servers.Insert(parseDatabaseServerName(server))
+	}
+	if !intents.DeletionTimestamp.IsZero() {
+		// Intents of deleted ClientIntents are removed.
+		intentsByServer = nil
+	}
+	// The status is updated even if reconciling a server fails, so that the servers intents were applied on are kept.
+	reconcileErr := r.reconcileServers(ctx, intents, intentsByServer, servers, appliedServers)
+	statusErr := r.updateDatabaseServersStatus(ctx, intents, appliedServers)
+	if reconcileErr != nil {
+		return ctrl.Result{}, reconcileErr
+	}
+	if statusErr != nil {
+		return ctrl.Result{}, statusErr
+	}
+
+	if intents.DeletionTimestamp.IsZero() && len(intentsByServer) != 0 {
+		r.RecordNormalEventf(intents, ReasonAppliedDatabaseIntents, "Database intents reconcile complete, reconciled %d servers", len(intentsByServer))
+	}
+	return ctrl.Result{}, nil
+}
+
+func formatDatabaseServerName(serverName types.NamespacedName) string {
+	return fmt.Sprintf("%s.%s", serverName.Name, serverName.Namespace)
+}
+
+func parseDatabaseServerName(server string) types.NamespacedName {
+	// Namespaces can't contain dots, unlike names.
+	separator := strings.LastIndex(server, ".")
+	if separator == -1 {
+		return types.NamespacedName{Name: server}
+	}
+	return types.NamespacedName{Name: server[:separator], Namespace: server[separator+1:]}
+}
+
+// reconcileServers applies intentsByServer on each of servers, and removes the client's intents from those it no longer
+// calls. appliedServers is updated with the servers intents may have been applied on, so that it is accurate even if
+// reconciling a server fails.
+func (r *PostgresReconciler) reconcileServers(
+	ctx context.Context,
+	intents *otterizev1alpha3.ClientIntents,
+	intentsByServer map[types.NamespacedName][]otterizev1alpha3.Intent,
+	servers sets.Set[types.NamespacedName],
+	appliedServers sets.Set[string],
+) error {
+	for _, serverName := range sortedServerNames(servers) {
+		intentsForServer := intentsByServer[serverName]
+		config := otterizev1alpha3.DatabaseServerConfig{}
+		err := r.client.Get(ctx, serverName, &config)
+		if k8serrors.IsNotFound(err) {
+			if len(intentsForServer) != 0 {
+				r.RecordWarningEventf(intents, ReasonDatabaseServerNotConfigured, "database server %s not configured", serverName)
+				logrus.WithField("server", serverName).Warning("Did not apply intents to server - no DatabaseServerConfig was defined")
+			}
+			// Intents applied on a server whose DatabaseServerConfig was deleted can no longer be removed by the operator.
+			appliedServers.Delete(formatDatabaseServerName(serverName))
+			continue
+		} else if err != nil {
+			r.RecordWarningEventf(intents, ReasonGettingDatabaseServerConfigsFailed, "failed getting DatabaseServerConfig %s: %s", serverName, err.Error())
+			return err
+		}
+
+		if len(intentsForServer) != 0 {
+			appliedServers.Insert(formatDatabaseServerName(serverName))
+		}
+		if err := r.applyOnServer(ctx, intents, config, intentsForServer); err != nil {
+			return err
+		}
+		if len(intentsForServer) == 0 {
+			appliedServers.Delete(formatDatabaseServerName(serverName))
+		}
+	}
+	return nil
+}
+
+func sortedServerNames(servers sets.Set[types.NamespacedName]) []types.NamespacedName {
+	serverNames := servers.UnsortedList()
+	slices.SortFunc(serverNames, func(a, b types.NamespacedName) bool {
+		return a.String() < b.String()
+	})
+	return serverNames
+}
+
+func (r *PostgresReconciler) updateDatabaseServersStatus(ctx context.Context, intents *otterizev1alpha3.ClientIntents, appliedServers sets.Set[string]) error {
+	databaseServers := sets.List(appliedServers)
+	currentDatabaseServers := lo.FromPtr(intents.Status).DatabaseServers
+	if (len(currentDatabaseServers) == 0 && len(databaseServers) == 0) || reflect.DeepEqual(currentDatabaseServers, databaseServers) {
+		return nil
+	}
+
+	intentsCopy := intents.DeepCopy()
+	if intentsCopy.Status == nil {
+		intentsCopy.Status = &otterizev1alpha3.IntentsStatus{}
+	}
+	intentsCopy.Status.DatabaseServers = lo.Ternary(len(databaseServers) == 0, nil, databaseServers)
+	if err := r.client.Status().Patch(ctx, intentsCopy, client.MergeFrom(intents)); err != nil {
+		return fmt.Errorf("failed updating database servers status: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresReconciler) applyOnServer(ctx context.Context, intents *otterizev1alpha3.ClientIntents, config otterizev1alpha3.DatabaseServerConfig, intentsForServer []otterizev1alpha3.Intent) error {
+	serverName := types.NamespacedName{Name: config.Name, Namespace: config.Namespace}
+	postgresAdmin, err := r.getNewPostgresAdmin(ctx, config)
+	if err != nil {
+		err = fmt.Errorf("failed to connect to database server %s: %w", serverName, err)
+		r.RecordWarningEventf(intents, ReasonCouldNotConnectToDatabaseServer, "Database intents reconcile failed: %s", err.Error())
+		return err
+	}
+	defer postgresAdmin.Close(ctx)
+
+	if len(intentsForServer) == 0 {
+		if err := postgresAdmin.RemoveClientIntents(ctx, intents.Spec.Service.Name, intents.Namespace); err != nil {
+			r.RecordWarningEventf(intents, ReasonRemovingDatabaseIntentsFailed, "Database intents removal failed: %s", err.Error())
+			return fmt.Errorf("failed removing intents on database server %s: %w", serverName, err)
+		}
+		return nil
+	}
+
+	missingTables, err := postgresAdmin.ApplyClientIntents(ctx, intents.Spec.Service.Name, intents.Namespace, intentsForServer)
+	if err != nil {
+		r.RecordWarningEventf(intents, ReasonApplyingDatabaseIntentsFailed, "Database intents reconcile failed: %s", err.Error())
+		return fmt.Errorf("failed applying intents on database server %s: %w", serverName, err)
+	}
+	if len(missingTables) != 0 {
+		r.RecordWarningEventf(intents, ReasonDatabaseIntentsTablesNotFound, "tables %s not found on database server %s", strings.Join(lo.Uniq(missingTables), ", "), serverName)
+	}
+	return nil
+}
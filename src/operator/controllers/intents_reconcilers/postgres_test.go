@@ -0,0 +1,224 @@
+package intents_reconcilers
+
+import (
+	"context"
+	"errors"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/postgres"
+	postgresmocks "github.com/otterize/intents-operator/src/operator/controllers/postgres/mocks"
+	"github.com/otterize/intents-operator/src/shared/testbase"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"testing"
+	"time"
+)
+
+const (
+	testDatabaseServerName = "test-database"
+)
+
+// statusWriter records the status patches of ClientIntents.
+type statusWriter struct {
+	patched []*otterizev1alpha3.ClientIntents
+}
+
+func (w *statusWriter) Create(ctx context.Context, obj client.Object, subResource client.Object, opts ...client.SubResourceCreateOption) error {
+	return errors.New("unexpected status create")
+}
+
+func (w *statusWriter) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+	return errors.New("unexpected status update")
+}
+
+func (w *statusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+	w.patched = append(w.patched, obj.(*otterizev1alpha3.ClientIntents).DeepCopy())
+	return nil
+}
+
+type PostgresReconcilerTestSuite struct {
+	testbase.MocksSuiteBase
+	Reconciler     *PostgresReconciler
+	postgresAdmin  *postgresmocks.MockPostgresAdmin
+	statusWriter   *statusWriter
+	connectedTo    []string
+	connectFailure error
+}
+
+func (s *PostgresReconcilerTestSuite) SetupTest() {
+	s.MocksSuiteBase.SetupTest()
+	s.postgresAdmin = postgresmocks.NewMockPostgresAdmin(s.Controller)
+	s.statusWriter = &statusWriter{}
+	s.Client.EXPECT().Status().Return(s.statusWriter).AnyTimes()
+	s.connectedTo = nil
+	s.connectFailure = nil
+
+	s.Reconciler = NewPostgresReconciler(
+		s.Client,
+		&runtime.Scheme{},
+		func(ctx context.Context, config otterizev1alpha3.DatabaseServerConfig) (postgres.PostgresAdmin, error) {
+			s.connectedTo = append(s.connectedTo, config.Name)
+			if s.connectFailure != nil {
+				return nil, s.connectFailure
+			}
+			return s.postgresAdmin, nil
+		},
+	)
+	s.Reconciler.Recorder = s.Recorder
+}
+
+func (s *PostgresReconcilerTestSuite) databaseServerConfig(name string) otterizev1alpha3.DatabaseServerConfig {
+	return otterizev1alpha3.DatabaseServerConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+		Spec: otterizev1alpha3.DatabaseServerConfigSpec{
+			Address:               "postgres:5432",
+			Database:              "postgres",
+			CredentialsSecretName: "postgres-credentials",
+		},
+	}
+}
+
+func (s *PostgresReconcilerTestSuite) expectReconcile(intents otterizev1alpha3.ClientIntents, configs ...otterizev1alpha3.DatabaseServerConfig) (ctrl.Result, error) {
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: intents.Name, Namespace: intents.Namespace}}
+	s.Client.EXPECT().Get(gomock.Any(), req.NamespacedName, gomock.AssignableToTypeOf(&otterizev1alpha3.ClientIntents{})).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, clientIntents *otterizev1alpha3.ClientIntents, opts ...any) error {
+			intents.DeepCopyInto(clientIntents)
+			return nil
+		})
+	s.Client.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf(&otterizev1alpha3.DatabaseServerConfig{})).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, databaseServerConfig *otterizev1alpha3.DatabaseServerConfig, opts ...any) error {
+			config, ok := lo.Find(configs, func(config otterizev1alpha3.DatabaseServerConfig) bool {
+				return config.Name == name.Name && config.Namespace == name.Namespace
+			})
+			if !ok {
+				return apierrors.NewNotFound(otterizev1alpha3.GroupVersion.WithResource("databaseserverconfigs").GroupResource(), name.Name)
+			}
+			config.DeepCopyInto(databaseServerConfig)
+			return nil
+		}).AnyTimes()
+
+	return s.Reconciler.Reconcile(context.Background(), req)
+}
+
+func databaseClientIntents(resources ...otterizev1alpha3.DatabaseResource) otterizev1alpha3.ClientIntents {
+	return otterizev1alpha3.ClientIntents{
+		ObjectMeta: metav1.ObjectMeta{Name: intentsObjectName, Namespace: testNamespace},
+		Spec: &otterizev1alpha3.IntentsSpec{
+			Service: otterizev1alpha3.Service{Name: clientName},
+			Calls: []otterizev1alpha3.Intent{
+				{Name: "test-server"},
+				{Name: testDatabaseServerName, Type: otterizev1alpha3.IntentTypeDatabase, DatabaseResources: resources},
+			},
+		},
+	}
+}
+
+func (s *PostgresReconcilerTestSuite) requireDatabaseServersStatus(databaseServers ...string) {
+	s.Require().NotEmpty(s.statusWriter.patched)
+	s.Require().Equal(databaseServers, lo.FromPtr(s.statusWriter.patched[len(s.statusWriter.patched)-1].Status).DatabaseServers)
+}
+
+func (s *PostgresReconcilerTestSuite) TestDatabaseIntentsApplied() {
+	intents := databaseClientIntents(otterizev1alpha3.DatabaseResource{
+		Table:      "orders",
+		Operations: []otterizev1alpha3.DatabaseOperation{otterizev1alpha3.DatabaseOperationSelect},
+	})
+
+	s.postgresAdmin.EXPECT().ApplyClientIntents(gomock.Any(), clientName, testNamespace, intents.Spec.Calls[1:]).Return(nil, nil)
+	s.postgresAdmin.EXPECT().Close(gomock.Any())
+
+	// Servers the intents don't call and were not applied on are not connected to
+	res, err := s.expectReconcile(intents, s.databaseServerConfig(testDatabaseServerName), s.databaseServerConfig("another-database"))
+	s.Require().NoError(err)
+	s.Require().Empty(res)
+	s.Require().Equal([]string{testDatabaseServerName}, s.connectedTo)
+	s.requireDatabaseServersStatus(testDatabaseServerName + "." + testNamespace)
+	s.ExpectEvent(ReasonAppliedDatabaseIntents)
+}
+
+func (s *PostgresReconcilerTestSuite) TestDatabaseIntentsRemovedFromServersNoLongerCalled() {
+	intents := databaseClientIntents(otterizev1alpha3.DatabaseResource{
+		Table:      "orders",
+		Operations: []otterizev1alpha3.DatabaseOperation{otterizev1alpha3.DatabaseOperationSelect},
+	})
+	intents.Status = &otterizev1alpha3.IntentsStatus{DatabaseServers: []string{"another-database." + testNamespace}}
+
+	s.postgresAdmin.EXPECT().ApplyClientIntents(gomock.Any(), clientName, testNamespace, intents.Spec.Calls[1:]).Return(nil, nil)
+	s.postgresAdmin.EXPECT().RemoveClientIntents(gomock.Any(), clientName, testNamespace).Return(nil)
+	s.postgresAdmin.EXPECT().Close(gomock.Any()).Times(2)
+
+	_, err := s.expectReconcile(intents, s.databaseServerConfig(testDatabaseServerName), s.databaseServerConfig("another-database"), s.databaseServerConfig("third-database"))
+	s.Require().NoError(err)
+	s.Require().Equal([]string{"another-database", testDatabaseServerName}, s.connectedTo)
+	s.requireDatabaseServersStatus(testDatabaseServerName + "." + testNamespace)
+	s.ExpectEvent(ReasonAppliedDatabaseIntents)
+}
+
+func (s *PostgresReconcilerTestSuite) TestMissingTablesReported() {
+	intents := databaseClientIntents(otterizev1alpha3.DatabaseResource{
+		Table:      "refunds",
+		Operations: []otterizev1alpha3.DatabaseOperation{otterizev1alpha3.DatabaseOperationSelect},
+	})
+
+	s.postgresAdmin.EXPECT().ApplyClientIntents(gomock.Any(), clientName, testNamespace, gomock.Any()).Return([]string{"public.refunds"}, nil)
+	s.postgresAdmin.EXPECT().Close(gomock.Any())
+
+	_, err := s.expectReconcile(intents, s.databaseServerConfig(testDatabaseServerName))
+	s.Require().NoError(err)
+	s.ExpectEvent(ReasonDatabaseIntentsTablesNotFound)
+	s.ExpectEvent(ReasonAppliedDatabaseIntents)
+}
+
+func (s *PostgresReconcilerTestSuite) TestDatabaseIntentsRemovedOnDeletion() {
+	intents := databaseClientIntents(otterizev1alpha3.DatabaseResource{
+		Table:      "orders",
+		Operations: []otterizev1alpha3.DatabaseOperation{otterizev1alpha3.DatabaseOperationAll},
+	})
+	intents.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	intents.Status = &otterizev1alpha3.IntentsStatus{DatabaseServers: []string{testDatabaseServerName + "." + testNamespace}}
+
+	s.postgresAdmin.EXPECT().RemoveClientIntents(gomock.Any(), clientName, testNamespace).Return(nil)
+	s.postgresAdmin.EXPECT().Close(gomock.Any())
+
+	_, err := s.expectReconcile(intents, s.databaseServerConfig(testDatabaseServerName))
+	s.Require().NoError(err)
+	s.requireDatabaseServersStatus()
+}
+
+func (s *PostgresReconcilerTestSuite) TestDatabaseServerNotConfigured() {
+	intents := databaseClientIntents(otterizev1alpha3.DatabaseResource{
+		Table:      "orders",
+		Operations: []otterizev1alpha3.DatabaseOperation{otterizev1alpha3.DatabaseOperationSelect},
+	})
+
+	_, err := s.expectReconcile(intents)
+	s.Require().NoError(err)
+	s.Require().Empty(s.connectedTo)
+	s.Require().Empty(s.statusWriter.patched)
+	s.ExpectEvent(ReasonDatabaseServerNotConfigured)
+	s.ExpectEvent(ReasonAppliedDatabaseIntents)
+}
+
+func (s *PostgresReconcilerTestSuite) TestConnectionFailureReturnsError() {
+	intents := databaseClientIntents(otterizev1alpha3.DatabaseResource{
+		Table:      "orders",
+		Operations: []otterizev1alpha3.DatabaseOperation{otterizev1alpha3.DatabaseOperationSelect},
+	})
+	s.connectFailure = errors.New("connection refused")
+
+	_, err := s.expectReconcile(intents, s.databaseServerConfig(testDatabaseServerName))
+	s.Require().ErrorIs(err, s.connectFailure)
+	// The server is recorded, as intents may have been applied on it before
+	s.requireDatabaseServersStatus(testDatabaseServerName + "." + testNamespace)
+	s.ExpectEvent(ReasonCouldNotConnectToDatabaseServer)
+}
+
+func TestPostgresReconcilerTestSuite(t *testing.T) {
+	suite.Run(t, new(PostgresReconcilerTestSuite))
+}
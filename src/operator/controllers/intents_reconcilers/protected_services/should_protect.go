@@ -4,7 +4,11 @@ import (
 	"context"
 	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -32,10 +36,42 @@ func IsServerEnforcementEnabledDueToProtectionOrDefaultState(ctx context.Context
 		return true, nil
 	}
 
+	namespaceProtected, err := IsNamespaceDefaultDenyEnabled(ctx, kube, serverNamespace)
+	if err != nil {
+		return false, err
+	}
+
+	if namespaceProtected {
+		logrus.Debugf("Server %s is in namespace %s, which has ingress default deny enabled", serverName, serverNamespace)
+		return true, nil
+	}
+
 	logrus.Debugf("Server %s in namespace %s is not in protected list", serverName, serverNamespace)
 	return false, nil
 }
 
+// IsNamespaceDefaultDenyEnabled checks whether a namespace is annotated to deny all ingress traffic, which protects
+// every server in it as if each had a ProtectedService.
+func IsNamespaceDefaultDenyEnabled(ctx context.Context, kube client.Client, namespace string) (bool, error) {
+	ns := &corev1.Namespace{}
+	err := kube.Get(ctx, types.NamespacedName{Name: namespace}, ns)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	policyTypes, err := otterizev1alpha3.GetNamespaceDefaultDenyPolicyTypes(ns)
+	if err != nil {
+		// The namespace default deny reconciler reports the invalid annotation, and does not apply it.
+		logrus.WithError(err).Debugf("Ignoring default deny annotation of namespace %s", namespace)
+		return false, nil
+	}
+
+	return slices.Contains(policyTypes, networkingv1.PolicyTypeIngress), nil
+}
+
 // InitProtectedServiceIndexField indexes protected service resources by their service name
 // This is used in finalizers to determine whether a network policy should be removed from the target namespace
 func InitProtectedServiceIndexField(mgr ctrl.Manager) error {
@@ -0,0 +1,167 @@
+package istiopolicy
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/shared/serviceidresolver"
+	"github.com/samber/lo"
+	"istio.io/client-go/pkg/apis/security/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+)
+
+const (
+	IstioDataplaneModeLabel   = "istio.io/dataplane-mode"
+	IstioDataplaneModeAmbient = "ambient"
+	IstioDataplaneModeNone    = "none"
+	IstioUseWaypointLabel     = "istio.io/use-waypoint"
+	IstioWaypointNone         = "none"
+	// OtterizeIstioTargetRefsAnnotation holds the targetRefs of policies enforced by a waypoint. The Istio API version
+	// the operator is built with does not have targetRefs, so they are not read back from the policy itself.
+	OtterizeIstioTargetRefsAnnotation = "intents.otterize.com/istio-target-refs"
+)
+
+// MeshEnrollment is how a pod is enrolled in the Istio mesh: either with a sidecar, or in ambient mode, where ztunnel
+// enforces L4 policies and the waypoint used by the pod's services, if any, enforces L7 policies.
+type MeshEnrollment struct {
+	Sidecar  bool
+	Ambient  bool
+	Waypoint string
+	Services []string
+}
+
+func (e MeshEnrollment) IsEnrolled() bool {
+	return e.Sidecar || e.Ambient
+}
+
+// AmbientServer returns the status recorded on the intents of clients calling the pod, or nil if it is not in ambient
+// mode.
+func (e MeshEnrollment) AmbientServer() *v1alpha3.AmbientServer {
+	if !e.Ambient {
+		return nil
+	}
+	return &v1alpha3.AmbientServer{Waypoint: e.Waypoint, Services: e.Services}
+}
+
+// ResolveMeshEnrollment returns how the pod is enrolled in the Istio mesh. Pods are enrolled in ambient mode by
+// labelling them or their namespace, and use the waypoint their service or namespace is labelled with. Waypoints set on
+// pods themselves only apply to traffic addressed to the pod rather than to its services, and are not considered.
+func ResolveMeshEnrollment(ctx context.Context, k8sClient client.Client, resolver serviceidresolver.ServiceResolver, pod corev1.Pod) (MeshEnrollment, error) {
+	if IsPodPartOfIstioMesh(pod) {
+		return MeshEnrollment{Sidecar: true}, nil
+	}
+
+	if pod.Labels[IstioDataplaneModeLabel] == IstioDataplaneModeNone {
+		return MeshEnrollment{}, nil
+	}
+
+	var namespace corev1.Namespace
+	err := k8sClient.Get(ctx, types.NamespacedName{Name: pod.Namespace}, &namespace)
+	if err != nil {
+		return MeshEnrollment{}, err
+	}
+
+	if pod.Labels[IstioDataplaneModeLabel] != IstioDataplaneModeAmbient && namespace.Labels[IstioDataplaneModeLabel] != IstioDataplaneModeAmbient {
+		return MeshEnrollment{}, nil
+	}
+
+	services, err := resolver.GetKubernetesServicesTargetingPod(ctx, &pod)
+	if err != nil {
+		return MeshEnrollment{}, err
+	}
+
+	enrollment := MeshEnrollment{Ambient: true}
+	for _, service := range services {
+		waypoint := lo.Ternary(service.Labels[IstioUseWaypointLabel] != "", service.Labels[IstioUseWaypointLabel], namespace.Labels[IstioUseWaypointLabel])
+		if waypoint == "" || waypoint == IstioWaypointNone {
+			continue
+		}
+		enrollment.Waypoint = waypoint
+		enrollment.Services = append(enrollment.Services, service.Name)
+	}
+	sort.Strings(enrollment.Services)
+
+	return enrollment, nil
+}
+
+// targetWaypoint makes the policy target the server's services, so it is enforced by their waypoint rather than by
+// ztunnel, which cannot enforce L7 rules.
+func targetWaypoint(policy *v1beta1.AuthorizationPolicy, ambientServer v1alpha3.AmbientServer) error {
+	targetRefs := lo.Map(ambientServer.Services, func(service string, _ int) map[string]string {
+		return map[string]string{"group": "", "kind": "Service", "name": service}
+	})
+	targetRefsValue, err := json.Marshal(targetRefs)
+	if err != nil {
+		return err
+	}
+
+	policy.Spec.Selector = nil
+	policy.Annotations = map[string]string{OtterizeIstioTargetRefsAnnotation: string(targetRefsValue)}
+	return nil
+}
+
+// canTargetWaypoint returns whether policies enforced by a waypoint can be created, recording a warning on the intents
+// if they cannot, since the installed AuthorizationPolicy CRD would drop their targetRefs.
+func (c *PolicyManagerImpl) canTargetWaypoint(ctx context.Context, clientIntents *v1alpha3.ClientIntents, serverName string) (bool, error) {
+	supported, err := IsIstioWaypointTargetRefsSupported(ctx, c.client)
+	if err != nil {
+		return false, err
+	}
+	if !supported {
+		c.recorder.RecordWarningEventf(clientIntents, ReasonWaypointTargetRefsUnsupported, "Server %s is enforced by a waypoint, but the installed Istio AuthorizationPolicy CRD does not support targetRefs, Istio policy ignored", serverName)
+	}
+	return supported, nil
+}
+
+// toUnstructuredPolicy converts a policy to an unstructured object, adding the targetRefs of waypoint policies. Fields
+// the policy does not set are explicitly nulled, so the object can also be used as a merge patch replacing a policy
+// that set them.
+func toUnstructuredPolicy(policy *v1beta1.AuthorizationPolicy) (*unstructured.Unstructured, error) {
+	policyValue, err := json.Marshal(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	object := &unstructured.Unstructured{}
+	err = json.Unmarshal(policyValue, &object.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the metadata managed by the operator is kept, as the object may be sent as a patch.
+	object.Object["metadata"] = map[string]any{}
+	object.SetAPIVersion(v1beta1.SchemeGroupVersion.String())
+	object.SetKind("AuthorizationPolicy")
+	object.SetName(policy.Name)
+	object.SetNamespace(policy.Namespace)
+	object.SetLabels(policy.Labels)
+	object.SetAnnotations(policy.Annotations)
+	unstructured.RemoveNestedField(object.Object, "status")
+
+	spec, _, err := unstructured.NestedMap(object.Object, "spec")
+	if err != nil {
+		return nil, err
+	}
+	if spec == nil {
+		spec = make(map[string]any)
+	}
+	spec["targetRefs"] = nil
+	if targetRefsValue, ok := policy.Annotations[OtterizeIstioTargetRefsAnnotation]; ok {
+		var targetRefs []any
+		err = json.Unmarshal([]byte(targetRefsValue), &targetRefs)
+		if err != nil {
+			return nil, err
+		}
+		spec["targetRefs"] = targetRefs
+	}
+	if _, ok := spec["selector"]; !ok {
+		spec["selector"] = nil
+	}
+	object.Object["spec"] = spec
+
+	return object, nil
+}
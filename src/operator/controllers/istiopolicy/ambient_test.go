@@ -0,0 +1,111 @@
+package istiopolicy
+
+import (
+	"context"
+	serviceidresolvermocks "github.com/otterize/intents-operator/src/shared/serviceidresolver/mocks"
+	"github.com/otterize/intents-operator/src/shared/testbase"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"testing"
+)
+
+type AmbientTestSuite struct {
+	testbase.MocksSuiteBase
+	resolver *serviceidresolvermocks.MockServiceResolver
+}
+
+func (s *AmbientTestSuite) SetupTest() {
+	s.MocksSuiteBase.SetupTest()
+	s.resolver = serviceidresolvermocks.NewMockServiceResolver(s.Controller)
+}
+
+func (s *AmbientTestSuite) expectNamespace(labels map[string]string) {
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Name: "test-namespace"}, gomock.AssignableToTypeOf(&corev1.Namespace{})).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, namespace *corev1.Namespace, opts ...client.GetOption) error {
+			namespace.Name = name.Name
+			namespace.Labels = labels
+			return nil
+		})
+}
+
+func newPod(labels map[string]string, containers ...string) corev1.Pod {
+	pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "test-namespace", Labels: labels}}
+	for _, container := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: container})
+	}
+	return pod
+}
+
+func newService(name string, labels map[string]string) corev1.Service {
+	return corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-namespace", Labels: labels}}
+}
+
+func (s *AmbientTestSuite) TestSidecarPod() {
+	enrollment, err := ResolveMeshEnrollment(context.Background(), s.Client, s.resolver, newPod(nil, "app", IstioProxyContainerName))
+	s.Require().NoError(err)
+	s.Require().Equal(MeshEnrollment{Sidecar: true}, enrollment)
+	s.Require().Nil(enrollment.AmbientServer())
+}
+
+func (s *AmbientTestSuite) TestPodNotInMesh() {
+	s.expectNamespace(map[string]string{})
+
+	enrollment, err := ResolveMeshEnrollment(context.Background(), s.Client, s.resolver, newPod(nil, "app"))
+	s.Require().NoError(err)
+	s.Require().False(enrollment.IsEnrolled())
+}
+
+func (s *AmbientTestSuite) TestPodOptedOutOfAmbientNamespace() {
+	enrollment, err := ResolveMeshEnrollment(context.Background(), s.Client, s.resolver,
+		newPod(map[string]string{IstioDataplaneModeLabel: IstioDataplaneModeNone}, "app"))
+	s.Require().NoError(err)
+	s.Require().False(enrollment.IsEnrolled())
+}
+
+func (s *AmbientTestSuite) TestAmbientNamespaceWithWaypoint() {
+	pod := newPod(nil, "app")
+	s.expectNamespace(map[string]string{IstioDataplaneModeLabel: IstioDataplaneModeAmbient, IstioUseWaypointLabel: "waypoint"})
+	s.resolver.EXPECT().GetKubernetesServicesTargetingPod(gomock.Any(), &pod).Return([]corev1.Service{
+		newService("server-b", nil),
+		newService("server-a", nil),
+		newService("server-no-waypoint", map[string]string{IstioUseWaypointLabel: IstioWaypointNone}),
+	}, nil)
+
+	enrollment, err := ResolveMeshEnrollment(context.Background(), s.Client, s.resolver, pod)
+	s.Require().NoError(err)
+	s.Require().True(enrollment.IsEnrolled())
+	s.Require().Equal("waypoint", enrollment.Waypoint)
+	s.Require().Equal([]string{"server-a", "server-b"}, enrollment.Services)
+}
+
+func (s *AmbientTestSuite) TestAmbientPodWithServiceWaypoint() {
+	pod := newPod(map[string]string{IstioDataplaneModeLabel: IstioDataplaneModeAmbient}, "app")
+	s.expectNamespace(map[string]string{})
+	s.resolver.EXPECT().GetKubernetesServicesTargetingPod(gomock.Any(), &pod).Return([]corev1.Service{
+		newService("server", map[string]string{IstioUseWaypointLabel: "server-waypoint"}),
+	}, nil)
+
+	enrollment, err := ResolveMeshEnrollment(context.Background(), s.Client, s.resolver, pod)
+	s.Require().NoError(err)
+	s.Require().Equal("server-waypoint", enrollment.AmbientServer().Waypoint)
+	s.Require().Equal([]string{"server"}, enrollment.AmbientServer().Services)
+}
+
+func (s *AmbientTestSuite) TestAmbientPodWithoutWaypoint() {
+	pod := newPod(nil, "app")
+	s.expectNamespace(map[string]string{IstioDataplaneModeLabel: IstioDataplaneModeAmbient})
+	s.resolver.EXPECT().GetKubernetesServicesTargetingPod(gomock.Any(), &pod).Return([]corev1.Service{newService("server", nil)}, nil)
+
+	enrollment, err := ResolveMeshEnrollment(context.Background(), s.Client, s.resolver, pod)
+	s.Require().NoError(err)
+	s.Require().True(enrollment.IsEnrolled())
+	s.Require().Empty(enrollment.AmbientServer().Waypoint)
+}
+
+func TestAmbientTestSuite(t *testing.T) {
+	suite.Run(t, new(AmbientTestSuite))
+}
@@ -0,0 +1,445 @@
+package istiopolicy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/otterize/intents-operator/src/operator/api/v1alpha2"
+	"github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/consts"
+	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/protected_services"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	v1beta1security "istio.io/api/security/v1beta1"
+	v1beta1type "istio.io/api/type/v1beta1"
+	"istio.io/client-go/pkg/apis/security/v1beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+)
+
+const (
+	OtterizeIstioConsolidatedPolicyNameTemplate = "authorization-policy-to-%s"
+	// OtterizeIstioConsolidatedPolicyLabelKey marks policies holding the rules of all the clients of a server.
+	OtterizeIstioConsolidatedPolicyLabelKey = "intents.otterize.com/istio-consolidated-policy"
+	// OtterizeIstioPolicyClientsAnnotation lists the formatted identities of the clients with a rule in a consolidated
+	// policy, so the policy can be found and rebuilt when one of them stops calling the server.
+	OtterizeIstioPolicyClientsAnnotation = "intents.otterize.com/istio-clients"
+)
+
+// consolidatedServer is a server that has, or should have, a consolidated policy. The name is unknown for servers found
+// through their existing policy, and is then taken from the intents calling the server.
+type consolidatedServer struct {
+	formattedName     string
+	name              string
+	namespace         string
+	kubernetesService bool
+}
+
+// indexValue is the value ClientIntents calling the server are indexed by, distinguishing intents targeting the
+// server's Kubernetes service.
+func (s consolidatedServer) indexValue() string {
+	return lo.Ternary(s.kubernetesService, "svc:"+s.formattedName, s.formattedName)
+}
+
+// createServerPolicies reconciles the consolidated policies of the servers the client calls, and of those it used to
+// call. Policies created for each client and server are then deleted, once the consolidated policies grant their access.
+func (c *PolicyManagerImpl) createServerPolicies(ctx context.Context, clientIntents *v1alpha3.ClientIntents, clientServiceAccount string) error {
+	servers, err := c.listServersOfClient(ctx, clientIntents)
+	if err != nil {
+		c.recorder.RecordWarningEventf(clientIntents, ReasonGettingIstioPolicyFailed, "Could not get Istio policies: %s", err.Error())
+		return err
+	}
+
+	for _, intent := range clientIntents.GetCallsList() {
+		if intent.Type != "" && intent.Type != v1alpha3.IntentTypeHTTP {
+			continue
+		}
+		serverNamespace := intent.GetTargetServerNamespace(clientIntents.Namespace)
+		formattedServer := v1alpha2.GetFormattedOtterizeIdentity(intent.GetTargetServerName(), serverNamespace)
+		server := consolidatedServer{
+			formattedName:     formattedServer,
+			name:              intent.GetTargetServerName(),
+			namespace:         serverNamespace,
+			kubernetesService: intent.IsTargetServerKubernetesService(),
+		}
+		servers[server.indexValue()] = server
+	}
+
+	serverKeys := lo.Keys(servers)
+	sort.Strings(serverKeys)
+	reconciledServers := 0
+	for _, serverKey := range serverKeys {
+		reconciled, err := c.reconcileServerPolicy(ctx, clientIntents, clientServiceAccount, servers[serverKey])
+		if err != nil {
+			return err
+		}
+		if reconciled {
+			reconciledServers++
+		}
+	}
+
+	clientFormattedIdentity := v1alpha2.GetFormattedOtterizeIdentity(clientIntents.Spec.Service.Name, clientIntents.Namespace)
+	var clientPolicies v1beta1.AuthorizationPolicyList
+	err = c.client.List(ctx, &clientPolicies, client.MatchingLabels{v1alpha2.OtterizeIstioClientAnnotationKey: clientFormattedIdentity})
+	if err != nil {
+		c.recorder.RecordWarningEventf(clientIntents, ReasonGettingIstioPolicyFailed, "Could not get Istio policies: %s", err.Error())
+		return err
+	}
+	for _, policy := range clientPolicies.Items {
+		err = c.client.Delete(ctx, policy)
+		if client.IgnoreNotFound(err) != nil {
+			c.recorder.RecordWarningEventf(clientIntents, ReasonDeleteIstioPolicyFailed, "Failed to delete Istio policy: %s", err.Error())
+			return err
+		}
+	}
+
+	if reconciledServers != 0 {
+		c.recorder.RecordNormalEventf(clientIntents, ReasonCreatedIstioPolicy, "Istio policy reconcile complete, reconciled %d servers", reconciledServers)
+	}
+
+	return nil
+}
+
+// removeFromServerPolicies rebuilds the consolidated policies the client has a rule in, without the client.
+func (c *PolicyManagerImpl) removeFromServerPolicies(ctx context.Context, clientIntents *v1alpha3.ClientIntents) error {
+	servers, err := c.listServersOfClient(ctx, clientIntents)
+	if err != nil {
+		return err
+	}
+
+	for _, server := range servers {
+		_, err = c.reconcileServerPolicy(ctx, clientIntents, "", server)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listServersOfClient returns the servers whose consolidated policies have a rule for the client.
+func (c *PolicyManagerImpl) listServersOfClient(ctx context.Context, clientIntents *v1alpha3.ClientIntents) (map[string]consolidatedServer, error) {
+	clientFormattedIdentity := v1alpha2.GetFormattedOtterizeIdentity(clientIntents.Spec.Service.Name, clientIntents.Namespace)
+
+	var existingPolicies v1beta1.AuthorizationPolicyList
+	err := c.client.List(ctx, &existingPolicies, client.MatchingLabels{OtterizeIstioConsolidatedPolicyLabelKey: "true"})
+	if err != nil {
+		return nil, err
+	}
+
+	servers := make(map[string]consolidatedServer)
+	for _, policy := range existingPolicies.Items {
+		var clients []string
+		err = json.Unmarshal([]byte(policy.Annotations[OtterizeIstioPolicyClientsAnnotation]), &clients)
+		if err != nil {
+			logrus.WithError(err).Warningf("Could not parse the clients of Istio policy %s/%s", policy.Namespace, policy.Name)
+		}
+
+		formattedServer := policy.Labels[v1alpha2.OtterizeServerLabelKey]
+		if formattedServer != "" && lo.Contains(clients, clientFormattedIdentity) {
+			server := consolidatedServer{
+				formattedName:     formattedServer,
+				namespace:         policy.Namespace,
+				kubernetesService: policy.Labels[OtterizeIstioKubernetesServiceLabelKey] == "true",
+			}
+			servers[server.indexValue()] = server
+		}
+	}
+
+	return servers, nil
+}
+
+// listIntentsCallingServer returns the ClientIntents calling the server, or its Kubernetes service for servers that
+// are Kubernetes services.
+func (c *PolicyManagerImpl) listIntentsCallingServer(ctx context.Context, server consolidatedServer) ([]v1alpha3.ClientIntents, error) {
+	var intentsList v1alpha3.ClientIntentsList
+	err := c.client.List(ctx, &intentsList, &client.MatchingFields{v1alpha3.OtterizeFormattedTargetServerIndexField: server.indexValue()})
+	if err != nil {
+		return nil, err
+	}
+
+	return intentsList.Items, nil
+}
+
+// reconcileServerPolicy builds the consolidated policy of the server from all the intents calling it, with the
+// in-hand clientIntents replacing their cached copy, and applies it as a single update. The update fails with a conflict
+// if the policy was changed since it was read, rather than overwriting rules written for other clients meanwhile.
+// It returns whether the server's policy grants access to any client.
+func (c *PolicyManagerImpl) reconcileServerPolicy(
+	ctx context.Context,
+	clientIntents *v1alpha3.ClientIntents,
+	clientServiceAccount string,
+	server consolidatedServer,
+) (bool, error) {
+	callers, err := c.listIntentsCallingServer(ctx, server)
+	if err != nil {
+		return false, err
+	}
+
+	isCurrentClient := func(intents *v1alpha3.ClientIntents) bool {
+		return intents.Namespace == clientIntents.Namespace && intents.Name == clientIntents.Name
+	}
+	callers = lo.Filter(callers, func(intents v1alpha3.ClientIntents, _ int) bool {
+		return !isCurrentClient(&intents) && intents.Spec != nil && intents.DeletionTimestamp.IsZero()
+	})
+	if clientIntents.DeletionTimestamp.IsZero() {
+		callers = append(callers, *clientIntents)
+	}
+
+	rulesByClient := make(map[string]*v1beta1security.Rule)
+	var ambientServer *v1alpha3.AmbientServer
+	for i := range callers {
+		caller := &callers[i]
+		intents := intentsCallingServer(caller, server)
+		if len(intents) == 0 {
+			continue
+		}
+		if server.name == "" {
+			server.name = intents[0].GetTargetServerName()
+		}
+
+		serviceAccount := lo.Ternary(isCurrentClient(caller), clientServiceAccount, caller.Annotations[v1alpha2.OtterizeClientServiceAccountAnnotation])
+		principals := c.clientPrincipals(caller, serviceAccount)
+		if len(principals) == 0 {
+			// A rule without principals would allow any source.
+			continue
+		}
+
+		ambientServers, err := caller.GetAmbientServers()
+		if err != nil {
+			return false, err
+		}
+		if status, ok := ambientServers[server.formattedName]; ok && (ambientServer == nil || isCurrentClient(caller)) {
+			ambientServer = &status
+		}
+
+		clientFormattedIdentity := v1alpha2.GetFormattedOtterizeIdentity(caller.GetServiceName(), caller.Namespace)
+		rulesByClient[clientFormattedIdentity] = &v1beta1security.Rule{
+			To:   c.serverRuleTo(intents),
+			From: []*v1beta1security.Rule_From{{Source: &v1beta1security.Source{Principals: principals}}},
+		}
+	}
+
+	if len(rulesByClient) != 0 {
+		shouldCreatePolicy, err := c.shouldCreateServerPolicy(ctx, clientIntents, server)
+		if err != nil {
+			return false, err
+		}
+		if !shouldCreatePolicy {
+			rulesByClient = make(map[string]*v1beta1security.Rule)
+		}
+	}
+
+	if len(rulesByClient) != 0 && ambientServer != nil && ambientServer.Waypoint != "" {
+		supported, err := c.canTargetWaypoint(ctx, clientIntents, server.name)
+		if err != nil {
+			return false, err
+		}
+		if !supported {
+			rulesByClient = make(map[string]*v1beta1security.Rule)
+		}
+	}
+	if ambientServer != nil && ambientServer.Waypoint == "" {
+		// Ztunnel cannot enforce L7 rules, and dropping them would allow the clients to call any path of the server.
+		httpClients := lo.Filter(lo.Keys(rulesByClient), func(clientFormattedIdentity string, _ int) bool {
+			return rulesByClient[clientFormattedIdentity].To != nil
+		})
+		if len(httpClients) != 0 {
+			c.recorder.RecordWarningEventf(clientIntents, ReasonServerMissingWaypoint, "Server %s is in Istio ambient mode without a waypoint, its HTTP rules cannot be enforced, Istio policy rules ignored", server.name)
+			for _, clientFormattedIdentity := range httpClients {
+				delete(rulesByClient, clientFormattedIdentity)
+			}
+		}
+	}
+
+	newPolicy, err := c.generateServerPolicy(server, rulesByClient, ambientServer)
+	if err != nil {
+		return false, err
+	}
+
+	if server.kubernetesService && len(newPolicy.Spec.Rules) != 0 {
+		found, err := c.targetKubernetesService(ctx, newPolicy, server.name, server.namespace)
+		if err != nil {
+			return false, err
+		}
+		if !found {
+			c.recorder.RecordWarningEventf(clientIntents, consts.ReasonKubernetesServiceNotFound, "Kubernetes service %s in namespace %s was not found or does not select pods, Istio policy ignored", server.name, server.namespace)
+			newPolicy.Spec.Rules = nil
+			rulesByClient = make(map[string]*v1beta1security.Rule)
+		}
+	}
+
+	err = c.applyServerPolicy(ctx, newPolicy)
+	if err != nil {
+		if !k8serrors.IsConflict(err) {
+			c.recorder.RecordWarningEventf(clientIntents, ReasonUpdatingIstioPolicyFailed, "Failed to update Istio policy: %s", err.Error())
+		}
+		return false, err
+	}
+
+	return len(rulesByClient) != 0, nil
+}
+
+// shouldCreateServerPolicy applies the same enforcement, enablement and namespace checks as policies created for each
+// client and server. Errors are returned rather than treated as disabled enforcement, which would delete the policy.
+func (c *PolicyManagerImpl) shouldCreateServerPolicy(ctx context.Context, clientIntents *v1alpha3.ClientIntents, server consolidatedServer) (bool, error) {
+	shouldCreatePolicy, err := protected_services.IsServerEnforcementEnabledDueToProtectionOrDefaultState(
+		ctx, c.client, server.name, server.namespace, c.enforcementDefaultState)
+	if err != nil {
+		return false, err
+	}
+
+	if !shouldCreatePolicy {
+		logrus.Infof("Enforcement is disabled globally and server is not explicitly protected, skipping network policy creation for server %s in namespace %s", server.name, server.namespace)
+		c.recorder.RecordNormalEventf(clientIntents, consts.ReasonEnforcementDefaultOff, "Enforcement is disabled globally and called service '%s' is not explicitly protected using a ProtectedService resource, network policy creation skipped", server.name)
+		return false, nil
+	}
+
+	if !c.enableIstioPolicyCreation {
+		c.recorder.RecordNormalEvent(clientIntents, consts.ReasonIstioPolicyCreationDisabled, "Istio policy creation is disabled, creation skipped")
+		return false, nil
+	}
+
+	if len(c.restrictToNamespaces) != 0 && !lo.Contains(c.restrictToNamespaces, server.namespace) {
+		c.recorder.RecordWarningEventf(
+			clientIntents,
+			ReasonNamespaceNotAllowed,
+			"Namespace %s was specified in intent, but is not allowed by configuration, Istio policy ignored",
+			server.namespace,
+		)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// intentsCallingServer returns the intents of the client that Istio policies are created for, and that target the
+// server.
+func intentsCallingServer(clientIntents *v1alpha3.ClientIntents, server consolidatedServer) []v1alpha3.Intent {
+	return lo.Filter(clientIntents.GetCallsList(), func(intent v1alpha3.Intent, _ int) bool {
+		if intent.Type != "" && intent.Type != v1alpha3.IntentTypeHTTP || intent.IsTargetServerKubernetesService() != server.kubernetesService {
+			return false
+		}
+		serverNamespace := intent.GetTargetServerNamespace(clientIntents.Namespace)
+		return v1alpha2.GetFormattedOtterizeIdentity(intent.GetTargetServerName(), serverNamespace) == server.formattedName
+	})
+}
+
+// serverRuleTo merges the operations of the client's HTTP intents to the server. Intents without a type allow any
+// operation.
+func (c *PolicyManagerImpl) serverRuleTo(intents []v1alpha3.Intent) []*v1beta1security.Rule_To {
+	ruleTo := make([]*v1beta1security.Rule_To, 0)
+	for _, intent := range intents {
+		if intent.Type != v1alpha3.IntentTypeHTTP {
+			return nil
+		}
+		for _, operation := range c.intentsHTTPResourceToIstioOperations(intent.HTTPResources) {
+			ruleTo = append(ruleTo, &v1beta1security.Rule_To{Operation: operation})
+		}
+	}
+
+	return ruleTo
+}
+
+func (c *PolicyManagerImpl) generateServerPolicy(
+	server consolidatedServer,
+	rulesByClient map[string]*v1beta1security.Rule,
+	ambientServer *v1alpha3.AmbientServer,
+) (*v1beta1.AuthorizationPolicy, error) {
+	clients := lo.Keys(rulesByClient)
+	sort.Strings(clients)
+	rules := lo.Map(clients, func(clientFormattedIdentity string, _ int) *v1beta1security.Rule {
+		return rulesByClient[clientFormattedIdentity]
+	})
+
+	nameTemplate := lo.Ternary(server.kubernetesService, OtterizeIstioConsolidatedSvcPolicyNameTemplate, OtterizeIstioConsolidatedPolicyNameTemplate)
+	newPolicy := &v1beta1.AuthorizationPolicy{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      fmt.Sprintf(nameTemplate, server.formattedName),
+			Namespace: server.namespace,
+			Labels: map[string]string{
+				v1alpha2.OtterizeServerLabelKey:         server.formattedName,
+				OtterizeIstioConsolidatedPolicyLabelKey: "true",
+			},
+		},
+		Spec: v1beta1security.AuthorizationPolicy{
+			Selector: &v1beta1type.WorkloadSelector{
+				MatchLabels: map[string]string{
+					v1alpha2.OtterizeServerLabelKey: server.formattedName,
+				},
+			},
+			Action: v1beta1security.AuthorizationPolicy_ALLOW,
+			Rules:  rules,
+		},
+	}
+
+	if ambientServer != nil && ambientServer.Waypoint != "" {
+		err := targetWaypoint(newPolicy, *ambientServer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	clientsValue, err := json.Marshal(clients)
+	if err != nil {
+		return nil, err
+	}
+	if newPolicy.Annotations == nil {
+		newPolicy.Annotations = make(map[string]string)
+	}
+	newPolicy.Annotations[OtterizeIstioPolicyClientsAnnotation] = string(clientsValue)
+
+	return newPolicy, nil
+}
+
+// applyServerPolicy creates, updates or deletes the server's consolidated policy. Updates and deletions are
+// conditioned on the version of the policy that was read.
+func (c *PolicyManagerImpl) applyServerPolicy(ctx context.Context, newPolicy *v1beta1.AuthorizationPolicy) error {
+	existingPolicy := &v1beta1.AuthorizationPolicy{}
+	err := c.client.Get(ctx, types.NamespacedName{Namespace: newPolicy.Namespace, Name: newPolicy.Name}, existingPolicy)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	found := err == nil
+
+	if len(newPolicy.Spec.Rules) == 0 {
+		if !found {
+			return nil
+		}
+		return client.IgnoreNotFound(c.client.Delete(ctx, existingPolicy, client.Preconditions{ResourceVersion: &existingPolicy.ResourceVersion}))
+	}
+
+	if !found {
+		return c.createPolicy(ctx, newPolicy)
+	}
+
+	if v1alpha3.IsManuallyOverridden(existingPolicy) {
+		logrus.Debugf("Istio policy %s/%s is manually overridden, skipping update", existingPolicy.Namespace, existingPolicy.Name)
+		return nil
+	}
+
+	if c.isPolicyEqual(existingPolicy, newPolicy) &&
+		existingPolicy.Annotations[OtterizeIstioPolicyClientsAnnotation] == newPolicy.Annotations[OtterizeIstioPolicyClientsAnnotation] {
+		return nil
+	}
+
+	_, isWaypointPolicy := existingPolicy.Annotations[OtterizeIstioTargetRefsAnnotation]
+	_, shouldTargetWaypoint := newPolicy.Annotations[OtterizeIstioTargetRefsAnnotation]
+	if isWaypointPolicy || shouldTargetWaypoint {
+		return c.patchWaypointPolicy(ctx, existingPolicy, newPolicy, true)
+	}
+
+	policyCopy := existingPolicy.DeepCopy()
+	policyCopy.Spec.Rules = newPolicy.Spec.Rules
+	policyCopy.Spec.Selector = newPolicy.Spec.Selector
+	policyCopy.Spec.Action = newPolicy.Spec.Action
+	if policyCopy.Annotations == nil {
+		policyCopy.Annotations = make(map[string]string)
+	}
+	policyCopy.Annotations[OtterizeIstioPolicyClientsAnnotation] = newPolicy.Annotations[OtterizeIstioPolicyClientsAnnotation]
+
+	return c.client.Patch(ctx, policyCopy, client.MergeFromWithOptions(existingPolicy, client.MergeFromWithOptimisticLock{}))
+}
@@ -12,28 +12,33 @@ import (
 	"github.com/otterize/intents-operator/src/shared/injectablerecorder"
 	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
 	v1beta1security "istio.io/api/security/v1beta1"
 	v1beta1type "istio.io/api/type/v1beta1"
 	"istio.io/client-go/pkg/apis/security/v1beta1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"reflect"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"strconv"
 	"strings"
 )
 
 const (
-	ReasonGettingIstioPolicyFailed  = "GettingIstioPolicyFailed"
-	ReasonCreatingIstioPolicyFailed = "CreatingIstioPolicyFailed"
-	ReasonUpdatingIstioPolicyFailed = "UpdatingIstioPolicyFailed"
-	ReasonDeleteIstioPolicyFailed   = "DeleteIstioPolicyFailed"
-	ReasonCreatedIstioPolicy        = "CreatedIstioPolicy"
-	ReasonNamespaceNotAllowed       = "NamespaceNotAllowed"
-	ReasonMissingSidecar            = "MissingSidecar"
-	ReasonServerMissingSidecar      = "ServerMissingSidecar"
-	ReasonSharedServiceAccount      = "SharedServiceAccountFound"
-	OtterizeIstioPolicyNameTemplate = "authorization-policy-to-%s-from-%s"
+	ReasonGettingIstioPolicyFailed      = "GettingIstioPolicyFailed"
+	ReasonCreatingIstioPolicyFailed     = "CreatingIstioPolicyFailed"
+	ReasonUpdatingIstioPolicyFailed     = "UpdatingIstioPolicyFailed"
+	ReasonDeleteIstioPolicyFailed       = "DeleteIstioPolicyFailed"
+	ReasonCreatedIstioPolicy            = "CreatedIstioPolicy"
+	ReasonNamespaceNotAllowed           = "NamespaceNotAllowed"
+	ReasonMissingSidecar                = "MissingSidecar"
+	ReasonServerMissingSidecar          = "ServerMissingSidecar"
+	ReasonServerMissingWaypoint         = "ServerMissingWaypoint"
+	ReasonWaypointTargetRefsUnsupported = "WaypointTargetRefsUnsupported"
+	ReasonSharedServiceAccount          = "SharedServiceAccountFound"
+	OtterizeIstioPolicyNameTemplate     = "authorization-policy-to-%s-from-%s"
 )
 
 type PolicyID types.UID
@@ -47,6 +52,8 @@ type PolicyManagerImpl struct {
 	restrictToNamespaces      []string
 	enforcementDefaultState   bool
 	enableIstioPolicyCreation bool
+	trustDomain               TrustDomain
+	consolidatePolicies       bool
 }
 
 type PolicyManager interface {
@@ -54,15 +61,20 @@ type PolicyManager interface {
 	Create(ctx context.Context, clientIntents *v1alpha3.ClientIntents, clientServiceAccount string) error
 	UpdateIntentsStatus(ctx context.Context, clientIntents *v1alpha3.ClientIntents, clientServiceAccount string, missingSideCar bool) error
 	UpdateServerSidecar(ctx context.Context, clientIntents *v1alpha3.ClientIntents, serverName string, missingSideCar bool) error
+	UpdateServerAmbientStatus(ctx context.Context, clientIntents *v1alpha3.ClientIntents, serverName string, ambientServer *v1alpha3.AmbientServer) error
 }
 
-func NewPolicyManager(client client.Client, recorder *injectablerecorder.InjectableRecorder, restrictedNamespaces []string, enforcementDefaultState bool, istioEnforcementEnabled bool) *PolicyManagerImpl {
+// NewPolicyManager creates a PolicyManagerImpl. When consolidatePolicies is set, each server gets a single policy with a
+// rule for each of its clients, rather than a policy for each client and server.
+func NewPolicyManager(client client.Client, recorder *injectablerecorder.InjectableRecorder, restrictedNamespaces []string, enforcementDefaultState bool, istioEnforcementEnabled bool, trustDomain TrustDomain, consolidatePolicies bool) *PolicyManagerImpl {
 	return &PolicyManagerImpl{
 		client:                    client,
 		recorder:                  recorder,
 		restrictToNamespaces:      restrictedNamespaces,
 		enforcementDefaultState:   enforcementDefaultState,
 		enableIstioPolicyCreation: istioEnforcementEnabled,
+		trustDomain:               trustDomain,
+		consolidatePolicies:       consolidatePolicies,
 	}
 }
 
@@ -86,6 +98,10 @@ func (c *PolicyManagerImpl) DeleteAll(
 			return err
 		}
 	}
+
+	if c.consolidatePolicies {
+		return c.removeFromServerPolicies(ctx, clientIntents)
+	}
 	return nil
 }
 
@@ -94,6 +110,10 @@ func (c *PolicyManagerImpl) Create(
 	clientIntents *v1alpha3.ClientIntents,
 	clientServiceAccount string,
 ) error {
+	if c.consolidatePolicies {
+		return c.createServerPolicies(ctx, clientIntents, clientServiceAccount)
+	}
+
 	clientFormattedIdentity := v1alpha2.GetFormattedOtterizeIdentity(clientIntents.Spec.Service.Name, clientIntents.Namespace)
 
 	var existingPolicies v1beta1.AuthorizationPolicyList
@@ -178,6 +198,50 @@ func (c *PolicyManagerImpl) UpdateServerSidecar(
 	return nil
 }
 
+// UpdateServerAmbientStatus records whether the server is in ambient mode, and the waypoint enforcing L7 policies for
+// it. A nil ambientServer means the server is not in ambient mode.
+func (c *PolicyManagerImpl) UpdateServerAmbientStatus(
+	ctx context.Context,
+	clientIntents *v1alpha3.ClientIntents,
+	serverName string,
+	ambientServer *v1alpha3.AmbientServer,
+) error {
+	ambientServers, err := clientIntents.GetAmbientServers()
+	if err != nil {
+		return err
+	}
+
+	existingServer, found := ambientServers[serverName]
+	if ambientServer == nil && !found || ambientServer != nil && found && reflect.DeepEqual(existingServer, *ambientServer) {
+		return nil
+	}
+
+	if ambientServer == nil {
+		delete(ambientServers, serverName)
+	} else {
+		ambientServers[serverName] = *ambientServer
+	}
+
+	ambientServersValue, err := json.Marshal(ambientServers)
+	if err != nil {
+		return err
+	}
+
+	updatedIntents := clientIntents.DeepCopy()
+	if updatedIntents.Annotations == nil {
+		updatedIntents.Annotations = make(map[string]string)
+	}
+	updatedIntents.Annotations[v1alpha3.OtterizeAmbientServersAnnotation] = string(ambientServersValue)
+	err = c.client.Patch(ctx, updatedIntents, client.MergeFrom(clientIntents))
+	if err != nil {
+		return err
+	}
+
+	// Policies created right after are targeted according to the updated status.
+	updatedIntents.DeepCopyInto(clientIntents)
+	return nil
+}
+
 func (c *PolicyManagerImpl) setServersWithoutSidecar(ctx context.Context, clientIntents *v1alpha3.ClientIntents, set sets.Set[string]) error {
 	serversSortedList := sets.List(set)
 	serversValues, err := json.Marshal(serversSortedList)
@@ -316,6 +380,11 @@ func (c *PolicyManagerImpl) createOrUpdatePolicies(
 ) (*goset.Set[PolicyID], error) {
 	updatedPolicies := goset.NewSet[PolicyID]()
 	createdAnyPolicies := false
+	ambientServers, err := clientIntents.GetAmbientServers()
+	if err != nil {
+		return nil, err
+	}
+
 	for _, intent := range clientIntents.GetCallsList() {
 		if intent.Type != "" && intent.Type != v1alpha3.IntentTypeHTTP {
 			continue
@@ -349,6 +418,36 @@ func (c *PolicyManagerImpl) createOrUpdatePolicies(
 		}
 
 		newPolicy := c.generateAuthorizationPolicy(clientIntents, intent, clientServiceAccount)
+		ambientServer, isAmbient := ambientServers[newPolicy.Labels[v1alpha2.OtterizeServerLabelKey]]
+		if isAmbient && ambientServer.Waypoint != "" {
+			supported, err := c.canTargetWaypoint(ctx, clientIntents, intent.GetTargetServerName())
+			if err != nil {
+				return nil, err
+			}
+			if !supported {
+				continue
+			}
+			err = targetWaypoint(newPolicy, ambientServer)
+			if err != nil {
+				return nil, err
+			}
+		} else if isAmbient && intent.Type == v1alpha3.IntentTypeHTTP {
+			// Ztunnel cannot enforce L7 rules, and dropping them would allow the client to call any path of the server.
+			c.recorder.RecordWarningEventf(clientIntents, ReasonServerMissingWaypoint, "Server %s is in Istio ambient mode without a waypoint, its HTTP rules cannot be enforced, Istio policy ignored", intent.GetTargetServerName())
+			continue
+		}
+
+		if intent.IsTargetServerKubernetesService() {
+			found, err := c.targetKubernetesService(ctx, newPolicy, intent.GetTargetServerName(), targetNamespace)
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				c.recorder.RecordWarningEventf(clientIntents, consts.ReasonKubernetesServiceNotFound, "Kubernetes service %s in namespace %s was not found or does not select pods, Istio policy ignored", intent.GetTargetServerName(), targetNamespace)
+				continue
+			}
+		}
+
 		existingPolicy, found := c.findPolicy(existingPolicies, newPolicy)
 		if found {
 			err := c.updatePolicy(ctx, existingPolicy, newPolicy)
@@ -360,7 +459,7 @@ func (c *PolicyManagerImpl) createOrUpdatePolicies(
 			continue
 		}
 
-		err = c.client.Create(ctx, newPolicy)
+		err = c.createPolicy(ctx, newPolicy)
 		if err != nil {
 			c.recorder.RecordWarningEventf(clientIntents, ReasonCreatingIstioPolicyFailed, "Failed to create Istio policy: %s", err.Error())
 			return nil, err
@@ -377,7 +476,8 @@ func (c *PolicyManagerImpl) createOrUpdatePolicies(
 
 func (c *PolicyManagerImpl) findPolicy(existingPolicies v1beta1.AuthorizationPolicyList, newPolicy *v1beta1.AuthorizationPolicy) (*v1beta1.AuthorizationPolicy, bool) {
 	for _, policy := range existingPolicies.Items {
-		if policy.Labels[v1alpha2.OtterizeServerLabelKey] == newPolicy.Labels[v1alpha2.OtterizeServerLabelKey] {
+		if policy.Labels[v1alpha2.OtterizeServerLabelKey] == newPolicy.Labels[v1alpha2.OtterizeServerLabelKey] &&
+			policy.Labels[OtterizeIstioKubernetesServiceLabelKey] == newPolicy.Labels[OtterizeIstioKubernetesServiceLabelKey] {
 			return policy, true
 		}
 	}
@@ -397,13 +497,25 @@ func (c *PolicyManagerImpl) deleteOutdatedPolicies(ctx context.Context, existing
 }
 
 func (c *PolicyManagerImpl) updatePolicy(ctx context.Context, existingPolicy *v1beta1.AuthorizationPolicy, newPolicy *v1beta1.AuthorizationPolicy) error {
+	if v1alpha3.IsManuallyOverridden(existingPolicy) {
+		logrus.Debugf("Istio policy %s/%s is manually overridden, skipping update", existingPolicy.Namespace, existingPolicy.Name)
+		return nil
+	}
+
 	if c.isPolicyEqual(existingPolicy, newPolicy) {
 		return nil
 	}
 
+	_, isWaypointPolicy := existingPolicy.Annotations[OtterizeIstioTargetRefsAnnotation]
+	_, shouldTargetWaypoint := newPolicy.Annotations[OtterizeIstioTargetRefsAnnotation]
+	if isWaypointPolicy || shouldTargetWaypoint {
+		return c.patchWaypointPolicy(ctx, existingPolicy, newPolicy, false)
+	}
+
 	policyCopy := existingPolicy.DeepCopy()
 	policyCopy.Spec.Rules = newPolicy.Spec.Rules
 	policyCopy.Spec.Selector = newPolicy.Spec.Selector
+	policyCopy.Spec.Action = newPolicy.Spec.Action
 
 	err := c.client.Patch(ctx, policyCopy, client.MergeFrom(existingPolicy))
 	if err != nil {
@@ -414,44 +526,68 @@ func (c *PolicyManagerImpl) updatePolicy(ctx context.Context, existingPolicy *v1
 	return nil
 }
 
-func (c *PolicyManagerImpl) getPolicyName(intents *v1alpha3.ClientIntents, intent v1alpha3.Intent) string {
-	clientName := fmt.Sprintf("%s.%s", intents.GetServiceName(), intents.Namespace)
-	policyName := fmt.Sprintf(OtterizeIstioPolicyNameTemplate, intent.GetTargetServerName(), clientName)
-	return policyName
-}
-
-func (c *PolicyManagerImpl) isPolicyEqual(existingPolicy *v1beta1.AuthorizationPolicy, newPolicy *v1beta1.AuthorizationPolicy) bool {
-	sameServer := existingPolicy.Spec.Selector.MatchLabels[v1alpha2.OtterizeServerLabelKey] == newPolicy.Spec.Selector.MatchLabels[v1alpha2.OtterizeServerLabelKey]
-	samePrincipals := existingPolicy.Spec.Rules[0].From[0].Source.Principals[0] == newPolicy.Spec.Rules[0].From[0].Source.Principals[0]
-	sameHTTPRules := compareHTTPRules(existingPolicy.Spec.Rules[0].To, newPolicy.Spec.Rules[0].To)
+func (c *PolicyManagerImpl) createPolicy(ctx context.Context, policy *v1beta1.AuthorizationPolicy) error {
+	if _, ok := policy.Annotations[OtterizeIstioTargetRefsAnnotation]; !ok {
+		return c.client.Create(ctx, policy)
+	}
 
-	return sameServer && samePrincipals && sameHTTPRules
+	object, err := toUnstructuredPolicy(policy)
+	if err != nil {
+		return err
+	}
+	unstructured.RemoveNestedField(object.Object, "spec", "selector")
+	return c.client.Create(ctx, object)
 }
 
-func compareHTTPRules(existingRules []*v1beta1security.Rule_To, newRules []*v1beta1security.Rule_To) bool {
-	if len(existingRules) == 0 && len(newRules) == 0 {
-		return true
+// patchWaypointPolicy updates policies that target, or used to target, a waypoint. Since the Istio API version the
+// operator is built with has no targetRefs, the update is applied as a merge patch of the unstructured policy. With
+// optimisticLock, the patch fails with a conflict if the policy changed since it was read.
+func (c *PolicyManagerImpl) patchWaypointPolicy(ctx context.Context, existingPolicy *v1beta1.AuthorizationPolicy, newPolicy *v1beta1.AuthorizationPolicy, optimisticLock bool) error {
+	policyCopy := existingPolicy.DeepCopy()
+	policyCopy.Spec.Rules = newPolicy.Spec.Rules
+	policyCopy.Spec.Selector = newPolicy.Spec.Selector
+	policyCopy.Spec.Action = newPolicy.Spec.Action
+	policyCopy.Annotations = newPolicy.Annotations
+
+	object, err := toUnstructuredPolicy(policyCopy)
+	if err != nil {
+		return err
+	}
+	if _, ok := newPolicy.Annotations[OtterizeIstioTargetRefsAnnotation]; !ok {
+		err = unstructured.SetNestedField(object.Object, nil, "metadata", "annotations", OtterizeIstioTargetRefsAnnotation)
+		if err != nil {
+			return err
+		}
+	}
+	if optimisticLock {
+		object.SetResourceVersion(existingPolicy.ResourceVersion)
 	}
 
-	if len(existingRules) != len(newRules) {
-		return false
+	err = c.client.Patch(ctx, object, client.Merge)
+	if err != nil {
+		c.recorder.RecordWarningEventf(existingPolicy, ReasonUpdatingIstioPolicyFailed, "Failed to update Istio policy: %s", err.Error())
+		return err
 	}
 
-	for i := range existingRules {
-		existingOperation := existingRules[i].Operation
-		newOperation := newRules[i].Operation
-		if existingOperation.Paths[0] != newOperation.Paths[0] {
-			return false
-		}
+	return nil
+}
 
-		for j, method := range existingOperation.Methods {
-			if method != newOperation.Methods[j] {
-				return false
-			}
-		}
+func (c *PolicyManagerImpl) getPolicyName(intents *v1alpha3.ClientIntents, intent v1alpha3.Intent) string {
+	clientName := fmt.Sprintf("%s.%s", intents.GetServiceName(), intents.Namespace)
+	nameTemplate := lo.Ternary(intent.IsTargetServerKubernetesService(), OtterizeIstioSvcPolicyNameTemplate, OtterizeIstioPolicyNameTemplate)
+	policyName := fmt.Sprintf(nameTemplate, intent.GetTargetServerName(), clientName)
+	return policyName
+}
+
+// isPolicyEqual compares the complete specs of the policies, so that any change made to a policy outside the operator
+// is reverted. The targetRefs of waypoint policies are compared through their annotation.
+func (c *PolicyManagerImpl) isPolicyEqual(existingPolicy *v1beta1.AuthorizationPolicy, newPolicy *v1beta1.AuthorizationPolicy) bool {
+	if existingPolicy.Annotations[OtterizeIstioTargetRefsAnnotation] != newPolicy.Annotations[OtterizeIstioTargetRefsAnnotation] {
+		return false
 	}
 
-	return true
+	// The specs are compared through copies, as comparing protobuf messages caches reflection state in them.
+	return proto.Equal(existingPolicy.Spec.DeepCopy(), newPolicy.Spec.DeepCopy())
 }
 
 func (c *PolicyManagerImpl) generateAuthorizationPolicy(
@@ -477,7 +613,6 @@ func (c *PolicyManagerImpl) generateAuthorizationPolicy(
 		}
 	}
 
-	source := fmt.Sprintf("cluster.local/ns/%s/sa/%s", clientIntents.Namespace, clientServiceAccountName)
 	newPolicy := &v1beta1.AuthorizationPolicy{
 		ObjectMeta: v1.ObjectMeta{
 			Name:      policyName,
@@ -500,9 +635,7 @@ func (c *PolicyManagerImpl) generateAuthorizationPolicy(
 					From: []*v1beta1security.Rule_From{
 						{
 							Source: &v1beta1security.Source{
-								Principals: []string{
-									source,
-								},
+								Principals: c.clientPrincipals(clientIntents, clientServiceAccountName),
 							},
 						},
 					},
@@ -514,6 +647,31 @@ func (c *PolicyManagerImpl) generateAuthorizationPolicy(
 	return newPolicy
 }
 
+// clientPrincipals returns the principals of the client's pods in this cluster, under the trust domain and each of its
+// aliases, followed by those of its allowed remote instances.
+func (c *PolicyManagerImpl) clientPrincipals(clientIntents *v1alpha3.ClientIntents, clientServiceAccountName string) []string {
+	principals := make([]string, 0)
+	if clientServiceAccountName != "" {
+		principals = append(principals, c.trustDomain.Principals(clientIntents.Namespace, clientServiceAccountName)...)
+	}
+
+	for _, remoteClient := range clientIntents.Spec.RemoteClients {
+		// The webhook rejects remote clients that are not allowed, this keeps them out when it is disabled.
+		if err := c.trustDomain.ValidateRemoteClient(remoteClient, clientIntents.Namespace); err != nil {
+			logrus.WithError(err).Warningf("Ignoring remote client of ClientIntents %s/%s", clientIntents.Namespace, clientIntents.Name)
+			continue
+		}
+		namespace := lo.Ternary(remoteClient.Namespace != "", remoteClient.Namespace, clientIntents.Namespace)
+		serviceAccountName := lo.Ternary(remoteClient.ServiceAccountName != "", remoteClient.ServiceAccountName, clientServiceAccountName)
+		if serviceAccountName == "" {
+			continue
+		}
+		principals = append(principals, formatPrincipal(remoteClient.TrustDomain, namespace, serviceAccountName))
+	}
+
+	return lo.Uniq(principals)
+}
+
 func (c *PolicyManagerImpl) intentsHTTPResourceToIstioOperations(resources []v1alpha3.HTTPResource) []*v1beta1security.Operation {
 	operations := make([]*v1beta1security.Operation, 0, len(resources))
 
@@ -3,6 +3,7 @@ package istiopolicy
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/otterize/intents-operator/src/operator/api/v1alpha2"
 	"github.com/otterize/intents-operator/src/operator/api/v1alpha3"
@@ -15,7 +16,14 @@ import (
 	v1beta12 "istio.io/api/security/v1beta1"
 	v1beta13 "istio.io/api/type/v1beta1"
 	"istio.io/client-go/pkg/apis/security/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"strconv"
 	"testing"
@@ -28,7 +36,7 @@ type PolicyManagerTestSuite struct {
 
 func (s *PolicyManagerTestSuite) SetupTest() {
 	s.MocksSuiteBase.SetupTest()
-	s.admin = NewPolicyManager(s.Client, &injectablerecorder.InjectableRecorder{Recorder: s.Recorder}, []string{}, true, true)
+	s.admin = NewPolicyManager(s.Client, &injectablerecorder.InjectableRecorder{Recorder: s.Recorder}, []string{}, true, true, TrustDomain{}, false)
 }
 
 func (s *PolicyManagerTestSuite) TearDownTest() {
@@ -153,6 +161,7 @@ func (s *PolicyManagerTestSuite) TestCreateEnforcementDisabledNoProtectedService
 		func(ctx context.Context, protectedServices *v1alpha3.ProtectedServiceList, options ...client.ListOption) error {
 			return nil
 		})
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Name: clientIntentsNamespace}, gomock.AssignableToTypeOf(&corev1.Namespace{})).Return(nil)
 
 	err := s.admin.Create(context.Background(), intents, clientServiceAccountName)
 	s.NoError(err)
@@ -1521,6 +1530,546 @@ func (s *PolicyManagerTestSuite) TestUpdateStatusSharedServiceAccount() {
 	s.ExpectEvent(ReasonSharedServiceAccount)
 }
 
+func (s *PolicyManagerTestSuite) TestCreatePrincipalsForTrustDomainAliasesAndRemoteClients() {
+	s.admin.trustDomain = TrustDomain{Name: "prod.example.com", Aliases: []string{"cluster-b.example.com", "cluster-c.example.com"}}
+	clientIntentsNamespace := "test-namespace"
+
+	intents := &v1alpha3.ClientIntents{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "client-intents",
+			Namespace: clientIntentsNamespace,
+		},
+		Spec: &v1alpha3.IntentsSpec{
+			Service: v1alpha3.Service{Name: "test-client"},
+			Calls:   []v1alpha3.Intent{{Name: "test-server"}},
+			RemoteClients: []v1alpha3.RemoteClient{
+				{TrustDomain: "cluster-c.example.com", ServiceAccountName: "remote-sa"},
+				{TrustDomain: "cluster-b.example.com"},
+				// Remote clients outside the trust domain aliases, in the local trust domain, or in other namespaces are ignored
+				{TrustDomain: "partner.example.com", ServiceAccountName: "partner-sa"},
+				{TrustDomain: "prod.example.com", ServiceAccountName: "other-sa"},
+				{TrustDomain: "cluster-b.example.com", Namespace: "other-namespace", ServiceAccountName: "other-sa"},
+			},
+		},
+	}
+
+	s.Client.EXPECT().List(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf(client.MatchingLabels{})).Return(nil)
+	s.Client.EXPECT().Create(gomock.Any(), gomock.AssignableToTypeOf(&v1beta1.AuthorizationPolicy{})).DoAndReturn(
+		func(_ context.Context, policy *v1beta1.AuthorizationPolicy, _ ...client.CreateOption) error {
+			s.Require().Equal([]string{
+				"prod.example.com/ns/test-namespace/sa/test-client-sa",
+				"cluster-b.example.com/ns/test-namespace/sa/test-client-sa",
+				"cluster-c.example.com/ns/test-namespace/sa/test-client-sa",
+				"cluster-c.example.com/ns/test-namespace/sa/remote-sa",
+			}, policy.Spec.Rules[0].From[0].Source.Principals)
+			return nil
+		})
+
+	err := s.admin.Create(context.Background(), intents, "test-client-sa")
+	s.NoError(err)
+	s.ExpectEvent(ReasonCreatedIstioPolicy)
+}
+
+func (s *PolicyManagerTestSuite) TestCreateRemoteClientInOtherNamespaceWhenAllowed() {
+	s.admin.trustDomain = TrustDomain{Name: "prod.example.com", Aliases: []string{"cluster-b.example.com"}, RemoteClientsInOtherNamespaces: true}
+
+	intents := &v1alpha3.ClientIntents{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "client-intents",
+			Namespace: "test-namespace",
+		},
+		Spec: &v1alpha3.IntentsSpec{
+			Service: v1alpha3.Service{Name: "test-client"},
+			Calls:   []v1alpha3.Intent{{Name: "test-server"}},
+			RemoteClients: []v1alpha3.RemoteClient{
+				{TrustDomain: "cluster-b.example.com", Namespace: "partner", ServiceAccountName: "partner-sa"},
+			},
+		},
+	}
+
+	s.Client.EXPECT().List(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf(client.MatchingLabels{})).Return(nil)
+	s.Client.EXPECT().Create(gomock.Any(), gomock.AssignableToTypeOf(&v1beta1.AuthorizationPolicy{})).DoAndReturn(
+		func(_ context.Context, policy *v1beta1.AuthorizationPolicy, _ ...client.CreateOption) error {
+			s.Require().Equal([]string{"cluster-b.example.com/ns/partner/sa/partner-sa"}, policy.Spec.Rules[0].From[0].Source.Principals)
+			return nil
+		})
+
+	err := s.admin.Create(context.Background(), intents, "")
+	s.NoError(err)
+	s.ExpectEvent(ReasonCreatedIstioPolicy)
+}
+
+func (s *PolicyManagerTestSuite) TestCreateRemoteOnlyClient() {
+	s.admin.trustDomain = TrustDomain{Name: DefaultTrustDomain, Aliases: []string{"cluster-b.example.com"}}
+	intents := &v1alpha3.ClientIntents{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "client-intents",
+			Namespace: "test-namespace",
+		},
+		Spec: &v1alpha3.IntentsSpec{
+			Service: v1alpha3.Service{Name: "test-client"},
+			Calls:   []v1alpha3.Intent{{Name: "test-server"}},
+			RemoteClients: []v1alpha3.RemoteClient{
+				{TrustDomain: "cluster-b.example.com", ServiceAccountName: "test-client-sa"},
+			},
+		},
+	}
+
+	s.Client.EXPECT().List(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf(client.MatchingLabels{})).Return(nil)
+	s.Client.EXPECT().Create(gomock.Any(), gomock.AssignableToTypeOf(&v1beta1.AuthorizationPolicy{})).DoAndReturn(
+		func(_ context.Context, policy *v1beta1.AuthorizationPolicy, _ ...client.CreateOption) error {
+			s.Require().Equal([]string{"cluster-b.example.com/ns/test-namespace/sa/test-client-sa"}, policy.Spec.Rules[0].From[0].Source.Principals)
+			return nil
+		})
+
+	err := s.admin.Create(context.Background(), intents, "")
+	s.NoError(err)
+	s.ExpectEvent(ReasonCreatedIstioPolicy)
+}
+
+func (s *PolicyManagerTestSuite) newAmbientServerIntents(intentType v1alpha3.IntentType, ambientServer v1alpha3.AmbientServer) *v1alpha3.ClientIntents {
+	ambientServers, err := json.Marshal(map[string]v1alpha3.AmbientServer{"test-server-test-namespace-8ddecb": ambientServer})
+	s.Require().NoError(err)
+
+	return &v1alpha3.ClientIntents{
+		ObjectMeta: v1.ObjectMeta{
+			Name:        "client-intents",
+			Namespace:   "test-namespace",
+			Annotations: map[string]string{v1alpha3.OtterizeAmbientServersAnnotation: string(ambientServers)},
+		},
+		Spec: &v1alpha3.IntentsSpec{
+			Service: v1alpha3.Service{Name: "test-client"},
+			Calls: []v1alpha3.Intent{
+				{
+					Name:          "test-server",
+					Type:          intentType,
+					HTTPResources: lo.Ternary(intentType == v1alpha3.IntentTypeHTTP, []v1alpha3.HTTPResource{{Path: "/login", Methods: []v1alpha3.HTTPMethod{v1alpha3.HTTPMethodPost}}}, nil),
+				},
+			},
+		},
+	}
+}
+
+func (s *PolicyManagerTestSuite) expectAuthorizationPolicyCRD(specProperties map[string]apiextensionsv1.JSONSchemaProps) {
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Name: IstioCRDName}, gomock.AssignableToTypeOf(&apiextensionsv1.CustomResourceDefinition{})).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, crd *apiextensionsv1.CustomResourceDefinition, opts ...client.GetOption) error {
+			crd.Spec.Versions = []apiextensionsv1.CustomResourceDefinitionVersion{{
+				Name: "v1beta1",
+				Schema: &apiextensionsv1.CustomResourceValidation{OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+					Properties: map[string]apiextensionsv1.JSONSchemaProps{"spec": {Type: "object", Properties: specProperties}},
+				}},
+			}}
+			return nil
+		})
+}
+
+func (s *PolicyManagerTestSuite) TestCreateAmbientServerWithWaypointTargetsServices() {
+	intents := s.newAmbientServerIntents(v1alpha3.IntentTypeHTTP, v1alpha3.AmbientServer{Waypoint: "waypoint", Services: []string{"test-server"}})
+
+	s.Client.EXPECT().List(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf(client.MatchingLabels{})).Return(nil)
+	s.expectAuthorizationPolicyCRD(map[string]apiextensionsv1.JSONSchemaProps{"selector": {Type: "object"}, "targetRefs": {Type: "array"}})
+	s.Client.EXPECT().Create(gomock.Any(), gomock.AssignableToTypeOf(&unstructured.Unstructured{})).DoAndReturn(
+		func(ctx context.Context, policy *unstructured.Unstructured, opts ...client.CreateOption) error {
+			s.Require().Equal("authorization-policy-to-test-server-from-test-client.test-namespace", policy.GetName())
+			s.Require().Equal("AuthorizationPolicy", policy.GetKind())
+			targetRefs, _, err := unstructured.NestedSlice(policy.Object, "spec", "targetRefs")
+			s.Require().NoError(err)
+			s.Require().Equal([]any{map[string]any{"group": "", "kind": "Service", "name": "test-server"}}, targetRefs)
+			_, hasSelector, err := unstructured.NestedFieldNoCopy(policy.Object, "spec", "selector")
+			s.Require().NoError(err)
+			s.Require().False(hasSelector)
+			operations, _, err := unstructured.NestedSlice(policy.Object, "spec", "rules")
+			s.Require().NoError(err)
+			s.Require().Equal([]any{"/login"}, operations[0].(map[string]any)["to"].([]any)[0].(map[string]any)["operation"].(map[string]any)["paths"])
+			return nil
+		})
+
+	err := s.admin.Create(context.Background(), intents, "test-client-sa")
+	s.NoError(err)
+	s.ExpectEvent(ReasonCreatedIstioPolicy)
+}
+
+func (s *PolicyManagerTestSuite) TestCreateAmbientServerWithWaypointUnsupportedByCRD() {
+	intents := s.newAmbientServerIntents(v1alpha3.IntentTypeHTTP, v1alpha3.AmbientServer{Waypoint: "waypoint", Services: []string{"test-server"}})
+
+	s.Client.EXPECT().List(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf(client.MatchingLabels{})).Return(nil)
+	s.expectAuthorizationPolicyCRD(map[string]apiextensionsv1.JSONSchemaProps{"selector": {Type: "object"}})
+
+	err := s.admin.Create(context.Background(), intents, "test-client-sa")
+	s.NoError(err)
+	s.ExpectEvent(ReasonWaypointTargetRefsUnsupported)
+}
+
+func (s *PolicyManagerTestSuite) TestCreateAmbientServerWithoutWaypointRejectsHTTPIntent() {
+	intents := s.newAmbientServerIntents(v1alpha3.IntentTypeHTTP, v1alpha3.AmbientServer{})
+
+	s.Client.EXPECT().List(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf(client.MatchingLabels{})).Return(nil)
+
+	err := s.admin.Create(context.Background(), intents, "test-client-sa")
+	s.NoError(err)
+	s.ExpectEvent(ReasonServerMissingWaypoint)
+}
+
+func (s *PolicyManagerTestSuite) TestUpdateWaypointPolicyToSelectorPolicy() {
+	intents := s.newAmbientServerIntents("", v1alpha3.AmbientServer{})
+	intents.Annotations = nil
+	existingPolicy := &v1beta1.AuthorizationPolicy{
+		ObjectMeta: v1.ObjectMeta{
+			Name:        "authorization-policy-to-test-server-from-test-client.test-namespace",
+			Namespace:   "test-namespace",
+			Annotations: map[string]string{OtterizeIstioTargetRefsAnnotation: `[{"group":"","kind":"Service","name":"test-server"}]`},
+			Labels: map[string]string{
+				v1alpha2.OtterizeServerLabelKey:           "test-server-test-namespace-8ddecb",
+				v1alpha2.OtterizeIstioClientAnnotationKey: "test-client-test-namespace-537e87",
+			},
+		},
+	}
+
+	s.Client.EXPECT().List(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf(client.MatchingLabels{})).DoAndReturn(
+		func(ctx context.Context, policies *v1beta1.AuthorizationPolicyList, opts ...client.ListOption) error {
+			policies.Items = append(policies.Items, existingPolicy)
+			return nil
+		})
+	s.Client.EXPECT().Patch(gomock.Any(), gomock.AssignableToTypeOf(&unstructured.Unstructured{}), client.Merge).DoAndReturn(
+		func(ctx context.Context, policy *unstructured.Unstructured, patch client.Patch, opts ...client.PatchOption) error {
+			spec := policy.Object["spec"].(map[string]any)
+			s.Require().Nil(spec["targetRefs"])
+			s.Require().Equal(map[string]any{"matchLabels": map[string]any{v1alpha2.OtterizeServerLabelKey: "test-server-test-namespace-8ddecb"}}, spec["selector"])
+			annotations := policy.Object["metadata"].(map[string]any)["annotations"].(map[string]any)
+			s.Require().Contains(annotations, OtterizeIstioTargetRefsAnnotation)
+			s.Require().Nil(annotations[OtterizeIstioTargetRefsAnnotation])
+			return nil
+		})
+
+	err := s.admin.Create(context.Background(), intents, "test-client-sa")
+	s.NoError(err)
+	s.ExpectEvent(ReasonCreatedIstioPolicy)
+}
+
+func (s *PolicyManagerTestSuite) TestUpdateServerAmbientStatus() {
+	intents := &v1alpha3.ClientIntents{
+		ObjectMeta: v1.ObjectMeta{Name: "client-intents", Namespace: "test-namespace"},
+		Spec:       &v1alpha3.IntentsSpec{Service: v1alpha3.Service{Name: "test-client"}},
+	}
+	ambientServer := &v1alpha3.AmbientServer{Waypoint: "waypoint", Services: []string{"test-server"}}
+
+	s.Client.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, updatedIntents *v1alpha3.ClientIntents, patch client.Patch, opts ...client.PatchOption) error {
+			s.Require().Equal(`{"test-server-test-namespace-8ddecb":{"waypoint":"waypoint","services":["test-server"]}}`,
+				updatedIntents.Annotations[v1alpha3.OtterizeAmbientServersAnnotation])
+			return nil
+		})
+	err := s.admin.UpdateServerAmbientStatus(context.Background(), intents, "test-server-test-namespace-8ddecb", ambientServer)
+	s.Require().NoError(err)
+
+	// The status is updated in place, so no update is needed when it did not change.
+	err = s.admin.UpdateServerAmbientStatus(context.Background(), intents, "test-server-test-namespace-8ddecb", ambientServer)
+	s.Require().NoError(err)
+
+	s.Client.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, updatedIntents *v1alpha3.ClientIntents, patch client.Patch, opts ...client.PatchOption) error {
+			s.Require().Equal(`{}`, updatedIntents.Annotations[v1alpha3.OtterizeAmbientServersAnnotation])
+			return nil
+		})
+	err = s.admin.UpdateServerAmbientStatus(context.Background(), intents, "test-server-test-namespace-8ddecb", nil)
+	s.Require().NoError(err)
+}
+
+func (s *PolicyManagerTestSuite) newConsolidatedCaller(name string, serviceAccount string, intents ...v1alpha3.Intent) v1alpha3.ClientIntents {
+	return v1alpha3.ClientIntents{
+		ObjectMeta: v1.ObjectMeta{
+			Name:        name + "-intents",
+			Namespace:   "test-namespace",
+			Annotations: map[string]string{v1alpha2.OtterizeClientServiceAccountAnnotation: serviceAccount},
+		},
+		Spec: &v1alpha3.IntentsSpec{Service: v1alpha3.Service{Name: name}, Calls: intents},
+	}
+}
+
+func (s *PolicyManagerTestSuite) expectConsolidatedPolicies(policies ...*v1beta1.AuthorizationPolicy) {
+	s.Client.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&v1beta1.AuthorizationPolicyList{}), client.MatchingLabels{OtterizeIstioConsolidatedPolicyLabelKey: "true"}).DoAndReturn(
+		func(ctx context.Context, list *v1beta1.AuthorizationPolicyList, opts ...client.ListOption) error {
+			list.Items = append(list.Items, policies...)
+			return nil
+		})
+}
+
+func (s *PolicyManagerTestSuite) expectCallers(formattedServer string, callers ...v1alpha3.ClientIntents) {
+	s.Client.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&v1alpha3.ClientIntentsList{}), &client.MatchingFields{v1alpha3.OtterizeFormattedTargetServerIndexField: formattedServer}).DoAndReturn(
+		func(ctx context.Context, list *v1alpha3.ClientIntentsList, opts ...client.ListOption) error {
+			list.Items = append(list.Items, callers...)
+			return nil
+		})
+}
+
+func (s *PolicyManagerTestSuite) expectClientPolicies(formattedClient string) {
+	s.Client.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&v1beta1.AuthorizationPolicyList{}), client.MatchingLabels{v1alpha2.OtterizeIstioClientAnnotationKey: formattedClient}).Return(nil)
+}
+
+func newConsolidatedPolicy(clients string, rules ...*v1beta12.Rule) *v1beta1.AuthorizationPolicy {
+	return &v1beta1.AuthorizationPolicy{
+		ObjectMeta: v1.ObjectMeta{
+			Name:            "authorization-policy-to-test-server-test-namespace-8ddecb",
+			Namespace:       "test-namespace",
+			ResourceVersion: "1",
+			Labels: map[string]string{
+				v1alpha2.OtterizeServerLabelKey:         "test-server-test-namespace-8ddecb",
+				OtterizeIstioConsolidatedPolicyLabelKey: "true",
+			},
+			Annotations: map[string]string{OtterizeIstioPolicyClientsAnnotation: clients},
+		},
+		Spec: v1beta12.AuthorizationPolicy{
+			Selector: &v1beta13.WorkloadSelector{
+				MatchLabels: map[string]string{v1alpha2.OtterizeServerLabelKey: "test-server-test-namespace-8ddecb"},
+			},
+			Rules: rules,
+		},
+	}
+}
+
+func newPrincipalRule(principal string) *v1beta12.Rule {
+	return &v1beta12.Rule{From: []*v1beta12.Rule_From{{Source: &v1beta12.Source{Principals: []string{principal}}}}}
+}
+
+func (s *PolicyManagerTestSuite) TestCreateConsolidatedPolicyWithRuleForEachClient() {
+	s.admin.consolidatePolicies = true
+	formattedServer := "test-server-test-namespace-8ddecb"
+	intents := s.newConsolidatedCaller("test-client", "test-client-sa", v1alpha3.Intent{
+		Name:          "test-server",
+		Type:          v1alpha3.IntentTypeHTTP,
+		HTTPResources: []v1alpha3.HTTPResource{{Path: "/login", Methods: []v1alpha3.HTTPMethod{v1alpha3.HTTPMethodPost}}},
+	})
+	// The cached copy of the client's intents is outdated, and is replaced by the reconciled one.
+	cachedIntents := s.newConsolidatedCaller("test-client", "test-client-sa", v1alpha3.Intent{Name: "test-server"})
+	otherClient := s.newConsolidatedCaller("another-client", "another-client-sa", v1alpha3.Intent{Name: "test-server"})
+
+	s.expectConsolidatedPolicies()
+	s.expectCallers(formattedServer, cachedIntents, otherClient)
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Namespace: "test-namespace", Name: "authorization-policy-to-test-server-test-namespace-8ddecb"}, gomock.AssignableToTypeOf(&v1beta1.AuthorizationPolicy{})).
+		Return(k8serrors.NewNotFound(schema.GroupResource{}, "authorization-policy-to-test-server-test-namespace-8ddecb"))
+	s.Client.EXPECT().Create(gomock.Any(), gomock.AssignableToTypeOf(&v1beta1.AuthorizationPolicy{})).DoAndReturn(
+		func(ctx context.Context, policy *v1beta1.AuthorizationPolicy, opts ...client.CreateOption) error {
+			s.Require().Equal("authorization-policy-to-test-server-test-namespace-8ddecb", policy.Name)
+			s.Require().Equal("true", policy.Labels[OtterizeIstioConsolidatedPolicyLabelKey])
+			s.Require().Equal(`["another-client-test-namespace-6f60cf","test-client-test-namespace-537e87"]`, policy.Annotations[OtterizeIstioPolicyClientsAnnotation])
+			s.Require().Len(policy.Spec.Rules, 2)
+			s.Require().Nil(policy.Spec.Rules[0].To)
+			s.Require().Equal([]string{generatePrincipal("test-namespace", "another-client-sa")}, policy.Spec.Rules[0].From[0].Source.Principals)
+			s.Require().Equal([]string{"/login"}, policy.Spec.Rules[1].To[0].Operation.Paths)
+			s.Require().Equal([]string{generatePrincipal("test-namespace", "test-client-sa")}, policy.Spec.Rules[1].From[0].Source.Principals)
+			return nil
+		})
+	s.expectClientPolicies("test-client-test-namespace-537e87")
+
+	err := s.admin.Create(context.Background(), &intents, "test-client-sa")
+	s.NoError(err)
+	s.ExpectEvent(ReasonCreatedIstioPolicy)
+}
+
+func (s *PolicyManagerTestSuite) TestConsolidatedPolicyForAmbientServerWithoutWaypointOmitsHTTPClients() {
+	s.admin.consolidatePolicies = true
+	formattedServer := "test-server-test-namespace-8ddecb"
+	intents := s.newConsolidatedCaller("test-client", "test-client-sa", v1alpha3.Intent{
+		Name:          "test-server",
+		Type:          v1alpha3.IntentTypeHTTP,
+		HTTPResources: []v1alpha3.HTTPResource{{Path: "/login", Methods: []v1alpha3.HTTPMethod{v1alpha3.HTTPMethodPost}}},
+	})
+	intents.Annotations[v1alpha3.OtterizeAmbientServersAnnotation] = `{"test-server-test-namespace-8ddecb":{}}`
+	otherClient := s.newConsolidatedCaller("another-client", "another-client-sa", v1alpha3.Intent{Name: "test-server"})
+
+	s.expectConsolidatedPolicies()
+	s.expectCallers(formattedServer, otherClient)
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Namespace: "test-namespace", Name: "authorization-policy-to-test-server-test-namespace-8ddecb"}, gomock.AssignableToTypeOf(&v1beta1.AuthorizationPolicy{})).
+		Return(k8serrors.NewNotFound(schema.GroupResource{}, "authorization-policy-to-test-server-test-namespace-8ddecb"))
+	s.Client.EXPECT().Create(gomock.Any(), gomock.AssignableToTypeOf(&v1beta1.AuthorizationPolicy{})).DoAndReturn(
+		func(ctx context.Context, policy *v1beta1.AuthorizationPolicy, opts ...client.CreateOption) error {
+			// The HTTP client's rule is omitted rather than widened to any path.
+			s.Require().Equal(`["another-client-test-namespace-6f60cf"]`, policy.Annotations[OtterizeIstioPolicyClientsAnnotation])
+			s.Require().Len(policy.Spec.Rules, 1)
+			s.Require().Equal([]string{generatePrincipal("test-namespace", "another-client-sa")}, policy.Spec.Rules[0].From[0].Source.Principals)
+			return nil
+		})
+	s.expectClientPolicies("test-client-test-namespace-537e87")
+
+	err := s.admin.Create(context.Background(), &intents, "test-client-sa")
+	s.NoError(err)
+	s.ExpectEvent(ReasonServerMissingWaypoint)
+	s.ExpectEvent(ReasonCreatedIstioPolicy)
+}
+
+func (s *PolicyManagerTestSuite) TestConsolidatedPolicyKeptWhenProtectionCheckFails() {
+	s.admin.consolidatePolicies = true
+	s.admin.enforcementDefaultState = false
+	formattedServer := "test-server-test-namespace-8ddecb"
+	intents := s.newConsolidatedCaller("test-client", "test-client-sa", v1alpha3.Intent{Name: "test-server"})
+	existingPolicy := newConsolidatedPolicy(`["test-client-test-namespace-537e87"]`, newPrincipalRule(generatePrincipal("test-namespace", "test-client-sa")))
+	listErr := errors.New("cache not synced")
+
+	s.expectConsolidatedPolicies(existingPolicy)
+	s.expectCallers(formattedServer)
+	s.Client.EXPECT().List(gomock.Any(), gomock.AssignableToTypeOf(&v1alpha3.ProtectedServiceList{}), gomock.Any(), gomock.Any()).Return(listErr)
+
+	// The policy is neither updated nor deleted, and the reconcile is retried.
+	err := s.admin.Create(context.Background(), &intents, "test-client-sa")
+	s.Require().ErrorIs(err, listErr)
+}
+
+func (s *PolicyManagerTestSuite) TestUpdateConsolidatedPolicyWithOptimisticLock() {
+	s.admin.consolidatePolicies = true
+	formattedServer := "test-server-test-namespace-8ddecb"
+	intents := s.newConsolidatedCaller("test-client", "test-client-sa", v1alpha3.Intent{Name: "test-server"})
+	otherClient := s.newConsolidatedCaller("another-client", "another-client-sa", v1alpha3.Intent{Name: "test-server"})
+	existingPolicy := newConsolidatedPolicy(`["another-client-test-namespace-6f60cf"]`, newPrincipalRule(generatePrincipal("test-namespace", "another-client-sa")))
+
+	s.expectConsolidatedPolicies(existingPolicy)
+	s.expectCallers(formattedServer, otherClient)
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Namespace: "test-namespace", Name: existingPolicy.Name}, gomock.AssignableToTypeOf(&v1beta1.AuthorizationPolicy{})).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, policy *v1beta1.AuthorizationPolicy, opts ...client.GetOption) error {
+			existingPolicy.DeepCopyInto(policy)
+			return nil
+		})
+	s.Client.EXPECT().Patch(gomock.Any(), gomock.AssignableToTypeOf(&v1beta1.AuthorizationPolicy{}), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, policy *v1beta1.AuthorizationPolicy, patch client.Patch, opts ...client.PatchOption) error {
+			s.Require().Len(policy.Spec.Rules, 2)
+			s.Require().Equal(`["another-client-test-namespace-6f60cf","test-client-test-namespace-537e87"]`, policy.Annotations[OtterizeIstioPolicyClientsAnnotation])
+			// Both clients' rules are written in a single patch, conditioned on the version of the policy that was read.
+			data, err := patch.Data(policy)
+			s.Require().NoError(err)
+			s.Require().Contains(string(data), `"resourceVersion":"1"`)
+			return nil
+		})
+	s.expectClientPolicies("test-client-test-namespace-537e87")
+
+	err := s.admin.Create(context.Background(), &intents, "test-client-sa")
+	s.NoError(err)
+	s.ExpectEvent(ReasonCreatedIstioPolicy)
+}
+
+func (s *PolicyManagerTestSuite) TestNothingToUpdateConsolidatedPolicy() {
+	s.admin.consolidatePolicies = true
+	formattedServer := "test-server-test-namespace-8ddecb"
+	intents := s.newConsolidatedCaller("test-client", "test-client-sa", v1alpha3.Intent{Name: "test-server"})
+	existingPolicy := newConsolidatedPolicy(`["test-client-test-namespace-537e87"]`, newPrincipalRule(generatePrincipal("test-namespace", "test-client-sa")))
+
+	s.expectConsolidatedPolicies(existingPolicy)
+	s.expectCallers(formattedServer, intents)
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Namespace: "test-namespace", Name: existingPolicy.Name}, gomock.AssignableToTypeOf(&v1beta1.AuthorizationPolicy{})).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, policy *v1beta1.AuthorizationPolicy, opts ...client.GetOption) error {
+			existingPolicy.DeepCopyInto(policy)
+			return nil
+		})
+	s.expectClientPolicies("test-client-test-namespace-537e87")
+
+	err := s.admin.Create(context.Background(), &intents, "test-client-sa")
+	s.NoError(err)
+	s.ExpectEvent(ReasonCreatedIstioPolicy)
+}
+
+func (s *PolicyManagerTestSuite) TestDeleteAllRemovesClientFromConsolidatedPolicy() {
+	s.admin.consolidatePolicies = true
+	formattedServer := "test-server-test-namespace-8ddecb"
+	intents := s.newConsolidatedCaller("test-client", "test-client-sa", v1alpha3.Intent{Name: "test-server"})
+	intents.DeletionTimestamp = lo.ToPtr(v1.Now())
+	otherClient := s.newConsolidatedCaller("another-client", "another-client-sa", v1alpha3.Intent{Name: "test-server"})
+	existingPolicy := newConsolidatedPolicy(`["another-client-test-namespace-6f60cf","test-client-test-namespace-537e87"]`,
+		newPrincipalRule(generatePrincipal("test-namespace", "another-client-sa")),
+		newPrincipalRule(generatePrincipal("test-namespace", "test-client-sa")))
+
+	s.expectClientPolicies("test-client-test-namespace-537e87")
+	s.expectConsolidatedPolicies(existingPolicy)
+	s.expectCallers(formattedServer, intents, otherClient)
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Namespace: "test-namespace", Name: existingPolicy.Name}, gomock.AssignableToTypeOf(&v1beta1.AuthorizationPolicy{})).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, policy *v1beta1.AuthorizationPolicy, opts ...client.GetOption) error {
+			existingPolicy.DeepCopyInto(policy)
+			return nil
+		})
+	s.Client.EXPECT().Patch(gomock.Any(), gomock.AssignableToTypeOf(&v1beta1.AuthorizationPolicy{}), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, policy *v1beta1.AuthorizationPolicy, patch client.Patch, opts ...client.PatchOption) error {
+			s.Require().Equal(`["another-client-test-namespace-6f60cf"]`, policy.Annotations[OtterizeIstioPolicyClientsAnnotation])
+			s.Require().Len(policy.Spec.Rules, 1)
+			s.Require().Equal([]string{generatePrincipal("test-namespace", "another-client-sa")}, policy.Spec.Rules[0].From[0].Source.Principals)
+			return nil
+		})
+
+	err := s.admin.DeleteAll(context.Background(), &intents)
+	s.NoError(err)
+}
+
+func (s *PolicyManagerTestSuite) TestDeleteAllDeletesConsolidatedPolicyOfLastClient() {
+	s.admin.consolidatePolicies = true
+	formattedServer := "test-server-test-namespace-8ddecb"
+	intents := s.newConsolidatedCaller("test-client", "test-client-sa", v1alpha3.Intent{Name: "test-server"})
+	intents.DeletionTimestamp = lo.ToPtr(v1.Now())
+	existingPolicy := newConsolidatedPolicy(`["test-client-test-namespace-537e87"]`, newPrincipalRule(generatePrincipal("test-namespace", "test-client-sa")))
+
+	s.expectClientPolicies("test-client-test-namespace-537e87")
+	s.expectConsolidatedPolicies(existingPolicy)
+	s.expectCallers(formattedServer, intents)
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Namespace: "test-namespace", Name: existingPolicy.Name}, gomock.AssignableToTypeOf(&v1beta1.AuthorizationPolicy{})).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, policy *v1beta1.AuthorizationPolicy, opts ...client.GetOption) error {
+			existingPolicy.DeepCopyInto(policy)
+			return nil
+		})
+	s.Client.EXPECT().Delete(gomock.Any(), gomock.AssignableToTypeOf(&v1beta1.AuthorizationPolicy{}), client.Preconditions{ResourceVersion: lo.ToPtr("1")}).Return(nil)
+
+	err := s.admin.DeleteAll(context.Background(), &intents)
+	s.NoError(err)
+}
+
+func (s *PolicyManagerTestSuite) TestCreateKubernetesServiceTargetSelectsServicePods() {
+	intents := &v1alpha3.ClientIntents{
+		ObjectMeta: v1.ObjectMeta{Name: "client-intents", Namespace: "test-namespace"},
+		Spec: &v1alpha3.IntentsSpec{
+			Service: v1alpha3.Service{Name: "test-client"},
+			Calls: []v1alpha3.Intent{{
+				Name:          "svc:test-server",
+				Type:          v1alpha3.IntentTypeHTTP,
+				HTTPResources: []v1alpha3.HTTPResource{{Path: "/login", Methods: []v1alpha3.HTTPMethod{v1alpha3.HTTPMethodPost}}},
+			}},
+		},
+	}
+
+	s.Client.EXPECT().List(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf(client.MatchingLabels{})).Return(nil)
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Name: "test-server", Namespace: "test-namespace"}, gomock.AssignableToTypeOf(&corev1.Service{})).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, service *corev1.Service, opts ...client.GetOption) error {
+			service.Spec.Selector = map[string]string{"app": "test-server"}
+			service.Spec.Ports = []corev1.ServicePort{{Port: 80, TargetPort: intstr.FromInt(8080)}, {Port: 9090}}
+			return nil
+		})
+	s.Client.EXPECT().Create(gomock.Any(), gomock.AssignableToTypeOf(&v1beta1.AuthorizationPolicy{})).DoAndReturn(
+		func(ctx context.Context, policy *v1beta1.AuthorizationPolicy, opts ...client.CreateOption) error {
+			s.Require().Equal("authorization-policy-to-svc-test-server-from-test-client.test-namespace", policy.Name)
+			s.Require().Equal("true", policy.Labels[OtterizeIstioKubernetesServiceLabelKey])
+			s.Require().Equal(map[string]string{"app": "test-server"}, policy.Spec.Selector.MatchLabels)
+			s.Require().Len(policy.Spec.Rules[0].To, 1)
+			s.Require().Equal([]string{"/login"}, policy.Spec.Rules[0].To[0].Operation.Paths)
+			s.Require().Equal([]string{"8080", "9090"}, policy.Spec.Rules[0].To[0].Operation.Ports)
+			return nil
+		})
+
+	err := s.admin.Create(context.Background(), intents, "test-client-sa")
+	s.NoError(err)
+	s.ExpectEvent(ReasonCreatedIstioPolicy)
+}
+
+func (s *PolicyManagerTestSuite) TestCreateKubernetesServiceTargetNotFound() {
+	intents := &v1alpha3.ClientIntents{
+		ObjectMeta: v1.ObjectMeta{Name: "client-intents", Namespace: "test-namespace"},
+		Spec: &v1alpha3.IntentsSpec{
+			Service: v1alpha3.Service{Name: "test-client"},
+			Calls:   []v1alpha3.Intent{{Name: "svc:test-server"}},
+		},
+	}
+
+	s.Client.EXPECT().List(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf(client.MatchingLabels{})).Return(nil)
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Name: "test-server", Namespace: "test-namespace"}, gomock.AssignableToTypeOf(&corev1.Service{})).
+		Return(k8serrors.NewNotFound(schema.GroupResource{}, "test-server"))
+
+	err := s.admin.Create(context.Background(), intents, "test-client-sa")
+	s.NoError(err)
+	s.ExpectEvent(consts.ReasonKubernetesServiceNotFound)
+}
+
 func generatePrincipal(clientIntentsNamespace string, clientServiceAccountName string) string {
 	return fmt.Sprintf("cluster.local/ns/%s/sa/%s", clientIntentsNamespace, clientServiceAccountName)
 }
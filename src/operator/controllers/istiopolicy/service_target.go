@@ -0,0 +1,112 @@
+package istiopolicy
+
+import (
+	"context"
+	"github.com/otterize/intents-operator/src/shared/serviceidresolver"
+	"github.com/samber/lo"
+	v1beta1security "istio.io/api/security/v1beta1"
+	v1beta1type "istio.io/api/type/v1beta1"
+	"istio.io/client-go/pkg/apis/security/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+	"strconv"
+)
+
+const (
+	OtterizeIstioSvcPolicyNameTemplate             = "authorization-policy-to-svc-%s-from-%s"
+	OtterizeIstioConsolidatedSvcPolicyNameTemplate = "authorization-policy-to-svc-%s"
+	// OtterizeIstioKubernetesServiceLabelKey marks policies for intents targeting a Kubernetes service (svc:name), which
+	// select the service's pods rather than the server's Otterize identity.
+	OtterizeIstioKubernetesServiceLabelKey = "intents.otterize.com/istio-kubernetes-service"
+)
+
+// ResolveKubernetesServiceToPod returns a pod backing the Kubernetes service, selected by the service's selector.
+func ResolveKubernetesServiceToPod(ctx context.Context, k8sClient client.Client, serviceName string, namespace string) (corev1.Pod, error) {
+	var service corev1.Service
+	err := k8sClient.Get(ctx, types.NamespacedName{Name: serviceName, Namespace: namespace}, &service)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return corev1.Pod{}, serviceidresolver.ErrPodNotFound
+		}
+		return corev1.Pod{}, err
+	}
+
+	if len(service.Spec.Selector) == 0 {
+		return corev1.Pod{}, serviceidresolver.ErrPodNotFound
+	}
+
+	var podsList corev1.PodList
+	err = k8sClient.List(ctx, &podsList, client.MatchingLabels(service.Spec.Selector), client.InNamespace(namespace))
+	if err != nil {
+		return corev1.Pod{}, err
+	}
+
+	for _, pod := range podsList.Items {
+		if pod.DeletionTimestamp == nil {
+			return pod, nil
+		}
+	}
+
+	return corev1.Pod{}, serviceidresolver.ErrPodNotFound
+}
+
+// targetKubernetesService makes the policy select the pods backing the Kubernetes service, and limits its rules to the
+// service's target ports. It returns false if the service does not exist or does not select pods, in which case the
+// policy cannot be enforced.
+func (c *PolicyManagerImpl) targetKubernetesService(ctx context.Context, policy *v1beta1.AuthorizationPolicy, serviceName string, namespace string) (bool, error) {
+	var service corev1.Service
+	err := c.client.Get(ctx, types.NamespacedName{Name: serviceName, Namespace: namespace}, &service)
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if len(service.Spec.Selector) == 0 {
+		return false, nil
+	}
+
+	policy.Labels[OtterizeIstioKubernetesServiceLabelKey] = "true"
+	// Policies enforced by a waypoint target the services it serves rather than selecting pods.
+	if _, isWaypointPolicy := policy.Annotations[OtterizeIstioTargetRefsAnnotation]; !isWaypointPolicy {
+		policy.Spec.Selector = &v1beta1type.WorkloadSelector{MatchLabels: lo.Assign(service.Spec.Selector)}
+	}
+
+	ports := serviceTargetPorts(service)
+	if len(ports) == 0 {
+		return true, nil
+	}
+
+	for _, rule := range policy.Spec.Rules {
+		if rule.To == nil {
+			rule.To = []*v1beta1security.Rule_To{{Operation: &v1beta1security.Operation{}}}
+		}
+		for _, to := range rule.To {
+			to.Operation.Ports = ports
+		}
+	}
+
+	return true, nil
+}
+
+// serviceTargetPorts returns the ports on the pods the service forwards traffic to. Target ports referenced by name may
+// resolve to different numbers on each pod, so no ports are returned for services that have them, and the policy is
+// not limited to ports.
+func serviceTargetPorts(service corev1.Service) []string {
+	ports := make([]string, 0, len(service.Spec.Ports))
+	for _, port := range service.Spec.Ports {
+		if port.TargetPort.StrVal != "" {
+			return nil
+		}
+		targetPort := lo.Ternary(port.TargetPort.IntVal != 0, port.TargetPort.IntVal, port.Port)
+		ports = append(ports, strconv.Itoa(int(targetPort)))
+	}
+
+	ports = lo.Uniq(ports)
+	sort.Strings(ports)
+	return ports
+}
@@ -3,10 +3,13 @@ package istiopolicy
 import (
 	"context"
 	"fmt"
+	"github.com/samber/lo"
+	networkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
 	"istio.io/client-go/pkg/apis/security/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -26,12 +29,24 @@ func IsPodPartOfIstioMesh(pod corev1.Pod) bool {
 }
 
 func IsIstioAuthorizationPoliciesInstalled(ctx context.Context, client client.Client) (bool, error) {
-	groupVersionKinds, _, err := client.Scheme().ObjectKinds(&v1beta1.AuthorizationPolicy{})
+	return isIstioResourceInstalled(ctx, client, &v1beta1.AuthorizationPolicy{}, "authorizationpolicies")
+}
+
+func IsIstioPeerAuthenticationsInstalled(ctx context.Context, client client.Client) (bool, error) {
+	return isIstioResourceInstalled(ctx, client, &v1beta1.PeerAuthentication{}, "peerauthentications")
+}
+
+func IsIstioSidecarsInstalled(ctx context.Context, client client.Client) (bool, error) {
+	return isIstioResourceInstalled(ctx, client, &networkingv1beta1.Sidecar{}, "sidecars")
+}
+
+func isIstioResourceInstalled(ctx context.Context, client client.Client, obj runtime.Object, resource string) (bool, error) {
+	groupVersionKinds, _, err := client.Scheme().ObjectKinds(obj)
 	if err != nil {
 		return false, err
 	}
 
-	istioCRDName := fmt.Sprintf("authorizationpolicies.%s", groupVersionKinds[0].Group)
+	istioCRDName := fmt.Sprintf("%s.%s", resource, groupVersionKinds[0].Group)
 	crd := apiextensionsv1.CustomResourceDefinition{}
 	err = client.Get(ctx, types.NamespacedName{Name: istioCRDName}, &crd)
 	if err != nil && !k8serrors.IsNotFound(err) {
@@ -44,3 +59,31 @@ func IsIstioAuthorizationPoliciesInstalled(ctx context.Context, client client.Cl
 
 	return true, nil
 }
+
+// IsIstioWaypointTargetRefsSupported returns whether the installed AuthorizationPolicy CRD keeps the targetRefs field.
+// If it does not, the API server prunes targetRefs from waypoint policies, which then have neither a selector nor
+// targetRefs and apply to every workload in the namespace.
+func IsIstioWaypointTargetRefsSupported(ctx context.Context, client client.Client) (bool, error) {
+	crd := apiextensionsv1.CustomResourceDefinition{}
+	err := client.Get(ctx, types.NamespacedName{Name: IstioCRDName}, &crd)
+	if err != nil {
+		return false, err
+	}
+
+	version, found := lo.Find(crd.Spec.Versions, func(version apiextensionsv1.CustomResourceDefinitionVersion) bool {
+		return version.Name == v1beta1.SchemeGroupVersion.Version
+	})
+	if !found || version.Schema == nil || version.Schema.OpenAPIV3Schema == nil {
+		return false, nil
+	}
+
+	spec, ok := version.Schema.OpenAPIV3Schema.Properties["spec"]
+	if !ok {
+		return false, nil
+	}
+	if spec.XPreserveUnknownFields != nil && *spec.XPreserveUnknownFields {
+		return true, nil
+	}
+	_, hasTargetRefs := spec.Properties["targetRefs"]
+	return hasTargetRefs, nil
+}
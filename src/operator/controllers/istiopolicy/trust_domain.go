@@ -0,0 +1,108 @@
+package istiopolicy
+
+import (
+	"context"
+	"fmt"
+	"github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+const (
+	DefaultTrustDomain     = "cluster.local"
+	istioMeshConfigMapName = "istio"
+	istioMeshConfigKey     = "mesh"
+)
+
+//+kubebuilder:rbac:groups="",resources=configmaps,resourceNames=istio,verbs=get
+
+// TrustDomain is the trust domain Istio issues workload identities in, along with the aliases identities issued in
+// other trust domains - such as those of federated clusters - are also accepted under.
+type TrustDomain struct {
+	Name    string
+	Aliases []string
+	// RemoteClientsInOtherNamespaces allows the remote clients of ClientIntents to be in namespaces other than that of
+	// the ClientIntents.
+	RemoteClientsInOtherNamespaces bool
+}
+
+type meshConfig struct {
+	TrustDomain        string   `yaml:"trustDomain"`
+	TrustDomainAliases []string `yaml:"trustDomainAliases"`
+}
+
+// DetectTrustDomain reads the trust domain and its aliases from the Istio mesh config. A configured trust domain takes
+// precedence over the detected one, and configured aliases, a comma separated list, are added to the detected ones.
+// When the mesh config is not found or may not be read, the configured or default trust domain is used.
+func DetectTrustDomain(ctx context.Context, k8sClient client.Reader, istioNamespace string, configuredName string, configuredAliases string) (TrustDomain, error) {
+	var configMap corev1.ConfigMap
+	err := k8sClient.Get(ctx, types.NamespacedName{Namespace: istioNamespace, Name: istioMeshConfigMapName}, &configMap)
+	if k8serrors.IsNotFound(err) || k8serrors.IsForbidden(err) {
+		logrus.WithError(err).Infof("Istio mesh config %s/%s not available, trust domain not detected", istioNamespace, istioMeshConfigMapName)
+		return ConfiguredTrustDomain(configuredName, configuredAliases), nil
+	} else if err != nil {
+		return TrustDomain{}, err
+	}
+
+	var mesh meshConfig
+	err = yaml.Unmarshal([]byte(configMap.Data[istioMeshConfigKey]), &mesh)
+	if err != nil {
+		return TrustDomain{}, fmt.Errorf("failed parsing Istio mesh config %s/%s: %w", istioNamespace, istioMeshConfigMapName, err)
+	}
+	return newTrustDomain(lo.Ternary(strings.TrimSpace(configuredName) != "", configuredName, mesh.TrustDomain), mesh.TrustDomainAliases, configuredAliases), nil
+}
+
+// ConfiguredTrustDomain returns the configured trust domain, or the default one if none is configured, and the
+// configured aliases, a comma separated list.
+func ConfiguredTrustDomain(configuredName string, configuredAliases string) TrustDomain {
+	return newTrustDomain(configuredName, nil, configuredAliases)
+}
+
+func newTrustDomain(name string, aliases []string, configuredAliases string) TrustDomain {
+	trustDomain := TrustDomain{Name: strings.TrimSpace(name)}
+	if trustDomain.Name == "" {
+		trustDomain.Name = DefaultTrustDomain
+	}
+
+	for _, alias := range strings.Split(configuredAliases, ",") {
+		aliases = append(aliases, strings.TrimSpace(alias))
+	}
+	trustDomain.Aliases = lo.Uniq(lo.Filter(aliases, func(alias string, _ int) bool {
+		return alias != "" && alias != trustDomain.Name
+	}))
+
+	return trustDomain
+}
+
+// ValidateRemoteClient returns an error if a remote client of ClientIntents in intentsNamespace is not allowed. Remote
+// clients must be in one of the aliases of the trust domain, as clients in this cluster are identified by their pods,
+// and in the namespace of the ClientIntents unless remote clients in other namespaces are allowed. Otherwise, authors
+// of ClientIntents could grant access to identities other than their own.
+func (t TrustDomain) ValidateRemoteClient(remoteClient v1alpha3.RemoteClient, intentsNamespace string) error {
+	if !lo.Contains(t.Aliases, remoteClient.TrustDomain) {
+		return fmt.Errorf("trust domain '%s' is not a configured trust domain alias", remoteClient.TrustDomain)
+	}
+	if remoteClient.Namespace != "" && remoteClient.Namespace != intentsNamespace && !t.RemoteClientsInOtherNamespaces {
+		return fmt.Errorf("namespace '%s' differs from the namespace of the ClientIntents, and remote clients in other namespaces are not allowed", remoteClient.Namespace)
+	}
+	return nil
+}
+
+// Principals returns the principals of a service account under the trust domain and each of its aliases.
+func (t TrustDomain) Principals(namespace string, serviceAccount string) []string {
+	principals := []string{formatPrincipal(lo.Ternary(t.Name != "", t.Name, DefaultTrustDomain), namespace, serviceAccount)}
+	for _, alias := range t.Aliases {
+		principals = append(principals, formatPrincipal(alias, namespace, serviceAccount))
+	}
+	return principals
+}
+
+func formatPrincipal(trustDomain string, namespace string, serviceAccount string) string {
+	return fmt.Sprintf("%s/ns/%s/sa/%s", trustDomain, namespace, serviceAccount)
+}
@@ -0,0 +1,69 @@
+package istiopolicy
+
+import (
+	"context"
+	"errors"
+	"github.com/otterize/intents-operator/src/shared/testbase"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"testing"
+)
+
+const testIstioNamespace = "istio-system"
+
+type TrustDomainTestSuite struct {
+	testbase.MocksSuiteBase
+}
+
+func (s *TrustDomainTestSuite) expectGetMeshConfig(mesh string) {
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Namespace: testIstioNamespace, Name: istioMeshConfigMapName}, gomock.AssignableToTypeOf(&corev1.ConfigMap{})).DoAndReturn(
+		func(_ context.Context, _ types.NamespacedName, configMap *corev1.ConfigMap, _ ...client.GetOption) error {
+			configMap.Data = map[string]string{istioMeshConfigKey: mesh}
+			return nil
+		})
+}
+
+func (s *TrustDomainTestSuite) TestDetectFromMeshConfig() {
+	s.expectGetMeshConfig("trustDomain: prod.example.com\ntrustDomainAliases:\n- old.example.com\n- prod.example.com\n")
+
+	trustDomain, err := DetectTrustDomain(context.Background(), s.Client, testIstioNamespace, "", "")
+	s.Require().NoError(err)
+	s.Require().Equal(TrustDomain{Name: "prod.example.com", Aliases: []string{"old.example.com"}}, trustDomain)
+}
+
+func (s *TrustDomainTestSuite) TestConfiguredTrustDomainTakesPrecedence() {
+	s.expectGetMeshConfig("trustDomain: prod.example.com\ntrustDomainAliases:\n- old.example.com\n")
+
+	trustDomain, err := DetectTrustDomain(context.Background(), s.Client, testIstioNamespace, "cluster-a.example.com", "cluster-b.example.com, old.example.com")
+	s.Require().NoError(err)
+	s.Require().Equal(TrustDomain{Name: "cluster-a.example.com", Aliases: []string{"old.example.com", "cluster-b.example.com"}}, trustDomain)
+}
+
+func (s *TrustDomainTestSuite) TestConfiguredWhenMeshConfigForbidden() {
+	s.Client.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf(&corev1.ConfigMap{})).Return(
+		k8serrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, istioMeshConfigMapName, errors.New("namespaced install")))
+
+	trustDomain, err := DetectTrustDomain(context.Background(), s.Client, testIstioNamespace, "cluster-a.example.com", "cluster-b.example.com")
+	s.Require().NoError(err)
+	s.Require().Equal(TrustDomain{Name: "cluster-a.example.com", Aliases: []string{"cluster-b.example.com"}}, trustDomain)
+}
+
+func (s *TrustDomainTestSuite) TestDefaultWhenMeshConfigNotFound() {
+	s.Client.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.AssignableToTypeOf(&corev1.ConfigMap{})).Return(
+		k8serrors.NewNotFound(schema.GroupResource{}, istioMeshConfigMapName))
+
+	trustDomain, err := DetectTrustDomain(context.Background(), s.Client, testIstioNamespace, "", "")
+	s.Require().NoError(err)
+	s.Require().Equal(DefaultTrustDomain, trustDomain.Name)
+	s.Require().Empty(trustDomain.Aliases)
+	s.Require().Equal([]string{"cluster.local/ns/test-namespace/sa/test-sa"}, trustDomain.Principals("test-namespace", "test-sa"))
+}
+
+func TestTrustDomainTestSuite(t *testing.T) {
+	suite.Run(t, new(TrustDomainTestSuite))
+}
@@ -0,0 +1,273 @@
+package kafka_server_config_reconcilers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/protected_services"
+	"github.com/otterize/intents-operator/src/operator/controllers/kafkaacls"
+	"github.com/otterize/intents-operator/src/shared/injectablerecorder"
+	"github.com/otterize/intents-operator/src/shared/serviceidresolver"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	ReasonKafkaACLDriftDetected = "KafkaACLDriftDetected"
+	ReasonKafkaACLDriftRepaired = "KafkaACLDriftRepaired"
+	ReasonKafkaACLResyncFailed  = "KafkaACLResyncFailed"
+	// maxDriftEventACLs is the number of missing and unexpected ACLs each listed in drift events.
+	maxDriftEventACLs = 5
+)
+
+// KafkaACLResyncer periodically compares the ACLs of each server in the ServersStore with those expected from all
+// ClientIntents and the server's configuration, so that ACLs edited on the broker outside the operator are detected.
+// Drift is repaired, or in report-only mode published as events and in the status of the server's KafkaServerConfig.
+type KafkaACLResyncer struct {
+	client.Client
+	injectablerecorder.InjectableRecorder
+	serversStore            kafkaacls.ServersStore
+	getNewKafkaIntentsAdmin kafkaacls.IntentsAdminFactoryFunction
+	enableKafkaACLCreation  bool
+	enforcementDefaultState bool
+	operatorPodName         string
+	operatorPodNamespace    string
+	serviceResolver         serviceidresolver.ServiceResolver
+	interval                time.Duration
+	reportOnly              bool
+}
+
+func NewKafkaACLResyncer(
+	client client.Client,
+	serversStore kafkaacls.ServersStore,
+	factoryFunc kafkaacls.IntentsAdminFactoryFunction,
+	enableKafkaACLCreation bool,
+	enforcementDefaultState bool,
+	operatorPodName string,
+	operatorPodNamespace string,
+	serviceResolver serviceidresolver.ServiceResolver,
+	interval time.Duration,
+	reportOnly bool,
+) *KafkaACLResyncer {
+	return &KafkaACLResyncer{
+		Client:                  client,
+		serversStore:            serversStore,
+		getNewKafkaIntentsAdmin: factoryFunc,
+		enableKafkaACLCreation:  enableKafkaACLCreation,
+		enforcementDefaultState: enforcementDefaultState,
+		operatorPodName:         operatorPodName,
+		operatorPodNamespace:    operatorPodNamespace,
+		serviceResolver:         serviceResolver,
+		interval:                interval,
+		reportOnly:              reportOnly,
+	}
+}
+
+// Start implements manager.Runnable. The first resync runs after one interval, as ACLs are applied when the manager
+// starts reconciling ClientIntents and KafkaServerConfigs.
+func (r *KafkaACLResyncer) Start(ctx context.Context) error {
+	logrus.Infof("Starting Kafka ACL resync every %s, report only: %t", r.interval, r.reportOnly)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := r.Resync(ctx); err != nil {
+				logrus.WithError(err).Error("Kafka ACL resync failed")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, so that only one operator replica repairs ACLs.
+func (r *KafkaACLResyncer) NeedLeaderElection() bool {
+	return true
+}
+
+// clientIntentsByServer holds the intents of each client to a Kafka server.
+type clientIntentsByServer map[types.NamespacedName]map[types.NamespacedName][]otterizev1alpha3.Intent
+
+// getClientIntentsByServer returns the Kafka intents of all clients other than the operator, whose ACLs are not
+// managed, and the ClientIntents of each client.
+func (r *KafkaACLResyncer) getClientIntentsByServer(ctx context.Context) (clientIntentsByServer, map[types.NamespacedName]otterizev1alpha3.ClientIntents, error) {
+	var intentsList otterizev1alpha3.ClientIntentsList
+	if err := r.List(ctx, &intentsList); err != nil {
+		return nil, nil, err
+	}
+
+	operatorServiceName := ""
+	intentsByServer := make(clientIntentsByServer)
+	intentsByClient := make(map[types.NamespacedName]otterizev1alpha3.ClientIntents)
+	for _, clientIntents := range intentsList.Items {
+		if clientIntents.Spec == nil || !clientIntents.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		if clientIntents.Namespace == r.operatorPodNamespace {
+			if operatorServiceName == "" {
+				name, ok, err := r.serviceResolver.GetPodAnnotatedName(ctx, r.operatorPodName, r.operatorPodNamespace)
+				if err != nil {
+					return nil, nil, fmt.Errorf("failed resolving intents operator identity - %w", err)
+				}
+				if !ok {
+					return nil, nil, errors.New("failed resolving intents operator identity - service name annotation required")
+				}
+				operatorServiceName = name
+			}
+			if clientIntents.GetServiceName() == operatorServiceName {
+				continue
+			}
+		}
+
+		clientName := types.NamespacedName{Name: clientIntents.GetServiceName(), Namespace: clientIntents.Namespace}
+		intentsByClient[clientName] = clientIntents
+		for _, intent := range clientIntents.GetCallsList() {
+			if intent.Type != otterizev1alpha3.IntentTypeKafka {
+				continue
+			}
+			serverName := types.NamespacedName{Name: intent.GetTargetServerName(), Namespace: intent.GetTargetServerNamespace(clientIntents.Namespace)}
+			if intentsByServer[serverName] == nil {
+				intentsByServer[serverName] = make(map[types.NamespacedName][]otterizev1alpha3.Intent)
+			}
+			intentsByServer[serverName][clientName] = append(intentsByServer[serverName][clientName], intent)
+		}
+	}
+	return intentsByServer, intentsByClient, nil
+}
+
+// Resync resyncs the ACLs of every server in the ServersStore once. A failing server does not prevent the others from
+// being resynced.
+func (r *KafkaACLResyncer) Resync(ctx context.Context) error {
+	intentsByServer, intentsByClient, err := r.getClientIntentsByServer(ctx)
+	if err != nil {
+		return err
+	}
+
+	return r.serversStore.MapErr(func(serverName types.NamespacedName, config *otterizev1alpha3.KafkaServerConfig, tls otterizev1alpha3.TLSSource) error {
+		if err := r.resyncServer(ctx, serverName, config, tls, intentsByServer[serverName], intentsByClient); err != nil {
+			logrus.WithError(err).WithField("server", serverName).Error("Kafka ACL resync failed")
+			r.RecordWarningEventf(config, ReasonKafkaACLResyncFailed, "Kafka ACL resync failed: %s", err.Error())
+		}
+		return nil
+	})
+}
+
+func (r *KafkaACLResyncer) resyncServer(
+	ctx context.Context,
+	serverName types.NamespacedName,
+	config *otterizev1alpha3.KafkaServerConfig,
+	tls otterizev1alpha3.TLSSource,
+	clientIntents map[types.NamespacedName][]otterizev1alpha3.Intent,
+	intentsByClient map[types.NamespacedName]otterizev1alpha3.ClientIntents,
+) error {
+	clientServiceAccounts := make(map[types.NamespacedName]string)
+	skippedClients := make([]string, 0)
+	if kafkaacls.PrincipalTemplateUsesServiceAccount(config.Spec.PrincipalTemplate) {
+		resolvedClientIntents := make(map[types.NamespacedName][]otterizev1alpha3.Intent)
+		for clientName, intents := range clientIntents {
+			pod, err := r.serviceResolver.ResolveClientIntentToPod(ctx, intentsByClient[clientName])
+			if errors.Is(err, serviceidresolver.ErrPodNotFound) {
+				// Without the client's principal, its ACLs cannot be told apart from drift.
+				logrus.Infof("Skipping Kafka ACL resync of client %s to server %s, no pods found for resolving its service account", clientName, serverName)
+				skippedClients = append(skippedClients, clientName.String())
+				continue
+			} else if err != nil {
+				return err
+			}
+			clientServiceAccounts[clientName] = pod.Spec.ServiceAccountName
+			resolvedClientIntents[clientName] = intents
+		}
+		clientIntents = resolvedClientIntents
+	}
+	sort.Strings(skippedClients)
+
+	shouldCreatePolicy, err := protected_services.IsServerEnforcementEnabledDueToProtectionOrDefaultState(ctx, r.Client, serverName.Name, serverName.Namespace, r.enforcementDefaultState)
+	if err != nil {
+		return err
+	}
+
+	kafkaIntentsAdmin, err := r.getNewKafkaIntentsAdmin(*config, tls, r.enableKafkaACLCreation, shouldCreatePolicy)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Kafka server %s: %w", serverName, err)
+	}
+	defer kafkaIntentsAdmin.Close()
+
+	drift, err := kafkaIntentsAdmin.ResyncACLs(clientIntents, clientServiceAccounts, !r.reportOnly)
+	if err != nil {
+		return fmt.Errorf("failed resyncing ACLs of Kafka server %s: %w", serverName, err)
+	}
+
+	if len(drift.Missing) != 0 || len(drift.Unexpected) != 0 {
+		if r.reportOnly {
+			logrus.WithField("server", serverName).Warningf("Found %d missing and %d unexpected Kafka ACLs", len(drift.Missing), len(drift.Unexpected))
+			r.RecordWarningEventf(config, ReasonKafkaACLDriftDetected, "Found %d missing and %d unexpected ACLs on Kafka server. Missing: %s. Unexpected: %s",
+				len(drift.Missing), len(drift.Unexpected), formatACLs(drift.Missing), formatACLs(drift.Unexpected))
+		} else {
+			logrus.WithField("server", serverName).Infof("Repaired %d missing and %d unexpected Kafka ACLs", len(drift.Missing), len(drift.Unexpected))
+			r.RecordNormalEventf(config, ReasonKafkaACLDriftRepaired, "Repaired %d missing and %d unexpected ACLs on Kafka server. Missing: %s. Unexpected: %s",
+				len(drift.Missing), len(drift.Unexpected), formatACLs(drift.Missing), formatACLs(drift.Unexpected))
+		}
+	}
+
+	return r.updateResyncStatus(ctx, config, drift, skippedClients)
+}
+
+func formatACLs(acls []otterizev1alpha3.KafkaACL) string {
+	if len(acls) == 0 {
+		return "none"
+	}
+
+	formatted := lo.Map(lo.Slice(acls, 0, maxDriftEventACLs), func(acl otterizev1alpha3.KafkaACL, _ int) string {
+		return fmt.Sprintf("%s %s %s on %s %s (%s)", acl.Principal, acl.Permission, acl.Operation, acl.ResourceType, acl.ResourceName, acl.PatternType)
+	})
+	if len(acls) > maxDriftEventACLs {
+		formatted = append(formatted, fmt.Sprintf("and %d more", len(acls)-maxDriftEventACLs))
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// updateResyncStatus reports the drift in the KafkaServerConfig's status in report-only mode, and clears it otherwise.
+// The clients that were skipped are reported in both modes.
+func (r *KafkaACLResyncer) updateResyncStatus(ctx context.Context, config *otterizev1alpha3.KafkaServerConfig, drift otterizev1alpha3.KafkaACLDrift, skippedClients []string) error {
+	var driftStatus *otterizev1alpha3.KafkaACLDriftStatus
+	if r.reportOnly && (len(drift.Missing) != 0 || len(drift.Unexpected) != 0) {
+		driftStatus = &otterizev1alpha3.KafkaACLDriftStatus{
+			MissingCount:    len(drift.Missing),
+			UnexpectedCount: len(drift.Unexpected),
+			KafkaACLDrift: otterizev1alpha3.KafkaACLDrift{
+				Missing:    lo.Slice(drift.Missing, 0, otterizev1alpha3.MaxKafkaACLDriftStatusACLs),
+				Unexpected: lo.Slice(drift.Unexpected, 0, otterizev1alpha3.MaxKafkaACLDriftStatusACLs),
+			},
+		}
+	}
+
+	// The ServersStore holds the KafkaServerConfig as of its last reconcile, so the status is compared with a fresh copy.
+	kafkaServerConfig := &otterizev1alpha3.KafkaServerConfig{}
+	if err := r.Get(ctx, types.NamespacedName{Name: config.Name, Namespace: config.Namespace}, kafkaServerConfig); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if len(skippedClients) == 0 {
+		skippedClients = nil
+	}
+	if reflect.DeepEqual(kafkaServerConfig.Status.ACLDrift, driftStatus) && reflect.DeepEqual(kafkaServerConfig.Status.ACLResyncSkippedClients, skippedClients) {
+		return nil
+	}
+
+	kafkaServerConfigCopy := kafkaServerConfig.DeepCopy()
+	kafkaServerConfigCopy.Status.ACLDrift = driftStatus
+	kafkaServerConfigCopy.Status.ACLResyncSkippedClients = skippedClients
+	if err := r.Status().Patch(ctx, kafkaServerConfigCopy, client.MergeFrom(kafkaServerConfig)); err != nil {
+		return fmt.Errorf("failed updating Kafka ACL resync status: %w", err)
+	}
+	return nil
+}
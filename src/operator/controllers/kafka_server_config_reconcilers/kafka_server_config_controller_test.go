@@ -91,7 +91,7 @@ func (s *KafkaServerConfigReconcilerTestSuite) setupServerStore(serviceName stri
 	serverConfig.SetNamespace(testNamespace)
 	emptyTls := otterizev1alpha3.TLSSource{}
 	factory := getMockIntentsAdminFactory(s.mockIntentsAdmin)
-	kafkaServersStore := kafkaacls.NewServersStore(emptyTls, false, factory, true)
+	kafkaServersStore := kafkaacls.NewServersStore(emptyTls, false, factory, true, nil)
 	kafkaServersStore.Add(serverConfig)
 	return kafkaServersStore
 }
@@ -168,6 +168,80 @@ func (s *KafkaServerConfigReconcilerTestSuite) TestKafkaServerConfigUpload() {
 	s.ExpectEvent(ReasonSuccessfullyAppliedKafkaServerConfig)
 }
 
+func (s *KafkaServerConfigReconcilerTestSuite) TestKafkaServerConfigTopicManagement() {
+	kafkaServerConfig := s.generateKafkaServerConfig()
+	kafkaServerConfig.Spec.TopicManagement = &otterizev1alpha3.KafkaTopicManagement{
+		Topics: []otterizev1alpha3.KafkaManagedTopic{{Name: kafkaTopicName, Partitions: 3, ReplicationFactor: 3}},
+	}
+	// The status is already up to date, so no status patch is expected
+	kafkaServerConfig.Status.Topics = &otterizev1alpha3.KafkaTopicsStatus{Created: []string{kafkaTopicName}, Missing: []string{"missing-topic"}}
+
+	objectName := types.NamespacedName{
+		Name:      kafkaServiceName,
+		Namespace: testNamespace,
+	}
+	s.Client.EXPECT().Get(gomock.Any(), objectName, &otterizev1alpha3.KafkaServerConfig{}).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, actualKSC *otterizev1alpha3.KafkaServerConfig, _ ...client.GetOption) error {
+			kafkaServerConfig.DeepCopyInto(actualKSC)
+			return nil
+		})
+
+	// The operator's own intents to all topics are not considered references to topics
+	clientIntents := otterizev1alpha3.ClientIntents{
+		ObjectMeta: metav1.ObjectMeta{Name: "client-intents", Namespace: testNamespace},
+		Spec: &otterizev1alpha3.IntentsSpec{
+			Service: otterizev1alpha3.Service{Name: "client"},
+			Calls: []otterizev1alpha3.Intent{
+				{
+					Name: kafkaServiceName,
+					Type: otterizev1alpha3.IntentTypeKafka,
+					Topics: []otterizev1alpha3.KafkaTopic{
+						{Name: kafkaTopicName, Operations: []otterizev1alpha3.KafkaOperation{otterizev1alpha3.KafkaOperationConsume}},
+						{Name: "missing-topic", Operations: []otterizev1alpha3.KafkaOperation{otterizev1alpha3.KafkaOperationProduce}},
+					},
+				},
+			},
+		},
+	}
+	operatorIntents := otterizev1alpha3.ClientIntents{
+		ObjectMeta: metav1.ObjectMeta{Name: FormatIntentsName(&kafkaServerConfig), Namespace: operatorPodNamespace},
+		Spec: &otterizev1alpha3.IntentsSpec{
+			Service: otterizev1alpha3.Service{Name: "intents-operator-service"},
+			Calls: []otterizev1alpha3.Intent{
+				{
+					Name:   fmt.Sprintf("%s.%s", kafkaServiceName, testNamespace),
+					Type:   otterizev1alpha3.IntentTypeKafka,
+					Topics: []otterizev1alpha3.KafkaTopic{{Name: "*", Operations: []otterizev1alpha3.KafkaOperation{otterizev1alpha3.KafkaOperationDescribe}}},
+				},
+			},
+		},
+	}
+	s.Client.EXPECT().List(gomock.Any(), &otterizev1alpha3.ClientIntentsList{}, &client.MatchingFields{otterizev1alpha3.OtterizeTargetServerIndexField: fmt.Sprintf("%s.%s", kafkaServiceName, testNamespace)}).DoAndReturn(
+		func(ctx context.Context, list *otterizev1alpha3.ClientIntentsList, _ ...client.ListOption) error {
+			list.Items = append(list.Items, clientIntents, operatorIntents)
+			return nil
+		})
+
+	s.mockIntentsAdmin.EXPECT().ApplyServerTopicsConf(kafkaServerConfig.Spec.Topics).Return(nil)
+	s.mockIntentsAdmin.EXPECT().ApplyTopics(*kafkaServerConfig.Spec.TopicManagement, []string{kafkaTopicName}, []string{kafkaTopicName, "missing-topic"}).
+		Return(otterizev1alpha3.KafkaTopicsStatus{Created: []string{kafkaTopicName}, Missing: []string{"missing-topic"}}, nil)
+	s.mockIntentsAdmin.EXPECT().Close()
+
+	s.Client.EXPECT().List(gomock.Any(), &otterizev1alpha3.KafkaServerConfigList{}, client.InNamespace(testNamespace), &client.ListOptions{Namespace: testNamespace}).DoAndReturn(
+		func(ctx context.Context, list *otterizev1alpha3.KafkaServerConfigList, _ ...client.ListOption) error {
+			list.Items = append(list.Items, kafkaServerConfig)
+			return nil
+		})
+	s.mockCloudClient.EXPECT().ReportKafkaServerConfig(gomock.Any(), testNamespace, gomock.Eq(s.getExpectedKafkaServerConfigs(kafkaServerConfig))).Return(nil)
+
+	res, err := s.reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: objectName})
+
+	s.Require().NoError(err)
+	s.Require().Empty(res)
+	s.ExpectEvent(ReasonKafkaTopicsMissing)
+	s.ExpectEvent(ReasonSuccessfullyAppliedKafkaServerConfig)
+}
+
 func (s *KafkaServerConfigReconcilerTestSuite) getExpectedKafkaServerConfigs(kafkaServerConfig otterizev1alpha3.KafkaServerConfig) []graphqlclient.KafkaServerConfigInput {
 	ksc, err := kafkaServerConfigCRDToCloudModel(kafkaServerConfig)
 	s.Require().NoError(err)
@@ -13,6 +13,7 @@ import (
 	"github.com/otterize/intents-operator/src/shared/serviceidresolver"
 	"github.com/otterize/intents-operator/src/shared/telemetries/telemetriesgql"
 	"github.com/otterize/intents-operator/src/shared/telemetries/telemetrysender"
+	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	v1 "k8s.io/api/core/v1"
@@ -20,8 +21,10 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"reflect"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
 	"time"
 )
 
@@ -29,6 +32,8 @@ const (
 	ReasonIntentsOperatorIdentityResolveFailed = "IntentsOperatorIdentityResolveFailed"
 	ReasonApplyingKafkaServerConfigFailed      = "ApplyingKafkaServerConfigFailed"
 	ReasonSuccessfullyAppliedKafkaServerConfig = "SuccessfullyAppliedKafkaServerConfig"
+	ReasonApplyingKafkaTopicsFailed            = "ApplyingKafkaTopicsFailed"
+	ReasonKafkaTopicsMissing                   = "KafkaTopicsMissing"
 )
 
 // KafkaServerConfigReconciler reconciles a KafkaServerConfig object
@@ -240,11 +245,79 @@ func (r *KafkaServerConfigReconciler) reconcileObject(ctx context.Context, kafka
 		return ctrl.Result{}, err
 	}
 
+	if err := r.reconcileTopics(ctx, kafkaServerConfig, kafkaIntentsAdmin); err != nil {
+		r.RecordWarningEventf(kafkaServerConfig, ReasonApplyingKafkaTopicsFailed, "failed to apply topics to Kafka broker: %s", err.Error())
+		return ctrl.Result{}, err
+	}
+
 	r.RecordNormalEvent(kafkaServerConfig, ReasonSuccessfullyAppliedKafkaServerConfig, "successfully applied server config")
 	telemetrysender.SendIntentOperator(telemetriesgql.EventTypeKafkaServerConfigApplied, len(kafkaServerConfig.Spec.Topics))
 	return ctrl.Result{}, nil
 }
 
+// getReferencedTopics returns the topics referenced by intents to the Kafka server, other than the operator's own.
+func (r *KafkaServerConfigReconciler) getReferencedTopics(ctx context.Context, kafkaServerConfig *otterizev1alpha3.KafkaServerConfig) ([]string, error) {
+	serverName := fmt.Sprintf("%s.%s", kafkaServerConfig.Spec.Service.Name, kafkaServerConfig.Namespace)
+	var intentsToServer otterizev1alpha3.ClientIntentsList
+	err := r.List(ctx, &intentsToServer, &client.MatchingFields{otterizev1alpha3.OtterizeTargetServerIndexField: serverName})
+	if err != nil {
+		return nil, err
+	}
+
+	topics := make([]string, 0)
+	for _, clientIntents := range intentsToServer.Items {
+		if !clientIntents.DeletionTimestamp.IsZero() ||
+			(clientIntents.Name == FormatIntentsName(kafkaServerConfig) && clientIntents.Namespace == r.operatorPodNamespace) {
+			continue
+		}
+		for _, intent := range clientIntents.GetCallsList() {
+			if intent.Type != otterizev1alpha3.IntentTypeKafka || intent.GetServerFullyQualifiedName(clientIntents.Namespace) != serverName {
+				continue
+			}
+			for _, topic := range intent.Topics {
+				topics = append(topics, topic.Name)
+			}
+		}
+	}
+	return lo.Uniq(topics), nil
+}
+
+// reconcileTopics applies the KafkaServerConfig's topic management, and reports the topics created by the operator and
+// the topics missing on the server in its status.
+func (r *KafkaServerConfigReconciler) reconcileTopics(ctx context.Context, kafkaServerConfig *otterizev1alpha3.KafkaServerConfig, kafkaIntentsAdmin kafkaacls.KafkaIntentsAdmin) error {
+	var topicsStatus *otterizev1alpha3.KafkaTopicsStatus
+	if kafkaServerConfig.Spec.TopicManagement != nil {
+		referencedTopics, err := r.getReferencedTopics(ctx, kafkaServerConfig)
+		if err != nil {
+			return err
+		}
+
+		var createdTopics []string
+		if kafkaServerConfig.Status.Topics != nil {
+			createdTopics = kafkaServerConfig.Status.Topics.Created
+		}
+		status, err := kafkaIntentsAdmin.ApplyTopics(*kafkaServerConfig.Spec.TopicManagement, createdTopics, referencedTopics)
+		if err != nil {
+			return err
+		}
+		if len(status.Missing) != 0 {
+			r.RecordWarningEventf(kafkaServerConfig, ReasonKafkaTopicsMissing, "topics referenced by intents are missing on Kafka broker: %s", strings.Join(status.Missing, ", "))
+		}
+		topicsStatus = &status
+	}
+
+	if reflect.DeepEqual(kafkaServerConfig.Status.Topics, topicsStatus) {
+		return nil
+	}
+
+	kafkaServerConfigCopy := kafkaServerConfig.DeepCopy()
+	kafkaServerConfigCopy.Status.Topics = topicsStatus
+	if err := r.Status().Patch(ctx, kafkaServerConfigCopy, client.MergeFrom(kafkaServerConfig)); err != nil {
+		return fmt.Errorf("failed updating topics status: %w", err)
+	}
+	return nil
+}
+
 func (r *KafkaServerConfigReconciler) uploadKafkaServerConfigs(ctx context.Context, namespace string) error {
 	if r.otterizeClient == nil {
 		return nil
@@ -0,0 +1,265 @@
+package kafkaacls
+
+import (
+	"context"
+	"fmt"
+	"github.com/Shopify/sarama"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"reflect"
+	"sort"
+)
+
+func toKafkaACLs(resourceAclsList []*sarama.ResourceAcls) []otterizev1alpha3.KafkaACL {
+	kafkaACLs := make([]otterizev1alpha3.KafkaACL, 0)
+	for _, resourceAcls := range resourceAclsList {
+		for _, acl := range resourceAcls.Acls {
+			kafkaACLs = append(kafkaACLs, otterizev1alpha3.KafkaACL{
+				Principal:    acl.Principal,
+				ResourceType: resourceAcls.ResourceType.String(),
+				ResourceName: resourceAcls.ResourceName,
+				PatternType:  resourceAcls.ResourcePatternType.String(),
+				Operation:    acl.Operation.String(),
+				Permission:   acl.PermissionType.String(),
+				Host:         acl.Host,
+			})
+		}
+	}
+	sortKafkaACLs(kafkaACLs)
+	return kafkaACLs
+}
+
+func sortKafkaACLs(kafkaACLs []otterizev1alpha3.KafkaACL) {
+	sort.Slice(kafkaACLs, func(i, j int) bool {
+		return fmt.Sprint(kafkaACLs[i]) < fmt.Sprint(kafkaACLs[j])
+	})
+}
+
+// isManagedACL returns whether the ACL is created by the operator, either from intents of clientPrincipals, or from the
+// server's topic and consumer group configuration. ACLs of the ANONYMOUS and any user principals count as managed only
+// in the form the configuration creates them, on topics it configures, so that such ACLs created by others are kept.
+func (a *KafkaIntentsAdminImpl) isManagedACL(resource sarama.Resource, acl sarama.Acl, clientPrincipals sets.Set[string], topicsConfAcls map[sarama.Resource][]sarama.Acl) bool {
+	switch acl.Principal {
+	case AnonymousUserPrincipalName, AnyUserPrincipalName:
+		if resource.ResourceType == sarama.AclResourceGroup {
+			// The consumer group wildcard is removed when consumer groups are restricted, see reconcileConsumerGroupWildcardACLs.
+			wildcardACLs := a.collectConsumerGroupsToACLList(AnyUserPrincipalName, []otterizev1alpha3.KafkaConsumerGroup{{Name: "*"}})
+			return !a.kafkaServer.Spec.RestrictConsumerGroups && lo.Contains(wildcardACLs[resource], acl)
+		}
+		// The permission is not compared, so that an ACL left from a previous configuration of the topic is replaced.
+		_, isConfiguredTopic := topicsConfAcls[resource]
+		return isConfiguredTopic && acl.Host == "*" && acl.Operation == sarama.AclOperationAll
+	default:
+		return acl.PermissionType == sarama.AclPermissionAllow && clientPrincipals.Has(acl.Principal)
+	}
+}
+
+// getAppliedManagedACLs returns the ACLs on the server that the operator manages for clientPrincipals and the server's
+// configuration.
+func (a *KafkaIntentsAdminImpl) getAppliedManagedACLs(clientPrincipals sets.Set[string]) (map[sarama.Resource][]sarama.Acl, error) {
+	resourceAclsList, err := a.kafkaAdminClient.ListAcls(sarama.AclFilter{
+		ResourceType:              sarama.AclResourceAny,
+		ResourcePatternTypeFilter: sarama.AclPatternAny,
+		PermissionType:            sarama.AclPermissionAny,
+		Operation:                 sarama.AclOperationAny,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed listing ACLs on server: %w", err)
+	}
+
+	topicsConfAcls := a.getExpectedTopicsConfAcls(a.kafkaServer.Spec.Topics)
+	resourceToAcls := map[sarama.Resource][]sarama.Acl{}
+	for _, resourceAcls := range resourceAclsList {
+		for _, acl := range resourceAcls.Acls {
+			if a.isManagedACL(resourceAcls.Resource, *acl, clientPrincipals, topicsConfAcls) {
+				resourceToAcls[resourceAcls.Resource] = append(resourceToAcls[resourceAcls.Resource], *acl)
+			}
+		}
+	}
+	return resourceToAcls, nil
+}
+
+// getExpectedACLs returns all the ACLs the operator should create on the server, from the intents of its clients and
+// its topic and consumer group configuration, and the principals of the clients.
+func (a *KafkaIntentsAdminImpl) getExpectedACLs(clientIntents map[types.NamespacedName][]otterizev1alpha3.Intent, clientServiceAccounts map[types.NamespacedName]string) (map[sarama.Resource][]sarama.Acl, sets.Set[string], error) {
+	expected := a.getExpectedTopicsConfAcls(a.kafkaServer.Spec.Topics)
+	if !a.kafkaServer.Spec.RestrictConsumerGroups {
+		wildcardACLs := a.collectConsumerGroupsToACLList(AnyUserPrincipalName, []otterizev1alpha3.KafkaConsumerGroup{{Name: "*"}})
+		for resource, acls := range wildcardACLs {
+			expected[resource] = lo.Uniq(append(expected[resource], acls...))
+		}
+	}
+
+	clientPrincipals := sets.New[string]()
+	for client, intents := range clientIntents {
+		principal := a.formatPrincipal(clientIdentity{name: client.Name, namespace: client.Namespace, serviceAccount: clientServiceAccounts[client]})
+		clientPrincipals.Insert(principal)
+		intentsACLs, err := a.collectTopicsToACLList(principal, getIntentResources(a.kafkaServer.Spec, intents))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed collecting topics to ACL list %w", err)
+		}
+		consumerGroups := lo.Flatten(lo.Map(intents, func(intent otterizev1alpha3.Intent, _ int) []otterizev1alpha3.KafkaConsumerGroup {
+			return intent.ConsumerGroups
+		}))
+		for resource, acls := range lo.Assign(intentsACLs, a.collectConsumerGroupsToACLList(principal, consumerGroups)) {
+			expected[resource] = lo.Uniq(append(expected[resource], acls...))
+		}
+	}
+	return expected, clientPrincipals, nil
+}
+
+// ResyncACLs compares the ACLs the operator manages on the server with those expected from clientIntents, which holds
+// the intents of each client to this server, and from the server's configuration. ACLs of principals of other clients
+// are left to RemoveOrphanedClientACLs. When repair is set, missing ACLs are created and unexpected ones are deleted.
+// Returns the drift found before repairing it.
+func (a *KafkaIntentsAdminImpl) ResyncACLs(clientIntents map[types.NamespacedName][]otterizev1alpha3.Intent, clientServiceAccounts map[types.NamespacedName]string, repair bool) (otterizev1alpha3.KafkaACLDrift, error) {
+	logger := logrus.WithFields(
+		logrus.Fields{
+			"serverName":      a.kafkaServer.Spec.Service,
+			"serverNamespace": a.kafkaServer.Namespace,
+		})
+
+	expected, clientPrincipals, err := a.getExpectedACLs(clientIntents, clientServiceAccounts)
+	if err != nil {
+		return otterizev1alpha3.KafkaACLDrift{}, err
+	}
+	applied, err := a.getAppliedManagedACLs(clientPrincipals)
+	if err != nil {
+		return otterizev1alpha3.KafkaACLDrift{}, err
+	}
+
+	resourceAclsCreate, resourceAclsDelete := a.kafkaResourceAclsDiff(expected, applied)
+	drift := otterizev1alpha3.KafkaACLDrift{Missing: toKafkaACLs(resourceAclsCreate), Unexpected: toKafkaACLs(resourceAclsDelete)}
+	if !repair {
+		return drift, nil
+	}
+
+	if len(resourceAclsCreate) != 0 {
+		if a.enforcementEnabledForServer && a.enableKafkaACLCreation {
+			logger.Infof("Creating %d missing ACLs", len(drift.Missing))
+			if err := a.kafkaAdminClient.CreateACLs(resourceAclsCreate); err != nil {
+				return drift, fmt.Errorf("failed applying ACLs to server: %w", err)
+			}
+		} else {
+			logger.Infof("Skipped creation of %d missing ACLs because enforcement or Kafka ACL creation is disabled", len(drift.Missing))
+		}
+	}
+
+	if len(resourceAclsDelete) != 0 {
+		logger.Infof("Deleting %d unexpected ACLs", len(drift.Unexpected))
+		if err := a.deleteResourceAcls(resourceAclsDelete); err != nil {
+			return drift, fmt.Errorf("failed deleting ACLs on server: %w", err)
+		}
+	}
+
+	return drift, nil
+}
+
+func strimziACLRuleToKafkaACLs(principal string, rule strimziACLRule) []otterizev1alpha3.KafkaACL {
+	return lo.Map(rule.Operations, func(operation string, _ int) otterizev1alpha3.KafkaACL {
+		return otterizev1alpha3.KafkaACL{
+			Principal:    principal,
+			ResourceType: rule.Resource.Type,
+			ResourceName: lo.Ternary(rule.Resource.Type == "cluster", KafkaClusterResourceName, rule.Resource.Name),
+			PatternType:  rule.Resource.PatternType,
+			Operation:    operation,
+			Permission:   "allow",
+			Host:         rule.Host,
+		}
+	})
+}
+
+// ResyncACLs reports the ACL rules expected from clientIntents, which holds the intents of each client to this server,
+// and from the server's topic configuration, that are missing from the KafkaUsers of the clients. When repair is set,
+// the managed rules of KafkaUsers with missing rules are written again. Rules added to KafkaUsers by others are kept by
+// design, so they are not reported as unexpected.
+func (a *StrimziIntentsAdmin) ResyncACLs(clientIntents map[types.NamespacedName][]otterizev1alpha3.Intent, clientServiceAccounts map[types.NamespacedName]string, repair bool) (otterizev1alpha3.KafkaACLDrift, error) {
+	ctx := context.Background()
+	drift := otterizev1alpha3.KafkaACLDrift{Missing: make([]otterizev1alpha3.KafkaACL, 0)}
+
+	topicConfigRules, err := getStrimziACLRules(a.getTopicConfigResources(a.kafkaServer.Spec.Topics))
+	if err != nil {
+		return drift, fmt.Errorf("failed collecting topic configuration ACL rules: %w", err)
+	}
+
+	for client, intents := range clientIntents {
+		identity := clientIdentity{name: client.Name, namespace: client.Namespace, serviceAccount: clientServiceAccounts[client]}
+		name, err := a.kafkaUserName(identity)
+		if err != nil {
+			return drift, err
+		}
+
+		intentsRules, err := getStrimziACLRules(append(getIntentResources(a.kafkaServer.Spec, intents), getConsumerGroupResources(intents)...))
+		if err != nil {
+			return drift, fmt.Errorf("failed collecting ACL rules: %w", err)
+		}
+		if len(intentsRules) == 0 {
+			continue
+		}
+		expectedRules := lo.Flatten([][]strimziACLRule{intentsRules, topicConfigRules})
+
+		appliedRules := make([]strimziACLRule, 0)
+		user, err := a.getKafkaUser(ctx, name)
+		if err != nil {
+			return drift, err
+		}
+		if user != nil {
+			entries, _, err := unstructured.NestedSlice(user.Object, "spec", "authorization", "acls")
+			if err != nil {
+				return drift, fmt.Errorf("failed reading ACLs of KafkaUser %s: %w", name, err)
+			}
+			for _, entry := range entries {
+				if rule, ok := entryToStrimziACLRule(entry); ok {
+					appliedRules = append(appliedRules, rule)
+				}
+			}
+		}
+
+		missingRules := lo.Reject(expectedRules, func(rule strimziACLRule, _ int) bool {
+			return lo.ContainsBy(appliedRules, func(appliedRule strimziACLRule) bool {
+				return reflect.DeepEqual(rule, appliedRule)
+			})
+		})
+		if len(missingRules) == 0 {
+			continue
+		}
+
+		principal := fmt.Sprintf("%s:%s", userPrincipalType, name)
+		for _, rule := range missingRules {
+			drift.Missing = append(drift.Missing, strimziACLRuleToKafkaACLs(principal, rule)...)
+		}
+
+		if repair && !a.aclCreationEnabled() {
+			a.logger().Infof("Skipped restoring %d missing ACL rules of KafkaUser %s because enforcement or Kafka ACL creation is disabled", len(missingRules), name)
+		} else if repair {
+			a.logger().Infof("Restoring %d missing ACL rules of KafkaUser %s", len(missingRules), name)
+			if err := a.repairKafkaUser(ctx, name, intentsRules, topicConfigRules); err != nil {
+				return drift, err
+			}
+		}
+	}
+
+	sortKafkaACLs(drift.Missing)
+	return drift, nil
+}
+
+// repairKafkaUser rewrites the managed rules of the KafkaUser, so that rules removed from its spec but still listed in
+// its annotations are added back.
+func (a *StrimziIntentsAdmin) repairKafkaUser(ctx context.Context, name string, intentsRules []strimziACLRule, topicConfigRules []strimziACLRule) error {
+	return a.updateKafkaUser(ctx, name, true, func(user *unstructured.Unstructured) error {
+		// Clearing the managed rules first adds back those that were removed from the spec.
+		for _, annotation := range []string{StrimziIntentsACLsAnnotation, StrimziTopicConfigACLsAnnotation} {
+			if err := setManagedACLRules(user, annotation, nil); err != nil {
+				return err
+			}
+		}
+		if err := setManagedACLRules(user, StrimziIntentsACLsAnnotation, intentsRules); err != nil {
+			return err
+		}
+		return setManagedACLRules(user, StrimziTopicConfigACLsAnnotation, topicConfigRules)
+	})
+}
@@ -0,0 +1,153 @@
+package kafkaacls
+
+import (
+	"github.com/Shopify/sarama"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	kafkaaclsmocks "github.com/otterize/intents-operator/src/operator/controllers/kafkaacls/mocks"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"testing"
+)
+
+const (
+	driftTopicName       = "my-topic"
+	driftClientPrincipal = "User:my-client.test-namespace"
+)
+
+type ACLDriftSuite struct {
+	suite.Suite
+	mockClusterAdmin *kafkaaclsmocks.MockClusterAdmin
+	intentsAdmin     KafkaIntentsAdmin
+	clientIntents    map[types.NamespacedName][]otterizev1alpha3.Intent
+}
+
+func (s *ACLDriftSuite) SetupTest() {
+	controller := gomock.NewController(s.T())
+	s.mockClusterAdmin = kafkaaclsmocks.NewMockClusterAdmin(controller)
+	kafkaServerConfig := otterizev1alpha3.KafkaServerConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kafkaServerConfigResourceName,
+			Namespace: testNamespace,
+		},
+		Spec: otterizev1alpha3.KafkaServerConfigSpec{
+			Service:                otterizev1alpha3.Service{Name: serverName},
+			Addr:                   serverAddress,
+			RestrictConsumerGroups: true,
+		},
+	}
+	s.intentsAdmin = NewKafkaIntentsAdminImpl(kafkaServerConfig, s.mockClusterAdmin, "$ServiceName.$Namespace", true, true)
+	s.clientIntents = map[types.NamespacedName][]otterizev1alpha3.Intent{
+		{Name: clientName, Namespace: testNamespace}: {
+			{
+				Name: serverName,
+				Type: otterizev1alpha3.IntentTypeKafka,
+				Topics: []otterizev1alpha3.KafkaTopic{
+					{Name: driftTopicName, Operations: []otterizev1alpha3.KafkaOperation{otterizev1alpha3.KafkaOperationConsume, otterizev1alpha3.KafkaOperationDescribe}},
+				},
+			},
+		},
+	}
+}
+
+// expectListAcls returns ACLs on the server where the client's describe ACL was deleted and a write ACL was added
+// outside the operator, alongside the default topic configuration, an ACL of an unrelated principal and ACLs of the
+// ANONYMOUS and any user principals that the topic configuration does not create.
+func (s *ACLDriftSuite) expectListAcls() {
+	s.mockClusterAdmin.EXPECT().ListAcls(sarama.AclFilter{
+		ResourceType:              sarama.AclResourceAny,
+		ResourcePatternTypeFilter: sarama.AclPatternAny,
+		PermissionType:            sarama.AclPermissionAny,
+		Operation:                 sarama.AclOperationAny,
+	}).Return([]sarama.ResourceAcls{
+		{
+			Resource: sarama.Resource{ResourceType: sarama.AclResourceTopic, ResourceName: "*", ResourcePatternType: sarama.AclPatternLiteral},
+			Acls: []*sarama.Acl{
+				{Principal: AnonymousUserPrincipalName, Host: "*", Operation: sarama.AclOperationAll, PermissionType: sarama.AclPermissionDeny},
+				{Principal: AnyUserPrincipalName, Host: "*", Operation: sarama.AclOperationDescribe, PermissionType: sarama.AclPermissionAllow},
+			},
+		},
+		{
+			Resource: sarama.Resource{ResourceType: sarama.AclResourceTopic, ResourceName: driftTopicName, ResourcePatternType: sarama.AclPatternLiteral},
+			Acls: []*sarama.Acl{
+				{Principal: driftClientPrincipal, Host: "*", Operation: sarama.AclOperationRead, PermissionType: sarama.AclPermissionAllow},
+				{Principal: driftClientPrincipal, Host: "*", Operation: sarama.AclOperationWrite, PermissionType: sarama.AclPermissionAllow},
+				{Principal: "User:admin", Host: "*", Operation: sarama.AclOperationAll, PermissionType: sarama.AclPermissionAllow},
+			},
+		},
+		{
+			Resource: sarama.Resource{ResourceType: sarama.AclResourceTopic, ResourceName: "public-", ResourcePatternType: sarama.AclPatternPrefixed},
+			Acls: []*sarama.Acl{
+				{Principal: AnonymousUserPrincipalName, Host: "*", Operation: sarama.AclOperationRead, PermissionType: sarama.AclPermissionAllow},
+				{Principal: AnyUserPrincipalName, Host: "*", Operation: sarama.AclOperationAll, PermissionType: sarama.AclPermissionAllow},
+			},
+		},
+	}, nil)
+}
+
+func (s *ACLDriftSuite) expectedDrift() otterizev1alpha3.KafkaACLDrift {
+	acl := otterizev1alpha3.KafkaACL{
+		Principal:    driftClientPrincipal,
+		ResourceType: "Topic",
+		ResourceName: driftTopicName,
+		PatternType:  "Literal",
+		Permission:   "Allow",
+		Host:         "*",
+	}
+	missing, unexpected := acl, acl
+	missing.Operation = "Describe"
+	unexpected.Operation = "Write"
+	return otterizev1alpha3.KafkaACLDrift{Missing: []otterizev1alpha3.KafkaACL{missing}, Unexpected: []otterizev1alpha3.KafkaACL{unexpected}}
+}
+
+func (s *ACLDriftSuite) TestResyncACLsReportOnly() {
+	// No CreateACLs or DeleteACL calls are expected
+	s.expectListAcls()
+
+	drift, err := s.intentsAdmin.ResyncACLs(s.clientIntents, nil, false)
+	s.Require().NoError(err)
+	s.Require().Equal(s.expectedDrift(), drift)
+}
+
+func (s *ACLDriftSuite) TestResyncACLsRepair() {
+	s.expectListAcls()
+	s.mockClusterAdmin.EXPECT().CreateACLs(MatchResourceAcls([]*sarama.ResourceAcls{
+		{
+			Resource: sarama.Resource{ResourceType: sarama.AclResourceTopic, ResourceName: driftTopicName, ResourcePatternType: sarama.AclPatternLiteral},
+			Acls: []*sarama.Acl{
+				{Principal: driftClientPrincipal, Host: "*", Operation: sarama.AclOperationDescribe, PermissionType: sarama.AclPermissionAllow},
+			},
+		},
+	})).Return(nil)
+	s.mockClusterAdmin.EXPECT().DeleteACL(sarama.AclFilter{
+		ResourceType:              sarama.AclResourceTopic,
+		ResourceName:              lo.ToPtr(driftTopicName),
+		ResourcePatternTypeFilter: sarama.AclPatternLiteral,
+		PermissionType:            sarama.AclPermissionAllow,
+		Operation:                 sarama.AclOperationWrite,
+		Principal:                 lo.ToPtr(driftClientPrincipal),
+		Host:                      lo.ToPtr("*"),
+	}, false).Return([]sarama.MatchingAcl{{}}, nil)
+
+	drift, err := s.intentsAdmin.ResyncACLs(s.clientIntents, nil, true)
+	s.Require().NoError(err)
+	s.Require().Equal(s.expectedDrift(), drift)
+}
+
+func (s *ACLDriftSuite) TestResyncACLsNoDrift() {
+	s.clientIntents[types.NamespacedName{Name: clientName, Namespace: testNamespace}][0].Topics[0].Operations = []otterizev1alpha3.KafkaOperation{
+		otterizev1alpha3.KafkaOperationConsume, otterizev1alpha3.KafkaOperationProduce,
+	}
+	s.expectListAcls()
+
+	drift, err := s.intentsAdmin.ResyncACLs(s.clientIntents, nil, true)
+	s.Require().NoError(err)
+	s.Require().Empty(drift.Missing)
+	s.Require().Empty(drift.Unexpected)
+}
+
+func TestACLDriftSuite(t *testing.T) {
+	suite.Run(t, new(ACLDriftSuite))
+}
@@ -0,0 +1,316 @@
+package kafkaacls
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Shopify/sarama"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/types"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// connectionAcquireTimeout is how long an operation waits for one of the server's concurrent operation slots.
+	connectionAcquireTimeout = time.Minute
+	// connectionIdleTimeout is how long an unused connection is kept open, so that servers no longer reconciled, such as
+	// those of deleted KafkaServerConfigs, are disconnected.
+	connectionIdleTimeout = 30 * time.Minute
+	connectBackoffInitial = time.Second
+	connectBackoffMax     = 5 * time.Minute
+)
+
+var (
+	ErrKafkaServerBusy    = errors.New("timed out waiting for concurrent operations on Kafka server to complete")
+	ErrKafkaServerBackoff = errors.New("backing off reconnecting to Kafka server")
+)
+
+type connectFunc func() (admin sarama.ClusterAdmin, usernameMapping string, err error)
+
+// pooledConnection is a connection to a Kafka server, shared by the KafkaIntentsAdmins leasing it.
+type pooledConnection struct {
+	admin           sarama.ClusterAdmin
+	usernameMapping string
+	fingerprint     string
+	leases          int
+	retired         bool
+	lastUsed        time.Time
+}
+
+func (c *pooledConnection) close() {
+	if err := c.admin.Close(); err != nil {
+		logrus.WithError(err).Error("Error closing kafka admin client")
+	}
+}
+
+// retire closes the connection once all of its leases are released.
+func (c *pooledConnection) retire() {
+	c.retired = true
+	if c.leases == 0 {
+		c.close()
+	}
+}
+
+type poolEntry struct {
+	lock        sync.Mutex
+	connection  *pooledConnection
+	operations  chan struct{}
+	failures    int
+	lastErr     error
+	nextConnect time.Time
+	// removed is set once the entry is removed from the pool, so that connections it still makes are not kept.
+	removed bool
+}
+
+func (e *poolEntry) connectFailed(err error) {
+	e.failures++
+	e.lastErr = err
+	e.nextConnect = time.Now().Add(lo.Min([]time.Duration{connectBackoffInitial << lo.Min([]int{e.failures - 1, 16}), connectBackoffMax}))
+}
+
+// ConnectionPool keeps a long-lived connection per Kafka server, instead of connecting on every reconcile. Connections
+// are replaced when the server's connection configuration or TLS files change, health checked while idle, and
+// reconnected with exponential backoff after failures. The number of concurrent operations on each server is bounded.
+type ConnectionPool struct {
+	lock                    sync.Mutex
+	entries                 map[types.NamespacedName]*poolEntry
+	maxConcurrentOperations int
+	healthCheckInterval     time.Duration
+}
+
+func NewConnectionPool(maxConcurrentOperations int, healthCheckInterval time.Duration) *ConnectionPool {
+	return &ConnectionPool{
+		entries:                 make(map[types.NamespacedName]*poolEntry),
+		maxConcurrentOperations: lo.Max([]int{maxConcurrentOperations, 1}),
+		healthCheckInterval:     healthCheckInterval,
+	}
+}
+
+func (p *ConnectionPool) getEntry(serverName types.NamespacedName) *poolEntry {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	entry, ok := p.entries[serverName]
+	if !ok {
+		entry = &poolEntry{operations: make(chan struct{}, p.maxConcurrentOperations)}
+		p.entries[serverName] = entry
+	}
+	return entry
+}
+
+// acquire leases a connection to the server, connecting if there is no connection with the given fingerprint. The
+// returned release function must be called once the connection is no longer used.
+func (p *ConnectionPool) acquire(serverName types.NamespacedName, fingerprint string, connect connectFunc) (sarama.ClusterAdmin, string, func(), error) {
+	entry := p.getEntry(serverName)
+	select {
+	case entry.operations <- struct{}{}:
+	case <-time.After(connectionAcquireTimeout):
+		return nil, "", nil, fmt.Errorf("%w: %s", ErrKafkaServerBusy, serverName)
+	}
+
+	connection, err := p.getConnection(serverName, entry, fingerprint, connect)
+	if err != nil {
+		<-entry.operations
+		return nil, "", nil, err
+	}
+
+	var releaseOnce sync.Once
+	release := func() {
+		releaseOnce.Do(func() {
+			entry.lock.Lock()
+			connection.leases--
+			connection.lastUsed = time.Now()
+			if connection.retired && connection.leases == 0 {
+				connection.close()
+			}
+			entry.lock.Unlock()
+			<-entry.operations
+		})
+	}
+	return connection.admin, connection.usernameMapping, release, nil
+}
+
+func (p *ConnectionPool) getConnection(serverName types.NamespacedName, entry *poolEntry, fingerprint string, connect connectFunc) (*pooledConnection, error) {
+	entry.lock.Lock()
+	defer entry.lock.Unlock()
+	logger := logrus.WithField("server", serverName)
+
+	if entry.connection != nil && entry.connection.fingerprint != fingerprint {
+		logger.Info("Kafka server connection configuration or certificates changed, reconnecting")
+		entry.connection.retire()
+		entry.connection = nil
+		// The previous failures were of the previous configuration.
+		entry.failures = 0
+		entry.nextConnect = time.Time{}
+	}
+
+	if entry.connection == nil {
+		if time.Now().Before(entry.nextConnect) {
+			return nil, fmt.Errorf("%w %s until %s, last error: %w", ErrKafkaServerBackoff, serverName, entry.nextConnect.Format(time.RFC3339), entry.lastErr)
+		}
+		admin, usernameMapping, err := connect()
+		if err != nil {
+			entry.connectFailed(err)
+			return nil, err
+		}
+		entry.failures = 0
+		entry.connection = &pooledConnection{admin: admin, usernameMapping: usernameMapping, fingerprint: fingerprint, retired: entry.removed}
+	}
+
+	entry.connection.leases++
+	entry.connection.lastUsed = time.Now()
+	return entry.connection, nil
+}
+
+// Remove closes the connection to the server once it is no longer used.
+func (p *ConnectionPool) Remove(serverName types.NamespacedName) {
+	p.lock.Lock()
+	entry, ok := p.entries[serverName]
+	delete(p.entries, serverName)
+	p.lock.Unlock()
+	if !ok {
+		return
+	}
+
+	entry.lock.Lock()
+	defer entry.lock.Unlock()
+	entry.removed = true
+	if entry.connection != nil {
+		entry.connection.retire()
+		entry.connection = nil
+	}
+}
+
+// Start implements manager.Runnable, health checking idle connections until ctx is done and then closing them. A zero
+// health check interval disables health checks and closing idle connections.
+func (p *ConnectionPool) Start(ctx context.Context) error {
+	if p.healthCheckInterval <= 0 {
+		<-ctx.Done()
+		p.closeAll()
+		return nil
+	}
+
+	ticker := time.NewTicker(p.healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.closeAll()
+			return nil
+		case <-ticker.C:
+			p.checkConnections()
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Connections are only made by the leader's reconcilers,
+// but are closed on shutdown by every replica.
+func (p *ConnectionPool) NeedLeaderElection() bool {
+	return false
+}
+
+func (p *ConnectionPool) checkConnections() {
+	p.lock.Lock()
+	entries := lo.Assign(p.entries)
+	p.lock.Unlock()
+
+	for serverName, entry := range entries {
+		if p.checkConnection(serverName, entry) {
+			continue
+		}
+		p.lock.Lock()
+		if p.entries[serverName] == entry {
+			delete(p.entries, serverName)
+		}
+		p.lock.Unlock()
+	}
+}
+
+// checkConnection health checks the server's connection while it is not in use, closing it if the check fails or it
+// has been idle for connectionIdleTimeout. Returns false if the entry was removed as its connection was idle.
+// The check is made without holding the entry's lock, so that it does not block operations on the server, and the
+// connection is leased meanwhile so that it is not closed under the check if it is replaced concurrently.
+func (p *ConnectionPool) checkConnection(serverName types.NamespacedName, entry *poolEntry) bool {
+	entry.lock.Lock()
+	connection := entry.connection
+	if connection == nil || connection.leases != 0 {
+		entry.lock.Unlock()
+		return true
+	}
+
+	if time.Since(connection.lastUsed) > connectionIdleTimeout {
+		logrus.WithField("server", serverName).Debug("Closing idle Kafka server connection")
+		connection.close()
+		entry.connection = nil
+		entry.removed = true
+		entry.lock.Unlock()
+		return false
+	}
+	connection.leases++
+	entry.lock.Unlock()
+
+	_, _, err := connection.admin.DescribeCluster()
+
+	entry.lock.Lock()
+	defer entry.lock.Unlock()
+	connection.leases--
+	if err != nil && entry.connection == connection {
+		logrus.WithError(err).WithField("server", serverName).Warning("Kafka server connection health check failed, reconnecting")
+		entry.connection = nil
+		entry.connectFailed(err)
+		connection.retire()
+		return true
+	}
+
+	// The connection may have been replaced or removed during the check, in which case it is closed once unused.
+	if connection.retired && connection.leases == 0 {
+		connection.close()
+	}
+	return true
+}
+
+func (p *ConnectionPool) closeAll() {
+	p.lock.Lock()
+	serverNames := lo.Keys(p.entries)
+	p.lock.Unlock()
+	for _, serverName := range serverNames {
+		p.Remove(serverName)
+	}
+}
+
+// connectionFingerprint identifies the configuration of the connection to the Kafka server, including the contents of
+// its TLS files, so that connections are replaced when certificates are rotated on disk. Changes to SASL credentials in
+// the server's secret take effect once the connection fails authentication and is reconnected.
+func connectionFingerprint(kafkaServer otterizev1alpha3.KafkaServerConfig, defaultTls otterizev1alpha3.TLSSource) (string, error) {
+	tlsSource := kafkaServer.Spec.TLS
+	if lo.IsEmpty(tlsSource) && kafkaServer.Spec.Auth == nil {
+		tlsSource = defaultTls
+	}
+
+	config, err := json.Marshal([]any{kafkaServer.Spec.Addr, kafkaServer.Spec.Auth, tlsSource})
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	hash.Write(config)
+	for _, path := range []string{tlsSource.CertFile, tlsSource.KeyFile, tlsSource.RootCAFile} {
+		if path == "" {
+			continue
+		}
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			// Connecting fails as well, and is retried once the file is readable.
+			contents = []byte(err.Error())
+		}
+		hash.Write(contents)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
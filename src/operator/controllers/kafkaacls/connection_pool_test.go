@@ -0,0 +1,217 @@
+package kafkaacls
+
+import (
+	"errors"
+	"github.com/Shopify/sarama"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	kafkaaclsmocks "github.com/otterize/intents-operator/src/operator/controllers/kafkaacls/mocks"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	"k8s.io/apimachinery/pkg/types"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var poolServerName = types.NamespacedName{Name: serverName, Namespace: testNamespace}
+
+type ConnectionPoolSuite struct {
+	suite.Suite
+	controller   *gomock.Controller
+	pool         *ConnectionPool
+	connectCount int
+	connectErr   error
+	admins       []*kafkaaclsmocks.MockClusterAdmin
+}
+
+func (s *ConnectionPoolSuite) SetupTest() {
+	s.controller = gomock.NewController(s.T())
+	s.pool = NewConnectionPool(1, time.Minute)
+	s.connectCount = 0
+	s.connectErr = nil
+	s.admins = nil
+}
+
+func (s *ConnectionPoolSuite) connect() (sarama.ClusterAdmin, string, error) {
+	s.connectCount++
+	if s.connectErr != nil {
+		return nil, "", s.connectErr
+	}
+	admin := kafkaaclsmocks.NewMockClusterAdmin(s.controller)
+	s.admins = append(s.admins, admin)
+	return admin, "CN=$ServiceName.$Namespace", nil
+}
+
+func (s *ConnectionPoolSuite) TestConnectionIsReused() {
+	for i := 0; i < 3; i++ {
+		admin, usernameMapping, release, err := s.pool.acquire(poolServerName, "fingerprint", s.connect)
+		s.Require().NoError(err)
+		s.Require().Equal("CN=$ServiceName.$Namespace", usernameMapping)
+		s.Require().Equal(s.admins[0], admin)
+		release()
+	}
+	s.Require().Equal(1, s.connectCount)
+
+	s.admins[0].EXPECT().Close().Return(nil)
+	s.pool.Remove(poolServerName)
+}
+
+func (s *ConnectionPoolSuite) TestFingerprintChangeReconnectsOnceReleased() {
+	s.pool = NewConnectionPool(2, time.Minute)
+	_, _, releaseFirst, err := s.pool.acquire(poolServerName, "fingerprint", s.connect)
+	s.Require().NoError(err)
+
+	// The previous connection is still in use, so it is only closed once released
+	admin, _, releaseSecond, err := s.pool.acquire(poolServerName, "rotated-fingerprint", s.connect)
+	s.Require().NoError(err)
+	s.Require().Equal(2, s.connectCount)
+	s.Require().Equal(s.admins[1], admin)
+
+	s.admins[0].EXPECT().Close().Return(nil)
+	releaseFirst()
+	releaseSecond()
+}
+
+func (s *ConnectionPoolSuite) TestConnectBackoff() {
+	s.connectErr = errors.New("connection refused")
+	_, _, _, err := s.pool.acquire(poolServerName, "fingerprint", s.connect)
+	s.Require().ErrorIs(err, s.connectErr)
+
+	_, _, _, err = s.pool.acquire(poolServerName, "fingerprint", s.connect)
+	s.Require().ErrorIs(err, ErrKafkaServerBackoff)
+	s.Require().Equal(1, s.connectCount)
+}
+
+func (s *ConnectionPoolSuite) TestConcurrentOperationsAreBounded() {
+	_, _, release, err := s.pool.acquire(poolServerName, "fingerprint", s.connect)
+	s.Require().NoError(err)
+
+	acquired := make(chan func())
+	go func() {
+		_, _, release, err := s.pool.acquire(poolServerName, "fingerprint", s.connect)
+		s.NoError(err)
+		acquired <- release
+	}()
+
+	select {
+	case <-acquired:
+		s.Fail("acquired more connections than the maximum number of concurrent operations")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	release()
+	select {
+	case release := <-acquired:
+		release()
+	case <-time.After(time.Second):
+		s.Fail("connection was not acquired once released")
+	}
+}
+
+func (s *ConnectionPoolSuite) TestHealthCheckFailureReconnects() {
+	_, _, release, err := s.pool.acquire(poolServerName, "fingerprint", s.connect)
+	s.Require().NoError(err)
+	release()
+
+	s.admins[0].EXPECT().DescribeCluster().Return(nil, int32(0), errors.New("broker not available"))
+	s.admins[0].EXPECT().Close().Return(nil)
+	s.pool.checkConnections()
+
+	// The reconnect backs off after the failed health check
+	_, _, _, err = s.pool.acquire(poolServerName, "fingerprint", s.connect)
+	s.Require().ErrorIs(err, ErrKafkaServerBackoff)
+	s.Require().Equal(1, s.connectCount)
+}
+
+func (s *ConnectionPoolSuite) TestHealthCheckKeepsHealthyConnection() {
+	_, _, release, err := s.pool.acquire(poolServerName, "fingerprint", s.connect)
+	s.Require().NoError(err)
+	release()
+
+	s.admins[0].EXPECT().DescribeCluster().Return(nil, int32(1), nil)
+	s.pool.checkConnections()
+
+	_, _, release, err = s.pool.acquire(poolServerName, "fingerprint", s.connect)
+	s.Require().NoError(err)
+	release()
+	s.Require().Equal(1, s.connectCount)
+}
+
+func (s *ConnectionPoolSuite) TestHealthCheckDoesNotBlockOperations() {
+	s.pool = NewConnectionPool(2, time.Minute)
+	_, _, release, err := s.pool.acquire(poolServerName, "fingerprint", s.connect)
+	s.Require().NoError(err)
+	release()
+
+	s.admins[0].EXPECT().DescribeCluster().DoAndReturn(func() ([]*sarama.Broker, int32, error) {
+		acquired := make(chan func())
+		go func() {
+			_, _, release, err := s.pool.acquire(poolServerName, "fingerprint", s.connect)
+			s.NoError(err)
+			acquired <- release
+		}()
+
+		select {
+		case release := <-acquired:
+			release()
+		case <-time.After(time.Second):
+			s.Fail("connection was not acquired during the health check")
+		}
+		return nil, int32(1), nil
+	})
+	s.pool.checkConnections()
+	s.Require().Equal(1, s.connectCount)
+}
+
+func (s *ConnectionPoolSuite) TestConnectionReplacedDuringHealthCheckIsClosedAfterIt() {
+	s.pool = NewConnectionPool(2, time.Minute)
+	_, _, release, err := s.pool.acquire(poolServerName, "fingerprint", s.connect)
+	s.Require().NoError(err)
+	release()
+
+	s.admins[0].EXPECT().DescribeCluster().DoAndReturn(func() ([]*sarama.Broker, int32, error) {
+		// The checked connection is replaced, but is not closed while the check uses it
+		_, _, release, err := s.pool.acquire(poolServerName, "rotated-fingerprint", s.connect)
+		s.Require().NoError(err)
+		release()
+		s.admins[0].EXPECT().Close().Return(nil)
+		return nil, int32(0), errors.New("broker not available")
+	})
+	s.pool.checkConnections()
+
+	// The failed check does not discard the connection that replaced the checked one
+	admin, _, release, err := s.pool.acquire(poolServerName, "rotated-fingerprint", s.connect)
+	s.Require().NoError(err)
+	s.Require().Equal(s.admins[1], admin)
+	release()
+	s.Require().Equal(2, s.connectCount)
+}
+
+func (s *ConnectionPoolSuite) TestFingerprintChangesWithTLSFiles() {
+	dir := s.T().TempDir()
+	tlsSource := otterizev1alpha3.TLSSource{
+		CertFile:   filepath.Join(dir, "cert.pem"),
+		KeyFile:    filepath.Join(dir, "key.pem"),
+		RootCAFile: filepath.Join(dir, "ca.pem"),
+	}
+	for _, path := range []string{tlsSource.CertFile, tlsSource.KeyFile, tlsSource.RootCAFile} {
+		s.Require().NoError(os.WriteFile(path, []byte("original"), 0600))
+	}
+	kafkaServer := otterizev1alpha3.KafkaServerConfig{Spec: otterizev1alpha3.KafkaServerConfigSpec{Addr: serverAddress}}
+
+	fingerprint, err := connectionFingerprint(kafkaServer, tlsSource)
+	s.Require().NoError(err)
+	unchangedFingerprint, err := connectionFingerprint(kafkaServer, tlsSource)
+	s.Require().NoError(err)
+	s.Require().Equal(fingerprint, unchangedFingerprint)
+
+	s.Require().NoError(os.WriteFile(tlsSource.CertFile, []byte("rotated"), 0600))
+	rotatedFingerprint, err := connectionFingerprint(kafkaServer, tlsSource)
+	s.Require().NoError(err)
+	s.Require().NotEqual(fingerprint, rotatedFingerprint)
+}
+
+func TestConnectionPoolSuite(t *testing.T) {
+	suite.Run(t, new(ConnectionPoolSuite))
+}
@@ -0,0 +1,83 @@
+package kafkaacls
+
+import (
+	"fmt"
+	"github.com/Shopify/sarama"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+)
+
+// consumerGroupOperations are the operations on a consumer group that consuming with it requires. Topic level
+// privileges are granted separately: https://kafka.apache.org/documentation/#operations_resources_and_protocols
+var consumerGroupOperations = []sarama.AclOperation{sarama.AclOperationRead, sarama.AclOperationDescribe}
+
+func (a *KafkaIntentsAdminImpl) queryAppliedConsumerGroupACLs(principal string) (map[sarama.Resource][]sarama.Acl, error) {
+	principalAcls, err := a.kafkaAdminClient.ListAcls(sarama.AclFilter{
+		ResourceType:              sarama.AclResourceGroup,
+		Principal:                 &principal,
+		ResourcePatternTypeFilter: sarama.AclPatternAny,
+		PermissionType:            sarama.AclPermissionAllow,
+		Operation:                 sarama.AclOperationAny,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed listing consumer group ACLs on server: %w", err)
+	}
+
+	resourceToAcls := map[sarama.Resource][]sarama.Acl{}
+	for _, resourceAcls := range principalAcls {
+		resourceToAcls[resourceAcls.Resource] = append(
+			resourceToAcls[resourceAcls.Resource],
+			lo.Map(resourceAcls.Acls, func(acl *sarama.Acl, _ int) sarama.Acl {
+				return lo.FromPtr(acl)
+			})...,
+		)
+	}
+	return resourceToAcls, nil
+}
+
+func (a *KafkaIntentsAdminImpl) collectConsumerGroupsToACLList(principal string, consumerGroups []otterizev1alpha3.KafkaConsumerGroup) map[sarama.Resource][]sarama.Acl {
+	resourceToAcls := map[sarama.Resource][]sarama.Acl{}
+	for _, consumerGroup := range consumerGroups {
+		pattern := lo.Ternary(consumerGroup.Pattern == "", otterizev1alpha3.ResourcePatternTypeLiteral, consumerGroup.Pattern)
+		resource := sarama.Resource{
+			ResourceType:        sarama.AclResourceGroup,
+			ResourceName:        consumerGroup.Name,
+			ResourcePatternType: kafkaPatternTypeToSaramaPatternType[pattern],
+		}
+		for _, operation := range consumerGroupOperations {
+			acl := sarama.Acl{
+				Principal:      principal,
+				Host:           "*",
+				Operation:      operation,
+				PermissionType: sarama.AclPermissionAllow,
+			}
+			if !lo.Contains(resourceToAcls[resource], acl) {
+				resourceToAcls[resource] = append(resourceToAcls[resource], acl)
+			}
+		}
+	}
+	return resourceToAcls
+}
+
+// reconcileConsumerGroupWildcardACLs allows all users to use all consumer groups, unless the server opts in to
+// restricting clients to the consumer groups declared in their intents.
+func (a *KafkaIntentsAdminImpl) reconcileConsumerGroupWildcardACLs() error {
+	logger := logrus.WithFields(
+		logrus.Fields{
+			"serverName":      a.kafkaServer.Spec.Service,
+			"serverNamespace": a.kafkaServer.Namespace,
+		})
+
+	if !a.kafkaServer.Spec.RestrictConsumerGroups {
+		logger.Info("ensuring consumer group permissions")
+		return a.ensureConsumerGroupWildcardACLs()
+	}
+
+	deletedRulesCount, err := a.deleteConsumerGroupWildcardACLs()
+	if err != nil {
+		return err
+	}
+	logger.Infof("Consumer groups are restricted to those declared in intents, %d wildcard consumer group acl rules were deleted", deletedRulesCount)
+	return nil
+}
@@ -0,0 +1,147 @@
+package kafkaacls
+
+import (
+	"github.com/Shopify/sarama"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	kafkaaclsmocks "github.com/otterize/intents-operator/src/operator/controllers/kafkaacls/mocks"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+const (
+	clientName        = "my-client"
+	consumerGroupName = "my-group"
+)
+
+type ConsumerGroupsSuite struct {
+	suite.Suite
+	mockClusterAdmin *kafkaaclsmocks.MockClusterAdmin
+	intentsAdmin     KafkaIntentsAdmin
+}
+
+func (s *ConsumerGroupsSuite) SetupTest() {
+	controller := gomock.NewController(s.T())
+	s.mockClusterAdmin = kafkaaclsmocks.NewMockClusterAdmin(controller)
+	kafkaServerConfig := otterizev1alpha3.KafkaServerConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kafkaServerConfigResourceName,
+			Namespace: testNamespace,
+		},
+		Spec: otterizev1alpha3.KafkaServerConfigSpec{
+			Service: otterizev1alpha3.Service{Name: serverName},
+			Addr:    serverAddress,
+		},
+	}
+	s.intentsAdmin = NewKafkaIntentsAdminImpl(kafkaServerConfig, s.mockClusterAdmin, "$ServiceName.$Namespace", true, true)
+}
+
+func (s *ConsumerGroupsSuite) expectLogACLs() {
+	s.mockClusterAdmin.EXPECT().ListAcls(sarama.AclFilter{
+		ResourceType:              sarama.AclResourceAny,
+		ResourcePatternTypeFilter: sarama.AclPatternAny,
+		PermissionType:            sarama.AclPermissionAny,
+		Operation:                 sarama.AclOperationAny,
+	}).Return([]sarama.ResourceAcls{}, nil)
+}
+
+func (s *ConsumerGroupsSuite) TestApplyClientIntentsCreatesConsumerGroupACLs() {
+	principal := "User:my-client.test-namespace"
+	groupResource := sarama.Resource{
+		ResourceType:        sarama.AclResourceGroup,
+		ResourceName:        consumerGroupName,
+		ResourcePatternType: sarama.AclPatternPrefixed,
+	}
+	expectedACLs := []*sarama.ResourceAcls{
+		{
+			Resource: groupResource,
+			Acls: lo.Map(consumerGroupOperations, func(operation sarama.AclOperation, _ int) *sarama.Acl {
+				return &sarama.Acl{Principal: principal, Host: "*", Operation: operation, PermissionType: sarama.AclPermissionAllow}
+			}),
+		},
+	}
+
+	gomock.InOrder(
+		s.mockClusterAdmin.EXPECT().ListAcls(sarama.AclFilter{
+			ResourceType:              sarama.AclResourceAny,
+			Principal:                 lo.ToPtr(principal),
+			ResourcePatternTypeFilter: sarama.AclPatternAny,
+			PermissionType:            sarama.AclPermissionAllow,
+			Operation:                 sarama.AclOperationAny,
+		}).Return([]sarama.ResourceAcls{}, nil),
+		s.mockClusterAdmin.EXPECT().ListAcls(sarama.AclFilter{
+			ResourceType:              sarama.AclResourceGroup,
+			Principal:                 lo.ToPtr(principal),
+			ResourcePatternTypeFilter: sarama.AclPatternAny,
+			PermissionType:            sarama.AclPermissionAllow,
+			Operation:                 sarama.AclOperationAny,
+		}).Return([]sarama.ResourceAcls{}, nil),
+		s.mockClusterAdmin.EXPECT().CreateACLs(MatchResourceAcls(expectedACLs)).Return(nil),
+	)
+	s.expectLogACLs()
+
+	intents := []otterizev1alpha3.Intent{
+		{
+			Name: serverName,
+			Type: otterizev1alpha3.IntentTypeKafka,
+			ConsumerGroups: []otterizev1alpha3.KafkaConsumerGroup{
+				{Name: consumerGroupName, Pattern: otterizev1alpha3.ResourcePatternTypePrefix},
+			},
+		},
+	}
+	err := s.intentsAdmin.ApplyClientIntents(clientName, testNamespace, "", intents)
+	s.Require().NoError(err)
+}
+
+func (s *ConsumerGroupsSuite) TestApplyServerConfigDeletesWildcardWhenConsumerGroupsRestricted() {
+	kafkaServerConfig := otterizev1alpha3.KafkaServerConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kafkaServerConfigResourceName,
+			Namespace: testNamespace,
+		},
+		Spec: otterizev1alpha3.KafkaServerConfigSpec{
+			Service:                otterizev1alpha3.Service{Name: serverName},
+			Addr:                   serverAddress,
+			RestrictConsumerGroups: true,
+		},
+	}
+	s.intentsAdmin = NewKafkaIntentsAdminImpl(kafkaServerConfig, s.mockClusterAdmin, "$ServiceName.$Namespace", true, true)
+
+	gomock.InOrder(
+		s.mockClusterAdmin.EXPECT().ListAcls(gomock.Any()).Return([]sarama.ResourceAcls{}, nil).Times(2),
+		// The default topic configuration is applied as the server has none.
+		s.mockClusterAdmin.EXPECT().CreateACLs(gomock.Any()).Return(nil),
+		s.mockClusterAdmin.EXPECT().DeleteACL(sarama.AclFilter{
+			ResourceType:              sarama.AclResourceGroup,
+			ResourceName:              lo.ToPtr("*"),
+			ResourcePatternTypeFilter: sarama.AclPatternLiteral,
+			PermissionType:            sarama.AclPermissionAllow,
+			Principal:                 lo.ToPtr(AnyUserPrincipalName),
+			Operation:                 sarama.AclOperationAny,
+		}, false).Return([]sarama.MatchingAcl{{}, {}}, nil),
+	)
+	s.expectLogACLs()
+
+	err := s.intentsAdmin.ApplyServerTopicsConf(nil)
+	s.Require().NoError(err)
+}
+
+func (s *ConsumerGroupsSuite) TestApplyServerConfigKeepsWildcardByDefault() {
+	gomock.InOrder(
+		s.mockClusterAdmin.EXPECT().ListAcls(gomock.Any()).Return([]sarama.ResourceAcls{}, nil).Times(2),
+		// The default topic configuration is applied as the server has none.
+		s.mockClusterAdmin.EXPECT().CreateACLs(gomock.Any()).Return(nil),
+		// Clients may rely on the wildcard, so it is kept unless consumer groups are explicitly restricted.
+		s.mockClusterAdmin.EXPECT().CreateACLs(MatchResourceAcls([]*sarama.ResourceAcls{lo.ToPtr(getAclOperatorGroupPermission())})).Return(nil),
+	)
+	s.expectLogACLs()
+
+	err := s.intentsAdmin.ApplyServerTopicsConf(nil)
+	s.Require().NoError(err)
+}
+
+func TestConsumerGroupsSuite(t *testing.T) {
+	suite.Run(t, new(ConsumerGroupsSuite))
+}
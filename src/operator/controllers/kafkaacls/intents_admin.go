@@ -1,8 +1,10 @@
 package kafkaacls
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"github.com/Shopify/sarama"
 	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
@@ -10,9 +12,11 @@ import (
 	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
 	"github.com/vishalkuo/bimap"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"log"
 	"os"
-	"regexp"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"strings"
 )
 
@@ -23,29 +27,36 @@ type TopicToACLList map[sarama.Resource][]sarama.Acl
 const (
 	AnonymousUserPrincipalName = "User:ANONYMOUS"
 	AnyUserPrincipalName       = "User:*"
-)
-
-var (
-	serviceNameRE = regexp.MustCompile(`\$ServiceName`)
-	namespaceRE   = regexp.MustCompile(`\$Namespace`)
+	// KafkaClusterResourceName is the name of the cluster resource, which cluster level ACLs apply to.
+	KafkaClusterResourceName = "kafka-cluster"
 )
 
 type KafkaIntentsAdmin interface {
 	ApplyServerTopicsConf(topicsConf []otterizev1alpha3.TopicConfig) error
-	ApplyClientIntents(clientName string, clientNamespace string, intents []otterizev1alpha3.Intent) error
-	RemoveClientIntents(clientName string, clientNamespace string) error
+	ApplyClientIntents(clientName string, clientNamespace string, clientServiceAccount string, intents []otterizev1alpha3.Intent) error
+	ApplyClientQuotas(clientName string, clientNamespace string, clientServiceAccount string, quotas *otterizev1alpha3.KafkaQuotas) (otterizev1alpha3.KafkaQuotas, error)
+	RemoveClientIntents(clientName string, clientNamespace string, clientServiceAccount string) error
 	RemoveServerIntents(topicsConf []otterizev1alpha3.TopicConfig) error
+	RemoveOrphanedClientACLs(managedPrincipals []string, activeClients []types.NamespacedName, clientServiceAccounts map[types.NamespacedName]string, dryRun bool) (int, error)
+	FormatClientPrincipal(clientName string, clientNamespace string, clientServiceAccount string) string
+	ResyncACLs(clientIntents map[types.NamespacedName][]otterizev1alpha3.Intent, clientServiceAccounts map[types.NamespacedName]string, repair bool) (otterizev1alpha3.KafkaACLDrift, error)
+	ApplyTopics(topicManagement otterizev1alpha3.KafkaTopicManagement, createdTopics []string, referencedTopics []string) (otterizev1alpha3.KafkaTopicsStatus, error)
 	Close()
 }
 
 type KafkaIntentsAdminImpl struct {
 	kafkaServer                 otterizev1alpha3.KafkaServerConfig
 	kafkaAdminClient            sarama.ClusterAdmin
-	userNameMapping             string
+	principalTemplate           string
+	clusterName                 string
 	enableKafkaACLCreation      bool
 	enforcementEnabledForServer bool
+	// release returns the pooled connection instead of closing it, when set.
+	release func()
 }
 
+var ErrKafkaResourceNotAllowed = errors.New("access not allowed by the Kafka server configuration")
+
 var (
 	kafkaOperationToAclOperation = map[otterizev1alpha3.KafkaOperation]sarama.AclOperation{
 		otterizev1alpha3.KafkaOperationAll:             sarama.AclOperationAll,
@@ -120,7 +131,74 @@ func getUserPrincipalMapping(tlsCert tls.Certificate) (string, error) {
 
 }
 
+// NewKafkaIntentsAdminFactory returns a factory that reads the SASL credentials of Kafka servers configured with auth
+// from their secrets using k8sClient, and manages the KafkaUsers of servers configured with Strimzi. clusterName is
+// substituted for $Cluster in principal templates.
+func NewKafkaIntentsAdminFactory(k8sClient client.Client, clusterName string) IntentsAdminFactoryFunction {
+	return NewPooledKafkaIntentsAdminFactory(k8sClient, clusterName, nil)
+}
+
+// NewPooledKafkaIntentsAdminFactory is like NewKafkaIntentsAdminFactory, but the KafkaIntentsAdmins it returns share
+// the connections of connectionPool, and release them when closed.
+func NewPooledKafkaIntentsAdminFactory(k8sClient client.Client, clusterName string, connectionPool *ConnectionPool) IntentsAdminFactoryFunction {
+	return func(kafkaServer otterizev1alpha3.KafkaServerConfig, defaultTls otterizev1alpha3.TLSSource, enableKafkaACLCreation bool, enforcementEnabledForServer bool) (KafkaIntentsAdmin, error) {
+		return newKafkaIntentsAdmin(k8sClient, clusterName, connectionPool, kafkaServer, defaultTls, enableKafkaACLCreation, enforcementEnabledForServer)
+	}
+}
+
+// NewKafkaIntentsAdmin connects to Kafka servers using mTLS. Servers configured with SASL auth or Strimzi require
+// NewKafkaIntentsAdminFactory.
 func NewKafkaIntentsAdmin(kafkaServer otterizev1alpha3.KafkaServerConfig, defaultTls otterizev1alpha3.TLSSource, enableKafkaACLCreation bool, enforcementEnabledForServer bool) (KafkaIntentsAdmin, error) {
+	return newKafkaIntentsAdmin(nil, "", nil, kafkaServer, defaultTls, enableKafkaACLCreation, enforcementEnabledForServer)
+}
+
+func newKafkaIntentsAdmin(k8sClient client.Client, clusterName string, connectionPool *ConnectionPool, kafkaServer otterizev1alpha3.KafkaServerConfig, defaultTls otterizev1alpha3.TLSSource, enableKafkaACLCreation bool, enforcementEnabledForServer bool) (KafkaIntentsAdmin, error) {
+	logger := logrus.WithField("addr", kafkaServer.Spec.Addr)
+	if kafkaServer.Spec.PrincipalTemplate != "" {
+		// The webhook rejects invalid templates, but may be disabled.
+		if err := ValidatePrincipalTemplate(kafkaServer.Spec.PrincipalTemplate); err != nil {
+			return nil, err
+		}
+	}
+
+	if kafkaServer.Spec.Strimzi != nil {
+		if k8sClient == nil {
+			return nil, ErrStrimziNotSupported
+		}
+		logger.Infof("Managing ACLs through KafkaUsers of Strimzi cluster %s", kafkaServer.Spec.Strimzi.ClusterName)
+		return newStrimziIntentsAdmin(k8sClient, kafkaServer, clusterName, enableKafkaACLCreation, enforcementEnabledForServer), nil
+	}
+
+	connect := func() (sarama.ClusterAdmin, string, error) {
+		return connectKafkaServer(k8sClient, kafkaServer, defaultTls)
+	}
+	if connectionPool == nil {
+		saramaAdminClient, usernameMapping, err := connect()
+		if err != nil {
+			return nil, err
+		}
+		intentsAdmin := newKafkaIntentsAdminImpl(kafkaServer, saramaAdminClient, usernameMapping, enableKafkaACLCreation, enforcementEnabledForServer)
+		intentsAdmin.clusterName = clusterName
+		return intentsAdmin, nil
+	}
+
+	fingerprint, err := connectionFingerprint(kafkaServer, defaultTls)
+	if err != nil {
+		return nil, err
+	}
+	serverName := types.NamespacedName{Name: kafkaServer.Spec.Service.Name, Namespace: kafkaServer.Namespace}
+	saramaAdminClient, usernameMapping, release, err := connectionPool.acquire(serverName, fingerprint, connect)
+	if err != nil {
+		return nil, err
+	}
+	intentsAdmin := newKafkaIntentsAdminImpl(kafkaServer, saramaAdminClient, usernameMapping, enableKafkaACLCreation, enforcementEnabledForServer)
+	intentsAdmin.clusterName = clusterName
+	intentsAdmin.release = release
+	return intentsAdmin, nil
+}
+
+// connectKafkaServer connects to the Kafka server, and returns the username mapping of the default principal template.
+func connectKafkaServer(k8sClient client.Client, kafkaServer otterizev1alpha3.KafkaServerConfig, defaultTls otterizev1alpha3.TLSSource) (sarama.ClusterAdmin, string, error) {
 	logger := logrus.WithField("addr", kafkaServer.Spec.Addr)
 	logger.Info("Connecting to kafka server")
 	addrs := []string{kafkaServer.Spec.Addr}
@@ -128,6 +206,42 @@ func NewKafkaIntentsAdmin(kafkaServer otterizev1alpha3.KafkaServerConfig, defaul
 	config := sarama.NewConfig()
 	config.Version = sarama.V2_0_0_0
 
+	var usernameMapping string
+	var err error
+	if kafkaServer.Spec.Auth != nil {
+		logger.Infof("Using SASL %s authentication", kafkaServer.Spec.Auth.SASLMechanism)
+		usernameMapping, err = configureSASL(context.Background(), k8sClient, kafkaServer, config)
+		if err != nil {
+			return nil, "", err
+		}
+	} else {
+		usernameMapping, err = configureMTLS(kafkaServer, defaultTls, config)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	sarama.Logger = log.New(os.Stdout, "[sarama] ", log.LstdFlags)
+
+	// Describing and altering client quotas requires Kafka 2.6, so the newer protocol is only used with servers
+	// that support it.
+	supported, err := quotasSupported(kafkaServer.Spec.Addr, config)
+	if err != nil {
+		logger.WithError(err).Warning("Failed detecting whether the Kafka server supports client quotas")
+	} else if supported {
+		config.Version = sarama.V2_6_0_0
+	}
+
+	saramaAdminClient, err := sarama.NewClusterAdmin(addrs, config)
+	if err != nil {
+		return nil, "", err
+	}
+	return saramaAdminClient, usernameMapping, nil
+}
+
+func configureMTLS(kafkaServer otterizev1alpha3.KafkaServerConfig, defaultTls otterizev1alpha3.TLSSource, config *sarama.Config) (string, error) {
+	logger := logrus.WithField("addr", kafkaServer.Spec.Addr)
+
 	var tlsSource otterizev1alpha3.TLSSource
 	if lo.IsEmpty(kafkaServer.Spec.TLS) {
 		tlsSource = defaultTls
@@ -139,47 +253,108 @@ func NewKafkaIntentsAdmin(kafkaServer otterizev1alpha3.KafkaServerConfig, defaul
 
 	tlsConfig, err := getTLSConfig(tlsSource)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
 	usernameMapping, err := getUserPrincipalMapping(tlsConfig.Certificates[0])
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
 	config.Net.TLS.Config = tlsConfig
 	config.Net.TLS.Enable = true
-
-	sarama.Logger = log.New(os.Stdout, "[sarama] ", log.LstdFlags)
-
-	saramaAdminClient, err := sarama.NewClusterAdmin(addrs, config)
-	if err != nil {
-		return nil, err
-	}
-
-	return NewKafkaIntentsAdminImpl(kafkaServer, saramaAdminClient, usernameMapping, enableKafkaACLCreation, enforcementEnabledForServer), nil
+	return usernameMapping, nil
 }
 
+// NewKafkaIntentsAdminImpl formats client principals using the KafkaServerConfig's principal template, or as users
+// named by usernameMapping when it has none.
 func NewKafkaIntentsAdminImpl(kafkaServer otterizev1alpha3.KafkaServerConfig, saramaAdminClient sarama.ClusterAdmin, usernameMapping string, enableKafkaACLCreation bool, enforcementEnabledForServer bool) KafkaIntentsAdmin {
-	return &KafkaIntentsAdminImpl{kafkaServer: kafkaServer, kafkaAdminClient: saramaAdminClient, userNameMapping: usernameMapping, enableKafkaACLCreation: enableKafkaACLCreation, enforcementEnabledForServer: enforcementEnabledForServer}
+	return newKafkaIntentsAdminImpl(kafkaServer, saramaAdminClient, usernameMapping, enableKafkaACLCreation, enforcementEnabledForServer)
+}
+
+func newKafkaIntentsAdminImpl(kafkaServer otterizev1alpha3.KafkaServerConfig, saramaAdminClient sarama.ClusterAdmin, usernameMapping string, enableKafkaACLCreation bool, enforcementEnabledForServer bool) *KafkaIntentsAdminImpl {
+	principalTemplate := kafkaServer.Spec.PrincipalTemplate
+	if principalTemplate == "" {
+		principalTemplate = fmt.Sprintf("User:%s", usernameMapping)
+	}
+	return &KafkaIntentsAdminImpl{kafkaServer: kafkaServer, kafkaAdminClient: saramaAdminClient, principalTemplate: principalTemplate, enableKafkaACLCreation: enableKafkaACLCreation, enforcementEnabledForServer: enforcementEnabledForServer}
 }
 
 func (a *KafkaIntentsAdminImpl) Close() {
+	if a.release != nil {
+		a.release()
+		return
+	}
 	if err := a.kafkaAdminClient.Close(); err != nil {
 		logrus.WithError(err).Error("Error closing kafka admin client")
 	}
 }
 
-func (a *KafkaIntentsAdminImpl) formatPrincipal(clientName string, clientNamespace string) string {
-	username := a.userNameMapping
-	username = serviceNameRE.ReplaceAllString(username, clientName)
-	username = namespaceRE.ReplaceAllString(username, clientNamespace)
-	return fmt.Sprintf("User:%s", username)
+func (a *KafkaIntentsAdminImpl) formatPrincipal(client clientIdentity) string {
+	return formatPrincipalTemplate(a.principalTemplate, client, a.clusterName)
 }
 
-func (a *KafkaIntentsAdminImpl) queryAppliedIntentKafkaTopics(principal string) ([]otterizev1alpha3.KafkaTopic, error) {
+func (a *KafkaIntentsAdminImpl) FormatClientPrincipal(clientName string, clientNamespace string, clientServiceAccount string) string {
+	return a.formatPrincipal(clientIdentity{name: clientName, namespace: clientNamespace, serviceAccount: clientServiceAccount})
+}
+
+// intentResource is a Kafka resource a client accesses, and the operations it performs on it.
+type intentResource struct {
+	sarama.Resource
+	Operations []otterizev1alpha3.KafkaOperation
+}
+
+// checkIntentResourcesAllowed returns an error if the intents declare transactional IDs or cluster operations that the
+// server does not allow intents to grant, as such access is only granted to clients if the server opts in.
+func checkIntentResourcesAllowed(server otterizev1alpha3.KafkaServerConfigSpec, intents []otterizev1alpha3.Intent) error {
+	for _, intent := range intents {
+		if len(intent.TransactionalIDs) != 0 && !server.AllowTransactionalIDs {
+			return fmt.Errorf("%w: transactional IDs are not allowed by the KafkaServerConfig", ErrKafkaResourceNotAllowed)
+		}
+		for _, operation := range intent.ClusterOperations {
+			if !lo.Contains(server.AllowedClusterOperations, operation) {
+				return fmt.Errorf("%w: cluster operation %s is not in the allowed cluster operations of the KafkaServerConfig", ErrKafkaResourceNotAllowed, operation)
+			}
+		}
+	}
+	return nil
+}
+
+// getIntentResources returns the topics, transactional IDs and cluster operations of the intents, without the
+// transactional IDs and cluster operations the server does not allow, see checkIntentResourcesAllowed. Consumer groups
+// grant fixed operations, see collectConsumerGroupsToACLList.
+func getIntentResources(server otterizev1alpha3.KafkaServerConfigSpec, intents []otterizev1alpha3.Intent) []intentResource {
+	resources := make([]intentResource, 0)
+	for _, intent := range intents {
+		for _, topic := range intent.Topics {
+			resources = append(resources, intentResource{
+				Resource:   sarama.Resource{ResourceType: sarama.AclResourceTopic, ResourceName: topic.Name, ResourcePatternType: sarama.AclPatternLiteral},
+				Operations: topic.Operations,
+			})
+		}
+		if server.AllowTransactionalIDs {
+			for _, transactionalID := range intent.TransactionalIDs {
+				pattern := lo.Ternary(transactionalID.Pattern == "", otterizev1alpha3.ResourcePatternTypeLiteral, transactionalID.Pattern)
+				resources = append(resources, intentResource{
+					Resource:   sarama.Resource{ResourceType: sarama.AclResourceTransactionalID, ResourceName: transactionalID.Name, ResourcePatternType: kafkaPatternTypeToSaramaPatternType[pattern]},
+					Operations: transactionalID.Operations,
+				})
+			}
+		}
+		clusterOperations := lo.Intersect(server.AllowedClusterOperations, intent.ClusterOperations)
+		if len(clusterOperations) != 0 {
+			resources = append(resources, intentResource{
+				Resource:   sarama.Resource{ResourceType: sarama.AclResourceCluster, ResourceName: KafkaClusterResourceName, ResourcePatternType: sarama.AclPatternLiteral},
+				Operations: clusterOperations,
+			})
+		}
+	}
+	return resources
+}
+
+func (a *KafkaIntentsAdminImpl) queryAppliedIntentKafkaResources(principal string) ([]intentResource, error) {
 	principalAcls, err := a.kafkaAdminClient.ListAcls(sarama.AclFilter{
-		ResourceType:              sarama.AclResourceTopic,
+		ResourceType:              sarama.AclResourceAny,
 		Principal:                 &principal,
 		ResourcePatternTypeFilter: sarama.AclPatternAny,
 		PermissionType:            sarama.AclPermissionAllow,
@@ -189,36 +364,34 @@ func (a *KafkaIntentsAdminImpl) queryAppliedIntentKafkaTopics(principal string)
 		return nil, fmt.Errorf("failed listing ACLs on server: %w", err)
 	}
 
-	resourceAppliedKafkaTopics, err := lox.MapErr(principalAcls, func(acls sarama.ResourceAcls, _ int) (otterizev1alpha3.KafkaTopic, error) {
+	principalAcls = lo.Filter(principalAcls, func(acls sarama.ResourceAcls, _ int) bool {
+		return acls.ResourceType != sarama.AclResourceGroup
+	})
+	resourceAppliedKafkaResources, err := lox.MapErr(principalAcls, func(acls sarama.ResourceAcls, _ int) (intentResource, error) {
 		operations := make([]otterizev1alpha3.KafkaOperation, 0)
 		for _, acl := range acls.Acls {
 			operation, ok := KafkaOperationToAclOperationBMap.GetInverse(acl.Operation)
 			if !ok {
-				return otterizev1alpha3.KafkaTopic{}, fmt.Errorf("unknown operation %v", acl.Operation)
+				return intentResource{}, fmt.Errorf("unknown operation %v", acl.Operation)
 			}
 			operations = append(operations, operation)
 		}
-		return otterizev1alpha3.KafkaTopic{Name: acls.ResourceName, Operations: operations}, nil
+		return intentResource{Resource: acls.Resource, Operations: operations}, nil
 	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	return resourceAppliedKafkaTopics, nil
+	return resourceAppliedKafkaResources, nil
 }
 
-func (a *KafkaIntentsAdminImpl) collectTopicsToACLList(principal string, topics []otterizev1alpha3.KafkaTopic) (TopicToACLList, error) {
+func (a *KafkaIntentsAdminImpl) collectTopicsToACLList(principal string, resources []intentResource) (TopicToACLList, error) {
 	topicToACLList := TopicToACLList{}
 
-	for _, topic := range topics {
-		resource := sarama.Resource{
-			ResourceType:        sarama.AclResourceTopic,
-			ResourceName:        topic.Name,
-			ResourcePatternType: sarama.AclPatternLiteral,
-		}
-		acls := make([]sarama.Acl, 0)
-		for _, operation := range topic.Operations {
+	for _, resourceOperations := range resources {
+		resource := resourceOperations.Resource
+		for _, operation := range resourceOperations.Operations {
 			operation, ok := KafkaOperationToAclOperationBMap.Get(otterizev1alpha3.KafkaOperation(operation))
 			if !ok {
 				return nil, fmt.Errorf("unknown operation '%v'", operation)
@@ -230,9 +403,10 @@ func (a *KafkaIntentsAdminImpl) collectTopicsToACLList(principal string, topics
 				Operation:      operation,
 				PermissionType: sarama.AclPermissionAllow,
 			}
-			acls = append(acls, acl)
+			if !lo.Contains(topicToACLList[resource], acl) {
+				topicToACLList[resource] = append(topicToACLList[resource], acl)
+			}
 		}
-		topicToACLList[resource] = acls
 	}
 
 	return topicToACLList, nil
@@ -240,7 +414,7 @@ func (a *KafkaIntentsAdminImpl) collectTopicsToACLList(principal string, topics
 
 func (a *KafkaIntentsAdminImpl) deleteACLsByPrincipal(principal string) (int, error) {
 	aclFilter := sarama.AclFilter{
-		ResourceType:              sarama.AclResourceTopic,
+		ResourceType:              sarama.AclResourceAny,
 		ResourcePatternTypeFilter: sarama.AclPatternAny,
 		PermissionType:            sarama.AclPermissionAllow,
 		Operation:                 sarama.AclOperationAny,
@@ -297,8 +471,12 @@ func (a *KafkaIntentsAdminImpl) logACLs() error {
 	return nil
 }
 
-func (a *KafkaIntentsAdminImpl) ApplyClientIntents(clientName string, clientNamespace string, intents []otterizev1alpha3.Intent) error {
-	principal := a.formatPrincipal(clientName, clientNamespace)
+func (a *KafkaIntentsAdminImpl) ApplyClientIntents(clientName string, clientNamespace string, clientServiceAccount string, intents []otterizev1alpha3.Intent) error {
+	if err := checkIntentResourcesAllowed(a.kafkaServer.Spec, intents); err != nil {
+		return err
+	}
+
+	principal := a.formatPrincipal(clientIdentity{name: clientName, namespace: clientNamespace, serviceAccount: clientServiceAccount})
 	logger := logrus.WithFields(
 		logrus.Fields{
 			"principal":       principal,
@@ -306,27 +484,37 @@ func (a *KafkaIntentsAdminImpl) ApplyClientIntents(clientName string, clientName
 			"serverNamespace": a.kafkaServer.Namespace,
 		})
 
-	appliedIntentKafkaTopics, err := a.queryAppliedIntentKafkaTopics(principal)
+	appliedIntentKafkaResources, err := a.queryAppliedIntentKafkaResources(principal)
 	if err != nil {
 		return fmt.Errorf("failed getting applied ACL rules %w", err)
 	}
 
-	appliedIntentKafkaAcls, err := a.collectTopicsToACLList(principal, appliedIntentKafkaTopics)
+	appliedIntentKafkaAcls, err := a.collectTopicsToACLList(principal, appliedIntentKafkaResources)
 	if err != nil {
 		return fmt.Errorf("failed collecting topics to ACL list %w", err)
 	}
 
-	expectedIntentKafkaTopics := lo.Flatten(
-		lo.Map(intents, func(intent otterizev1alpha3.Intent, _ int) []otterizev1alpha3.KafkaTopic {
-			return intent.Topics
-		}),
-	)
-	expectedIntentsKafkaTopicsAcls, err := a.collectTopicsToACLList(principal, expectedIntentKafkaTopics)
+	expectedIntentsKafkaTopicsAcls, err := a.collectTopicsToACLList(principal, getIntentResources(a.kafkaServer.Spec, intents))
 	if err != nil {
 		return fmt.Errorf("failed collecting topics to ACL list %w", err)
 	}
 
-	resourceAclsCreate, resourceAclsDelete := a.kafkaResourceAclsDiff(expectedIntentsKafkaTopicsAcls, appliedIntentKafkaAcls)
+	appliedConsumerGroupAcls, err := a.queryAppliedConsumerGroupACLs(principal)
+	if err != nil {
+		return fmt.Errorf("failed getting applied consumer group ACL rules %w", err)
+	}
+
+	expectedConsumerGroups := lo.Flatten(
+		lo.Map(intents, func(intent otterizev1alpha3.Intent, _ int) []otterizev1alpha3.KafkaConsumerGroup {
+			return intent.ConsumerGroups
+		}),
+	)
+	expectedConsumerGroupAcls := a.collectConsumerGroupsToACLList(principal, expectedConsumerGroups)
+
+	resourceAclsCreate, resourceAclsDelete := a.kafkaResourceAclsDiff(
+		lo.Assign(expectedIntentsKafkaTopicsAcls, expectedConsumerGroupAcls),
+		lo.Assign(appliedIntentKafkaAcls, appliedConsumerGroupAcls),
+	)
 
 	if len(resourceAclsCreate) == 0 {
 		logger.Info("No new ACLs found to apply on server")
@@ -358,8 +546,8 @@ func (a *KafkaIntentsAdminImpl) ApplyClientIntents(clientName string, clientName
 	return nil
 }
 
-func (a *KafkaIntentsAdminImpl) RemoveClientIntents(clientName string, clientNamespace string) error {
-	principal := a.formatPrincipal(clientName, clientNamespace)
+func (a *KafkaIntentsAdminImpl) RemoveClientIntents(clientName string, clientNamespace string, clientServiceAccount string) error {
+	principal := a.formatPrincipal(clientIdentity{name: clientName, namespace: clientNamespace, serviceAccount: clientServiceAccount})
 	logger := logrus.WithFields(
 		logrus.Fields{
 			"principal":       principal,
@@ -402,6 +590,61 @@ func (a *KafkaIntentsAdminImpl) RemoveServerIntents(topicsConf []otterizev1alpha
 	return nil
 }
 
+// RemoveOrphanedClientACLs deletes the ACLs of managedPrincipals, the principals the operator recorded creating ACLs
+// for, that do not belong to any of activeClients, such as ACLs left behind when the operator was down while
+// ClientIntents were deleted. ACLs of other principals are never deleted, as the operator does not own them.
+// clientServiceAccounts holds the service accounts of active clients, for principal templates that use them.
+// Returns the number of orphaned principals found; when dryRun is set, they are only logged.
+func (a *KafkaIntentsAdminImpl) RemoveOrphanedClientACLs(managedPrincipals []string, activeClients []types.NamespacedName, clientServiceAccounts map[types.NamespacedName]string, dryRun bool) (int, error) {
+	logger := logrus.WithFields(
+		logrus.Fields{
+			"serverName":      a.kafkaServer.Spec.Service,
+			"serverNamespace": a.kafkaServer.Namespace,
+		})
+
+	activePrincipals := sets.New(lo.Map(activeClients, func(client types.NamespacedName, _ int) string {
+		return a.formatPrincipal(clientIdentity{name: client.Name, namespace: client.Namespace, serviceAccount: clientServiceAccounts[client]})
+	})...)
+	candidatePrincipals := sets.New(managedPrincipals...).Difference(activePrincipals)
+	if candidatePrincipals.Len() == 0 {
+		return 0, nil
+	}
+
+	resourceAcls, err := a.kafkaAdminClient.ListAcls(sarama.AclFilter{
+		ResourceType:              sarama.AclResourceAny,
+		ResourcePatternTypeFilter: sarama.AclPatternAny,
+		PermissionType:            sarama.AclPermissionAllow,
+		Operation:                 sarama.AclOperationAny,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed listing ACLs on server: %w", err)
+	}
+
+	orphanedPrincipals := sets.New[string]()
+	for _, resource := range resourceAcls {
+		for _, acl := range resource.Acls {
+			if candidatePrincipals.Has(acl.Principal) {
+				orphanedPrincipals.Insert(acl.Principal)
+			}
+		}
+	}
+
+	for _, principal := range sets.List(orphanedPrincipals) {
+		if dryRun {
+			logger.Infof("Dry run, not deleting ACLs of orphaned principal %s", principal)
+			continue
+		}
+
+		countDeleted, err := a.deleteACLsByPrincipal(principal)
+		if err != nil {
+			return 0, fmt.Errorf("failed clearing acls for principal %s: %w", principal, err)
+		}
+		logger.Infof("%d acl rules of orphaned principal %s were deleted", countDeleted, principal)
+	}
+
+	return orphanedPrincipals.Len(), nil
+}
+
 func (a *KafkaIntentsAdminImpl) getServerACLs(topicsConf []otterizev1alpha3.TopicConfig) []*sarama.ResourceAcls {
 	expectedACLs := a.getExpectedTopicsConfAcls(topicsConf)
 	var serverACLs []*sarama.ResourceAcls
@@ -593,9 +836,8 @@ func (a *KafkaIntentsAdminImpl) ApplyServerTopicsConf(topicsConf []otterizev1alp
 		logger.Info("No existing ACLs to delete for topic configuration")
 	}
 
-	logger.Infof("ensuring consumer group permissions")
-	if err := a.ensureConsumerGroupWildcardACLs(); err != nil {
-		logger.WithError(err).Error("failed ensuring Consumer group permissions")
+	if err := a.reconcileConsumerGroupWildcardACLs(); err != nil {
+		logger.WithError(err).Error("failed reconciling consumer group permissions")
 	}
 
 	if err := a.logACLs(); err != nil {
@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/mock/gomock"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"testing"
 	"time"
 )
@@ -350,6 +351,239 @@ func getAclAuthenticatedOnly(topicName string, anonymousUsersPrincipal string, a
 	}
 }
 
+func (s *IntentAdminSuite) TestRemoveOrphanedClientACLs() {
+	kafkaServerConfig := otterizev1alpha3.KafkaServerConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kafkaServerConfigResourceName,
+			Namespace: testNamespace,
+		},
+		Spec: otterizev1alpha3.KafkaServerConfigSpec{
+			Service: otterizev1alpha3.Service{
+				Name: serverName,
+			},
+			Addr: serverAddress,
+		},
+	}
+
+	s.intentsAdmin = NewKafkaIntentsAdminImpl(kafkaServerConfig, s.mockClusterAdmin, "CN=$ServiceName.$Namespace", true, true)
+
+	activePrincipal := "User:CN=active-client.client-namespace"
+	orphanedPrincipal := "User:CN=deleted-client.client-namespace"
+	// Principals the operator did not record creating are never deleted, even if they match the principal template.
+	unmanagedPrincipal := "User:CN=other-client.client-namespace"
+	unrelatedPrincipal := "User:admin"
+	topicAcls := sarama.ResourceAcls{
+		Resource: sarama.Resource{
+			ResourceType:        sarama.AclResourceTopic,
+			ResourceName:        "my-topic",
+			ResourcePatternType: sarama.AclPatternLiteral,
+		},
+		Acls: lo.Map([]string{activePrincipal, orphanedPrincipal, unmanagedPrincipal, unrelatedPrincipal, allUsersPrincipal}, func(principal string, _ int) *sarama.Acl {
+			return &sarama.Acl{Principal: principal, Host: "*", Operation: sarama.AclOperationRead, PermissionType: sarama.AclPermissionAllow}
+		}),
+	}
+
+	s.mockClusterAdmin.EXPECT().ListAcls(gomock.Any()).Return([]sarama.ResourceAcls{topicAcls}, nil)
+	s.mockClusterAdmin.EXPECT().DeleteACL(sarama.AclFilter{
+		ResourceType:              sarama.AclResourceAny,
+		ResourcePatternTypeFilter: sarama.AclPatternAny,
+		PermissionType:            sarama.AclPermissionAllow,
+		Operation:                 sarama.AclOperationAny,
+		Principal:                 lo.ToPtr(orphanedPrincipal),
+		Host:                      lo.ToPtr("*"),
+	}, true).Return([]sarama.MatchingAcl{{}}, nil)
+
+	orphanCount, err := s.intentsAdmin.RemoveOrphanedClientACLs([]string{activePrincipal, orphanedPrincipal}, []types.NamespacedName{{Name: "active-client", Namespace: "client-namespace"}}, nil, false)
+	s.Require().NoError(err)
+	s.Require().Equal(1, orphanCount)
+}
+
+func (s *IntentAdminSuite) TestRemoveOrphanedClientACLsDryRun() {
+	kafkaServerConfig := otterizev1alpha3.KafkaServerConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kafkaServerConfigResourceName,
+			Namespace: testNamespace,
+		},
+		Spec: otterizev1alpha3.KafkaServerConfigSpec{
+			Service: otterizev1alpha3.Service{
+				Name: serverName,
+			},
+			Addr: serverAddress,
+		},
+	}
+
+	s.intentsAdmin = NewKafkaIntentsAdminImpl(kafkaServerConfig, s.mockClusterAdmin, "CN=$ServiceName.$Namespace", true, true)
+
+	topicAcls := sarama.ResourceAcls{
+		Resource: sarama.Resource{
+			ResourceType:        sarama.AclResourceTopic,
+			ResourceName:        "my-topic",
+			ResourcePatternType: sarama.AclPatternLiteral,
+		},
+		Acls: []*sarama.Acl{
+			{Principal: "User:CN=deleted-client.client-namespace", Host: "*", Operation: sarama.AclOperationRead, PermissionType: sarama.AclPermissionAllow},
+		},
+	}
+
+	// No DeleteACL call is expected
+	s.mockClusterAdmin.EXPECT().ListAcls(gomock.Any()).Return([]sarama.ResourceAcls{topicAcls}, nil)
+
+	orphanCount, err := s.intentsAdmin.RemoveOrphanedClientACLs([]string{"User:CN=deleted-client.client-namespace"}, nil, nil, true)
+	s.Require().NoError(err)
+	s.Require().Equal(1, orphanCount)
+}
+
+func (s *IntentAdminSuite) TestApplyClientIntentsTransactionalIDsAndCluster() {
+	kafkaServerConfig := otterizev1alpha3.KafkaServerConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kafkaServerConfigResourceName,
+			Namespace: testNamespace,
+		},
+		Spec: otterizev1alpha3.KafkaServerConfigSpec{
+			Service: otterizev1alpha3.Service{
+				Name: serverName,
+			},
+			Addr:                     serverAddress,
+			AllowTransactionalIDs:    true,
+			AllowedClusterOperations: []otterizev1alpha3.KafkaOperation{otterizev1alpha3.KafkaOperationIdempotentWrite},
+		},
+	}
+
+	s.intentsAdmin = NewKafkaIntentsAdminImpl(kafkaServerConfig, s.mockClusterAdmin, "CN=$ServiceName.$Namespace", true, true)
+
+	principal := "User:CN=client.client-namespace"
+	transactionalIDAcls := sarama.ResourceAcls{
+		Resource: sarama.Resource{
+			ResourceType:        sarama.AclResourceTransactionalID,
+			ResourceName:        "client-tx-",
+			ResourcePatternType: sarama.AclPatternPrefixed,
+		},
+		Acls: []*sarama.Acl{
+			{Principal: principal, Host: "*", Operation: sarama.AclOperationWrite, PermissionType: sarama.AclPermissionAllow},
+			{Principal: principal, Host: "*", Operation: sarama.AclOperationDescribe, PermissionType: sarama.AclPermissionAllow},
+		},
+	}
+	clusterAcls := sarama.ResourceAcls{
+		Resource: sarama.Resource{
+			ResourceType:        sarama.AclResourceCluster,
+			ResourceName:        KafkaClusterResourceName,
+			ResourcePatternType: sarama.AclPatternLiteral,
+		},
+		Acls: []*sarama.Acl{
+			{Principal: principal, Host: "*", Operation: sarama.AclOperationIdempotentWrite, PermissionType: sarama.AclPermissionAllow},
+		},
+	}
+	staleTransactionalIDAcls := sarama.ResourceAcls{
+		Resource: sarama.Resource{
+			ResourceType:        sarama.AclResourceTransactionalID,
+			ResourceName:        "old-tx",
+			ResourcePatternType: sarama.AclPatternLiteral,
+		},
+		Acls: []*sarama.Acl{
+			{Principal: principal, Host: "*", Operation: sarama.AclOperationWrite, PermissionType: sarama.AclPermissionAllow},
+		},
+	}
+
+	gomock.InOrder(
+		s.mockClusterAdmin.EXPECT().ListAcls(sarama.AclFilter{
+			ResourceType:              sarama.AclResourceAny,
+			Principal:                 lo.ToPtr(principal),
+			ResourcePatternTypeFilter: sarama.AclPatternAny,
+			PermissionType:            sarama.AclPermissionAllow,
+			Operation:                 sarama.AclOperationAny,
+		}).Return([]sarama.ResourceAcls{staleTransactionalIDAcls}, nil),
+		s.mockClusterAdmin.EXPECT().ListAcls(gomock.Any()).Return([]sarama.ResourceAcls{}, nil),
+		s.mockClusterAdmin.EXPECT().CreateACLs(gomock.Any()).DoAndReturn(func(resourceAcls []*sarama.ResourceAcls) error {
+			// Resources are created in no particular order.
+			s.Require().ElementsMatch([]*sarama.ResourceAcls{&transactionalIDAcls, &clusterAcls}, resourceAcls)
+			return nil
+		}),
+		s.mockClusterAdmin.EXPECT().DeleteACL(sarama.AclFilter{
+			ResourceType:              sarama.AclResourceTransactionalID,
+			ResourceName:              lo.ToPtr("old-tx"),
+			ResourcePatternTypeFilter: sarama.AclPatternLiteral,
+			PermissionType:            sarama.AclPermissionAllow,
+			Operation:                 sarama.AclOperationWrite,
+			Principal:                 lo.ToPtr(principal),
+			Host:                      lo.ToPtr("*"),
+		}, false).Return([]sarama.MatchingAcl{{}}, nil),
+		s.mockClusterAdmin.EXPECT().ListAcls(gomock.Any()).Return([]sarama.ResourceAcls{}, nil),
+	)
+
+	intents := []otterizev1alpha3.Intent{
+		{
+			Name: serverName,
+			Type: otterizev1alpha3.IntentTypeKafka,
+			TransactionalIDs: []otterizev1alpha3.KafkaTransactionalID{
+				{
+					Name:       "client-tx-",
+					Pattern:    otterizev1alpha3.ResourcePatternTypePrefix,
+					Operations: []otterizev1alpha3.KafkaOperation{otterizev1alpha3.KafkaOperationProduce, otterizev1alpha3.KafkaOperationDescribe},
+				},
+			},
+			ClusterOperations: []otterizev1alpha3.KafkaOperation{otterizev1alpha3.KafkaOperationIdempotentWrite},
+		},
+	}
+	err := s.intentsAdmin.ApplyClientIntents("client", "client-namespace", "", intents)
+	s.Require().NoError(err)
+}
+
+func (s *IntentAdminSuite) TestApplyClientIntentsClusterOperationNotAllowed() {
+	kafkaServerConfig := otterizev1alpha3.KafkaServerConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kafkaServerConfigResourceName,
+			Namespace: testNamespace,
+		},
+		Spec: otterizev1alpha3.KafkaServerConfigSpec{
+			Service: otterizev1alpha3.Service{
+				Name: serverName,
+			},
+			Addr:                     serverAddress,
+			AllowedClusterOperations: []otterizev1alpha3.KafkaOperation{otterizev1alpha3.KafkaOperationIdempotentWrite},
+		},
+	}
+
+	s.intentsAdmin = NewKafkaIntentsAdminImpl(kafkaServerConfig, s.mockClusterAdmin, "CN=$ServiceName.$Namespace", true, true)
+
+	// No ACLs are listed or created
+	intents := []otterizev1alpha3.Intent{
+		{
+			Name:              serverName,
+			Type:              otterizev1alpha3.IntentTypeKafka,
+			ClusterOperations: []otterizev1alpha3.KafkaOperation{otterizev1alpha3.KafkaOperationAlter},
+		},
+	}
+	err := s.intentsAdmin.ApplyClientIntents("client", "client-namespace", "", intents)
+	s.Require().ErrorIs(err, ErrKafkaResourceNotAllowed)
+}
+
+func (s *IntentAdminSuite) TestApplyClientIntentsTransactionalIDsNotAllowedByDefault() {
+	kafkaServerConfig := otterizev1alpha3.KafkaServerConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kafkaServerConfigResourceName,
+			Namespace: testNamespace,
+		},
+		Spec: otterizev1alpha3.KafkaServerConfigSpec{
+			Service: otterizev1alpha3.Service{
+				Name: serverName,
+			},
+			Addr: serverAddress,
+		},
+	}
+
+	s.intentsAdmin = NewKafkaIntentsAdminImpl(kafkaServerConfig, s.mockClusterAdmin, "CN=$ServiceName.$Namespace", true, true)
+
+	intents := []otterizev1alpha3.Intent{
+		{
+			Name:             serverName,
+			Type:             otterizev1alpha3.IntentTypeKafka,
+			TransactionalIDs: []otterizev1alpha3.KafkaTransactionalID{{Name: "client-tx", Operations: []otterizev1alpha3.KafkaOperation{otterizev1alpha3.KafkaOperationProduce}}},
+		},
+	}
+	err := s.intentsAdmin.ApplyClientIntents("client", "client-namespace", "", intents)
+	s.Require().ErrorIs(err, ErrKafkaResourceNotAllowed)
+}
+
 func (s *IntentAdminSuite) TearDownTest() {
 	s.intentsAdmin = nil
 }
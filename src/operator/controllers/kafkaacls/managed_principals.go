@@ -0,0 +1,47 @@
+package kafkaacls
+
+import (
+	"context"
+	"fmt"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"golang.org/x/exp/slices"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RecordManagedPrincipal records in the status of the KafkaServerConfig that the operator created ACLs for the
+// principal, so that garbage collection may delete them once no intents use it.
+func RecordManagedPrincipal(ctx context.Context, k8sClient client.Client, serverConfig types.NamespacedName, principal string) error {
+	return updateManagedPrincipals(ctx, k8sClient, serverConfig, func(managedPrincipals sets.Set[string]) sets.Set[string] {
+		return managedPrincipals.Insert(principal)
+	})
+}
+
+// PruneManagedPrincipals removes the principals that are not in keep from the status of the KafkaServerConfig, once
+// their ACLs were deleted.
+func PruneManagedPrincipals(ctx context.Context, k8sClient client.Client, serverConfig types.NamespacedName, keep sets.Set[string]) error {
+	return updateManagedPrincipals(ctx, k8sClient, serverConfig, func(managedPrincipals sets.Set[string]) sets.Set[string] {
+		return managedPrincipals.Intersection(keep)
+	})
+}
+
+func updateManagedPrincipals(ctx context.Context, k8sClient client.Client, serverConfig types.NamespacedName, update func(sets.Set[string]) sets.Set[string]) error {
+	config := &otterizev1alpha3.KafkaServerConfig{}
+	if err := k8sClient.Get(ctx, serverConfig, config); err != nil {
+		return fmt.Errorf("failed getting KafkaServerConfig %s: %w", serverConfig, err)
+	}
+
+	managedPrincipals := sets.List(update(sets.New(config.Status.ManagedPrincipals...)))
+	if slices.Equal(managedPrincipals, config.Status.ManagedPrincipals) {
+		return nil
+	}
+
+	updatedConfig := config.DeepCopy()
+	updatedConfig.Status.ManagedPrincipals = managedPrincipals
+	// The optimistic lock keeps concurrent reconciles from dropping each other's principals.
+	if err := k8sClient.Status().Patch(ctx, updatedConfig, client.MergeFromWithOptions(config, client.MergeFromWithOptimisticLock{})); err != nil {
+		return fmt.Errorf("failed updating managed principals of KafkaServerConfig %s: %w", serverConfig, err)
+	}
+	return nil
+}
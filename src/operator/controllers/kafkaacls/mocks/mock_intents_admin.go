@@ -9,6 +9,7 @@ import (
 
 	v1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
 	gomock "go.uber.org/mock/gomock"
+	types "k8s.io/apimachinery/pkg/types"
 )
 
 // MockKafkaIntentsAdmin is a mock of KafkaIntentsAdmin interface.
@@ -35,17 +36,32 @@ func (m *MockKafkaIntentsAdmin) EXPECT() *MockKafkaIntentsAdminMockRecorder {
 }
 
 // ApplyClientIntents mocks base method.
-func (m *MockKafkaIntentsAdmin) ApplyClientIntents(clientName, clientNamespace string, intents []v1alpha3.Intent) error {
+func (m *MockKafkaIntentsAdmin) ApplyClientIntents(clientName, clientNamespace, clientServiceAccount string, intents []v1alpha3.Intent) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ApplyClientIntents", clientName, clientNamespace, intents)
+	ret := m.ctrl.Call(m, "ApplyClientIntents", clientName, clientNamespace, clientServiceAccount, intents)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // ApplyClientIntents indicates an expected call of ApplyClientIntents.
-func (mr *MockKafkaIntentsAdminMockRecorder) ApplyClientIntents(clientName, clientNamespace, intents interface{}) *gomock.Call {
+func (mr *MockKafkaIntentsAdminMockRecorder) ApplyClientIntents(clientName, clientNamespace, clientServiceAccount, intents interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyClientIntents", reflect.TypeOf((*MockKafkaIntentsAdmin)(nil).ApplyClientIntents), clientName, clientNamespace, intents)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyClientIntents", reflect.TypeOf((*MockKafkaIntentsAdmin)(nil).ApplyClientIntents), clientName, clientNamespace, clientServiceAccount, intents)
+}
+
+// ApplyClientQuotas mocks base method.
+func (m *MockKafkaIntentsAdmin) ApplyClientQuotas(clientName, clientNamespace, clientServiceAccount string, quotas *v1alpha3.KafkaQuotas) (v1alpha3.KafkaQuotas, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyClientQuotas", clientName, clientNamespace, clientServiceAccount, quotas)
+	ret0, _ := ret[0].(v1alpha3.KafkaQuotas)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyClientQuotas indicates an expected call of ApplyClientQuotas.
+func (mr *MockKafkaIntentsAdminMockRecorder) ApplyClientQuotas(clientName, clientNamespace, clientServiceAccount, quotas interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyClientQuotas", reflect.TypeOf((*MockKafkaIntentsAdmin)(nil).ApplyClientQuotas), clientName, clientNamespace, clientServiceAccount, quotas)
 }
 
 // ApplyServerTopicsConf mocks base method.
@@ -62,6 +78,21 @@ func (mr *MockKafkaIntentsAdminMockRecorder) ApplyServerTopicsConf(topicsConf in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyServerTopicsConf", reflect.TypeOf((*MockKafkaIntentsAdmin)(nil).ApplyServerTopicsConf), topicsConf)
 }
 
+// ApplyTopics mocks base method.
+func (m *MockKafkaIntentsAdmin) ApplyTopics(topicManagement v1alpha3.KafkaTopicManagement, createdTopics, referencedTopics []string) (v1alpha3.KafkaTopicsStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyTopics", topicManagement, createdTopics, referencedTopics)
+	ret0, _ := ret[0].(v1alpha3.KafkaTopicsStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyTopics indicates an expected call of ApplyTopics.
+func (mr *MockKafkaIntentsAdminMockRecorder) ApplyTopics(topicManagement, createdTopics, referencedTopics interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyTopics", reflect.TypeOf((*MockKafkaIntentsAdmin)(nil).ApplyTopics), topicManagement, createdTopics, referencedTopics)
+}
+
 // Close mocks base method.
 func (m *MockKafkaIntentsAdmin) Close() {
 	m.ctrl.T.Helper()
@@ -74,18 +105,47 @@ func (mr *MockKafkaIntentsAdminMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockKafkaIntentsAdmin)(nil).Close))
 }
 
+// FormatClientPrincipal mocks base method.
+func (m *MockKafkaIntentsAdmin) FormatClientPrincipal(clientName, clientNamespace, clientServiceAccount string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FormatClientPrincipal", clientName, clientNamespace, clientServiceAccount)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// FormatClientPrincipal indicates an expected call of FormatClientPrincipal.
+func (mr *MockKafkaIntentsAdminMockRecorder) FormatClientPrincipal(clientName, clientNamespace, clientServiceAccount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FormatClientPrincipal", reflect.TypeOf((*MockKafkaIntentsAdmin)(nil).FormatClientPrincipal), clientName, clientNamespace, clientServiceAccount)
+}
+
 // RemoveClientIntents mocks base method.
-func (m *MockKafkaIntentsAdmin) RemoveClientIntents(clientName, clientNamespace string) error {
+func (m *MockKafkaIntentsAdmin) RemoveClientIntents(clientName, clientNamespace, clientServiceAccount string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RemoveClientIntents", clientName, clientNamespace)
+	ret := m.ctrl.Call(m, "RemoveClientIntents", clientName, clientNamespace, clientServiceAccount)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // RemoveClientIntents indicates an expected call of RemoveClientIntents.
-func (mr *MockKafkaIntentsAdminMockRecorder) RemoveClientIntents(clientName, clientNamespace interface{}) *gomock.Call {
+func (mr *MockKafkaIntentsAdminMockRecorder) RemoveClientIntents(clientName, clientNamespace, clientServiceAccount interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveClientIntents", reflect.TypeOf((*MockKafkaIntentsAdmin)(nil).RemoveClientIntents), clientName, clientNamespace)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveClientIntents", reflect.TypeOf((*MockKafkaIntentsAdmin)(nil).RemoveClientIntents), clientName, clientNamespace, clientServiceAccount)
+}
+
+// RemoveOrphanedClientACLs mocks base method.
+func (m *MockKafkaIntentsAdmin) RemoveOrphanedClientACLs(managedPrincipals []string, activeClients []types.NamespacedName, clientServiceAccounts map[types.NamespacedName]string, dryRun bool) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveOrphanedClientACLs", managedPrincipals, activeClients, clientServiceAccounts, dryRun)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveOrphanedClientACLs indicates an expected call of RemoveOrphanedClientACLs.
+func (mr *MockKafkaIntentsAdminMockRecorder) RemoveOrphanedClientACLs(managedPrincipals, activeClients, clientServiceAccounts, dryRun interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveOrphanedClientACLs", reflect.TypeOf((*MockKafkaIntentsAdmin)(nil).RemoveOrphanedClientACLs), managedPrincipals, activeClients, clientServiceAccounts, dryRun)
 }
 
 // RemoveServerIntents mocks base method.
@@ -101,3 +161,18 @@ func (mr *MockKafkaIntentsAdminMockRecorder) RemoveServerIntents(topicsConf inte
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveServerIntents", reflect.TypeOf((*MockKafkaIntentsAdmin)(nil).RemoveServerIntents), topicsConf)
 }
+
+// ResyncACLs mocks base method.
+func (m *MockKafkaIntentsAdmin) ResyncACLs(clientIntents map[types.NamespacedName][]v1alpha3.Intent, clientServiceAccounts map[types.NamespacedName]string, repair bool) (v1alpha3.KafkaACLDrift, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResyncACLs", clientIntents, clientServiceAccounts, repair)
+	ret0, _ := ret[0].(v1alpha3.KafkaACLDrift)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResyncACLs indicates an expected call of ResyncACLs.
+func (mr *MockKafkaIntentsAdminMockRecorder) ResyncACLs(clientIntents, clientServiceAccounts, repair interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResyncACLs", reflect.TypeOf((*MockKafkaIntentsAdmin)(nil).ResyncACLs), clientIntents, clientServiceAccounts, repair)
+}
@@ -0,0 +1,69 @@
+package kafkaacls
+
+import (
+	"errors"
+	"fmt"
+	"github.com/samber/lo"
+	"regexp"
+	"strings"
+)
+
+const (
+	PrincipalTemplateServiceName    = "$ServiceName"
+	PrincipalTemplateNamespace      = "$Namespace"
+	PrincipalTemplateServiceAccount = "$ServiceAccount"
+	PrincipalTemplateCluster        = "$Cluster"
+)
+
+var (
+	principalTemplateVariableRE = regexp.MustCompile(`\$[A-Za-z]+`)
+	principalTemplateVariables  = []string{
+		PrincipalTemplateServiceName,
+		PrincipalTemplateNamespace,
+		PrincipalTemplateServiceAccount,
+		PrincipalTemplateCluster,
+	}
+)
+
+// clientIdentity is the identity of a client whose Kafka principal is formatted from a principal template.
+// serviceAccount is only required by templates that use $ServiceAccount.
+type clientIdentity struct {
+	name           string
+	namespace      string
+	serviceAccount string
+}
+
+// ValidatePrincipalTemplate checks that the template has the form <PrincipalType>:<name>, uses only known variables,
+// and identifies clients by their service name or service account.
+func ValidatePrincipalTemplate(template string) error {
+	principalType, name, found := strings.Cut(template, ":")
+	if !found || principalType == "" || name == "" {
+		return fmt.Errorf("principal template %q must have the form <PrincipalType>:<name>, such as User:$ServiceName.$Namespace", template)
+	}
+
+	for _, variable := range principalTemplateVariableRE.FindAllString(template, -1) {
+		if !lo.Contains(principalTemplateVariables, variable) {
+			return fmt.Errorf("unknown variable %s in principal template, supported variables are %s", variable, strings.Join(principalTemplateVariables, ", "))
+		}
+	}
+
+	if !strings.Contains(template, PrincipalTemplateServiceName) && !strings.Contains(template, PrincipalTemplateServiceAccount) {
+		return errors.New("principal template must use $ServiceName or $ServiceAccount to tell clients apart")
+	}
+
+	return nil
+}
+
+// PrincipalTemplateUsesServiceAccount returns whether formatting the template requires the service account of clients.
+func PrincipalTemplateUsesServiceAccount(template string) bool {
+	return strings.Contains(template, PrincipalTemplateServiceAccount)
+}
+
+func formatPrincipalTemplate(template string, client clientIdentity, clusterName string) string {
+	return strings.NewReplacer(
+		PrincipalTemplateServiceName, client.name,
+		PrincipalTemplateNamespace, client.namespace,
+		PrincipalTemplateServiceAccount, client.serviceAccount,
+		PrincipalTemplateCluster, clusterName,
+	).Replace(template)
+}
@@ -0,0 +1,104 @@
+package kafkaacls
+
+import (
+	"github.com/Shopify/sarama"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	kafkaaclsmocks "github.com/otterize/intents-operator/src/operator/controllers/kafkaacls/mocks"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"testing"
+)
+
+type PrincipalTemplateSuite struct {
+	suite.Suite
+	mockClusterAdmin *kafkaaclsmocks.MockClusterAdmin
+}
+
+func (s *PrincipalTemplateSuite) SetupTest() {
+	controller := gomock.NewController(s.T())
+	s.mockClusterAdmin = kafkaaclsmocks.NewMockClusterAdmin(controller)
+}
+
+func (s *PrincipalTemplateSuite) TestValidatePrincipalTemplate() {
+	s.Require().NoError(ValidatePrincipalTemplate("User:CN=$ServiceName.$Namespace"))
+	s.Require().NoError(ValidatePrincipalTemplate("User:spiffe://$Cluster/ns/$Namespace/sa/$ServiceAccount"))
+
+	s.Require().ErrorContains(ValidatePrincipalTemplate("$ServiceName.$Namespace"), "<PrincipalType>:<name>")
+	s.Require().ErrorContains(ValidatePrincipalTemplate("User:"), "<PrincipalType>:<name>")
+	s.Require().ErrorContains(ValidatePrincipalTemplate("User:$ServiceName.$Namespce"), "unknown variable $Namespce")
+	s.Require().ErrorContains(ValidatePrincipalTemplate("User:$Namespace"), "$ServiceName or $ServiceAccount")
+}
+
+func (s *PrincipalTemplateSuite) TestFormatPrincipal() {
+	kafkaServerConfig := otterizev1alpha3.KafkaServerConfig{
+		Spec: otterizev1alpha3.KafkaServerConfigSpec{
+			PrincipalTemplate: "User:spiffe://$Cluster/ns/$Namespace/sa/$ServiceAccount/$ServiceName",
+		},
+	}
+
+	intentsAdmin := newKafkaIntentsAdminImpl(kafkaServerConfig, s.mockClusterAdmin, "CN=$ServiceName.$Namespace", true, true)
+	intentsAdmin.clusterName = "prod"
+
+	principal := intentsAdmin.formatPrincipal(clientIdentity{name: "client", namespace: "client-namespace", serviceAccount: "client-sa"})
+	s.Require().Equal("User:spiffe://prod/ns/client-namespace/sa/client-sa/client", principal)
+}
+
+func (s *PrincipalTemplateSuite) TestFormatPrincipalDefaultsToUserNameMapping() {
+	intentsAdmin := newKafkaIntentsAdminImpl(otterizev1alpha3.KafkaServerConfig{}, s.mockClusterAdmin, "CN=$ServiceName.$Namespace", true, true)
+
+	principal := intentsAdmin.formatPrincipal(clientIdentity{name: "client", namespace: "client-namespace"})
+	s.Require().Equal("User:CN=client.client-namespace", principal)
+}
+
+func (s *PrincipalTemplateSuite) TestRemoveOrphanedClientACLsWithServiceAccountTemplate() {
+	kafkaServerConfig := otterizev1alpha3.KafkaServerConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kafkaServerConfigResourceName,
+			Namespace: testNamespace,
+		},
+		Spec: otterizev1alpha3.KafkaServerConfigSpec{
+			Service:           otterizev1alpha3.Service{Name: serverName},
+			Addr:              serverAddress,
+			PrincipalTemplate: "User:spiffe://$Cluster/ns/$Namespace/sa/$ServiceAccount",
+		},
+	}
+
+	intentsAdmin := newKafkaIntentsAdminImpl(kafkaServerConfig, s.mockClusterAdmin, "", true, true)
+	intentsAdmin.clusterName = "prod"
+
+	activePrincipal := "User:spiffe://prod/ns/client-namespace/sa/active-sa"
+	orphanedPrincipal := "User:spiffe://prod/ns/client-namespace/sa/deleted-sa"
+	otherClusterPrincipal := "User:spiffe://staging/ns/client-namespace/sa/deleted-sa"
+	topicAcls := sarama.ResourceAcls{
+		Resource: sarama.Resource{
+			ResourceType:        sarama.AclResourceTopic,
+			ResourceName:        "my-topic",
+			ResourcePatternType: sarama.AclPatternLiteral,
+		},
+		Acls: lo.Map([]string{activePrincipal, orphanedPrincipal, otherClusterPrincipal}, func(principal string, _ int) *sarama.Acl {
+			return &sarama.Acl{Principal: principal, Host: "*", Operation: sarama.AclOperationRead, PermissionType: sarama.AclPermissionAllow}
+		}),
+	}
+
+	s.mockClusterAdmin.EXPECT().ListAcls(gomock.Any()).Return([]sarama.ResourceAcls{topicAcls}, nil)
+	s.mockClusterAdmin.EXPECT().DeleteACL(sarama.AclFilter{
+		ResourceType:              sarama.AclResourceAny,
+		ResourcePatternTypeFilter: sarama.AclPatternAny,
+		PermissionType:            sarama.AclPermissionAllow,
+		Operation:                 sarama.AclOperationAny,
+		Principal:                 lo.ToPtr(orphanedPrincipal),
+		Host:                      lo.ToPtr("*"),
+	}, true).Return([]sarama.MatchingAcl{{}}, nil)
+
+	activeClient := types.NamespacedName{Name: "active-client", Namespace: "client-namespace"}
+	orphanCount, err := intentsAdmin.RemoveOrphanedClientACLs([]string{activePrincipal, orphanedPrincipal}, []types.NamespacedName{activeClient}, map[types.NamespacedName]string{activeClient: "active-sa"}, false)
+	s.Require().NoError(err)
+	s.Require().Equal(1, orphanCount)
+}
+
+func TestPrincipalTemplateSuite(t *testing.T) {
+	suite.Run(t, new(PrincipalTemplateSuite))
+}
@@ -0,0 +1,159 @@
+package kafkaacls
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Shopify/sarama"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	"strings"
+)
+
+const (
+	ProducerByteRateQuotaKey = "producer_byte_rate"
+	ConsumerByteRateQuotaKey = "consumer_byte_rate"
+	userPrincipalType        = "User"
+	// describeClientQuotasApiKey and alterClientQuotasApiKey are the Kafka protocol API keys of the client quota
+	// requests, as reported by servers in ApiVersions responses.
+	describeClientQuotasApiKey int16 = 48
+	alterClientQuotasApiKey    int16 = 49
+)
+
+var (
+	ErrQuotasRequireUserPrincipal = errors.New("Kafka quotas can only be applied to User principals")
+	ErrQuotasNotSupported         = errors.New("Kafka quotas require Kafka 2.6 or later, which the Kafka server does not support")
+)
+
+// quotasSupported returns whether the Kafka server at addr supports describing and altering client quotas, based on
+// the API versions it reports.
+func quotasSupported(addr string, config *sarama.Config) (bool, error) {
+	broker := sarama.NewBroker(addr)
+	if err := broker.Open(config); err != nil {
+		return false, err
+	}
+	defer func() {
+		if err := broker.Close(); err != nil {
+			logrus.WithError(err).WithField("addr", addr).Debug("Error closing Kafka broker connection")
+		}
+	}()
+
+	response, err := broker.ApiVersions(&sarama.ApiVersionsRequest{})
+	if err != nil {
+		return false, err
+	}
+	return apiVersionsSupportQuotas(response), nil
+}
+
+func apiVersionsSupportQuotas(response *sarama.ApiVersionsResponse) bool {
+	apiKeys := lo.Map(response.ApiKeys, func(key sarama.ApiVersionsResponseKey, _ int) int16 {
+		return key.ApiKey
+	})
+	return lo.Every(apiKeys, []int16{describeClientQuotasApiKey, alterClientQuotasApiKey})
+}
+
+// quotaEntity returns the user entity that quotas of the principal apply to, or false if the principal is not a user.
+func quotaEntity(principal string) ([]sarama.QuotaEntityComponent, bool) {
+	principalType, name, _ := strings.Cut(principal, ":")
+	if principalType != userPrincipalType {
+		return nil, false
+	}
+	return []sarama.QuotaEntityComponent{{EntityType: sarama.QuotaEntityUser, MatchType: sarama.QuotaMatchExact, Name: name}}, true
+}
+
+func quotasToValues(quotas otterizev1alpha3.KafkaQuotas) map[string]float64 {
+	values := map[string]float64{}
+	if quotas.ProducerByteRate != 0 {
+		values[ProducerByteRateQuotaKey] = float64(quotas.ProducerByteRate)
+	}
+	if quotas.ConsumerByteRate != 0 {
+		values[ConsumerByteRateQuotaKey] = float64(quotas.ConsumerByteRate)
+	}
+	return values
+}
+
+func valuesToQuotas(values map[string]float64) otterizev1alpha3.KafkaQuotas {
+	return otterizev1alpha3.KafkaQuotas{
+		ProducerByteRate: int64(values[ProducerByteRateQuotaKey]),
+		ConsumerByteRate: int64(values[ConsumerByteRateQuotaKey]),
+	}
+}
+
+func (a *KafkaIntentsAdminImpl) describeClientQuotas(entity []sarama.QuotaEntityComponent) (map[string]float64, error) {
+	filter := lo.Map(entity, func(component sarama.QuotaEntityComponent, _ int) sarama.QuotaFilterComponent {
+		return sarama.QuotaFilterComponent{EntityType: component.EntityType, MatchType: component.MatchType, Match: component.Name}
+	})
+	entries, err := a.kafkaAdminClient.DescribeClientQuotas(filter, true)
+	if errors.Is(err, sarama.ErrUnsupportedVersion) {
+		return nil, fmt.Errorf("%w, server %s", ErrQuotasNotSupported, a.kafkaServer.Spec.Addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed describing client quotas: %w", err)
+	}
+
+	values := map[string]float64{}
+	for _, entry := range entries {
+		for key, value := range entry.Values {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+// ApplyClientQuotas sets the quotas of the client's principal, and removes byte rate quotas that are no longer
+// declared. It returns the quotas the server reports after applying them.
+func (a *KafkaIntentsAdminImpl) ApplyClientQuotas(clientName string, clientNamespace string, clientServiceAccount string, quotas *otterizev1alpha3.KafkaQuotas) (otterizev1alpha3.KafkaQuotas, error) {
+	principal := a.formatPrincipal(clientIdentity{name: clientName, namespace: clientNamespace, serviceAccount: clientServiceAccount})
+	logger := logrus.WithFields(
+		logrus.Fields{
+			"principal":       principal,
+			"serverName":      a.kafkaServer.Spec.Service,
+			"serverNamespace": a.kafkaServer.Namespace,
+		})
+
+	entity, ok := quotaEntity(principal)
+	if !ok {
+		if quotas != nil {
+			return otterizev1alpha3.KafkaQuotas{}, fmt.Errorf("%w, principal is %s", ErrQuotasRequireUserPrincipal, principal)
+		}
+		return otterizev1alpha3.KafkaQuotas{}, nil
+	}
+
+	appliedValues, err := a.describeClientQuotas(entity)
+	if err != nil {
+		return otterizev1alpha3.KafkaQuotas{}, err
+	}
+	expectedValues := quotasToValues(lo.FromPtr(quotas))
+
+	ops := make([]sarama.ClientQuotasOp, 0)
+	for _, key := range []string{ProducerByteRateQuotaKey, ConsumerByteRateQuotaKey} {
+		expectedValue, expected := expectedValues[key]
+		appliedValue, applied := appliedValues[key]
+		if expected && (!applied || appliedValue != expectedValue) {
+			ops = append(ops, sarama.ClientQuotasOp{Key: key, Value: expectedValue})
+		} else if !expected && applied {
+			ops = append(ops, sarama.ClientQuotasOp{Key: key, Remove: true})
+		}
+	}
+
+	if len(ops) == 0 {
+		return valuesToQuotas(appliedValues), nil
+	}
+
+	for _, op := range ops {
+		if !op.Remove && !(a.enforcementEnabledForServer && a.enableKafkaACLCreation) {
+			logger.Infof("Skipped setting quota %s because enforcement or Kafka ACL creation is disabled", op.Key)
+			continue
+		}
+		logger.Infof("Altering quota %s, value: %v, remove: %t", op.Key, op.Value, op.Remove)
+		if err := a.kafkaAdminClient.AlterClientQuotas(entity, op, false); err != nil {
+			return otterizev1alpha3.KafkaQuotas{}, fmt.Errorf("failed altering client quota %s: %w", op.Key, err)
+		}
+	}
+
+	appliedValues, err = a.describeClientQuotas(entity)
+	if err != nil {
+		return otterizev1alpha3.KafkaQuotas{}, err
+	}
+	return valuesToQuotas(appliedValues), nil
+}
@@ -0,0 +1,115 @@
+package kafkaacls
+
+import (
+	"github.com/Shopify/sarama"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	kafkaaclsmocks "github.com/otterize/intents-operator/src/operator/controllers/kafkaacls/mocks"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+type QuotasSuite struct {
+	suite.Suite
+	mockClusterAdmin *kafkaaclsmocks.MockClusterAdmin
+	intentsAdmin     KafkaIntentsAdmin
+	entity           []sarama.QuotaEntityComponent
+	filter           []sarama.QuotaFilterComponent
+}
+
+func (s *QuotasSuite) SetupTest() {
+	controller := gomock.NewController(s.T())
+	s.mockClusterAdmin = kafkaaclsmocks.NewMockClusterAdmin(controller)
+	kafkaServerConfig := otterizev1alpha3.KafkaServerConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kafkaServerConfigResourceName,
+			Namespace: testNamespace,
+		},
+		Spec: otterizev1alpha3.KafkaServerConfigSpec{
+			Service: otterizev1alpha3.Service{Name: serverName},
+			Addr:    serverAddress,
+		},
+	}
+	s.intentsAdmin = NewKafkaIntentsAdminImpl(kafkaServerConfig, s.mockClusterAdmin, "$ServiceName.$Namespace", true, true)
+	s.entity = []sarama.QuotaEntityComponent{{EntityType: sarama.QuotaEntityUser, MatchType: sarama.QuotaMatchExact, Name: "my-client.test-namespace"}}
+	s.filter = []sarama.QuotaFilterComponent{{EntityType: sarama.QuotaEntityUser, MatchType: sarama.QuotaMatchExact, Match: "my-client.test-namespace"}}
+}
+
+func (s *QuotasSuite) describeResult(values map[string]float64) []sarama.DescribeClientQuotasEntry {
+	return []sarama.DescribeClientQuotasEntry{{Entity: s.entity, Values: values}}
+}
+
+func (s *QuotasSuite) TestApplyClientQuotas() {
+	gomock.InOrder(
+		s.mockClusterAdmin.EXPECT().DescribeClientQuotas(s.filter, true).Return(s.describeResult(map[string]float64{ConsumerByteRateQuotaKey: 2048}), nil),
+		s.mockClusterAdmin.EXPECT().AlterClientQuotas(s.entity, sarama.ClientQuotasOp{Key: ProducerByteRateQuotaKey, Value: 1024}, false).Return(nil),
+		s.mockClusterAdmin.EXPECT().DescribeClientQuotas(s.filter, true).Return(s.describeResult(map[string]float64{ProducerByteRateQuotaKey: 1024, ConsumerByteRateQuotaKey: 2048}), nil),
+	)
+
+	applied, err := s.intentsAdmin.ApplyClientQuotas(clientName, testNamespace, "", &otterizev1alpha3.KafkaQuotas{ProducerByteRate: 1024, ConsumerByteRate: 2048})
+	s.Require().NoError(err)
+	s.Require().Equal(otterizev1alpha3.KafkaQuotas{ProducerByteRate: 1024, ConsumerByteRate: 2048}, applied)
+}
+
+func (s *QuotasSuite) TestApplyClientQuotasRemovesUndeclaredByteRates() {
+	gomock.InOrder(
+		s.mockClusterAdmin.EXPECT().DescribeClientQuotas(s.filter, true).Return(s.describeResult(map[string]float64{ConsumerByteRateQuotaKey: 2048, "request_percentage": 50}), nil),
+		s.mockClusterAdmin.EXPECT().AlterClientQuotas(s.entity, sarama.ClientQuotasOp{Key: ConsumerByteRateQuotaKey, Remove: true}, false).Return(nil),
+		s.mockClusterAdmin.EXPECT().DescribeClientQuotas(s.filter, true).Return(s.describeResult(map[string]float64{"request_percentage": 50}), nil),
+	)
+
+	applied, err := s.intentsAdmin.ApplyClientQuotas(clientName, testNamespace, "", nil)
+	s.Require().NoError(err)
+	s.Require().Empty(applied)
+}
+
+func (s *QuotasSuite) TestApplyClientQuotasRequiresUserPrincipal() {
+	kafkaServerConfig := otterizev1alpha3.KafkaServerConfig{
+		Spec: otterizev1alpha3.KafkaServerConfigSpec{PrincipalTemplate: "Group:$ServiceName"},
+	}
+	intentsAdmin := NewKafkaIntentsAdminImpl(kafkaServerConfig, s.mockClusterAdmin, "", true, true)
+
+	_, err := intentsAdmin.ApplyClientQuotas(clientName, testNamespace, "", &otterizev1alpha3.KafkaQuotas{ProducerByteRate: 1024})
+	s.Require().ErrorIs(err, ErrQuotasRequireUserPrincipal)
+
+	applied, err := intentsAdmin.ApplyClientQuotas(clientName, testNamespace, "", nil)
+	s.Require().NoError(err)
+	s.Require().Empty(applied)
+}
+
+func (s *QuotasSuite) TestApplyClientQuotasOnServerWithoutQuotasSupport() {
+	s.mockClusterAdmin.EXPECT().DescribeClientQuotas(s.filter, true).Return(nil, sarama.ErrUnsupportedVersion)
+
+	_, err := s.intentsAdmin.ApplyClientQuotas(clientName, testNamespace, "", &otterizev1alpha3.KafkaQuotas{ProducerByteRate: 1024})
+	s.Require().ErrorIs(err, ErrQuotasNotSupported)
+}
+
+func (s *QuotasSuite) TestApiVersionsSupportQuotas() {
+	keys := func(apiKeys ...int16) *sarama.ApiVersionsResponse {
+		response := &sarama.ApiVersionsResponse{}
+		for _, apiKey := range apiKeys {
+			response.ApiKeys = append(response.ApiKeys, sarama.ApiVersionsResponseKey{ApiKey: apiKey})
+		}
+		return response
+	}
+
+	s.Require().True(apiVersionsSupportQuotas(keys(0, 1, describeClientQuotasApiKey, alterClientQuotasApiKey)))
+	s.Require().False(apiVersionsSupportQuotas(keys(0, 1, describeClientQuotasApiKey)))
+	s.Require().False(apiVersionsSupportQuotas(keys(0, 1)))
+}
+
+func (s *QuotasSuite) TestRemoveClientIntentsKeepsQuotas() {
+	// Quotas are only removed by ApplyClientQuotas, for clients that had quotas applied.
+	gomock.InOrder(
+		s.mockClusterAdmin.EXPECT().DeleteACL(gomock.Any(), true).Return([]sarama.MatchingAcl{}, nil),
+		s.mockClusterAdmin.EXPECT().ListAcls(gomock.Any()).Return([]sarama.ResourceAcls{}, nil),
+	)
+
+	err := s.intentsAdmin.RemoveClientIntents(clientName, testNamespace, "")
+	s.Require().NoError(err)
+}
+
+func TestQuotasSuite(t *testing.T) {
+	suite.Run(t, new(QuotasSuite))
+}
@@ -0,0 +1,165 @@
+package kafkaacls
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/Shopify/sarama"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/samber/lo"
+	"github.com/xdg-go/scram"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+const DefaultSASLUserNameMapping = "$ServiceName.$Namespace"
+
+var (
+	ErrSASLNotSupported             = errors.New("SASL authentication requires a Kubernetes client to read the credentials secret")
+	ErrSASLPlainWithoutTLS          = errors.New("SASL PLAIN authentication sends the password in the clear and requires TLS")
+	ErrCredentialsSecretNotLabelled = fmt.Errorf("Kafka credentials secret must be labelled %s=true", otterizev1alpha3.KafkaCredentialsSecretLabelKey)
+)
+
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+// configureSASL sets up SASL authentication to the Kafka server using the credentials in the secret referenced by its
+// KafkaServerConfig, and returns the user name mapping of client principals when the server has no principal template.
+func configureSASL(ctx context.Context, secretsReader client.Reader, kafkaServer otterizev1alpha3.KafkaServerConfig, config *sarama.Config) (string, error) {
+	auth := kafkaServer.Spec.Auth
+	if secretsReader == nil {
+		return "", ErrSASLNotSupported
+	}
+	if auth.SASLMechanism == otterizev1alpha3.SASLMechanismPlain && auth.Plaintext {
+		return "", ErrSASLPlainWithoutTLS
+	}
+
+	secret := corev1.Secret{}
+	err := secretsReader.Get(ctx, types.NamespacedName{Name: auth.CredentialsSecretName, Namespace: kafkaServer.Namespace}, &secret)
+	if err != nil {
+		return "", fmt.Errorf("failed getting Kafka credentials secret %s: %w", auth.CredentialsSecretName, err)
+	}
+	// Only secrets explicitly marked as Kafka credentials are used, so that a KafkaServerConfig cannot make the operator
+	// send any secret in its namespace to an arbitrary server.
+	if secret.Labels[otterizev1alpha3.KafkaCredentialsSecretLabelKey] != "true" {
+		return "", fmt.Errorf("%w: %s", ErrCredentialsSecretNotLabelled, auth.CredentialsSecretName)
+	}
+
+	config.Net.SASL.Enable = true
+	config.Net.SASL.Handshake = true
+	config.Net.SASL.Version = sarama.SASLHandshakeV1
+	config.Net.SASL.Mechanism = sarama.SASLMechanism(auth.SASLMechanism)
+
+	switch auth.SASLMechanism {
+	case otterizev1alpha3.SASLMechanismPlain, otterizev1alpha3.SASLMechanismSCRAMSHA256, otterizev1alpha3.SASLMechanismSCRAMSHA512:
+		config.Net.SASL.User, err = getSecretValue(secret, otterizev1alpha3.KafkaCredentialsUsernameKey)
+		if err != nil {
+			return "", err
+		}
+		config.Net.SASL.Password, err = getSecretValue(secret, otterizev1alpha3.KafkaCredentialsPasswordKey)
+		if err != nil {
+			return "", err
+		}
+		if auth.SASLMechanism == otterizev1alpha3.SASLMechanismSCRAMSHA256 {
+			config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &scramClient{hashGenerator: scram.SHA256} }
+		} else if auth.SASLMechanism == otterizev1alpha3.SASLMechanismSCRAMSHA512 {
+			config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &scramClient{hashGenerator: scram.SHA512} }
+		}
+	case otterizev1alpha3.SASLMechanismOAuthBearer:
+		tokenProvider, err := newOAuthTokenProvider(secret)
+		if err != nil {
+			return "", err
+		}
+		config.Net.SASL.TokenProvider = tokenProvider
+	default:
+		return "", fmt.Errorf("unsupported SASL mechanism %s", auth.SASLMechanism)
+	}
+
+	if !auth.Plaintext {
+		config.Net.TLS.Enable = true
+		if lo.IsEmpty(kafkaServer.Spec.TLS) {
+			// Verify the server against the system's root CAs.
+			config.Net.TLS.Config = &tls.Config{}
+		} else {
+			tlsConfig, err := getTLSConfig(kafkaServer.Spec.TLS)
+			if err != nil {
+				return "", err
+			}
+			config.Net.TLS.Config = tlsConfig
+		}
+	}
+
+	return DefaultSASLUserNameMapping, nil
+}
+
+func getSecretValue(secret corev1.Secret, key string) (string, error) {
+	value, ok := secret.Data[key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in Kafka credentials secret %s", key, secret.Name)
+	}
+	return string(value), nil
+}
+
+type oauthTokenProvider struct {
+	tokenSource oauth2.TokenSource
+}
+
+func newOAuthTokenProvider(secret corev1.Secret) (*oauthTokenProvider, error) {
+	clientID, err := getSecretValue(secret, otterizev1alpha3.KafkaCredentialsClientIDKey)
+	if err != nil {
+		return nil, err
+	}
+	clientSecret, err := getSecretValue(secret, otterizev1alpha3.KafkaCredentialsClientSecretKey)
+	if err != nil {
+		return nil, err
+	}
+	tokenURL, err := getSecretValue(secret, otterizev1alpha3.KafkaCredentialsTokenURLKey)
+	if err != nil {
+		return nil, err
+	}
+
+	oauthConfig := clientcredentials.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		TokenURL:     tokenURL,
+		Scopes:       strings.Fields(string(secret.Data[otterizev1alpha3.KafkaCredentialsScopesKey])),
+	}
+
+	// The token source caches the token and fetches a new one when it expires.
+	return &oauthTokenProvider{tokenSource: oauthConfig.TokenSource(context.Background())}, nil
+}
+
+func (p *oauthTokenProvider) Token() (*sarama.AccessToken, error) {
+	token, err := p.tokenSource.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed fetching OAuth token: %w", err)
+	}
+	return &sarama.AccessToken{Token: token.AccessToken}, nil
+}
+
+// scramClient adapts the SCRAM conversation of github.com/xdg-go/scram to sarama.
+type scramClient struct {
+	hashGenerator scram.HashGeneratorFcn
+	conversation  *scram.ClientConversation
+}
+
+func (c *scramClient) Begin(userName string, password string, authzID string) error {
+	client, err := c.hashGenerator.NewClient(userName, password, authzID)
+	if err != nil {
+		return fmt.Errorf("failed creating SCRAM client: %w", err)
+	}
+	c.conversation = client.NewConversation()
+	return nil
+}
+
+func (c *scramClient) Step(challenge string) (string, error) {
+	return c.conversation.Step(challenge)
+}
+
+func (c *scramClient) Done() bool {
+	return c.conversation.Done()
+}
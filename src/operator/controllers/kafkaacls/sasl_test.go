@@ -0,0 +1,268 @@
+package kafkaacls
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/Shopify/sarama"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	mocks "github.com/otterize/intents-operator/src/shared/serviceidresolver/mocks"
+	"github.com/stretchr/testify/suite"
+	"github.com/xdg-go/scram"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"testing"
+	"time"
+)
+
+const credentialsSecretName = "kafka-credentials"
+
+type SASLSuite struct {
+	suite.Suite
+	client *mocks.MockClient
+}
+
+func (s *SASLSuite) SetupTest() {
+	controller := gomock.NewController(s.T())
+	s.client = mocks.NewMockClient(controller)
+}
+
+func (s *SASLSuite) TestSCRAMClientAuthenticatesWithServer() {
+	for _, hashGenerator := range []scram.HashGeneratorFcn{scram.SHA256, scram.SHA512} {
+		server, err := hashGenerator.NewServer(func(userName string) (scram.StoredCredentials, error) {
+			client, err := hashGenerator.NewClient(userName, "pencil", "")
+			if err != nil {
+				return scram.StoredCredentials{}, err
+			}
+			return client.GetStoredCredentials(scram.KeyFactors{Salt: "salt", Iters: 4096}), nil
+		})
+		s.Require().NoError(err)
+		serverConversation := server.NewConversation()
+
+		scramClient := &scramClient{hashGenerator: hashGenerator}
+		s.Require().NoError(scramClient.Begin("user", "pencil", ""))
+		challenge := ""
+		for !scramClient.Done() {
+			response, err := scramClient.Step(challenge)
+			s.Require().NoError(err)
+			if scramClient.Done() {
+				break
+			}
+			challenge, err = serverConversation.Step(response)
+			s.Require().NoError(err)
+		}
+		s.Require().True(serverConversation.Valid())
+	}
+}
+
+func (s *SASLSuite) TestSCRAMClientRejectsWrongPassword() {
+	server, err := scram.SHA256.NewServer(func(userName string) (scram.StoredCredentials, error) {
+		client, err := scram.SHA256.NewClient(userName, "pencil", "")
+		if err != nil {
+			return scram.StoredCredentials{}, err
+		}
+		return client.GetStoredCredentials(scram.KeyFactors{Salt: "salt", Iters: 4096}), nil
+	})
+	s.Require().NoError(err)
+	serverConversation := server.NewConversation()
+
+	scramClient := &scramClient{hashGenerator: scram.SHA256}
+	s.Require().NoError(scramClient.Begin("user", "wrong", ""))
+	clientFirst, err := scramClient.Step("")
+	s.Require().NoError(err)
+	serverFirst, err := serverConversation.Step(clientFirst)
+	s.Require().NoError(err)
+	clientFinal, err := scramClient.Step(serverFirst)
+	s.Require().NoError(err)
+	_, err = serverConversation.Step(clientFinal)
+	s.Require().Error(err)
+}
+
+// writeSelfSignedTLSFiles writes a certificate for 127.0.0.1, used as the server and client certificate and as the
+// root CA.
+func (s *SASLSuite) writeSelfSignedTLSFiles() otterizev1alpha3.TLSSource {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s.Require().NoError(err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kafka"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	s.Require().NoError(err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	s.Require().NoError(err)
+
+	dir := s.T().TempDir()
+	tlsSource := otterizev1alpha3.TLSSource{
+		CertFile:   filepath.Join(dir, "cert.pem"),
+		KeyFile:    filepath.Join(dir, "key.pem"),
+		RootCAFile: filepath.Join(dir, "ca.pem"),
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	s.Require().NoError(os.WriteFile(tlsSource.CertFile, certPEM, 0600))
+	s.Require().NoError(os.WriteFile(tlsSource.RootCAFile, certPEM, 0600))
+	s.Require().NoError(os.WriteFile(tlsSource.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return tlsSource
+}
+
+func (s *SASLSuite) expectCredentialsSecret(labels map[string]string, data map[string][]byte) {
+	s.client.EXPECT().Get(gomock.Any(), types.NamespacedName{Name: credentialsSecretName, Namespace: testNamespace}, gomock.AssignableToTypeOf(&corev1.Secret{})).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, secret *corev1.Secret, opts ...client.GetOption) error {
+			secret.Name = credentialsSecretName
+			secret.Labels = labels
+			secret.Data = data
+			return nil
+		})
+}
+
+func (s *SASLSuite) TestConnectWithSASLPlain() {
+	tlsSource := s.writeSelfSignedTLSFiles()
+	serverCert, err := tls.LoadX509KeyPair(tlsSource.CertFile, tlsSource.KeyFile)
+	s.Require().NoError(err)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{serverCert}})
+	s.Require().NoError(err)
+
+	broker := sarama.NewMockBrokerListener(s.T(), 1, listener)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"ApiVersionsRequest":      sarama.NewMockApiVersionsResponse(s.T()),
+		"SaslHandshakeRequest":    sarama.NewMockSaslHandshakeResponse(s.T()).SetEnabledMechanisms([]string{sarama.SASLTypePlaintext}),
+		"SaslAuthenticateRequest": sarama.NewMockSaslAuthenticateResponse(s.T()),
+		"MetadataRequest": sarama.NewMockMetadataResponse(s.T()).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetController(broker.BrokerID()),
+	})
+
+	kafkaServerConfig := otterizev1alpha3.KafkaServerConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kafkaServerConfigResourceName,
+			Namespace: testNamespace,
+		},
+		Spec: otterizev1alpha3.KafkaServerConfigSpec{
+			Service: otterizev1alpha3.Service{Name: serverName},
+			Addr:    broker.Addr(),
+			TLS:     tlsSource,
+			Auth: &otterizev1alpha3.KafkaAuth{
+				SASLMechanism:         otterizev1alpha3.SASLMechanismPlain,
+				CredentialsSecretName: credentialsSecretName,
+			},
+			PrincipalTemplate: "User:$Namespace-$ServiceName",
+		},
+	}
+
+	s.expectCredentialsSecret(
+		map[string]string{otterizev1alpha3.KafkaCredentialsSecretLabelKey: "true"},
+		map[string][]byte{
+			otterizev1alpha3.KafkaCredentialsUsernameKey: []byte("otterize"),
+			otterizev1alpha3.KafkaCredentialsPasswordKey: []byte("secret"),
+		})
+
+	intentsAdmin, err := NewKafkaIntentsAdminFactory(s.client, "")(kafkaServerConfig, otterizev1alpha3.TLSSource{}, true, true)
+	s.Require().NoError(err)
+	defer intentsAdmin.Close()
+
+	s.Require().Equal("User:test-namespace-my-client", intentsAdmin.(*KafkaIntentsAdminImpl).formatPrincipal(clientIdentity{name: "my-client", namespace: testNamespace}))
+
+	var authBytes []byte
+	for _, request := range broker.History() {
+		if authenticateRequest, ok := request.Request.(*sarama.SaslAuthenticateRequest); ok {
+			authBytes = authenticateRequest.SaslAuthBytes
+		}
+	}
+	s.Require().Equal([]byte("\x00otterize\x00secret"), authBytes)
+}
+
+func (s *SASLSuite) TestSASLPlainWithoutTLSRejected() {
+	kafkaServerConfig := otterizev1alpha3.KafkaServerConfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace},
+		Spec: otterizev1alpha3.KafkaServerConfigSpec{
+			Auth: &otterizev1alpha3.KafkaAuth{
+				SASLMechanism:         otterizev1alpha3.SASLMechanismPlain,
+				CredentialsSecretName: credentialsSecretName,
+				Plaintext:             true,
+			},
+		},
+	}
+
+	_, err := configureSASL(context.Background(), s.client, kafkaServerConfig, sarama.NewConfig())
+	s.Require().ErrorIs(err, ErrSASLPlainWithoutTLS)
+}
+
+func (s *SASLSuite) TestUnlabelledCredentialsSecretRejected() {
+	kafkaServerConfig := otterizev1alpha3.KafkaServerConfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace},
+		Spec: otterizev1alpha3.KafkaServerConfigSpec{
+			Auth: &otterizev1alpha3.KafkaAuth{
+				SASLMechanism:         otterizev1alpha3.SASLMechanismSCRAMSHA512,
+				CredentialsSecretName: credentialsSecretName,
+			},
+		},
+	}
+
+	s.expectCredentialsSecret(nil, map[string][]byte{
+		otterizev1alpha3.KafkaCredentialsUsernameKey: []byte("otterize"),
+		otterizev1alpha3.KafkaCredentialsPasswordKey: []byte("secret"),
+	})
+
+	_, err := configureSASL(context.Background(), s.client, kafkaServerConfig, sarama.NewConfig())
+	s.Require().ErrorIs(err, ErrCredentialsSecretNotLabelled)
+}
+
+func (s *SASLSuite) TestMissingCredentialsKey() {
+	kafkaServerConfig := otterizev1alpha3.KafkaServerConfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace},
+		Spec: otterizev1alpha3.KafkaServerConfigSpec{
+			Auth: &otterizev1alpha3.KafkaAuth{
+				SASLMechanism:         otterizev1alpha3.SASLMechanismOAuthBearer,
+				CredentialsSecretName: credentialsSecretName,
+			},
+		},
+	}
+
+	s.client.EXPECT().Get(gomock.Any(), types.NamespacedName{Name: credentialsSecretName, Namespace: testNamespace}, gomock.AssignableToTypeOf(&corev1.Secret{})).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, secret *corev1.Secret, opts ...client.GetOption) error {
+			secret.Labels = map[string]string{otterizev1alpha3.KafkaCredentialsSecretLabelKey: "true"}
+			secret.Data = map[string][]byte{otterizev1alpha3.KafkaCredentialsClientIDKey: []byte("otterize")}
+			return nil
+		})
+
+	_, err := configureSASL(context.Background(), s.client, kafkaServerConfig, sarama.NewConfig())
+	s.Require().ErrorContains(err, otterizev1alpha3.KafkaCredentialsClientSecretKey)
+}
+
+func (s *SASLSuite) TestSASLRequiresSecretsReader() {
+	kafkaServerConfig := otterizev1alpha3.KafkaServerConfig{
+		Spec: otterizev1alpha3.KafkaServerConfigSpec{
+			Auth: &otterizev1alpha3.KafkaAuth{
+				SASLMechanism:         otterizev1alpha3.SASLMechanismSCRAMSHA512,
+				CredentialsSecretName: credentialsSecretName,
+			},
+		},
+	}
+
+	_, err := NewKafkaIntentsAdmin(kafkaServerConfig, otterizev1alpha3.TLSSource{}, true, true)
+	s.Require().ErrorIs(err, ErrSASLNotSupported)
+}
+
+func TestSASLSuite(t *testing.T) {
+	suite.Run(t, new(SASLSuite))
+}
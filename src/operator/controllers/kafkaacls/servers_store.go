@@ -24,15 +24,19 @@ type ServersStoreImpl struct {
 	tlsSourceFiles              otterizev1alpha3.TLSSource
 	IntentsAdminFactoryFunction IntentsAdminFactoryFunction
 	enforcementDefaultState     bool
+	connectionPool              *ConnectionPool
 }
 
-func NewServersStore(tlsSourceFiles otterizev1alpha3.TLSSource, enableKafkaACLCreation bool, factoryFunc IntentsAdminFactoryFunction, enforcementDefaultState bool) *ServersStoreImpl {
+// NewServersStore returns a store whose KafkaIntentsAdmins are made by factoryFunc. If the factory shares the
+// connections of connectionPool, the connections of servers removed from the store are closed. connectionPool may be nil.
+func NewServersStore(tlsSourceFiles otterizev1alpha3.TLSSource, enableKafkaACLCreation bool, factoryFunc IntentsAdminFactoryFunction, enforcementDefaultState bool, connectionPool *ConnectionPool) *ServersStoreImpl {
 	return &ServersStoreImpl{
 		serversByName:               map[types.NamespacedName]*otterizev1alpha3.KafkaServerConfig{},
 		enableKafkaACLCreation:      enableKafkaACLCreation,
 		tlsSourceFiles:              tlsSourceFiles,
 		IntentsAdminFactoryFunction: factoryFunc,
 		enforcementDefaultState:     enforcementDefaultState,
+		connectionPool:              connectionPool,
 	}
 }
 
@@ -44,6 +48,9 @@ func (s *ServersStoreImpl) Add(config *otterizev1alpha3.KafkaServerConfig) {
 func (s *ServersStoreImpl) Remove(serverName string, namespace string) {
 	name := types.NamespacedName{Name: serverName, Namespace: namespace}
 	delete(s.serversByName, name)
+	if s.connectionPool != nil {
+		s.connectionPool.Remove(name)
+	}
 }
 
 func (s *ServersStoreImpl) Exists(serverName string, namespace string) bool {
@@ -0,0 +1,663 @@
+package kafkaacls
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Shopify/sarama"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+//+kubebuilder:rbac:groups="kafka.strimzi.io",resources=kafkausers,verbs=get;list;watch;create;update;patch;delete
+
+const (
+	StrimziClusterLabelKey = "strimzi.io/cluster"
+	// StrimziIntentsACLsAnnotation and StrimziTopicConfigACLsAnnotation hold the ACL rules of a KafkaUser that were
+	// created from client intents and from the server's topic configuration, to tell them apart from rules managed by
+	// others.
+	StrimziIntentsACLsAnnotation       = "intents.otterize.com/kafka-intents-acls"
+	StrimziTopicConfigACLsAnnotation   = "intents.otterize.com/kafka-topic-config-acls"
+	StrimziCreatedByOtterizeAnnotation = "intents.otterize.com/created-by-otterize"
+	// StrimziQuotasAnnotation holds the quotas of a KafkaUser that were set from client intents, so that quotas set by
+	// others are kept.
+	StrimziQuotasAnnotation = "intents.otterize.com/kafka-quotas"
+)
+
+var (
+	ErrStrimziNotSupported     = errors.New("managing ACLs through Strimzi requires a Kubernetes client")
+	ErrInvalidStrimziPrincipal = errors.New("Strimzi principals must be of the form User:<name> or User:CN=<name>, where <name> is a valid KafkaUser name")
+	ErrStrimziTopicManagement  = errors.New("topics of Strimzi clusters are managed through KafkaTopic resources, not topic management")
+
+	KafkaUserGroupVersionKind = schema.GroupVersionKind{Group: "kafka.strimzi.io", Version: "v1beta2", Kind: "KafkaUser"}
+
+	saramaResourceTypeToStrimziResourceType = map[sarama.AclResourceType]string{
+		sarama.AclResourceTopic:           "topic",
+		sarama.AclResourceGroup:           "group",
+		sarama.AclResourceCluster:         "cluster",
+		sarama.AclResourceTransactionalID: "transactionalId",
+	}
+	saramaPatternTypeToStrimziPatternType = map[sarama.AclResourcePatternType]string{
+		sarama.AclPatternLiteral:  "literal",
+		sarama.AclPatternPrefixed: "prefix",
+	}
+)
+
+type strimziACLResource struct {
+	Type        string `json:"type"`
+	Name        string `json:"name,omitempty"`
+	PatternType string `json:"patternType,omitempty"`
+}
+
+// strimziACLRule is an entry of KafkaUser.spec.authorization.acls.
+type strimziACLRule struct {
+	Resource   strimziACLResource `json:"resource"`
+	Operations []string           `json:"operations"`
+	Host       string             `json:"host"`
+}
+
+// StrimziIntentsAdmin manages the ACLs and quotas of clients through the KafkaUser resources of a Strimzi cluster,
+// as the Strimzi User Operator overwrites ACLs created through the Kafka admin API. ACL rules in KafkaUsers that were
+// not created by the operator are kept.
+type StrimziIntentsAdmin struct {
+	client                      client.Client
+	kafkaServer                 otterizev1alpha3.KafkaServerConfig
+	principalTemplate           string
+	clusterName                 string
+	enableKafkaACLCreation      bool
+	enforcementEnabledForServer bool
+}
+
+// NewStrimziIntentsAdmin formats client principals using the KafkaServerConfig's principal template, or as users named
+// by DefaultSASLUserNameMapping when it has none. The KafkaServerConfig must have a Strimzi configuration.
+func NewStrimziIntentsAdmin(k8sClient client.Client, kafkaServer otterizev1alpha3.KafkaServerConfig, clusterName string, enableKafkaACLCreation bool, enforcementEnabledForServer bool) KafkaIntentsAdmin {
+	return newStrimziIntentsAdmin(k8sClient, kafkaServer, clusterName, enableKafkaACLCreation, enforcementEnabledForServer)
+}
+
+func newStrimziIntentsAdmin(k8sClient client.Client, kafkaServer otterizev1alpha3.KafkaServerConfig, clusterName string, enableKafkaACLCreation bool, enforcementEnabledForServer bool) *StrimziIntentsAdmin {
+	principalTemplate := kafkaServer.Spec.PrincipalTemplate
+	if principalTemplate == "" {
+		principalTemplate = fmt.Sprintf("User:%s", DefaultSASLUserNameMapping)
+	}
+	return &StrimziIntentsAdmin{
+		client:                      k8sClient,
+		kafkaServer:                 kafkaServer,
+		principalTemplate:           principalTemplate,
+		clusterName:                 clusterName,
+		enableKafkaACLCreation:      enableKafkaACLCreation,
+		enforcementEnabledForServer: enforcementEnabledForServer,
+	}
+}
+
+func (a *StrimziIntentsAdmin) Close() {}
+
+func (a *StrimziIntentsAdmin) ApplyTopics(_ otterizev1alpha3.KafkaTopicManagement, _ []string, _ []string) (otterizev1alpha3.KafkaTopicsStatus, error) {
+	return otterizev1alpha3.KafkaTopicsStatus{}, ErrStrimziTopicManagement
+}
+
+func (a *StrimziIntentsAdmin) logger() *logrus.Entry {
+	return logrus.WithFields(
+		logrus.Fields{
+			"serverName":      a.kafkaServer.Spec.Service,
+			"serverNamespace": a.kafkaServer.Namespace,
+			"strimziCluster":  a.kafkaServer.Spec.Strimzi.ClusterName,
+		})
+}
+
+func (a *StrimziIntentsAdmin) namespace() string {
+	return lo.Ternary(a.kafkaServer.Spec.Strimzi.Namespace != "", a.kafkaServer.Spec.Strimzi.Namespace, a.kafkaServer.Namespace)
+}
+
+func (a *StrimziIntentsAdmin) serverLabelValue() string {
+	return otterizev1alpha3.GetFormattedOtterizeIdentity(a.kafkaServer.Spec.Service.Name, a.kafkaServer.Namespace)
+}
+
+func (a *StrimziIntentsAdmin) FormatClientPrincipal(clientName string, clientNamespace string, clientServiceAccount string) string {
+	return formatPrincipalTemplate(a.principalTemplate, clientIdentity{name: clientName, namespace: clientNamespace, serviceAccount: clientServiceAccount}, a.clusterName)
+}
+
+// kafkaUserName returns the name of the KafkaUser of the client, which Strimzi maps to the principal User:<name> with
+// SCRAM authentication, or User:CN=<name> with TLS authentication.
+func (a *StrimziIntentsAdmin) kafkaUserName(client clientIdentity) (string, error) {
+	principal := formatPrincipalTemplate(a.principalTemplate, client, a.clusterName)
+	principalType, name, _ := strings.Cut(principal, ":")
+	name = strings.TrimPrefix(name, "CN=")
+	if principalType != userPrincipalType || len(validation.IsDNS1123Subdomain(name)) != 0 {
+		return "", fmt.Errorf("%w, principal is %s", ErrInvalidStrimziPrincipal, principal)
+	}
+	return name, nil
+}
+
+func (a *StrimziIntentsAdmin) aclCreationEnabled() bool {
+	return a.enforcementEnabledForServer && a.enableKafkaACLCreation
+}
+
+func (a *StrimziIntentsAdmin) getKafkaUser(ctx context.Context, name string) (*unstructured.Unstructured, error) {
+	user := &unstructured.Unstructured{}
+	user.SetGroupVersionKind(KafkaUserGroupVersionKind)
+	err := a.client.Get(ctx, types.NamespacedName{Name: name, Namespace: a.namespace()}, user)
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed getting KafkaUser %s: %w", name, err)
+	}
+	return user, nil
+}
+
+func (a *StrimziIntentsAdmin) listKafkaUsers(ctx context.Context) ([]unstructured.Unstructured, error) {
+	users := &unstructured.UnstructuredList{}
+	users.SetGroupVersionKind(KafkaUserGroupVersionKind.GroupVersion().WithKind(KafkaUserGroupVersionKind.Kind + "List"))
+	err := a.client.List(ctx, users,
+		client.InNamespace(a.namespace()),
+		client.MatchingLabels{otterizev1alpha3.OtterizeKafkaServerLabelKey: a.serverLabelValue()})
+	if err != nil {
+		return nil, fmt.Errorf("failed listing KafkaUsers: %w", err)
+	}
+	return users.Items, nil
+}
+
+// updateKafkaUser applies mutate to the KafkaUser and updates it if it changed. A missing KafkaUser is created if
+// mutate changes it and createIfMissing is set.
+func (a *StrimziIntentsAdmin) updateKafkaUser(ctx context.Context, name string, createIfMissing bool, mutate func(user *unstructured.Unstructured) error) error {
+	user, err := a.getKafkaUser(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if user == nil {
+		user = &unstructured.Unstructured{}
+		user.SetGroupVersionKind(KafkaUserGroupVersionKind)
+		user.SetName(name)
+		user.SetNamespace(a.namespace())
+		user.Object["spec"] = map[string]interface{}{}
+		if err := mutate(user); err != nil {
+			return err
+		}
+		if !createIfMissing || reflect.DeepEqual(user.Object["spec"], map[string]interface{}{}) {
+			return nil
+		}
+		a.setLabels(user)
+		user.SetAnnotations(lo.Assign(user.GetAnnotations(), map[string]string{StrimziCreatedByOtterizeAnnotation: "true"}))
+		a.logger().Infof("Creating KafkaUser %s", name)
+		if err := a.client.Create(ctx, user); err != nil {
+			return fmt.Errorf("failed creating KafkaUser %s: %w", name, err)
+		}
+		return nil
+	}
+
+	original := user.DeepCopy()
+	if err := mutate(user); err != nil {
+		return err
+	}
+	if reflect.DeepEqual(original.Object, user.Object) {
+		return nil
+	}
+	a.setLabels(user)
+	a.logger().Infof("Updating KafkaUser %s", name)
+	if err := a.client.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed updating KafkaUser %s: %w", name, err)
+	}
+	return nil
+}
+
+func (a *StrimziIntentsAdmin) setLabels(user *unstructured.Unstructured) {
+	user.SetLabels(lo.Assign(user.GetLabels(), map[string]string{
+		StrimziClusterLabelKey:                       a.kafkaServer.Spec.Strimzi.ClusterName,
+		otterizev1alpha3.OtterizeKafkaServerLabelKey: a.serverLabelValue(),
+	}))
+}
+
+// removeFromKafkaUser deletes the KafkaUser if the operator created it, or otherwise removes the ACL rules and quotas the
+// operator manages from it.
+func (a *StrimziIntentsAdmin) removeFromKafkaUser(ctx context.Context, user *unstructured.Unstructured) error {
+	if user.GetAnnotations()[StrimziCreatedByOtterizeAnnotation] == "true" {
+		a.logger().Infof("Deleting KafkaUser %s", user.GetName())
+		if err := a.client.Delete(ctx, user); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("failed deleting KafkaUser %s: %w", user.GetName(), err)
+		}
+		return nil
+	}
+
+	for _, annotation := range []string{StrimziIntentsACLsAnnotation, StrimziTopicConfigACLsAnnotation} {
+		if err := setManagedACLRules(user, annotation, nil); err != nil {
+			return err
+		}
+	}
+	if err := setManagedQuotas(user, otterizev1alpha3.KafkaQuotas{}); err != nil {
+		return err
+	}
+	labels := user.GetLabels()
+	delete(labels, otterizev1alpha3.OtterizeKafkaServerLabelKey)
+	user.SetLabels(labels)
+
+	a.logger().Infof("Removing managed ACL rules from KafkaUser %s", user.GetName())
+	if err := a.client.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed updating KafkaUser %s: %w", user.GetName(), err)
+	}
+	return nil
+}
+
+// getStrimziACLRules converts the resources to ACL rules, merging the operations of each resource.
+func getStrimziACLRules(resources []intentResource) ([]strimziACLRule, error) {
+	rules := make([]strimziACLRule, 0)
+	indexByResource := map[strimziACLResource]int{}
+	for _, resource := range resources {
+		resourceType, ok := saramaResourceTypeToStrimziResourceType[resource.ResourceType]
+		if !ok {
+			return nil, fmt.Errorf("unsupported resource type %s", resource.ResourceType.String())
+		}
+		strimziResource := strimziACLResource{Type: resourceType}
+		if resource.ResourceType != sarama.AclResourceCluster {
+			strimziResource.Name = resource.ResourceName
+			strimziResource.PatternType = saramaPatternTypeToStrimziPatternType[resource.ResourcePatternType]
+		}
+
+		index, ok := indexByResource[strimziResource]
+		if !ok {
+			index = len(rules)
+			indexByResource[strimziResource] = index
+			rules = append(rules, strimziACLRule{Resource: strimziResource, Operations: make([]string, 0), Host: "*"})
+		}
+
+		for _, operation := range resource.Operations {
+			aclOperation, ok := KafkaOperationToAclOperationBMap.Get(operation)
+			if !ok {
+				return nil, fmt.Errorf("unknown operation '%v'", operation)
+			}
+			if !lo.Contains(rules[index].Operations, aclOperation.String()) {
+				rules[index].Operations = append(rules[index].Operations, aclOperation.String())
+			}
+		}
+	}
+	return rules, nil
+}
+
+func getConsumerGroupResources(intents []otterizev1alpha3.Intent) []intentResource {
+	operations := lo.Map(consumerGroupOperations, func(operation sarama.AclOperation, _ int) otterizev1alpha3.KafkaOperation {
+		kafkaOperation, _ := KafkaOperationToAclOperationBMap.GetInverse(operation)
+		return kafkaOperation
+	})
+
+	resources := make([]intentResource, 0)
+	for _, intent := range intents {
+		for _, consumerGroup := range intent.ConsumerGroups {
+			pattern := lo.Ternary(consumerGroup.Pattern == "", otterizev1alpha3.ResourcePatternTypeLiteral, consumerGroup.Pattern)
+			resources = append(resources, intentResource{
+				Resource:   sarama.Resource{ResourceType: sarama.AclResourceGroup, ResourceName: consumerGroup.Name, ResourcePatternType: kafkaPatternTypeToSaramaPatternType[pattern]},
+				Operations: operations,
+			})
+		}
+	}
+	return resources
+}
+
+// getTopicConfigResources returns the resources every KafkaUser of the server may access according to its topic
+// configuration. KafkaUsers cannot grant access to anonymous users, so ClientIdentityRequired is not supported.
+func (a *StrimziIntentsAdmin) getTopicConfigResources(topicsConf []otterizev1alpha3.TopicConfig) []intentResource {
+	resources := make([]intentResource, 0)
+	for _, topicConfig := range topicsConf {
+		if !topicConfig.ClientIdentityRequired {
+			a.logger().Warnf("Anonymous access to topic %s is not supported with Strimzi, configure it in the Kafka resource instead", topicConfig.Topic)
+		}
+		if topicConfig.IntentsRequired {
+			continue
+		}
+		resources = append(resources, intentResource{
+			Resource:   sarama.Resource{ResourceType: sarama.AclResourceTopic, ResourceName: topicConfig.Topic, ResourcePatternType: kafkaPatternTypeToSaramaPatternType[topicConfig.Pattern]},
+			Operations: []otterizev1alpha3.KafkaOperation{otterizev1alpha3.KafkaOperationAll},
+		})
+	}
+
+	if !a.kafkaServer.Spec.RestrictConsumerGroups {
+		resources = append(resources, getConsumerGroupResources([]otterizev1alpha3.Intent{
+			{ConsumerGroups: []otterizev1alpha3.KafkaConsumerGroup{{Name: "*", Pattern: otterizev1alpha3.ResourcePatternTypeLiteral}}},
+		})...)
+	}
+	return resources
+}
+
+func getManagedACLRules(user *unstructured.Unstructured, annotation string) ([]strimziACLRule, error) {
+	rules := make([]strimziACLRule, 0)
+	value, ok := user.GetAnnotations()[annotation]
+	if !ok {
+		return rules, nil
+	}
+	if err := json.Unmarshal([]byte(value), &rules); err != nil {
+		return nil, fmt.Errorf("failed parsing annotation %s of KafkaUser %s: %w", annotation, user.GetName(), err)
+	}
+	return rules, nil
+}
+
+// setManagedACLRules replaces the ACL rules of the KafkaUser listed in the annotation with rules, and keeps all others.
+func setManagedACLRules(user *unstructured.Unstructured, annotation string, rules []strimziACLRule) error {
+	previousRules, err := getManagedACLRules(user, annotation)
+	if err != nil {
+		return err
+	}
+
+	entries, _, err := unstructured.NestedSlice(user.Object, "spec", "authorization", "acls")
+	if err != nil {
+		return fmt.Errorf("failed reading ACLs of KafkaUser %s: %w", user.GetName(), err)
+	}
+
+	// Each managed rule was appended as its own entry, so only one entry is removed for it. An identical entry added by
+	// others is kept.
+	keptEntries := entries
+	for _, previousRule := range previousRules {
+		_, index, found := lo.FindLastIndexOf(keptEntries, func(entry interface{}) bool {
+			rule, ok := entryToStrimziACLRule(entry)
+			return ok && reflect.DeepEqual(rule, previousRule)
+		})
+		if found {
+			keptEntries = append(keptEntries[:index:index], keptEntries[index+1:]...)
+		}
+	}
+
+	for _, rule := range rules {
+		entry, err := strimziACLRuleToEntry(rule)
+		if err != nil {
+			return err
+		}
+		keptEntries = append(keptEntries, entry)
+	}
+
+	if len(keptEntries) == 0 {
+		unstructured.RemoveNestedField(user.Object, "spec", "authorization")
+	} else {
+		if err := unstructured.SetNestedField(user.Object, "simple", "spec", "authorization", "type"); err != nil {
+			return err
+		}
+		if err := unstructured.SetNestedSlice(user.Object, keptEntries, "spec", "authorization", "acls"); err != nil {
+			return err
+		}
+	}
+
+	annotations := user.GetAnnotations()
+	if len(rules) == 0 {
+		delete(annotations, annotation)
+	} else {
+		value, err := json.Marshal(rules)
+		if err != nil {
+			return err
+		}
+		annotations = lo.Assign(annotations, map[string]string{annotation: string(value)})
+	}
+	user.SetAnnotations(annotations)
+	return nil
+}
+
+func entryToStrimziACLRule(entry interface{}) (strimziACLRule, bool) {
+	rule := strimziACLRule{}
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return rule, false
+	}
+	return rule, json.Unmarshal(value, &rule) == nil
+}
+
+func strimziACLRuleToEntry(rule strimziACLRule) (interface{}, error) {
+	value, err := json.Marshal(rule)
+	if err != nil {
+		return nil, err
+	}
+	entry := map[string]interface{}{}
+	if err := json.Unmarshal(value, &entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// filterAddedACLRules keeps only rules that are already applied, for when ACL creation is disabled.
+func filterAddedACLRules(user *unstructured.Unstructured, annotation string, rules []strimziACLRule) ([]strimziACLRule, error) {
+	previousRules, err := getManagedACLRules(user, annotation)
+	if err != nil {
+		return nil, err
+	}
+	return lo.Filter(rules, func(rule strimziACLRule, _ int) bool {
+		return lo.ContainsBy(previousRules, func(previousRule strimziACLRule) bool {
+			return reflect.DeepEqual(rule, previousRule)
+		})
+	}), nil
+}
+
+func (a *StrimziIntentsAdmin) applyManagedACLRules(user *unstructured.Unstructured, annotation string, rules []strimziACLRule) error {
+	if !a.aclCreationEnabled() {
+		filteredRules, err := filterAddedACLRules(user, annotation, rules)
+		if err != nil {
+			return err
+		}
+		if len(filteredRules) != len(rules) {
+			a.logger().Infof("Skipped adding %d ACL rules to KafkaUser %s because enforcement or Kafka ACL creation is disabled", len(rules)-len(filteredRules), user.GetName())
+		}
+		rules = filteredRules
+	}
+	return setManagedACLRules(user, annotation, rules)
+}
+
+func getQuotas(user *unstructured.Unstructured) otterizev1alpha3.KafkaQuotas {
+	producerByteRate, _, _ := unstructured.NestedInt64(user.Object, "spec", "quotas", "producerByteRate")
+	consumerByteRate, _, _ := unstructured.NestedInt64(user.Object, "spec", "quotas", "consumerByteRate")
+	return otterizev1alpha3.KafkaQuotas{ProducerByteRate: producerByteRate, ConsumerByteRate: consumerByteRate}
+}
+
+func getManagedQuotas(user *unstructured.Unstructured) (otterizev1alpha3.KafkaQuotas, error) {
+	quotas := otterizev1alpha3.KafkaQuotas{}
+	value, ok := user.GetAnnotations()[StrimziQuotasAnnotation]
+	if !ok {
+		return quotas, nil
+	}
+	if err := json.Unmarshal([]byte(value), &quotas); err != nil {
+		return quotas, fmt.Errorf("failed parsing annotation %s of KafkaUser %s: %w", StrimziQuotasAnnotation, user.GetName(), err)
+	}
+	return quotas, nil
+}
+
+// setManagedQuotas sets the byte rate quotas of the KafkaUser that are not zero, and removes those that are zero if they
+// were set by the operator and not changed since. Quotas set by others are kept.
+func setManagedQuotas(user *unstructured.Unstructured, quotas otterizev1alpha3.KafkaQuotas) error {
+	previousQuotas, err := getManagedQuotas(user)
+	if err != nil {
+		return err
+	}
+	currentQuotas := getQuotas(user)
+
+	for _, quota := range []struct {
+		key                      string
+		value, previous, current int64
+	}{
+		{"producerByteRate", quotas.ProducerByteRate, previousQuotas.ProducerByteRate, currentQuotas.ProducerByteRate},
+		{"consumerByteRate", quotas.ConsumerByteRate, previousQuotas.ConsumerByteRate, currentQuotas.ConsumerByteRate},
+	} {
+		if quota.value != 0 {
+			_ = unstructured.SetNestedField(user.Object, quota.value, "spec", "quotas", quota.key)
+		} else if quota.previous != 0 && quota.previous == quota.current {
+			unstructured.RemoveNestedField(user.Object, "spec", "quotas", quota.key)
+		}
+	}
+	if currentQuotas, found, _ := unstructured.NestedMap(user.Object, "spec", "quotas"); found && len(currentQuotas) == 0 {
+		unstructured.RemoveNestedField(user.Object, "spec", "quotas")
+	}
+
+	annotations := user.GetAnnotations()
+	if lo.IsEmpty(quotas) {
+		delete(annotations, StrimziQuotasAnnotation)
+	} else {
+		value, err := json.Marshal(quotas)
+		if err != nil {
+			return err
+		}
+		annotations = lo.Assign(annotations, map[string]string{StrimziQuotasAnnotation: string(value)})
+	}
+	user.SetAnnotations(annotations)
+	return nil
+}
+
+func (a *StrimziIntentsAdmin) ApplyClientIntents(clientName string, clientNamespace string, clientServiceAccount string, intents []otterizev1alpha3.Intent) error {
+	name, err := a.kafkaUserName(clientIdentity{name: clientName, namespace: clientNamespace, serviceAccount: clientServiceAccount})
+	if err != nil {
+		return err
+	}
+	if err := checkIntentResourcesAllowed(a.kafkaServer.Spec, intents); err != nil {
+		return err
+	}
+
+	intentsRules, err := getStrimziACLRules(append(getIntentResources(a.kafkaServer.Spec, intents), getConsumerGroupResources(intents)...))
+	if err != nil {
+		return fmt.Errorf("failed collecting ACL rules: %w", err)
+	}
+	topicConfigRules, err := getStrimziACLRules(a.getTopicConfigResources(a.kafkaServer.Spec.Topics))
+	if err != nil {
+		return fmt.Errorf("failed collecting topic configuration ACL rules: %w", err)
+	}
+
+	return a.updateKafkaUser(context.Background(), name, a.aclCreationEnabled(), func(user *unstructured.Unstructured) error {
+		if err := a.applyManagedACLRules(user, StrimziIntentsACLsAnnotation, intentsRules); err != nil {
+			return err
+		}
+		// Rules from the topic configuration are only needed by users with intents.
+		return a.applyManagedACLRules(user, StrimziTopicConfigACLsAnnotation, lo.Ternary(len(intentsRules) == 0, nil, topicConfigRules))
+	})
+}
+
+// ApplyClientQuotas sets the byte rate quotas of the client's KafkaUser, and removes those that are no longer declared.
+// It returns the quotas of the KafkaUser after applying them.
+func (a *StrimziIntentsAdmin) ApplyClientQuotas(clientName string, clientNamespace string, clientServiceAccount string, quotas *otterizev1alpha3.KafkaQuotas) (otterizev1alpha3.KafkaQuotas, error) {
+	name, err := a.kafkaUserName(clientIdentity{name: clientName, namespace: clientNamespace, serviceAccount: clientServiceAccount})
+	if err != nil {
+		if quotas != nil {
+			return otterizev1alpha3.KafkaQuotas{}, err
+		}
+		return otterizev1alpha3.KafkaQuotas{}, nil
+	}
+
+	appliedQuotas := otterizev1alpha3.KafkaQuotas{}
+	err = a.updateKafkaUser(context.Background(), name, a.aclCreationEnabled(), func(user *unstructured.Unstructured) error {
+		expectedQuotas := lo.FromPtr(quotas)
+		if !a.aclCreationEnabled() {
+			// Quotas may only be removed, not set.
+			managedQuotas, err := getManagedQuotas(user)
+			if err != nil {
+				return err
+			}
+			expectedQuotas = otterizev1alpha3.KafkaQuotas{
+				ProducerByteRate: lo.Ternary(expectedQuotas.ProducerByteRate == managedQuotas.ProducerByteRate, expectedQuotas.ProducerByteRate, 0),
+				ConsumerByteRate: lo.Ternary(expectedQuotas.ConsumerByteRate == managedQuotas.ConsumerByteRate, expectedQuotas.ConsumerByteRate, 0),
+			}
+		}
+		if err := setManagedQuotas(user, expectedQuotas); err != nil {
+			return err
+		}
+		appliedQuotas = getQuotas(user)
+		return nil
+	})
+	if err != nil {
+		return otterizev1alpha3.KafkaQuotas{}, err
+	}
+	if !a.aclCreationEnabled() && quotas != nil && appliedQuotas != *quotas {
+		a.logger().Infof("Skipped setting quotas of KafkaUser %s because enforcement or Kafka ACL creation is disabled", name)
+	}
+	return appliedQuotas, nil
+}
+
+func (a *StrimziIntentsAdmin) RemoveClientIntents(clientName string, clientNamespace string, clientServiceAccount string) error {
+	name, err := a.kafkaUserName(clientIdentity{name: clientName, namespace: clientNamespace, serviceAccount: clientServiceAccount})
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	user, err := a.getKafkaUser(ctx, name)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+	return a.removeFromKafkaUser(ctx, user)
+}
+
+// ApplyServerTopicsConf updates the ACL rules from the topic configuration in the KafkaUsers of all clients of the
+// server.
+func (a *StrimziIntentsAdmin) ApplyServerTopicsConf(topicsConf []otterizev1alpha3.TopicConfig) error {
+	rules, err := getStrimziACLRules(a.getTopicConfigResources(topicsConf))
+	if err != nil {
+		return fmt.Errorf("failed collecting topic configuration ACL rules: %w", err)
+	}
+	return a.setTopicConfigACLRules(rules)
+}
+
+// RemoveServerIntents removes the ACL rules from the topic configuration from the KafkaUsers of all clients of the
+// server.
+func (a *StrimziIntentsAdmin) RemoveServerIntents(_ []otterizev1alpha3.TopicConfig) error {
+	return a.setTopicConfigACLRules(nil)
+}
+
+func (a *StrimziIntentsAdmin) setTopicConfigACLRules(rules []strimziACLRule) error {
+	ctx := context.Background()
+	users, err := a.listKafkaUsers(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		intentsRules, err := getManagedACLRules(&user, StrimziIntentsACLsAnnotation)
+		if err != nil {
+			return err
+		}
+		err = a.updateKafkaUser(ctx, user.GetName(), false, func(user *unstructured.Unstructured) error {
+			return a.applyManagedACLRules(user, StrimziTopicConfigACLsAnnotation, lo.Ternary(len(intentsRules) == 0, nil, rules))
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveOrphanedClientACLs removes the ACL rules and quotas the operator manages from KafkaUsers of the server that do
+// not belong to any of activeClients, and deletes such KafkaUsers that it created. managedPrincipals is not needed, as
+// the rules the operator manages are recorded on the KafkaUsers themselves.
+// Returns the number of orphaned KafkaUsers found; when dryRun is set, they are only logged.
+func (a *StrimziIntentsAdmin) RemoveOrphanedClientACLs(_ []string, activeClients []types.NamespacedName, clientServiceAccounts map[types.NamespacedName]string, dryRun bool) (int, error) {
+	ctx := context.Background()
+	activeUserNames := sets.New[string]()
+	for _, activeClient := range activeClients {
+		name, err := a.kafkaUserName(clientIdentity{name: activeClient.Name, namespace: activeClient.Namespace, serviceAccount: clientServiceAccounts[activeClient]})
+		if err != nil {
+			continue
+		}
+		activeUserNames.Insert(name)
+	}
+
+	users, err := a.listKafkaUsers(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	orphanedUsers := lo.Filter(users, func(user unstructured.Unstructured, _ int) bool {
+		return !activeUserNames.Has(user.GetName())
+	})
+	for _, user := range orphanedUsers {
+		if dryRun {
+			a.logger().Infof("Dry run, not removing ACL rules of orphaned KafkaUser %s", user.GetName())
+			continue
+		}
+		if err := a.removeFromKafkaUser(ctx, &user); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(orphanedUsers), nil
+}
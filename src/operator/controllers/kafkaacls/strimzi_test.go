@@ -0,0 +1,306 @@
+package kafkaacls
+
+import (
+	"context"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	mocks "github.com/otterize/intents-operator/src/shared/serviceidresolver/mocks"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"testing"
+)
+
+const (
+	strimziClusterName = "my-cluster"
+	kafkaUserName      = "my-client.test-namespace"
+)
+
+type StrimziSuite struct {
+	suite.Suite
+	client       *mocks.MockClient
+	intentsAdmin KafkaIntentsAdmin
+}
+
+func (s *StrimziSuite) SetupTest() {
+	controller := gomock.NewController(s.T())
+	s.client = mocks.NewMockClient(controller)
+	kafkaServerConfig := otterizev1alpha3.KafkaServerConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kafkaServerConfigResourceName,
+			Namespace: testNamespace,
+		},
+		Spec: otterizev1alpha3.KafkaServerConfigSpec{
+			Service:                  otterizev1alpha3.Service{Name: serverName},
+			Strimzi:                  &otterizev1alpha3.StrimziConfig{ClusterName: strimziClusterName},
+			RestrictConsumerGroups:   true,
+			AllowedClusterOperations: []otterizev1alpha3.KafkaOperation{otterizev1alpha3.KafkaOperationIdempotentWrite},
+		},
+	}
+
+	intentsAdmin, err := NewKafkaIntentsAdminFactory(s.client, "")(kafkaServerConfig, otterizev1alpha3.TLSSource{}, true, true)
+	s.Require().NoError(err)
+	s.intentsAdmin = intentsAdmin
+}
+
+func (s *StrimziSuite) expectGetKafkaUser(existingUser *unstructured.Unstructured) {
+	s.client.EXPECT().Get(gomock.Any(), types.NamespacedName{Name: kafkaUserName, Namespace: testNamespace}, gomock.AssignableToTypeOf(&unstructured.Unstructured{})).DoAndReturn(
+		func(ctx context.Context, name types.NamespacedName, user *unstructured.Unstructured, opts ...client.GetOption) error {
+			if existingUser == nil {
+				return k8serrors.NewNotFound(schema.GroupResource{Group: KafkaUserGroupVersionKind.Group, Resource: "kafkausers"}, name.Name)
+			}
+			existingUser.DeepCopyInto(user)
+			return nil
+		})
+}
+
+func newTestKafkaUser(acls []interface{}) *unstructured.Unstructured {
+	user := &unstructured.Unstructured{}
+	user.SetGroupVersionKind(KafkaUserGroupVersionKind)
+	user.SetName(kafkaUserName)
+	user.SetNamespace(testNamespace)
+	user.Object["spec"] = map[string]interface{}{
+		"authentication": map[string]interface{}{"type": "tls"},
+		"authorization":  map[string]interface{}{"type": "simple", "acls": acls},
+	}
+	return user
+}
+
+func (s *StrimziSuite) TestApplyClientIntentsCreatesKafkaUser() {
+	s.expectGetKafkaUser(nil)
+	s.client.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, user *unstructured.Unstructured, opts ...client.CreateOption) error {
+			s.Require().Equal(strimziClusterName, user.GetLabels()[StrimziClusterLabelKey])
+			s.Require().Equal("true", user.GetAnnotations()[StrimziCreatedByOtterizeAnnotation])
+			acls, _, err := unstructured.NestedSlice(user.Object, "spec", "authorization", "acls")
+			s.Require().NoError(err)
+			s.Require().Equal([]interface{}{
+				map[string]interface{}{
+					"resource":   map[string]interface{}{"type": "topic", "name": "my-topic", "patternType": "literal"},
+					"operations": []interface{}{"Read", "Write"},
+					"host":       "*",
+				},
+				map[string]interface{}{
+					"resource":   map[string]interface{}{"type": "group", "name": "my-group", "patternType": "prefix"},
+					"operations": []interface{}{"Read", "Describe"},
+					"host":       "*",
+				},
+			}, acls)
+			return nil
+		})
+
+	intents := []otterizev1alpha3.Intent{
+		{
+			Name: serverName,
+			Type: otterizev1alpha3.IntentTypeKafka,
+			Topics: []otterizev1alpha3.KafkaTopic{
+				{Name: "my-topic", Operations: []otterizev1alpha3.KafkaOperation{otterizev1alpha3.KafkaOperationConsume, otterizev1alpha3.KafkaOperationProduce}},
+			},
+			ConsumerGroups: []otterizev1alpha3.KafkaConsumerGroup{
+				{Name: "my-group", Pattern: otterizev1alpha3.ResourcePatternTypePrefix},
+			},
+		},
+	}
+	err := s.intentsAdmin.ApplyClientIntents(clientName, testNamespace, "", intents)
+	s.Require().NoError(err)
+}
+
+func (s *StrimziSuite) TestApplyClientIntentsKeepsUnmanagedACLs() {
+	unmanagedRule := map[string]interface{}{
+		"resource":   map[string]interface{}{"type": "topic", "name": "other-topic", "patternType": "literal"},
+		"operations": []interface{}{"Read"},
+		"host":       "*",
+	}
+	previouslyManagedRule := map[string]interface{}{
+		"resource":   map[string]interface{}{"type": "topic", "name": "old-topic", "patternType": "literal"},
+		"operations": []interface{}{"Write"},
+		"host":       "*",
+	}
+	existingUser := newTestKafkaUser([]interface{}{unmanagedRule, previouslyManagedRule})
+	existingUser.SetAnnotations(map[string]string{
+		StrimziIntentsACLsAnnotation: `[{"resource":{"type":"topic","name":"old-topic","patternType":"literal"},"operations":["Write"],"host":"*"}]`,
+	})
+
+	s.expectGetKafkaUser(existingUser)
+	s.client.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, user *unstructured.Unstructured, opts ...client.UpdateOption) error {
+			s.Require().Empty(user.GetAnnotations()[StrimziCreatedByOtterizeAnnotation])
+			acls, _, err := unstructured.NestedSlice(user.Object, "spec", "authorization", "acls")
+			s.Require().NoError(err)
+			s.Require().Equal([]interface{}{
+				unmanagedRule,
+				map[string]interface{}{
+					"resource":   map[string]interface{}{"type": "cluster"},
+					"operations": []interface{}{"IdempotentWrite"},
+					"host":       "*",
+				},
+			}, acls)
+			return nil
+		})
+
+	intents := []otterizev1alpha3.Intent{
+		{
+			Name:              serverName,
+			Type:              otterizev1alpha3.IntentTypeKafka,
+			ClusterOperations: []otterizev1alpha3.KafkaOperation{otterizev1alpha3.KafkaOperationIdempotentWrite},
+		},
+	}
+	err := s.intentsAdmin.ApplyClientIntents(clientName, testNamespace, "", intents)
+	s.Require().NoError(err)
+}
+
+func (s *StrimziSuite) TestApplyClientIntentsSkipsUnchangedKafkaUser() {
+	rule := map[string]interface{}{
+		"resource":   map[string]interface{}{"type": "topic", "name": "my-topic", "patternType": "literal"},
+		"operations": []interface{}{"Read"},
+		"host":       "*",
+	}
+	existingUser := newTestKafkaUser([]interface{}{rule})
+	existingUser.SetAnnotations(map[string]string{
+		StrimziIntentsACLsAnnotation: `[{"resource":{"type":"topic","name":"my-topic","patternType":"literal"},"operations":["Read"],"host":"*"}]`,
+	})
+	existingUser.SetLabels(map[string]string{
+		StrimziClusterLabelKey:                       strimziClusterName,
+		otterizev1alpha3.OtterizeKafkaServerLabelKey: otterizev1alpha3.GetFormattedOtterizeIdentity(serverName, testNamespace),
+	})
+	s.expectGetKafkaUser(existingUser)
+
+	intents := []otterizev1alpha3.Intent{
+		{
+			Name: serverName,
+			Type: otterizev1alpha3.IntentTypeKafka,
+			Topics: []otterizev1alpha3.KafkaTopic{
+				{Name: "my-topic", Operations: []otterizev1alpha3.KafkaOperation{otterizev1alpha3.KafkaOperationConsume}},
+			},
+		},
+	}
+	err := s.intentsAdmin.ApplyClientIntents(clientName, testNamespace, "", intents)
+	s.Require().NoError(err)
+}
+
+func (s *StrimziSuite) TestApplyClientQuotas() {
+	s.expectGetKafkaUser(newTestKafkaUser(nil))
+	s.client.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, user *unstructured.Unstructured, opts ...client.UpdateOption) error {
+			quotas, _, err := unstructured.NestedMap(user.Object, "spec", "quotas")
+			s.Require().NoError(err)
+			s.Require().Equal(map[string]interface{}{"producerByteRate": int64(1024)}, quotas)
+			s.Require().Equal(`{"producerByteRate":1024}`, user.GetAnnotations()[StrimziQuotasAnnotation])
+			return nil
+		})
+
+	applied, err := s.intentsAdmin.ApplyClientQuotas(clientName, testNamespace, "", &otterizev1alpha3.KafkaQuotas{ProducerByteRate: 1024})
+	s.Require().NoError(err)
+	s.Require().Equal(otterizev1alpha3.KafkaQuotas{ProducerByteRate: 1024}, applied)
+}
+
+func (s *StrimziSuite) TestRemoveClientIntentsDeletesCreatedKafkaUser() {
+	existingUser := newTestKafkaUser(nil)
+	existingUser.SetAnnotations(map[string]string{StrimziCreatedByOtterizeAnnotation: "true"})
+	s.expectGetKafkaUser(existingUser)
+	s.client.EXPECT().Delete(gomock.Any(), gomock.AssignableToTypeOf(&unstructured.Unstructured{})).Return(nil)
+
+	err := s.intentsAdmin.RemoveClientIntents(clientName, testNamespace, "")
+	s.Require().NoError(err)
+}
+
+func (s *StrimziSuite) TestRemoveClientIntentsKeepsUnmanagedKafkaUser() {
+	managedRule := map[string]interface{}{
+		"resource":   map[string]interface{}{"type": "topic", "name": "my-topic", "patternType": "literal"},
+		"operations": []interface{}{"Read"},
+		"host":       "*",
+	}
+	existingUser := newTestKafkaUser([]interface{}{managedRule})
+	existingUser.SetAnnotations(map[string]string{
+		StrimziIntentsACLsAnnotation: `[{"resource":{"type":"topic","name":"my-topic","patternType":"literal"},"operations":["Read"],"host":"*"}]`,
+	})
+	s.expectGetKafkaUser(existingUser)
+	s.client.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, user *unstructured.Unstructured, opts ...client.UpdateOption) error {
+			s.Require().NotContains(user.GetAnnotations(), StrimziIntentsACLsAnnotation)
+			_, found, err := unstructured.NestedMap(user.Object, "spec", "authorization")
+			s.Require().NoError(err)
+			s.Require().False(found)
+			_, found, err = unstructured.NestedMap(user.Object, "spec", "authentication")
+			s.Require().NoError(err)
+			s.Require().True(found)
+			return nil
+		})
+
+	err := s.intentsAdmin.RemoveClientIntents(clientName, testNamespace, "")
+	s.Require().NoError(err)
+}
+
+func (s *StrimziSuite) TestRemoveClientIntentsKeepsQuotasAndRulesOfOthers() {
+	rule := map[string]interface{}{
+		"resource":   map[string]interface{}{"type": "topic", "name": "my-topic", "patternType": "literal"},
+		"operations": []interface{}{"Read"},
+		"host":       "*",
+	}
+	// The rule was added by others before the operator added the same rule.
+	existingUser := newTestKafkaUser([]interface{}{rule, rule})
+	existingUser.SetAnnotations(map[string]string{
+		StrimziIntentsACLsAnnotation: `[{"resource":{"type":"topic","name":"my-topic","patternType":"literal"},"operations":["Read"],"host":"*"}]`,
+		StrimziQuotasAnnotation:      `{"producerByteRate":1024}`,
+	})
+	s.Require().NoError(unstructured.SetNestedMap(existingUser.Object, map[string]interface{}{"producerByteRate": int64(1024), "consumerByteRate": int64(2048)}, "spec", "quotas"))
+	s.expectGetKafkaUser(existingUser)
+	s.client.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, user *unstructured.Unstructured, opts ...client.UpdateOption) error {
+			s.Require().NotContains(user.GetAnnotations(), StrimziIntentsACLsAnnotation)
+			s.Require().NotContains(user.GetAnnotations(), StrimziQuotasAnnotation)
+			acls, _, err := unstructured.NestedSlice(user.Object, "spec", "authorization", "acls")
+			s.Require().NoError(err)
+			s.Require().Equal([]interface{}{rule}, acls)
+			quotas, _, err := unstructured.NestedMap(user.Object, "spec", "quotas")
+			s.Require().NoError(err)
+			s.Require().Equal(map[string]interface{}{"consumerByteRate": int64(2048)}, quotas)
+			return nil
+		})
+
+	err := s.intentsAdmin.RemoveClientIntents(clientName, testNamespace, "")
+	s.Require().NoError(err)
+}
+
+func (s *StrimziSuite) TestApplyClientQuotasWithoutQuotasKeepsQuotasOfOthers() {
+	existingUser := newTestKafkaUser(nil)
+	s.Require().NoError(unstructured.SetNestedMap(existingUser.Object, map[string]interface{}{"producerByteRate": int64(1024)}, "spec", "quotas"))
+	s.expectGetKafkaUser(existingUser)
+
+	// The KafkaUser is not updated.
+	applied, err := s.intentsAdmin.ApplyClientQuotas(clientName, testNamespace, "", nil)
+	s.Require().NoError(err)
+	s.Require().Equal(otterizev1alpha3.KafkaQuotas{ProducerByteRate: 1024}, applied)
+}
+
+func (s *StrimziSuite) TestInvalidPrincipal() {
+	kafkaServerConfig := otterizev1alpha3.KafkaServerConfig{
+		Spec: otterizev1alpha3.KafkaServerConfigSpec{
+			PrincipalTemplate: "Group:$ServiceName",
+			Strimzi:           &otterizev1alpha3.StrimziConfig{ClusterName: strimziClusterName},
+		},
+	}
+	intentsAdmin := NewStrimziIntentsAdmin(s.client, kafkaServerConfig, "", true, true)
+
+	err := intentsAdmin.ApplyClientIntents(clientName, testNamespace, "", nil)
+	s.Require().ErrorIs(err, ErrInvalidStrimziPrincipal)
+}
+
+func (s *StrimziSuite) TestStrimziRequiresClient() {
+	kafkaServerConfig := otterizev1alpha3.KafkaServerConfig{
+		Spec: otterizev1alpha3.KafkaServerConfigSpec{
+			Strimzi: &otterizev1alpha3.StrimziConfig{ClusterName: strimziClusterName},
+		},
+	}
+
+	_, err := NewKafkaIntentsAdmin(kafkaServerConfig, otterizev1alpha3.TLSSource{}, true, true)
+	s.Require().ErrorIs(err, ErrStrimziNotSupported)
+}
+
+func TestStrimziSuite(t *testing.T) {
+	suite.Run(t, new(StrimziSuite))
+}
@@ -0,0 +1,125 @@
+package kafkaacls
+
+import (
+	"fmt"
+	"github.com/Shopify/sarama"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sort"
+)
+
+// ApplyTopics creates the topics declared in topicManagement that don't exist on the server, and adds partitions and
+// config overrides to those that do. createdTopics are the topics previously created by the operator, which are deleted
+// once removed from topicManagement if it enables deletion and none of referencedTopics, the topics referenced by
+// intents to this server, match them. Returns the topics created by the operator that still exist, and the referenced
+// topics missing on the server.
+func (a *KafkaIntentsAdminImpl) ApplyTopics(topicManagement otterizev1alpha3.KafkaTopicManagement, createdTopics []string, referencedTopics []string) (otterizev1alpha3.KafkaTopicsStatus, error) {
+	logger := logrus.WithFields(
+		logrus.Fields{
+			"serverName":      a.kafkaServer.Spec.Service,
+			"serverNamespace": a.kafkaServer.Namespace,
+		})
+
+	existingTopics, err := a.kafkaAdminClient.ListTopics()
+	if err != nil {
+		return otterizev1alpha3.KafkaTopicsStatus{}, fmt.Errorf("failed listing topics on server: %w", err)
+	}
+
+	created := sets.New[string](lo.Filter(createdTopics, func(topic string, _ int) bool {
+		_, ok := existingTopics[topic]
+		return ok
+	})...)
+	for _, topic := range topicManagement.Topics {
+		detail, ok := existingTopics[topic.Name]
+		if !ok {
+			logger.Infof("Creating topic %s", topic.Name)
+			err := a.kafkaAdminClient.CreateTopic(topic.Name, &sarama.TopicDetail{
+				NumPartitions:     topic.Partitions,
+				ReplicationFactor: topic.ReplicationFactor,
+				ConfigEntries:     toConfigEntries(topic.Config),
+			}, false)
+			if err != nil {
+				return otterizev1alpha3.KafkaTopicsStatus{}, fmt.Errorf("failed creating topic %s: %w", topic.Name, err)
+			}
+			created.Insert(topic.Name)
+			existingTopics[topic.Name] = sarama.TopicDetail{}
+			continue
+		}
+
+		if err := a.updateTopic(topic, detail); err != nil {
+			return otterizev1alpha3.KafkaTopicsStatus{}, err
+		}
+	}
+
+	declaredTopics := sets.New[string](lo.Map(topicManagement.Topics, func(topic otterizev1alpha3.KafkaManagedTopic, _ int) string {
+		return topic.Name
+	})...)
+	for _, topic := range sets.List(created.Difference(declaredTopics)) {
+		if !topicManagement.DeleteRemovedTopics {
+			continue
+		}
+		if isTopicReferenced(topic, referencedTopics) {
+			logger.Infof("Not deleting topic %s, as it is still referenced by intents", topic)
+			continue
+		}
+		logger.Infof("Deleting topic %s, which was removed from the KafkaServerConfig", topic)
+		if err := a.kafkaAdminClient.DeleteTopic(topic); err != nil {
+			return otterizev1alpha3.KafkaTopicsStatus{}, fmt.Errorf("failed deleting topic %s: %w", topic, err)
+		}
+		created.Delete(topic)
+	}
+
+	missing := lo.Uniq(lo.Filter(referencedTopics, func(topic string, _ int) bool {
+		_, ok := existingTopics[topic]
+		return topic != "*" && !ok
+	}))
+	sort.Strings(missing)
+
+	return otterizev1alpha3.KafkaTopicsStatus{Created: lo.Ternary(created.Len() == 0, nil, sets.List(created)), Missing: lo.Ternary(len(missing) == 0, nil, missing)}, nil
+}
+
+// updateTopic adds partitions to an existing topic with fewer than declared, and sets its declared config overrides.
+// The replication factor of existing topics is not changed, as that requires reassigning partitions.
+func (a *KafkaIntentsAdminImpl) updateTopic(topic otterizev1alpha3.KafkaManagedTopic, detail sarama.TopicDetail) error {
+	logger := logrus.WithField("topic", topic.Name)
+	if topic.Partitions > detail.NumPartitions {
+		logger.Infof("Increasing topic partitions from %d to %d", detail.NumPartitions, topic.Partitions)
+		if err := a.kafkaAdminClient.CreatePartitions(topic.Name, topic.Partitions, nil, false); err != nil {
+			return fmt.Errorf("failed adding partitions to topic %s: %w", topic.Name, err)
+		}
+	} else if topic.Partitions < detail.NumPartitions {
+		logger.Warnf("Topic has %d partitions, more than the declared %d, which cannot be removed", detail.NumPartitions, topic.Partitions)
+	}
+
+	outdated := lo.PickBy(topic.Config, func(key string, value string) bool {
+		current, ok := detail.ConfigEntries[key]
+		return !ok || current == nil || *current != value
+	})
+	if len(outdated) == 0 {
+		return nil
+	}
+
+	// AlterConfig replaces all of the topic's config overrides, so those not declared are kept as they are.
+	entries := lo.Assign(lo.OmitBy(detail.ConfigEntries, func(_ string, value *string) bool {
+		return value == nil
+	}), toConfigEntries(topic.Config))
+	logger.Infof("Updating topic config %v", lo.Keys(outdated))
+	if err := a.kafkaAdminClient.AlterConfig(sarama.TopicResource, topic.Name, entries, false); err != nil {
+		return fmt.Errorf("failed updating config of topic %s: %w", topic.Name, err)
+	}
+	return nil
+}
+
+func toConfigEntries(config map[string]string) map[string]*string {
+	entries := make(map[string]*string, len(config))
+	for key, value := range config {
+		entries[key] = lo.ToPtr(value)
+	}
+	return entries
+}
+
+func isTopicReferenced(topic string, referencedTopics []string) bool {
+	return lo.Contains(referencedTopics, topic) || lo.Contains(referencedTopics, "*")
+}
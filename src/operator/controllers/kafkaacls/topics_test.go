@@ -0,0 +1,120 @@
+package kafkaacls
+
+import (
+	"github.com/Shopify/sarama"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	kafkaaclsmocks "github.com/otterize/intents-operator/src/operator/controllers/kafkaacls/mocks"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+const (
+	managedTopicName = "orders"
+	removedTopicName = "old-orders"
+)
+
+type TopicsSuite struct {
+	suite.Suite
+	mockClusterAdmin *kafkaaclsmocks.MockClusterAdmin
+	intentsAdmin     KafkaIntentsAdmin
+}
+
+func (s *TopicsSuite) SetupTest() {
+	controller := gomock.NewController(s.T())
+	s.mockClusterAdmin = kafkaaclsmocks.NewMockClusterAdmin(controller)
+	kafkaServerConfig := otterizev1alpha3.KafkaServerConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kafkaServerConfigResourceName,
+			Namespace: testNamespace,
+		},
+		Spec: otterizev1alpha3.KafkaServerConfigSpec{
+			Service: otterizev1alpha3.Service{Name: serverName},
+			Addr:    serverAddress,
+		},
+	}
+	s.intentsAdmin = NewKafkaIntentsAdminImpl(kafkaServerConfig, s.mockClusterAdmin, "$ServiceName.$Namespace", true, true)
+}
+
+func (s *TopicsSuite) managedTopic() otterizev1alpha3.KafkaManagedTopic {
+	return otterizev1alpha3.KafkaManagedTopic{
+		Name:              managedTopicName,
+		Partitions:        6,
+		ReplicationFactor: 3,
+		Config:            map[string]string{"retention.ms": "86400000"},
+	}
+}
+
+func (s *TopicsSuite) TestApplyTopicsCreatesMissingTopics() {
+	s.mockClusterAdmin.EXPECT().ListTopics().Return(map[string]sarama.TopicDetail{"payments": {NumPartitions: 1}}, nil)
+	s.mockClusterAdmin.EXPECT().CreateTopic(managedTopicName, &sarama.TopicDetail{
+		NumPartitions:     6,
+		ReplicationFactor: 3,
+		ConfigEntries:     map[string]*string{"retention.ms": lo.ToPtr("86400000")},
+	}, false).Return(nil)
+
+	status, err := s.intentsAdmin.ApplyTopics(
+		otterizev1alpha3.KafkaTopicManagement{Topics: []otterizev1alpha3.KafkaManagedTopic{s.managedTopic()}},
+		nil,
+		[]string{"payments", managedTopicName, "refunds", "*"},
+	)
+	s.Require().NoError(err)
+	s.Require().Equal(otterizev1alpha3.KafkaTopicsStatus{Created: []string{managedTopicName}, Missing: []string{"refunds"}}, status)
+}
+
+func (s *TopicsSuite) TestApplyTopicsUpdatesExistingTopics() {
+	s.mockClusterAdmin.EXPECT().ListTopics().Return(map[string]sarama.TopicDetail{
+		managedTopicName: {
+			NumPartitions:     3,
+			ReplicationFactor: 3,
+			ConfigEntries:     map[string]*string{"retention.ms": lo.ToPtr("3600000"), "cleanup.policy": lo.ToPtr("compact")},
+		},
+	}, nil)
+	s.mockClusterAdmin.EXPECT().CreatePartitions(managedTopicName, int32(6), nil, false).Return(nil)
+	s.mockClusterAdmin.EXPECT().AlterConfig(sarama.TopicResource, managedTopicName, map[string]*string{
+		"retention.ms":   lo.ToPtr("86400000"),
+		"cleanup.policy": lo.ToPtr("compact"),
+	}, false).Return(nil)
+
+	status, err := s.intentsAdmin.ApplyTopics(
+		otterizev1alpha3.KafkaTopicManagement{Topics: []otterizev1alpha3.KafkaManagedTopic{s.managedTopic()}},
+		nil,
+		nil,
+	)
+	s.Require().NoError(err)
+	s.Require().Equal(otterizev1alpha3.KafkaTopicsStatus{}, status)
+}
+
+func (s *TopicsSuite) TestApplyTopicsDeletesRemovedTopicsOnlyWhenEnabled() {
+	existingTopics := map[string]sarama.TopicDetail{removedTopicName: {NumPartitions: 1}}
+
+	// No DeleteTopic call is expected
+	s.mockClusterAdmin.EXPECT().ListTopics().Return(existingTopics, nil)
+	status, err := s.intentsAdmin.ApplyTopics(otterizev1alpha3.KafkaTopicManagement{}, []string{removedTopicName}, nil)
+	s.Require().NoError(err)
+	s.Require().Equal(otterizev1alpha3.KafkaTopicsStatus{Created: []string{removedTopicName}}, status)
+
+	s.mockClusterAdmin.EXPECT().ListTopics().Return(existingTopics, nil)
+	s.mockClusterAdmin.EXPECT().DeleteTopic(removedTopicName).Return(nil)
+	status, err = s.intentsAdmin.ApplyTopics(otterizev1alpha3.KafkaTopicManagement{DeleteRemovedTopics: true}, []string{removedTopicName}, nil)
+	s.Require().NoError(err)
+	s.Require().Equal(otterizev1alpha3.KafkaTopicsStatus{}, status)
+}
+
+func (s *TopicsSuite) TestApplyTopicsKeepsRemovedTopicsReferencedByIntents() {
+	// Topics not created by the operator are never deleted, and neither are those still referenced by intents
+	s.mockClusterAdmin.EXPECT().ListTopics().Return(map[string]sarama.TopicDetail{
+		removedTopicName: {NumPartitions: 1},
+		"payments":       {NumPartitions: 1},
+	}, nil)
+
+	status, err := s.intentsAdmin.ApplyTopics(otterizev1alpha3.KafkaTopicManagement{DeleteRemovedTopics: true}, []string{removedTopicName}, []string{removedTopicName})
+	s.Require().NoError(err)
+	s.Require().Equal(otterizev1alpha3.KafkaTopicsStatus{Created: []string{removedTopicName}}, status)
+}
+
+func TestTopicsSuite(t *testing.T) {
+	suite.Run(t, new(TopicsSuite))
+}
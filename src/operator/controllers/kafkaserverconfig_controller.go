@@ -101,6 +101,7 @@ func (r *KafkaServerConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&otterizev1alpha3.KafkaServerConfig{}).
 		WithOptions(controller.Options{RecoverPanic: lo.ToPtr(true)}).
 		Watches(&source.Kind{Type: &otterizev1alpha3.ProtectedService{}}, handler.EnqueueRequestsFromMapFunc(r.mapProtectedServiceToKafkaServerConfig)).
+		Watches(&source.Kind{Type: &otterizev1alpha3.ClientIntents{}}, handler.EnqueueRequestsFromMapFunc(r.mapClientIntentsToKafkaServerConfig)).
 		Complete(r)
 	if err != nil {
 		return err
@@ -152,3 +153,38 @@ func (r *KafkaServerConfigReconciler) getKSCsForProtectedService(protectedServic
 	kscsToReconcile = append(kscsToReconcile, kafkaServerConfigs.Items...)
 	return kscsToReconcile
 }
+
+// mapClientIntentsToKafkaServerConfig enqueues the KafkaServerConfigs with topic management that the intents reference,
+// so that the topics they report as missing are kept up to date.
+func (r *KafkaServerConfigReconciler) mapClientIntentsToKafkaServerConfig(obj client.Object) []reconcile.Request {
+	clientIntents := obj.(*otterizev1alpha3.ClientIntents)
+	if clientIntents.Spec == nil {
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0)
+	for _, intent := range clientIntents.GetCallsList() {
+		if intent.Type != otterizev1alpha3.IntentTypeKafka {
+			continue
+		}
+
+		var kafkaServerConfigs otterizev1alpha3.KafkaServerConfigList
+		err := r.Client.List(context.Background(),
+			&kafkaServerConfigs,
+			&client.MatchingFields{otterizev1alpha3.OtterizeKafkaServerConfigServiceNameField: intent.GetTargetServerName()},
+			&client.ListOptions{Namespace: intent.GetTargetServerNamespace(clientIntents.Namespace)},
+		)
+		if err != nil {
+			logrus.Errorf("Failed to list KSCs for server %s: %v", intent.GetTargetServerName(), err)
+			continue
+		}
+
+		for _, ksc := range kafkaServerConfigs.Items {
+			if ksc.Spec.TopicManagement == nil {
+				continue
+			}
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: ksc.Name, Namespace: ksc.Namespace}})
+		}
+	}
+	return lo.Uniq(requests)
+}
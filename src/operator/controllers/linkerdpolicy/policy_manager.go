@@ -0,0 +1,524 @@
+package linkerdpolicy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/consts"
+	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/protected_services"
+	"github.com/otterize/intents-operator/src/shared/injectablerecorder"
+	"github.com/otterize/intents-operator/src/shared/serviceidresolver"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strconv"
+	"strings"
+)
+
+const (
+	ReasonCreatingLinkerdPolicyFailed = "CreatingLinkerdPolicyFailed"
+	ReasonDeleteLinkerdPolicyFailed   = "DeleteLinkerdPolicyFailed"
+	ReasonCreatedLinkerdPolicy        = "CreatedLinkerdPolicy"
+	ReasonNamespaceNotAllowed         = "NamespaceNotAllowed"
+	ReasonMissingSidecar              = "MissingSidecar"
+	ReasonServerMissingSidecar        = "ServerMissingSidecar"
+	ReasonServerPortsNotFound         = "ServerPortsNotFound"
+	ReasonSharedServiceAccount        = "SharedServiceAccountFound"
+	OtterizeLinkerdPolicyNameTemplate = "linkerd-policy-to-%s-from-%s"
+	defaultRouteNameTemplate          = "%s-default"
+)
+
+//+kubebuilder:rbac:groups="policy.linkerd.io",resources=servers;httproutes;authorizationpolicies;meshtlsauthentications,verbs=get;list;watch;create;update;patch;delete
+
+type PolicyManager interface {
+	DeleteAll(ctx context.Context, clientIntents *otterizev1alpha3.ClientIntents) error
+	Create(ctx context.Context, clientIntents *otterizev1alpha3.ClientIntents, clientServiceAccount string) error
+	UpdateIntentsStatus(ctx context.Context, clientIntents *otterizev1alpha3.ClientIntents, clientServiceAccount string, missingSideCar bool) error
+	UpdateServerSidecar(ctx context.Context, clientIntents *otterizev1alpha3.ClientIntents, serverName string, missingSideCar bool) error
+}
+
+// PolicyManagerImpl manages the Linkerd policy resources granting clients access to servers:
+//   - A Server per port of each called server, shared by all of its clients. Once a port has a Server, Linkerd denies
+//     traffic to it that no AuthorizationPolicy allows. The Servers are removed along with the last client's policies.
+//   - Per client and server, a MeshTLSAuthentication matching the client's service account, and AuthorizationPolicies
+//     requiring it - targeting the Servers, or for HTTP intents, an HTTPRoute matching the intents' paths and methods.
+type PolicyManagerImpl struct {
+	client                      client.Client
+	recorder                    *injectablerecorder.InjectableRecorder
+	kinds                       PolicyKinds
+	serviceIdResolver           serviceidresolver.ServiceResolver
+	restrictToNamespaces        []string
+	enforcementDefaultState     bool
+	enableLinkerdPolicyCreation bool
+}
+
+func NewPolicyManager(
+	client client.Client,
+	recorder *injectablerecorder.InjectableRecorder,
+	kinds PolicyKinds,
+	restrictToNamespaces []string,
+	enforcementDefaultState bool,
+	linkerdEnforcementEnabled bool,
+) *PolicyManagerImpl {
+	return &PolicyManagerImpl{
+		client:                      client,
+		recorder:                    recorder,
+		kinds:                       kinds,
+		serviceIdResolver:           serviceidresolver.NewResolver(client),
+		restrictToNamespaces:        restrictToNamespaces,
+		enforcementDefaultState:     enforcementDefaultState,
+		enableLinkerdPolicyCreation: linkerdEnforcementEnabled,
+	}
+}
+
+type serverPort struct {
+	name string
+	port int32
+}
+
+func (c *PolicyManagerImpl) DeleteAll(ctx context.Context, clientIntents *otterizev1alpha3.ClientIntents) error {
+	clientFormattedIdentity := otterizev1alpha3.GetFormattedOtterizeIdentity(clientIntents.Spec.Service.Name, clientIntents.Namespace)
+	existingResources, err := c.listClientResources(ctx, clientFormattedIdentity)
+	if err != nil {
+		return err
+	}
+
+	err = c.deleteResources(ctx, existingResources)
+	if err != nil {
+		return err
+	}
+
+	return c.deleteUnusedServers(ctx, existingResources)
+}
+
+func (c *PolicyManagerImpl) Create(ctx context.Context, clientIntents *otterizev1alpha3.ClientIntents, clientServiceAccount string) error {
+	clientFormattedIdentity := otterizev1alpha3.GetFormattedOtterizeIdentity(clientIntents.Spec.Service.Name, clientIntents.Namespace)
+	existingResources, err := c.listClientResources(ctx, clientFormattedIdentity)
+	if err != nil {
+		return err
+	}
+
+	desiredResources, err := c.buildDesiredResources(ctx, clientIntents, clientServiceAccount)
+	if err != nil {
+		return err
+	}
+
+	staleResources, err := c.applyResources(ctx, existingResources, desiredResources)
+	if err != nil {
+		c.recorder.RecordWarningEventf(clientIntents, ReasonCreatingLinkerdPolicyFailed, "Failed to create Linkerd policy: %s", err.Error())
+		return err
+	}
+
+	err = c.deleteUnusedServers(ctx, staleResources)
+	if err != nil {
+		c.recorder.RecordWarningEventf(clientIntents, ReasonDeleteLinkerdPolicyFailed, "Failed to delete Linkerd policy: %s", err.Error())
+		return err
+	}
+
+	if len(desiredResources) != 0 {
+		c.recorder.RecordNormalEventf(clientIntents, ReasonCreatedLinkerdPolicy, "Linkerd policy reconcile complete, reconciled %d servers", len(clientIntents.GetCallsList()))
+	}
+
+	return nil
+}
+
+func (c *PolicyManagerImpl) buildDesiredResources(ctx context.Context, clientIntents *otterizev1alpha3.ClientIntents, clientServiceAccount string) ([]*unstructured.Unstructured, error) {
+	desiredResources := make([]*unstructured.Unstructured, 0)
+	for _, intent := range clientIntents.GetCallsList() {
+		if intent.Type != "" && intent.Type != otterizev1alpha3.IntentTypeHTTP {
+			continue
+		}
+
+		targetNamespace := intent.GetTargetServerNamespace(clientIntents.Namespace)
+		shouldCreatePolicy, err := protected_services.IsServerEnforcementEnabledDueToProtectionOrDefaultState(
+			ctx, c.client, intent.GetTargetServerName(), targetNamespace, c.enforcementDefaultState)
+		if err != nil {
+			return nil, err
+		}
+
+		if !shouldCreatePolicy {
+			logrus.Infof("Enforcement is disabled globally and server is not explicitly protected, skipping Linkerd policy creation for server %s in namespace %s", intent.GetTargetServerName(), targetNamespace)
+			c.recorder.RecordNormalEventf(clientIntents, consts.ReasonEnforcementDefaultOff, "Enforcement is disabled globally and called service '%s' is not explicitly protected using a ProtectedService resource, Linkerd policy creation skipped", intent.Name)
+			continue
+		}
+
+		if !c.enableLinkerdPolicyCreation {
+			c.recorder.RecordNormalEvent(clientIntents, consts.ReasonLinkerdPolicyCreationDisabled, "Linkerd policy creation is disabled, creation skipped")
+			return make([]*unstructured.Unstructured, 0), nil
+		}
+
+		if len(c.restrictToNamespaces) != 0 && !lo.Contains(c.restrictToNamespaces, targetNamespace) {
+			c.recorder.RecordWarningEventf(
+				clientIntents,
+				ReasonNamespaceNotAllowed,
+				"Namespace %s was specified in intent, but is not allowed by configuration, Linkerd policy ignored",
+				targetNamespace,
+			)
+			continue
+		}
+
+		servers, err := c.ensureServers(ctx, clientIntents, intent, targetNamespace)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(servers) == 0 {
+			continue
+		}
+
+		desiredResources = append(desiredResources, c.buildClientResources(clientIntents, intent, clientServiceAccount, servers)...)
+	}
+
+	return lo.UniqBy(desiredResources, resourceKey), nil
+}
+
+// ensureServers creates the Servers for the ports of the intent's target, and returns them. Servers are resolved from
+// the container ports of the target's pods, so no Servers (and no policies) are created until the pods are running.
+func (c *PolicyManagerImpl) ensureServers(ctx context.Context, clientIntents *otterizev1alpha3.ClientIntents, intent otterizev1alpha3.Intent, serverNamespace string) ([]serverPort, error) {
+	pod, err := c.serviceIdResolver.ResolveIntentServerToPod(ctx, intent, serverNamespace)
+	if err != nil {
+		if errors.Is(err, serviceidresolver.ErrPodNotFound) {
+			logrus.Infof("No pods found for server %s in namespace %s, Linkerd policies will be created once it is running", intent.GetTargetServerName(), serverNamespace)
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	ports := serverPorts(pod)
+	if len(ports) == 0 {
+		c.recorder.RecordWarningEventf(clientIntents, ReasonServerPortsNotFound, "Server %s does not declare any container ports, Linkerd policies cannot be created for it", intent.GetTargetServerName())
+		return nil, nil
+	}
+
+	formattedTargetServer := otterizev1alpha3.GetFormattedOtterizeIdentity(intent.GetTargetServerName(), serverNamespace)
+	existingResources, err := c.listServerResources(ctx, serverNamespace, formattedTargetServer)
+	if err != nil {
+		return nil, err
+	}
+
+	servers := lo.Map(ports, func(port int32, _ int) serverPort {
+		return serverPort{name: fmt.Sprintf("%s-%d", formattedTargetServer, port), port: port}
+	})
+	desiredResources := lo.Map(servers, func(server serverPort, _ int) *unstructured.Unstructured {
+		return c.buildServer(server, serverNamespace, formattedTargetServer)
+	})
+
+	// Once any HTTPRoute is attached to a Server, Linkerd rejects requests that match none of its routes. The default
+	// route keeps the requests of clients authorized for the whole server routable, and is kept until the Servers are
+	// removed.
+	hasDefaultRoute := lo.SomeBy(existingResources, func(resource *unstructured.Unstructured) bool {
+		return resource.GroupVersionKind() == c.kinds.HTTPRoute
+	})
+	if intent.Type == otterizev1alpha3.IntentTypeHTTP || hasDefaultRoute {
+		desiredResources = append(desiredResources, c.buildDefaultRoute(servers, serverNamespace, formattedTargetServer))
+	}
+
+	_, err = c.applyResources(ctx, existingResources, desiredResources)
+	if err != nil {
+		return nil, err
+	}
+
+	return servers, nil
+}
+
+// deleteUnusedServers deletes the Servers of the servers referenced by the given client resources that no client has
+// authorization policies for anymore.
+func (c *PolicyManagerImpl) deleteUnusedServers(ctx context.Context, clientResources []*unstructured.Unstructured) error {
+	serverNames := sets.New[types.NamespacedName]()
+	for _, resource := range clientResources {
+		serverNames.Insert(types.NamespacedName{Namespace: resource.GetNamespace(), Name: resource.GetLabels()[otterizev1alpha3.OtterizeServerLabelKey]})
+	}
+
+	for _, serverName := range serverNames.UnsortedList() {
+		policies, err := c.list(ctx, c.kinds.AuthorizationPolicy,
+			client.InNamespace(serverName.Namespace),
+			client.MatchingLabels{otterizev1alpha3.OtterizeServerLabelKey: serverName.Name},
+			client.HasLabels{otterizev1alpha3.OtterizeLinkerdClientLabelKey})
+		if err != nil {
+			return err
+		}
+
+		if len(policies) != 0 {
+			continue
+		}
+
+		serverResources, err := c.listServerResources(ctx, serverName.Namespace, serverName.Name)
+		if err != nil {
+			return err
+		}
+
+		err = c.deleteResources(ctx, serverResources)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *PolicyManagerImpl) listClientResources(ctx context.Context, clientFormattedIdentity string) ([]*unstructured.Unstructured, error) {
+	resources := make([]*unstructured.Unstructured, 0)
+	for _, kind := range []schema.GroupVersionKind{c.kinds.AuthorizationPolicy, c.kinds.HTTPRoute, c.kinds.MeshTLSAuthentication} {
+		kindResources, err := c.list(ctx, kind, client.MatchingLabels{otterizev1alpha3.OtterizeLinkerdClientLabelKey: clientFormattedIdentity})
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, kindResources...)
+	}
+	return resources, nil
+}
+
+func (c *PolicyManagerImpl) listServerResources(ctx context.Context, namespace string, formattedServer string) ([]*unstructured.Unstructured, error) {
+	resources := make([]*unstructured.Unstructured, 0)
+	for _, kind := range []schema.GroupVersionKind{c.kinds.Server, c.kinds.HTTPRoute} {
+		kindResources, err := c.list(ctx, kind, client.InNamespace(namespace), client.MatchingLabels{otterizev1alpha3.OtterizeLinkerdServerLabelKey: formattedServer})
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, kindResources...)
+	}
+	return resources, nil
+}
+
+func (c *PolicyManagerImpl) list(ctx context.Context, kind schema.GroupVersionKind, opts ...client.ListOption) ([]*unstructured.Unstructured, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(kind.GroupVersion().WithKind(kind.Kind + "List"))
+	err := c.client.List(ctx, list, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Map(list.Items, func(item unstructured.Unstructured, _ int) *unstructured.Unstructured {
+		resource := item.DeepCopy()
+		resource.SetGroupVersionKind(kind)
+		return resource
+	}), nil
+}
+
+// applyResources creates the desired resources, updates the existing ones that differ from them, and deletes the
+// existing resources that are no longer desired, which it returns.
+func (c *PolicyManagerImpl) applyResources(ctx context.Context, existingResources []*unstructured.Unstructured, desiredResources []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	existingByKey := lo.KeyBy(existingResources, resourceKey)
+	for _, desiredResource := range desiredResources {
+		existingResource, found := existingByKey[resourceKey(desiredResource)]
+		if !found {
+			err := c.client.Create(ctx, desiredResource)
+			if err != nil {
+				return nil, err
+			}
+			logrus.Infof("Created Linkerd %s %s/%s", desiredResource.GetKind(), desiredResource.GetNamespace(), desiredResource.GetName())
+			continue
+		}
+
+		err := c.updateResource(ctx, existingResource, desiredResource)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	desiredKeys := sets.New(lo.Map(desiredResources, func(resource *unstructured.Unstructured, _ int) string {
+		return resourceKey(resource)
+	})...)
+	staleResources := lo.Filter(existingResources, func(resource *unstructured.Unstructured, _ int) bool {
+		return !desiredKeys.Has(resourceKey(resource))
+	})
+
+	err := c.deleteResources(ctx, staleResources)
+	if err != nil {
+		return nil, err
+	}
+
+	return staleResources, nil
+}
+
+func (c *PolicyManagerImpl) updateResource(ctx context.Context, existingResource *unstructured.Unstructured, desiredResource *unstructured.Unstructured) error {
+	if otterizev1alpha3.IsManuallyOverridden(existingResource) {
+		logrus.Debugf("Linkerd %s %s/%s is manually overridden, skipping update", existingResource.GetKind(), existingResource.GetNamespace(), existingResource.GetName())
+		return nil
+	}
+
+	// Linkerd defaults fields left unset, so only the fields set by the operator are compared.
+	if isSubset(desiredResource.Object["spec"], existingResource.Object["spec"]) && isSubset(desiredResource.GetLabels(), existingResource.GetLabels()) {
+		return nil
+	}
+
+	resourceCopy := existingResource.DeepCopy()
+	resourceCopy.SetLabels(desiredResource.GetLabels())
+	resourceCopy.Object["spec"] = desiredResource.Object["spec"]
+	return c.client.Patch(ctx, resourceCopy, client.MergeFrom(existingResource))
+}
+
+func (c *PolicyManagerImpl) deleteResources(ctx context.Context, resources []*unstructured.Unstructured) error {
+	for _, resource := range resources {
+		err := c.client.Delete(ctx, resource)
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		logrus.Infof("Deleted Linkerd %s %s/%s", resource.GetKind(), resource.GetNamespace(), resource.GetName())
+	}
+	return nil
+}
+
+func resourceKey(resource *unstructured.Unstructured) string {
+	return strings.Join([]string{resource.GetKind(), resource.GetNamespace(), resource.GetName()}, "/")
+}
+
+// isSubset returns whether every field set in subset has the same value in set. Lists are compared item by item.
+func isSubset(subset any, set any) bool {
+	switch subsetValue := subset.(type) {
+	case map[string]any:
+		setValue, ok := set.(map[string]any)
+		if !ok {
+			return false
+		}
+		for key, value := range subsetValue {
+			if !isSubset(value, setValue[key]) {
+				return false
+			}
+		}
+		return true
+	case map[string]string:
+		setValue, ok := set.(map[string]string)
+		if !ok {
+			return len(subsetValue) == 0
+		}
+		for key, value := range subsetValue {
+			if setValue[key] != value {
+				return false
+			}
+		}
+		return true
+	case []any:
+		setValue, ok := set.([]any)
+		if !ok || len(setValue) != len(subsetValue) {
+			return false
+		}
+		for i := range subsetValue {
+			if !isSubset(subsetValue[i], setValue[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return subset == set
+	}
+}
+
+func (c *PolicyManagerImpl) UpdateIntentsStatus(
+	ctx context.Context,
+	clientIntents *otterizev1alpha3.ClientIntents,
+	clientServiceAccount string,
+	missingSideCar bool,
+) error {
+	serviceAccountValue, hasServiceAccount := clientIntents.Annotations[otterizev1alpha3.OtterizeLinkerdClientServiceAccountAnnotation]
+	missingSidecarValue, hasMissingSidecar := clientIntents.Annotations[otterizev1alpha3.OtterizeLinkerdMissingProxyAnnotation]
+	if !hasServiceAccount || serviceAccountValue != clientServiceAccount || !hasMissingSidecar || missingSidecarValue != strconv.FormatBool(missingSideCar) {
+		updatedIntents := clientIntents.DeepCopy()
+		if updatedIntents.Annotations == nil {
+			updatedIntents.Annotations = make(map[string]string)
+		}
+
+		updatedIntents.Annotations[otterizev1alpha3.OtterizeLinkerdClientServiceAccountAnnotation] = clientServiceAccount
+		updatedIntents.Annotations[otterizev1alpha3.OtterizeLinkerdMissingProxyAnnotation] = strconv.FormatBool(missingSideCar)
+		err := c.client.Patch(ctx, updatedIntents, client.MergeFrom(clientIntents))
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.updateSharedServiceAccountsInNamespace(ctx, clientIntents.Namespace)
+}
+
+// updateSharedServiceAccountsInNamespace marks the intents of clients sharing a service account, since a
+// MeshTLSAuthentication grants access to every workload running as it.
+func (c *PolicyManagerImpl) updateSharedServiceAccountsInNamespace(ctx context.Context, namespace string) error {
+	var namespacesClientIntents otterizev1alpha3.ClientIntentsList
+	err := c.client.List(ctx, &namespacesClientIntents, client.InNamespace(namespace))
+	if err != nil {
+		return err
+	}
+
+	clientsByServiceAccount := lo.GroupBy(namespacesClientIntents.Items, func(intents otterizev1alpha3.ClientIntents) string {
+		return intents.Annotations[otterizev1alpha3.OtterizeLinkerdClientServiceAccountAnnotation]
+	})
+
+	for serviceAccount, clientIntents := range clientsByServiceAccount {
+		if serviceAccount == "" {
+			continue
+		}
+
+		isServiceAccountShared := len(clientIntents) > 1
+		sharedAccountValue := strconv.FormatBool(isServiceAccountShared)
+		clientsNames := strings.Join(lo.Map(clientIntents, func(intents otterizev1alpha3.ClientIntents, _ int) string {
+			return intents.GetServiceName()
+		}), ", ")
+
+		for _, intents := range clientIntents {
+			if intents.Annotations[otterizev1alpha3.OtterizeSharedServiceAccountAnnotation] == sharedAccountValue {
+				continue
+			}
+
+			updatedIntents := intents.DeepCopy()
+			updatedIntents.Annotations[otterizev1alpha3.OtterizeSharedServiceAccountAnnotation] = sharedAccountValue
+			err = c.client.Patch(ctx, updatedIntents, client.MergeFrom(&intents))
+			if err != nil {
+				return err
+			}
+
+			if isServiceAccountShared {
+				c.recorder.RecordWarningEventf(updatedIntents, ReasonSharedServiceAccount, "Service account %s is shared and will also grant access to the following clients: %s", serviceAccount, clientsNames)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *PolicyManagerImpl) UpdateServerSidecar(
+	ctx context.Context,
+	clientIntents *otterizev1alpha3.ClientIntents,
+	serverName string,
+	missingSideCar bool,
+) error {
+	servers, err := clientIntents.GetServersWithoutLinkerdProxy()
+	if err != nil {
+		return err
+	}
+
+	// If no update needed, skip intents update to avoid loop.
+	if servers.Has(serverName) == missingSideCar {
+		return nil
+	}
+
+	if missingSideCar {
+		servers.Insert(serverName)
+	} else {
+		servers.Delete(serverName)
+	}
+
+	serversValue, err := json.Marshal(sets.List(servers))
+	if err != nil {
+		return err
+	}
+
+	updatedIntents := clientIntents.DeepCopy()
+	if updatedIntents.Annotations == nil {
+		updatedIntents.Annotations = make(map[string]string)
+	}
+	updatedIntents.Annotations[otterizev1alpha3.OtterizeLinkerdServersWithoutProxyAnnotation] = string(serversValue)
+	err = c.client.Patch(ctx, updatedIntents, client.MergeFrom(clientIntents))
+	if err != nil {
+		return err
+	}
+
+	if missingSideCar {
+		c.recorder.RecordWarningEventf(clientIntents, ReasonServerMissingSidecar, "Can't apply Linkerd policies for server %s since it doesn't have a Linkerd proxy", serverName)
+	}
+
+	return nil
+}
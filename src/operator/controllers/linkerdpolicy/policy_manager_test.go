@@ -0,0 +1,295 @@
+package linkerdpolicy
+
+import (
+	"context"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/consts"
+	"github.com/otterize/intents-operator/src/shared/injectablerecorder"
+	serviceidresolvermocks "github.com/otterize/intents-operator/src/shared/serviceidresolver/mocks"
+	"github.com/otterize/intents-operator/src/shared/testbase"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"testing"
+)
+
+const (
+	testNamespace = "test-namespace"
+	clientName    = "test-client"
+	serverName    = "test-server"
+)
+
+var testKinds = PolicyKinds{
+	Server:                schema.GroupVersionKind{Group: LinkerdPolicyAPIGroup, Version: "v1beta1", Kind: ServerKind},
+	HTTPRoute:             schema.GroupVersionKind{Group: LinkerdPolicyAPIGroup, Version: "v1beta3", Kind: HTTPRouteKind},
+	AuthorizationPolicy:   schema.GroupVersionKind{Group: LinkerdPolicyAPIGroup, Version: "v1alpha1", Kind: AuthorizationPolicyKind},
+	MeshTLSAuthentication: schema.GroupVersionKind{Group: LinkerdPolicyAPIGroup, Version: "v1alpha1", Kind: MeshTLSAuthenticationKind},
+}
+
+type listKindMatcher struct {
+	kind schema.GroupVersionKind
+}
+
+func (m *listKindMatcher) Matches(x interface{}) bool {
+	list, ok := x.(*unstructured.UnstructuredList)
+	return ok && list.GroupVersionKind() == m.kind.GroupVersion().WithKind(m.kind.Kind+"List")
+}
+
+func (m *listKindMatcher) String() string {
+	return m.kind.Kind + "List"
+}
+
+type PolicyManagerTestSuite struct {
+	testbase.MocksSuiteBase
+	resolver *serviceidresolvermocks.MockServiceResolver
+	manager  *PolicyManagerImpl
+}
+
+func (s *PolicyManagerTestSuite) SetupTest() {
+	s.MocksSuiteBase.SetupTest()
+	s.resolver = serviceidresolvermocks.NewMockServiceResolver(s.Controller)
+	s.manager = NewPolicyManager(s.Client, &injectablerecorder.InjectableRecorder{Recorder: s.Recorder}, testKinds, []string{}, true, true)
+	s.manager.serviceIdResolver = s.resolver
+}
+
+func (s *PolicyManagerTestSuite) TearDownTest() {
+	s.manager = nil
+	s.MocksSuiteBase.TearDownTest()
+}
+
+func (s *PolicyManagerTestSuite) expectList(kind schema.GroupVersionKind, items ...*unstructured.Unstructured) {
+	s.Client.EXPECT().List(gomock.Any(), &listKindMatcher{kind: kind}, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, list *unstructured.UnstructuredList, opts ...client.ListOption) error {
+			for _, item := range items {
+				list.Items = append(list.Items, *item)
+			}
+			return nil
+		})
+}
+
+func (s *PolicyManagerTestSuite) expectCreates(count int) *[]*unstructured.Unstructured {
+	created := make([]*unstructured.Unstructured, 0)
+	s.Client.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+			created = append(created, obj.(*unstructured.Unstructured))
+			return nil
+		}).Times(count)
+	return &created
+}
+
+func (s *PolicyManagerTestSuite) expectServerPod() {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-server-pod", Namespace: testNamespace},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "server", Ports: []corev1.ContainerPort{{ContainerPort: 8080}, {ContainerPort: 53, Protocol: corev1.ProtocolUDP}}},
+				{Name: LinkerdProxyContainerName, Ports: []corev1.ContainerPort{{ContainerPort: 4143}}},
+			},
+		},
+	}
+	s.resolver.EXPECT().ResolveIntentServerToPod(gomock.Any(), gomock.Any(), testNamespace).Return(pod, nil)
+}
+
+func newClientIntents(calls ...otterizev1alpha3.Intent) *otterizev1alpha3.ClientIntents {
+	return &otterizev1alpha3.ClientIntents{
+		ObjectMeta: metav1.ObjectMeta{Name: "client-intents", Namespace: testNamespace},
+		Spec: &otterizev1alpha3.IntentsSpec{
+			Service: otterizev1alpha3.Service{Name: clientName},
+			Calls:   calls,
+		},
+	}
+}
+
+func findResource(resources []*unstructured.Unstructured, kind string, name string) *unstructured.Unstructured {
+	for _, resource := range resources {
+		if resource.GetKind() == kind && resource.GetName() == name {
+			return resource
+		}
+	}
+	return nil
+}
+
+func (s *PolicyManagerTestSuite) TestCreate_AuthorizesServerPorts() {
+	intents := newClientIntents(otterizev1alpha3.Intent{Name: serverName})
+	formattedServer := otterizev1alpha3.GetFormattedOtterizeIdentity(serverName, testNamespace)
+
+	s.expectList(testKinds.AuthorizationPolicy)
+	s.expectList(testKinds.HTTPRoute)
+	s.expectList(testKinds.MeshTLSAuthentication)
+	s.expectServerPod()
+	s.expectList(testKinds.Server)
+	s.expectList(testKinds.HTTPRoute)
+	created := s.expectCreates(3)
+
+	err := s.manager.Create(context.Background(), intents, "test-client-sa")
+	s.Require().NoError(err)
+	s.ExpectEvent(ReasonCreatedLinkerdPolicy)
+
+	policyName := "linkerd-policy-to-test-server-from-test-client.test-namespace"
+	server := findResource(*created, ServerKind, formattedServer+"-8080")
+	s.Require().NotNil(server)
+	s.Require().Equal(int64(8080), server.Object["spec"].(map[string]any)["port"])
+	s.Require().Equal(formattedServer, server.GetLabels()[otterizev1alpha3.OtterizeLinkerdServerLabelKey])
+
+	authentication := findResource(*created, MeshTLSAuthenticationKind, policyName)
+	s.Require().NotNil(authentication)
+	s.Require().Equal([]any{map[string]any{"kind": "ServiceAccount", "name": "test-client-sa", "namespace": testNamespace}},
+		authentication.Object["spec"].(map[string]any)["identityRefs"])
+
+	policy := findResource(*created, AuthorizationPolicyKind, policyName+"-8080")
+	s.Require().NotNil(policy)
+	s.Require().Equal(policyRef(ServerKind, formattedServer+"-8080"), policy.Object["spec"].(map[string]any)["targetRef"])
+	s.Require().Equal(otterizev1alpha3.GetFormattedOtterizeIdentity(clientName, testNamespace), policy.GetLabels()[otterizev1alpha3.OtterizeLinkerdClientLabelKey])
+}
+
+func (s *PolicyManagerTestSuite) TestCreate_HTTPIntentCreatesRoutes() {
+	intents := newClientIntents(otterizev1alpha3.Intent{
+		Name: serverName,
+		Type: otterizev1alpha3.IntentTypeHTTP,
+		HTTPResources: []otterizev1alpha3.HTTPResource{
+			{Path: "/api/*", Methods: []otterizev1alpha3.HTTPMethod{otterizev1alpha3.HTTPMethodGet}},
+		},
+	})
+	formattedServer := otterizev1alpha3.GetFormattedOtterizeIdentity(serverName, testNamespace)
+
+	s.expectList(testKinds.AuthorizationPolicy)
+	s.expectList(testKinds.HTTPRoute)
+	s.expectList(testKinds.MeshTLSAuthentication)
+	s.expectServerPod()
+	s.expectList(testKinds.Server)
+	s.expectList(testKinds.HTTPRoute)
+	created := s.expectCreates(5)
+
+	err := s.manager.Create(context.Background(), intents, "test-client-sa")
+	s.Require().NoError(err)
+	s.ExpectEvent(ReasonCreatedLinkerdPolicy)
+
+	policyName := "linkerd-policy-to-test-server-from-test-client.test-namespace"
+	s.Require().NotNil(findResource(*created, HTTPRouteKind, formattedServer+"-default"))
+
+	route := findResource(*created, HTTPRouteKind, policyName)
+	s.Require().NotNil(route)
+	s.Require().Equal([]any{map[string]any{
+		"matches": []any{map[string]any{"path": map[string]any{"type": "PathPrefix", "value": "/api/"}, "method": "GET"}},
+	}}, route.Object["spec"].(map[string]any)["rules"])
+
+	policy := findResource(*created, AuthorizationPolicyKind, policyName)
+	s.Require().NotNil(policy)
+	s.Require().Equal(policyRef(HTTPRouteKind, policyName), policy.Object["spec"].(map[string]any)["targetRef"])
+}
+
+func (s *PolicyManagerTestSuite) TestCreate_EnforcementDisabled() {
+	s.manager.enableLinkerdPolicyCreation = false
+	intents := newClientIntents(otterizev1alpha3.Intent{Name: serverName})
+
+	s.expectList(testKinds.AuthorizationPolicy)
+	s.expectList(testKinds.HTTPRoute)
+	s.expectList(testKinds.MeshTLSAuthentication)
+
+	err := s.manager.Create(context.Background(), intents, "test-client-sa")
+	s.Require().NoError(err)
+	s.ExpectEvent(consts.ReasonLinkerdPolicyCreationDisabled)
+}
+
+func (s *PolicyManagerTestSuite) TestDeleteAll_DeletesUnusedServers() {
+	intents := newClientIntents(otterizev1alpha3.Intent{Name: serverName})
+	formattedServer := otterizev1alpha3.GetFormattedOtterizeIdentity(serverName, testNamespace)
+	labels := map[string]string{
+		otterizev1alpha3.OtterizeServerLabelKey:        formattedServer,
+		otterizev1alpha3.OtterizeLinkerdClientLabelKey: otterizev1alpha3.GetFormattedOtterizeIdentity(clientName, testNamespace),
+	}
+	policy := newResource(testKinds.AuthorizationPolicy, "policy", testNamespace, labels, map[string]any{})
+	authentication := newResource(testKinds.MeshTLSAuthentication, "policy", testNamespace, labels, map[string]any{})
+	server := newResource(testKinds.Server, formattedServer+"-8080", testNamespace,
+		map[string]string{otterizev1alpha3.OtterizeLinkerdServerLabelKey: formattedServer}, map[string]any{})
+
+	s.expectList(testKinds.AuthorizationPolicy, policy)
+	s.expectList(testKinds.HTTPRoute)
+	s.expectList(testKinds.MeshTLSAuthentication, authentication)
+	s.Client.EXPECT().Delete(gomock.Any(), policy).Return(nil)
+	s.Client.EXPECT().Delete(gomock.Any(), authentication).Return(nil)
+	s.expectList(testKinds.AuthorizationPolicy)
+	s.expectList(testKinds.Server, server)
+	s.expectList(testKinds.HTTPRoute)
+	s.Client.EXPECT().Delete(gomock.Any(), server).Return(nil)
+
+	err := s.manager.DeleteAll(context.Background(), intents)
+	s.Require().NoError(err)
+}
+
+func (s *PolicyManagerTestSuite) TestDeleteAll_KeepsServersUsedByOtherClients() {
+	intents := newClientIntents(otterizev1alpha3.Intent{Name: serverName})
+	formattedServer := otterizev1alpha3.GetFormattedOtterizeIdentity(serverName, testNamespace)
+	policy := newResource(testKinds.AuthorizationPolicy, "policy", testNamespace, map[string]string{
+		otterizev1alpha3.OtterizeServerLabelKey:        formattedServer,
+		otterizev1alpha3.OtterizeLinkerdClientLabelKey: otterizev1alpha3.GetFormattedOtterizeIdentity(clientName, testNamespace),
+	}, map[string]any{})
+	otherClientPolicy := newResource(testKinds.AuthorizationPolicy, "other-policy", testNamespace, map[string]string{
+		otterizev1alpha3.OtterizeServerLabelKey:        formattedServer,
+		otterizev1alpha3.OtterizeLinkerdClientLabelKey: otterizev1alpha3.GetFormattedOtterizeIdentity("other-client", testNamespace),
+	}, map[string]any{})
+
+	s.expectList(testKinds.AuthorizationPolicy, policy)
+	s.expectList(testKinds.HTTPRoute)
+	s.expectList(testKinds.MeshTLSAuthentication)
+	s.Client.EXPECT().Delete(gomock.Any(), policy).Return(nil)
+	s.expectList(testKinds.AuthorizationPolicy, otherClientPolicy)
+
+	err := s.manager.DeleteAll(context.Background(), intents)
+	s.Require().NoError(err)
+}
+
+func (s *PolicyManagerTestSuite) TestUpdateServerSidecar_DoesNotTouchIstioAnnotations() {
+	clientIntents := newClientIntents(otterizev1alpha3.Intent{Name: serverName})
+	clientIntents.Annotations = map[string]string{otterizev1alpha3.OtterizeServersWithoutSidecarAnnotation: "[]"}
+	formattedServer := otterizev1alpha3.GetFormattedOtterizeIdentity(serverName, testNamespace)
+
+	s.Client.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			annotations := obj.GetAnnotations()
+			s.Require().Equal("[]", annotations[otterizev1alpha3.OtterizeServersWithoutSidecarAnnotation])
+			s.Require().Equal(`["`+formattedServer+`"]`, annotations[otterizev1alpha3.OtterizeLinkerdServersWithoutProxyAnnotation])
+			return nil
+		})
+
+	err := s.manager.UpdateServerSidecar(context.Background(), clientIntents, formattedServer, true)
+	s.Require().NoError(err)
+	s.ExpectEvent(ReasonServerMissingSidecar)
+}
+
+func (s *PolicyManagerTestSuite) TestHTTPResourcesToRouteRules() {
+	rules := httpResourcesToRouteRules([]otterizev1alpha3.HTTPResource{
+		{Path: "/exact"},
+		{Path: "*", Methods: []otterizev1alpha3.HTTPMethod{otterizev1alpha3.HTTPMethodGet, otterizev1alpha3.HTTPMethodPost, otterizev1alpha3.HTTPMethodGet}},
+	})
+
+	s.Require().Equal([]any{
+		map[string]any{"matches": []any{map[string]any{"path": map[string]any{"type": "Exact", "value": "/exact"}}}},
+		map[string]any{"matches": []any{
+			map[string]any{"path": map[string]any{"type": "PathPrefix", "value": "/"}, "method": "GET"},
+			map[string]any{"path": map[string]any{"type": "PathPrefix", "value": "/"}, "method": "POST"},
+		}},
+	}, rules)
+}
+
+func (s *PolicyManagerTestSuite) TestIsSubset() {
+	set := map[string]any{
+		"port":          int64(8080),
+		"proxyProtocol": "HTTP/1",
+		"podSelector":   map[string]any{"matchLabels": map[string]any{"app": "server"}},
+	}
+
+	s.Require().True(isSubset(map[string]any{"port": int64(8080), "podSelector": map[string]any{"matchLabels": map[string]any{"app": "server"}}}, set))
+	s.Require().False(isSubset(map[string]any{"port": int64(8081)}, set))
+	s.Require().False(isSubset(map[string]any{"podSelector": map[string]any{"matchLabels": map[string]any{"app": "other"}}}, set))
+	s.Require().True(isSubset(map[string]string{}, nil))
+	s.Require().False(isSubset([]any{"a"}, []any{"a", "b"}))
+}
+
+func TestPolicyManagerTestSuite(t *testing.T) {
+	suite.Run(t, new(PolicyManagerTestSuite))
+}
@@ -0,0 +1,127 @@
+package linkerdpolicy
+
+import (
+	"fmt"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"strings"
+)
+
+func newResource(kind schema.GroupVersionKind, name string, namespace string, labels map[string]string, spec map[string]any) *unstructured.Unstructured {
+	resource := &unstructured.Unstructured{Object: map[string]any{"spec": spec}}
+	resource.SetGroupVersionKind(kind)
+	resource.SetName(name)
+	resource.SetNamespace(namespace)
+	resource.SetLabels(labels)
+	return resource
+}
+
+func policyRef(kind string, name string) map[string]any {
+	return map[string]any{
+		"group": LinkerdPolicyAPIGroup,
+		"kind":  kind,
+		"name":  name,
+	}
+}
+
+func (c *PolicyManagerImpl) buildServer(server serverPort, namespace string, formattedServer string) *unstructured.Unstructured {
+	return newResource(c.kinds.Server, server.name, namespace,
+		map[string]string{otterizev1alpha3.OtterizeLinkerdServerLabelKey: formattedServer},
+		map[string]any{
+			"podSelector": map[string]any{
+				"matchLabels": map[string]any{
+					otterizev1alpha3.OtterizeServerLabelKey: formattedServer,
+				},
+			},
+			"port": int64(server.port),
+		})
+}
+
+func (c *PolicyManagerImpl) buildDefaultRoute(servers []serverPort, namespace string, formattedServer string) *unstructured.Unstructured {
+	return newResource(c.kinds.HTTPRoute, fmt.Sprintf(defaultRouteNameTemplate, formattedServer), namespace,
+		map[string]string{otterizev1alpha3.OtterizeLinkerdServerLabelKey: formattedServer},
+		map[string]any{
+			"parentRefs": serverRefs(servers),
+			"rules": []any{
+				map[string]any{
+					"matches": []any{
+						map[string]any{"path": map[string]any{"type": "PathPrefix", "value": "/"}},
+					},
+				},
+			},
+		})
+}
+
+// buildClientResources returns the resources authorizing the client to call the intent's target: a
+// MeshTLSAuthentication matching the client's service account, and an AuthorizationPolicy requiring it for each
+// Server, or for HTTP intents, for an HTTPRoute matching the intent's resources.
+func (c *PolicyManagerImpl) buildClientResources(clientIntents *otterizev1alpha3.ClientIntents, intent otterizev1alpha3.Intent, clientServiceAccount string, servers []serverPort) []*unstructured.Unstructured {
+	serverNamespace := intent.GetTargetServerNamespace(clientIntents.Namespace)
+	clientName := fmt.Sprintf("%s.%s", clientIntents.GetServiceName(), clientIntents.Namespace)
+	policyName := fmt.Sprintf(OtterizeLinkerdPolicyNameTemplate, intent.GetTargetServerName(), clientName)
+	labels := map[string]string{
+		otterizev1alpha3.OtterizeServerLabelKey:        otterizev1alpha3.GetFormattedOtterizeIdentity(intent.GetTargetServerName(), serverNamespace),
+		otterizev1alpha3.OtterizeLinkerdClientLabelKey: otterizev1alpha3.GetFormattedOtterizeIdentity(clientIntents.GetServiceName(), clientIntents.Namespace),
+	}
+
+	authentication := newResource(c.kinds.MeshTLSAuthentication, policyName, serverNamespace, labels, map[string]any{
+		"identityRefs": []any{
+			map[string]any{
+				"kind":      "ServiceAccount",
+				"name":      clientServiceAccount,
+				"namespace": clientIntents.Namespace,
+			},
+		},
+	})
+	authorizationSpec := func(targetRef map[string]any) map[string]any {
+		return map[string]any{
+			"targetRef":                  targetRef,
+			"requiredAuthenticationRefs": []any{policyRef(MeshTLSAuthenticationKind, policyName)},
+		}
+	}
+
+	resources := []*unstructured.Unstructured{authentication}
+	if intent.Type == otterizev1alpha3.IntentTypeHTTP {
+		route := newResource(c.kinds.HTTPRoute, policyName, serverNamespace, labels, map[string]any{
+			"parentRefs": serverRefs(servers),
+			"rules":      httpResourcesToRouteRules(intent.HTTPResources),
+		})
+		policy := newResource(c.kinds.AuthorizationPolicy, policyName, serverNamespace, labels, authorizationSpec(policyRef(HTTPRouteKind, policyName)))
+		return append(resources, route, policy)
+	}
+
+	for _, server := range servers {
+		policyForPort := newResource(c.kinds.AuthorizationPolicy, fmt.Sprintf("%s-%d", policyName, server.port), serverNamespace, labels, authorizationSpec(policyRef(ServerKind, server.name)))
+		resources = append(resources, policyForPort)
+	}
+	return resources
+}
+
+func serverRefs(servers []serverPort) []any {
+	return lo.Map(servers, func(server serverPort, _ int) any {
+		return policyRef(ServerKind, server.name)
+	})
+}
+
+// httpResourcesToRouteRules maps each HTTP resource to a rule. Paths ending with '*' match by prefix, like in Istio
+// authorization policies, and any other path must match exactly.
+func httpResourcesToRouteRules(resources []otterizev1alpha3.HTTPResource) []any {
+	return lo.Map(resources, func(resource otterizev1alpha3.HTTPResource, _ int) any {
+		path := map[string]any{"type": "Exact", "value": resource.Path}
+		if strings.HasSuffix(resource.Path, "*") {
+			path = map[string]any{"type": "PathPrefix", "value": lo.Ternary(resource.Path == "*", "/", strings.TrimSuffix(resource.Path, "*"))}
+		}
+
+		if len(resource.Methods) == 0 {
+			return map[string]any{"matches": []any{map[string]any{"path": path}}}
+		}
+
+		return map[string]any{
+			"matches": lo.Map(lo.Uniq(resource.Methods), func(method otterizev1alpha3.HTTPMethod, _ int) any {
+				return map[string]any{"path": path, "method": string(method)}
+			}),
+		}
+	})
+}
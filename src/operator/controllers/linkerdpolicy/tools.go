@@ -0,0 +1,76 @@
+package linkerdpolicy
+
+import (
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	LinkerdPolicyAPIGroup     = "policy.linkerd.io"
+	LinkerdProxyContainerName = "linkerd-proxy"
+	ServerKind                = "Server"
+	HTTPRouteKind             = "HTTPRoute"
+	AuthorizationPolicyKind   = "AuthorizationPolicy"
+	MeshTLSAuthenticationKind = "MeshTLSAuthentication"
+)
+
+// PolicyKinds are the GroupVersionKinds of the Linkerd policy resources served by the cluster. Linkerd has moved these
+// resources between versions across releases, so the preferred version of each is used, and the resources are handled
+// as unstructured objects.
+type PolicyKinds struct {
+	Server                schema.GroupVersionKind
+	HTTPRoute             schema.GroupVersionKind
+	AuthorizationPolicy   schema.GroupVersionKind
+	MeshTLSAuthentication schema.GroupVersionKind
+}
+
+// DetectPolicyKinds returns the Linkerd policy kinds served by the cluster, or nil if any of their CRDs is not installed.
+func DetectPolicyKinds(mapper meta.RESTMapper) (*PolicyKinds, error) {
+	kinds := make(map[string]schema.GroupVersionKind)
+	for _, kind := range []string{ServerKind, HTTPRouteKind, AuthorizationPolicyKind, MeshTLSAuthenticationKind} {
+		mapping, err := mapper.RESTMapping(schema.GroupKind{Group: LinkerdPolicyAPIGroup, Kind: kind})
+		if meta.IsNoMatchError(err) {
+			logrus.Debugf("Linkerd %s CRD not found, Linkerd policy creation disabled", kind)
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		kinds[kind] = mapping.GroupVersionKind
+	}
+
+	return &PolicyKinds{
+		Server:                kinds[ServerKind],
+		HTTPRoute:             kinds[HTTPRouteKind],
+		AuthorizationPolicy:   kinds[AuthorizationPolicyKind],
+		MeshTLSAuthentication: kinds[MeshTLSAuthenticationKind],
+	}, nil
+}
+
+func IsPodPartOfLinkerdMesh(pod corev1.Pod) bool {
+	isProxy := func(container corev1.Container) bool {
+		return container.Name == LinkerdProxyContainerName
+	}
+	// Linkerd runs the proxy as a native sidecar (an init container) when configured to.
+	return lo.SomeBy(pod.Spec.Containers, isProxy) || lo.SomeBy(pod.Spec.InitContainers, isProxy)
+}
+
+// serverPorts returns the ports the pod's containers serve on, excluding those of the Linkerd proxy itself.
+func serverPorts(pod corev1.Pod) []int32 {
+	ports := make([]int32, 0)
+	for _, container := range pod.Spec.Containers {
+		if container.Name == LinkerdProxyContainerName {
+			continue
+		}
+		for _, port := range container.Ports {
+			if port.Protocol != "" && port.Protocol != corev1.ProtocolTCP {
+				continue
+			}
+			ports = append(ports, port.ContainerPort)
+		}
+	}
+	return ports
+}
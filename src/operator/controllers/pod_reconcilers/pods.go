@@ -5,6 +5,7 @@ import (
 	"fmt"
 	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
 	"github.com/otterize/intents-operator/src/operator/controllers/istiopolicy"
+	"github.com/otterize/intents-operator/src/operator/controllers/linkerdpolicy"
 	"github.com/otterize/intents-operator/src/shared/injectablerecorder"
 	"github.com/otterize/intents-operator/src/shared/operatorconfig"
 	"github.com/otterize/intents-operator/src/shared/serviceidresolver"
@@ -14,12 +15,16 @@ import (
 	"github.com/spf13/viper"
 	v1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
@@ -33,12 +38,14 @@ type PodWatcher struct {
 	client.Client
 	serviceIdResolver *serviceidresolver.Resolver
 	istioPolicyAdmin  istiopolicy.PolicyManager
+	// linkerdPolicyAdmin is only set when the Linkerd policy CRDs are installed.
+	linkerdPolicyAdmin linkerdpolicy.PolicyManager
 	injectablerecorder.InjectableRecorder
 }
 
-func NewPodWatcher(c client.Client, eventRecorder record.EventRecorder, watchedNamespaces []string, enforcementDefaultState bool, istioEnforcementEnabled bool) *PodWatcher {
+func NewPodWatcher(c client.Client, eventRecorder record.EventRecorder, watchedNamespaces []string, enforcementDefaultState bool, istioEnforcementEnabled bool, istioTrustDomain istiopolicy.TrustDomain, istioConsolidatedPolicies bool) *PodWatcher {
 	recorder := injectablerecorder.InjectableRecorder{Recorder: eventRecorder}
-	creator := istiopolicy.NewPolicyManager(c, &recorder, watchedNamespaces, enforcementDefaultState, istioEnforcementEnabled)
+	creator := istiopolicy.NewPolicyManager(c, &recorder, watchedNamespaces, enforcementDefaultState, istioEnforcementEnabled, istioTrustDomain, istioConsolidatedPolicies)
 	return &PodWatcher{
 		Client:             c,
 		serviceIdResolver:  serviceidresolver.NewResolver(c),
@@ -47,6 +54,10 @@ func NewPodWatcher(c client.Client, eventRecorder record.EventRecorder, watchedN
 	}
 }
 
+func (p *PodWatcher) SetLinkerdPolicyManager(policyManager linkerdpolicy.PolicyManager) {
+	p.linkerdPolicyAdmin = policyManager
+}
+
 func (p *PodWatcher) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logrus.Infof("Reconciling due to pod change: %s", req.Name)
 	pod := v1.Pod{}
@@ -86,6 +97,11 @@ func (p *PodWatcher) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, err
 	}
 
+	err = p.handleLinkerdPolicy(ctx, pod, serviceID)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	return ctrl.Result{}, nil
 }
 
@@ -134,12 +150,86 @@ func (p *PodWatcher) handleIstioPolicy(ctx context.Context, pod v1.Pod, serviceI
 	return nil
 }
 
+// handleLinkerdPolicy creates the Linkerd policies of clients once their pods start, and those of clients calling the
+// pod, since the Servers of a server can only be created once its ports are known.
+func (p *PodWatcher) handleLinkerdPolicy(ctx context.Context, pod v1.Pod, serviceID serviceidentity.ServiceIdentity) error {
+	if p.linkerdPolicyAdmin == nil || pod.DeletionTimestamp != nil {
+		return nil
+	}
+
+	missingSideCar := !linkerdpolicy.IsPodPartOfLinkerdMesh(pod)
+	formattedServer := otterizev1alpha3.GetFormattedOtterizeIdentity(serviceID.Name, pod.Namespace)
+	var callersIntents otterizev1alpha3.ClientIntentsList
+	err := p.List(
+		ctx, &callersIntents,
+		&client.MatchingFields{otterizev1alpha3.OtterizeTargetServerIndexField: fmt.Sprintf("%s.%s", serviceID.Name, pod.Namespace)})
+	if err != nil {
+		return err
+	}
+
+	for _, clientIntents := range callersIntents.Items {
+		err = p.linkerdPolicyAdmin.UpdateServerSidecar(ctx, &clientIntents, formattedServer, missingSideCar)
+		if err != nil {
+			return err
+		}
+
+		clientServiceAccount, ok := clientIntents.Annotations[otterizev1alpha3.OtterizeLinkerdClientServiceAccountAnnotation]
+		if !ok || missingSideCar || clientIntents.DeletionTimestamp != nil || clientIntents.Annotations[otterizev1alpha3.OtterizeLinkerdMissingProxyAnnotation] != "false" {
+			continue
+		}
+
+		err = p.linkerdPolicyAdmin.Create(ctx, &clientIntents, clientServiceAccount)
+		if err != nil {
+			logrus.WithError(err).Errorln("Failed creating Linkerd policies")
+			return err
+		}
+	}
+
+	var intents otterizev1alpha3.ClientIntentsList
+	err = p.List(
+		ctx,
+		&intents,
+		&client.MatchingFields{OtterizeClientNameIndexField: serviceID.Name},
+		&client.ListOptions{Namespace: pod.Namespace})
+	if err != nil {
+		return err
+	}
+
+	for _, clientIntents := range intents.Items {
+		if clientIntents.DeletionTimestamp != nil {
+			continue
+		}
+
+		err = p.linkerdPolicyAdmin.UpdateIntentsStatus(ctx, &clientIntents, pod.Spec.ServiceAccountName, missingSideCar)
+		if err != nil {
+			return err
+		}
+
+		if missingSideCar {
+			logrus.Infof("Pod %s/%s does not have a Linkerd proxy, skipping Linkerd policy creation", pod.Namespace, pod.Name)
+			continue
+		}
+
+		err = p.linkerdPolicyAdmin.Create(ctx, &clientIntents, pod.Spec.ServiceAccountName)
+		if err != nil {
+			logrus.WithError(err).Errorln("Failed creating Linkerd policies")
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (p *PodWatcher) updateServerSideCar(ctx context.Context, pod v1.Pod, serviceID serviceidentity.ServiceIdentity) error {
-	missingSideCar := !istiopolicy.IsPodPartOfIstioMesh(pod)
+	enrollment, err := istiopolicy.ResolveMeshEnrollment(ctx, p.Client, p.serviceIdResolver, pod)
+	if err != nil {
+		return err
+	}
+	missingSideCar := !enrollment.IsEnrolled()
 
 	serviceFullName := fmt.Sprintf("%s.%s", serviceID.Name, pod.Namespace)
 	var intentsList otterizev1alpha3.ClientIntentsList
-	err := p.List(
+	err = p.List(
 		ctx, &intentsList,
 		&client.MatchingFields{otterizev1alpha3.OtterizeTargetServerIndexField: serviceFullName})
 	if err != nil {
@@ -156,6 +246,29 @@ func (p *PodWatcher) updateServerSideCar(ctx context.Context, pod v1.Pod, servic
 		if err != nil {
 			return err
 		}
+
+		ambientServers, err := clientIntents.GetAmbientServers()
+		if err != nil {
+			return err
+		}
+		if _, wasAmbient := ambientServers[formattedTargetServer]; !wasAmbient && !enrollment.Ambient {
+			continue
+		}
+
+		err = p.istioPolicyAdmin.UpdateServerAmbientStatus(ctx, &clientIntents, formattedTargetServer, enrollment.AmbientServer())
+		if err != nil {
+			return err
+		}
+
+		// The policies of the client are updated to be enforced by the server's waypoint, or by ztunnel.
+		clientServiceAccount, ok := clientIntents.Annotations[otterizev1alpha3.OtterizeClientServiceAccountAnnotation]
+		if !ok || clientIntents.DeletionTimestamp != nil || clientIntents.Annotations[otterizev1alpha3.OtterizeMissingSidecarAnnotation] != "false" {
+			continue
+		}
+		err = p.istioPolicyAdmin.Create(ctx, &clientIntents, clientServiceAccount)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -231,9 +344,13 @@ func (p *PodWatcher) createIstioPolicies(ctx context.Context, intents otterizev1
 		return nil
 	}
 
-	missingSideCar := !istiopolicy.IsPodPartOfIstioMesh(pod)
+	enrollment, err := istiopolicy.ResolveMeshEnrollment(ctx, p.Client, p.serviceIdResolver, pod)
+	if err != nil {
+		return err
+	}
+	missingSideCar := !enrollment.IsEnrolled()
 
-	err := p.istioPolicyAdmin.UpdateIntentsStatus(ctx, &intents, pod.Spec.ServiceAccountName, missingSideCar)
+	err = p.istioPolicyAdmin.UpdateIntentsStatus(ctx, &intents, pod.Spec.ServiceAccountName, missingSideCar)
 	if err != nil {
 		return err
 	}
@@ -272,6 +389,19 @@ func (p *PodWatcher) InitIntentsClientIndices(mgr manager.Manager) error {
 	return nil
 }
 
+func (p *PodWatcher) mapNamespaceToPods(obj client.Object) []reconcile.Request {
+	var pods v1.PodList
+	err := p.List(context.Background(), &pods, client.InNamespace(obj.GetName()))
+	if err != nil {
+		logrus.WithError(err).Errorf("Failed listing pods in namespace %s", obj.GetName())
+		return nil
+	}
+
+	return lo.Map(pods.Items, func(pod v1.Pod, _ int) reconcile.Request {
+		return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}}
+	})
+}
+
 func (p *PodWatcher) Register(mgr manager.Manager) error {
 	watcher, err := controller.New("intents-operator", mgr, controller.Options{
 		Reconciler:   p,
@@ -285,5 +415,24 @@ func (p *PodWatcher) Register(mgr manager.Manager) error {
 		return fmt.Errorf("unable to watch Pods: %p", err)
 	}
 
+	// Enrolling a namespace in Istio ambient mode, or setting its waypoint, changes how the Istio policies of its pods
+	// are enforced.
+	err = watcher.Watch(
+		&source.Kind{Type: &v1.Namespace{}},
+		handler.EnqueueRequestsFromMapFunc(p.mapNamespaceToPods),
+		predicate.Funcs{
+			CreateFunc:  func(event.CreateEvent) bool { return false },
+			DeleteFunc:  func(event.DeleteEvent) bool { return false },
+			GenericFunc: func(event.GenericEvent) bool { return false },
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldLabels, newLabels := e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels()
+				return oldLabels[istiopolicy.IstioDataplaneModeLabel] != newLabels[istiopolicy.IstioDataplaneModeLabel] ||
+					oldLabels[istiopolicy.IstioUseWaypointLabel] != newLabels[istiopolicy.IstioUseWaypointLabel]
+			},
+		})
+	if err != nil {
+		return fmt.Errorf("unable to watch Namespaces: %w", err)
+	}
+
 	return nil
 }
@@ -0,0 +1,137 @@
+package policy_drift
+
+import (
+	"context"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/shared/injectablerecorder"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"strings"
+)
+
+// operatorFieldManager is the field manager the API server records for the operator's own writes. The operator's
+// client uses the default user agent, from which the API server derives the field manager.
+var operatorFieldManager = strings.Split(rest.DefaultKubernetesUserAgent(), "/")[0]
+
+// PolicyDriftHandler watches policies generated from ClientIntents, and enqueues the ClientIntents that own them
+// when they are modified or deleted outside the operator, so that the ClientIntents controller regenerates them.
+// Reverts are made by the ClientIntents controller itself, so they are serialized with the rest of its work.
+// Policies annotated with intents.otterize.com/manual-override=true are left as-is.
+type PolicyDriftHandler struct {
+	injectablerecorder.InjectableRecorder
+	policyKind  string
+	object      client.Object
+	driftReason string
+	isGenerated func(obj client.Object) bool
+	listOwners  func(ctx context.Context, policy client.Object) ([]otterizev1alpha3.ClientIntents, error)
+}
+
+var _ handler.EventHandler = &PolicyDriftHandler{}
+
+// Object returns the type of the policies handled, to be watched by the ClientIntents controller.
+func (h *PolicyDriftHandler) Object() client.Object {
+	return h.object
+}
+
+func (h *PolicyDriftHandler) Create(event.CreateEvent, workqueue.RateLimitingInterface) {}
+
+func (h *PolicyDriftHandler) Generic(event.GenericEvent, workqueue.RateLimitingInterface) {}
+
+// Update enqueues the owners of a policy whose spec was modified outside the operator. Metadata-only updates
+// (generation unchanged) are ignored.
+func (h *PolicyDriftHandler) Update(e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	policy := e.ObjectNew
+	if e.ObjectOld.GetGeneration() == policy.GetGeneration() || !policy.GetDeletionTimestamp().IsZero() || !h.isGenerated(policy) {
+		return
+	}
+
+	if !isModifiedOutsideOperator(e.ObjectOld, policy) {
+		return
+	}
+
+	owners := h.enqueueOwners(policy, q)
+	if len(owners) == 0 {
+		return
+	}
+
+	logrus.Infof("%s %s/%s was modified outside the operator, reverting", h.policyKind, policy.GetNamespace(), policy.GetName())
+	for i := range owners {
+		h.RecordWarningEventf(
+			&owners[i],
+			h.driftReason,
+			"%s %s/%s was modified outside the intents operator and is being reverted. To keep manual changes, annotate it with %s=true",
+			h.policyKind,
+			policy.GetNamespace(),
+			policy.GetName(),
+			otterizev1alpha3.OtterizeManualOverrideAnnotation,
+		)
+	}
+}
+
+// Delete enqueues the owners of a deleted policy, so that it is recreated if it is still required. Policies the
+// operator deletes itself have no owners left, or are not regenerated when their owners are reconciled.
+func (h *PolicyDriftHandler) Delete(e event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	if !h.isGenerated(e.Object) {
+		return
+	}
+
+	owners := h.enqueueOwners(e.Object, q)
+	if len(owners) > 0 {
+		logrus.Debugf("%s %s/%s was deleted, reconciling its owning client intents", h.policyKind, e.Object.GetNamespace(), e.Object.GetName())
+	}
+}
+
+func (h *PolicyDriftHandler) enqueueOwners(policy client.Object, q workqueue.RateLimitingInterface) []otterizev1alpha3.ClientIntents {
+	owners, err := h.listOwners(context.Background(), policy)
+	if err != nil {
+		logrus.WithError(err).Errorf("Failed to list client intents owning %s %s/%s", h.policyKind, policy.GetNamespace(), policy.GetName())
+		return nil
+	}
+
+	for _, owner := range owners {
+		q.Add(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: owner.Namespace, Name: owner.Name}})
+	}
+
+	return owners
+}
+
+// isModifiedOutsideOperator returns whether an update was made by a field manager other than the operator, based on
+// the managed fields entries the update added or touched. Updates whose field manager cannot be determined are
+// considered to be made outside the operator.
+func isModifiedOutsideOperator(oldObj client.Object, newObj client.Object) bool {
+	oldEntries := make(map[string]metav1.ManagedFieldsEntry)
+	for _, entry := range oldObj.GetManagedFields() {
+		oldEntries[managedFieldsEntryKey(entry)] = entry
+	}
+
+	writers := make([]string, 0)
+	for _, entry := range newObj.GetManagedFields() {
+		oldEntry, ok := oldEntries[managedFieldsEntryKey(entry)]
+		if ok && oldEntry.Time.Equal(entry.Time) {
+			continue
+		}
+		writers = append(writers, entry.Manager)
+	}
+
+	if len(writers) == 0 {
+		return true
+	}
+
+	for _, writer := range writers {
+		if writer != operatorFieldManager {
+			return true
+		}
+	}
+	return false
+}
+
+func managedFieldsEntryKey(entry metav1.ManagedFieldsEntry) string {
+	return strings.Join([]string{entry.Manager, string(entry.Operation), entry.Subresource}, "/")
+}
@@ -0,0 +1,61 @@
+package policy_drift
+
+import (
+	"context"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/consts"
+	"github.com/otterize/intents-operator/src/operator/controllers/istiopolicy"
+	"github.com/samber/lo"
+	"istio.io/client-go/pkg/apis/security/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//+kubebuilder:rbac:groups="security.istio.io",resources=authorizationpolicies,verbs=get;list;watch
+
+type istioPolicyOwners struct {
+	client client.Client
+}
+
+// NewIstioPolicyDriftHandler creates a handler that reverts drift of Istio authorization policies generated from
+// ClientIntents, by enqueueing the ClientIntents of the policy's client.
+func NewIstioPolicyDriftHandler(c client.Client) *PolicyDriftHandler {
+	owners := &istioPolicyOwners{client: c}
+	return &PolicyDriftHandler{
+		policyKind:  "Istio policy",
+		object:      &v1beta1.AuthorizationPolicy{},
+		driftReason: consts.ReasonIstioPolicyDriftReverted,
+		isGenerated: isGeneratedIstioPolicy,
+		listOwners:  owners.listOwners,
+	}
+}
+
+// listOwners returns the ClientIntents whose reconciliation regenerates the policy. Consolidated policies are
+// regenerated from all the intents calling the server, so reconciling any one of them is enough.
+func (o *istioPolicyOwners) listOwners(ctx context.Context, policy client.Object) ([]otterizev1alpha3.ClientIntents, error) {
+	labels := policy.GetLabels()
+	if labels[istiopolicy.OtterizeIstioConsolidatedPolicyLabelKey] == "true" {
+		formattedServer := labels[otterizev1alpha3.OtterizeServerLabelKey]
+		if labels[istiopolicy.OtterizeIstioKubernetesServiceLabelKey] == "true" {
+			formattedServer = "svc:" + formattedServer
+		}
+		var intentsList otterizev1alpha3.ClientIntentsList
+		err := o.client.List(
+			ctx,
+			&intentsList,
+			&client.MatchingFields{otterizev1alpha3.OtterizeFormattedTargetServerIndexField: formattedServer},
+		)
+		if err != nil {
+			return nil, err
+		}
+		return lo.Slice(intentsList.Items, 0, 1), nil
+	}
+
+	// Authorization policies are created in the server's namespace, so the client may be in any namespace.
+	return listIntentsOfClient(ctx, o.client, "", labels[otterizev1alpha3.OtterizeIstioClientAnnotationKey])
+}
+
+func isGeneratedIstioPolicy(obj client.Object) bool {
+	_, isClientPolicy := obj.GetLabels()[otterizev1alpha3.OtterizeIstioClientAnnotationKey]
+	isConsolidatedPolicy := obj.GetLabels()[istiopolicy.OtterizeIstioConsolidatedPolicyLabelKey] == "true"
+	return (isClientPolicy || isConsolidatedPolicy) && !otterizev1alpha3.IsManuallyOverridden(obj)
+}
@@ -0,0 +1,84 @@
+package policy_drift
+
+import (
+	"context"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/consts"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// driftedNetworkPolicyLabels are the labels of network policies generated from ClientIntents, in the order they are
+// checked when deciding which label identifies a policy's owners.
+var driftedNetworkPolicyLabels = []string{
+	otterizev1alpha3.OtterizeNetworkPolicy,
+	otterizev1alpha3.OtterizeSvcNetworkPolicy,
+	otterizev1alpha3.OtterizeEgressNetworkPolicy,
+	otterizev1alpha3.OtterizeSvcEgressNetworkPolicy,
+}
+
+//+kubebuilder:rbac:groups="networking.k8s.io",resources=networkpolicies,verbs=get;list;watch
+//+kubebuilder:rbac:groups=k8s.otterize.com,resources=clientintents,verbs=get;list;watch
+
+type networkPolicyOwners struct {
+	client      client.Client
+	ownedLabels []string
+}
+
+// NewNetworkPolicyDriftHandler creates a handler that reverts drift of network policies labeled with one of
+// ownedLabels (e.g. intents.otterize.com/network-policy), which should only include the labels of policies generated
+// by enabled reconcilers.
+func NewNetworkPolicyDriftHandler(c client.Client, ownedLabels []string) *PolicyDriftHandler {
+	owners := &networkPolicyOwners{client: c, ownedLabels: ownedLabels}
+	return &PolicyDriftHandler{
+		policyKind:  "Network policy",
+		object:      &v1.NetworkPolicy{},
+		driftReason: consts.ReasonNetworkPolicyDriftReverted,
+		isGenerated: owners.isGeneratedPolicy,
+		listOwners:  owners.listOwners,
+	}
+}
+
+func (o *networkPolicyOwners) getOwningLabel(policy client.Object) (string, bool) {
+	// Default deny and external traffic policies are derived from ProtectedServices and Services rather than intents.
+	_, isDefaultDeny := policy.GetLabels()[otterizev1alpha3.OtterizeNetworkPolicyServiceDefaultDeny]
+	_, isExternalTraffic := policy.GetLabels()[otterizev1alpha3.OtterizeNetworkPolicyExternalTraffic]
+	if isDefaultDeny || isExternalTraffic {
+		return "", false
+	}
+
+	for _, label := range driftedNetworkPolicyLabels {
+		if _, ok := policy.GetLabels()[label]; !ok {
+			continue
+		}
+		return label, lo.Contains(o.ownedLabels, label)
+	}
+	return "", false
+}
+
+func (o *networkPolicyOwners) listOwners(ctx context.Context, policy client.Object) ([]otterizev1alpha3.ClientIntents, error) {
+	label, found := o.getOwningLabel(policy)
+	if !found {
+		return nil, nil
+	}
+
+	labelValue := policy.GetLabels()[label]
+	switch label {
+	case otterizev1alpha3.OtterizeNetworkPolicy:
+		return listIntentsCallingServer(ctx, o.client, labelValue, otterizev1alpha3.OtterizeNetworkPolicyNameTemplate, policy)
+	case otterizev1alpha3.OtterizeSvcNetworkPolicy:
+		return listIntentsCallingServer(ctx, o.client, "svc:"+labelValue, otterizev1alpha3.OtterizeServiceNetworkPolicyNameTemplate, policy)
+	default:
+		// Egress policies are created in the client's namespace, and labeled with the client's identity.
+		return listIntentsOfClient(ctx, o.client, policy.GetNamespace(), labelValue)
+	}
+}
+
+func (o *networkPolicyOwners) isGeneratedPolicy(obj client.Object) bool {
+	if _, ok := obj.(*v1.NetworkPolicy); !ok || otterizev1alpha3.IsManuallyOverridden(obj) {
+		return false
+	}
+	_, found := o.getOwningLabel(obj)
+	return found
+}
@@ -0,0 +1,161 @@
+package policy_drift
+
+import (
+	"context"
+	"fmt"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/consts"
+	"github.com/otterize/intents-operator/src/shared/testbase"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"testing"
+	"time"
+)
+
+const (
+	testClientNamespace = "test-client-namespace"
+	testServerNamespace = "test-server-namespace"
+	testServerName      = "test-server"
+)
+
+type NetworkPolicyDriftHandlerTestSuite struct {
+	testbase.MocksSuiteBase
+	queue   workqueue.RateLimitingInterface
+	handler *PolicyDriftHandler
+}
+
+func (s *NetworkPolicyDriftHandlerTestSuite) SetupTest() {
+	s.MocksSuiteBase.SetupTest()
+	s.queue = workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	s.handler = NewNetworkPolicyDriftHandler(s.Client, []string{otterizev1alpha3.OtterizeNetworkPolicy})
+	s.handler.Recorder = s.Recorder
+}
+
+func (s *NetworkPolicyDriftHandlerTestSuite) TearDownTest() {
+	s.queue.ShutDown()
+	s.MocksSuiteBase.TearDownTest()
+}
+
+func (s *NetworkPolicyDriftHandlerTestSuite) accessPolicy(generation int64, annotations map[string]string, managers ...metav1.ManagedFieldsEntry) *v1.NetworkPolicy {
+	return &v1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:          fmt.Sprintf(otterizev1alpha3.OtterizeNetworkPolicyNameTemplate, testServerName, testClientNamespace),
+			Namespace:     testServerNamespace,
+			Generation:    generation,
+			Annotations:   annotations,
+			ManagedFields: managers,
+			Labels: map[string]string{
+				otterizev1alpha3.OtterizeNetworkPolicy: otterizev1alpha3.GetFormattedOtterizeIdentity(testServerName, testServerNamespace),
+			},
+		},
+	}
+}
+
+func managedFieldsEntry(manager string, seconds int64) metav1.ManagedFieldsEntry {
+	return metav1.ManagedFieldsEntry{
+		Manager:   manager,
+		Operation: metav1.ManagedFieldsOperationUpdate,
+		Time:      &metav1.Time{Time: time.Unix(seconds, 0)},
+	}
+}
+
+func (s *NetworkPolicyDriftHandlerTestSuite) expectListOwningIntents() {
+	clientIntents := otterizev1alpha3.ClientIntents{
+		ObjectMeta: metav1.ObjectMeta{Name: "client-intents", Namespace: testClientNamespace},
+		Spec: &otterizev1alpha3.IntentsSpec{
+			Service: otterizev1alpha3.Service{Name: "test-client"},
+			Calls:   []otterizev1alpha3.Intent{{Name: fmt.Sprintf("%s.%s", testServerName, testServerNamespace)}},
+		},
+	}
+	unrelatedIntents := otterizev1alpha3.ClientIntents{
+		ObjectMeta: metav1.ObjectMeta{Name: "other-intents", Namespace: "other-namespace"},
+		Spec: &otterizev1alpha3.IntentsSpec{
+			Service: otterizev1alpha3.Service{Name: "other-client"},
+			Calls:   []otterizev1alpha3.Intent{{Name: fmt.Sprintf("%s.%s", testServerName, testServerNamespace)}},
+		},
+	}
+
+	s.Client.EXPECT().List(
+		gomock.Any(),
+		gomock.AssignableToTypeOf(&otterizev1alpha3.ClientIntentsList{}),
+		&client.MatchingFields{otterizev1alpha3.OtterizeFormattedTargetServerIndexField: otterizev1alpha3.GetFormattedOtterizeIdentity(testServerName, testServerNamespace)},
+	).DoAndReturn(func(_ context.Context, list *otterizev1alpha3.ClientIntentsList, _ ...client.ListOption) error {
+		list.Items = []otterizev1alpha3.ClientIntents{clientIntents, unrelatedIntents}
+		return nil
+	})
+}
+
+func (s *NetworkPolicyDriftHandlerTestSuite) requireOwnerEnqueued() {
+	s.Require().Equal(1, s.queue.Len())
+	item, _ := s.queue.Get()
+	s.Require().Equal(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: testClientNamespace, Name: "client-intents"}}, item)
+}
+
+func (s *NetworkPolicyDriftHandlerTestSuite) TestModifiedPolicyOwnerIsEnqueuedAndReported() {
+	s.expectListOwningIntents()
+
+	s.handler.Update(event.UpdateEvent{
+		ObjectOld: s.accessPolicy(2, nil, managedFieldsEntry(operatorFieldManager, 1)),
+		ObjectNew: s.accessPolicy(3, nil, managedFieldsEntry(operatorFieldManager, 1), managedFieldsEntry("kubectl-edit", 2)),
+	}, s.queue)
+
+	s.requireOwnerEnqueued()
+	s.ExpectEvent(consts.ReasonNetworkPolicyDriftReverted)
+}
+
+func (s *NetworkPolicyDriftHandlerTestSuite) TestPolicyModifiedByOperatorIsIgnored() {
+	s.handler.Update(event.UpdateEvent{
+		ObjectOld: s.accessPolicy(2, nil, managedFieldsEntry(operatorFieldManager, 1)),
+		ObjectNew: s.accessPolicy(3, nil, managedFieldsEntry(operatorFieldManager, 2)),
+	}, s.queue)
+
+	s.Require().Zero(s.queue.Len())
+}
+
+func (s *NetworkPolicyDriftHandlerTestSuite) TestMetadataChangeIsIgnored() {
+	s.handler.Update(event.UpdateEvent{
+		ObjectOld: s.accessPolicy(2, nil),
+		ObjectNew: s.accessPolicy(2, nil, managedFieldsEntry("kubectl-edit", 2)),
+	}, s.queue)
+
+	s.Require().Zero(s.queue.Len())
+}
+
+func (s *NetworkPolicyDriftHandlerTestSuite) TestManuallyOverriddenPolicyIsIgnored() {
+	annotations := map[string]string{otterizev1alpha3.OtterizeManualOverrideAnnotation: "true"}
+	s.handler.Update(event.UpdateEvent{
+		ObjectOld: s.accessPolicy(2, annotations),
+		ObjectNew: s.accessPolicy(3, annotations),
+	}, s.queue)
+	s.handler.Delete(event.DeleteEvent{Object: s.accessPolicy(3, annotations)}, s.queue)
+
+	s.Require().Zero(s.queue.Len())
+}
+
+func (s *NetworkPolicyDriftHandlerTestSuite) TestDeletedPolicyOwnerIsEnqueued() {
+	s.expectListOwningIntents()
+
+	s.handler.Delete(event.DeleteEvent{Object: s.accessPolicy(2, nil)}, s.queue)
+
+	s.requireOwnerEnqueued()
+}
+
+func (s *NetworkPolicyDriftHandlerTestSuite) TestPolicyOfDisabledReconcilerIsIgnored() {
+	policy := s.accessPolicy(2, nil)
+	policy.Labels = map[string]string{otterizev1alpha3.OtterizeEgressNetworkPolicy: "test-client-test-client-namespace"}
+
+	s.handler.Delete(event.DeleteEvent{Object: policy}, s.queue)
+
+	s.Require().Zero(s.queue.Len())
+}
+
+func TestNetworkPolicyDriftHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(NetworkPolicyDriftHandlerTestSuite))
+}
@@ -0,0 +1,58 @@
+package policy_drift
+
+import (
+	"context"
+	"fmt"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// listIntentsCallingServer returns the ClientIntents that generate the server-side policy: those that call the server
+// and whose policy name, formatted from nameTemplate, matches the policy.
+func listIntentsCallingServer(
+	ctx context.Context,
+	k8sClient client.Client,
+	formattedTargetServer string,
+	nameTemplate string,
+	policy client.Object,
+) ([]otterizev1alpha3.ClientIntents, error) {
+	var intentsList otterizev1alpha3.ClientIntentsList
+	err := k8sClient.List(
+		ctx,
+		&intentsList,
+		&client.MatchingFields{otterizev1alpha3.OtterizeFormattedTargetServerIndexField: formattedTargetServer},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Filter(intentsList.Items, func(intents otterizev1alpha3.ClientIntents, _ int) bool {
+		return lo.ContainsBy(intents.GetCallsList(), func(intent otterizev1alpha3.Intent) bool {
+			policyName := fmt.Sprintf(nameTemplate, intent.GetTargetServerName(), intents.Namespace)
+			return policyName == policy.GetName() && intent.GetTargetServerNamespace(intents.Namespace) == policy.GetNamespace()
+		})
+	}), nil
+}
+
+// listIntentsOfClient returns the ClientIntents of the client identified by formattedClient. An empty namespace
+// searches all namespaces.
+func listIntentsOfClient(
+	ctx context.Context,
+	k8sClient client.Client,
+	namespace string,
+	formattedClient string,
+) ([]otterizev1alpha3.ClientIntents, error) {
+	var intentsList otterizev1alpha3.ClientIntentsList
+	err := k8sClient.List(ctx, &intentsList, &client.ListOptions{Namespace: namespace})
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Filter(intentsList.Items, func(intents otterizev1alpha3.ClientIntents, _ int) bool {
+		if intents.Spec == nil {
+			return false
+		}
+		return otterizev1alpha3.GetFormattedOtterizeIdentity(intents.GetServiceName(), intents.Namespace) == formattedClient
+	}), nil
+}
@@ -0,0 +1,3 @@
+package postgres
+
+//go:generate go run go.uber.org/mock/mockgen@v0.2.0 -destination=./mocks/mock_postgres_admin.go -package=postgresmocks -source=postgres_admin.go PostgresAdmin
@@ -0,0 +1,77 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: postgres_admin.go
+
+// Package postgresmocks is a generated GoMock package.
+package postgresmocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	v1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockPostgresAdmin is a mock of PostgresAdmin interface.
+type MockPostgresAdmin struct {
+	ctrl     *gomock.Controller
+	recorder *MockPostgresAdminMockRecorder
+}
+
+// MockPostgresAdminMockRecorder is the mock recorder for MockPostgresAdmin.
+type MockPostgresAdminMockRecorder struct {
+	mock *MockPostgresAdmin
+}
+
+// NewMockPostgresAdmin creates a new mock instance.
+func NewMockPostgresAdmin(ctrl *gomock.Controller) *MockPostgresAdmin {
+	mock := &MockPostgresAdmin{ctrl: ctrl}
+	mock.recorder = &MockPostgresAdminMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPostgresAdmin) EXPECT() *MockPostgresAdminMockRecorder {
+	return m.recorder
+}
+
+// ApplyClientIntents mocks base method.
+func (m *MockPostgresAdmin) ApplyClientIntents(ctx context.Context, clientName, clientNamespace string, intents []v1alpha3.Intent) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyClientIntents", ctx, clientName, clientNamespace, intents)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyClientIntents indicates an expected call of ApplyClientIntents.
+func (mr *MockPostgresAdminMockRecorder) ApplyClientIntents(ctx, clientName, clientNamespace, intents interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyClientIntents", reflect.TypeOf((*MockPostgresAdmin)(nil).ApplyClientIntents), ctx, clientName, clientNamespace, intents)
+}
+
+// Close mocks base method.
+func (m *MockPostgresAdmin) Close(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Close", ctx)
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockPostgresAdminMockRecorder) Close(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockPostgresAdmin)(nil).Close), ctx)
+}
+
+// RemoveClientIntents mocks base method.
+func (m *MockPostgresAdmin) RemoveClientIntents(ctx context.Context, clientName, clientNamespace string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveClientIntents", ctx, clientName, clientNamespace)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveClientIntents indicates an expected call of RemoveClientIntents.
+func (mr *MockPostgresAdminMockRecorder) RemoveClientIntents(ctx, clientName, clientNamespace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveClientIntents", reflect.TypeOf((*MockPostgresAdmin)(nil).RemoveClientIntents), ctx, clientName, clientNamespace)
+}
@@ -0,0 +1,361 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"net/url"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultSchema = "public"
+	// sqlStateDependentObjectsStillExist is returned when dropping a role that still has privileges, such as those
+	// granted in other databases of the server.
+	sqlStateDependentObjectsStillExist = "2BP01"
+)
+
+// allTablePrivileges are the table privileges granted by GRANT ALL PRIVILEGES.
+var allTablePrivileges = sets.New("SELECT", "INSERT", "UPDATE", "DELETE", "TRUNCATE", "REFERENCES", "TRIGGER")
+
+// PostgresAdmin applies database intents on a PostgreSQL server, as table privileges granted to a role per client.
+type PostgresAdmin interface {
+	// ApplyClientIntents creates the client's role if it doesn't exist, and grants it the operations of the intents'
+	// database resources, revoking any other privileges it has on the database's tables. Returns the tables of the
+	// intents that don't exist, which are not granted.
+	ApplyClientIntents(ctx context.Context, clientName string, clientNamespace string, intents []otterizev1alpha3.Intent) ([]string, error)
+	// RemoveClientIntents revokes all privileges of the client's role on the database's tables, and drops it.
+	RemoveClientIntents(ctx context.Context, clientName string, clientNamespace string) error
+	Close(ctx context.Context)
+}
+
+type AdminFactoryFunction func(ctx context.Context, config otterizev1alpha3.DatabaseServerConfig) (PostgresAdmin, error)
+
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+// NewPostgresAdminFactory returns a factory connecting to the server of a DatabaseServerConfig with the admin
+// credentials in its secret.
+func NewPostgresAdminFactory(secretsReader client.Reader) AdminFactoryFunction {
+	return func(ctx context.Context, config otterizev1alpha3.DatabaseServerConfig) (PostgresAdmin, error) {
+		secret := corev1.Secret{}
+		err := secretsReader.Get(ctx, types.NamespacedName{Name: config.Spec.CredentialsSecretName, Namespace: config.Namespace}, &secret)
+		if err != nil {
+			return nil, fmt.Errorf("failed getting database credentials secret %s: %w", config.Spec.CredentialsSecretName, err)
+		}
+
+		username, ok := secret.Data[otterizev1alpha3.DatabaseCredentialsUsernameKey]
+		if !ok {
+			return nil, fmt.Errorf("key %s not found in database credentials secret %s", otterizev1alpha3.DatabaseCredentialsUsernameKey, secret.Name)
+		}
+		password, ok := secret.Data[otterizev1alpha3.DatabaseCredentialsPasswordKey]
+		if !ok {
+			return nil, fmt.Errorf("key %s not found in database credentials secret %s", otterizev1alpha3.DatabaseCredentialsPasswordKey, secret.Name)
+		}
+
+		return NewPostgresAdmin(ctx, config, string(username), string(password))
+	}
+}
+
+type PostgresAdminImpl struct {
+	config otterizev1alpha3.DatabaseServerConfig
+	conn   *pgx.Conn
+}
+
+func NewPostgresAdmin(ctx context.Context, config otterizev1alpha3.DatabaseServerConfig, username string, password string) (*PostgresAdminImpl, error) {
+	connectionURL := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(username, password),
+		Host:     config.Spec.Address,
+		Path:     "/" + config.Spec.Database,
+		RawQuery: url.Values{"sslmode": []string{lo.Ternary(config.Spec.SSLMode != "", config.Spec.SSLMode, "prefer")}}.Encode(),
+	}
+	conn, err := pgx.Connect(ctx, connectionURL.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed connecting to database %s at %s: %w", config.Spec.Database, config.Spec.Address, err)
+	}
+
+	return &PostgresAdminImpl{config: config, conn: conn}, nil
+}
+
+// RoleName returns the name of the client's role, which is within the maximum length of PostgreSQL identifiers.
+func RoleName(clientName string, clientNamespace string) string {
+	return otterizev1alpha3.GetFormattedOtterizeIdentity(clientName, clientNamespace)
+}
+
+type tableName struct {
+	schema string
+	name   string
+}
+
+func parseTableName(table string) tableName {
+	if schema, name, ok := strings.Cut(table, "."); ok {
+		return tableName{schema: schema, name: name}
+	}
+	return tableName{schema: defaultSchema, name: table}
+}
+
+func (t tableName) String() string {
+	return fmt.Sprintf("%s.%s", t.schema, t.name)
+}
+
+func (t tableName) sanitize() string {
+	return pgx.Identifier{t.schema, t.name}.Sanitize()
+}
+
+func (a *PostgresAdminImpl) ApplyClientIntents(ctx context.Context, clientName string, clientNamespace string, intents []otterizev1alpha3.Intent) ([]string, error) {
+	role := RoleName(clientName, clientNamespace)
+	logger := logrus.WithFields(logrus.Fields{"database": a.config.Spec.Database, "role": role})
+
+	tx, err := a.conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	exists, err := roleExists(ctx, tx, role)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		logger.Info("Creating role")
+		if _, err := tx.Exec(ctx, fmt.Sprintf("CREATE ROLE %s WITH LOGIN", pgx.Identifier{role}.Sanitize())); err != nil {
+			return nil, fmt.Errorf("failed creating role %s: %w", role, err)
+		}
+	}
+
+	desiredPrivileges, missingTables, err := getDesiredPrivileges(ctx, tx, intents)
+	if err != nil {
+		return nil, err
+	}
+	currentPrivileges, err := getTablePrivileges(ctx, tx, role)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := lo.Uniq(append(lo.Keys(desiredPrivileges), lo.Keys(currentPrivileges)...))
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].String() < tables[j].String()
+	})
+	for _, table := range tables {
+		desired, current := desiredPrivileges[table], currentPrivileges[table]
+		if desired.Equal(current) {
+			continue
+		}
+		logger.Infof("Updating privileges on table %s from %v to %v", table, sets.List(current), sets.List(desired))
+		if err := revokeTablePrivileges(ctx, tx, table, role); err != nil {
+			return nil, err
+		}
+		if desired.Len() == 0 {
+			continue
+		}
+		privileges := lo.Ternary(desired.Equal(allTablePrivileges), "ALL PRIVILEGES", strings.Join(sets.List(desired), ", "))
+		if _, err := tx.Exec(ctx, fmt.Sprintf("GRANT %s ON TABLE %s TO %s", privileges, table.sanitize(), pgx.Identifier{role}.Sanitize())); err != nil {
+			return nil, fmt.Errorf("failed granting %s on table %s: %w", privileges, table, err)
+		}
+	}
+
+	desiredSchemas := sets.New(lo.Map(lo.Keys(desiredPrivileges), func(table tableName, _ int) string {
+		return table.schema
+	})...)
+	if err := a.updateSchemaUsage(ctx, tx, role, desiredSchemas); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return missingTables, nil
+}
+
+// updateSchemaUsage grants the role usage of the schemas of its tables, which is required to access them, and revokes
+// it on other schemas.
+func (a *PostgresAdminImpl) updateSchemaUsage(ctx context.Context, tx pgx.Tx, role string, desiredSchemas sets.Set[string]) error {
+	currentSchemas, err := getSchemaUsage(ctx, tx, role)
+	if err != nil {
+		return err
+	}
+
+	for _, schema := range sets.List(desiredSchemas.Difference(currentSchemas)) {
+		if _, err := tx.Exec(ctx, fmt.Sprintf("GRANT USAGE ON SCHEMA %s TO %s", pgx.Identifier{schema}.Sanitize(), pgx.Identifier{role}.Sanitize())); err != nil {
+			return fmt.Errorf("failed granting usage on schema %s: %w", schema, err)
+		}
+	}
+	for _, schema := range sets.List(currentSchemas.Difference(desiredSchemas)) {
+		if _, err := tx.Exec(ctx, fmt.Sprintf("REVOKE USAGE ON SCHEMA %s FROM %s", pgx.Identifier{schema}.Sanitize(), pgx.Identifier{role}.Sanitize())); err != nil {
+			return fmt.Errorf("failed revoking usage on schema %s: %w", schema, err)
+		}
+	}
+	return nil
+}
+
+func (a *PostgresAdminImpl) RemoveClientIntents(ctx context.Context, clientName string, clientNamespace string) error {
+	role := RoleName(clientName, clientNamespace)
+	logger := logrus.WithFields(logrus.Fields{"database": a.config.Spec.Database, "role": role})
+
+	tx, err := a.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	exists, err := roleExists(ctx, tx, role)
+	if err != nil || !exists {
+		return err
+	}
+
+	currentPrivileges, err := getTablePrivileges(ctx, tx, role)
+	if err != nil {
+		return err
+	}
+	for table := range currentPrivileges {
+		if err := revokeTablePrivileges(ctx, tx, table, role); err != nil {
+			return err
+		}
+	}
+	if err := a.updateSchemaUsage(ctx, tx, role, sets.New[string]()); err != nil {
+		return err
+	}
+
+	// Roles are shared by the server's databases, so the role is kept while it has privileges in other databases.
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = savepoint.Exec(ctx, fmt.Sprintf("DROP ROLE %s", pgx.Identifier{role}.Sanitize()))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == sqlStateDependentObjectsStillExist {
+		logger.WithError(err).Info("Not dropping role, which has privileges in other databases")
+		if err := savepoint.Rollback(ctx); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed dropping role %s: %w", role, err)
+	} else {
+		logger.Info("Dropped role")
+		if err := savepoint.Commit(ctx); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (a *PostgresAdminImpl) Close(ctx context.Context) {
+	if err := a.conn.Close(ctx); err != nil {
+		logrus.WithError(err).Error("Error closing database connection")
+	}
+}
+
+func roleExists(ctx context.Context, tx pgx.Tx, role string) (bool, error) {
+	var exists bool
+	err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = $1)", role).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed checking whether role %s exists: %w", role, err)
+	}
+	return exists, nil
+}
+
+// getDesiredPrivileges returns the table privileges of the intents' database resources, and the tables that don't exist.
+func getDesiredPrivileges(ctx context.Context, tx pgx.Tx, intents []otterizev1alpha3.Intent) (map[tableName]sets.Set[string], []string, error) {
+	desiredPrivileges := make(map[tableName]sets.Set[string])
+	missingTables := make([]string, 0)
+	for _, intent := range intents {
+		for _, resource := range intent.DatabaseResources {
+			table := parseTableName(resource.Table)
+			var exists bool
+			if err := tx.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", table.sanitize()).Scan(&exists); err != nil {
+				return nil, nil, fmt.Errorf("failed checking whether table %s exists: %w", table, err)
+			}
+			if !exists {
+				missingTables = append(missingTables, resource.Table)
+				continue
+			}
+
+			if _, ok := desiredPrivileges[table]; !ok {
+				desiredPrivileges[table] = sets.New[string]()
+			}
+			for _, operation := range resource.Operations {
+				if operation == otterizev1alpha3.DatabaseOperationAll {
+					desiredPrivileges[table] = desiredPrivileges[table].Union(allTablePrivileges)
+					continue
+				}
+				desiredPrivileges[table].Insert(string(operation))
+			}
+		}
+	}
+	return desiredPrivileges, lo.Uniq(missingTables), nil
+}
+
+// getTablePrivileges returns the privileges granted to the role on the database's tables, by any grantor.
+func getTablePrivileges(ctx context.Context, tx pgx.Tx, role string) (map[tableName]sets.Set[string], error) {
+	rows, err := tx.Query(ctx, `
+		SELECT n.nspname, c.relname, acl.privilege_type
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		CROSS JOIN LATERAL aclexplode(c.relacl) acl
+		JOIN pg_roles r ON r.oid = acl.grantee
+		WHERE r.rolname = $1 AND c.relkind IN ('r', 'p', 'v', 'm', 'f')`, role)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing table privileges of role %s: %w", role, err)
+	}
+	defer rows.Close()
+
+	privileges := make(map[tableName]sets.Set[string])
+	for rows.Next() {
+		var table tableName
+		var privilege string
+		if err := rows.Scan(&table.schema, &table.name, &privilege); err != nil {
+			return nil, err
+		}
+		if _, ok := privileges[table]; !ok {
+			privileges[table] = sets.New[string]()
+		}
+		// Privileges not granted by the operator, such as MAINTAIN, are revoked along with the others once the
+		// table's privileges change.
+		if allTablePrivileges.Has(privilege) {
+			privileges[table].Insert(privilege)
+		}
+	}
+	return privileges, rows.Err()
+}
+
+// getSchemaUsage returns the schemas the role has been granted usage of.
+func getSchemaUsage(ctx context.Context, tx pgx.Tx, role string) (sets.Set[string], error) {
+	rows, err := tx.Query(ctx, `
+		SELECT n.nspname
+		FROM pg_namespace n
+		CROSS JOIN LATERAL aclexplode(n.nspacl) acl
+		JOIN pg_roles r ON r.oid = acl.grantee
+		WHERE r.rolname = $1 AND acl.privilege_type = 'USAGE'`, role)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing schema usage of role %s: %w", role, err)
+	}
+	defer rows.Close()
+
+	schemas := sets.New[string]()
+	for rows.Next() {
+		var schema string
+		if err := rows.Scan(&schema); err != nil {
+			return nil, err
+		}
+		schemas.Insert(schema)
+	}
+	return schemas, rows.Err()
+}
+
+func revokeTablePrivileges(ctx context.Context, tx pgx.Tx, table tableName, role string) error {
+	if _, err := tx.Exec(ctx, fmt.Sprintf("REVOKE ALL PRIVILEGES ON TABLE %s FROM %s", table.sanitize(), pgx.Identifier{role}.Sanitize())); err != nil {
+		return fmt.Errorf("failed revoking privileges on table %s: %w", table, err)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/always_allowed"
 	"github.com/otterize/intents-operator/src/shared/injectablerecorder"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/networking/v1"
@@ -19,6 +20,7 @@ type DefaultDenyReconciler struct {
 	extNetpolHandler ExternalNepolHandler
 	injectablerecorder.InjectableRecorder
 	netpolEnforcementEnabled bool
+	alwaysAllowed            always_allowed.Config
 }
 
 type ExternalNepolHandler interface {
@@ -34,6 +36,12 @@ func NewDefaultDenyReconciler(client client.Client, extNetpolHandler ExternalNep
 	}
 }
 
+// SetAlwaysAllowedConfig sets traffic that is allowed into protected servers regardless of intents, such as
+// monitoring. It is added to each default deny policy.
+func (r *DefaultDenyReconciler) SetAlwaysAllowedConfig(config always_allowed.Config) {
+	r.alwaysAllowed = config
+}
+
 func (r *DefaultDenyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	err := r.handleDefaultDenyInNamespace(ctx, req)
 	if client.IgnoreNotFound(err) != nil {
@@ -68,6 +76,10 @@ func (r *DefaultDenyReconciler) blockAccessToServices(ctx context.Context, prote
 
 		formattedServerName := otterizev1alpha3.GetFormattedOtterizeIdentity(protectedService.Spec.Name, namespace)
 		policy := r.buildNetworkPolicyObjectForIntent(formattedServerName, protectedService.Spec.Name, namespace)
+		err := r.alwaysAllowed.AddToPolicy(ctx, r.Client, &policy)
+		if err != nil {
+			return err
+		}
 		if r.netpolEnforcementEnabled {
 			serversToProtect[formattedServerName] = policy
 		}
@@ -121,6 +133,15 @@ func (r *DefaultDenyReconciler) updateIfNeeded(
 
 	existingPolicy.Spec = newPolicy.Spec
 	existingPolicy.Labels = newPolicy.Labels
+	alwaysAllowedRules, ok := newPolicy.Annotations[otterizev1alpha3.OtterizeAlwaysAllowedRulesAnnotation]
+	if ok {
+		if existingPolicy.Annotations == nil {
+			existingPolicy.Annotations = make(map[string]string)
+		}
+		existingPolicy.Annotations[otterizev1alpha3.OtterizeAlwaysAllowedRulesAnnotation] = alwaysAllowedRules
+	} else {
+		delete(existingPolicy.Annotations, otterizev1alpha3.OtterizeAlwaysAllowedRulesAnnotation)
+	}
 
 	err := r.Update(context.Background(), &existingPolicy)
 	if err != nil {
@@ -0,0 +1,212 @@
+package protected_service_reconcilers
+
+import (
+	"context"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/always_allowed"
+	"github.com/otterize/intents-operator/src/shared/injectablerecorder"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"reflect"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	ReasonInvalidNamespaceDefaultDeny = "InvalidNamespaceDefaultDeny"
+	ReasonNamespaceDefaultDenyApplied = "NamespaceDefaultDenyApplied"
+	ReasonEgressDefaultDenyDisabled   = "EgressDefaultDenyDisabled"
+)
+
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups="networking.k8s.io",resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+
+// NamespaceDefaultDenyReconciler creates a single deny-all network policy, selecting every pod, in namespaces annotated
+// with intents.otterize.com/default-deny. Unlike the per-service policies created for ProtectedServices, this also
+// covers pods the operator never labeled. Network policies generated from intents are additive, so they still allow
+// the declared traffic. Egress is only denied when the egress network policy reconcilers are enabled, since otherwise
+// nothing allows the egress traffic declared by intents.
+type NamespaceDefaultDenyReconciler struct {
+	client.Client
+	injectablerecorder.InjectableRecorder
+	restrictToNamespaces []string
+	alwaysAllowed        always_allowed.Config
+	enableEgress         bool
+}
+
+func NewNamespaceDefaultDenyReconciler(client client.Client, restrictToNamespaces []string, enableEgress bool) *NamespaceDefaultDenyReconciler {
+	return &NamespaceDefaultDenyReconciler{
+		Client:               client,
+		restrictToNamespaces: restrictToNamespaces,
+		enableEgress:         enableEgress,
+	}
+}
+
+// SetAlwaysAllowedConfig sets traffic that is allowed into the namespace regardless of intents, such as monitoring.
+func (r *NamespaceDefaultDenyReconciler) SetAlwaysAllowedConfig(config always_allowed.Config) {
+	r.alwaysAllowed = config
+}
+
+func (r *NamespaceDefaultDenyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	if len(r.restrictToNamespaces) != 0 && !lo.Contains(r.restrictToNamespaces, req.Name) {
+		return ctrl.Result{}, nil
+	}
+
+	namespace := &corev1.Namespace{}
+	err := r.Get(ctx, types.NamespacedName{Name: req.Name}, namespace)
+	if k8serrors.IsNotFound(err) {
+		// Network policies are deleted along with their namespace.
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !namespace.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	policyTypes, err := otterizev1alpha3.GetNamespaceDefaultDenyPolicyTypes(namespace)
+	if err != nil {
+		// Keep any existing policy in place, so that a typo does not open up the namespace.
+		r.RecordWarningEventf(namespace, ReasonInvalidNamespaceDefaultDeny, "Namespace default deny was not updated: %s", err.Error())
+		return ctrl.Result{}, nil
+	}
+
+	if !r.enableEgress && lo.Contains(policyTypes, v1.PolicyTypeEgress) {
+		r.RecordWarningEvent(namespace, ReasonEgressDefaultDenyDisabled, "Namespace default deny not applied to egress traffic, as egress network policies are disabled and would not allow the egress traffic of intents")
+		policyTypes = lo.Without(policyTypes, v1.PolicyTypeEgress)
+	}
+
+	existingPolicy := &v1.NetworkPolicy{}
+	err = r.Get(ctx, types.NamespacedName{Namespace: namespace.Name, Name: otterizev1alpha3.OtterizeNamespaceDefaultDenyNetworkPolicyName}, existingPolicy)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	policyExists := err == nil
+
+	if len(policyTypes) == 0 {
+		if policyExists {
+			return ctrl.Result{}, r.deletePolicy(ctx, existingPolicy)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	desiredPolicy := r.buildNamespaceDefaultDenyPolicy(namespace.Name, policyTypes)
+	if lo.Contains(policyTypes, v1.PolicyTypeIngress) {
+		err = r.alwaysAllowed.AddToPolicy(ctx, r.Client, desiredPolicy)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	if !policyExists {
+		err = r.Create(ctx, desiredPolicy)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		logrus.Infof("Created network policy %s/%s", desiredPolicy.Namespace, desiredPolicy.Name)
+		r.RecordNormalEventf(namespace, ReasonNamespaceDefaultDenyApplied, "Namespace default deny applied to %v traffic", policyTypes)
+		return ctrl.Result{}, nil
+	}
+
+	if reflect.DeepEqual(existingPolicy.Spec, desiredPolicy.Spec) && reflect.DeepEqual(existingPolicy.Labels, desiredPolicy.Labels) {
+		return ctrl.Result{}, nil
+	}
+
+	policyCopy := existingPolicy.DeepCopy()
+	policyCopy.Spec = desiredPolicy.Spec
+	policyCopy.Labels = desiredPolicy.Labels
+	policyCopy.Annotations = desiredPolicy.Annotations
+	err = r.Patch(ctx, policyCopy, client.MergeFrom(existingPolicy))
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	logrus.Infof("Updated network policy %s/%s", policyCopy.Namespace, policyCopy.Name)
+	r.RecordNormalEventf(namespace, ReasonNamespaceDefaultDenyApplied, "Namespace default deny applied to %v traffic", policyTypes)
+
+	return ctrl.Result{}, nil
+}
+
+func (r *NamespaceDefaultDenyReconciler) deletePolicy(ctx context.Context, policy *v1.NetworkPolicy) error {
+	err := r.Delete(ctx, policy)
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	logrus.Infof("Deleted network policy %s/%s", policy.Namespace, policy.Name)
+	return nil
+}
+
+func (r *NamespaceDefaultDenyReconciler) buildNamespaceDefaultDenyPolicy(namespace string, policyTypes []v1.PolicyType) *v1.NetworkPolicy {
+	policy := &v1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      otterizev1alpha3.OtterizeNamespaceDefaultDenyNetworkPolicyName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				otterizev1alpha3.OtterizeNetworkPolicyNamespaceDefaultDeny: "true",
+			},
+		},
+		Spec: v1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{},
+			PolicyTypes: policyTypes,
+		},
+	}
+
+	// Ingress is left nil rather than empty when denying all ingress, as the API server returns an empty list as nil,
+	// and the policy would otherwise never be equal to the existing one and be patched on every reconcile.
+	if lo.Contains(policyTypes, v1.PolicyTypeEgress) {
+		// Name resolution stays allowed, as without it pods cannot reach even the servers their intents allow.
+		dnsPort := intstr.FromInt(53)
+		policy.Spec.Egress = []v1.NetworkPolicyEgressRule{
+			{
+				Ports: []v1.NetworkPolicyPort{
+					{Protocol: lo.ToPtr(corev1.ProtocolUDP), Port: &dnsPort},
+					{Protocol: lo.ToPtr(corev1.ProtocolTCP), Port: &dnsPort},
+				},
+			},
+		}
+	}
+
+	return policy
+}
+
+func (r *NamespaceDefaultDenyReconciler) mapPolicyToNamespace(obj client.Object) []reconcile.Request {
+	if _, ok := obj.GetLabels()[otterizev1alpha3.OtterizeNetworkPolicyNamespaceDefaultDeny]; !ok {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: obj.GetNamespace()}}}
+}
+
+func (r *NamespaceDefaultDenyReconciler) mapPodToNamespace(obj client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: obj.GetNamespace()}}}
+}
+
+func (r *NamespaceDefaultDenyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.InjectRecorder(mgr.GetEventRecorderFor("intents-operator"))
+
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
+		Named("namespace-default-deny").
+		For(&corev1.Namespace{}).
+		Watches(&source.Kind{Type: &v1.NetworkPolicy{}}, handler.EnqueueRequestsFromMapFunc(r.mapPolicyToNamespace)).
+		WithOptions(controller.Options{RecoverPanic: lo.ToPtr(true)})
+
+	if r.alwaysAllowed.HasPortAnnotations() {
+		controllerBuilder = controllerBuilder.Watches(
+			&source.Kind{Type: &corev1.Pod{}},
+			handler.EnqueueRequestsFromMapFunc(r.mapPodToNamespace),
+			builder.WithPredicates(r.alwaysAllowed.PortAnnotationsChangedPredicate()),
+		)
+	}
+
+	return controllerBuilder.Complete(r)
+}
@@ -0,0 +1,202 @@
+package protected_service_reconcilers
+
+import (
+	"context"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/always_allowed"
+	"github.com/otterize/intents-operator/src/shared/testbase"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"testing"
+)
+
+type NamespaceDefaultDenyReconcilerTestSuite struct {
+	testbase.MocksSuiteBase
+	reconciler *NamespaceDefaultDenyReconciler
+}
+
+func (s *NamespaceDefaultDenyReconcilerTestSuite) SetupTest() {
+	s.MocksSuiteBase.SetupTest()
+	s.reconciler = NewNamespaceDefaultDenyReconciler(s.Client, nil, true)
+	s.reconciler.Recorder = s.Recorder
+}
+
+func (s *NamespaceDefaultDenyReconcilerTestSuite) expectGetNamespace(defaultDenyAnnotation string) {
+	namespace := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}}
+	if defaultDenyAnnotation != "" {
+		namespace.Annotations = map[string]string{otterizev1alpha3.OtterizeNamespaceDefaultDenyAnnotation: defaultDenyAnnotation}
+	}
+
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Name: testNamespace}, gomock.AssignableToTypeOf(&corev1.Namespace{})).DoAndReturn(
+		func(_ context.Context, _ types.NamespacedName, ns *corev1.Namespace, _ ...client.GetOption) error {
+			namespace.DeepCopyInto(ns)
+			return nil
+		})
+}
+
+func (s *NamespaceDefaultDenyReconcilerTestSuite) expectGetPolicy(existingPolicy *v1.NetworkPolicy) {
+	key := types.NamespacedName{Namespace: testNamespace, Name: otterizev1alpha3.OtterizeNamespaceDefaultDenyNetworkPolicyName}
+	s.Client.EXPECT().Get(gomock.Any(), key, gomock.AssignableToTypeOf(&v1.NetworkPolicy{})).DoAndReturn(
+		func(_ context.Context, _ types.NamespacedName, policy *v1.NetworkPolicy, _ ...client.GetOption) error {
+			if existingPolicy == nil {
+				return k8serrors.NewNotFound(schema.GroupResource{}, key.Name)
+			}
+			existingPolicy.DeepCopyInto(policy)
+			return nil
+		})
+}
+
+func (s *NamespaceDefaultDenyReconcilerTestSuite) reconcile() {
+	res, err := s.reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: testNamespace}})
+	s.Require().NoError(err)
+	s.Require().True(res.IsZero())
+}
+
+func (s *NamespaceDefaultDenyReconcilerTestSuite) TestIngressDefaultDenyCreated() {
+	s.expectGetNamespace("true")
+	s.expectGetPolicy(nil)
+
+	expectedPolicy := &v1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      otterizev1alpha3.OtterizeNamespaceDefaultDenyNetworkPolicyName,
+			Namespace: testNamespace,
+			Labels: map[string]string{
+				otterizev1alpha3.OtterizeNetworkPolicyNamespaceDefaultDeny: "true",
+			},
+		},
+		Spec: v1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{},
+			PolicyTypes: []v1.PolicyType{v1.PolicyTypeIngress},
+		},
+	}
+	s.Client.EXPECT().Create(gomock.Any(), gomock.Eq(expectedPolicy)).Return(nil)
+
+	s.reconcile()
+	s.ExpectEvent(ReasonNamespaceDefaultDenyApplied)
+}
+
+func (s *NamespaceDefaultDenyReconcilerTestSuite) TestEgressDefaultDenyAllowsDNS() {
+	s.expectGetNamespace("ingress, egress")
+	s.expectGetPolicy(nil)
+
+	s.Client.EXPECT().Create(gomock.Any(), gomock.AssignableToTypeOf(&v1.NetworkPolicy{})).DoAndReturn(
+		func(_ context.Context, policy *v1.NetworkPolicy, _ ...client.CreateOption) error {
+			s.Require().Equal([]v1.PolicyType{v1.PolicyTypeIngress, v1.PolicyTypeEgress}, policy.Spec.PolicyTypes)
+			s.Require().Empty(policy.Spec.Ingress)
+			s.Require().Len(policy.Spec.Egress, 1)
+			s.Require().Empty(policy.Spec.Egress[0].To)
+			for _, port := range policy.Spec.Egress[0].Ports {
+				s.Require().Equal(53, port.Port.IntValue())
+			}
+			return nil
+		})
+
+	s.reconcile()
+	s.ExpectEvent(ReasonNamespaceDefaultDenyApplied)
+}
+
+func (s *NamespaceDefaultDenyReconcilerTestSuite) TestEgressDefaultDenySkippedWhenEgressPoliciesDisabled() {
+	s.reconciler = NewNamespaceDefaultDenyReconciler(s.Client, nil, false)
+	s.reconciler.Recorder = s.Recorder
+	s.expectGetNamespace("ingress, egress")
+	s.expectGetPolicy(nil)
+
+	s.Client.EXPECT().Create(gomock.Any(), gomock.AssignableToTypeOf(&v1.NetworkPolicy{})).DoAndReturn(
+		func(_ context.Context, policy *v1.NetworkPolicy, _ ...client.CreateOption) error {
+			s.Require().Equal([]v1.PolicyType{v1.PolicyTypeIngress}, policy.Spec.PolicyTypes)
+			s.Require().Empty(policy.Spec.Egress)
+			return nil
+		})
+
+	s.reconcile()
+	s.ExpectEvent(ReasonEgressDefaultDenyDisabled)
+	s.ExpectEvent(ReasonNamespaceDefaultDenyApplied)
+}
+
+func (s *NamespaceDefaultDenyReconcilerTestSuite) TestAlwaysAllowedRulesAdded() {
+	alwaysAllowed, err := always_allowed.ParseConfig("", "kubernetes.io/metadata.name=monitoring", "", "9090", "")
+	s.Require().NoError(err)
+	s.reconciler.SetAlwaysAllowedConfig(alwaysAllowed)
+
+	s.expectGetNamespace("true")
+	s.expectGetPolicy(nil)
+
+	s.Client.EXPECT().Create(gomock.Any(), gomock.AssignableToTypeOf(&v1.NetworkPolicy{})).DoAndReturn(
+		func(_ context.Context, policy *v1.NetworkPolicy, _ ...client.CreateOption) error {
+			s.Require().Equal(alwaysAllowed.IngressRules(nil), policy.Spec.Ingress)
+			s.Require().Equal("1", policy.Annotations[otterizev1alpha3.OtterizeAlwaysAllowedRulesAnnotation])
+			return nil
+		})
+
+	s.reconcile()
+	s.ExpectEvent(ReasonNamespaceDefaultDenyApplied)
+}
+
+func (s *NamespaceDefaultDenyReconcilerTestSuite) TestUnchangedPolicyNotPatched() {
+	// The policy as returned by the API server, which omits empty lists
+	dnsPort := intstr.FromInt(53)
+	existingPolicy := &v1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      otterizev1alpha3.OtterizeNamespaceDefaultDenyNetworkPolicyName,
+			Namespace: testNamespace,
+			Labels: map[string]string{
+				otterizev1alpha3.OtterizeNetworkPolicyNamespaceDefaultDeny: "true",
+			},
+			ResourceVersion: "1",
+		},
+		Spec: v1.NetworkPolicySpec{
+			PolicyTypes: []v1.PolicyType{v1.PolicyTypeIngress, v1.PolicyTypeEgress},
+			Egress: []v1.NetworkPolicyEgressRule{
+				{
+					Ports: []v1.NetworkPolicyPort{
+						{Protocol: lo.ToPtr(corev1.ProtocolUDP), Port: &dnsPort},
+						{Protocol: lo.ToPtr(corev1.ProtocolTCP), Port: &dnsPort},
+					},
+				},
+			},
+		},
+	}
+	s.expectGetNamespace("ingress, egress")
+	s.expectGetPolicy(existingPolicy)
+
+	s.reconcile()
+	s.ExpectNoEvent()
+}
+
+func (s *NamespaceDefaultDenyReconcilerTestSuite) TestPolicyDeletedWhenAnnotationRemoved() {
+	existingPolicy := s.reconciler.buildNamespaceDefaultDenyPolicy(testNamespace, []v1.PolicyType{v1.PolicyTypeIngress})
+	s.expectGetNamespace("")
+	s.expectGetPolicy(existingPolicy)
+	s.Client.EXPECT().Delete(gomock.Any(), gomock.Eq(existingPolicy)).Return(nil)
+
+	s.reconcile()
+	s.ExpectNoEvent()
+}
+
+func (s *NamespaceDefaultDenyReconcilerTestSuite) TestInvalidAnnotationKeepsExistingPolicy() {
+	s.expectGetNamespace("ingres")
+
+	s.reconcile()
+	s.ExpectEvent(ReasonInvalidNamespaceDefaultDeny)
+}
+
+func (s *NamespaceDefaultDenyReconcilerTestSuite) TestUnwatchedNamespaceIgnored() {
+	s.reconciler.restrictToNamespaces = []string{"other-namespace"}
+
+	s.reconcile()
+	s.ExpectNoEvent()
+}
+
+func TestNamespaceDefaultDenyReconcilerTestSuite(t *testing.T) {
+	suite.Run(t, new(NamespaceDefaultDenyReconcilerTestSuite))
+}
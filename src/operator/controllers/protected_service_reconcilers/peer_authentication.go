@@ -0,0 +1,242 @@
+package protected_service_reconcilers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/istiopolicy"
+	"github.com/otterize/intents-operator/src/shared/injectablerecorder"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/exp/slices"
+	v1beta1security "istio.io/api/security/v1beta1"
+	v1beta1type "istio.io/api/type/v1beta1"
+	"istio.io/client-go/pkg/apis/security/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strconv"
+	"strings"
+)
+
+const (
+	ReasonClientsMissingSidecar = "ClientsMissingSidecar"
+)
+
+//+kubebuilder:rbac:groups="security.istio.io",resources=peerauthentications,verbs=get;list;watch;create;update;patch;delete
+
+// PeerAuthenticationReconciler requires mutual TLS for protected services. Istio authorization policies identify
+// clients by their principal, which only exists under mTLS - a server in PERMISSIVE mode would also accept plaintext
+// traffic from clients without a sidecar, bypassing the policies.
+type PeerAuthenticationReconciler struct {
+	client.Client
+	injectablerecorder.InjectableRecorder
+}
+
+func NewPeerAuthenticationReconciler(client client.Client) *PeerAuthenticationReconciler {
+	return &PeerAuthenticationReconciler{
+		Client: client,
+	}
+}
+
+func (r *PeerAuthenticationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	installed, err := istiopolicy.IsIstioPeerAuthenticationsInstalled(ctx, r.Client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !installed {
+		logrus.Debug("Peer authentication CRD is not installed, Istio peer authentication creation skipped")
+		return ctrl.Result{}, nil
+	}
+
+	var protectedServices otterizev1alpha3.ProtectedServiceList
+	err = r.List(ctx, &protectedServices, client.InNamespace(req.Namespace))
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	activeProtectedServices := lo.Filter(protectedServices.Items, func(protectedService otterizev1alpha3.ProtectedService, _ int) bool {
+		return protectedService.DeletionTimestamp == nil
+	})
+
+	err = r.applyPeerAuthentications(ctx, activeProtectedServices, req.Namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	for i := range activeProtectedServices {
+		err = r.updateClientsWithoutSidecar(ctx, &activeProtectedServices[i])
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func (r *PeerAuthenticationReconciler) applyPeerAuthentications(ctx context.Context, protectedServices []otterizev1alpha3.ProtectedService, namespace string) error {
+	serversToProtect := make(map[string]*v1beta1.PeerAuthentication)
+	for _, protectedService := range protectedServices {
+		formattedServerName := otterizev1alpha3.GetFormattedOtterizeIdentity(protectedService.Spec.Name, namespace)
+		serversToProtect[formattedServerName] = buildStrictPeerAuthentication(formattedServerName, protectedService.Spec.Name, namespace)
+	}
+
+	var existingPeerAuthentications v1beta1.PeerAuthenticationList
+	err := r.List(ctx, &existingPeerAuthentications, client.InNamespace(namespace), client.HasLabels{otterizev1alpha3.OtterizeIstioPeerAuthenticationServerLabelKey})
+	if err != nil {
+		return err
+	}
+
+	for _, existingPeerAuthentication := range existingPeerAuthentications.Items {
+		serverName := existingPeerAuthentication.Labels[otterizev1alpha3.OtterizeIstioPeerAuthenticationServerLabelKey]
+		desiredPeerAuthentication, found := serversToProtect[serverName]
+		if !found {
+			err = r.Delete(ctx, existingPeerAuthentication)
+			if client.IgnoreNotFound(err) != nil {
+				return err
+			}
+			logrus.Infof("Deleted peer authentication %s/%s", existingPeerAuthentication.Namespace, existingPeerAuthentication.Name)
+			continue
+		}
+
+		err = r.updateIfNeeded(ctx, existingPeerAuthentication, desiredPeerAuthentication)
+		if err != nil {
+			return err
+		}
+		delete(serversToProtect, serverName)
+	}
+
+	for _, peerAuthentication := range serversToProtect {
+		err = r.Create(ctx, peerAuthentication)
+		if err != nil {
+			return err
+		}
+		logrus.Infof("Created peer authentication %s/%s", peerAuthentication.Namespace, peerAuthentication.Name)
+	}
+
+	return nil
+}
+
+func (r *PeerAuthenticationReconciler) updateIfNeeded(ctx context.Context, existing *v1beta1.PeerAuthentication, desired *v1beta1.PeerAuthentication) error {
+	if otterizev1alpha3.IsManuallyOverridden(existing) {
+		logrus.Debugf("Peer authentication %s/%s is manually overridden, skipping update", existing.Namespace, existing.Name)
+		return nil
+	}
+
+	if isPeerAuthenticationEqual(existing, desired) {
+		return nil
+	}
+
+	peerAuthenticationCopy := existing.DeepCopy()
+	peerAuthenticationCopy.Labels = desired.Labels
+	peerAuthenticationCopy.Spec.Selector = desired.Spec.Selector
+	peerAuthenticationCopy.Spec.Mtls = desired.Spec.Mtls
+	peerAuthenticationCopy.Spec.PortLevelMtls = nil
+	return r.Patch(ctx, peerAuthenticationCopy, client.MergeFrom(existing))
+}
+
+func isPeerAuthenticationEqual(existing *v1beta1.PeerAuthentication, desired *v1beta1.PeerAuthentication) bool {
+	if !reflect.DeepEqual(existing.Labels, desired.Labels) {
+		return false
+	}
+
+	if existing.Spec.Selector == nil || !reflect.DeepEqual(existing.Spec.Selector.MatchLabels, desired.Spec.Selector.MatchLabels) {
+		return false
+	}
+
+	// Port level settings could relax the mode for some of the server's ports.
+	if len(existing.Spec.PortLevelMtls) != 0 {
+		return false
+	}
+
+	return existing.Spec.Mtls != nil && existing.Spec.Mtls.Mode == desired.Spec.Mtls.Mode
+}
+
+func buildStrictPeerAuthentication(formattedServerName string, serviceName string, namespace string) *v1beta1.PeerAuthentication {
+	return &v1beta1.PeerAuthentication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf(otterizev1alpha3.OtterizeIstioPeerAuthenticationNameTemplate, serviceName),
+			Namespace: namespace,
+			Labels: map[string]string{
+				otterizev1alpha3.OtterizeIstioPeerAuthenticationServerLabelKey: formattedServerName,
+			},
+		},
+		Spec: v1beta1security.PeerAuthentication{
+			Selector: &v1beta1type.WorkloadSelector{
+				MatchLabels: map[string]string{
+					otterizev1alpha3.OtterizeServerLabelKey: formattedServerName,
+				},
+			},
+			Mtls: &v1beta1security.PeerAuthentication_MutualTLS{
+				Mode: v1beta1security.PeerAuthentication_MutualTLS_STRICT,
+			},
+		},
+	}
+}
+
+// updateClientsWithoutSidecar records the clients that call the protected service but have no sidecar, and therefore
+// can no longer reach it, using the intents.otterize.com/clients-without-sidecar annotation.
+func (r *PeerAuthenticationReconciler) updateClientsWithoutSidecar(ctx context.Context, protectedService *otterizev1alpha3.ProtectedService) error {
+	var intentsToServer otterizev1alpha3.ClientIntentsList
+	err := r.List(ctx,
+		&intentsToServer,
+		&client.MatchingFields{otterizev1alpha3.OtterizeTargetServerIndexField: fmt.Sprintf("%s.%s", protectedService.Spec.Name, protectedService.Namespace)},
+	)
+	if err != nil {
+		return err
+	}
+
+	clientsWithoutSidecar := make([]string, 0)
+	for _, clientIntents := range intentsToServer.Items {
+		missingSidecar, err := strconv.ParseBool(clientIntents.Annotations[otterizev1alpha3.OtterizeMissingSidecarAnnotation])
+		if err != nil || !missingSidecar {
+			continue
+		}
+		clientsWithoutSidecar = append(clientsWithoutSidecar, fmt.Sprintf("%s.%s", clientIntents.GetServiceName(), clientIntents.Namespace))
+	}
+	clientsWithoutSidecar = lo.Uniq(clientsWithoutSidecar)
+	slices.Sort(clientsWithoutSidecar)
+
+	existingValue, ok := protectedService.Annotations[otterizev1alpha3.OtterizeClientsWithoutSidecarAnnotation]
+	if !ok && len(clientsWithoutSidecar) == 0 {
+		return nil
+	}
+	if ok {
+		existingClients := make([]string, 0)
+		err = json.Unmarshal([]byte(existingValue), &existingClients)
+		// An unparsable value is overwritten.
+		if err == nil && slices.Equal(existingClients, clientsWithoutSidecar) {
+			return nil
+		}
+	}
+
+	updatedProtectedService := protectedService.DeepCopy()
+	if len(clientsWithoutSidecar) == 0 {
+		delete(updatedProtectedService.Annotations, otterizev1alpha3.OtterizeClientsWithoutSidecarAnnotation)
+	} else {
+		value, err := json.Marshal(clientsWithoutSidecar)
+		if err != nil {
+			return err
+		}
+		if updatedProtectedService.Annotations == nil {
+			updatedProtectedService.Annotations = make(map[string]string)
+		}
+		updatedProtectedService.Annotations[otterizev1alpha3.OtterizeClientsWithoutSidecarAnnotation] = string(value)
+	}
+
+	err = r.Patch(ctx, updatedProtectedService, client.MergeFrom(protectedService))
+	if err != nil {
+		return err
+	}
+
+	if len(clientsWithoutSidecar) != 0 {
+		r.RecordWarningEventf(protectedService, ReasonClientsMissingSidecar,
+			"Service %s requires mutual TLS, so traffic from clients without a sidecar is rejected: %s",
+			protectedService.Spec.Name, strings.Join(clientsWithoutSidecar, ", "))
+	}
+
+	return nil
+}
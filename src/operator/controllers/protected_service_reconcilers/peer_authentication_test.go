@@ -0,0 +1,184 @@
+package protected_service_reconcilers
+
+import (
+	"context"
+	"fmt"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/shared/testbase"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	v1beta1security "istio.io/api/security/v1beta1"
+	"istio.io/client-go/pkg/apis/security/v1beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"testing"
+)
+
+type PeerAuthenticationReconcilerTestSuite struct {
+	testbase.MocksSuiteBase
+	reconciler *PeerAuthenticationReconciler
+	scheme     *runtime.Scheme
+}
+
+func (s *PeerAuthenticationReconcilerTestSuite) SetupTest() {
+	s.MocksSuiteBase.SetupTest()
+	s.scheme = runtime.NewScheme()
+	s.scheme.AddKnownTypeWithName(schema.GroupVersionKind{Group: "security.istio.io", Version: "v1beta1", Kind: "PeerAuthentication"}, &v1beta1.PeerAuthentication{})
+	s.reconciler = NewPeerAuthenticationReconciler(s.Client)
+	s.reconciler.Recorder = s.Recorder
+}
+
+func (s *PeerAuthenticationReconcilerTestSuite) expectPeerAuthenticationsInstalled() {
+	s.Client.EXPECT().Scheme().Return(s.scheme)
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Name: "peerauthentications.security.istio.io"}, gomock.Any()).Return(nil)
+}
+
+func (s *PeerAuthenticationReconcilerTestSuite) expectListProtectedServices(protectedServices ...otterizev1alpha3.ProtectedService) {
+	s.Client.EXPECT().List(gomock.Any(), gomock.Eq(&otterizev1alpha3.ProtectedServiceList{}), client.InNamespace(testNamespace)).DoAndReturn(
+		func(_ context.Context, list *otterizev1alpha3.ProtectedServiceList, _ ...client.ListOption) error {
+			list.Items = protectedServices
+			return nil
+		})
+}
+
+func (s *PeerAuthenticationReconcilerTestSuite) expectListPeerAuthentications(peerAuthentications ...*v1beta1.PeerAuthentication) {
+	s.Client.EXPECT().List(gomock.Any(), gomock.Eq(&v1beta1.PeerAuthenticationList{}), client.InNamespace(testNamespace), client.HasLabels{otterizev1alpha3.OtterizeIstioPeerAuthenticationServerLabelKey}).DoAndReturn(
+		func(_ context.Context, list *v1beta1.PeerAuthenticationList, _ ...client.ListOption) error {
+			list.Items = peerAuthentications
+			return nil
+		})
+}
+
+func (s *PeerAuthenticationReconcilerTestSuite) expectListIntentsToServer(clientIntents ...otterizev1alpha3.ClientIntents) {
+	fullServerName := fmt.Sprintf("%s.%s", protectedServiceName, testNamespace)
+	s.Client.EXPECT().List(gomock.Any(), gomock.Eq(&otterizev1alpha3.ClientIntentsList{}), &client.MatchingFields{otterizev1alpha3.OtterizeTargetServerIndexField: fullServerName}).DoAndReturn(
+		func(_ context.Context, list *otterizev1alpha3.ClientIntentsList, _ ...client.ListOption) error {
+			list.Items = clientIntents
+			return nil
+		})
+}
+
+func (s *PeerAuthenticationReconcilerTestSuite) reconcile() {
+	res, err := s.reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: protectedServicesResourceName}})
+	s.Require().NoError(err)
+	s.Require().True(res.IsZero())
+}
+
+func newProtectedService(annotations map[string]string) otterizev1alpha3.ProtectedService {
+	return otterizev1alpha3.ProtectedService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        protectedServicesResourceName,
+			Namespace:   testNamespace,
+			Annotations: annotations,
+		},
+		Spec: otterizev1alpha3.ProtectedServiceSpec{
+			Name: protectedServiceName,
+		},
+	}
+}
+
+func (s *PeerAuthenticationReconcilerTestSuite) TestCRDNotInstalled() {
+	s.Client.EXPECT().Scheme().Return(s.scheme)
+	s.Client.EXPECT().Get(gomock.Any(), types.NamespacedName{Name: "peerauthentications.security.istio.io"}, gomock.Any()).Return(
+		k8serrors.NewNotFound(schema.GroupResource{}, "peerauthentications.security.istio.io"))
+
+	s.reconcile()
+}
+
+func (s *PeerAuthenticationReconcilerTestSuite) TestStrictPeerAuthenticationCreated() {
+	s.expectPeerAuthenticationsInstalled()
+	s.expectListProtectedServices(newProtectedService(nil))
+	s.expectListPeerAuthentications()
+
+	s.Client.EXPECT().Create(gomock.Any(), gomock.AssignableToTypeOf(&v1beta1.PeerAuthentication{})).DoAndReturn(
+		func(_ context.Context, peerAuthentication *v1beta1.PeerAuthentication, _ ...client.CreateOption) error {
+			s.Require().Equal(fmt.Sprintf(otterizev1alpha3.OtterizeIstioPeerAuthenticationNameTemplate, protectedServiceName), peerAuthentication.Name)
+			s.Require().Equal(testNamespace, peerAuthentication.Namespace)
+			s.Require().Equal(protectedServiceFormattedName, peerAuthentication.Labels[otterizev1alpha3.OtterizeIstioPeerAuthenticationServerLabelKey])
+			s.Require().Equal(map[string]string{otterizev1alpha3.OtterizeServerLabelKey: protectedServiceFormattedName}, peerAuthentication.Spec.Selector.MatchLabels)
+			s.Require().Equal(v1beta1security.PeerAuthentication_MutualTLS_STRICT, peerAuthentication.Spec.Mtls.Mode)
+			return nil
+		})
+	s.expectListIntentsToServer()
+
+	s.reconcile()
+}
+
+func (s *PeerAuthenticationReconcilerTestSuite) TestExistingPeerAuthenticationUnchanged() {
+	s.expectPeerAuthenticationsInstalled()
+	s.expectListProtectedServices(newProtectedService(nil))
+	s.expectListPeerAuthentications(buildStrictPeerAuthentication(protectedServiceFormattedName, protectedServiceName, testNamespace))
+	s.expectListIntentsToServer()
+
+	s.reconcile()
+}
+
+func (s *PeerAuthenticationReconcilerTestSuite) TestPeerAuthenticationDeletedWhenNoLongerProtected() {
+	existingPeerAuthentication := buildStrictPeerAuthentication(protectedServiceFormattedName, protectedServiceName, testNamespace)
+	s.expectPeerAuthenticationsInstalled()
+	s.expectListProtectedServices()
+	s.expectListPeerAuthentications(existingPeerAuthentication)
+	s.Client.EXPECT().Delete(gomock.Any(), gomock.Eq(existingPeerAuthentication)).Return(nil)
+
+	s.reconcile()
+}
+
+func (s *PeerAuthenticationReconcilerTestSuite) TestClientsWithoutSidecarRecorded() {
+	protectedService := newProtectedService(nil)
+	s.expectPeerAuthenticationsInstalled()
+	s.expectListProtectedServices(protectedService)
+	s.expectListPeerAuthentications(buildStrictPeerAuthentication(protectedServiceFormattedName, protectedServiceName, testNamespace))
+	s.expectListIntentsToServer(
+		otterizev1alpha3.ClientIntents{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "client-intents",
+				Namespace:   "client-namespace",
+				Annotations: map[string]string{otterizev1alpha3.OtterizeMissingSidecarAnnotation: "true"},
+			},
+			Spec: &otterizev1alpha3.IntentsSpec{Service: otterizev1alpha3.Service{Name: "client"}},
+		},
+		otterizev1alpha3.ClientIntents{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "other-client-intents",
+				Namespace:   "client-namespace",
+				Annotations: map[string]string{otterizev1alpha3.OtterizeMissingSidecarAnnotation: "false"},
+			},
+			Spec: &otterizev1alpha3.IntentsSpec{Service: otterizev1alpha3.Service{Name: "other-client"}},
+		},
+	)
+
+	s.Client.EXPECT().Patch(gomock.Any(), gomock.AssignableToTypeOf(&otterizev1alpha3.ProtectedService{}), gomock.Any()).DoAndReturn(
+		func(_ context.Context, updated *otterizev1alpha3.ProtectedService, _ client.Patch, _ ...client.PatchOption) error {
+			s.Require().Equal(`["client.client-namespace"]`, updated.Annotations[otterizev1alpha3.OtterizeClientsWithoutSidecarAnnotation])
+			return nil
+		})
+
+	s.reconcile()
+	s.ExpectEvent(ReasonClientsMissingSidecar)
+}
+
+func (s *PeerAuthenticationReconcilerTestSuite) TestClientsWithoutSidecarAnnotationRemoved() {
+	protectedService := newProtectedService(map[string]string{otterizev1alpha3.OtterizeClientsWithoutSidecarAnnotation: `["client.client-namespace"]`})
+	s.expectPeerAuthenticationsInstalled()
+	s.expectListProtectedServices(protectedService)
+	s.expectListPeerAuthentications(buildStrictPeerAuthentication(protectedServiceFormattedName, protectedServiceName, testNamespace))
+	s.expectListIntentsToServer()
+
+	s.Client.EXPECT().Patch(gomock.Any(), gomock.AssignableToTypeOf(&otterizev1alpha3.ProtectedService{}), gomock.Any()).DoAndReturn(
+		func(_ context.Context, updated *otterizev1alpha3.ProtectedService, _ client.Patch, _ ...client.PatchOption) error {
+			s.Require().NotContains(updated.Annotations, otterizev1alpha3.OtterizeClientsWithoutSidecarAnnotation)
+			return nil
+		})
+
+	s.reconcile()
+	s.ExpectNoEvent()
+}
+
+func TestPeerAuthenticationReconcilerTestSuite(t *testing.T) {
+	suite.Run(t, new(PeerAuthenticationReconcilerTestSuite))
+}
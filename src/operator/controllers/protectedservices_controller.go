@@ -19,15 +19,25 @@ package controllers
 import (
 	"context"
 	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/always_allowed"
 	"github.com/otterize/intents-operator/src/operator/controllers/protected_service_reconcilers"
 	"github.com/otterize/intents-operator/src/shared/operator_cloud_client"
 	"github.com/otterize/intents-operator/src/shared/reconcilergroup"
 	"github.com/otterize/intents-operator/src/shared/telemetries/telemetrysender"
 	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 const (
@@ -43,7 +53,9 @@ var protectedServiceLegacyFinalizers = []string{
 // ProtectedServiceReconciler reconciles a ProtectedService object
 type ProtectedServiceReconciler struct {
 	client.Client
-	group *reconcilergroup.Group
+	group                   *reconcilergroup.Group
+	istioEnforcementEnabled bool
+	alwaysAllowed           always_allowed.Config
 }
 
 //+kubebuilder:rbac:groups=k8s.otterize.com,resources=protectedservices,verbs=get;list;watch;create;update;patch;delete
@@ -57,7 +69,9 @@ func NewProtectedServiceReconciler(
 	extNetpolHandler protected_service_reconcilers.ExternalNepolHandler,
 	enforcementDefaultState bool,
 	netpolEnforcementEnabled bool,
+	istioEnforcementEnabled bool,
 	networkPolicyHandler protected_service_reconcilers.NetworkPolicyHandler,
+	alwaysAllowed always_allowed.Config,
 ) *ProtectedServiceReconciler {
 	group := reconcilergroup.NewGroup(
 		protectedServicesGroupName,
@@ -70,6 +84,7 @@ func NewProtectedServiceReconciler(
 
 	if netpolEnforcementEnabled {
 		defaultDenyReconciler := protected_service_reconcilers.NewDefaultDenyReconciler(client, extNetpolHandler, netpolEnforcementEnabled)
+		defaultDenyReconciler.SetAlwaysAllowedConfig(alwaysAllowed)
 		group.AddToGroup(defaultDenyReconciler)
 	}
 
@@ -78,6 +93,11 @@ func NewProtectedServiceReconciler(
 		group.AddToGroup(policyCleaner)
 	}
 
+	if istioEnforcementEnabled {
+		peerAuthenticationReconciler := protected_service_reconcilers.NewPeerAuthenticationReconciler(client)
+		group.AddToGroup(peerAuthenticationReconciler)
+	}
+
 	if otterizeClient != nil {
 		otterizeCloudReconciler := protected_service_reconcilers.NewCloudReconciler(client, scheme, otterizeClient)
 		group.AddToGroup(otterizeCloudReconciler)
@@ -89,8 +109,10 @@ func NewProtectedServiceReconciler(
 	}
 
 	return &ProtectedServiceReconciler{
-		Client: client,
-		group:  group,
+		Client:                  client,
+		group:                   group,
+		istioEnforcementEnabled: istioEnforcementEnabled,
+		alwaysAllowed:           lo.Ternary(netpolEnforcementEnabled, alwaysAllowed, always_allowed.Config{}),
 	}
 }
 
@@ -100,10 +122,27 @@ func (r *ProtectedServiceReconciler) Reconcile(ctx context.Context, req ctrl.Req
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ProtectedServiceReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	err := ctrl.NewControllerManagedBy(mgr).
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
 		For(&otterizev1alpha3.ProtectedService{}).
-		WithOptions(controller.Options{RecoverPanic: lo.ToPtr(true)}).
-		Complete(r)
+		WithOptions(controller.Options{RecoverPanic: lo.ToPtr(true)})
+
+	if r.istioEnforcementEnabled {
+		controllerBuilder = controllerBuilder.Watches(
+			&source.Kind{Type: &otterizev1alpha3.ClientIntents{}},
+			handler.EnqueueRequestsFromMapFunc(r.mapClientIntentsToProtectedServices),
+			builder.WithPredicates(missingSidecarChangedPredicate()),
+		)
+	}
+
+	if r.alwaysAllowed.HasPortAnnotations() {
+		controllerBuilder = controllerBuilder.Watches(
+			&source.Kind{Type: &corev1.Pod{}},
+			handler.EnqueueRequestsFromMapFunc(r.mapPodToProtectedServices),
+			builder.WithPredicates(r.alwaysAllowed.PortAnnotationsChangedPredicate()),
+		)
+	}
+
+	err := controllerBuilder.Complete(r)
 	if err != nil {
 		return err
 	}
@@ -111,3 +150,80 @@ func (r *ProtectedServiceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.group.InjectRecorder(mgr.GetEventRecorderFor(protectedServicesGroupName))
 	return nil
 }
+
+// missingSidecarChangedPredicate passes client intents events that may change the list of clients without a sidecar
+// calling a protected service.
+func missingSidecarChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return true
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return e.ObjectOld.GetAnnotations()[otterizev1alpha3.OtterizeMissingSidecarAnnotation] != e.ObjectNew.GetAnnotations()[otterizev1alpha3.OtterizeMissingSidecarAnnotation]
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return true
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+// mapPodToProtectedServices maps pods whose always allowed port annotations changed to the protected services whose
+// default deny policy selects them.
+func (r *ProtectedServiceReconciler) mapPodToProtectedServices(obj client.Object) []reconcile.Request {
+	formattedServer, ok := obj.GetLabels()[otterizev1alpha3.OtterizeServerLabelKey]
+	if !ok {
+		return nil
+	}
+
+	var protectedServices otterizev1alpha3.ProtectedServiceList
+	err := r.List(context.Background(), &protectedServices, client.InNamespace(obj.GetNamespace()))
+	if err != nil {
+		logrus.WithError(err).Errorf("Failed to list protected services in namespace %s", obj.GetNamespace())
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0)
+	for _, protectedService := range protectedServices.Items {
+		if otterizev1alpha3.GetFormattedOtterizeIdentity(protectedService.Spec.Name, protectedService.Namespace) != formattedServer {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: protectedService.Name, Namespace: protectedService.Namespace},
+		})
+	}
+
+	return requests
+}
+
+func (r *ProtectedServiceReconciler) mapClientIntentsToProtectedServices(obj client.Object) []reconcile.Request {
+	clientIntents := obj.(*otterizev1alpha3.ClientIntents)
+
+	requests := make([]reconcile.Request, 0)
+	for _, intent := range clientIntents.GetCallsList() {
+		if intent.Type != "" && intent.Type != otterizev1alpha3.IntentTypeHTTP {
+			continue
+		}
+
+		var protectedServices otterizev1alpha3.ProtectedServiceList
+		err := r.List(context.Background(),
+			&protectedServices,
+			client.InNamespace(intent.GetTargetServerNamespace(clientIntents.Namespace)),
+			client.MatchingFields{otterizev1alpha3.OtterizeProtectedServiceNameIndexField: intent.GetTargetServerName()},
+		)
+		if err != nil {
+			logrus.WithError(err).Errorf("Failed to list protected services for server %s", intent.GetTargetServerName())
+			continue
+		}
+
+		for _, protectedService := range protectedServices.Items {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: protectedService.Name, Namespace: protectedService.Namespace},
+			})
+		}
+	}
+
+	return lo.Uniq(requests)
+}
@@ -25,9 +25,16 @@ import (
 	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers"
 	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/egress_network_policy"
 	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/ingress_network_policy"
+	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/istio_egress"
+	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/kubernetes_rbac"
 	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/port_egress_network_policy"
 	"github.com/otterize/intents-operator/src/operator/controllers/intents_reconcilers/port_network_policy"
+	"github.com/otterize/intents-operator/src/operator/controllers/istiopolicy"
+	"github.com/otterize/intents-operator/src/operator/controllers/linkerdpolicy"
 	"github.com/otterize/intents-operator/src/operator/controllers/pod_reconcilers"
+	"github.com/otterize/intents-operator/src/operator/controllers/policy_drift"
+	"github.com/otterize/intents-operator/src/operator/controllers/postgres"
+	"github.com/otterize/intents-operator/src/operator/controllers/protected_service_reconcilers"
 	"github.com/otterize/intents-operator/src/operator/otterizecrds"
 	"github.com/otterize/intents-operator/src/operator/webhooks"
 	"github.com/otterize/intents-operator/src/shared/awsagent"
@@ -45,7 +52,10 @@ import (
 
 	otterizev1alpha2 "github.com/otterize/intents-operator/src/operator/api/v1alpha2"
 	"github.com/otterize/intents-operator/src/operator/controllers"
+	"github.com/otterize/intents-operator/src/operator/controllers/always_allowed"
 	"github.com/otterize/intents-operator/src/operator/controllers/external_traffic"
+	"github.com/otterize/intents-operator/src/operator/controllers/garbage_collection"
+	"github.com/otterize/intents-operator/src/operator/controllers/kafka_server_config_reconcilers"
 	"github.com/otterize/intents-operator/src/operator/controllers/kafkaacls"
 	"github.com/otterize/intents-operator/src/shared/operatorconfig"
 	"github.com/otterize/intents-operator/src/shared/otterizecloud/graphqlclient"
@@ -59,6 +69,7 @@ import (
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	istionetworkingscheme "istio.io/client-go/pkg/apis/networking/v1beta1"
 	istiosecurityscheme "istio.io/client-go/pkg/apis/security/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -78,6 +89,7 @@ func init() {
 	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(istiosecurityscheme.AddToScheme(scheme))
+	utilruntime.Must(istionetworkingscheme.AddToScheme(scheme))
 	utilruntime.Must(otterizev1alpha2.AddToScheme(scheme))
 	utilruntime.Must(otterizev1alpha3.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
@@ -107,9 +119,13 @@ func main() {
 		EnableNetworkPolicy:                  viper.GetBool(operatorconfig.EnableNetworkPolicyKey),
 		EnableKafkaACL:                       viper.GetBool(operatorconfig.EnableKafkaACLKey),
 		EnableIstioPolicy:                    viper.GetBool(operatorconfig.EnableIstioPolicyKey),
+		EnableLinkerdPolicy:                  viper.GetBool(operatorconfig.EnableLinkerdPolicyKey),
 		EnableDatabaseReconciler:             viper.GetBool(operatorconfig.EnableDatabaseReconciler),
 		EnableEgressNetworkPolicyReconcilers: viper.GetBool(operatorconfig.EnableEgressNetworkPolicyReconcilersKey),
 		EnableAWSPolicy:                      viper.GetBool(operatorconfig.EnableAWSPolicyKey),
+		EnableKubernetesRBAC:                 viper.GetBool(operatorconfig.EnableKubernetesRBACKey),
+		EnableIstioEgressPolicy:              viper.GetBool(operatorconfig.EnableIstioEgressPolicyKey),
+		IstioConsolidatedPolicies:            viper.GetBool(operatorconfig.IstioConsolidatedPoliciesKey),
 	}
 	disableWebhookServer := viper.GetBool(operatorconfig.DisableWebhookServerKey)
 	tlsSource := otterizev1alpha3.TLSSource{
@@ -183,16 +199,29 @@ func main() {
 		logrus.WithError(err).Fatal("unable to create kubernetes API client")
 	}
 
-	kafkaServersStore := kafkaacls.NewServersStore(tlsSource, enforcementConfig.EnableKafkaACL, kafkaacls.NewKafkaIntentsAdmin, enforcementConfig.EnforcementDefaultState)
+	kafkaConnectionPool := kafkaacls.NewConnectionPool(viper.GetInt(operatorconfig.KafkaMaxConcurrentOperationsKey), viper.GetDuration(operatorconfig.KafkaConnectionHealthCheckIntervalKey))
+	if err = mgr.Add(kafkaConnectionPool); err != nil {
+		logrus.WithError(err).Fatal("unable to set up Kafka connection pool")
+	}
+	kafkaIntentsAdminFactory := kafkaacls.NewPooledKafkaIntentsAdminFactory(directClient, viper.GetString(operatorconfig.ClusterNameKey), kafkaConnectionPool)
+	kafkaServersStore := kafkaacls.NewServersStore(tlsSource, enforcementConfig.EnableKafkaACL, kafkaIntentsAdminFactory, enforcementConfig.EnforcementDefaultState, kafkaConnectionPool)
 
 	extNetpolHandler := external_traffic.NewNetworkPolicyHandler(mgr.GetClient(), mgr.GetScheme(), autoCreateNetworkPoliciesForExternalTraffic, autoCreateNetworkPoliciesForExternalTrafficDisableIntentsRequirement)
+	ingressControllerConfig, err := external_traffic.ParseIngressControllerConfig(
+		viper.GetString(operatorconfig.IngressControllerPodSelectorKey),
+		viper.GetString(operatorconfig.IngressControllerNamespaceSelectorKey))
+	if err != nil {
+		logrus.WithError(err).Fatal("unable to parse ingress controller configuration")
+	}
+	extNetpolHandler.SetIngressControllerConfig(ingressControllerConfig)
 	endpointReconciler := external_traffic.NewEndpointsReconciler(mgr.GetClient(), extNetpolHandler)
 	externalPolicySvcReconciler := external_traffic.NewServiceReconciler(mgr.GetClient(), extNetpolHandler)
-	networkPolicyHandler := ingress_network_policy.NewNetworkPolicyReconciler(mgr.GetClient(), scheme, extNetpolHandler, watchedNamespaces, enforcementConfig.EnableNetworkPolicy, enforcementConfig.EnforcementDefaultState, autoCreateNetworkPoliciesForExternalTrafficDisableIntentsRequirement)
-	egressNetworkPolicyHandler := egress_network_policy.NewEgressNetworkPolicyReconciler(mgr.GetClient(), scheme, watchedNamespaces, enforcementConfig.EnableNetworkPolicy, enforcementConfig.EnforcementDefaultState)
+	networkPolicyHandler := ingress_network_policy.NewNetworkPolicyReconciler(mgr.GetClient(), scheme, extNetpolHandler, watchedNamespaces, enforcementConfig.EnableNetworkPolicy, enforcementConfig.EnforcementDefaultState, autoCreateNetworkPoliciesForExternalTrafficDisableIntentsRequirement, podNamespace)
+	egressNetworkPolicyHandler := egress_network_policy.NewEgressNetworkPolicyReconciler(mgr.GetClient(), scheme, watchedNamespaces, enforcementConfig.EnableNetworkPolicy, enforcementConfig.EnforcementDefaultState, podNamespace)
 	additionalIntentsReconcilers := make([]reconcilergroup.ReconcilerWithEvents, 0)
+	var awsIntentsAgent *awsagent.Agent
 	if viper.GetBool(operatorconfig.EnableAWSPolicyKey) {
-		awsIntentsAgent := awsagent.NewAWSAgent(context.Background(), oidcUrl)
+		awsIntentsAgent = awsagent.NewAWSAgent(context.Background(), oidcUrl, viper.GetString(operatorconfig.ClusterNameKey))
 		awsIntentsReconciler := intents_reconcilers.NewAWSIntentsReconciler(mgr.GetClient(), scheme, awsIntentsAgent, serviceidresolver.NewResolver(mgr.GetClient()))
 		additionalIntentsReconcilers = append(additionalIntentsReconcilers, awsIntentsReconciler)
 		awsPodWatcher := aws_pod_reconciler.NewAWSPodReconciler(mgr.GetClient(), mgr.GetEventRecorderFor("intents-operator"), awsIntentsReconciler)
@@ -201,7 +230,45 @@ func main() {
 			logrus.WithError(err).Fatal("unable to register pod watcher")
 		}
 	}
+	if enforcementConfig.EnableKubernetesRBAC {
+		createAPIServerEgressPolicy := enforcementConfig.EnableEgressNetworkPolicyReconcilers && enforcementConfig.EnableNetworkPolicy && enforcementConfig.EnforcementDefaultState
+		kubernetesRBACReconciler := kubernetes_rbac.NewKubernetesRBACReconciler(mgr.GetClient(), scheme, watchedNamespaces, serviceidresolver.NewResolver(mgr.GetClient()), createAPIServerEgressPolicy, !disableWebhookServer)
+		additionalIntentsReconcilers = append(additionalIntentsReconcilers, kubernetesRBACReconciler)
+	}
+	isIstioEgressEnabled := false
+	if enforcementConfig.EnableIstioPolicy && enforcementConfig.EnableIstioEgressPolicy {
+		isIstioSidecarInstalled, err := istiopolicy.IsIstioSidecarsInstalled(signalHandlerCtx, directClient)
+		if err != nil {
+			logrus.WithError(err).Fatal("unable to check whether Istio sidecar resources are installed")
+		}
+		if isIstioSidecarInstalled {
+			isIstioEgressEnabled = true
+			istioEgressReconciler := istio_egress.NewIstioEgressReconciler(mgr.GetClient(), scheme, watchedNamespaces, serviceidresolver.NewResolver(mgr.GetClient()), enforcementConfig.EnforcementDefaultState, viper.GetString(operatorconfig.IstioNamespaceKey))
+			additionalIntentsReconcilers = append(additionalIntentsReconcilers, istioEgressReconciler)
+		}
+	}
+	linkerdPolicyKinds, err := linkerdpolicy.DetectPolicyKinds(mgr.GetRESTMapper())
+	if err != nil {
+		logrus.WithError(err).Fatal("unable to detect Linkerd policy kinds")
+	}
+	var linkerdPolicyReconciler *intents_reconcilers.LinkerdPolicyReconciler
+	if linkerdPolicyKinds != nil {
+		logrus.Info("Linkerd policy CRDs detected, will create Linkerd policies for intents")
+		linkerdPolicyReconciler = intents_reconcilers.NewLinkerdPolicyReconciler(mgr.GetClient(), scheme, *linkerdPolicyKinds, watchedNamespaces, enforcementConfig.EnableLinkerdPolicy, enforcementConfig.EnforcementDefaultState)
+		additionalIntentsReconcilers = append(additionalIntentsReconcilers, linkerdPolicyReconciler)
+	}
 	svcNetworkPolicyHandler := port_network_policy.NewPortNetworkPolicyReconciler(mgr.GetClient(), scheme, extNetpolHandler, watchedNamespaces, enforcementConfig.EnableNetworkPolicy, enforcementConfig.EnforcementDefaultState)
+	alwaysAllowedConfig, err := always_allowed.ParseConfig(
+		viper.GetString(operatorconfig.AlwaysAllowedPodSelectorKey),
+		viper.GetString(operatorconfig.AlwaysAllowedNamespaceSelectorKey),
+		viper.GetString(operatorconfig.AlwaysAllowedCIDRsKey),
+		viper.GetString(operatorconfig.AlwaysAllowedPortsKey),
+		viper.GetString(operatorconfig.AlwaysAllowedPortAnnotationsKey))
+	if err != nil {
+		logrus.WithError(err).Fatal("unable to parse always allowed traffic configuration")
+	}
+	networkPolicyHandler.SetAlwaysAllowedConfig(alwaysAllowedConfig)
+	svcNetworkPolicyHandler.SetAlwaysAllowedConfig(alwaysAllowedConfig)
 	svcEgressNetworkPolicyHandler := port_egress_network_policy.NewPortEgressNetworkPolicyReconciler(mgr.GetClient(), scheme, watchedNamespaces, enforcementConfig.EnableNetworkPolicy, enforcementConfig.EnforcementDefaultState)
 
 	if err = endpointReconciler.InitIngressReferencedServicesIndex(mgr); err != nil {
@@ -210,6 +277,27 @@ func main() {
 
 	ingressReconciler := external_traffic.NewIngressReconciler(mgr.GetClient(), extNetpolHandler)
 
+	gatewayRouteKinds, err := external_traffic.DetectGatewayRouteKinds(mgr.GetRESTMapper())
+	if err != nil {
+		logrus.WithError(err).Fatal("unable to detect Gateway API route kinds")
+	}
+	referenceGrantKind, err := external_traffic.DetectReferenceGrantKind(mgr.GetRESTMapper())
+	if err != nil {
+		logrus.WithError(err).Fatal("unable to detect Gateway API ReferenceGrant kind")
+	}
+	// Gateway API objects are read as unstructured objects, since their CRDs are optional, and the manager's client
+	// does not cache those - so they are read from the manager's cache instead.
+	extNetpolHandler.SetGatewayRouteKinds(mgr.GetCache(), gatewayRouteKinds, referenceGrantKind)
+	gatewayRouteReconcilers := make([]*external_traffic.GatewayRouteReconciler, 0)
+	for _, routeKind := range gatewayRouteKinds {
+		logrus.Infof("Gateway API %s CRD detected, will create external traffic policies for its backends", routeKind.Kind)
+		gatewayRouteReconciler := external_traffic.NewGatewayRouteReconciler(mgr.GetClient(), mgr.GetCache(), extNetpolHandler, routeKind, referenceGrantKind)
+		if err = gatewayRouteReconciler.InitGatewayRouteReferencedServicesIndex(mgr); err != nil {
+			logrus.WithError(err).Fatal("unable to init index for gateway route", "kind", routeKind.Kind)
+		}
+		gatewayRouteReconcilers = append(gatewayRouteReconcilers, gatewayRouteReconciler)
+	}
+
 	otterizeCloudClient, connectedToCloud, err := operator_cloud_client.NewClient(signalHandlerCtx)
 	if err != nil {
 		logrus.WithError(err).Error("Failed to initialize Otterize Cloud client")
@@ -258,12 +346,28 @@ func main() {
 		}
 	}
 
+	// The trust domain is only detected from the mesh config when Istio policies are created.
+	istioTrustDomain := istiopolicy.ConfiguredTrustDomain(viper.GetString(operatorconfig.IstioTrustDomainKey), viper.GetString(operatorconfig.IstioTrustDomainAliasesKey))
+	if enforcementConfig.EnableIstioPolicy {
+		istioTrustDomain, err = istiopolicy.DetectTrustDomain(
+			signalHandlerCtx,
+			directClient,
+			viper.GetString(operatorconfig.IstioNamespaceKey),
+			viper.GetString(operatorconfig.IstioTrustDomainKey),
+			viper.GetString(operatorconfig.IstioTrustDomainAliasesKey))
+		if err != nil {
+			logrus.WithError(err).Fatal("unable to detect Istio trust domain")
+		}
+		logrus.Infof("Using Istio trust domain %s, aliases: %v", istioTrustDomain.Name, istioTrustDomain.Aliases)
+	}
+	istioTrustDomain.RemoteClientsInOtherNamespaces = viper.GetBool(operatorconfig.IstioRemoteClientsInOtherNamespacesKey)
+
 	if !disableWebhookServer {
 		intentsValidator := webhooks.NewIntentsValidatorV1alpha2(mgr.GetClient())
 		if err = (&otterizev1alpha2.ClientIntents{}).SetupWebhookWithManager(mgr, intentsValidator); err != nil {
 			logrus.WithError(err).Fatal(err, "unable to create webhook for v1alpha2", "webhook", "ClientIntents")
 		}
-		intentsValidatorV1alpha3 := webhooks.NewIntentsValidatorV1alpha3(mgr.GetClient())
+		intentsValidatorV1alpha3 := webhooks.NewIntentsValidatorV1alpha3(mgr.GetClient(), istioTrustDomain)
 		if err = (&otterizev1alpha3.ClientIntents{}).SetupWebhookWithManager(mgr, intentsValidatorV1alpha3); err != nil {
 			logrus.WithError(err).Fatal(err, "unable to create webhook v1alpha3", "webhook", "ClientIntents")
 		}
@@ -282,7 +386,8 @@ func main() {
 			logrus.WithError(err).Fatal("unable to create webhook v1alpha2", "webhook", "KafkaServerConfig")
 		}
 
-		if err = (&otterizev1alpha3.KafkaServerConfig{}).SetupWebhookWithManager(mgr); err != nil {
+		kafkaServerConfigValidatorV1alpha3 := webhooks.NewKafkaServerConfigValidatorV1alpha3(mgr.GetClient())
+		if err = (&otterizev1alpha3.KafkaServerConfig{}).SetupWebhookWithManager(mgr, kafkaServerConfigValidatorV1alpha3); err != nil {
 			logrus.WithError(err).Fatal("unable to create webhook v1alpha3", "webhook", "KafkaServerConfig")
 		}
 
@@ -292,21 +397,31 @@ func main() {
 		mgr.GetClient(),
 		mgr.GetScheme(),
 		kafkaServersStore,
+		kafkaIntentsAdminFactory,
+		postgres.NewPostgresAdminFactory(directClient),
 		networkPolicyHandler,
 		svcNetworkPolicyHandler,
 		egressNetworkPolicyHandler,
 		svcEgressNetworkPolicyHandler,
 		watchedNamespaces,
 		enforcementConfig,
+		istioTrustDomain,
 		otterizeCloudClient,
 		podName,
 		podNamespace,
 		additionalIntentsReconcilers...,
 	)
 
+	if enforcementConfig.EnableNetworkPolicy {
+		intentsReconciler.SetAlwaysAllowedConfig(alwaysAllowedConfig)
+	}
+
 	if err = ingressReconciler.InitNetworkPoliciesByIngressNameIndex(mgr); err != nil {
 		logrus.WithError(err).Fatal("unable to init index for ingress")
 	}
+	if err = external_traffic.InitNetworkPoliciesByGatewayRouteIndex(mgr); err != nil {
+		logrus.WithError(err).Fatal("unable to init index for gateway routes")
+	}
 	if err = intentsReconciler.InitIntentsServerIndices(mgr); err != nil {
 		logrus.WithError(err).Fatal("unable to init indices")
 	}
@@ -319,6 +434,20 @@ func main() {
 		logrus.WithError(err).Fatal("unable to init protected service index")
 	}
 
+	driftedNetworkPolicyLabels := []string{otterizev1alpha3.OtterizeNetworkPolicy, otterizev1alpha3.OtterizeSvcNetworkPolicy}
+	if enforcementConfig.EnableEgressNetworkPolicyReconcilers {
+		driftedNetworkPolicyLabels = append(driftedNetworkPolicyLabels, otterizev1alpha3.OtterizeEgressNetworkPolicy, otterizev1alpha3.OtterizeSvcEgressNetworkPolicy)
+	}
+	intentsReconciler.AddPolicyDriftHandler(policy_drift.NewNetworkPolicyDriftHandler(mgr.GetClient(), driftedNetworkPolicyLabels))
+
+	isIstioInstalled, err := istiopolicy.IsIstioAuthorizationPoliciesInstalled(signalHandlerCtx, directClient)
+	if err != nil {
+		logrus.WithError(err).Fatal("unable to check whether Istio is installed")
+	}
+	if isIstioInstalled {
+		intentsReconciler.AddPolicyDriftHandler(policy_drift.NewIstioPolicyDriftHandler(mgr.GetClient()))
+	}
+
 	if err = intentsReconciler.SetupWithManager(mgr); err != nil {
 		logrus.WithError(err).Fatal("unable to create controller", "controller", "Intents")
 	}
@@ -334,6 +463,12 @@ func main() {
 		logrus.WithError(err).Fatal("unable to create controller", "controller", "Ingress")
 	}
 
+	for _, gatewayRouteReconciler := range gatewayRouteReconcilers {
+		if err = gatewayRouteReconciler.SetupWithManager(mgr); err != nil {
+			logrus.WithError(err).Fatal("unable to create controller", "controller", "GatewayRoute")
+		}
+	}
+
 	kafkaServerConfigReconciler := controllers.NewKafkaServerConfigReconciler(
 		mgr.GetClient(),
 		mgr.GetScheme(),
@@ -359,7 +494,9 @@ func main() {
 		extNetpolHandler,
 		enforcementConfig.EnforcementDefaultState,
 		enforcementConfig.EnableNetworkPolicy,
+		enforcementConfig.EnableIstioPolicy,
 		networkPolicyHandler,
+		alwaysAllowedConfig,
 	)
 
 	err = protectedServicesReconciler.SetupWithManager(mgr)
@@ -367,7 +504,18 @@ func main() {
 		logrus.WithError(err).Fatal("unable to create controller", "controller", "ProtectedServices")
 	}
 
-	podWatcher := pod_reconcilers.NewPodWatcher(mgr.GetClient(), mgr.GetEventRecorderFor("intents-operator"), watchedNamespaces, enforcementConfig.EnforcementDefaultState, enforcementConfig.EnableIstioPolicy)
+	if enforcementConfig.EnableNetworkPolicy {
+		namespaceDefaultDenyReconciler := protected_service_reconcilers.NewNamespaceDefaultDenyReconciler(mgr.GetClient(), watchedNamespaces, enforcementConfig.EnableEgressNetworkPolicyReconcilers)
+		namespaceDefaultDenyReconciler.SetAlwaysAllowedConfig(alwaysAllowedConfig)
+		if err = namespaceDefaultDenyReconciler.SetupWithManager(mgr); err != nil {
+			logrus.WithError(err).Fatal("unable to create controller", "controller", "NamespaceDefaultDeny")
+		}
+	}
+
+	podWatcher := pod_reconcilers.NewPodWatcher(mgr.GetClient(), mgr.GetEventRecorderFor("intents-operator"), watchedNamespaces, enforcementConfig.EnforcementDefaultState, enforcementConfig.EnableIstioPolicy, istioTrustDomain, enforcementConfig.IstioConsolidatedPolicies)
+	if linkerdPolicyReconciler != nil {
+		podWatcher.SetLinkerdPolicyManager(linkerdPolicyReconciler.PolicyManager())
+	}
 	nsWatcher := pod_reconcilers.NewNamespaceWatcher(mgr.GetClient())
 	svcReconcilers := []reconcile.Reconciler{svcNetworkPolicyHandler}
 	if enforcementConfig.EnableEgressNetworkPolicyReconcilers {
@@ -380,6 +528,82 @@ func main() {
 		logrus.WithError(err).Panic()
 	}
 
+	if gcInterval := viper.GetDuration(operatorconfig.GarbageCollectionIntervalKey); gcInterval > 0 {
+		garbageCollector := garbage_collection.NewGarbageCollector(gcInterval, viper.GetBool(operatorconfig.GarbageCollectionDryRunKey))
+		garbageCollector.AddCollector(garbage_collection.NewCollector("network-policy", networkPolicyHandler.RemoveOrphanNetworkPolicies))
+		garbageCollector.AddCollector(garbage_collection.NewCollector("svc-network-policy", svcNetworkPolicyHandler.RemoveOrphanNetworkPolicies))
+		if enforcementConfig.EnableEgressNetworkPolicyReconcilers {
+			garbageCollector.AddCollector(garbage_collection.NewCollector("egress-network-policy", egressNetworkPolicyHandler.RemoveOrphanNetworkPolicies))
+			garbageCollector.AddCollector(garbage_collection.NewCollector("svc-egress-network-policy", svcEgressNetworkPolicyHandler.RemoveOrphanNetworkPolicies))
+			garbageCollector.AddCollector(garbage_collection.NewCollector("svc-egress-network-policy", svcWatcher.RemoveOrphanEgressServiceNetpols))
+		}
+		if enforcementConfig.EnableNetworkPolicy {
+			garbageCollector.AddCollector(garbage_collection.NewNamespaceDefaultDenyCollector(mgr.GetClient()))
+		}
+		if enforcementConfig.EnableIstioPolicy {
+			isPeerAuthenticationInstalled, err := istiopolicy.IsIstioPeerAuthenticationsInstalled(signalHandlerCtx, directClient)
+			if err != nil {
+				logrus.WithError(err).Fatal("unable to check whether Istio peer authentication resources are installed")
+			}
+			if isPeerAuthenticationInstalled {
+				garbageCollector.AddCollector(garbage_collection.NewPeerAuthenticationCollector(mgr.GetClient()))
+			}
+		}
+		// Istio policies, Kafka ACLs, Linkerd policies, Istio egress resources and Kubernetes RBAC may belong to
+		// clients in any namespace, so they can only be told apart from orphans when all namespaces are watched.
+		if len(watchedNamespaces) == 0 {
+			if isIstioInstalled {
+				garbageCollector.AddCollector(garbage_collection.NewIstioPolicyCollector(mgr.GetClient()))
+			}
+			if enforcementConfig.EnableKafkaACL {
+				garbageCollector.AddCollector(garbage_collection.NewKafkaACLCollector(mgr.GetClient(), kafkaServersStore))
+			}
+			if linkerdPolicyKinds != nil {
+				garbageCollector.AddCollector(garbage_collection.NewLinkerdPolicyCollector(mgr.GetClient(), *linkerdPolicyKinds))
+			}
+			if isIstioEgressEnabled {
+				garbageCollector.AddCollector(garbage_collection.NewIstioEgressCollector(mgr.GetClient()))
+			}
+			if enforcementConfig.EnableKubernetesRBAC {
+				garbageCollector.AddCollector(garbage_collection.NewKubernetesRBACCollector(mgr.GetClient()))
+			}
+		}
+		// Policies are only told apart from those of other clusters in the AWS account by their cluster name tag.
+		if awsIntentsAgent != nil && viper.GetString(operatorconfig.ClusterNameKey) != "" {
+			awsPolicyCollector := garbage_collection.NewAWSPolicyCollector(mgr.GetClient(), awsIntentsAgent, watchedNamespaces)
+			if viper.GetBool(operatorconfig.GarbageCollectionDeleteAWSPoliciesKey) {
+				garbageCollector.AddCollector(awsPolicyCollector)
+			} else {
+				garbageCollector.AddDryRunCollector(awsPolicyCollector)
+			}
+		} else if awsIntentsAgent != nil {
+			logrus.Warningf("Garbage collection of AWS policies disabled, %s is not set", operatorconfig.ClusterNameKey)
+		}
+		if err = mgr.Add(garbageCollector); err != nil {
+			logrus.WithError(err).Fatal("unable to set up garbage collection")
+		}
+	}
+
+	// Like the garbage collector, the resync needs the intents of all namespaces to compute the expected ACLs.
+	if resyncInterval := viper.GetDuration(operatorconfig.KafkaACLResyncIntervalKey); resyncInterval > 0 && enforcementConfig.EnableKafkaACL && len(watchedNamespaces) == 0 {
+		kafkaACLResyncer := kafka_server_config_reconcilers.NewKafkaACLResyncer(
+			mgr.GetClient(),
+			kafkaServersStore,
+			kafkaIntentsAdminFactory,
+			enforcementConfig.EnableKafkaACL,
+			enforcementConfig.EnforcementDefaultState,
+			podName,
+			podNamespace,
+			serviceidresolver.NewResolver(mgr.GetClient()),
+			resyncInterval,
+			viper.GetBool(operatorconfig.KafkaACLResyncReportOnlyKey),
+		)
+		kafkaACLResyncer.InjectRecorder(mgr.GetEventRecorderFor("intents-operator"))
+		if err = mgr.Add(kafkaACLResyncer); err != nil {
+			logrus.WithError(err).Fatal("unable to set up Kafka ACL resync")
+		}
+	}
+
 	err = podWatcher.InitIntentsClientIndices(mgr)
 	if err != nil {
 		logrus.WithError(err).Panic()
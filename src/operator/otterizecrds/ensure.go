@@ -20,6 +20,9 @@ var protectedServiceCRDContents []byte
 //go:embed kafkaserverconfigs-customresourcedefinition.yaml
 var KafkaServerConfigContents []byte
 
+//go:embed databaseserverconfigs-customresourcedefinition.yaml
+var databaseServerConfigContents []byte
+
 func Ensure(ctx context.Context, k8sClient client.Client, operatorNamespace string) error {
 	err := ensureCRD(ctx, k8sClient, operatorNamespace, clientIntentsCRDContents)
 	if err != nil {
@@ -33,6 +36,10 @@ func Ensure(ctx context.Context, k8sClient client.Client, operatorNamespace stri
 	if err != nil {
 		return fmt.Errorf("failed to ensure KafkaServerConfig CRD: %w", err)
 	}
+	err = ensureCRD(ctx, k8sClient, operatorNamespace, databaseServerConfigContents)
+	if err != nil {
+		return fmt.Errorf("failed to ensure DatabaseServerConfig CRD: %w", err)
+	}
 	return nil
 }
 
@@ -42,7 +49,10 @@ func ensureCRD(ctx context.Context, k8sClient client.Client, operatorNamespace s
 	if err != nil {
 		return fmt.Errorf("failed to unmarshal ClientIntents CRD: %w", err)
 	}
-	crdToCreate.Spec.Conversion.Webhook.ClientConfig.Service.Namespace = operatorNamespace
+	// CRDs with a single version have no conversion webhook.
+	if crdToCreate.Spec.Conversion != nil && crdToCreate.Spec.Conversion.Webhook != nil {
+		crdToCreate.Spec.Conversion.Webhook.ClientConfig.Service.Namespace = operatorNamespace
+	}
 	crd := apiextensionsv1.CustomResourceDefinition{}
 	err = k8sClient.Get(ctx, types.NamespacedName{Name: crdToCreate.Name}, &crd)
 	if err != nil && !k8serrors.IsNotFound(err) {
@@ -20,6 +20,8 @@ import (
 	"context"
 	"fmt"
 	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/istiopolicy"
+	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -32,6 +34,7 @@ import (
 
 type IntentsValidatorV1alpha3 struct {
 	client.Client
+	istioTrustDomain istiopolicy.TrustDomain
 }
 
 func (v *IntentsValidatorV1alpha3) SetupWebhookWithManager(mgr ctrl.Manager) error {
@@ -41,9 +44,10 @@ func (v *IntentsValidatorV1alpha3) SetupWebhookWithManager(mgr ctrl.Manager) err
 		Complete()
 }
 
-func NewIntentsValidatorV1alpha3(c client.Client) *IntentsValidatorV1alpha3 {
+func NewIntentsValidatorV1alpha3(c client.Client, istioTrustDomain istiopolicy.TrustDomain) *IntentsValidatorV1alpha3 {
 	return &IntentsValidatorV1alpha3{
-		Client: c,
+		Client:           c,
+		istioTrustDomain: istioTrustDomain,
 	}
 }
 
@@ -51,6 +55,26 @@ func NewIntentsValidatorV1alpha3(c client.Client) *IntentsValidatorV1alpha3 {
 
 var _ webhook.CustomValidator = &IntentsValidatorV1alpha3{}
 
+var (
+	// Kafka supports write and describe on transactional IDs, see
+	// https://kafka.apache.org/documentation/#operations_resources_and_protocols
+	transactionalIDOperations = []otterizev1alpha3.KafkaOperation{
+		otterizev1alpha3.KafkaOperationAll,
+		otterizev1alpha3.KafkaOperationProduce,
+		otterizev1alpha3.KafkaOperationDescribe,
+	}
+	clusterOperations = []otterizev1alpha3.KafkaOperation{
+		otterizev1alpha3.KafkaOperationAll,
+		otterizev1alpha3.KafkaOperationCreate,
+		otterizev1alpha3.KafkaOperationAlter,
+		otterizev1alpha3.KafkaOperationDescribe,
+		otterizev1alpha3.KafkaOperationClusterAction,
+		otterizev1alpha3.KafkaOperationDescribeConfigs,
+		otterizev1alpha3.KafkaOperationAlterConfigs,
+		otterizev1alpha3.KafkaOperationIdempotentWrite,
+	}
+)
+
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
 func (v *IntentsValidatorV1alpha3) ValidateCreate(ctx context.Context, obj runtime.Object) error {
 	var allErrs field.ErrorList
@@ -67,6 +91,10 @@ func (v *IntentsValidatorV1alpha3) ValidateCreate(ctx context.Context, obj runti
 		allErrs = append(allErrs, err)
 	}
 
+	if err := validateKubernetesAccess(ctx, v.Client, intentsObj, nil); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
 	if len(allErrs) == 0 {
 		return nil
 	}
@@ -93,6 +121,10 @@ func (v *IntentsValidatorV1alpha3) ValidateUpdate(ctx context.Context, oldObj, n
 		allErrs = append(allErrs, err)
 	}
 
+	if err := validateKubernetesAccess(ctx, v.Client, intentsObj, oldObj.(*otterizev1alpha3.ClientIntents)); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
 	if len(allErrs) == 0 {
 		return nil
 	}
@@ -140,6 +172,84 @@ func (v *IntentsValidatorV1alpha3) validateSpec(intents *otterizev1alpha3.Client
 				}
 			}
 		}
+		if intent.Type != otterizev1alpha3.IntentTypeKafka && len(intent.ConsumerGroups) != 0 {
+			return &field.Error{
+				Type:   field.ErrorTypeForbidden,
+				Field:  "consumerGroups",
+				Detail: fmt.Sprintf("invalid intent format. only type %s can contain consumer groups", otterizev1alpha3.IntentTypeKafka),
+			}
+		}
+		if intent.Type != otterizev1alpha3.IntentTypeKafka && (len(intent.TransactionalIDs) != 0 || len(intent.ClusterOperations) != 0) {
+			return &field.Error{
+				Type:   field.ErrorTypeForbidden,
+				Field:  "transactionalIds",
+				Detail: fmt.Sprintf("invalid intent format. only type %s can contain transactional IDs or cluster operations", otterizev1alpha3.IntentTypeKafka),
+			}
+		}
+		if intent.Type != otterizev1alpha3.IntentTypeKafka && intent.Quotas != nil {
+			return &field.Error{
+				Type:   field.ErrorTypeForbidden,
+				Field:  "quotas",
+				Detail: fmt.Sprintf("invalid intent format. only type %s can contain quotas", otterizev1alpha3.IntentTypeKafka),
+			}
+		}
+		for _, transactionalID := range intent.TransactionalIDs {
+			for _, operation := range transactionalID.Operations {
+				if !lo.Contains(transactionalIDOperations, operation) {
+					return &field.Error{
+						Type:   field.ErrorTypeNotSupported,
+						Field:  "transactionalIds",
+						Detail: fmt.Sprintf("operation %s is not supported on transactional IDs, supported operations are %v", operation, transactionalIDOperations),
+					}
+				}
+			}
+		}
+		for _, operation := range intent.ClusterOperations {
+			if !lo.Contains(clusterOperations, operation) {
+				return &field.Error{
+					Type:   field.ErrorTypeNotSupported,
+					Field:  "clusterOperations",
+					Detail: fmt.Sprintf("operation %s is not supported on the cluster, supported operations are %v", operation, clusterOperations),
+				}
+			}
+		}
+		if intent.Type == otterizev1alpha3.IntentTypeKubernetes && len(intent.KubernetesResources) == 0 {
+			return &field.Error{
+				Type:   field.ErrorTypeRequired,
+				Field:  "kubernetesResources",
+				Detail: fmt.Sprintf("invalid intent format. type %s must contain kubernetes resources", otterizev1alpha3.IntentTypeKubernetes),
+			}
+		}
+		if intent.Type != otterizev1alpha3.IntentTypeKubernetes && len(intent.KubernetesResources) != 0 {
+			return &field.Error{
+				Type:   field.ErrorTypeForbidden,
+				Field:  "kubernetesResources",
+				Detail: fmt.Sprintf("invalid intent format. only type %s can contain kubernetes resources", otterizev1alpha3.IntentTypeKubernetes),
+			}
+		}
+		for _, resource := range intent.KubernetesResources {
+			if len(resource.Resources) == 0 || len(resource.Verbs) == 0 {
+				return &field.Error{
+					Type:   field.ErrorTypeRequired,
+					Field:  "kubernetesResources",
+					Detail: "kubernetes resources must list at least one resource and one verb",
+				}
+			}
+		}
+		if intent.Type == otterizev1alpha3.IntentTypeInternet && (intent.Internet == nil || len(intent.Internet.Domains) == 0) {
+			return &field.Error{
+				Type:   field.ErrorTypeRequired,
+				Field:  "internet",
+				Detail: fmt.Sprintf("invalid intent format. type %s must contain internet domains", otterizev1alpha3.IntentTypeInternet),
+			}
+		}
+		if intent.Type != otterizev1alpha3.IntentTypeInternet && intent.Internet != nil {
+			return &field.Error{
+				Type:   field.ErrorTypeForbidden,
+				Field:  "internet",
+				Detail: fmt.Sprintf("invalid intent format. only type %s can contain internet domains", otterizev1alpha3.IntentTypeInternet),
+			}
+		}
 		if strings.Count(intent.Name, ".") > 1 {
 			return &field.Error{
 				Type:   field.ErrorTypeForbidden,
@@ -148,5 +258,22 @@ func (v *IntentsValidatorV1alpha3) validateSpec(intents *otterizev1alpha3.Client
 			}
 		}
 	}
+	for _, remoteClient := range intents.Spec.RemoteClients {
+		// Principals are formatted as <trust domain>/ns/<namespace>/sa/<service account>.
+		if remoteClient.TrustDomain == "" || strings.Contains(remoteClient.TrustDomain, "/") {
+			return &field.Error{
+				Type:   field.ErrorTypeInvalid,
+				Field:  "remoteClients",
+				Detail: fmt.Sprintf("invalid trust domain '%s'", remoteClient.TrustDomain),
+			}
+		}
+		if err := v.istioTrustDomain.ValidateRemoteClient(remoteClient, intents.Namespace); err != nil {
+			return &field.Error{
+				Type:   field.ErrorTypeForbidden,
+				Field:  "remoteClients",
+				Detail: err.Error(),
+			}
+		}
+	}
 	return nil
 }
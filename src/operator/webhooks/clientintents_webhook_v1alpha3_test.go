@@ -0,0 +1,68 @@
+package webhooks
+
+import (
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/istiopolicy"
+	"github.com/stretchr/testify/suite"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"testing"
+)
+
+type RemoteClientsValidationTestSuite struct {
+	suite.Suite
+	validator *IntentsValidatorV1alpha3
+}
+
+func (s *RemoteClientsValidationTestSuite) SetupTest() {
+	s.validator = NewIntentsValidatorV1alpha3(nil, istiopolicy.TrustDomain{Name: "prod.example.com", Aliases: []string{"cluster-b.example.com"}})
+}
+
+func remoteClientIntents(remoteClients ...otterizev1alpha3.RemoteClient) *otterizev1alpha3.ClientIntents {
+	return &otterizev1alpha3.ClientIntents{
+		ObjectMeta: metav1.ObjectMeta{Name: "client-intents", Namespace: "test-namespace"},
+		Spec: &otterizev1alpha3.IntentsSpec{
+			Service:       otterizev1alpha3.Service{Name: "client"},
+			Calls:         []otterizev1alpha3.Intent{{Name: "server"}},
+			RemoteClients: remoteClients,
+		},
+	}
+}
+
+func (s *RemoteClientsValidationTestSuite) TestTrustDomainAliasAllowed() {
+	err := s.validator.validateSpec(remoteClientIntents(
+		otterizev1alpha3.RemoteClient{TrustDomain: "cluster-b.example.com"},
+		otterizev1alpha3.RemoteClient{TrustDomain: "cluster-b.example.com", Namespace: "test-namespace", ServiceAccountName: "remote-sa"},
+	))
+	s.Require().Nil(err)
+}
+
+func (s *RemoteClientsValidationTestSuite) TestTrustDomainNotAnAliasRejected() {
+	err := s.validator.validateSpec(remoteClientIntents(otterizev1alpha3.RemoteClient{TrustDomain: "partner.example.com"}))
+	s.Require().NotNil(err)
+	s.Require().Equal(field.ErrorTypeForbidden, err.Type)
+}
+
+func (s *RemoteClientsValidationTestSuite) TestLocalTrustDomainRejected() {
+	// Clients in this cluster are identified by their pods, so their identity may not be declared
+	err := s.validator.validateSpec(remoteClientIntents(otterizev1alpha3.RemoteClient{TrustDomain: "prod.example.com", ServiceAccountName: "other-sa"}))
+	s.Require().NotNil(err)
+	s.Require().Equal(field.ErrorTypeForbidden, err.Type)
+}
+
+func (s *RemoteClientsValidationTestSuite) TestOtherNamespaceRejected() {
+	err := s.validator.validateSpec(remoteClientIntents(otterizev1alpha3.RemoteClient{TrustDomain: "cluster-b.example.com", Namespace: "other-namespace"}))
+	s.Require().NotNil(err)
+	s.Require().Equal(field.ErrorTypeForbidden, err.Type)
+}
+
+func (s *RemoteClientsValidationTestSuite) TestOtherNamespaceAllowedWhenConfigured() {
+	s.validator.istioTrustDomain.RemoteClientsInOtherNamespaces = true
+
+	err := s.validator.validateSpec(remoteClientIntents(otterizev1alpha3.RemoteClient{TrustDomain: "cluster-b.example.com", Namespace: "other-namespace"}))
+	s.Require().Nil(err)
+}
+
+func TestRemoteClientsValidationTestSuite(t *testing.T) {
+	suite.Run(t, new(RemoteClientsValidationTestSuite))
+}
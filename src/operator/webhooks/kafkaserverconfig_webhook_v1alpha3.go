@@ -0,0 +1,103 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooks
+
+import (
+	"context"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/kafkaacls"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+type KafkaServerConfigValidatorV1alpha3 struct {
+	client.Client
+}
+
+func NewKafkaServerConfigValidatorV1alpha3(c client.Client) *KafkaServerConfigValidatorV1alpha3 {
+	return &KafkaServerConfigValidatorV1alpha3{
+		Client: c,
+	}
+}
+
+//+kubebuilder:webhook:path=/validate-k8s-otterize-com-v1alpha3-kafkaserverconfig,mutating=false,failurePolicy=fail,sideEffects=None,groups=k8s.otterize.com,resources=kafkaserverconfigs,verbs=create;update,versions=v1alpha3,name=kafkaserverconfigv1alpha3.kb.io,admissionReviewVersions=v1
+
+var _ webhook.CustomValidator = &KafkaServerConfigValidatorV1alpha3{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (v *KafkaServerConfigValidatorV1alpha3) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	return v.validate(obj.(*otterizev1alpha3.KafkaServerConfig))
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (v *KafkaServerConfigValidatorV1alpha3) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	return v.validate(newObj.(*otterizev1alpha3.KafkaServerConfig))
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (v *KafkaServerConfigValidatorV1alpha3) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+func (v *KafkaServerConfigValidatorV1alpha3) validate(kafkaServerConfig *otterizev1alpha3.KafkaServerConfig) error {
+	var allErrs field.ErrorList
+	if err := v.validatePrincipalTemplate(kafkaServerConfig); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	if err := v.validateAuth(kafkaServerConfig); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	gvk := kafkaServerConfig.GroupVersionKind()
+	return errors.NewInvalid(
+		schema.GroupKind{Group: gvk.Group, Kind: gvk.Kind},
+		kafkaServerConfig.Name, allErrs)
+}
+
+func (v *KafkaServerConfigValidatorV1alpha3) validatePrincipalTemplate(kafkaServerConfig *otterizev1alpha3.KafkaServerConfig) *field.Error {
+	principalTemplate := kafkaServerConfig.Spec.PrincipalTemplate
+	if principalTemplate == "" {
+		return nil
+	}
+
+	if err := kafkaacls.ValidatePrincipalTemplate(principalTemplate); err != nil {
+		return field.Invalid(field.NewPath("spec", "principalTemplate"), principalTemplate, err.Error())
+	}
+
+	return nil
+}
+
+func (v *KafkaServerConfigValidatorV1alpha3) validateAuth(kafkaServerConfig *otterizev1alpha3.KafkaServerConfig) *field.Error {
+	auth := kafkaServerConfig.Spec.Auth
+	if auth == nil {
+		return nil
+	}
+
+	if auth.SASLMechanism == otterizev1alpha3.SASLMechanismPlain && auth.Plaintext {
+		return field.Forbidden(field.NewPath("spec", "auth", "plaintext"), kafkaacls.ErrSASLPlainWithoutTLS.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/samber/lo"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+//+kubebuilder:rbac:groups="authorization.k8s.io",resources=subjectaccessreviews,verbs=create
+
+// kubernetesAccessRequest is the access a kubernetes intent grants on a resource in a namespace, which is empty for
+// cluster-wide access.
+type kubernetesAccessRequest struct {
+	namespace string
+	resource  otterizev1alpha3.KubernetesResource
+}
+
+func getKubernetesAccessRequests(intentsObj *otterizev1alpha3.ClientIntents) []kubernetesAccessRequest {
+	if intentsObj == nil || intentsObj.Spec == nil {
+		return nil
+	}
+
+	requests := make([]kubernetesAccessRequest, 0)
+	for _, intent := range intentsObj.GetFilteredCallsList(otterizev1alpha3.IntentTypeKubernetes) {
+		for _, resource := range intent.KubernetesResources {
+			namespace := lo.Ternary(resource.ClusterWide, "", intent.GetTargetServerNamespace(intentsObj.Namespace))
+			requests = append(requests, kubernetesAccessRequest{namespace: namespace, resource: resource})
+		}
+	}
+	return requests
+}
+
+// validateKubernetesAccess checks that the user creating or updating the intents holds the Kubernetes API access their
+// kubernetes intents grant the client, so that intents cannot be used to escalate privileges. Access that oldIntents
+// already granted is not checked again, so that other users can still update the intents.
+func validateKubernetesAccess(ctx context.Context, kube client.Client, intentsObj *otterizev1alpha3.ClientIntents, oldIntents *otterizev1alpha3.ClientIntents) *field.Error {
+	existingRequests := getKubernetesAccessRequests(oldIntents)
+	newRequests := lo.Filter(getKubernetesAccessRequests(intentsObj), func(request kubernetesAccessRequest, _ int) bool {
+		return !lo.ContainsBy(existingRequests, func(existing kubernetesAccessRequest) bool {
+			return reflect.DeepEqual(existing, request)
+		})
+	})
+	if len(newRequests) == 0 {
+		return nil
+	}
+
+	admissionRequest, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return field.InternalError(field.NewPath("kubernetesResources"), fmt.Errorf("could not determine the user granting Kubernetes access: %w", err))
+	}
+	userInfo := admissionRequest.UserInfo
+	extra := make(map[string]authorizationv1.ExtraValue, len(userInfo.Extra))
+	for key, value := range userInfo.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+
+	for _, request := range newRequests {
+		for _, attributes := range toResourceAttributes(request) {
+			review := &authorizationv1.SubjectAccessReview{
+				Spec: authorizationv1.SubjectAccessReviewSpec{
+					ResourceAttributes: &attributes,
+					User:               userInfo.Username,
+					Groups:             userInfo.Groups,
+					UID:                userInfo.UID,
+					Extra:              extra,
+				},
+			}
+			if err := kube.Create(ctx, review); err != nil {
+				return field.InternalError(field.NewPath("kubernetesResources"), fmt.Errorf("could not check Kubernetes access: %w", err))
+			}
+			if !review.Status.Allowed {
+				return &field.Error{
+					Type:   field.ErrorTypeForbidden,
+					Field:  "kubernetesResources",
+					Detail: fmt.Sprintf("user %s may not grant access it does not hold itself: %s", userInfo.Username, formatResourceAttributes(attributes)),
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// toResourceAttributes expands the resource into the attributes of every access it grants.
+func toResourceAttributes(request kubernetesAccessRequest) []authorizationv1.ResourceAttributes {
+	resourceNames := lo.Ternary(len(request.resource.ResourceNames) == 0, []string{""}, request.resource.ResourceNames)
+	attributes := make([]authorizationv1.ResourceAttributes, 0)
+	for _, group := range request.resource.APIGroups {
+		for _, resource := range request.resource.Resources {
+			for _, verb := range request.resource.Verbs {
+				for _, name := range resourceNames {
+					attributes = append(attributes, authorizationv1.ResourceAttributes{
+						Namespace: request.namespace,
+						Group:     group,
+						Resource:  resource,
+						Verb:      verb,
+						Name:      name,
+					})
+				}
+			}
+		}
+	}
+	return attributes
+}
+
+func formatResourceAttributes(attributes authorizationv1.ResourceAttributes) string {
+	formatted := fmt.Sprintf("%s %s", attributes.Verb, attributes.Resource)
+	if attributes.Group != "" {
+		formatted = fmt.Sprintf("%s.%s", formatted, attributes.Group)
+	}
+	if attributes.Name != "" {
+		formatted = fmt.Sprintf("%s/%s", formatted, attributes.Name)
+	}
+	if attributes.Namespace != "" {
+		return fmt.Sprintf("%s in namespace %s", formatted, attributes.Namespace)
+	}
+	return fmt.Sprintf("%s cluster-wide", formatted)
+}
@@ -0,0 +1,96 @@
+package webhooks
+
+import (
+	"context"
+	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/shared/testbase"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"testing"
+)
+
+type KubernetesAccessTestSuite struct {
+	testbase.MocksSuiteBase
+	ctx context.Context
+}
+
+func (s *KubernetesAccessTestSuite) SetupTest() {
+	s.MocksSuiteBase.SetupTest()
+	s.ctx = admission.NewContextWithRequest(context.Background(), admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{UserInfo: authenticationv1.UserInfo{Username: "author", Groups: []string{"developers"}}},
+	})
+}
+
+func kubernetesClientIntents(resources ...otterizev1alpha3.KubernetesResource) *otterizev1alpha3.ClientIntents {
+	return &otterizev1alpha3.ClientIntents{
+		ObjectMeta: metav1.ObjectMeta{Name: "client-intents", Namespace: "test-namespace"},
+		Spec: &otterizev1alpha3.IntentsSpec{
+			Service: otterizev1alpha3.Service{Name: "client"},
+			Calls: []otterizev1alpha3.Intent{{
+				Name:                "kubernetes.other-namespace",
+				Type:                otterizev1alpha3.IntentTypeKubernetes,
+				KubernetesResources: resources,
+			}},
+		},
+	}
+}
+
+func (s *KubernetesAccessTestSuite) expectSubjectAccessReview(expected authorizationv1.ResourceAttributes, allowed bool) {
+	s.Client.EXPECT().Create(gomock.Any(), gomock.AssignableToTypeOf(&authorizationv1.SubjectAccessReview{})).DoAndReturn(
+		func(_ context.Context, review *authorizationv1.SubjectAccessReview, _ ...client.CreateOption) error {
+			s.Require().Equal("author", review.Spec.User)
+			s.Require().Equal([]string{"developers"}, review.Spec.Groups)
+			s.Require().Equal(expected, *review.Spec.ResourceAttributes)
+			review.Status.Allowed = allowed
+			return nil
+		})
+}
+
+func (s *KubernetesAccessTestSuite) TestAccessHeldByAuthorAllowed() {
+	intents := kubernetesClientIntents(otterizev1alpha3.KubernetesResource{
+		APIGroups: []string{""},
+		Resources: []string{"configmaps"},
+		Verbs:     []string{"get", "list"},
+	})
+	s.expectSubjectAccessReview(authorizationv1.ResourceAttributes{Namespace: "other-namespace", Resource: "configmaps", Verb: "get"}, true)
+	s.expectSubjectAccessReview(authorizationv1.ResourceAttributes{Namespace: "other-namespace", Resource: "configmaps", Verb: "list"}, true)
+
+	s.Require().Nil(validateKubernetesAccess(s.ctx, s.Client, intents, nil))
+}
+
+func (s *KubernetesAccessTestSuite) TestAccessNotHeldByAuthorForbidden() {
+	intents := kubernetesClientIntents(otterizev1alpha3.KubernetesResource{
+		APIGroups:   []string{"apps"},
+		Resources:   []string{"deployments"},
+		Verbs:       []string{"delete"},
+		ClusterWide: true,
+	})
+	s.expectSubjectAccessReview(authorizationv1.ResourceAttributes{Group: "apps", Resource: "deployments", Verb: "delete"}, false)
+
+	err := validateKubernetesAccess(s.ctx, s.Client, intents, nil)
+	s.Require().NotNil(err)
+	s.Require().Equal(field.ErrorTypeForbidden, err.Type)
+}
+
+func (s *KubernetesAccessTestSuite) TestAccessAlreadyGrantedNotCheckedOnUpdate() {
+	resource := otterizev1alpha3.KubernetesResource{
+		APIGroups: []string{""},
+		Resources: []string{"secrets"},
+		Verbs:     []string{"get"},
+	}
+	intents := kubernetesClientIntents(resource)
+
+	// Another user may update intents without holding access they already granted.
+	s.Require().Nil(validateKubernetesAccess(context.Background(), s.Client, intents, intents.DeepCopy()))
+}
+
+func TestKubernetesAccessTestSuite(t *testing.T) {
+	suite.Run(t, new(KubernetesAccessTestSuite))
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	otterizev1alpha2 "github.com/otterize/intents-operator/src/operator/api/v1alpha2"
 	otterizev1alpha3 "github.com/otterize/intents-operator/src/operator/api/v1alpha3"
+	"github.com/otterize/intents-operator/src/operator/controllers/istiopolicy"
 	"github.com/otterize/intents-operator/src/shared/testbase"
 	"github.com/stretchr/testify/suite"
 	istiosecurityscheme "istio.io/client-go/pkg/apis/security/v1beta1"
@@ -73,7 +74,7 @@ func (s *ValidationWebhookTestSuite) SetupTest() {
 	s.ControllerManagerTestSuiteBase.SetupTest()
 	intentsValidator := NewIntentsValidatorV1alpha2(s.Mgr.GetClient())
 	s.Require().NoError(intentsValidator.SetupWebhookWithManager(s.Mgr))
-	intentsValidator3 := NewIntentsValidatorV1alpha3(s.Mgr.GetClient())
+	intentsValidator3 := NewIntentsValidatorV1alpha3(s.Mgr.GetClient(), istiopolicy.TrustDomain{})
 	s.Require().NoError(intentsValidator3.SetupWebhookWithManager(s.Mgr))
 	s.Mgr.GetWebhookServer().CertDir = s.TestEnv.WebhookInstallOptions.LocalServingCertDir
 	s.Mgr.GetWebhookServer().Host = s.TestEnv.WebhookInstallOptions.LocalServingHost
@@ -14,11 +14,15 @@ type Agent struct {
 	iamClient *iam.Client
 	accountId string
 	oidcUrl   string
+	// clusterName tags the policies the agent creates, so that policies of other clusters sharing the AWS account are
+	// told apart from those of this cluster.
+	clusterName string
 }
 
 func NewAWSAgent(
 	ctx context.Context,
 	oidcUrl string, // TODO: use eks.DescribeCluster to get the OIDC URL.
+	clusterName string,
 ) *Agent {
 	logrus.Info("AWS Intents agent - enabled")
 
@@ -42,9 +46,10 @@ func NewAWSAgent(
 	}
 
 	return &Agent{
-		stsClient: stsClient,
-		iamClient: iamClient,
-		accountId: *callerIdent.Account,
-		oidcUrl:   strings.Split(oidcUrl, "://")[1],
+		stsClient:   stsClient,
+		iamClient:   iamClient,
+		accountId:   *callerIdent.Account,
+		oidcUrl:     strings.Split(oidcUrl, "://")[1],
+		clusterName: clusterName,
 	}
 }
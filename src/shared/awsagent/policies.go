@@ -11,6 +11,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/iam/types"
 	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
+	"strings"
 )
 
 func (a *Agent) AddRolePolicy(ctx context.Context, namespace, accountName, policyName string, statements []StatementEntry) error {
@@ -121,6 +122,64 @@ func (a *Agent) DeleteRolePolicy(ctx context.Context, namespace, policyName stri
 	return nil
 }
 
+// ListOtterizePolicies lists the IAM policies created by the operator of this cluster, identified by their
+// otterize/policyName and otterize/policyNamespace tags, and by their otterize/clusterName tag matching the agent's
+// cluster name. Policies of other clusters in the account, and those created before they were tagged with a cluster
+// name, are not listed.
+func (a *Agent) ListOtterizePolicies(ctx context.Context) ([]PolicyReference, error) {
+	if a.clusterName == "" {
+		return nil, errors.New("cluster name not set, cannot tell the policies of this cluster apart from those of others")
+	}
+
+	policies := make([]PolicyReference, 0)
+	paginator := iam.NewListPoliciesPaginator(a.iamClient, &iam.ListPoliciesInput{
+		Scope: types.PolicyScopeTypeLocal,
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, policy := range page.Policies {
+			if !strings.HasPrefix(aws.ToString(policy.PolicyName), policyNamePrefix) {
+				continue
+			}
+
+			tagsOutput, err := a.iamClient.ListPolicyTags(ctx, &iam.ListPolicyTagsInput{
+				PolicyArn: policy.Arn,
+			})
+
+			if err != nil {
+				return nil, err
+			}
+
+			nameTag, hasName := lo.Find(tagsOutput.Tags, func(tag types.Tag) bool {
+				return aws.ToString(tag.Key) == policyNameTagKey
+			})
+			namespaceTag, hasNamespace := lo.Find(tagsOutput.Tags, func(tag types.Tag) bool {
+				return aws.ToString(tag.Key) == policyNamespaceTagKey
+			})
+			isOfCluster := lo.ContainsBy(tagsOutput.Tags, func(tag types.Tag) bool {
+				return aws.ToString(tag.Key) == policyClusterNameTagKey && aws.ToString(tag.Value) == a.clusterName
+			})
+
+			if !hasName || !hasNamespace || !isOfCluster {
+				continue
+			}
+
+			policies = append(policies, PolicyReference{
+				Namespace: aws.ToString(namespaceTag.Value),
+				Name:      aws.ToString(nameTag.Value),
+			})
+		}
+	}
+
+	return policies, nil
+}
+
 func (a *Agent) SetRolePolicy(ctx context.Context, namespace, accountName string, statements []StatementEntry) error {
 	logger := logrus.WithField("account", accountName).WithField("namespace", namespace)
 	roleName := generateRoleName(namespace, accountName)
@@ -181,6 +240,10 @@ func (a *Agent) createPolicy(ctx context.Context, role *types.Role, namespace, p
 				Key:   aws.String(policyHashTagKey),
 				Value: aws.String(policyHash),
 			},
+			{
+				Key:   aws.String(policyClusterNameTagKey),
+				Value: aws.String(a.clusterName),
+			},
 		},
 	})
 
@@ -235,6 +298,10 @@ func (a *Agent) updatePolicy(ctx context.Context, policy *types.Policy, statemen
 				Key:   aws.String(policyHashTagKey),
 				Value: aws.String(policyHash),
 			},
+			{
+				Key:   aws.String(policyClusterNameTagKey),
+				Value: aws.String(a.clusterName),
+			},
 		},
 	})
 
@@ -301,7 +368,7 @@ func generatePolicyDocument(statements []StatementEntry) (string, string, error)
 }
 
 func generatePolicyName(ns, policyName string) string {
-	return fmt.Sprintf("otterize-policy-%s-%s", ns, policyName)
+	return fmt.Sprintf("%s%s-%s", policyNamePrefix, ns, policyName)
 
 }
 
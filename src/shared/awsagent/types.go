@@ -6,9 +6,18 @@ const iamEffectAllow = "Allow"
 const serviceAccountNameTagKey = "otterize/serviceAccountName"
 const serviceAccountNamespaceTagKey = "otterize/serviceAccountNamespace"
 
+const policyNamePrefix = "otterize-policy-"
+
 const policyNameTagKey = "otterize/policyName"
 const policyNamespaceTagKey = "otterize/policyNamespace"
 const policyHashTagKey = "otterize/policyHash"
+const policyClusterNameTagKey = "otterize/clusterName"
+
+// PolicyReference identifies the ClientIntents an IAM policy was created for.
+type PolicyReference struct {
+	Namespace string
+	Name      string
+}
 
 // PolicyDocument is our definition of our policies to be uploaded to IAM.
 type PolicyDocument struct {
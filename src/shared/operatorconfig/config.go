@@ -35,6 +35,10 @@ const (
 	EnableNetworkPolicyDefault                                          = true
 	EnableIstioPolicyKey                                                = "enable-istio-policy-creation" // Whether to enable Istio authorization policy creation
 	EnableIstioPolicyDefault                                            = true
+	EnableIstioEgressPolicyKey                                          = "enable-istio-egress-policy" // Whether to restrict the egress of Istio clients to the targets of their intents, using Sidecar and ServiceEntry resources
+	EnableIstioEgressPolicyDefault                                      = false
+	EnableLinkerdPolicyKey                                              = "enable-linkerd-policy-creation" // Whether to enable Linkerd authorization policy creation, when the Linkerd policy CRDs are installed
+	EnableLinkerdPolicyDefault                                          = true
 	EnableKafkaACLKey                                                   = "enable-kafka-acl-creation" // Whether to disable Intents Kafka ACL creation
 	EnableKafkaACLDefault                                               = true
 	IntentsOperatorPodNameKey                                           = "pod-name"
@@ -51,6 +55,38 @@ const (
 	EnableAWSPolicyKey                                                  = "enable-aws-iam-policy"
 	EnableAWSPolicyDefault                                              = false
 	ClusterOIDCProviderUrlKey                                           = "eks-oidc-url"
+	IngressControllerPodSelectorKey                                     = "ingress-controller-pod-selector"       // Label selector for the ingress controller pods. If set, services exposed only through an Ingress or Gateway API route accept external traffic only from these pods
+	IngressControllerNamespaceSelectorKey                               = "ingress-controller-namespace-selector" // Label selector for the namespaces the ingress controller pods run in
+	GarbageCollectionIntervalKey                                        = "garbage-collection-interval"           // Interval between sweeps for artifacts left behind by deleted intents. Zero disables garbage collection
+	GarbageCollectionIntervalDefault                                    = 30 * time.Minute
+	GarbageCollectionDryRunKey                                          = "garbage-collection-dry-run" // Only log and count orphaned artifacts, without deleting them. Enabled by default, so that nothing is deleted until the counts have been checked
+	GarbageCollectionDryRunDefault                                      = true
+	GarbageCollectionDeleteAWSPoliciesKey                               = "garbage-collection-delete-aws-policies" // Delete orphaned AWS IAM policies of this cluster, which are otherwise only logged and counted. Requires cluster-name to be set
+	GarbageCollectionDeleteAWSPoliciesDefault                           = false
+	KafkaACLResyncIntervalKey                                           = "kafka-acl-resync-interval" // Interval between comparisons of the ACLs on Kafka servers with those expected from intents. Zero disables the resync
+	KafkaACLResyncIntervalDefault                                       = 10 * time.Minute
+	KafkaACLResyncReportOnlyKey                                         = "kafka-acl-resync-report-only" // Report Kafka ACL drift as events and in KafkaServerConfig status, without repairing it
+	KafkaACLResyncReportOnlyDefault                                     = false
+	KafkaMaxConcurrentOperationsKey                                     = "kafka-max-concurrent-operations" // Number of reconciles that may use the connection to each Kafka server at once
+	KafkaMaxConcurrentOperationsDefault                                 = 4
+	KafkaConnectionHealthCheckIntervalKey                               = "kafka-connection-health-check-interval" // Interval between health checks of idle Kafka server connections. Zero disables health checks
+	KafkaConnectionHealthCheckIntervalDefault                           = time.Minute
+	EnableKubernetesRBACKey                                             = "enable-kubernetes-rbac" // Manage Roles and bindings granting the Kubernetes API access declared in kubernetes intents
+	EnableKubernetesRBACDefault                                         = false
+	AlwaysAllowedPodSelectorKey                                         = "always-allowed-pod-selector"       // Label selector for pods, such as monitoring agents, that may always access protected servers
+	AlwaysAllowedNamespaceSelectorKey                                   = "always-allowed-namespace-selector" // Label selector for namespaces whose pods may always access protected servers
+	AlwaysAllowedCIDRsKey                                               = "always-allowed-cidrs"              // Comma separated CIDRs, such as node CIDRs, that may always access protected servers
+	AlwaysAllowedPortsKey                                               = "always-allowed-ports"              // Comma separated ports the always allowed traffic is restricted to
+	AlwaysAllowedPortAnnotationsKey                                     = "always-allowed-port-annotations"   // Comma separated pod annotations, such as prometheus.io/port, holding additional always allowed ports
+	IstioNamespaceKey                                                   = "istio-namespace"                   // Namespace of the Istio control plane, where the mesh config is read from
+	IstioNamespaceDefault                                               = "istio-system"
+	IstioTrustDomainKey                                                 = "istio-trust-domain"          // Trust domain of Istio identities in this cluster. Detected from the mesh config when not set
+	IstioTrustDomainAliasesKey                                          = "istio-trust-domain-aliases"  // Comma separated trust domains, such as those of federated clusters, whose identities are also accepted for clients
+	IstioConsolidatedPoliciesKey                                        = "istio-consolidated-policies" // Create a single Istio authorization policy per server, with a rule for each client
+	IstioConsolidatedPoliciesDefault                                    = false
+	IstioRemoteClientsInOtherNamespacesKey                              = "istio-remote-clients-in-other-namespaces" // Allow the remote clients of ClientIntents to be in namespaces other than that of the ClientIntents
+	IstioRemoteClientsInOtherNamespacesDefault                          = false
+	ClusterNameKey                                                      = "cluster-name" // Name of this cluster, substituted for $Cluster in the principal templates of Kafka servers
 )
 
 func init() {
@@ -63,9 +99,22 @@ func init() {
 	viper.SetDefault(EnableNetworkPolicyKey, EnableNetworkPolicyDefault)
 	viper.SetDefault(EnableKafkaACLKey, EnableKafkaACLDefault)
 	viper.SetDefault(EnableIstioPolicyKey, EnableIstioPolicyDefault)
+	viper.SetDefault(EnableIstioEgressPolicyKey, EnableIstioEgressPolicyDefault)
+	viper.SetDefault(EnableLinkerdPolicyKey, EnableLinkerdPolicyDefault)
 	viper.SetDefault(DisableWebhookServerKey, DisableWebhookServerDefault)
 	viper.SetDefault(EnableEgressNetworkPolicyReconcilersKey, EnableEgressNetworkPolicyReconcilersDefault)
 	viper.SetDefault(EnableAWSPolicyKey, EnableAWSPolicyDefault)
+	viper.SetDefault(GarbageCollectionIntervalKey, GarbageCollectionIntervalDefault)
+	viper.SetDefault(GarbageCollectionDryRunKey, GarbageCollectionDryRunDefault)
+	viper.SetDefault(GarbageCollectionDeleteAWSPoliciesKey, GarbageCollectionDeleteAWSPoliciesDefault)
+	viper.SetDefault(KafkaACLResyncIntervalKey, KafkaACLResyncIntervalDefault)
+	viper.SetDefault(KafkaACLResyncReportOnlyKey, KafkaACLResyncReportOnlyDefault)
+	viper.SetDefault(KafkaMaxConcurrentOperationsKey, KafkaMaxConcurrentOperationsDefault)
+	viper.SetDefault(KafkaConnectionHealthCheckIntervalKey, KafkaConnectionHealthCheckIntervalDefault)
+	viper.SetDefault(EnableKubernetesRBACKey, EnableKubernetesRBACDefault)
+	viper.SetDefault(IstioNamespaceKey, IstioNamespaceDefault)
+	viper.SetDefault(IstioConsolidatedPoliciesKey, IstioConsolidatedPoliciesDefault)
+	viper.SetDefault(IstioRemoteClientsInOtherNamespacesKey, IstioRemoteClientsInOtherNamespacesDefault)
 	viper.SetEnvPrefix(EnvPrefix)
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
 	viper.AutomaticEnv()